@@ -0,0 +1,145 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AgentTokenDTO represents the data transfer object for agent tokens
+type AgentTokenDTO struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	CreatedBy  string     `json:"createdBy"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+	Revoked    bool       `json:"revoked"`
+}
+
+// AgentTokenCreateDTO represents the data for creating an agent token
+type AgentTokenCreateDTO struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// AgentTokenSecretDTO is a newly created agent token together with its secret
+type AgentTokenSecretDTO struct {
+	AgentTokenDTO
+	Token string `json:"token"`
+}
+
+// AgentReportDTO represents a host facts report pushed by an agent
+type AgentReportDTO struct {
+	Facts model.HostFacts `json:"facts"`
+	// AssetType and Location are used when the report creates a new asset
+	AssetType string `json:"assetType"`
+	Location  string `json:"location"`
+}
+
+// AgentReportResultDTO tells an agent which asset its report was merged into
+type AgentReportResultDTO struct {
+	ID      string `json:"id"`
+	AssetID string `json:"assetId"`
+	Name    string `json:"name"`
+}
+
+// AgentApplication provides application services for inventory agents
+type AgentApplication struct {
+	agentService *service.AgentService
+}
+
+// NewAgentApplication creates a new agent application service
+func NewAgentApplication(agentService *service.AgentService) *AgentApplication {
+	return &AgentApplication{
+		agentService: agentService,
+	}
+}
+
+// GetTokens gets all agent tokens
+func (a *AgentApplication) GetTokens(ctx context.Context) ([]*AgentTokenDTO, error) {
+	tokens, err := a.agentService.GetTokens(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenDTOs := make([]*AgentTokenDTO, len(tokens))
+	for i, token := range tokens {
+		tokenDTOs[i] = mapAgentTokenToDTO(token)
+	}
+
+	return tokenDTOs, nil
+}
+
+// CreateToken creates an agent token and returns its secret
+func (a *AgentApplication) CreateToken(ctx context.Context, dto AgentTokenCreateDTO, createdBy string) (*AgentTokenSecretDTO, error) {
+	token, secret, err := a.agentService.CreateToken(ctx, dto.Name, createdBy)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AgentTokenSecretDTO{
+		AgentTokenDTO: *mapAgentTokenToDTO(token),
+		Token:         secret,
+	}, nil
+}
+
+// RevokeToken revokes an agent token
+func (a *AgentApplication) RevokeToken(ctx context.Context, id string) (*AgentTokenDTO, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := a.agentService.RevokeToken(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapAgentTokenToDTO(token), nil
+}
+
+// Authenticate validates an agent token secret
+func (a *AgentApplication) Authenticate(ctx context.Context, secret string) (*AgentTokenDTO, error) {
+	token, err := a.agentService.Authenticate(ctx, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapAgentTokenToDTO(token), nil
+}
+
+// ReportFacts merges a host facts report into the matching asset
+func (a *AgentApplication) ReportFacts(ctx context.Context, agent *AgentTokenDTO, dto AgentReportDTO) (*AgentReportResultDTO, error) {
+	asset, err := a.agentService.ReportFacts(ctx, agent.Name, agent.ID, service.AgentReport{
+		Facts:     dto.Facts,
+		AssetType: dto.AssetType,
+		Location:  dto.Location,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &AgentReportResultDTO{
+		ID:      asset.ID.Hex(),
+		AssetID: asset.AssetID,
+		Name:    asset.Name,
+	}, nil
+}
+
+// mapAgentTokenToDTO maps an agent token to its DTO
+func mapAgentTokenToDTO(token *model.AgentToken) *AgentTokenDTO {
+	return &AgentTokenDTO{
+		ID:         token.ID.Hex(),
+		Name:       token.Name,
+		Prefix:     token.Prefix,
+		CreatedBy:  token.CreatedBy,
+		CreatedAt:  token.CreatedAt,
+		LastUsedAt: token.LastUsedAt,
+		RevokedAt:  token.RevokedAt,
+		Revoked:    token.IsRevoked(),
+	}
+}
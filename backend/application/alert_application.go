@@ -0,0 +1,344 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AlertDTO represents the data transfer object for alerts
+type AlertDTO struct {
+	ID             string      `json:"id"`
+	RuleID         string      `json:"ruleId,omitempty"`
+	AssetID        string      `json:"assetId"`
+	AssetName      string      `json:"assetName"`
+	Type           string      `json:"type"`
+	Severity       string      `json:"severity"`
+	Status         string      `json:"status"`
+	Title          string      `json:"title"`
+	Description    string      `json:"description"`
+	Condition      string      `json:"condition"`
+	CurrentValue   interface{} `json:"currentValue"`
+	ThresholdValue interface{} `json:"thresholdValue"`
+	AcknowledgedBy string      `json:"acknowledgedBy,omitempty"`
+	AcknowledgedAt *time.Time  `json:"acknowledgedAt,omitempty"`
+	ResolvedBy     string      `json:"resolvedBy,omitempty"`
+	ResolvedAt     *time.Time  `json:"resolvedAt,omitempty"`
+	Resolution     string      `json:"resolution,omitempty"`
+	CreatedAt      time.Time   `json:"createdAt"`
+	UpdatedAt      time.Time   `json:"updatedAt"`
+}
+
+// AlertRuleDTO represents the data transfer object for alert rules
+type AlertRuleDTO struct {
+	ID          string                 `json:"id"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Type        string                 `json:"type"`
+	Severity    string                 `json:"severity"`
+	Condition   string                 `json:"condition"`
+	Field       string                 `json:"field"`
+	Operator    string                 `json:"operator"`
+	Threshold   interface{}            `json:"threshold"`
+	Enabled     bool                   `json:"enabled"`
+	AssetFilter map[string]interface{} `json:"assetFilter"`
+	CreatedAt   time.Time              `json:"createdAt"`
+	UpdatedAt   time.Time              `json:"updatedAt"`
+}
+
+// AlertRuleSaveDTO represents the data for creating or updating an alert rule
+type AlertRuleSaveDTO struct {
+	Name        string                 `json:"name" binding:"required"`
+	Description string                 `json:"description"`
+	Type        string                 `json:"type"`
+	Severity    string                 `json:"severity" binding:"required"`
+	Field       string                 `json:"field" binding:"required"`
+	Operator    string                 `json:"operator" binding:"required"`
+	Threshold   interface{}            `json:"threshold"`
+	Enabled     *bool                  `json:"enabled"`
+	AssetFilter map[string]interface{} `json:"assetFilter"`
+}
+
+// AlertFilterDTO represents the filter criteria for alerts
+type AlertFilterDTO struct {
+	Status   string `form:"status"`
+	Severity string `form:"severity"`
+	AssetID  string `form:"assetId"`
+	RuleID   string `form:"ruleId"`
+}
+
+// ResolveAlertDTO represents resolve alert request data
+type ResolveAlertDTO struct {
+	Resolution string `json:"resolution" binding:"required"`
+}
+
+// AlertEvaluationDTO represents the result of a manual rule sweep
+type AlertEvaluationDTO struct {
+	Opened   int `json:"opened"`
+	Resolved int `json:"resolved"`
+}
+
+// AlertApplication provides application services for alerts
+type AlertApplication struct {
+	alertService *service.AlertService
+}
+
+// NewAlertApplication creates a new alert application service
+func NewAlertApplication(alertService *service.AlertService) *AlertApplication {
+	return &AlertApplication{
+		alertService: alertService,
+	}
+}
+
+// GetAlerts gets alerts with optional filtering
+func (a *AlertApplication) GetAlerts(ctx context.Context, filter AlertFilterDTO) ([]*AlertDTO, error) {
+	filterMap := make(map[string]interface{})
+
+	if filter.Status != "" {
+		filterMap["status"] = filter.Status
+	}
+
+	if filter.Severity != "" {
+		filterMap["severity"] = filter.Severity
+	}
+
+	if filter.AssetID != "" {
+		assetID, err := primitive.ObjectIDFromHex(filter.AssetID)
+		if err != nil {
+			return nil, err
+		}
+		filterMap["assetId"] = assetID
+	}
+
+	if filter.RuleID != "" {
+		ruleID, err := primitive.ObjectIDFromHex(filter.RuleID)
+		if err != nil {
+			return nil, err
+		}
+		filterMap["ruleId"] = ruleID
+	}
+
+	alerts, err := a.alertService.GetAlerts(ctx, filterMap)
+	if err != nil {
+		return nil, err
+	}
+
+	alertDTOs := make([]*AlertDTO, len(alerts))
+	for i, alert := range alerts {
+		alertDTOs[i] = mapAlertToDTO(alert)
+	}
+
+	return alertDTOs, nil
+}
+
+// GetAlertByID gets an alert by ID
+func (a *AlertApplication) GetAlertByID(ctx context.Context, id string) (*AlertDTO, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	alert, err := a.alertService.GetAlertByID(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapAlertToDTO(alert), nil
+}
+
+// GetAlertStats gets alert statistics
+func (a *AlertApplication) GetAlertStats(ctx context.Context) (map[string]int64, error) {
+	return a.alertService.GetAlertStats(ctx)
+}
+
+// AcknowledgeAlert acknowledges an alert
+func (a *AlertApplication) AcknowledgeAlert(ctx context.Context, id, userID, username string) (*AlertDTO, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	alert, err := a.alertService.AcknowledgeAlert(ctx, objectID, userID, username)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapAlertToDTO(alert), nil
+}
+
+// ResolveAlert resolves an alert
+func (a *AlertApplication) ResolveAlert(ctx context.Context, id, userID, username string, dto ResolveAlertDTO) (*AlertDTO, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	alert, err := a.alertService.ResolveAlert(ctx, objectID, userID, username, dto.Resolution)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapAlertToDTO(alert), nil
+}
+
+// CloseAlert closes an alert
+func (a *AlertApplication) CloseAlert(ctx context.Context, id string) (*AlertDTO, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	alert, err := a.alertService.CloseAlert(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapAlertToDTO(alert), nil
+}
+
+// GetRules gets all alert rules
+func (a *AlertApplication) GetRules(ctx context.Context) ([]*AlertRuleDTO, error) {
+	rules, err := a.alertService.GetRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ruleDTOs := make([]*AlertRuleDTO, len(rules))
+	for i, rule := range rules {
+		ruleDTOs[i] = mapAlertRuleToDTO(rule)
+	}
+
+	return ruleDTOs, nil
+}
+
+// GetRuleByID gets an alert rule by ID
+func (a *AlertApplication) GetRuleByID(ctx context.Context, id string) (*AlertRuleDTO, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	rule, err := a.alertService.GetRule(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapAlertRuleToDTO(rule), nil
+}
+
+// CreateRule creates a new alert rule
+func (a *AlertApplication) CreateRule(ctx context.Context, dto AlertRuleSaveDTO) (*AlertRuleDTO, error) {
+	rule, err := a.alertService.CreateRule(ctx, mapAlertRuleSpec(dto))
+	if err != nil {
+		return nil, err
+	}
+
+	return mapAlertRuleToDTO(rule), nil
+}
+
+// UpdateRule updates an alert rule
+func (a *AlertApplication) UpdateRule(ctx context.Context, id string, dto AlertRuleSaveDTO) (*AlertRuleDTO, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	rule, err := a.alertService.UpdateRule(ctx, objectID, mapAlertRuleSpec(dto))
+	if err != nil {
+		return nil, err
+	}
+
+	return mapAlertRuleToDTO(rule), nil
+}
+
+// DeleteRule deletes an alert rule
+func (a *AlertApplication) DeleteRule(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	return a.alertService.DeleteRule(ctx, objectID)
+}
+
+// EvaluateRules runs all enabled rules immediately
+func (a *AlertApplication) EvaluateRules(ctx context.Context) (*AlertEvaluationDTO, error) {
+	opened, resolved, err := a.alertService.EvaluateAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AlertEvaluationDTO{Opened: opened, Resolved: resolved}, nil
+}
+
+// Helper function to map a save DTO to a rule spec; rules are enabled unless stated otherwise
+func mapAlertRuleSpec(dto AlertRuleSaveDTO) service.AlertRuleSpec {
+	enabled := true
+	if dto.Enabled != nil {
+		enabled = *dto.Enabled
+	}
+
+	return service.AlertRuleSpec{
+		Name:        dto.Name,
+		Description: dto.Description,
+		Type:        dto.Type,
+		Severity:    dto.Severity,
+		Field:       dto.Field,
+		Operator:    dto.Operator,
+		Threshold:   dto.Threshold,
+		AssetFilter: dto.AssetFilter,
+		Enabled:     enabled,
+	}
+}
+
+// Helper function to map an alert to a DTO
+func mapAlertToDTO(alert *model.Alert) *AlertDTO {
+	dto := &AlertDTO{
+		ID:             alert.ID.Hex(),
+		AssetID:        alert.AssetID.Hex(),
+		AssetName:      alert.AssetName,
+		Type:           string(alert.Type),
+		Severity:       string(alert.Severity),
+		Status:         string(alert.Status),
+		Title:          alert.Title,
+		Description:    alert.Description,
+		Condition:      alert.Condition,
+		CurrentValue:   alert.CurrentValue,
+		ThresholdValue: alert.ThresholdValue,
+		AcknowledgedBy: alert.AcknowledgedBy,
+		AcknowledgedAt: alert.AcknowledgedAt,
+		ResolvedBy:     alert.ResolvedBy,
+		ResolvedAt:     alert.ResolvedAt,
+		Resolution:     alert.Resolution,
+		CreatedAt:      alert.CreatedAt,
+		UpdatedAt:      alert.UpdatedAt,
+	}
+
+	if !alert.RuleID.IsZero() {
+		dto.RuleID = alert.RuleID.Hex()
+	}
+
+	return dto
+}
+
+// Helper function to map an alert rule to a DTO
+func mapAlertRuleToDTO(rule *model.AlertRule) *AlertRuleDTO {
+	return &AlertRuleDTO{
+		ID:          rule.ID.Hex(),
+		Name:        rule.Name,
+		Description: rule.Description,
+		Type:        string(rule.Type),
+		Severity:    string(rule.Severity),
+		Condition:   rule.Condition,
+		Field:       rule.Field,
+		Operator:    rule.Operator,
+		Threshold:   rule.Threshold,
+		Enabled:     rule.Enabled,
+		AssetFilter: rule.AssetFilter,
+		CreatedAt:   rule.CreatedAt,
+		UpdatedAt:   rule.UpdatedAt,
+	}
+}
@@ -0,0 +1,184 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ApprovalStageDTO represents one stage of an approval policy
+type ApprovalStageDTO struct {
+	Name         string   `json:"name" binding:"required"`
+	ApproverType string   `json:"approverType" binding:"required"`
+	Roles        []string `json:"roles,omitempty"`
+	Users        []string `json:"users,omitempty"`
+}
+
+// ApprovalPolicyDTO represents the data transfer object for approval policies
+type ApprovalPolicyDTO struct {
+	ID           string             `json:"id"`
+	Name         string             `json:"name"`
+	Description  string             `json:"description"`
+	WorkflowType string             `json:"workflowType,omitempty"`
+	Priorities   []string           `json:"priorities"`
+	Stages       []ApprovalStageDTO `json:"stages"`
+	Enabled      bool               `json:"enabled"`
+	CreatedAt    time.Time          `json:"createdAt"`
+	UpdatedAt    time.Time          `json:"updatedAt"`
+}
+
+// ApprovalPolicySaveDTO represents the data for creating or updating an approval policy.
+// An empty workflow type or priority list matches any workflow.
+type ApprovalPolicySaveDTO struct {
+	Name         string             `json:"name" binding:"required"`
+	Description  string             `json:"description"`
+	WorkflowType string             `json:"workflowType"`
+	Priorities   []string           `json:"priorities"`
+	Stages       []ApprovalStageDTO `json:"stages" binding:"required,dive"`
+	Enabled      *bool              `json:"enabled"`
+}
+
+// ApprovalPolicyApplication provides application services for approval policies
+type ApprovalPolicyApplication struct {
+	policyService *service.ApprovalPolicyService
+}
+
+// NewApprovalPolicyApplication creates a new approval policy application service
+func NewApprovalPolicyApplication(policyService *service.ApprovalPolicyService) *ApprovalPolicyApplication {
+	return &ApprovalPolicyApplication{
+		policyService: policyService,
+	}
+}
+
+// GetPolicies gets all approval policies
+func (a *ApprovalPolicyApplication) GetPolicies(ctx context.Context) ([]*ApprovalPolicyDTO, error) {
+	policies, err := a.policyService.GetPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	policyDTOs := make([]*ApprovalPolicyDTO, len(policies))
+	for i, policy := range policies {
+		policyDTOs[i] = mapApprovalPolicyToDTO(policy)
+	}
+
+	return policyDTOs, nil
+}
+
+// GetPolicyByID gets an approval policy by ID
+func (a *ApprovalPolicyApplication) GetPolicyByID(ctx context.Context, id string) (*ApprovalPolicyDTO, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := a.policyService.GetPolicy(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapApprovalPolicyToDTO(policy), nil
+}
+
+// CreatePolicy creates an approval policy
+func (a *ApprovalPolicyApplication) CreatePolicy(ctx context.Context, dto ApprovalPolicySaveDTO) (*ApprovalPolicyDTO, error) {
+	policy, err := a.policyService.CreatePolicy(ctx, mapApprovalPolicySpec(dto))
+	if err != nil {
+		return nil, err
+	}
+
+	return mapApprovalPolicyToDTO(policy), nil
+}
+
+// UpdatePolicy updates an approval policy
+func (a *ApprovalPolicyApplication) UpdatePolicy(ctx context.Context, id string, dto ApprovalPolicySaveDTO) (*ApprovalPolicyDTO, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := a.policyService.UpdatePolicy(ctx, objectID, mapApprovalPolicySpec(dto))
+	if err != nil {
+		return nil, err
+	}
+
+	return mapApprovalPolicyToDTO(policy), nil
+}
+
+// DeletePolicy deletes an approval policy
+func (a *ApprovalPolicyApplication) DeletePolicy(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	return a.policyService.DeletePolicy(ctx, objectID)
+}
+
+// Helper function to map a save DTO to a policy spec; policies are enabled unless stated otherwise
+func mapApprovalPolicySpec(dto ApprovalPolicySaveDTO) service.ApprovalPolicySpec {
+	enabled := true
+	if dto.Enabled != nil {
+		enabled = *dto.Enabled
+	}
+
+	stages := make([]model.ApprovalStageDefinition, len(dto.Stages))
+	for i, stage := range dto.Stages {
+		roles := make([]model.UserRole, len(stage.Roles))
+		for j, role := range stage.Roles {
+			roles[j] = model.UserRole(role)
+		}
+		stages[i] = model.ApprovalStageDefinition{
+			Name:         stage.Name,
+			ApproverType: model.ApproverType(stage.ApproverType),
+			Roles:        roles,
+			Users:        stage.Users,
+		}
+	}
+
+	return service.ApprovalPolicySpec{
+		Name:         dto.Name,
+		Description:  dto.Description,
+		WorkflowType: dto.WorkflowType,
+		Priorities:   dto.Priorities,
+		Stages:       stages,
+		Enabled:      enabled,
+	}
+}
+
+// Helper function to map an approval policy to a DTO
+func mapApprovalPolicyToDTO(policy *model.ApprovalPolicy) *ApprovalPolicyDTO {
+	priorities := make([]string, len(policy.Priorities))
+	for i, priority := range policy.Priorities {
+		priorities[i] = string(priority)
+	}
+
+	stages := make([]ApprovalStageDTO, len(policy.Stages))
+	for i, stage := range policy.Stages {
+		roles := make([]string, len(stage.Roles))
+		for j, role := range stage.Roles {
+			roles[j] = string(role)
+		}
+		stages[i] = ApprovalStageDTO{
+			Name:         stage.Name,
+			ApproverType: string(stage.ApproverType),
+			Roles:        roles,
+			Users:        stage.Users,
+		}
+	}
+
+	return &ApprovalPolicyDTO{
+		ID:           policy.ID.Hex(),
+		Name:         policy.Name,
+		Description:  policy.Description,
+		WorkflowType: string(policy.WorkflowType),
+		Priorities:   priorities,
+		Stages:       stages,
+		Enabled:      policy.Enabled,
+		CreatedAt:    policy.CreatedAt,
+		UpdatedAt:    policy.UpdatedAt,
+	}
+}
@@ -25,9 +25,21 @@ type AssetDTO struct {
 	Department    string    `json:"department"`
 	Owner         string    `json:"owner"`
 	IPAddress     string    `json:"ipAddress"`
+	Hostname      string    `json:"hostname,omitempty"`
+	MACAddress    string    `json:"macAddress,omitempty"`
 	LastScanned   time.Time `json:"lastScanned"`
 	CreatedAt     time.Time `json:"createdAt"`
 	UpdatedAt     time.Time `json:"updatedAt"`
+
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+	Disposal   *model.DisposalRecord  `json:"disposal,omitempty"`
+
+	OSGuess    string                       `json:"osGuess,omitempty"`
+	Services   []model.DiscoveredService    `json:"services,omitempty"`
+	ExternalID string                       `json:"externalId,omitempty"`
+	MachineID  string                       `json:"machineId,omitempty"`
+	Facts      *model.HostFacts             `json:"facts,omitempty"`
+	Provenance map[string]model.FieldSource `json:"provenance,omitempty"`
 }
 
 // AssetCreateDTO represents the data for creating an asset
@@ -36,8 +48,18 @@ type AssetCreateDTO struct {
 	Type        string `json:"type" binding:"required"`
 	Location    string `json:"location" binding:"required"`
 	Description string `json:"description"`
+	Status      string `json:"status,omitempty"`
 	Requester   string `json:"requester,omitempty"`
 	RequesterID string `json:"requesterId,omitempty"`
+
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// AssetStatusChangeDTO represents a request to move an asset to another lifecycle status
+type AssetStatusChangeDTO struct {
+	Status   string                `json:"status" binding:"required"`
+	Reason   string                `json:"reason"`
+	Disposal *model.DisposalRecord `json:"disposal,omitempty"`
 }
 
 // AssetUpdateCostsDTO represents the data for updating asset costs
@@ -87,6 +109,34 @@ type AssetHistoryDTO struct {
 	NewValues    map[string]interface{} `json:"newValues"`
 	ChangeReason string                 `json:"changeReason"`
 	Timestamp    time.Time              `json:"timestamp"`
+	MergedFrom   string                 `json:"mergedFrom,omitempty"`
+	RevertedTo   string                 `json:"revertedTo,omitempty"`
+}
+
+// AssetStateDTO represents an asset as it was at a point in time
+type AssetStateDTO struct {
+	Asset      *AssetDTO `json:"asset"`
+	AsOf       time.Time `json:"asOf"`
+	Unrestored []string  `json:"unrestored,omitempty"`
+}
+
+// AssetStateDiffDTO represents the changes to an asset between two points in time
+type AssetStateDiffDTO struct {
+	From    *AssetStateDTO      `json:"from"`
+	To      *AssetStateDTO      `json:"to"`
+	Changes []model.FieldChange `json:"changes"`
+}
+
+// AssetRevertDTO represents a request to revert an asset to a history revision
+type AssetRevertDTO struct {
+	Reason string `json:"reason"`
+}
+
+// AssetRevertPreviewDTO represents what reverting an asset to a history revision would change
+type AssetRevertPreviewDTO struct {
+	Revision *AssetHistoryDTO    `json:"revision"`
+	State    *AssetStateDTO      `json:"state"`
+	Changes  []model.FieldChange `json:"changes"`
 }
 
 // AssetApplication provides application services for assets
@@ -159,7 +209,7 @@ func (a *AssetApplication) GetAssets(ctx context.Context, filter AssetFilterDTO)
 
 // CreateAsset creates a new asset
 func (a *AssetApplication) CreateAsset(ctx context.Context, createDTO AssetCreateDTO) (*AssetDTO, error) {
-	asset, _, err := a.assetService.CreateAsset(ctx, createDTO.Name, createDTO.Type, createDTO.Location, createDTO.Description)
+	asset, _, err := a.assetService.CreateAsset(ctx, createDTO.Name, createDTO.Type, createDTO.Location, createDTO.Description, createDTO.Attributes)
 	if err != nil {
 		return nil, err
 	}
@@ -174,7 +224,7 @@ func (a *AssetApplication) UpdateAsset(ctx context.Context, id string, updateDTO
 		return nil, err
 	}
 
-	asset, err := a.assetService.UpdateAsset(ctx, objectID, updateDTO.Name, updateDTO.Location, updateDTO.Description)
+	asset, err := a.assetService.UpdateAsset(ctx, objectID, updateDTO.Name, updateDTO.Location, updateDTO.Description, updateDTO.Attributes)
 	if err != nil {
 		return nil, err
 	}
@@ -185,14 +235,14 @@ func (a *AssetApplication) UpdateAsset(ctx context.Context, id string, updateDTO
 // CreateAssetWithApproval creates a new asset with approval workflow
 func (a *AssetApplication) CreateAssetWithApproval(ctx context.Context, createDTO AssetCreateDTO) (*AssetDTO, error) {
 	// Create workflow for asset creation
-	asset, workflow, err := a.assetService.CreateAssetWithApproval(ctx, createDTO.Name, createDTO.Type, createDTO.Location, createDTO.Description, createDTO.Requester, createDTO.RequesterID)
+	asset, workflow, err := a.assetService.CreateAssetWithApproval(ctx, createDTO.Name, createDTO.Type, createDTO.Location, createDTO.Description, createDTO.Attributes, createDTO.Requester, createDTO.RequesterID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Submit to Feishu (optional)
+	// Submit to the default approval channel (optional)
 	if a.workflowService != nil {
-		_, _ = a.workflowService.SubmitToFeishu(ctx, workflow)
+		_, _ = a.workflowService.SubmitForApproval(ctx, workflow, "")
 	}
 
 	return mapAssetToDTO(asset), nil
@@ -206,14 +256,14 @@ func (a *AssetApplication) UpdateAssetWithApproval(ctx context.Context, id strin
 	}
 
 	// Create workflow for asset update
-	workflow, err := a.assetService.CreateAssetUpdateWorkflow(ctx, objectID, updateDTO.Name, updateDTO.Location, updateDTO.Description, updateDTO.Requester, updateDTO.RequesterID)
+	workflow, err := a.assetService.CreateAssetUpdateWorkflow(ctx, objectID, updateDTO.Name, updateDTO.Location, updateDTO.Description, updateDTO.Attributes, updateDTO.Requester, updateDTO.RequesterID)
 	if err != nil {
 		return err
 	}
 
-	// Submit to Feishu (optional)
+	// Submit to the default approval channel (optional)
 	if a.workflowService != nil {
-		_, _ = a.workflowService.SubmitToFeishu(ctx, workflow)
+		_, _ = a.workflowService.SubmitForApproval(ctx, workflow, "")
 	}
 
 	return nil
@@ -252,12 +302,56 @@ func (a *AssetApplication) RequestMaintenance(ctx context.Context, id string, re
 	return err
 }
 
+// ChangeAssetStatus moves an asset to another lifecycle status. When the transition needs
+// approval the returned workflow is set and the asset is unchanged until it is approved.
+func (a *AssetApplication) ChangeAssetStatus(ctx context.Context, id string, user *UserDTO, dto AssetStatusChangeDTO) (*AssetDTO, *WorkflowDTO, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	disposal := dto.Disposal
+	if disposal != nil {
+		if disposal.DisposedBy == "" {
+			disposal.DisposedBy = user.Username
+		}
+		if disposal.DisposedAt.IsZero() {
+			disposal.DisposedAt = time.Now()
+		}
+	}
+
+	asset, workflow, err := a.assetService.ChangeStatus(ctx, objectID, model.AssetStatus(dto.Status), disposal, user.Username, user.ID, dto.Reason)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if workflow == nil {
+		return mapAssetToDTO(asset), nil, nil
+	}
+
+	// Submit to the default approval channel (optional)
+	if a.workflowService != nil {
+		_, _ = a.workflowService.SubmitForApproval(ctx, workflow, "")
+	}
+
+	return mapAssetToDTO(asset), mapWorkflowToDTO(workflow), nil
+}
+
+// GetLifecycle gets the asset lifecycle definition
+func (a *AssetApplication) GetLifecycle() *model.Lifecycle {
+	return a.assetService.GetLifecycle()
+}
+
 // BulkCreateAssets creates multiple assets
 func (a *AssetApplication) BulkCreateAssets(ctx context.Context, createDTOs []AssetCreateDTO) (int, error) {
 	assets := make([]model.Asset, len(createDTOs))
 
 	for i, dto := range createDTOs {
 		assets[i] = *model.NewAsset(dto.Name, model.AssetType(dto.Type), dto.Location, dto.Description)
+		if dto.Status != "" {
+			assets[i].Status = model.AssetStatus(dto.Status)
+		}
+		assets[i].Attributes = dto.Attributes
 	}
 
 	return a.assetService.BulkCreateAssets(ctx, assets)
@@ -341,16 +435,7 @@ func (a *AssetApplication) UpdateAssetCosts(ctx context.Context, id string, cost
 		return err
 	}
 
-	asset, err := a.assetService.GetAssetByID(ctx, objectID)
-	if err != nil {
-		return err
-	}
-
-	// Update the asset costs
-	asset.UpdateCosts(costsDTO.PurchasePrice, costsDTO.AnnualCost, costsDTO.Currency)
-
-	// Save the updated asset by calling the service method with correct parameters
-	_, err = a.assetService.UpdateAsset(ctx, asset.ID, asset.Name, asset.Location, asset.Description)
+	_, err = a.assetService.UpdateAssetCosts(ctx, objectID, costsDTO.PurchasePrice, costsDTO.AnnualCost, costsDTO.Currency)
 	return err
 }
 
@@ -370,10 +455,20 @@ func mapAssetToDTO(asset *model.Asset) *AssetDTO {
 		Department:    asset.Department,
 		Owner:         asset.Owner,
 		IPAddress:     asset.IPAddress,
+		Hostname:      asset.Hostname,
+		MACAddress:    asset.MACAddress,
 		Tags:          asset.Tags,
 		LastScanned:   asset.LastScanned,
 		CreatedAt:     asset.CreatedAt,
 		UpdatedAt:     asset.UpdatedAt,
+		Attributes:    asset.Attributes,
+		Disposal:      asset.Disposal,
+		OSGuess:       asset.OSGuess,
+		Services:      asset.Services,
+		ExternalID:    asset.ExternalID,
+		MachineID:     asset.MachineID,
+		Facts:         asset.Facts,
+		Provenance:    asset.Provenance,
 	}
 }
 
@@ -384,16 +479,7 @@ func (a *AssetApplication) AddAssetTags(ctx context.Context, id string, tags []s
 		return err
 	}
 
-	asset, err := a.assetService.GetAssetByID(ctx, objectID)
-	if err != nil {
-		return err
-	}
-
-	for _, tag := range tags {
-		asset.AddTag(tag)
-	}
-
-	_, err = a.assetService.UpdateAsset(ctx, asset.ID, asset.Name, asset.Location, asset.Description)
+	_, err = a.assetService.AddAssetTags(ctx, objectID, tags)
 	return err
 }
 
@@ -404,14 +490,7 @@ func (a *AssetApplication) RemoveAssetTag(ctx context.Context, id string, tag st
 		return err
 	}
 
-	asset, err := a.assetService.GetAssetByID(ctx, objectID)
-	if err != nil {
-		return err
-	}
-
-	asset.RemoveTag(tag)
-
-	_, err = a.assetService.UpdateAsset(ctx, asset.ID, asset.Name, asset.Location, asset.Description)
+	_, err = a.assetService.RemoveAssetTag(ctx, objectID, tag)
 	return err
 }
 
@@ -476,21 +555,122 @@ func (a *AssetApplication) GetAssetHistory(ctx context.Context, assetID string)
 	// Convert to DTOs
 	var dtos []*AssetHistoryDTO
 	for _, history := range histories {
-		dto := &AssetHistoryDTO{
-			ID:           history.ID.Hex(),
-			AssetID:      history.AssetID.Hex(),
-			AssetName:    history.AssetName,
-			ChangeType:   history.ChangeType,
-			ChangedBy:    history.ChangedBy,
-			ChangedByID:  history.ChangedByID,
-			FieldChanges: history.FieldChanges,
-			OldValues:    history.OldValues,
-			NewValues:    history.NewValues,
-			ChangeReason: history.ChangeReason,
-			Timestamp:    history.Timestamp,
-		}
-		dtos = append(dtos, dto)
+		dtos = append(dtos, mapAssetHistoryToDTO(history))
 	}
 
 	return dtos, nil
 }
+
+// Helper function to map an asset history record to a DTO
+func mapAssetHistoryToDTO(history *model.AssetHistory) *AssetHistoryDTO {
+	return &AssetHistoryDTO{
+		ID:           history.ID.Hex(),
+		AssetID:      history.AssetID.Hex(),
+		AssetName:    history.AssetName,
+		ChangeType:   history.ChangeType,
+		ChangedBy:    history.ChangedBy,
+		ChangedByID:  history.ChangedByID,
+		FieldChanges: history.FieldChanges,
+		OldValues:    history.OldValues,
+		NewValues:    history.NewValues,
+		ChangeReason: history.ChangeReason,
+		Timestamp:    history.Timestamp,
+		MergedFrom:   history.MergedFrom,
+		RevertedTo:   history.RevertedTo,
+	}
+}
+
+// GetAssetAsOf gets the state of an asset at a point in time
+func (a *AssetApplication) GetAssetAsOf(ctx context.Context, id string, asOf time.Time) (*AssetStateDTO, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := a.assetService.GetAssetAsOf(ctx, objectID, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapAssetStateToDTO(state), nil
+}
+
+// DiffAssetStates compares the states of an asset at two points in time
+func (a *AssetApplication) DiffAssetStates(ctx context.Context, id string, from, to time.Time) (*AssetStateDiffDTO, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	diff, err := a.assetService.DiffAssetStates(ctx, objectID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AssetStateDiffDTO{
+		From:    mapAssetStateToDTO(diff.From),
+		To:      mapAssetStateToDTO(diff.To),
+		Changes: diff.Changes,
+	}, nil
+}
+
+// PreviewRevert shows what reverting an asset to a history revision would change
+func (a *AssetApplication) PreviewRevert(ctx context.Context, id, revisionID string) (*AssetRevertPreviewDTO, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	revisionObjectID, err := primitive.ObjectIDFromHex(revisionID)
+	if err != nil {
+		return nil, err
+	}
+
+	preview, err := a.assetService.PreviewRevert(ctx, objectID, revisionObjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapRevertPreviewToDTO(preview), nil
+}
+
+// RevertAsset requests approval to revert an asset to a history revision
+func (a *AssetApplication) RevertAsset(ctx context.Context, id, revisionID string, user *UserDTO, dto AssetRevertDTO) (*WorkflowDTO, *AssetRevertPreviewDTO, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	revisionObjectID, err := primitive.ObjectIDFromHex(revisionID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	workflow, preview, err := a.assetService.CreateAssetRevertWorkflow(ctx, objectID, revisionObjectID, dto.Reason, user.Username, user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Submit to the default approval channel (optional)
+	if a.workflowService != nil {
+		_, _ = a.workflowService.SubmitForApproval(ctx, workflow, "")
+	}
+
+	return mapWorkflowToDTO(workflow), mapRevertPreviewToDTO(preview), nil
+}
+
+// Helper function to map a revert preview to a DTO
+func mapRevertPreviewToDTO(preview *model.RevertPreview) *AssetRevertPreviewDTO {
+	return &AssetRevertPreviewDTO{
+		Revision: mapAssetHistoryToDTO(preview.Revision),
+		State:    mapAssetStateToDTO(preview.State),
+		Changes:  preview.Changes,
+	}
+}
+
+// Helper function to map an asset state to a DTO
+func mapAssetStateToDTO(state *model.AssetState) *AssetStateDTO {
+	return &AssetStateDTO{
+		Asset:      mapAssetToDTO(state.Asset),
+		AsOf:       state.AsOf,
+		Unrestored: state.Unrestored,
+	}
+}
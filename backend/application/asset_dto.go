@@ -40,6 +40,9 @@ type AssetUpdateDTO struct {
 	Description string `json:"description"`
 	Requester   string `json:"requester,omitempty"`
 	RequesterID string `json:"requesterId,omitempty"`
+
+	// Attributes replaces the CI type attributes when present
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
 }
 
 // AssetBulkUpdateDTO represents bulk update fields for assets
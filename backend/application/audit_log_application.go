@@ -37,7 +37,13 @@ type AuditLogDTO struct {
 	NewValue     map[string]interface{} `json:"newValue,omitempty"`
 	Success      bool                   `json:"success"`
 	ErrorMessage string                 `json:"errorMessage,omitempty"`
+	Method       string                 `json:"method,omitempty"`
+	Path         string                 `json:"path,omitempty"`
+	StatusCode   int                    `json:"statusCode,omitempty"`
 	Timestamp    time.Time              `json:"timestamp"`
+	Sequence     int64                  `json:"sequence,omitempty"`
+	PreviousHash string                 `json:"previousHash,omitempty"`
+	Hash         string                 `json:"hash,omitempty"`
 }
 
 // AuditLogSearchDTO represents search criteria for audit logs
@@ -57,6 +63,18 @@ type AuditLogSearchDTO struct {
 	SortOrder    string    `json:"sortOrder"`
 }
 
+// RequestAuditDTO describes an API request for the audit log. Path is the route the request
+// matched, such as /api/assets/:id.
+type RequestAuditDTO struct {
+	Action       string
+	ResourceType string
+	ResourceID   string
+	Method       string
+	Path         string
+	StatusCode   int
+	Error        string
+}
+
 // AuditLogStatsDTO represents audit log statistics
 type AuditLogStatsDTO struct {
 	TotalLogs       int64             `json:"totalLogs"`
@@ -74,6 +92,25 @@ type UserActivityDTO struct {
 	Actions  int64  `json:"actions"`
 }
 
+// WithClient returns a context whose audit logs record the client a request came from
+func WithClient(ctx context.Context, ipAddress, userAgent string) context.Context {
+	return model.WithAuditClient(ctx, model.AuditClient{IPAddress: ipAddress, UserAgent: userAgent})
+}
+
+// LogRequest records a request of a user that changes data
+func (a *AuditLogApplication) LogRequest(ctx context.Context, user *UserDTO, dto RequestAuditDTO) error {
+	client := model.AuditClientFromContext(ctx)
+	return a.auditLogService.LogRequest(ctx, user.ID, user.Username, model.AuditAction(dto.Action), dto.ResourceType, dto.ResourceID,
+		dto.Method, dto.Path, dto.StatusCode, dto.Error, client.IPAddress, client.UserAgent)
+}
+
+// LogPermissionDenied records a request of a user that was refused for lacking a permission
+func (a *AuditLogApplication) LogPermissionDenied(ctx context.Context, user *UserDTO, permission string, dto RequestAuditDTO) error {
+	client := model.AuditClientFromContext(ctx)
+	return a.auditLogService.LogPermissionDenied(ctx, user.ID, user.Username, permission, dto.ResourceType, dto.ResourceID,
+		dto.Method, dto.Path, dto.StatusCode, client.IPAddress, client.UserAgent)
+}
+
 // GetUserActivityLogs retrieves activity logs for a specific user
 func (a *AuditLogApplication) GetUserActivityLogs(ctx context.Context, userID string, limit int) ([]*AuditLogDTO, error) {
 	logs, err := a.auditLogService.GetUserActivityLogs(ctx, userID, limit)
@@ -196,8 +233,25 @@ func (a *AuditLogApplication) GetAuditLogStats(ctx context.Context, startDate, e
 	return stats, nil
 }
 
-// CleanupOldLogs removes audit logs older than the retention period
-func (a *AuditLogApplication) CleanupOldLogs(ctx context.Context, retentionDays int) error {
+// VerifyAuditLogs verifies the audit log chain from one sequence to another; zero bounds
+// verify from the first entry or to the last one
+func (a *AuditLogApplication) VerifyAuditLogs(ctx context.Context, fromSequence, toSequence int64) (*model.AuditVerification, error) {
+	return a.auditLogService.VerifyChain(ctx, fromSequence, toSequence)
+}
+
+// GetCheckpoints retrieves the most recent checkpoints of the audit log chain
+func (a *AuditLogApplication) GetCheckpoints(ctx context.Context, limit int) ([]*model.AuditCheckpoint, error) {
+	return a.auditLogService.GetCheckpoints(ctx, limit)
+}
+
+// GetArchives retrieves the records of the archived segments of the audit log chain
+func (a *AuditLogApplication) GetArchives(ctx context.Context) ([]*model.AuditArchive, error) {
+	return a.auditLogService.GetArchives(ctx)
+}
+
+// CleanupOldLogs archives audit logs older than the retention period, returning the new archive
+// or nil when there was nothing to archive
+func (a *AuditLogApplication) CleanupOldLogs(ctx context.Context, retentionDays int) (*model.AuditArchive, error) {
 	return a.auditLogService.CleanupOldLogs(ctx, retentionDays)
 }
 
@@ -218,7 +272,13 @@ func (a *AuditLogApplication) toAuditLogDTO(log *model.AuditLog) *AuditLogDTO {
 		NewValue:     log.NewValue,
 		Success:      log.Success,
 		ErrorMessage: log.ErrorMessage,
+		Method:       log.Method,
+		Path:         log.Path,
+		StatusCode:   log.StatusCode,
 		Timestamp:    log.Timestamp,
+		Sequence:     log.Sequence,
+		PreviousHash: log.PreviousHash,
+		Hash:         log.Hash,
 	}
 }
 
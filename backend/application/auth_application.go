@@ -3,6 +3,7 @@ package application
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/phuhao00/cmdb/backend/domain/model"
 	"github.com/phuhao00/cmdb/backend/domain/service"
@@ -27,10 +28,19 @@ type LoginDTO struct {
 	Password string `json:"password" binding:"required"`
 }
 
-// LoginResponseDTO represents login response data
+// LoginResponseDTO represents login and refresh response data. Token is the access token.
 type LoginResponseDTO struct {
-	User  *UserDTO `json:"user"`
-	Token string   `json:"token"`
+	User                  *UserDTO  `json:"user"`
+	Token                 string    `json:"token"`
+	TokenType             string    `json:"tokenType"`
+	ExpiresIn             int64     `json:"expiresIn"`
+	RefreshToken          string    `json:"refreshToken"`
+	RefreshTokenExpiresAt time.Time `json:"refreshTokenExpiresAt"`
+}
+
+// RefreshDTO represents refresh request data
+type RefreshDTO struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
 }
 
 // UserDTO represents user data for API responses
@@ -42,10 +52,18 @@ type UserDTO struct {
 	Role        string             `json:"role"`
 	Status      string             `json:"status"`
 	Permissions []model.Permission `json:"permissions"`
+	Manager     string             `json:"manager,omitempty"`
+	ChannelIDs  map[string]string  `json:"channelIds,omitempty"`
+	AuthSource  string             `json:"authSource"`
 	LastLoginAt *string            `json:"lastLoginAt"`
 	CreatedAt   string             `json:"createdAt"`
 }
 
+// WithUser returns a context whose changes are attributed to the user, such as in the asset history
+func WithUser(ctx context.Context, user *UserDTO) context.Context {
+	return model.WithActor(ctx, model.Actor{ID: user.ID, Name: user.Username})
+}
+
 // CreateUserDTO represents create user request data
 type CreateUserDTO struct {
 	Username string `json:"username" binding:"required"`
@@ -66,17 +84,35 @@ type UpdateUserStatusDTO struct {
 	Status string `json:"status" binding:"required"`
 }
 
+// UpdateUserManagerDTO represents update user manager request data
+type UpdateUserManagerDTO struct {
+	Manager string `json:"manager"`
+}
+
+// UpdateUserChannelIDDTO represents update user channel account request data
+type UpdateUserChannelIDDTO struct {
+	Channel   string `json:"channel" binding:"required"`
+	AccountID string `json:"accountId"`
+}
+
 // Login authenticates a user
 func (a *AuthApplication) Login(ctx context.Context, dto LoginDTO) (*LoginResponseDTO, error) {
-	user, token, err := a.authService.Login(ctx, dto.Username, dto.Password)
+	user, tokens, err := a.authService.Login(ctx, dto.Username, dto.Password)
 	if err != nil {
 		return nil, err
 	}
 
-	return &LoginResponseDTO{
-		User:  a.userToDTO(user),
-		Token: token,
-	}, nil
+	return a.loginResponse(user, tokens), nil
+}
+
+// Refresh exchanges a refresh token for new tokens
+func (a *AuthApplication) Refresh(ctx context.Context, dto RefreshDTO) (*LoginResponseDTO, error) {
+	user, tokens, err := a.authService.Refresh(ctx, dto.RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.loginResponse(user, tokens), nil
 }
 
 // Logout invalidates a user's session
@@ -84,14 +120,58 @@ func (a *AuthApplication) Logout(ctx context.Context, token string) error {
 	return a.authService.Logout(ctx, token)
 }
 
-// ValidateToken validates a session token
+// ValidateToken validates an access token and returns the user it was issued to, as of then
 func (a *AuthApplication) ValidateToken(ctx context.Context, token string) (*UserDTO, error) {
-	user, err := a.authService.ValidateToken(ctx, token)
+	claims, err := a.authService.ValidateToken(ctx, token)
 	if err != nil {
 		return nil, err
 	}
 
-	return a.userToDTO(user), nil
+	return &UserDTO{
+		ID:          claims.Subject,
+		Username:    claims.Username,
+		Email:       claims.Email,
+		FullName:    claims.Name,
+		Role:        string(claims.Role),
+		Status:      string(model.ActiveStatus),
+		Permissions: claims.Permissions,
+	}, nil
+}
+
+// JWKS returns the public keys access tokens are verified with
+func (a *AuthApplication) JWKS() model.JSONWebKeySet {
+	return a.authService.JWKS()
+}
+
+// SingleSignOnEnabled tells whether users can sign in through an identity provider
+func (a *AuthApplication) SingleSignOnEnabled() bool {
+	return a.authService.SingleSignOnConfig() != nil
+}
+
+// StartSingleSignOn begins a sign-in at the identity provider and returns the address to send
+// the user to and the state of the sign-in
+func (a *AuthApplication) StartSingleSignOn(ctx context.Context, returnTo string) (string, string, error) {
+	return a.authService.StartSingleSignOn(ctx, returnTo)
+}
+
+// CompleteSingleSignOn finishes a sign-in at the identity provider and returns the tokens and the
+// page to go back to
+func (a *AuthApplication) CompleteSingleSignOn(ctx context.Context, state, code string) (*LoginResponseDTO, string, error) {
+	user, tokens, returnTo, err := a.authService.CompleteSingleSignOn(ctx, state, code)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return a.loginResponse(user, tokens), returnTo, nil
+}
+
+// PostLoginURL returns the page of the web app that receives the outcome of a sign-in at the
+// identity provider
+func (a *AuthApplication) PostLoginURL() string {
+	if config := a.authService.SingleSignOnConfig(); config != nil {
+		return config.PostLoginURL
+	}
+	return "/"
 }
 
 // CreateUser creates a new user
@@ -170,6 +250,38 @@ func (a *AuthApplication) UpdateUserStatus(ctx context.Context, userIDStr string
 	return a.authService.UpdateUserStatus(ctx, userID, status)
 }
 
+// UpdateUserManager sets a user's line manager
+func (a *AuthApplication) UpdateUserManager(ctx context.Context, userIDStr string, dto UpdateUserManagerDTO) error {
+	userID, err := primitive.ObjectIDFromHex(userIDStr)
+	if err != nil {
+		return err
+	}
+
+	return a.authService.SetUserManager(ctx, userID, dto.Manager)
+}
+
+// UpdateUserChannelID links a user to their account at an approval channel
+func (a *AuthApplication) UpdateUserChannelID(ctx context.Context, userIDStr string, dto UpdateUserChannelIDDTO) error {
+	userID, err := primitive.ObjectIDFromHex(userIDStr)
+	if err != nil {
+		return err
+	}
+
+	return a.authService.SetUserChannelID(ctx, userID, dto.Channel, dto.AccountID)
+}
+
+// loginResponse converts the tokens issued to a user to DTO
+func (a *AuthApplication) loginResponse(user *model.User, tokens *model.AuthTokens) *LoginResponseDTO {
+	return &LoginResponseDTO{
+		User:                  a.userToDTO(user),
+		Token:                 tokens.AccessToken,
+		TokenType:             "Bearer",
+		ExpiresIn:             tokens.ExpiresIn,
+		RefreshToken:          tokens.RefreshToken,
+		RefreshTokenExpiresAt: tokens.RefreshExpiresAt,
+	}
+}
+
 // userToDTO converts a user model to DTO
 func (a *AuthApplication) userToDTO(user *model.User) *UserDTO {
 	dto := &UserDTO{
@@ -180,9 +292,16 @@ func (a *AuthApplication) userToDTO(user *model.User) *UserDTO {
 		Role:        string(user.Role),
 		Status:      string(user.Status),
 		Permissions: user.Permissions,
+		Manager:     user.Manager,
+		ChannelIDs:  user.ChannelIDs,
+		AuthSource:  model.LocalAuthSource,
 		CreatedAt:   user.CreatedAt.Format("2006-01-02 15:04:05"),
 	}
 
+	if user.AuthSource != "" {
+		dto.AuthSource = user.AuthSource
+	}
+
 	if user.LastLoginAt != nil {
 		lastLogin := user.LastLoginAt.Format("2006-01-02 15:04:05")
 		dto.LastLoginAt = &lastLogin
@@ -0,0 +1,124 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/service"
+)
+
+// CITypeDTO represents the data transfer object for CI types
+type CITypeDTO struct {
+	Name        string                      `json:"name"`
+	DisplayName string                      `json:"displayName"`
+	Description string                      `json:"description"`
+	Prefix      string                      `json:"prefix"`
+	Attributes  []model.AttributeDefinition `json:"attributes"`
+	BuiltIn     bool                        `json:"builtIn"`
+	CreatedAt   time.Time                   `json:"createdAt"`
+	UpdatedAt   time.Time                   `json:"updatedAt"`
+}
+
+// CITypeSaveDTO represents the data for creating or updating a CI type. The name is taken
+// from the URL on update.
+type CITypeSaveDTO struct {
+	Name        string                      `json:"name"`
+	DisplayName string                      `json:"displayName" binding:"required"`
+	Description string                      `json:"description"`
+	Prefix      string                      `json:"prefix"`
+	Attributes  []model.AttributeDefinition `json:"attributes"`
+}
+
+// CITypeApplication provides application services for CI types
+type CITypeApplication struct {
+	ciTypeService *service.CITypeService
+}
+
+// NewCITypeApplication creates a new CI type application service
+func NewCITypeApplication(ciTypeService *service.CITypeService) *CITypeApplication {
+	return &CITypeApplication{
+		ciTypeService: ciTypeService,
+	}
+}
+
+// GetTypes gets all CI types
+func (a *CITypeApplication) GetTypes(ctx context.Context) ([]*CITypeDTO, error) {
+	ciTypes, err := a.ciTypeService.GetTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ciTypeDTOs := make([]*CITypeDTO, len(ciTypes))
+	for i, ciType := range ciTypes {
+		ciTypeDTOs[i] = mapCITypeToDTO(ciType)
+	}
+
+	return ciTypeDTOs, nil
+}
+
+// GetType gets a CI type by name
+func (a *CITypeApplication) GetType(ctx context.Context, name string) (*CITypeDTO, error) {
+	ciType, err := a.ciTypeService.GetType(ctx, model.AssetType(name))
+	if err != nil {
+		return nil, err
+	}
+
+	return mapCITypeToDTO(ciType), nil
+}
+
+// CreateType creates a CI type
+func (a *CITypeApplication) CreateType(ctx context.Context, dto CITypeSaveDTO) (*CITypeDTO, error) {
+	ciType, err := a.ciTypeService.CreateType(ctx, mapCITypeSpec(dto))
+	if err != nil {
+		return nil, err
+	}
+
+	return mapCITypeToDTO(ciType), nil
+}
+
+// UpdateType updates a CI type
+func (a *CITypeApplication) UpdateType(ctx context.Context, name string, dto CITypeSaveDTO) (*CITypeDTO, error) {
+	dto.Name = name
+	ciType, err := a.ciTypeService.UpdateType(ctx, model.AssetType(name), mapCITypeSpec(dto))
+	if err != nil {
+		return nil, err
+	}
+
+	return mapCITypeToDTO(ciType), nil
+}
+
+// DeleteType deletes a CI type
+func (a *CITypeApplication) DeleteType(ctx context.Context, name string) error {
+	return a.ciTypeService.DeleteType(ctx, model.AssetType(name))
+}
+
+// Helper function to map a save DTO to a CI type spec
+func mapCITypeSpec(dto CITypeSaveDTO) service.CITypeSpec {
+	return service.CITypeSpec{
+		Name:        dto.Name,
+		DisplayName: dto.DisplayName,
+		Description: dto.Description,
+		Prefix:      dto.Prefix,
+		Attributes:  dto.Attributes,
+	}
+}
+
+// Helper function to map a CI type to a DTO
+func mapCITypeToDTO(ciType *model.CIType) *CITypeDTO {
+	attributes := ciType.Attributes
+	if attributes == nil {
+		attributes = []model.AttributeDefinition{}
+	}
+
+	return &CITypeDTO{
+		Name:        string(ciType.Name),
+		DisplayName: ciType.DisplayName,
+		Description: ciType.Description,
+		Prefix:      ciType.Prefix,
+		Attributes:  attributes,
+		BuiltIn:     ciType.BuiltIn,
+		CreatedAt:   ciType.CreatedAt,
+		UpdatedAt:   ciType.UpdatedAt,
+	}
+}
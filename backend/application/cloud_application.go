@@ -0,0 +1,188 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CloudAccountDTO represents the data transfer object for cloud accounts
+type CloudAccountDTO struct {
+	ID              string             `json:"id"`
+	Name            string             `json:"name"`
+	Description     string             `json:"description"`
+	Provider        string             `json:"provider"`
+	Regions         []string           `json:"regions"`
+	CredentialsFile string             `json:"credentialsFile,omitempty"`
+	Endpoint        string             `json:"endpoint,omitempty"`
+	FixturesDir     string             `json:"fixturesDir,omitempty"`
+	Prices          map[string]float64 `json:"prices,omitempty"`
+	Currency        string             `json:"currency"`
+	TagRules        []model.TagRule    `json:"tagRules"`
+	IntervalMinutes int                `json:"intervalMinutes"`
+	Enabled         bool               `json:"enabled"`
+	LastSyncAt      *time.Time         `json:"lastSyncAt,omitempty"`
+	LastSync        *model.SyncResult  `json:"lastSync,omitempty"`
+	CreatedBy       string             `json:"createdBy"`
+	CreatedAt       time.Time          `json:"createdAt"`
+	UpdatedAt       time.Time          `json:"updatedAt"`
+}
+
+// CloudAccountSaveDTO represents the data for creating or updating a cloud account. Tag rules
+// default to copying every provider tag as key:value.
+type CloudAccountSaveDTO struct {
+	Name            string             `json:"name" binding:"required"`
+	Description     string             `json:"description"`
+	Provider        string             `json:"provider" binding:"required"`
+	Regions         []string           `json:"regions" binding:"required"`
+	CredentialsFile string             `json:"credentialsFile"`
+	Endpoint        string             `json:"endpoint"`
+	FixturesDir     string             `json:"fixturesDir"`
+	Prices          map[string]float64 `json:"prices"`
+	Currency        string             `json:"currency"`
+	TagRules        []model.TagRule    `json:"tagRules"`
+	IntervalMinutes int                `json:"intervalMinutes"`
+	Enabled         *bool              `json:"enabled"`
+}
+
+// CloudApplication provides application services for cloud inventory
+type CloudApplication struct {
+	cloudService *service.CloudService
+}
+
+// NewCloudApplication creates a new cloud application service
+func NewCloudApplication(cloudService *service.CloudService) *CloudApplication {
+	return &CloudApplication{
+		cloudService: cloudService,
+	}
+}
+
+// GetProviders gets the names of the supported cloud providers
+func (a *CloudApplication) GetProviders() []string {
+	return a.cloudService.Providers()
+}
+
+// GetAccounts gets all cloud accounts
+func (a *CloudApplication) GetAccounts(ctx context.Context) ([]*CloudAccountDTO, error) {
+	accounts, err := a.cloudService.GetAccounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	accountDTOs := make([]*CloudAccountDTO, len(accounts))
+	for i, account := range accounts {
+		accountDTOs[i] = mapCloudAccountToDTO(account)
+	}
+
+	return accountDTOs, nil
+}
+
+// GetAccount gets a cloud account by ID
+func (a *CloudApplication) GetAccount(ctx context.Context, id string) (*CloudAccountDTO, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := a.cloudService.GetAccount(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapCloudAccountToDTO(account), nil
+}
+
+// CreateAccount creates a cloud account
+func (a *CloudApplication) CreateAccount(ctx context.Context, dto CloudAccountSaveDTO, createdBy string) (*CloudAccountDTO, error) {
+	account, err := a.cloudService.CreateAccount(ctx, mapCloudAccountSpec(dto), createdBy)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapCloudAccountToDTO(account), nil
+}
+
+// UpdateAccount updates a cloud account
+func (a *CloudApplication) UpdateAccount(ctx context.Context, id string, dto CloudAccountSaveDTO) (*CloudAccountDTO, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := a.cloudService.UpdateAccount(ctx, objectID, mapCloudAccountSpec(dto))
+	if err != nil {
+		return nil, err
+	}
+
+	return mapCloudAccountToDTO(account), nil
+}
+
+// DeleteAccount deletes a cloud account
+func (a *CloudApplication) DeleteAccount(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	return a.cloudService.DeleteAccount(ctx, objectID)
+}
+
+// SyncAccount syncs a cloud account now. The result of a failed sync is returned with its error.
+func (a *CloudApplication) SyncAccount(ctx context.Context, id, triggeredBy string) (*model.SyncResult, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.cloudService.SyncAccount(ctx, objectID, triggeredBy)
+}
+
+// Helper function to map a save DTO to an account spec; accounts are enabled unless stated otherwise
+func mapCloudAccountSpec(dto CloudAccountSaveDTO) service.CloudAccountSpec {
+	enabled := true
+	if dto.Enabled != nil {
+		enabled = *dto.Enabled
+	}
+
+	return service.CloudAccountSpec{
+		Name:            dto.Name,
+		Description:     dto.Description,
+		Provider:        dto.Provider,
+		Regions:         dto.Regions,
+		CredentialsFile: dto.CredentialsFile,
+		Endpoint:        dto.Endpoint,
+		FixturesDir:     dto.FixturesDir,
+		Prices:          dto.Prices,
+		Currency:        dto.Currency,
+		TagRules:        dto.TagRules,
+		IntervalMinutes: dto.IntervalMinutes,
+		Enabled:         enabled,
+	}
+}
+
+// Helper function to map a cloud account to a DTO
+func mapCloudAccountToDTO(account *model.CloudAccount) *CloudAccountDTO {
+	return &CloudAccountDTO{
+		ID:              account.ID.Hex(),
+		Name:            account.Name,
+		Description:     account.Description,
+		Provider:        account.Provider,
+		Regions:         account.Regions,
+		CredentialsFile: account.CredentialsFile,
+		Endpoint:        account.Endpoint,
+		FixturesDir:     account.FixturesDir,
+		Prices:          account.Prices,
+		Currency:        account.Currency,
+		TagRules:        account.TagRules,
+		IntervalMinutes: account.IntervalMinutes,
+		Enabled:         account.Enabled,
+		LastSyncAt:      account.LastSyncAt,
+		LastSync:        account.LastSync,
+		CreatedBy:       account.CreatedBy,
+		CreatedAt:       account.CreatedAt,
+		UpdatedAt:       account.UpdatedAt,
+	}
+}
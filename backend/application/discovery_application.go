@@ -0,0 +1,423 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DiscoveryRangeDTO represents the data transfer object for discovery ranges
+type DiscoveryRangeDTO struct {
+	ID              string     `json:"id"`
+	Name            string     `json:"name"`
+	Description     string     `json:"description"`
+	CIDRs           []string   `json:"cidrs"`
+	Ports           []int      `json:"ports"`
+	Location        string     `json:"location"`
+	ICMP            bool       `json:"icmp"`
+	IntervalMinutes int        `json:"intervalMinutes"`
+	RateLimit       int        `json:"rateLimit"`
+	Concurrency     int        `json:"concurrency"`
+	TimeoutMillis   int        `json:"timeoutMillis"`
+	Enabled         bool       `json:"enabled"`
+	LastRunAt       *time.Time `json:"lastRunAt,omitempty"`
+	CreatedBy       string     `json:"createdBy"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+}
+
+// DiscoveryRangeSaveDTO represents the data for creating or updating a discovery range
+type DiscoveryRangeSaveDTO struct {
+	Name            string   `json:"name" binding:"required"`
+	Description     string   `json:"description"`
+	CIDRs           []string `json:"cidrs" binding:"required"`
+	Ports           []int    `json:"ports"`
+	Location        string   `json:"location"`
+	ICMP            bool     `json:"icmp"`
+	IntervalMinutes int      `json:"intervalMinutes"`
+	RateLimit       int      `json:"rateLimit"`
+	Concurrency     int      `json:"concurrency"`
+	TimeoutMillis   int      `json:"timeoutMillis"`
+	Enabled         *bool    `json:"enabled"`
+}
+
+// ScanRunDTO represents the data transfer object for discovery scans
+type ScanRunDTO struct {
+	ID            string     `json:"id"`
+	RangeID       string     `json:"rangeId"`
+	RangeName     string     `json:"rangeName"`
+	Status        string     `json:"status"`
+	TriggeredBy   string     `json:"triggeredBy"`
+	Addresses     int        `json:"addresses"`
+	HostsUp       int        `json:"hostsUp"`
+	Matched       int        `json:"matched"`
+	StatusChanges int        `json:"statusChanges"`
+	Candidates    int        `json:"candidates"`
+	Error         string     `json:"error,omitempty"`
+	StartedAt     time.Time  `json:"startedAt"`
+	FinishedAt    *time.Time `json:"finishedAt,omitempty"`
+}
+
+// ScanRunFilterDTO represents the filter criteria for discovery scans
+type ScanRunFilterDTO struct {
+	RangeID string `form:"rangeId"`
+	Status  string `form:"status"`
+	Limit   int64  `form:"limit"`
+}
+
+// DiscoveryCandidateDTO represents the data transfer object for discovered hosts awaiting review
+type DiscoveryCandidateDTO struct {
+	ID            string                    `json:"id"`
+	IPAddress     string                    `json:"ipAddress"`
+	MACAddress    string                    `json:"macAddress,omitempty"`
+	Hostname      string                    `json:"hostname,omitempty"`
+	OpenPorts     []int                     `json:"openPorts"`
+	Services      []model.DiscoveredService `json:"services"`
+	SuggestedType string                    `json:"suggestedType"`
+	RangeID       string                    `json:"rangeId"`
+	RangeName     string                    `json:"rangeName"`
+	Location      string                    `json:"location"`
+	Status        string                    `json:"status"`
+	SeenCount     int                       `json:"seenCount"`
+	FirstSeen     time.Time                 `json:"firstSeen"`
+	LastSeen      time.Time                 `json:"lastSeen"`
+	AssetID       string                    `json:"assetId,omitempty"`
+	ReviewedBy    string                    `json:"reviewedBy,omitempty"`
+	ReviewedAt    *time.Time                `json:"reviewedAt,omitempty"`
+}
+
+// CandidateFilterDTO represents the filter criteria for discovery candidates
+type CandidateFilterDTO struct {
+	Status  string `form:"status"`
+	RangeID string `form:"rangeId"`
+}
+
+// CandidateApproveDTO represents the asset to create from a discovery candidate; every field is optional
+type CandidateApproveDTO struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Location    string `json:"location"`
+	Description string `json:"description"`
+}
+
+// ScanImportDTO represents the options of a scan report import, given as query parameters
+type ScanImportDTO struct {
+	Format   string `form:"format"`
+	Location string `form:"location"`
+	DryRun   bool   `form:"dryRun"`
+}
+
+// DiscoveryApplication provides application services for network discovery
+type DiscoveryApplication struct {
+	discoveryService *service.DiscoveryService
+}
+
+// NewDiscoveryApplication creates a new discovery application service
+func NewDiscoveryApplication(discoveryService *service.DiscoveryService) *DiscoveryApplication {
+	return &DiscoveryApplication{
+		discoveryService: discoveryService,
+	}
+}
+
+// GetRanges gets all discovery ranges
+func (a *DiscoveryApplication) GetRanges(ctx context.Context) ([]*DiscoveryRangeDTO, error) {
+	ranges, err := a.discoveryService.GetRanges(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rangeDTOs := make([]*DiscoveryRangeDTO, len(ranges))
+	for i, rng := range ranges {
+		rangeDTOs[i] = mapDiscoveryRangeToDTO(rng)
+	}
+
+	return rangeDTOs, nil
+}
+
+// GetRange gets a discovery range by ID
+func (a *DiscoveryApplication) GetRange(ctx context.Context, id string) (*DiscoveryRangeDTO, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	rng, err := a.discoveryService.GetRange(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapDiscoveryRangeToDTO(rng), nil
+}
+
+// CreateRange creates a discovery range
+func (a *DiscoveryApplication) CreateRange(ctx context.Context, dto DiscoveryRangeSaveDTO, createdBy string) (*DiscoveryRangeDTO, error) {
+	rng, err := a.discoveryService.CreateRange(ctx, mapDiscoveryRangeSpec(dto), createdBy)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapDiscoveryRangeToDTO(rng), nil
+}
+
+// UpdateRange updates a discovery range
+func (a *DiscoveryApplication) UpdateRange(ctx context.Context, id string, dto DiscoveryRangeSaveDTO) (*DiscoveryRangeDTO, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	rng, err := a.discoveryService.UpdateRange(ctx, objectID, mapDiscoveryRangeSpec(dto))
+	if err != nil {
+		return nil, err
+	}
+
+	return mapDiscoveryRangeToDTO(rng), nil
+}
+
+// DeleteRange deletes a discovery range
+func (a *DiscoveryApplication) DeleteRange(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	return a.discoveryService.DeleteRange(ctx, objectID)
+}
+
+// StartScan starts a scan of a discovery range
+func (a *DiscoveryApplication) StartScan(ctx context.Context, rangeID, triggeredBy string) (*ScanRunDTO, error) {
+	objectID, err := primitive.ObjectIDFromHex(rangeID)
+	if err != nil {
+		return nil, err
+	}
+
+	run, err := a.discoveryService.StartScan(ctx, objectID, triggeredBy)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapScanRunToDTO(run), nil
+}
+
+// CancelScan cancels a running scan
+func (a *DiscoveryApplication) CancelScan(ctx context.Context, runID string) error {
+	objectID, err := primitive.ObjectIDFromHex(runID)
+	if err != nil {
+		return err
+	}
+
+	return a.discoveryService.CancelScan(ctx, objectID)
+}
+
+// GetRuns gets the most recent discovery scans
+func (a *DiscoveryApplication) GetRuns(ctx context.Context, filter ScanRunFilterDTO) ([]*ScanRunDTO, error) {
+	filterMap := make(map[string]interface{})
+
+	if filter.Status != "" {
+		filterMap["status"] = filter.Status
+	}
+
+	if filter.RangeID != "" {
+		rangeID, err := primitive.ObjectIDFromHex(filter.RangeID)
+		if err != nil {
+			return nil, err
+		}
+		filterMap["rangeId"] = rangeID
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+
+	runs, err := a.discoveryService.GetRuns(ctx, filterMap, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	runDTOs := make([]*ScanRunDTO, len(runs))
+	for i, run := range runs {
+		runDTOs[i] = mapScanRunToDTO(run)
+	}
+
+	return runDTOs, nil
+}
+
+// GetRun gets a discovery scan by ID
+func (a *DiscoveryApplication) GetRun(ctx context.Context, id string) (*ScanRunDTO, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	run, err := a.discoveryService.GetRun(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapScanRunToDTO(run), nil
+}
+
+// GetCandidates gets discovered hosts that match no asset
+func (a *DiscoveryApplication) GetCandidates(ctx context.Context, filter CandidateFilterDTO) ([]*DiscoveryCandidateDTO, error) {
+	filterMap := make(map[string]interface{})
+
+	if filter.Status != "" {
+		filterMap["status"] = filter.Status
+	}
+
+	if filter.RangeID != "" {
+		rangeID, err := primitive.ObjectIDFromHex(filter.RangeID)
+		if err != nil {
+			return nil, err
+		}
+		filterMap["rangeId"] = rangeID
+	}
+
+	candidates, err := a.discoveryService.GetCandidates(ctx, filterMap)
+	if err != nil {
+		return nil, err
+	}
+
+	candidateDTOs := make([]*DiscoveryCandidateDTO, len(candidates))
+	for i, candidate := range candidates {
+		candidateDTOs[i] = mapDiscoveryCandidateToDTO(candidate)
+	}
+
+	return candidateDTOs, nil
+}
+
+// ApproveCandidate creates an asset from a discovery candidate
+func (a *DiscoveryApplication) ApproveCandidate(ctx context.Context, id string, dto CandidateApproveDTO, reviewer string) (*AssetDTO, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	asset, err := a.discoveryService.ApproveCandidate(ctx, objectID, service.CandidateApprovalSpec{
+		Name:        dto.Name,
+		Type:        dto.Type,
+		Location:    dto.Location,
+		Description: dto.Description,
+	}, reviewer)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapAssetToDTO(asset), nil
+}
+
+// IgnoreCandidate marks a discovery candidate as not worth tracking
+func (a *DiscoveryApplication) IgnoreCandidate(ctx context.Context, id, reviewer string) (*DiscoveryCandidateDTO, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	candidate, err := a.discoveryService.IgnoreCandidate(ctx, objectID, reviewer)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapDiscoveryCandidateToDTO(candidate), nil
+}
+
+// Helper function to map a save DTO to a range spec; ranges are enabled unless stated otherwise
+func mapDiscoveryRangeSpec(dto DiscoveryRangeSaveDTO) service.DiscoveryRangeSpec {
+	enabled := true
+	if dto.Enabled != nil {
+		enabled = *dto.Enabled
+	}
+
+	return service.DiscoveryRangeSpec{
+		Name:            dto.Name,
+		Description:     dto.Description,
+		CIDRs:           dto.CIDRs,
+		Ports:           dto.Ports,
+		Location:        dto.Location,
+		ICMP:            dto.ICMP,
+		IntervalMinutes: dto.IntervalMinutes,
+		RateLimit:       dto.RateLimit,
+		Concurrency:     dto.Concurrency,
+		TimeoutMillis:   dto.TimeoutMillis,
+		Enabled:         enabled,
+	}
+}
+
+// Helper function to map a discovery range to a DTO
+func mapDiscoveryRangeToDTO(rng *model.DiscoveryRange) *DiscoveryRangeDTO {
+	return &DiscoveryRangeDTO{
+		ID:              rng.ID.Hex(),
+		Name:            rng.Name,
+		Description:     rng.Description,
+		CIDRs:           rng.CIDRs,
+		Ports:           rng.Ports,
+		Location:        rng.Location,
+		ICMP:            rng.ICMP,
+		IntervalMinutes: rng.IntervalMinutes,
+		RateLimit:       rng.RateLimit,
+		Concurrency:     rng.Concurrency,
+		TimeoutMillis:   rng.TimeoutMillis,
+		Enabled:         rng.Enabled,
+		LastRunAt:       rng.LastRunAt,
+		CreatedBy:       rng.CreatedBy,
+		CreatedAt:       rng.CreatedAt,
+		UpdatedAt:       rng.UpdatedAt,
+	}
+}
+
+// Helper function to map a scan run to a DTO
+func mapScanRunToDTO(run *model.ScanRun) *ScanRunDTO {
+	return &ScanRunDTO{
+		ID:            run.ID.Hex(),
+		RangeID:       run.RangeID.Hex(),
+		RangeName:     run.RangeName,
+		Status:        string(run.Status),
+		TriggeredBy:   run.TriggeredBy,
+		Addresses:     run.Addresses,
+		HostsUp:       run.HostsUp,
+		Matched:       run.Matched,
+		StatusChanges: run.StatusChanges,
+		Candidates:    run.Candidates,
+		Error:         run.Error,
+		StartedAt:     run.StartedAt,
+		FinishedAt:    run.FinishedAt,
+	}
+}
+
+// Helper function to map a discovery candidate to a DTO
+func mapDiscoveryCandidateToDTO(candidate *model.DiscoveryCandidate) *DiscoveryCandidateDTO {
+	host := model.DiscoveredHost{Services: candidate.Services}
+
+	return &DiscoveryCandidateDTO{
+		ID:            candidate.ID.Hex(),
+		IPAddress:     candidate.IPAddress,
+		MACAddress:    candidate.MACAddress,
+		Hostname:      candidate.Hostname,
+		OpenPorts:     host.OpenPorts(),
+		Services:      candidate.Services,
+		SuggestedType: string(candidate.SuggestedType),
+		RangeID:       candidate.RangeID.Hex(),
+		RangeName:     candidate.RangeName,
+		Location:      candidate.Location,
+		Status:        string(candidate.Status),
+		SeenCount:     candidate.SeenCount,
+		FirstSeen:     candidate.FirstSeen,
+		LastSeen:      candidate.LastSeen,
+		AssetID:       candidate.AssetID,
+		ReviewedBy:    candidate.ReviewedBy,
+		ReviewedAt:    candidate.ReviewedAt,
+	}
+}
+
+// ImportScan imports the hosts of an nmap or masscan report into assets
+func (a *DiscoveryApplication) ImportScan(ctx context.Context, data []byte, dto ScanImportDTO, requester, requesterID string) (*model.ScanImport, error) {
+	return a.discoveryService.ImportScan(ctx, data, service.ScanImportSpec{
+		Format:   dto.Format,
+		Location: dto.Location,
+		DryRun:   dto.DryRun,
+	}, requester, requesterID)
+}
@@ -0,0 +1,91 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/service"
+)
+
+// IDTemplateDTO represents the data transfer object for ID templates
+type IDTemplateDTO struct {
+	Scope     string    `json:"scope"`
+	Template  string    `json:"template"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// IDTemplateSaveDTO represents the data for setting an ID template
+type IDTemplateSaveDTO struct {
+	Template string `json:"template" binding:"required"`
+}
+
+// IDTemplateListDTO lists the configured ID templates together with the built-in ones
+type IDTemplateListDTO struct {
+	Templates []*IDTemplateDTO    `json:"templates"`
+	Defaults  map[string]string   `json:"defaults"`
+	Allowed   map[string][]string `json:"placeholders"`
+}
+
+// IDTemplateApplication provides application services for ID templates
+type IDTemplateApplication struct {
+	idService *service.IDService
+}
+
+// NewIDTemplateApplication creates a new ID template application service
+func NewIDTemplateApplication(idService *service.IDService) *IDTemplateApplication {
+	return &IDTemplateApplication{
+		idService: idService,
+	}
+}
+
+// GetTemplates gets the configured ID templates
+func (a *IDTemplateApplication) GetTemplates(ctx context.Context) (*IDTemplateListDTO, error) {
+	templates, err := a.idService.GetTemplates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	templateDTOs := make([]*IDTemplateDTO, len(templates))
+	for i, template := range templates {
+		templateDTOs[i] = mapIDTemplateToDTO(template)
+	}
+
+	return &IDTemplateListDTO{
+		Templates: templateDTOs,
+		Defaults: map[string]string{
+			model.IDScopeAsset:    model.DefaultAssetIDTemplate,
+			model.IDScopeWorkflow: model.DefaultWorkflowIDTemplate,
+		},
+		Allowed: map[string][]string{
+			model.IDScopeAsset:    model.AssetIDPlaceholders,
+			model.IDScopeWorkflow: model.WorkflowIDPlaceholders,
+		},
+	}, nil
+}
+
+// SetTemplate sets the ID template of an asset type or of workflows
+func (a *IDTemplateApplication) SetTemplate(ctx context.Context, scope string, dto IDTemplateSaveDTO) (*IDTemplateDTO, error) {
+	template, err := a.idService.SetTemplate(ctx, service.IDTemplateSpec{Scope: scope, Template: dto.Template})
+	if err != nil {
+		return nil, err
+	}
+
+	return mapIDTemplateToDTO(template), nil
+}
+
+// DeleteTemplate deletes the ID template of a scope
+func (a *IDTemplateApplication) DeleteTemplate(ctx context.Context, scope string) error {
+	return a.idService.DeleteTemplate(ctx, scope)
+}
+
+// Helper function to map an ID template to a DTO
+func mapIDTemplateToDTO(template *model.IDTemplate) *IDTemplateDTO {
+	return &IDTemplateDTO{
+		Scope:     template.Scope,
+		Template:  template.Template,
+		CreatedAt: template.CreatedAt,
+		UpdatedAt: template.UpdatedAt,
+	}
+}
@@ -0,0 +1,162 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// KubernetesClusterDTO represents the data transfer object for Kubernetes clusters
+type KubernetesClusterDTO struct {
+	ID              string            `json:"id"`
+	Name            string            `json:"name"`
+	Description     string            `json:"description"`
+	KubeconfigPath  string            `json:"kubeconfigPath"`
+	Context         string            `json:"context,omitempty"`
+	Location        string            `json:"location"`
+	IntervalMinutes int               `json:"intervalMinutes"`
+	Enabled         bool              `json:"enabled"`
+	LastSyncAt      *time.Time        `json:"lastSyncAt,omitempty"`
+	LastSync        *model.SyncResult `json:"lastSync,omitempty"`
+	CreatedBy       string            `json:"createdBy"`
+	CreatedAt       time.Time         `json:"createdAt"`
+	UpdatedAt       time.Time         `json:"updatedAt"`
+}
+
+// KubernetesClusterSaveDTO represents the data for creating or updating a Kubernetes cluster
+type KubernetesClusterSaveDTO struct {
+	Name            string `json:"name" binding:"required"`
+	Description     string `json:"description"`
+	KubeconfigPath  string `json:"kubeconfigPath" binding:"required"`
+	Context         string `json:"context"`
+	Location        string `json:"location"`
+	IntervalMinutes int    `json:"intervalMinutes"`
+	Enabled         *bool  `json:"enabled"`
+}
+
+// KubernetesApplication provides application services for Kubernetes discovery
+type KubernetesApplication struct {
+	kubernetesService *service.KubernetesService
+}
+
+// NewKubernetesApplication creates a new Kubernetes application service
+func NewKubernetesApplication(kubernetesService *service.KubernetesService) *KubernetesApplication {
+	return &KubernetesApplication{
+		kubernetesService: kubernetesService,
+	}
+}
+
+// GetClusters gets all Kubernetes clusters
+func (a *KubernetesApplication) GetClusters(ctx context.Context) ([]*KubernetesClusterDTO, error) {
+	clusters, err := a.kubernetesService.GetClusters(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	clusterDTOs := make([]*KubernetesClusterDTO, len(clusters))
+	for i, cluster := range clusters {
+		clusterDTOs[i] = mapKubernetesClusterToDTO(cluster)
+	}
+
+	return clusterDTOs, nil
+}
+
+// GetCluster gets a Kubernetes cluster by ID
+func (a *KubernetesApplication) GetCluster(ctx context.Context, id string) (*KubernetesClusterDTO, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	cluster, err := a.kubernetesService.GetCluster(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapKubernetesClusterToDTO(cluster), nil
+}
+
+// CreateCluster creates a Kubernetes cluster
+func (a *KubernetesApplication) CreateCluster(ctx context.Context, dto KubernetesClusterSaveDTO, createdBy string) (*KubernetesClusterDTO, error) {
+	cluster, err := a.kubernetesService.CreateCluster(ctx, mapKubernetesClusterSpec(dto), createdBy)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapKubernetesClusterToDTO(cluster), nil
+}
+
+// UpdateCluster updates a Kubernetes cluster
+func (a *KubernetesApplication) UpdateCluster(ctx context.Context, id string, dto KubernetesClusterSaveDTO) (*KubernetesClusterDTO, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	cluster, err := a.kubernetesService.UpdateCluster(ctx, objectID, mapKubernetesClusterSpec(dto))
+	if err != nil {
+		return nil, err
+	}
+
+	return mapKubernetesClusterToDTO(cluster), nil
+}
+
+// DeleteCluster deletes a Kubernetes cluster
+func (a *KubernetesApplication) DeleteCluster(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	return a.kubernetesService.DeleteCluster(ctx, objectID)
+}
+
+// SyncCluster syncs a Kubernetes cluster now. The result of a failed sync is returned with its error.
+func (a *KubernetesApplication) SyncCluster(ctx context.Context, id, triggeredBy string) (*model.SyncResult, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.kubernetesService.SyncCluster(ctx, objectID, triggeredBy)
+}
+
+// Helper function to map a save DTO to a cluster spec; clusters are enabled unless stated otherwise
+func mapKubernetesClusterSpec(dto KubernetesClusterSaveDTO) service.KubernetesClusterSpec {
+	enabled := true
+	if dto.Enabled != nil {
+		enabled = *dto.Enabled
+	}
+
+	return service.KubernetesClusterSpec{
+		Name:            dto.Name,
+		Description:     dto.Description,
+		KubeconfigPath:  dto.KubeconfigPath,
+		Context:         dto.Context,
+		Location:        dto.Location,
+		IntervalMinutes: dto.IntervalMinutes,
+		Enabled:         enabled,
+	}
+}
+
+// Helper function to map a Kubernetes cluster to a DTO
+func mapKubernetesClusterToDTO(cluster *model.KubernetesCluster) *KubernetesClusterDTO {
+	return &KubernetesClusterDTO{
+		ID:              cluster.ID.Hex(),
+		Name:            cluster.Name,
+		Description:     cluster.Description,
+		KubeconfigPath:  cluster.KubeconfigPath,
+		Context:         cluster.Context,
+		Location:        cluster.Location,
+		IntervalMinutes: cluster.IntervalMinutes,
+		Enabled:         cluster.Enabled,
+		LastSyncAt:      cluster.LastSyncAt,
+		LastSync:        cluster.LastSync,
+		CreatedBy:       cluster.CreatedBy,
+		CreatedAt:       cluster.CreatedAt,
+		UpdatedAt:       cluster.UpdatedAt,
+	}
+}
@@ -0,0 +1,342 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// FieldConflictDTO represents the data transfer object for field conflicts
+type FieldConflictDTO struct {
+	ID             string            `json:"id"`
+	AssetID        string            `json:"assetId"`
+	AssetCode      string            `json:"assetCode"`
+	AssetName      string            `json:"assetName"`
+	Field          string            `json:"field"`
+	CurrentValue   json.RawMessage   `json:"currentValue"`
+	CurrentSource  model.FieldSource `json:"currentSource"`
+	ProposedValue  json.RawMessage   `json:"proposedValue"`
+	ProposedSource model.FieldSource `json:"proposedSource"`
+	Status         string            `json:"status"`
+	ReportCount    int               `json:"reportCount"`
+	ResolvedBy     string            `json:"resolvedBy,omitempty"`
+	ResolvedAt     *time.Time        `json:"resolvedAt,omitempty"`
+	CreatedAt      time.Time         `json:"createdAt"`
+	UpdatedAt      time.Time         `json:"updatedAt"`
+}
+
+// ConflictFilterDTO represents the filter criteria for field conflicts
+type ConflictFilterDTO struct {
+	Status  string `form:"status"`
+	AssetID string `form:"assetId"`
+	Field   string `form:"field"`
+}
+
+// ConflictOverrideDTO represents the value a reviewer sets instead of a reported one; without
+// a value the asset keeps its current value
+type ConflictOverrideDTO struct {
+	Value json.RawMessage `json:"value"`
+}
+
+// MergeMemberDTO represents an asset of a merge proposal
+type MergeMemberDTO struct {
+	ID      string `json:"id"`
+	AssetID string `json:"assetId"`
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+}
+
+// MergeProposalDTO represents the data transfer object for merge proposals
+type MergeProposalDTO struct {
+	ID         string           `json:"id"`
+	Assets     []MergeMemberDTO `json:"assets"`
+	MatchedOn  []string         `json:"matchedOn"`
+	Score      float64          `json:"score"`
+	Status     string           `json:"status"`
+	ReviewedBy string           `json:"reviewedBy,omitempty"`
+	ReviewedAt *time.Time       `json:"reviewedAt,omitempty"`
+	CreatedAt  time.Time        `json:"createdAt"`
+	UpdatedAt  time.Time        `json:"updatedAt"`
+}
+
+// MergeProposalFilterDTO represents the filter criteria for merge proposals
+type MergeProposalFilterDTO struct {
+	Status string `form:"status"`
+}
+
+// MergeProposalMergeDTO chooses the asset that survives a merge proposal; without one, the
+// asset created first survives
+type MergeProposalMergeDTO struct {
+	SurvivorID string `json:"survivorId"`
+}
+
+// AssetMergeDTO represents a request to merge a duplicate asset into another asset
+type AssetMergeDTO struct {
+	SurvivorID  string `json:"survivorId" binding:"required"`
+	DuplicateID string `json:"duplicateId" binding:"required"`
+}
+
+// MergeResultDTO represents the outcome of a merge
+type MergeResultDTO struct {
+	Survivor      *AssetDTO `json:"survivor"`
+	MergedAssetID string    `json:"mergedAssetId"`
+	TakenFields   []string  `json:"takenFields"`
+	History       int64     `json:"history"`
+	Workflows     int64     `json:"workflows"`
+	AuditLogs     int64     `json:"auditLogs"`
+	Relationships int64     `json:"relationships"`
+}
+
+// ReconciliationApplication provides application services for source reconciliation
+type ReconciliationApplication struct {
+	reconciliationService *service.ReconciliationService
+}
+
+// NewReconciliationApplication creates a new reconciliation application service
+func NewReconciliationApplication(reconciliationService *service.ReconciliationService) *ReconciliationApplication {
+	return &ReconciliationApplication{
+		reconciliationService: reconciliationService,
+	}
+}
+
+// GetPolicy gets the source precedence rules
+func (a *ReconciliationApplication) GetPolicy() *model.ReconciliationPolicy {
+	return a.reconciliationService.GetPolicy()
+}
+
+// GetDuplicatePolicy gets the duplicate rules
+func (a *ReconciliationApplication) GetDuplicatePolicy() *model.DuplicatePolicy {
+	return a.reconciliationService.GetDuplicatePolicy()
+}
+
+// GetConflicts gets field conflicts with optional filtering
+func (a *ReconciliationApplication) GetConflicts(ctx context.Context, filter ConflictFilterDTO) ([]*FieldConflictDTO, error) {
+	filterMap := make(map[string]interface{})
+
+	if filter.Status != "" {
+		filterMap["status"] = filter.Status
+	}
+
+	if filter.Field != "" {
+		filterMap["field"] = filter.Field
+	}
+
+	if filter.AssetID != "" {
+		assetID, err := primitive.ObjectIDFromHex(filter.AssetID)
+		if err != nil {
+			return nil, err
+		}
+		filterMap["assetId"] = assetID
+	}
+
+	conflicts, err := a.reconciliationService.GetConflicts(ctx, filterMap)
+	if err != nil {
+		return nil, err
+	}
+
+	conflictDTOs := make([]*FieldConflictDTO, len(conflicts))
+	for i, conflict := range conflicts {
+		conflictDTOs[i] = mapFieldConflictToDTO(conflict)
+	}
+
+	return conflictDTOs, nil
+}
+
+// GetConflict gets a field conflict by ID
+func (a *ReconciliationApplication) GetConflict(ctx context.Context, id string) (*FieldConflictDTO, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	conflict, err := a.reconciliationService.GetConflict(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapFieldConflictToDTO(conflict), nil
+}
+
+// AcceptConflict applies the reported value of a field conflict
+func (a *ReconciliationApplication) AcceptConflict(ctx context.Context, id, reviewer string) (*FieldConflictDTO, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	conflict, err := a.reconciliationService.AcceptConflict(ctx, objectID, reviewer)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapFieldConflictToDTO(conflict), nil
+}
+
+// OverrideConflict rejects the reported value of a field conflict
+func (a *ReconciliationApplication) OverrideConflict(ctx context.Context, id string, dto ConflictOverrideDTO, reviewer string) (*FieldConflictDTO, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	conflict, err := a.reconciliationService.OverrideConflict(ctx, objectID, dto.Value, reviewer)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapFieldConflictToDTO(conflict), nil
+}
+
+// GetMergeProposals gets merge proposals with optional filtering
+func (a *ReconciliationApplication) GetMergeProposals(ctx context.Context, filter MergeProposalFilterDTO) ([]*MergeProposalDTO, error) {
+	filterMap := make(map[string]interface{})
+
+	if filter.Status != "" {
+		filterMap["status"] = filter.Status
+	}
+
+	proposals, err := a.reconciliationService.GetMergeProposals(ctx, filterMap)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapMergeProposalsToDTOs(proposals), nil
+}
+
+// DetectDuplicates looks for assets reported under different keys now
+func (a *ReconciliationApplication) DetectDuplicates(ctx context.Context) ([]*MergeProposalDTO, error) {
+	proposals, err := a.reconciliationService.DetectDuplicates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapMergeProposalsToDTOs(proposals), nil
+}
+
+// DismissMergeProposal marks the assets of a merge proposal as distinct
+func (a *ReconciliationApplication) DismissMergeProposal(ctx context.Context, id, reviewer string) (*MergeProposalDTO, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	proposal, err := a.reconciliationService.DismissMergeProposal(ctx, objectID, reviewer)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapMergeProposalToDTO(proposal), nil
+}
+
+// MergeProposal merges the assets of a merge proposal
+func (a *ReconciliationApplication) MergeProposal(ctx context.Context, id string, dto MergeProposalMergeDTO, reviewer string) (*MergeResultDTO, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	survivorID := primitive.NilObjectID
+	if dto.SurvivorID != "" {
+		if survivorID, err = primitive.ObjectIDFromHex(dto.SurvivorID); err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := a.reconciliationService.MergeProposal(ctx, objectID, survivorID, reviewer)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapMergeResultToDTO(result), nil
+}
+
+// MergeAssets merges a duplicate asset into another asset
+func (a *ReconciliationApplication) MergeAssets(ctx context.Context, dto AssetMergeDTO, reviewer string) (*MergeResultDTO, error) {
+	survivorID, err := primitive.ObjectIDFromHex(dto.SurvivorID)
+	if err != nil {
+		return nil, err
+	}
+	duplicateID, err := primitive.ObjectIDFromHex(dto.DuplicateID)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := a.reconciliationService.MergeAssets(ctx, survivorID, duplicateID, reviewer)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapMergeResultToDTO(result), nil
+}
+
+// Helper function to map a merge result to a DTO
+func mapMergeResultToDTO(result *service.MergeResult) *MergeResultDTO {
+	return &MergeResultDTO{
+		Survivor:      mapAssetToDTO(result.Survivor),
+		MergedAssetID: result.MergedAssetID,
+		TakenFields:   result.TakenFields,
+		History:       result.History,
+		Workflows:     result.Workflows,
+		AuditLogs:     result.AuditLogs,
+		Relationships: result.Relationships,
+	}
+}
+
+// Helper function to map a field conflict to a DTO
+func mapFieldConflictToDTO(conflict *model.FieldConflict) *FieldConflictDTO {
+	return &FieldConflictDTO{
+		ID:             conflict.ID.Hex(),
+		AssetID:        conflict.AssetID.Hex(),
+		AssetCode:      conflict.AssetCode,
+		AssetName:      conflict.AssetName,
+		Field:          conflict.Field,
+		CurrentValue:   json.RawMessage(conflict.CurrentValue),
+		CurrentSource:  conflict.CurrentSource,
+		ProposedValue:  json.RawMessage(conflict.ProposedValue),
+		ProposedSource: conflict.ProposedSource,
+		Status:         string(conflict.Status),
+		ReportCount:    conflict.ReportCount,
+		ResolvedBy:     conflict.ResolvedBy,
+		ResolvedAt:     conflict.ResolvedAt,
+		CreatedAt:      conflict.CreatedAt,
+		UpdatedAt:      conflict.UpdatedAt,
+	}
+}
+
+// Helper function to map merge proposals to DTOs
+func mapMergeProposalsToDTOs(proposals []*model.MergeProposal) []*MergeProposalDTO {
+	proposalDTOs := make([]*MergeProposalDTO, len(proposals))
+	for i, proposal := range proposals {
+		proposalDTOs[i] = mapMergeProposalToDTO(proposal)
+	}
+	return proposalDTOs
+}
+
+// Helper function to map a merge proposal to a DTO
+func mapMergeProposalToDTO(proposal *model.MergeProposal) *MergeProposalDTO {
+	members := make([]MergeMemberDTO, len(proposal.Assets))
+	for i, member := range proposal.Assets {
+		members[i] = MergeMemberDTO{
+			ID:      member.ID.Hex(),
+			AssetID: member.AssetID,
+			Name:    member.Name,
+			Type:    string(member.Type),
+		}
+	}
+
+	return &MergeProposalDTO{
+		ID:         proposal.ID.Hex(),
+		Assets:     members,
+		MatchedOn:  proposal.MatchedOn,
+		Score:      proposal.Score,
+		Status:     string(proposal.Status),
+		ReviewedBy: proposal.ReviewedBy,
+		ReviewedAt: proposal.ReviewedAt,
+		CreatedAt:  proposal.CreatedAt,
+		UpdatedAt:  proposal.UpdatedAt,
+	}
+}
@@ -0,0 +1,178 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RelationshipDTO represents the data transfer object for asset relationships
+type RelationshipDTO struct {
+	ID          string    `json:"id"`
+	SourceID    string    `json:"sourceId"`
+	TargetID    string    `json:"targetId"`
+	Type        string    `json:"type"`
+	Direction   string    `json:"direction"`
+	Description string    `json:"description"`
+	CreatedBy   string    `json:"createdBy"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// RelationshipCreateDTO represents the data for creating a relationship
+type RelationshipCreateDTO struct {
+	TargetID    string `json:"targetId" binding:"required"`
+	Type        string `json:"type" binding:"required"`
+	Description string `json:"description"`
+	CreatedBy   string `json:"-"`
+	CreatedByID string `json:"-"`
+}
+
+// ImpactNodeDTO represents an asset reached during impact analysis
+type ImpactNodeDTO struct {
+	Asset            *AssetDTO `json:"asset"`
+	Depth            int       `json:"depth"`
+	ParentID         string    `json:"parentId"`
+	RelationshipID   string    `json:"relationshipId"`
+	RelationshipType string    `json:"relationshipType"`
+}
+
+// ImpactAnalysisDTO represents the result of an impact analysis
+type ImpactAnalysisDTO struct {
+	Asset      *AssetDTO        `json:"asset"`
+	Depth      int              `json:"depth"`
+	Upstream   []*ImpactNodeDTO `json:"upstream"`
+	Downstream []*ImpactNodeDTO `json:"downstream"`
+}
+
+// RelationshipApplication provides application services for asset relationships
+type RelationshipApplication struct {
+	relationshipService *service.RelationshipService
+}
+
+// NewRelationshipApplication creates a new relationship application service
+func NewRelationshipApplication(relationshipService *service.RelationshipService) *RelationshipApplication {
+	return &RelationshipApplication{
+		relationshipService: relationshipService,
+	}
+}
+
+// GetAssetRelationships gets all relationships of an asset
+func (a *RelationshipApplication) GetAssetRelationships(ctx context.Context, assetID string) ([]*RelationshipDTO, error) {
+	objectID, err := primitive.ObjectIDFromHex(assetID)
+	if err != nil {
+		return nil, err
+	}
+
+	relationships, err := a.relationshipService.GetAssetRelationships(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	dtos := make([]*RelationshipDTO, len(relationships))
+	for i, relationship := range relationships {
+		dtos[i] = mapRelationshipToDTO(relationship, objectID)
+	}
+
+	return dtos, nil
+}
+
+// CreateRelationship creates a relationship from an asset to another asset
+func (a *RelationshipApplication) CreateRelationship(ctx context.Context, assetID string, createDTO RelationshipCreateDTO) (*RelationshipDTO, error) {
+	sourceID, err := primitive.ObjectIDFromHex(assetID)
+	if err != nil {
+		return nil, err
+	}
+
+	targetID, err := primitive.ObjectIDFromHex(createDTO.TargetID)
+	if err != nil {
+		return nil, err
+	}
+
+	relationship, err := a.relationshipService.CreateRelationship(ctx, sourceID, targetID, createDTO.Type, createDTO.Description, createDTO.CreatedBy, createDTO.CreatedByID)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapRelationshipToDTO(relationship, sourceID), nil
+}
+
+// DeleteRelationship deletes a relationship of an asset
+func (a *RelationshipApplication) DeleteRelationship(ctx context.Context, assetID, relationshipID string) error {
+	assetObjectID, err := primitive.ObjectIDFromHex(assetID)
+	if err != nil {
+		return err
+	}
+
+	relationshipObjectID, err := primitive.ObjectIDFromHex(relationshipID)
+	if err != nil {
+		return err
+	}
+
+	return a.relationshipService.DeleteRelationship(ctx, assetObjectID, relationshipObjectID)
+}
+
+// AnalyzeImpact walks upstream and/or downstream dependencies of an asset
+func (a *RelationshipApplication) AnalyzeImpact(ctx context.Context, assetID string, direction string, depth int) (*ImpactAnalysisDTO, error) {
+	objectID, err := primitive.ObjectIDFromHex(assetID)
+	if err != nil {
+		return nil, err
+	}
+
+	analysis, err := a.relationshipService.AnalyzeImpact(ctx, objectID, service.ImpactDirection(direction), depth)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ImpactAnalysisDTO{
+		Asset:      mapAssetToDTO(analysis.Asset),
+		Depth:      analysis.Depth,
+		Upstream:   mapImpactNodesToDTO(analysis.Upstream),
+		Downstream: mapImpactNodesToDTO(analysis.Downstream),
+	}, nil
+}
+
+// GetRelationshipTypes returns all supported relationship types
+func (a *RelationshipApplication) GetRelationshipTypes() []string {
+	types := make([]string, len(model.RelationshipTypes))
+	for i, t := range model.RelationshipTypes {
+		types[i] = string(t)
+	}
+	return types
+}
+
+// Helper function to map a relationship to a DTO from the point of view of an asset
+func mapRelationshipToDTO(relationship *model.Relationship, viewpoint primitive.ObjectID) *RelationshipDTO {
+	direction := "outgoing"
+	if relationship.TargetID == viewpoint {
+		direction = "incoming"
+	}
+
+	return &RelationshipDTO{
+		ID:          relationship.ID.Hex(),
+		SourceID:    relationship.SourceID.Hex(),
+		TargetID:    relationship.TargetID.Hex(),
+		Type:        string(relationship.Type),
+		Direction:   direction,
+		Description: relationship.Description,
+		CreatedBy:   relationship.CreatedBy,
+		CreatedAt:   relationship.CreatedAt,
+	}
+}
+
+// Helper function to map impact nodes to DTOs
+func mapImpactNodesToDTO(nodes []*service.ImpactNode) []*ImpactNodeDTO {
+	dtos := make([]*ImpactNodeDTO, len(nodes))
+	for i, node := range nodes {
+		dtos[i] = &ImpactNodeDTO{
+			Asset:            mapAssetToDTO(node.Asset),
+			Depth:            node.Depth,
+			ParentID:         node.ParentID.Hex(),
+			RelationshipID:   node.Relationship.ID.Hex(),
+			RelationshipType: string(node.Relationship.Type),
+		}
+	}
+	return dtos
+}
@@ -2,6 +2,8 @@ package application
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/phuhao00/cmdb/backend/domain/model"
@@ -11,18 +13,25 @@ import (
 
 // WorkflowDTO represents the data transfer object for workflows
 type WorkflowDTO struct {
-	ID         string    `json:"id"`
-	WorkflowID string    `json:"workflowId"`
-	Type       string    `json:"type"`
-	AssetID    string    `json:"assetId"`
-	AssetName  string    `json:"assetName"`
-	Requester  string    `json:"requester"`
-	Priority   string    `json:"priority"`
-	Status     string    `json:"status"`
-	Reason     string    `json:"reason"`
-	FeishuID   string    `json:"feishuId"`
-	CreatedAt  time.Time `json:"createdAt"`
-	UpdatedAt  time.Time `json:"updatedAt"`
+	ID              string                `json:"id"`
+	WorkflowID      string                `json:"workflowId"`
+	Type            string                `json:"type"`
+	AssetID         string                `json:"assetId"`
+	AssetName       string                `json:"assetName"`
+	Requester       string                `json:"requester"`
+	Priority        string                `json:"priority"`
+	Status          string                `json:"status"`
+	Reason          string                `json:"reason"`
+	ApprovalChannel string                `json:"approvalChannel,omitempty"`
+	ExternalID      string                `json:"externalId,omitempty"`
+	PolicyName      string                `json:"policyName,omitempty"`
+	Stages          []model.ApprovalStage `json:"stages"`
+	CurrentStage    int                   `json:"currentStage"`
+	DueAt           *time.Time            `json:"dueAt,omitempty"`
+	SLABreached     bool                  `json:"slaBreached"`
+	EscalatedAt     *time.Time            `json:"escalatedAt,omitempty"`
+	CreatedAt       time.Time             `json:"createdAt"`
+	UpdatedAt       time.Time             `json:"updatedAt"`
 }
 
 // WorkflowCreateDTO represents the data for creating a workflow
@@ -33,13 +42,14 @@ type WorkflowCreateDTO struct {
 	RequesterID string `json:"requesterId"`
 	Priority    string `json:"priority" binding:"required"`
 	Reason      string `json:"reason" binding:"required"`
+	// ApprovalChannel selects the channel to send the workflow to; empty uses the default
+	ApprovalChannel string `json:"approvalChannel"`
 }
 
-// FeishuWebhookDTO represents the data from a Feishu webhook
-type FeishuWebhookDTO struct {
-	ApprovalCode string `json:"approval_code"`
-	Status       string `json:"status"`
-	WorkflowID   string `json:"workflow_id"`
+// ApprovalChannelsDTO lists the configured approval channels
+type ApprovalChannelsDTO struct {
+	Channels []string `json:"channels"`
+	Default  string   `json:"default"`
 }
 
 // WorkflowFilterDTO represents the filter criteria for workflows
@@ -114,6 +124,10 @@ func (a *WorkflowApplication) GetWorkflows(ctx context.Context, filter WorkflowF
 
 // CreateWorkflow creates a new workflow
 func (a *WorkflowApplication) CreateWorkflow(ctx context.Context, createDTO WorkflowCreateDTO) (*WorkflowDTO, error) {
+	if createDTO.ApprovalChannel != "" && !a.workflowService.HasApprovalChannel(createDTO.ApprovalChannel) {
+		return nil, fmt.Errorf("%w: %s", service.ErrApprovalChannelNotConfigured, createDTO.ApprovalChannel)
+	}
+
 	requesterID := createDTO.RequesterID
 	if requesterID == "" {
 		requesterID = "unknown"
@@ -133,30 +147,33 @@ func (a *WorkflowApplication) CreateWorkflow(ctx context.Context, createDTO Work
 		return nil, err
 	}
 
-	// Submit to Feishu
-	feishuID, err := a.workflowService.SubmitToFeishu(ctx, workflow)
-	if err != nil {
+	// Submit to the approval channel when one is configured
+	if _, err := a.workflowService.SubmitForApproval(ctx, workflow, createDTO.ApprovalChannel); err != nil && !errors.Is(err, service.ErrApprovalChannelNotConfigured) {
 		return nil, err
 	}
 
-	workflow.SetFeishuID(feishuID)
-
 	return mapWorkflowToDTO(workflow), nil
 }
 
-// ApproveWorkflow approves a workflow
-func (a *WorkflowApplication) ApproveWorkflow(ctx context.Context, workflowID, approverID, approverName string, dto ApproveWorkflowDTO) error {
-	return a.workflowService.ApproveWorkflow(ctx, workflowID, approverID, approverName, dto.Comments)
+// ApproveWorkflow approves the current approval stage of a workflow
+func (a *WorkflowApplication) ApproveWorkflow(ctx context.Context, workflowID string, user *UserDTO, dto ApproveWorkflowDTO) error {
+	return a.workflowService.ApproveWorkflow(ctx, workflowID, mapUserToApprover(user), dto.Comments)
+}
+
+// RejectWorkflow rejects a workflow at its current approval stage
+func (a *WorkflowApplication) RejectWorkflow(ctx context.Context, workflowID string, user *UserDTO, dto RejectWorkflowDTO) error {
+	return a.workflowService.RejectWorkflow(ctx, workflowID, mapUserToApprover(user), dto.Comments)
 }
 
-// RejectWorkflow rejects a workflow
-func (a *WorkflowApplication) RejectWorkflow(ctx context.Context, workflowID, approverID, approverName string, dto RejectWorkflowDTO) error {
-	return a.workflowService.RejectWorkflow(ctx, workflowID, approverID, approverName, dto.Comments)
+// HandleApprovalCallback handles a callback from an approval channel
+func (a *WorkflowApplication) HandleApprovalCallback(ctx context.Context, channel string, req *service.ApprovalCallbackRequest) (*service.ApprovalCallback, error) {
+	return a.workflowService.HandleApprovalCallback(ctx, channel, req)
 }
 
-// HandleFeishuWebhook handles a webhook from Feishu
-func (a *WorkflowApplication) HandleFeishuWebhook(ctx context.Context, webhookDTO FeishuWebhookDTO) error {
-	return a.workflowService.HandleFeishuWebhook(ctx, webhookDTO.WorkflowID, webhookDTO.Status)
+// GetApprovalChannels gets the configured approval channels
+func (a *WorkflowApplication) GetApprovalChannels() *ApprovalChannelsDTO {
+	channels, defaultChannel := a.workflowService.GetApprovalChannels()
+	return &ApprovalChannelsDTO{Channels: channels, Default: defaultChannel}
 }
 
 // GetWorkflowStats gets workflow statistics
@@ -164,6 +181,11 @@ func (a *WorkflowApplication) GetWorkflowStats(ctx context.Context) (map[string]
 	return a.workflowService.GetWorkflowStats(ctx)
 }
 
+// GetWorkflowSLAStats gets workflow SLA statistics
+func (a *WorkflowApplication) GetWorkflowSLAStats(ctx context.Context) (*model.WorkflowSLAStats, error) {
+	return a.workflowService.GetWorkflowSLAStats(ctx)
+}
+
 // GetWorkflowTypeStats gets workflow type statistics
 func (a *WorkflowApplication) GetWorkflowTypeStats(ctx context.Context) (map[string]int64, error) {
 	return a.workflowService.GetWorkflowTypeStats(ctx)
@@ -218,17 +240,34 @@ func (a *WorkflowApplication) GetUserWorkflows(ctx context.Context, userID strin
 // Helper function to map a workflow to a DTO
 func mapWorkflowToDTO(workflow *model.Workflow) *WorkflowDTO {
 	return &WorkflowDTO{
-		ID:         workflow.ID.Hex(),
-		WorkflowID: workflow.WorkflowID,
-		Type:       string(workflow.Type),
-		AssetID:    workflow.AssetID,
-		AssetName:  workflow.AssetName,
-		Requester:  workflow.Requester,
-		Priority:   string(workflow.Priority),
-		Status:     string(workflow.Status),
-		Reason:     workflow.Reason,
-		FeishuID:   workflow.FeishuID,
-		CreatedAt:  workflow.CreatedAt,
-		UpdatedAt:  workflow.UpdatedAt,
+		ID:              workflow.ID.Hex(),
+		WorkflowID:      workflow.WorkflowID,
+		Type:            string(workflow.Type),
+		AssetID:         workflow.AssetID,
+		AssetName:       workflow.AssetName,
+		Requester:       workflow.Requester,
+		Priority:        string(workflow.Priority),
+		Status:          string(workflow.Status),
+		Reason:          workflow.Reason,
+		ApprovalChannel: workflow.ApprovalChannel,
+		ExternalID:      workflow.ExternalID,
+		PolicyName:      workflow.PolicyName,
+		Stages:          workflow.Stages,
+		CurrentStage:    workflow.CurrentStage,
+		DueAt:           workflow.DueAt,
+		SLABreached:     workflow.SLABreached,
+		EscalatedAt:     workflow.EscalatedAt,
+		CreatedAt:       workflow.CreatedAt,
+		UpdatedAt:       workflow.UpdatedAt,
+	}
+}
+
+// mapUserToApprover maps the authenticated user to a stage approver
+func mapUserToApprover(user *UserDTO) service.Approver {
+	return service.Approver{
+		ID:       user.ID,
+		Username: user.Username,
+		Name:     user.FullName,
+		Role:     model.UserRole(user.Role),
 	}
 }
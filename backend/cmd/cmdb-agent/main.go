@@ -0,0 +1,93 @@
+// Command cmdb-agent reports the inventory of the host it runs on to the CMDB.
+//
+// It collects the hostname, OS, kernel, CPU, memory, disks, network interfaces,
+// installed packages and listening ports, and pushes them to POST /api/agent/facts
+// with an agent token created by an administrator. The server merges the facts
+// into the asset with the host's machine ID.
+//
+//	cmdb-agent -server http://cmdb:8080 -token cmdb_... -interval 1h
+//
+// CMDB_SERVER and CMDB_AGENT_TOKEN may be used instead of the flags. -print writes
+// the collected facts to stdout without contacting the server.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/infrastructure/hostfacts"
+)
+
+func main() {
+	server := flag.String("server", os.Getenv("CMDB_SERVER"), "CMDB server URL")
+	token := flag.String("token", os.Getenv("CMDB_AGENT_TOKEN"), "agent token")
+	interval := flag.Duration("interval", time.Hour, "time between reports")
+	once := flag.Bool("once", false, "send one report and exit")
+	printOnly := flag.Bool("print", false, "print the collected facts and exit")
+	assetType := flag.String("type", "", "asset type if the report creates a new asset (default server)")
+	location := flag.String("location", "", "location if the report creates a new asset")
+	stateFile := flag.String("state", "/var/lib/cmdb-agent/machine-id", "where to keep a generated machine ID")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	collector := hostfacts.NewCollector(*stateFile)
+
+	if *printOnly {
+		facts, err := collector.Collect(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(facts); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *server == "" || *token == "" {
+		log.Fatal("-server and -token (or CMDB_SERVER and CMDB_AGENT_TOKEN) are required")
+	}
+	client := hostfacts.NewClient(*server, *token)
+
+	report := func() error {
+		facts, err := collector.Collect(ctx)
+		if err != nil {
+			return err
+		}
+		result, err := client.Send(ctx, hostfacts.Report{Facts: facts, AssetType: *assetType, Location: *location})
+		if err != nil {
+			return err
+		}
+		log.Printf("reported facts for machine %s to asset %s (%s)", facts.MachineID, result.AssetID, result.Name)
+		return nil
+	}
+
+	if *once {
+		if err := report(); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		if err := report(); err != nil {
+			log.Printf("report failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,33 @@
+// Command feishu-stub runs a local stand-in for the Feishu approval API.
+//
+// Point the backend at it with FEISHU_BASE_URL and use the same FEISHU_* credentials
+// for both processes. POST /stub/decide {"instanceCode": "...", "status": "APPROVED"}
+// delivers a signed callback to FEISHU_STUB_CALLBACK_URL.
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/phuhao00/cmdb/backend/infrastructure/feishu"
+)
+
+func main() {
+	callbackURL := os.Getenv("FEISHU_STUB_CALLBACK_URL")
+	if callbackURL == "" {
+		callbackURL = "http://localhost:8080/api/feishu/webhook"
+	}
+
+	addr := os.Getenv("FEISHU_STUB_ADDR")
+	if addr == "" {
+		addr = ":9090"
+	}
+
+	stub := feishu.NewStubServer(feishu.ConfigFromEnv(), callbackURL)
+
+	log.Printf("feishu stub listening on %s, callbacks to %s", addr, callbackURL)
+	if err := http.ListenAndServe(addr, stub); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AgentToken authenticates inventory agents pushing host facts. Only a hash of the secret is
+// stored; the secret itself is shown once when the token is created.
+type AgentToken struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name       string             `json:"name" bson:"name"`
+	TokenHash  string             `json:"-" bson:"tokenHash"`
+	Prefix     string             `json:"prefix" bson:"prefix"`
+	CreatedBy  string             `json:"createdBy" bson:"createdBy"`
+	CreatedAt  time.Time          `json:"createdAt" bson:"createdAt"`
+	LastUsedAt *time.Time         `json:"lastUsedAt,omitempty" bson:"lastUsedAt,omitempty"`
+	RevokedAt  *time.Time         `json:"revokedAt,omitempty" bson:"revokedAt,omitempty"`
+}
+
+// NewAgentToken creates a new agent token for a secret
+func NewAgentToken(name, secret, createdBy string) *AgentToken {
+	prefix := secret
+	if len(prefix) > 12 {
+		prefix = prefix[:12]
+	}
+	return &AgentToken{
+		Name:      name,
+		TokenHash: HashAgentToken(secret),
+		Prefix:    prefix,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now(),
+	}
+}
+
+// HashAgentToken hashes an agent token secret for storage and lookup
+func HashAgentToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsRevoked checks if the token has been revoked
+func (t *AgentToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
+
+// Revoke revokes the token
+func (t *AgentToken) Revoke() {
+	now := time.Now()
+	t.RevokedAt = &now
+}
+
+// MarkUsed records that the token authenticated a request
+func (t *AgentToken) MarkUsed() {
+	now := time.Now()
+	t.LastUsedAt = &now
+}
@@ -38,11 +38,20 @@ const (
 	AlertTypePerformance    AlertType = "performance"
 	AlertTypeSecurityUpdate AlertType = "security_update"
 	AlertTypeCustom         AlertType = "custom"
+
+	// Rule operators
+	OperatorGT  = "gt"
+	OperatorLT  = "lt"
+	OperatorEQ  = "eq"
+	OperatorNE  = "ne"
+	OperatorGTE = "gte"
+	OperatorLTE = "lte"
 )
 
 // Alert represents an alert for an asset
 type Alert struct {
 	ID               primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	RuleID           primitive.ObjectID `json:"ruleId,omitempty" bson:"ruleId,omitempty"`
 	AssetID          primitive.ObjectID `json:"assetId" bson:"assetId"`
 	AssetName        string             `json:"assetName" bson:"assetName"`
 	Type             AlertType          `json:"type" bson:"type"`
@@ -137,6 +146,21 @@ func (a *Alert) IsActive() bool {
 	return a.Status == AlertStatusActive
 }
 
+// IsOpen checks if the alert still needs attention (active or acknowledged)
+func (a *Alert) IsOpen() bool {
+	return a.Status == AlertStatusActive || a.Status == AlertStatusAcknowledged
+}
+
+// IsResolved checks if the alert is resolved
+func (a *Alert) IsResolved() bool {
+	return a.Status == AlertStatusResolved
+}
+
+// IsClosed checks if the alert is closed
+func (a *Alert) IsClosed() bool {
+	return a.Status == AlertStatusClosed
+}
+
 // GetSeverityWeight returns numeric weight for severity (for sorting)
 func (a *Alert) GetSeverityWeight() int {
 	switch a.Severity {
@@ -180,6 +204,16 @@ func (r *AlertRule) SetCondition(field, operator string, threshold interface{})
 	r.UpdatedAt = time.Now()
 }
 
+// IsValidOperator checks if the operator is one of the supported rule operators
+func IsValidOperator(operator string) bool {
+	switch operator {
+	case OperatorGT, OperatorLT, OperatorEQ, OperatorNE, OperatorGTE, OperatorLTE:
+		return true
+	default:
+		return false
+	}
+}
+
 // formatThreshold formats the threshold value for display
 func formatThreshold(threshold interface{}) string {
 	switch v := threshold.(type) {
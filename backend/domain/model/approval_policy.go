@@ -0,0 +1,189 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ApproverType describes how the approvers of a stage are chosen
+type ApproverType string
+
+// Approver type constants
+const (
+	// ApproverTypeRole lets any user holding one of the stage roles decide
+	ApproverTypeRole ApproverType = "role"
+	// ApproverTypeUser lets one of the listed usernames decide
+	ApproverTypeUser ApproverType = "user"
+	// ApproverTypeOwnerManager resolves to the manager of the asset owner when the workflow is created
+	ApproverTypeOwnerManager ApproverType = "owner_manager"
+)
+
+// ApprovalStageDefinition describes one stage of an approval policy
+type ApprovalStageDefinition struct {
+	Name         string       `json:"name" bson:"name"`
+	ApproverType ApproverType `json:"approverType" bson:"approverType"`
+	Roles        []UserRole   `json:"roles,omitempty" bson:"roles,omitempty"`
+	Users        []string     `json:"users,omitempty" bson:"users,omitempty"`
+}
+
+// ApprovalPolicy defines the ordered approval stages for workflows of a type and priority
+type ApprovalPolicy struct {
+	ID           primitive.ObjectID        `json:"id" bson:"_id,omitempty"`
+	Name         string                    `json:"name" bson:"name"`
+	Description  string                    `json:"description" bson:"description"`
+	WorkflowType WorkflowType              `json:"workflowType,omitempty" bson:"workflowType,omitempty"`
+	Priorities   []WorkflowPriority        `json:"priorities,omitempty" bson:"priorities,omitempty"`
+	Stages       []ApprovalStageDefinition `json:"stages" bson:"stages"`
+	Enabled      bool                      `json:"enabled" bson:"enabled"`
+	CreatedAt    time.Time                 `json:"createdAt" bson:"createdAt"`
+	UpdatedAt    time.Time                 `json:"updatedAt" bson:"updatedAt"`
+}
+
+// NewApprovalPolicy creates a new enabled approval policy
+func NewApprovalPolicy(name, description string, workflowType WorkflowType, priorities []WorkflowPriority, stages []ApprovalStageDefinition) *ApprovalPolicy {
+	now := time.Now()
+	return &ApprovalPolicy{
+		Name:         name,
+		Description:  description,
+		WorkflowType: workflowType,
+		Priorities:   priorities,
+		Stages:       stages,
+		Enabled:      true,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+}
+
+// Matches checks whether the policy applies to a workflow type and priority.
+// An empty type or priority list matches anything.
+func (p *ApprovalPolicy) Matches(workflowType WorkflowType, priority WorkflowPriority) bool {
+	if !p.Enabled {
+		return false
+	}
+
+	if p.WorkflowType != "" && p.WorkflowType != workflowType {
+		return false
+	}
+
+	if len(p.Priorities) == 0 {
+		return true
+	}
+	for _, candidate := range p.Priorities {
+		if candidate == priority {
+			return true
+		}
+	}
+	return false
+}
+
+// Specificity ranks matching policies; a type match outweighs a priority match
+func (p *ApprovalPolicy) Specificity() int {
+	score := 0
+	if p.WorkflowType != "" {
+		score += 2
+	}
+	if len(p.Priorities) > 0 {
+		score++
+	}
+	return score
+}
+
+// IsValid checks if the approver type is known
+func (t ApproverType) IsValid() bool {
+	switch t {
+	case ApproverTypeRole, ApproverTypeUser, ApproverTypeOwnerManager:
+		return true
+	}
+	return false
+}
+
+// ApprovalStage is a stage of a workflow's approval chain together with its outcome
+type ApprovalStage struct {
+	Name         string         `json:"name" bson:"name"`
+	ApproverType ApproverType   `json:"approverType" bson:"approverType"`
+	Roles        []UserRole     `json:"roles,omitempty" bson:"roles,omitempty"`
+	Users        []string       `json:"users,omitempty" bson:"users,omitempty"`
+	BackupUsers  []string       `json:"backupUsers,omitempty" bson:"backupUsers,omitempty"`
+	BackupRoles  []UserRole     `json:"backupRoles,omitempty" bson:"backupRoles,omitempty"`
+	Status       WorkflowStatus `json:"status" bson:"status"`
+	ApproverID   string         `json:"approverId,omitempty" bson:"approverId,omitempty"`
+	ApproverName string         `json:"approverName,omitempty" bson:"approverName,omitempty"`
+	Comments     string         `json:"comments,omitempty" bson:"comments,omitempty"`
+	DecidedAt    *time.Time     `json:"decidedAt,omitempty" bson:"decidedAt,omitempty"`
+}
+
+// DefaultApprovalStage is used when no policy matches: a single decision by an admin or manager
+func DefaultApprovalStage() ApprovalStage {
+	return ApprovalStage{
+		Name:         "Approval",
+		ApproverType: ApproverTypeRole,
+		Roles:        []UserRole{AdminRole, ManagerRole},
+		Status:       PendingStatus,
+	}
+}
+
+// CanBeDecidedBy checks whether a user may decide this stage. Named users take
+// precedence over roles; admins and backup approvers of an escalated stage may always decide.
+func (s *ApprovalStage) CanBeDecidedBy(username string, role UserRole) bool {
+	if role == AdminRole {
+		return true
+	}
+	return s.IsApprover(username, role)
+}
+
+// IsApprover checks whether a user is one of the approvers the stage asks for: its named
+// users or, without any, its roles, and the backup approvers once it was escalated
+func (s *ApprovalStage) IsApprover(username string, role UserRole) bool {
+	if containsString(s.BackupUsers, username) || containsRole(s.BackupRoles, role) {
+		return true
+	}
+
+	if len(s.Users) > 0 {
+		return containsString(s.Users, username)
+	}
+
+	return containsRole(s.Roles, role)
+}
+
+// decide records the stage outcome
+func (s *ApprovalStage) decide(status WorkflowStatus, approverID, approverName, comments string) {
+	now := time.Now()
+	s.Status = status
+	s.ApproverID = approverID
+	s.ApproverName = approverName
+	s.Comments = comments
+	s.DecidedAt = &now
+}
+
+// addBackupApprovers adds approvers who may decide the stage after it was escalated
+func (s *ApprovalStage) addBackupApprovers(users []string, roles []UserRole) {
+	for _, user := range users {
+		if !containsString(s.BackupUsers, user) {
+			s.BackupUsers = append(s.BackupUsers, user)
+		}
+	}
+	for _, role := range roles {
+		if !containsRole(s.BackupRoles, role) {
+			s.BackupRoles = append(s.BackupRoles, role)
+		}
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsRole(roles []UserRole, role UserRole) bool {
+	for _, candidate := range roles {
+		if candidate == role {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,8 @@
 package model
 
 import (
+	"maps"
+	"slices"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -21,10 +23,16 @@ const (
 	WorkstationType AssetType = "workstation"
 
 	// Asset Statuses
+	OrderedStatus        AssetStatus = "ordered"
+	ReceivedStatus       AssetStatus = "received"
+	InStockStatus        AssetStatus = "in_stock"
+	DeployedStatus       AssetStatus = "deployed"
 	OnlineStatus         AssetStatus = "online"
 	OfflineStatus        AssetStatus = "offline"
 	MaintenanceStatus    AssetStatus = "maintenance"
+	RetiredStatus        AssetStatus = "retired"
 	DecommissionedStatus AssetStatus = "decommissioned"
+	DisposedStatus       AssetStatus = "disposed"
 )
 
 // Asset represents an IT asset in the CMDB domain
@@ -46,8 +54,52 @@ type Asset struct {
 	Owner       string    `json:"owner" bson:"owner"`
 	LastScanned time.Time `json:"lastScanned" bson:"lastScanned"`
 	IPAddress   string    `json:"ipAddress" bson:"ipAddress"`
-	CreatedAt   time.Time `json:"createdAt" bson:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt" bson:"updatedAt"`
+	// Hostname and MACAddress identify the asset to network discovery
+	Hostname   string `json:"hostname,omitempty" bson:"hostname,omitempty"`
+	MACAddress string `json:"macAddress,omitempty" bson:"macAddress,omitempty"`
+	// OSGuess and Services are the operating system and open services found by imported scans
+	OSGuess  string              `json:"osGuess,omitempty" bson:"osGuess,omitempty"`
+	Services []DiscoveredService `json:"services,omitempty" bson:"services,omitempty"`
+	// ExternalID identifies the asset in an external system it is synced from, e.g. Kubernetes
+	ExternalID string `json:"externalId,omitempty" bson:"externalId,omitempty"`
+	// MachineID is the stable ID reported by the inventory agent running on the asset
+	MachineID string `json:"machineId,omitempty" bson:"machineId,omitempty"`
+	// Facts is the latest host inventory reported by the agent
+	Facts *HostFacts `json:"facts,omitempty" bson:"facts,omitempty"`
+	// Provenance records which source last set each automatically maintained field
+	Provenance map[string]FieldSource `json:"provenance,omitempty" bson:"provenance,omitempty"`
+	// Attributes holds the values of the attributes defined by the asset's CI type
+	Attributes map[string]interface{} `json:"attributes,omitempty" bson:"attributes,omitempty"`
+	// Disposal records how the asset left the organisation; required to dispose of it
+	Disposal  *DisposalRecord `json:"disposal,omitempty" bson:"disposal,omitempty"`
+	CreatedAt time.Time       `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time       `json:"updatedAt" bson:"updatedAt"`
+}
+
+// DisposalRecord documents the disposal of an asset
+type DisposalRecord struct {
+	Method     string    `json:"method" bson:"method"`
+	Reference  string    `json:"reference,omitempty" bson:"reference,omitempty"`
+	DisposedBy string    `json:"disposedBy" bson:"disposedBy"`
+	DisposedAt time.Time `json:"disposedAt" bson:"disposedAt"`
+}
+
+// Sources of automatically maintained asset fields
+const (
+	SourceAgent      = "agent"
+	SourceDiscovery  = "discovery"
+	SourceImport     = "import"
+	SourceKubernetes = "kubernetes"
+	SourceCloud      = "cloud"
+	// SourceManual marks values entered by users; fields without provenance count as manual
+	SourceManual = "manual"
+)
+
+// FieldSource records where the current value of an asset field came from
+type FieldSource struct {
+	Source     string    `json:"source" bson:"source"`
+	Reporter   string    `json:"reporter,omitempty" bson:"reporter,omitempty"`
+	ReportedAt time.Time `json:"reportedAt" bson:"reportedAt"`
 }
 
 // NewAsset creates a new asset with default values
@@ -71,6 +123,12 @@ func (a *Asset) SetStatus(status AssetStatus) {
 	a.UpdatedAt = time.Now()
 }
 
+// SetDisposal records how the asset was disposed of
+func (a *Asset) SetDisposal(disposal *DisposalRecord) {
+	a.Disposal = disposal
+	a.UpdatedAt = time.Now()
+}
+
 // Update updates the asset fields and updates the UpdatedAt timestamp
 func (a *Asset) Update(name string, location string, description string) {
 	a.Name = name
@@ -87,6 +145,25 @@ func (a *Asset) UpdateCosts(purchasePrice float64, annualCost float64, currency
 	a.UpdatedAt = time.Now()
 }
 
+// SetFieldSource records the source of a field's current value
+func (a *Asset) SetFieldSource(field, source, reporter string) {
+	if a.Provenance == nil {
+		a.Provenance = make(map[string]FieldSource)
+	}
+	a.Provenance[field] = FieldSource{Source: source, Reporter: reporter, ReportedAt: time.Now()}
+}
+
+// Clone copies the asset, including its tags, attributes and provenance, so that changes to
+// the copy do not affect the original
+func (a *Asset) Clone() *Asset {
+	clone := *a
+	clone.Tags = slices.Clone(a.Tags)
+	clone.Services = slices.Clone(a.Services)
+	clone.Attributes = maps.Clone(a.Attributes)
+	clone.Provenance = maps.Clone(a.Provenance)
+	return &clone
+}
+
 // IsDecommissioned checks if the asset is decommissioned
 func (a *Asset) IsDecommissioned() bool {
 	return a.Status == DecommissionedStatus
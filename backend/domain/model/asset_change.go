@@ -0,0 +1,108 @@
+package model
+
+import "context"
+
+// SystemActor makes the changes no user, agent or source system is known for
+var SystemActor = Actor{ID: "system", Name: "System User"}
+
+// Actor identifies who changes an asset: a user, an inventory agent or a system assets are
+// synced from
+type Actor struct {
+	ID   string
+	Name string
+}
+
+// AssetChange describes the asset writes made with a context, for the history records written
+// with them
+type AssetChange struct {
+	// Type is one of the ChangeType constants. New assets are always recorded as created and
+	// deleted ones as deleted; other writes default to an update.
+	Type   string
+	Reason string
+	// The workflow the change was approved in, if any
+	WorkflowID   string
+	ApprovedBy   string
+	ApprovedByID string
+	// RevertedTo is the ID of the history record a revert restores
+	RevertedTo string
+}
+
+type actorKey struct{}
+
+type assetChangeKey struct{}
+
+// WithActor returns a context whose asset writes are recorded as made by the actor
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor of a context, or SystemActor when it has none
+func ActorFromContext(ctx context.Context) Actor {
+	if actor, ok := ctx.Value(actorKey{}).(Actor); ok {
+		return actor
+	}
+	return SystemActor
+}
+
+// WithAssetChange returns a context whose asset writes are recorded as the change
+func WithAssetChange(ctx context.Context, change AssetChange) context.Context {
+	return context.WithValue(ctx, assetChangeKey{}, change)
+}
+
+// AssetChangeFromContext returns the change of a context
+func AssetChangeFromContext(ctx context.Context) AssetChange {
+	change, _ := ctx.Value(assetChangeKey{}).(AssetChange)
+	return change
+}
+
+// NewAssetChangeHistory records the write of an asset made with a context. oldAsset is the
+// asset as stored before, or nil for a new asset. Besides the fields CompareAssets compares,
+// the host facts are compared report by report.
+func NewAssetChangeHistory(ctx context.Context, oldAsset, asset *Asset) *AssetHistory {
+	change := AssetChangeFromContext(ctx)
+	changeType := change.Type
+	switch {
+	case oldAsset == nil:
+		changeType = ChangeTypeCreate
+		oldAsset = &Asset{}
+	case changeType == "" || changeType == ChangeTypeCreate:
+		changeType = ChangeTypeUpdate
+	}
+
+	history := newChangeHistory(ctx, asset, changeType, change)
+	history.CompareAssets(oldAsset, asset)
+	switch {
+	case oldAsset.Facts == nil && asset.Facts != nil:
+		history.AddFieldChange("facts", nil, asset.Facts.CollectedAt)
+	case oldAsset.Facts != nil && asset.Facts == nil:
+		history.AddFieldChange("facts", oldAsset.Facts.CollectedAt, nil)
+	case oldAsset.Facts != nil:
+		history.FieldChanges = append(history.FieldChanges, DiffHostFacts(oldAsset.Facts, asset.Facts)...)
+	}
+	return history
+}
+
+// NewAssetDeleteHistory records the deletion of an asset made with a context, keeping its last
+// identifying values
+func NewAssetDeleteHistory(ctx context.Context, asset *Asset) *AssetHistory {
+	history := newChangeHistory(ctx, asset, ChangeTypeDelete, AssetChangeFromContext(ctx))
+	history.OldValues = map[string]interface{}{
+		"assetId":  asset.AssetID,
+		"name":     asset.Name,
+		"type":     string(asset.Type),
+		"status":   string(asset.Status),
+		"location": asset.Location,
+	}
+	return history
+}
+
+// newChangeHistory creates a history record attributed to the actor of a context
+func newChangeHistory(ctx context.Context, asset *Asset, changeType string, change AssetChange) *AssetHistory {
+	actor := ActorFromContext(ctx)
+	history := NewAssetHistory(asset.ID, asset.Name, changeType, actor.Name, actor.ID, change.Reason)
+	if change.WorkflowID != "" {
+		history.SetApproval(change.WorkflowID, change.ApprovedBy, change.ApprovedByID)
+	}
+	history.RevertedTo = change.RevertedTo
+	return history
+}
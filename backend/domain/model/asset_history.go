@@ -1,6 +1,8 @@
 package model
 
 import (
+	"fmt"
+	"sort"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -14,11 +16,18 @@ type AssetHistory struct {
 	ChangeType    string                 `json:"changeType" bson:"changeType"`
 	ChangedBy     string                 `json:"changedBy" bson:"changedBy"`
 	ChangedByID   string                 `json:"changedById" bson:"changedById"`
+	ApprovedBy    string                 `json:"approvedBy,omitempty" bson:"approvedBy,omitempty"`
+	ApprovedByID  string                 `json:"approvedById,omitempty" bson:"approvedById,omitempty"`
+	WorkflowID    string                 `json:"workflowId,omitempty" bson:"workflowId,omitempty"`
 	FieldChanges  []FieldChange          `json:"fieldChanges" bson:"fieldChanges"`
 	OldValues     map[string]interface{} `json:"oldValues" bson:"oldValues"`
 	NewValues     map[string]interface{} `json:"newValues" bson:"newValues"`
 	ChangeReason  string                 `json:"changeReason" bson:"changeReason"`
 	Timestamp     time.Time              `json:"timestamp" bson:"timestamp"`
+	// MergedFrom is the asset ID of the asset the record was made for, when that asset was merged into this one
+	MergedFrom    string                 `json:"mergedFrom,omitempty" bson:"mergedFrom,omitempty"`
+	// RevertedTo is the ID of the history record a revert restored the asset to
+	RevertedTo    string                 `json:"revertedTo,omitempty" bson:"revertedTo,omitempty"`
 }
 
 // FieldChange represents a single field change
@@ -38,6 +47,9 @@ const (
 	ChangeTypeTagsUpdate     = "tags_update"
 	ChangeTypeCostUpdate     = "cost_update"
 	ChangeTypeDelete         = "delete"
+	ChangeTypeFactsUpdate    = "facts_update"
+	ChangeTypeMerge          = "merge"
+	ChangeTypeRevert         = "revert"
 )
 
 // NewAssetHistory creates a new asset history record
@@ -57,6 +69,13 @@ func NewAssetHistory(assetID primitive.ObjectID, assetName, changeType, changedB
 	}
 }
 
+// SetApproval attributes the change to the workflow that approved it
+func (h *AssetHistory) SetApproval(workflowID, approvedBy, approvedByID string) {
+	h.WorkflowID = workflowID
+	h.ApprovedBy = approvedBy
+	h.ApprovedByID = approvedByID
+}
+
 // AddFieldChange adds a field change to the history record
 func (h *AssetHistory) AddFieldChange(fieldName string, oldValue, newValue interface{}) {
 	h.FieldChanges = append(h.FieldChanges, FieldChange{
@@ -95,6 +114,24 @@ func (h *AssetHistory) CompareAssets(oldAsset, newAsset *Asset) {
 	if oldAsset.IPAddress != newAsset.IPAddress {
 		h.AddFieldChange("ipAddress", oldAsset.IPAddress, newAsset.IPAddress)
 	}
+	if oldAsset.Hostname != newAsset.Hostname {
+		h.AddFieldChange("hostname", oldAsset.Hostname, newAsset.Hostname)
+	}
+	if oldAsset.MACAddress != newAsset.MACAddress {
+		h.AddFieldChange("macAddress", oldAsset.MACAddress, newAsset.MACAddress)
+	}
+	if oldAsset.MachineID != newAsset.MachineID {
+		h.AddFieldChange("machineId", oldAsset.MachineID, newAsset.MachineID)
+	}
+	if oldAsset.ExternalID != newAsset.ExternalID {
+		h.AddFieldChange("externalId", oldAsset.ExternalID, newAsset.ExternalID)
+	}
+	if oldAsset.OSGuess != newAsset.OSGuess {
+		h.AddFieldChange("osGuess", oldAsset.OSGuess, newAsset.OSGuess)
+	}
+	if oldServices, newServices := serviceList(oldAsset.Services), serviceList(newAsset.Services); !equalStringSlices(oldServices, newServices) {
+		h.AddFieldChange("services", oldServices, newServices)
+	}
 	
 	// Compare cost fields
 	if oldAsset.PurchasePrice != newAsset.PurchasePrice {
@@ -111,6 +148,36 @@ func (h *AssetHistory) CompareAssets(oldAsset, newAsset *Asset) {
 	if !equalStringSlices(oldAsset.Tags, newAsset.Tags) {
 		h.AddFieldChange("tags", oldAsset.Tags, newAsset.Tags)
 	}
+
+	// Compare CI type attributes one by one
+	names := make(map[string]bool)
+	for name := range oldAsset.Attributes {
+		names[name] = true
+	}
+	for name := range newAsset.Attributes {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	for _, name := range sorted {
+		oldValue, newValue := oldAsset.Attributes[name], newAsset.Attributes[name]
+		// Compare printed values so that numbers decoded as different integer types are equal
+		if fmt.Sprint(oldValue) != fmt.Sprint(newValue) {
+			h.AddFieldChange("attributes."+name, oldValue, newValue)
+		}
+	}
+}
+
+// serviceList describes services as "port/name" strings
+func serviceList(services []DiscoveredService) []string {
+	list := make([]string, len(services))
+	for i, svc := range services {
+		list[i] = fmt.Sprintf("%d/%s", svc.Port, svc.Name)
+	}
+	return list
 }
 
 // equalStringSlices compares two string slices for equality
@@ -145,6 +212,12 @@ func (h *AssetHistory) GetChangeDescription() string {
 		return "成本信息更新"
 	case ChangeTypeDelete:
 		return "资产删除"
+	case ChangeTypeFactsUpdate:
+		return "主机信息更新"
+	case ChangeTypeMerge:
+		return "资产合并"
+	case ChangeTypeRevert:
+		return "资产回滚"
 	default:
 		return "其他变更"
 	}
@@ -0,0 +1,75 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidRevision is returned when a revision cannot be reverted to because it does not
+// describe the asset
+var ErrInvalidRevision = errors.New("revision does not describe this asset")
+
+// ErrNothingToRevert is returned when an asset already matches the revision
+var ErrNothingToRevert = errors.New("asset already matches the revision")
+
+// ErrRevertOutdated is returned when an asset changed after a revert was requested
+var ErrRevertOutdated = errors.New("asset was changed after the revert was requested")
+
+// RevertPreview shows what reverting an asset to a history revision would change
+type RevertPreview struct {
+	Revision *AssetHistory `json:"revision"`
+	// State is the asset as it was right after the revision
+	State *AssetState `json:"state"`
+	// Changes lists each field with its current value and the value it is reverted to
+	Changes []FieldChange `json:"changes"`
+}
+
+// NewRevertPreview compares an asset with its state at a revision
+func NewRevertPreview(current *Asset, revision *AssetHistory, state *AssetState) *RevertPreview {
+	return &RevertPreview{
+		Revision: revision,
+		State:    state,
+		Changes:  DiffAssets(current, state.Asset),
+	}
+}
+
+// AssetRevert is the change of a revert workflow
+type AssetRevert struct {
+	RevisionID   string    `json:"revisionId" bson:"revisionId"`
+	RevisionTime time.Time `json:"revisionTime" bson:"revisionTime"`
+	// Changes lists each field with its value when the revert was requested and the value it
+	// is reverted to
+	Changes []FieldChange `json:"changes" bson:"changes"`
+}
+
+// NewAssetRevert captures the changes of a revert preview
+func NewAssetRevert(preview *RevertPreview) AssetRevert {
+	return AssetRevert{
+		RevisionID:   preview.Revision.ID.Hex(),
+		RevisionTime: preview.Revision.Timestamp,
+		Changes:      preview.Changes,
+	}
+}
+
+// Apply sets the fields of an asset to the values of the revision, unless the asset changed
+// after the revert was requested
+func (r *AssetRevert) Apply(asset *Asset) error {
+	requested := asset.Clone()
+	for _, change := range r.Changes {
+		if !requested.restoreHistoryValue(change.FieldName, change.OldValue) {
+			return fmt.Errorf("cannot revert %s", change.FieldName)
+		}
+	}
+	if len(DiffAssets(asset, requested)) > 0 {
+		return ErrRevertOutdated
+	}
+
+	for _, change := range r.Changes {
+		if !asset.restoreHistoryValue(change.FieldName, change.NewValue) {
+			return fmt.Errorf("cannot revert %s", change.FieldName)
+		}
+	}
+	asset.UpdatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,173 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrAssetNotYetCreated is returned when an asset's state is requested for a time before it
+// was created
+var ErrAssetNotYetCreated = errors.New("asset did not exist at that time")
+
+// AssetState is an asset as it was at a point in time, reconstructed from its history
+type AssetState struct {
+	Asset *Asset    `json:"asset"`
+	AsOf  time.Time `json:"asOf"`
+	// Unrestored lists the fields that changed later but whose earlier value the history does
+	// not record in full. Host facts reported by the agent are then left out of the state.
+	Unrestored []string `json:"unrestored,omitempty"`
+}
+
+// AssetStateDiff lists the changes to an asset between two points in time
+type AssetStateDiff struct {
+	From    *AssetState   `json:"from"`
+	To      *AssetState   `json:"to"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// ReconstructAsset rewinds an asset to a point in time by undoing, newest first, the history
+// records made after it
+func ReconstructAsset(current *Asset, newer []*AssetHistory, asOf time.Time) (*AssetState, error) {
+	if asOf.Before(current.CreatedAt) {
+		return nil, ErrAssetNotYetCreated
+	}
+
+	var undo []*AssetHistory
+	for _, history := range newer {
+		if history.Timestamp.After(asOf) {
+			undo = append(undo, history)
+		}
+	}
+	return RewindAsset(current, undo, asOf)
+}
+
+// RewindAsset undoes history records, given newest first, on a copy of an asset. History moved
+// from a merged asset describes that asset and is skipped; its changes never applied to this one.
+func RewindAsset(current *Asset, undo []*AssetHistory, asOf time.Time) (*AssetState, error) {
+	state := &AssetState{Asset: current.Clone(), AsOf: asOf}
+	for _, history := range undo {
+		if history.MergedFrom != "" {
+			continue
+		}
+		if history.ChangeType == ChangeTypeCreate {
+			return nil, ErrAssetNotYetCreated
+		}
+
+		for i := len(history.FieldChanges) - 1; i >= 0; i-- {
+			change := history.FieldChanges[i]
+			if !state.Asset.restoreHistoryValue(change.FieldName, change.OldValue) {
+				state.unrestored(change.FieldName)
+			}
+		}
+	}
+	return state, nil
+}
+
+// unrestored records a field whose earlier value could not be restored and clears it
+func (s *AssetState) unrestored(field string) {
+	if field == "facts" || strings.HasPrefix(field, "facts.") {
+		field = "facts"
+		s.Asset.Facts = nil
+	}
+	if !slices.Contains(s.Unrestored, field) {
+		s.Unrestored = append(s.Unrestored, field)
+	}
+}
+
+// DiffAssets lists the fields history tracks that differ between two versions of an asset
+func DiffAssets(oldAsset, newAsset *Asset) []FieldChange {
+	history := NewAssetHistory(newAsset.ID, newAsset.Name, ChangeTypeUpdate, "", "", "")
+	history.CompareAssets(oldAsset, newAsset)
+	return history.FieldChanges
+}
+
+// restoreHistoryValue sets a field to a value recorded by CompareAssets, as read back from the
+// history. It reports whether the field could be set.
+func (a *Asset) restoreHistoryValue(field string, value interface{}) bool {
+	switch field {
+	case "type":
+		text, ok := value.(string)
+		if ok {
+			a.Type = AssetType(text)
+		}
+		return ok
+	case "status":
+		text, ok := value.(string)
+		if ok {
+			a.Status = AssetStatus(text)
+		}
+		return ok
+	case "externalId":
+		text, ok := value.(string)
+		if ok {
+			a.ExternalID = text
+		}
+		return ok
+	case "purchasePrice":
+		price, ok := historyNumber(value)
+		if ok {
+			a.PurchasePrice = price
+		}
+		return ok
+	case "services":
+		services, ok := parseServiceList(value)
+		if ok {
+			a.Services = services
+		}
+		return ok
+	}
+
+	if !IsReconciledField(field) {
+		return false
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return false
+	}
+	return a.SetFieldValue(field, data) == nil
+}
+
+// historyNumber reads a number stored in the history, which may come back as any numeric type
+func historyNumber(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case nil:
+		return 0, true
+	}
+	return 0, false
+}
+
+// parseServiceList reads services back from the "port/name" strings of serviceList; banners
+// are not recorded
+func parseServiceList(value interface{}) ([]DiscoveredService, bool) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, false
+	}
+	var entries []string
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, false
+	}
+
+	var services []DiscoveredService
+	for _, entry := range entries {
+		port, name, _ := strings.Cut(entry, "/")
+		number, err := strconv.Atoi(port)
+		if err != nil {
+			return nil, false
+		}
+		services = append(services, DiscoveredService{Port: number, Name: name})
+	}
+	return services, true
+}
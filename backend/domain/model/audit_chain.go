@@ -0,0 +1,195 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrAuditChainBroken is returned when audit logs that are about to be archived fail verification
+var ErrAuditChainBroken = errors.New("audit log chain is broken")
+
+// Problems verification can find in the audit log chain
+const (
+	// AuditIssueMissing means entries of the range are gone
+	AuditIssueMissing = "missing"
+	// AuditIssueModified means an entry no longer matches its hash
+	AuditIssueModified = "modified"
+	// AuditIssueBrokenLink means an entry does not follow the hash of the entry before it
+	AuditIssueBrokenLink = "broken_link"
+	// AuditIssueCheckpointMismatch means the entry a checkpoint covers has another hash
+	AuditIssueCheckpointMismatch = "checkpoint_mismatch"
+	// AuditIssueBadSignature means a checkpoint is not signed by a trusted key
+	AuditIssueBadSignature = "bad_signature"
+)
+
+// auditLogContent is what an audit log's hash covers: every field except the hash itself and
+// the resource it was reassigned to
+type auditLogContent struct {
+	ID           string                 `json:"id"`
+	Sequence     int64                  `json:"sequence"`
+	PreviousHash string                 `json:"previousHash"`
+	UserID       string                 `json:"userId"`
+	Username     string                 `json:"username"`
+	Action       AuditAction            `json:"action"`
+	ResourceType string                 `json:"resourceType"`
+	ResourceID   string                 `json:"resourceId"`
+	ResourceName string                 `json:"resourceName"`
+	Description  string                 `json:"description"`
+	IPAddress    string                 `json:"ipAddress"`
+	UserAgent    string                 `json:"userAgent"`
+	OldValue     map[string]interface{} `json:"oldValue"`
+	NewValue     map[string]interface{} `json:"newValue"`
+	Success      bool                   `json:"success"`
+	ErrorMessage string                 `json:"errorMessage"`
+	Method       string                 `json:"method"`
+	Path         string                 `json:"path"`
+	StatusCode   int                    `json:"statusCode"`
+	Timestamp    int64                  `json:"timestamp"`
+}
+
+// Chain gives a new entry the next place in the hash chain, after the entry with the previous
+// hash, and computes its hash
+func (a *AuditLog) Chain(sequence int64, previousHash string) error {
+	if a.ID.IsZero() {
+		a.ID = primitive.NewObjectID()
+	}
+	a.Sequence = sequence
+	a.PreviousHash = previousHash
+
+	hash, err := a.ComputeHash()
+	if err != nil {
+		return err
+	}
+	a.Hash = hash
+	return nil
+}
+
+// ComputeHash returns the SHA-256 hash of an entry as it is stored. The entry is read back from
+// BSON first, so that the hash does not change when it is loaded from the database.
+func (a *AuditLog) ComputeHash() (string, error) {
+	data, err := bson.Marshal(a)
+	if err != nil {
+		return "", err
+	}
+	var stored AuditLog
+	if err := bson.Unmarshal(data, &stored); err != nil {
+		return "", err
+	}
+
+	content := auditLogContent{
+		ID:           stored.ID.Hex(),
+		Sequence:     stored.Sequence,
+		PreviousHash: stored.PreviousHash,
+		UserID:       stored.UserID,
+		Username:     stored.Username,
+		Action:       stored.Action,
+		ResourceType: stored.ResourceType,
+		ResourceID:   stored.ResourceID,
+		ResourceName: stored.ResourceName,
+		Description:  stored.Description,
+		IPAddress:    stored.IPAddress,
+		UserAgent:    stored.UserAgent,
+		OldValue:     stored.OldValue,
+		NewValue:     stored.NewValue,
+		Success:      stored.Success,
+		ErrorMessage: stored.ErrorMessage,
+		Method:       stored.Method,
+		Path:         stored.Path,
+		StatusCode:   stored.StatusCode,
+		Timestamp:    stored.Timestamp.UnixMilli(),
+	}
+
+	encoded, err := json.Marshal(content)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// AuditCheckpoint is a signed statement of the hash of the audit log chain up to an entry.
+// Entries it covers cannot be changed or removed without the checkpoint showing it.
+type AuditCheckpoint struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Sequence  int64              `json:"sequence" bson:"sequence"`
+	Hash      string             `json:"hash" bson:"hash"`
+	KeyID     string             `json:"keyId" bson:"keyId"`
+	Signature string             `json:"signature" bson:"signature"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// NewAuditCheckpoint creates an unsigned checkpoint of the chain up to an entry
+func NewAuditCheckpoint(entry *AuditLog) *AuditCheckpoint {
+	return &AuditCheckpoint{
+		Sequence:  entry.Sequence,
+		Hash:      entry.Hash,
+		CreatedAt: time.Now().Truncate(time.Millisecond),
+	}
+}
+
+// SigningPayload returns the bytes a checkpoint's signature covers
+func (c *AuditCheckpoint) SigningPayload() []byte {
+	return []byte(fmt.Sprintf("cmdb-audit-checkpoint:%d:%s:%d", c.Sequence, c.Hash, c.CreatedAt.UnixMilli()))
+}
+
+// AuditArchive describes a segment of the audit log chain that was moved out of the database
+// when its retention period ended. Its checkpoint covers the last entry of the segment.
+type AuditArchive struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	FromSequence int64              `json:"fromSequence" bson:"fromSequence"`
+	ToSequence   int64              `json:"toSequence" bson:"toSequence"`
+	// PreviousHash is the hash the first entry of the segment follows
+	PreviousHash string          `json:"previousHash" bson:"previousHash"`
+	LastHash     string          `json:"lastHash" bson:"lastHash"`
+	Entries      int64           `json:"entries" bson:"entries"`
+	Location     string          `json:"location" bson:"location"`
+	Checkpoint   AuditCheckpoint `json:"checkpoint" bson:"checkpoint"`
+	ArchivedAt   time.Time       `json:"archivedAt" bson:"archivedAt"`
+}
+
+// AuditChainIssue is a problem found in the audit log chain. Missing entries are reported as a
+// range from Sequence to ToSequence.
+type AuditChainIssue struct {
+	Sequence   int64  `json:"sequence"`
+	ToSequence int64  `json:"toSequence,omitempty"`
+	Problem    string `json:"problem"`
+	Detail     string `json:"detail,omitempty"`
+}
+
+// AuditVerification is the result of verifying a range of the audit log chain
+type AuditVerification struct {
+	FromSequence int64 `json:"fromSequence"`
+	ToSequence   int64 `json:"toSequence"`
+	// Checked counts the entries whose hashes were checked
+	Checked            int64 `json:"checked"`
+	CheckpointsChecked int   `json:"checkpointsChecked"`
+	// ArchivedThrough is the last sequence moved to an archive; those entries are read back
+	// from their archives and checked against the archives' checkpoints
+	ArchivedThrough int64             `json:"archivedThrough,omitempty"`
+	Valid           bool              `json:"valid"`
+	Issues          []AuditChainIssue `json:"issues"`
+	VerifiedAt      time.Time         `json:"verifiedAt"`
+}
+
+// AddIssue records a problem, which makes the verification fail
+func (v *AuditVerification) AddIssue(sequence int64, problem, detail string) {
+	v.Issues = append(v.Issues, AuditChainIssue{Sequence: sequence, Problem: problem, Detail: detail})
+	v.Valid = false
+}
+
+// AddMissing records that the entries from one sequence to another are gone
+func (v *AuditVerification) AddMissing(from, to int64) {
+	issue := AuditChainIssue{Sequence: from, Problem: AuditIssueMissing}
+	if to > from {
+		issue.ToSequence = to
+	}
+	v.Issues = append(v.Issues, issue)
+	v.Valid = false
+}
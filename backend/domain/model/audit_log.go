@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -26,14 +27,23 @@ const (
 
 	// User related actions
 	UserLoggedIn        AuditAction = "user_logged_in"
+	UserLoginFailed     AuditAction = "user_login_failed"
 	UserLoggedOut       AuditAction = "user_logged_out"
 	UserCreated         AuditAction = "user_created"
 	UserUpdated         AuditAction = "user_updated"
 	UserPasswordChanged AuditAction = "user_password_changed"
+	RefreshTokenReused  AuditAction = "refresh_token_reused"
 
 	// Report related actions
 	ReportGenerated  AuditAction = "report_generated"
 	ReportDownloaded AuditAction = "report_downloaded"
+
+	// Access control actions
+	PermissionDenied AuditAction = "permission_denied"
+
+	// ResourceReassigned records that a resource was merged into another one, whose audit
+	// history then includes the entries written for it
+	ResourceReassigned AuditAction = "resource_reassigned"
 )
 
 // AuditLog represents an audit log entry
@@ -52,7 +62,14 @@ type AuditLog struct {
 	NewValue     map[string]interface{} `json:"newValue,omitempty" bson:"newValue,omitempty"`
 	Success      bool                   `json:"success" bson:"success"`
 	ErrorMessage string                 `json:"errorMessage,omitempty" bson:"errorMessage,omitempty"`
+	Method       string                 `json:"method,omitempty" bson:"method,omitempty"`
+	Path         string                 `json:"path,omitempty" bson:"path,omitempty"`
+	StatusCode   int                    `json:"statusCode,omitempty" bson:"statusCode,omitempty"`
 	Timestamp    time.Time              `json:"timestamp" bson:"timestamp"`
+	// The entry's place in the hash chain; entries written before the chain have no sequence
+	Sequence     int64  `json:"sequence,omitempty" bson:"sequence,omitempty"`
+	PreviousHash string `json:"previousHash,omitempty" bson:"previousHash,omitempty"`
+	Hash         string `json:"hash,omitempty" bson:"hash,omitempty"`
 }
 
 // NewAuditLog creates a new audit log entry
@@ -91,3 +108,29 @@ func (a *AuditLog) SetError(errorMessage string) {
 	a.Success = false
 	a.ErrorMessage = errorMessage
 }
+
+// SetRequest records the API request an entry was written for
+func (a *AuditLog) SetRequest(method, path string, statusCode int) {
+	a.Method = method
+	a.Path = path
+	a.StatusCode = statusCode
+}
+
+// AuditClient is where a request came from, for the audit logs written while serving it
+type AuditClient struct {
+	IPAddress string
+	UserAgent string
+}
+
+type auditClientKey struct{}
+
+// WithAuditClient returns a context whose audit logs record the client
+func WithAuditClient(ctx context.Context, client AuditClient) context.Context {
+	return context.WithValue(ctx, auditClientKey{}, client)
+}
+
+// AuditClientFromContext returns the client of a context, which is empty outside requests
+func AuditClientFromContext(ctx context.Context) AuditClient {
+	client, _ := ctx.Value(auditClientKey{}).(AuditClient)
+	return client
+}
@@ -0,0 +1,162 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Kinds of audit stream sinks
+const (
+	// AuditSinkSyslog sends audit logs to a syslog collector over UDP, TCP or TLS
+	AuditSinkSyslog = "syslog"
+	// AuditSinkHTTP posts audit logs to an HTTP endpoint
+	AuditSinkHTTP = "http"
+)
+
+// Formats audit logs are streamed in
+const (
+	// AuditFormatRFC5424 sends the fields of an audit log as syslog structured data, with its
+	// description as the message
+	AuditFormatRFC5424 = "rfc5424"
+	// AuditFormatJSON sends audit logs as JSON objects, one per line over HTTP
+	AuditFormatJSON = "json"
+	// AuditFormatCEF sends audit logs in ArcSight Common Event Format
+	AuditFormatCEF = "cef"
+)
+
+// DefaultAuditSpoolMaxBytes bounds the disk buffer of a sink unless configured otherwise
+const DefaultAuditSpoolMaxBytes = 100 << 20
+
+// auditSinkName keeps sink names usable as file names
+var auditSinkName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// AuditStreamConfig lists the external collectors audit logs are forwarded to
+type AuditStreamConfig struct {
+	Sinks []AuditSinkConfig `json:"sinks"`
+}
+
+// AuditSinkConfig configures a collector audit logs are forwarded to and which of them it gets
+type AuditSinkConfig struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Format string `json:"format,omitempty"`
+
+	// Network is udp, tcp or tls, for syslog sinks
+	Network            string `json:"network,omitempty"`
+	Address            string `json:"address,omitempty"`
+	CAFile             string `json:"caFile,omitempty"`
+	CertFile           string `json:"certFile,omitempty"`
+	KeyFile            string `json:"keyFile,omitempty"`
+	ServerName         string `json:"serverName,omitempty"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify,omitempty"`
+
+	// URL and Headers are for HTTP sinks
+	URL     string            `json:"url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Actions and ResourceTypes select the audit logs the sink gets; empty lists select all.
+	// An action ending in * matches every action starting with the rest.
+	Actions       []string `json:"actions,omitempty"`
+	ResourceTypes []string `json:"resourceTypes,omitempty"`
+
+	// SpoolMaxBytes bounds the audit logs buffered on disk while the sink is down
+	SpoolMaxBytes int64 `json:"spoolMaxBytes,omitempty"`
+}
+
+// ParseAuditStreamConfig reads audit stream sinks from JSON, validates them and fills in the
+// default format and spool size
+func ParseAuditStreamConfig(data []byte) (*AuditStreamConfig, error) {
+	var config AuditStreamConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+
+	for i := range config.Sinks {
+		sink := &config.Sinks[i]
+		if sink.Format == "" {
+			sink.Format = AuditFormatJSON
+			if sink.Type == AuditSinkSyslog {
+				sink.Format = AuditFormatRFC5424
+			}
+		}
+		if sink.SpoolMaxBytes == 0 {
+			sink.SpoolMaxBytes = DefaultAuditSpoolMaxBytes
+		}
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// Validate checks that the sinks have unique names and the settings their type needs
+func (c *AuditStreamConfig) Validate() error {
+	seen := make(map[string]bool)
+	for i, sink := range c.Sinks {
+		if !auditSinkName.MatchString(sink.Name) {
+			return fmt.Errorf("sink %d: name must only contain letters, digits, - and _", i+1)
+		}
+		if seen[sink.Name] {
+			return fmt.Errorf("sink %s is listed twice", sink.Name)
+		}
+		seen[sink.Name] = true
+		if err := sink.validate(); err != nil {
+			return fmt.Errorf("sink %s: %w", sink.Name, err)
+		}
+	}
+	return nil
+}
+
+func (c AuditSinkConfig) validate() error {
+	switch c.Type {
+	case AuditSinkSyslog:
+		if c.Network != "udp" && c.Network != "tcp" && c.Network != "tls" {
+			return fmt.Errorf("network must be udp, tcp or tls")
+		}
+		if c.Address == "" {
+			return fmt.Errorf("address is required")
+		}
+		if c.Format != AuditFormatRFC5424 && c.Format != AuditFormatJSON && c.Format != AuditFormatCEF {
+			return fmt.Errorf("format must be rfc5424, json or cef")
+		}
+	case AuditSinkHTTP:
+		if target, err := url.Parse(c.URL); err != nil || (target.Scheme != "http" && target.Scheme != "https") {
+			return fmt.Errorf("url must be an http or https URL")
+		}
+		if c.Format != AuditFormatJSON && c.Format != AuditFormatCEF {
+			return fmt.Errorf("format must be json or cef")
+		}
+	default:
+		return fmt.Errorf("type must be syslog or http")
+	}
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return fmt.Errorf("certFile and keyFile must be set together")
+	}
+	if c.SpoolMaxBytes < 0 {
+		return fmt.Errorf("spoolMaxBytes must not be negative")
+	}
+	return nil
+}
+
+// Matches reports whether the sink gets an audit log
+func (c AuditSinkConfig) Matches(log *AuditLog) bool {
+	if len(c.ResourceTypes) > 0 && !containsString(c.ResourceTypes, log.ResourceType) {
+		return false
+	}
+	if len(c.Actions) == 0 {
+		return true
+	}
+	for _, action := range c.Actions {
+		if prefix, ok := strings.CutSuffix(action, "*"); ok && strings.HasPrefix(string(log.Action), prefix) {
+			return true
+		}
+		if action == string(log.Action) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidToken is returned for a token that is malformed, signed by an unknown key or issued
+// by someone else
+var ErrInvalidToken = errors.New("invalid token")
+
+// ErrTokenExpired is returned for a token past its expiry
+var ErrTokenExpired = errors.New("token expired")
+
+// ErrTokenRevoked is returned for an access token whose session or user was revoked
+var ErrTokenRevoked = errors.New("token revoked")
+
+// ErrRefreshTokenReused is returned when a refresh token that was already exchanged is
+// presented again, which revokes the whole session
+var ErrRefreshTokenReused = errors.New("refresh token was already used")
+
+// AccessClaims are the claims of a signed access token. The user's role and permissions are
+// carried in the token so that requests are authorized without loading the user.
+type AccessClaims struct {
+	Issuer      string       `json:"iss"`
+	Subject     string       `json:"sub"`
+	IssuedAt    int64        `json:"iat"`
+	NotBefore   int64        `json:"nbf"`
+	ExpiresAt   int64        `json:"exp"`
+	ID          string       `json:"jti"`
+	SessionID   string       `json:"sid"`
+	Username    string       `json:"preferred_username"`
+	Email       string       `json:"email,omitempty"`
+	Name        string       `json:"name,omitempty"`
+	Role        UserRole     `json:"role"`
+	Permissions []Permission `json:"permissions,omitempty"`
+
+	// IssuedAtMs is when the token was issued in milliseconds, so that a user revocation does
+	// not also cover the tokens issued later in the same second, such as after a password change
+	IssuedAtMs int64 `json:"iat_ms,omitempty"`
+}
+
+// NewAccessClaims creates the claims of an access token of a user's session, valid for ttl. The
+// issuer is set when the token is signed.
+func NewAccessClaims(user *User, sessionID string, ttl time.Duration) *AccessClaims {
+	now := time.Now()
+	return &AccessClaims{
+		Subject:     user.ID.Hex(),
+		IssuedAt:    now.Unix(),
+		IssuedAtMs:  now.UnixMilli(),
+		NotBefore:   now.Unix(),
+		ExpiresAt:   now.Add(ttl).Unix(),
+		ID:          primitive.NewObjectID().Hex(),
+		SessionID:   sessionID,
+		Username:    user.Username,
+		Email:       user.Email,
+		Name:        user.FullName,
+		Role:        user.Role,
+		Permissions: user.Permissions,
+	}
+}
+
+// AuthTokens are the tokens handed to a client when it logs in or refreshes its session
+type AuthTokens struct {
+	AccessToken      string
+	RefreshToken     string
+	ExpiresIn        int64
+	RefreshExpiresAt time.Time
+}
+
+// HashRefreshToken hashes a refresh token for storage and lookup
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// RevocationKind tells what a token revocation applies to
+type RevocationKind string
+
+// Token revocation kinds
+const (
+	// RevokedSession revokes the access tokens of one session, such as on logout
+	RevokedSession RevocationKind = "session"
+	// RevokedUser revokes every access token of a user issued up to the revocation, such as
+	// when the account is locked
+	RevokedUser RevocationKind = "user"
+)
+
+// TokenRevocation stops access tokens from being accepted before they expire. It is kept until
+// the last token it covers has expired.
+type TokenRevocation struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Kind      RevocationKind     `json:"kind" bson:"kind"`
+	Value     string             `json:"value" bson:"value"`
+	Reason    string             `json:"reason" bson:"reason"`
+	RevokedAt time.Time          `json:"revokedAt" bson:"revokedAt"`
+	ExpiresAt time.Time          `json:"expiresAt" bson:"expiresAt"`
+}
+
+// NewTokenRevocation creates a revocation of a session or user, kept for the lifetime of the
+// access tokens it covers
+func NewTokenRevocation(kind RevocationKind, value, reason string, lifetime time.Duration) *TokenRevocation {
+	now := time.Now()
+	return &TokenRevocation{
+		Kind:      kind,
+		Value:     value,
+		Reason:    reason,
+		RevokedAt: now,
+		ExpiresAt: now.Add(lifetime),
+	}
+}
+
+// Covers reports whether the revocation applies to an access token
+func (r *TokenRevocation) Covers(claims *AccessClaims) bool {
+	switch r.Kind {
+	case RevokedSession:
+		return claims.SessionID == r.Value
+	case RevokedUser:
+		return claims.Subject == r.Value && claims.issuedAtMs() <= r.RevokedAt.UnixMilli()
+	}
+	return false
+}
+
+// issuedAtMs returns when the token was issued in milliseconds. Tokens signed without the claim
+// count as issued at the start of their second.
+func (c *AccessClaims) issuedAtMs() int64 {
+	if c.IssuedAtMs != 0 {
+		return c.IssuedAtMs
+	}
+	return c.IssuedAt * 1000
+}
+
+// JSONWebKey is the public part of a token signing key, as published in a JWKS
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// JSONWebKeySet lists the keys access tokens may be signed with
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
@@ -0,0 +1,305 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AttributeType is the value type of a CI type attribute
+type AttributeType string
+
+// Attribute types
+const (
+	AttributeString    AttributeType = "string"
+	AttributeInteger   AttributeType = "integer"
+	AttributeNumber    AttributeType = "number"
+	AttributeBoolean   AttributeType = "boolean"
+	AttributeDate      AttributeType = "date"
+	AttributeEnum      AttributeType = "enum"
+	AttributeReference AttributeType = "reference"
+)
+
+// ErrSchemaViolation is returned when asset attributes do not match the schema of their CI type
+var ErrSchemaViolation = errors.New("asset does not match its CI type schema")
+
+// SchemaError lists every attribute of an asset that violates its CI type schema
+type SchemaError struct {
+	Type       AssetType
+	Violations []string
+}
+
+// Error implements the error interface
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("invalid %s attributes: %s", e.Type, strings.Join(e.Violations, "; "))
+}
+
+// Unwrap lets errors.Is match ErrSchemaViolation
+func (e *SchemaError) Unwrap() error {
+	return ErrSchemaViolation
+}
+
+// AttributeDefinition describes one typed attribute of a CI type
+type AttributeDefinition struct {
+	Name        string        `json:"name" bson:"name"`
+	Label       string        `json:"label,omitempty" bson:"label,omitempty"`
+	Type        AttributeType `json:"type" bson:"type"`
+	Required    bool          `json:"required,omitempty" bson:"required,omitempty"`
+	Description string        `json:"description,omitempty" bson:"description,omitempty"`
+	// Enum lists the allowed values of enum attributes
+	Enum []string `json:"enum,omitempty" bson:"enum,omitempty"`
+	// Pattern is a regular expression string values must match
+	Pattern string `json:"pattern,omitempty" bson:"pattern,omitempty"`
+	// Min and Max bound integer and number values
+	Min *float64 `json:"min,omitempty" bson:"min,omitempty"`
+	Max *float64 `json:"max,omitempty" bson:"max,omitempty"`
+	// ReferenceType restricts reference attributes to CIs of one type
+	ReferenceType AssetType `json:"referenceType,omitempty" bson:"referenceType,omitempty"`
+}
+
+// CIType is an asset type together with the schema of its attributes
+type CIType struct {
+	ID          primitive.ObjectID    `json:"id" bson:"_id,omitempty"`
+	Name        AssetType             `json:"name" bson:"name"`
+	DisplayName string                `json:"displayName" bson:"displayName"`
+	Description string                `json:"description" bson:"description"`
+	Prefix      string                `json:"prefix" bson:"prefix"`
+	Attributes  []AttributeDefinition `json:"attributes" bson:"attributes"`
+	BuiltIn     bool                  `json:"builtIn" bson:"-"`
+	CreatedAt   time.Time             `json:"createdAt" bson:"createdAt"`
+	UpdatedAt   time.Time             `json:"updatedAt" bson:"updatedAt"`
+}
+
+// NewCIType creates a new CI type
+func NewCIType(name AssetType, displayName, description, prefix string, attributes []AttributeDefinition) *CIType {
+	now := time.Now()
+	return &CIType{
+		Name:        name,
+		DisplayName: displayName,
+		Description: description,
+		Prefix:      prefix,
+		Attributes:  attributes,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+// hardwareAttributes are the optional attributes shared by the built-in hardware types
+func hardwareAttributes() []AttributeDefinition {
+	return []AttributeDefinition{
+		{Name: "serialNumber", Label: "Serial Number", Type: AttributeString},
+		{Name: "manufacturer", Label: "Manufacturer", Type: AttributeString},
+		{Name: "model", Label: "Model", Type: AttributeString},
+		{Name: "warrantyExpiry", Label: "Warranty Expiry", Type: AttributeDate},
+	}
+}
+
+// BuiltInCITypes returns the CI types that exist without configuration. Admins may
+// redefine them to add attributes, but not delete them.
+func BuiltInCITypes() []*CIType {
+	types := []*CIType{
+		{Name: ServerType, DisplayName: "Server", Prefix: "SRV"},
+		{Name: NetworkType, DisplayName: "Network Device", Prefix: "NET"},
+		{Name: StorageType, DisplayName: "Storage", Prefix: "STG"},
+		{Name: WorkstationType, DisplayName: "Workstation", Prefix: "WS"},
+	}
+	for _, t := range types {
+		t.Attributes = hardwareAttributes()
+	}
+	types = append(types, kubernetesCITypes()...)
+	types = append(types, cloudCITypes()...)
+	for _, t := range types {
+		t.BuiltIn = true
+	}
+	return types
+}
+
+// ciTypeName matches valid CI type and attribute names
+var ciTypeName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// attributeName matches valid attribute names
+var attributeName = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]*$`)
+
+// Validate checks the CI type definition itself
+func (t *CIType) Validate() error {
+	if !ciTypeName.MatchString(string(t.Name)) {
+		return errors.New("type name must start with a lowercase letter and contain only lowercase letters, digits and underscores")
+	}
+
+	seen := make(map[string]bool)
+	for _, attr := range t.Attributes {
+		if !attributeName.MatchString(attr.Name) {
+			return fmt.Errorf("invalid attribute name %q", attr.Name)
+		}
+		if seen[attr.Name] {
+			return fmt.Errorf("duplicate attribute %s", attr.Name)
+		}
+		seen[attr.Name] = true
+
+		switch attr.Type {
+		case AttributeString, AttributeInteger, AttributeNumber, AttributeBoolean, AttributeDate, AttributeReference:
+		case AttributeEnum:
+			if len(attr.Enum) == 0 {
+				return fmt.Errorf("attribute %s: enum needs at least one value", attr.Name)
+			}
+		default:
+			return fmt.Errorf("attribute %s: unknown type %s", attr.Name, attr.Type)
+		}
+
+		if attr.Pattern != "" {
+			if attr.Type != AttributeString {
+				return fmt.Errorf("attribute %s: only string attributes take a pattern", attr.Name)
+			}
+			if _, err := regexp.Compile(attr.Pattern); err != nil {
+				return fmt.Errorf("attribute %s: invalid pattern: %v", attr.Name, err)
+			}
+		}
+		if (attr.Min != nil || attr.Max != nil) && attr.Type != AttributeInteger && attr.Type != AttributeNumber {
+			return fmt.Errorf("attribute %s: only integer and number attributes take a range", attr.Name)
+		}
+		if attr.Min != nil && attr.Max != nil && *attr.Min > *attr.Max {
+			return fmt.Errorf("attribute %s: min is greater than max", attr.Name)
+		}
+		if attr.ReferenceType != "" && attr.Type != AttributeReference {
+			return fmt.Errorf("attribute %s: only reference attributes take a reference type", attr.Name)
+		}
+	}
+
+	return nil
+}
+
+// Attribute returns the definition of an attribute
+func (t *CIType) Attribute(name string) (AttributeDefinition, bool) {
+	for _, attr := range t.Attributes {
+		if attr.Name == name {
+			return attr, true
+		}
+	}
+	return AttributeDefinition{}, false
+}
+
+// ValidateAttributes checks attribute values against the schema and returns them normalised:
+// integers as int64, numbers as float64 and dates as YYYY-MM-DD. Reference values are only
+// checked to be asset IDs here; whether the referenced CIs exist is up to the caller.
+func (t *CIType) ValidateAttributes(values map[string]interface{}) (map[string]interface{}, error) {
+	var violations []string
+	normalized := make(map[string]interface{}, len(values))
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		attr, ok := t.Attribute(name)
+		if !ok {
+			violations = append(violations, fmt.Sprintf("%s is not an attribute of %s", name, t.Name))
+			continue
+		}
+		if values[name] == nil {
+			continue
+		}
+		value, err := attr.normalize(values[name])
+		if err != nil {
+			violations = append(violations, fmt.Sprintf("%s %v", name, err))
+			continue
+		}
+		normalized[name] = value
+	}
+
+	for _, attr := range t.Attributes {
+		if _, ok := normalized[attr.Name]; attr.Required && !ok {
+			violations = append(violations, fmt.Sprintf("%s is required", attr.Name))
+		}
+	}
+
+	if len(violations) > 0 {
+		return nil, &SchemaError{Type: t.Name, Violations: violations}
+	}
+	return normalized, nil
+}
+
+// normalize converts a value to the attribute type and checks its constraints
+func (a AttributeDefinition) normalize(value interface{}) (interface{}, error) {
+	switch a.Type {
+	case AttributeString, AttributeReference:
+		s, ok := value.(string)
+		if !ok {
+			return nil, errors.New("must be a string")
+		}
+		if a.Type == AttributeReference && strings.TrimSpace(s) == "" {
+			return nil, errors.New("must be an asset ID")
+		}
+		if a.Pattern != "" && !regexp.MustCompile(a.Pattern).MatchString(s) {
+			return nil, fmt.Errorf("must match %s", a.Pattern)
+		}
+		return s, nil
+	case AttributeEnum:
+		s, ok := value.(string)
+		if !ok || !containsString(a.Enum, s) {
+			return nil, fmt.Errorf("must be one of %s", strings.Join(a.Enum, ", "))
+		}
+		return s, nil
+	case AttributeBoolean:
+		b, ok := value.(bool)
+		if !ok {
+			return nil, errors.New("must be true or false")
+		}
+		return b, nil
+	case AttributeDate:
+		s, ok := value.(string)
+		if !ok {
+			return nil, errors.New("must be a date (YYYY-MM-DD)")
+		}
+		if d, err := time.Parse("2006-01-02", s); err == nil {
+			return d.Format("2006-01-02"), nil
+		}
+		if d, err := time.Parse(time.RFC3339, s); err == nil {
+			return d.Format("2006-01-02"), nil
+		}
+		return nil, errors.New("must be a date (YYYY-MM-DD)")
+	case AttributeInteger, AttributeNumber:
+		n, ok := toFloat(value)
+		if !ok {
+			return nil, fmt.Errorf("must be a %s", a.Type)
+		}
+		if a.Type == AttributeInteger && n != math.Trunc(n) {
+			return nil, errors.New("must be a whole number")
+		}
+		if a.Min != nil && n < *a.Min {
+			return nil, fmt.Errorf("must be at least %g", *a.Min)
+		}
+		if a.Max != nil && n > *a.Max {
+			return nil, fmt.Errorf("must be at most %g", *a.Max)
+		}
+		if a.Type == AttributeInteger {
+			return int64(n), nil
+		}
+		return n, nil
+	}
+	return nil, fmt.Errorf("has unknown type %s", a.Type)
+}
+
+// toFloat converts JSON and BSON numbers to float64
+func toFloat(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
@@ -0,0 +1,240 @@
+package model
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CI types of cloud resources
+const (
+	CloudInstanceType     AssetType = "cloud_instance"
+	CloudVolumeType       AssetType = "cloud_volume"
+	CloudLoadBalancerType AssetType = "cloud_load_balancer"
+	CloudVPCType          AssetType = "cloud_vpc"
+)
+
+// CloudResourceKind is the kind of a cloud resource
+type CloudResourceKind string
+
+// Cloud resource kinds read by cloud inventory
+const (
+	KindInstance     CloudResourceKind = "instance"
+	KindVolume       CloudResourceKind = "volume"
+	KindLoadBalancer CloudResourceKind = "load_balancer"
+	KindVPC          CloudResourceKind = "vpc"
+)
+
+// cloudKindTypes maps each kind to the CI type of its resources
+var cloudKindTypes = map[CloudResourceKind]AssetType{
+	KindInstance:     CloudInstanceType,
+	KindVolume:       CloudVolumeType,
+	KindLoadBalancer: CloudLoadBalancerType,
+	KindVPC:          CloudVPCType,
+}
+
+// AssetType returns the CI type of resources of the kind
+func (k CloudResourceKind) AssetType() AssetType {
+	return cloudKindTypes[k]
+}
+
+// hoursPerYear converts hourly prices to annual costs
+const hoursPerYear = 24 * 365
+
+// cloudCITypes returns the built-in CI types of cloud resources
+func cloudCITypes() []*CIType {
+	common := func(attributes ...AttributeDefinition) []AttributeDefinition {
+		defs := []AttributeDefinition{
+			{Name: "provider", Label: "Provider", Type: AttributeString},
+			{Name: "account", Label: "Account", Type: AttributeString},
+			{Name: "resourceId", Label: "Resource ID", Type: AttributeString},
+			{Name: "region", Label: "Region", Type: AttributeString},
+			{Name: "zone", Label: "Zone", Type: AttributeString},
+			{Name: "state", Label: "State", Type: AttributeString},
+		}
+		return append(defs, attributes...)
+	}
+	str := func(name, label string) AttributeDefinition {
+		return AttributeDefinition{Name: name, Label: label, Type: AttributeString}
+	}
+
+	return []*CIType{
+		{Name: CloudInstanceType, DisplayName: "Cloud Instance", Prefix: "VM", Attributes: common(
+			str("instanceType", "Instance Type"), str("image", "Image"), str("vpcId", "VPC ID"),
+			str("privateIp", "Private IP"), str("publicIp", "Public IP"),
+		)},
+		{Name: CloudVolumeType, DisplayName: "Cloud Volume", Prefix: "VOL", Attributes: common(
+			AttributeDefinition{Name: "sizeGiB", Label: "Size (GiB)", Type: AttributeInteger},
+			str("volumeType", "Volume Type"), str("attachedTo", "Attached To"),
+		)},
+		{Name: CloudLoadBalancerType, DisplayName: "Cloud Load Balancer", Prefix: "LB", Attributes: common(
+			str("dnsName", "DNS Name"), str("scheme", "Scheme"), str("loadBalancerType", "Type"), str("vpcId", "VPC ID"),
+		)},
+		{Name: CloudVPCType, DisplayName: "Cloud VPC", Prefix: "VPC", Attributes: common(
+			str("cidr", "CIDR"),
+		)},
+	}
+}
+
+// CloudResource is a resource read from a cloud provider
+type CloudResource struct {
+	Kind CloudResourceKind `json:"kind"`
+	// ID is the provider's ID of the resource, e.g. an EC2 instance ID
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	Region    string            `json:"region"`
+	Zone      string            `json:"zone,omitempty"`
+	State     string            `json:"state,omitempty"`
+	IPAddress string            `json:"ipAddress,omitempty"`
+	Hostname  string            `json:"hostname,omitempty"`
+	Tags      map[string]string `json:"tags,omitempty"`
+	// Attributes holds the kind specific attributes of the resource's CI type
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+	// PriceKey selects the hourly price of the resource, e.g. its instance type; Units
+	// multiplies it, e.g. the size of a volume in GiB
+	PriceKey string  `json:"priceKey,omitempty"`
+	Units    float64 `json:"units,omitempty"`
+	// VPCID and AttachedTo link the resource to its VPC and the instances it is attached to
+	VPCID      string   `json:"vpcId,omitempty"`
+	AttachedTo []string `json:"attachedTo,omitempty"`
+}
+
+// Location describes where a resource is as region/zone
+func (r *CloudResource) Location() string {
+	if r.Zone == "" {
+		return r.Region
+	}
+	return r.Region + "/" + r.Zone
+}
+
+// TagRule maps provider tags to asset tags. Key and Value are shell patterns matched against
+// a provider tag; an empty Value matches any value. Tag is the asset tag to add, in which
+// {key} and {value} are replaced by those of the provider tag.
+type TagRule struct {
+	Key   string `json:"key" bson:"key"`
+	Value string `json:"value,omitempty" bson:"value,omitempty"`
+	Tag   string `json:"tag" bson:"tag"`
+}
+
+// DefaultTagRules copy every provider tag as key:value
+var DefaultTagRules = []TagRule{{Key: "*", Tag: "{key}:{value}"}}
+
+// Validate checks the patterns of the rule
+func (r TagRule) Validate() error {
+	if _, err := path.Match(r.Key, ""); err != nil {
+		return err
+	}
+	if _, err := path.Match(r.Value, ""); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ApplyTagRules maps provider tags to sorted, unique asset tags. A provider tag may match
+// several rules.
+func ApplyTagRules(rules []TagRule, tags map[string]string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for key, value := range tags {
+		for _, rule := range rules {
+			if ok, _ := path.Match(rule.Key, key); !ok {
+				continue
+			}
+			if rule.Value != "" {
+				if ok, _ := path.Match(rule.Value, value); !ok {
+					continue
+				}
+			}
+			// Separators left dangling by tags without values are dropped
+			tag := strings.NewReplacer("{key}", key, "{value}", value).Replace(rule.Tag)
+			tag = strings.Trim(tag, " :=")
+			if tag != "" && !seen[tag] {
+				seen[tag] = true
+				result = append(result, tag)
+			}
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// CloudAccount is a cloud provider account whose resources are synced into the CMDB
+type CloudAccount struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name        string             `json:"name" bson:"name"`
+	Description string             `json:"description" bson:"description"`
+	// Provider is a registered provider, e.g. aws, aliyun or openstack
+	Provider string   `json:"provider" bson:"provider"`
+	Regions  []string `json:"regions" bson:"regions"`
+	// CredentialsFile is a JSON file on the server with the provider's credential variables;
+	// without it they are read from the environment
+	CredentialsFile string `json:"credentialsFile,omitempty" bson:"credentialsFile,omitempty"`
+	// Endpoint replaces the provider's API endpoints, e.g. with a local mock
+	Endpoint string `json:"endpoint,omitempty" bson:"endpoint,omitempty"`
+	// FixturesDir holds recorded API responses to read instead of calling the provider
+	FixturesDir string `json:"fixturesDir,omitempty" bson:"fixturesDir,omitempty"`
+	// Prices are hourly prices by price key, e.g. instance type, used for annual costs
+	Prices   map[string]float64 `json:"prices,omitempty" bson:"prices,omitempty"`
+	Currency string             `json:"currency" bson:"currency"`
+	TagRules []TagRule          `json:"tagRules" bson:"tagRules"`
+	// IntervalMinutes schedules resyncs; zero means the account is only synced on demand
+	IntervalMinutes int         `json:"intervalMinutes" bson:"intervalMinutes"`
+	Enabled         bool        `json:"enabled" bson:"enabled"`
+	LastSyncAt      *time.Time  `json:"lastSyncAt,omitempty" bson:"lastSyncAt,omitempty"`
+	LastSync        *SyncResult `json:"lastSync,omitempty" bson:"lastSync,omitempty"`
+	CreatedBy       string      `json:"createdBy" bson:"createdBy"`
+	CreatedAt       time.Time   `json:"createdAt" bson:"createdAt"`
+	UpdatedAt       time.Time   `json:"updatedAt" bson:"updatedAt"`
+}
+
+// NewCloudAccount creates a new cloud account
+func NewCloudAccount(name, provider, createdBy string) *CloudAccount {
+	now := time.Now()
+	return &CloudAccount{
+		Name:      name,
+		Provider:  provider,
+		Currency:  "USD",
+		TagRules:  DefaultTagRules,
+		Enabled:   true,
+		CreatedBy: createdBy,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// IsDue checks whether a scheduled account should be synced at now
+func (a *CloudAccount) IsDue(now time.Time) bool {
+	if !a.Enabled || a.IntervalMinutes <= 0 {
+		return false
+	}
+	return a.LastSyncAt == nil || !now.Before(a.LastSyncAt.Add(time.Duration(a.IntervalMinutes)*time.Minute))
+}
+
+// RecordSync stores the result of a sync
+func (a *CloudAccount) RecordSync(result *SyncResult) {
+	a.LastSyncAt = &result.StartedAt
+	a.LastSync = result
+}
+
+// ExternalID identifies the CI of a resource of the account; an empty ID gives the prefix
+// shared by all of them
+func (a *CloudAccount) ExternalID(resourceID string) string {
+	return "cloud:" + a.ID.Hex() + ":" + resourceID
+}
+
+// AnnualCost prices a resource for a year at the account's hourly prices; resources without
+// a price cost nothing
+func (a *CloudAccount) AnnualCost(resource *CloudResource) float64 {
+	price, ok := a.Prices[resource.PriceKey]
+	if !ok || resource.PriceKey == "" {
+		return 0
+	}
+	units := resource.Units
+	if units == 0 {
+		units = 1
+	}
+	return price * units * hoursPerYear
+}
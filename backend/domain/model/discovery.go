@@ -0,0 +1,361 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ScanRunStatus represents the state of a discovery scan
+type ScanRunStatus string
+
+// CandidateStatus represents the review state of a discovered host
+type CandidateStatus string
+
+// Discovery constants
+const (
+	// Scan run statuses
+	ScanRunning   ScanRunStatus = "running"
+	ScanCompleted ScanRunStatus = "completed"
+	ScanFailed    ScanRunStatus = "failed"
+	ScanCancelled ScanRunStatus = "cancelled"
+
+	// Candidate statuses
+	CandidatePending  CandidateStatus = "pending"
+	CandidateApproved CandidateStatus = "approved"
+	CandidateIgnored  CandidateStatus = "ignored"
+
+	// MaxDiscoveryAddresses bounds the number of addresses a single range may sweep
+	MaxDiscoveryAddresses = 65536
+)
+
+// DefaultDiscoveryPorts are probed when a range does not list its own ports
+var DefaultDiscoveryPorts = []int{22, 23, 80, 135, 443, 445, 2049, 3260, 3306, 3389, 5432, 5900, 8080, 9100}
+
+// wellKnownServices names the services usually found on a port
+var wellKnownServices = map[int]string{
+	21:    "ftp",
+	22:    "ssh",
+	23:    "telnet",
+	25:    "smtp",
+	53:    "dns",
+	80:    "http",
+	135:   "msrpc",
+	139:   "netbios",
+	161:   "snmp",
+	443:   "https",
+	445:   "smb",
+	515:   "printer",
+	631:   "ipp",
+	830:   "netconf",
+	2049:  "nfs",
+	3260:  "iscsi",
+	3306:  "mysql",
+	3389:  "rdp",
+	5432:  "postgresql",
+	5900:  "vnc",
+	5985:  "winrm",
+	6379:  "redis",
+	6443:  "kubernetes",
+	8080:  "http-alt",
+	8443:  "https-alt",
+	9100:  "jetdirect",
+	9200:  "elasticsearch",
+	27017: "mongodb",
+}
+
+// ServiceName returns the well-known service of a port, or "unknown"
+func ServiceName(port int) string {
+	if name, ok := wellKnownServices[port]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// DiscoveryRange is a set of networks swept by the discovery scanner
+type DiscoveryRange struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name        string             `json:"name" bson:"name"`
+	Description string             `json:"description" bson:"description"`
+	CIDRs       []string           `json:"cidrs" bson:"cidrs"`
+	Ports       []int              `json:"ports" bson:"ports"`
+	// Location is suggested for assets created from hosts found in the range
+	Location string `json:"location" bson:"location"`
+	// ICMP enables echo probes in addition to TCP; it needs raw socket privileges
+	ICMP bool `json:"icmp" bson:"icmp"`
+	// IntervalMinutes schedules the range; zero means it is only scanned on demand
+	IntervalMinutes int `json:"intervalMinutes" bson:"intervalMinutes"`
+	// RateLimit is the maximum number of probes per second
+	RateLimit     int        `json:"rateLimit" bson:"rateLimit"`
+	Concurrency   int        `json:"concurrency" bson:"concurrency"`
+	TimeoutMillis int        `json:"timeoutMillis" bson:"timeoutMillis"`
+	Enabled       bool       `json:"enabled" bson:"enabled"`
+	LastRunAt     *time.Time `json:"lastRunAt,omitempty" bson:"lastRunAt,omitempty"`
+	CreatedBy     string     `json:"createdBy" bson:"createdBy"`
+	CreatedAt     time.Time  `json:"createdAt" bson:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt" bson:"updatedAt"`
+}
+
+// NewDiscoveryRange creates a new discovery range
+func NewDiscoveryRange(name string, cidrs []string, createdBy string) *DiscoveryRange {
+	now := time.Now()
+	return &DiscoveryRange{
+		Name:      name,
+		CIDRs:     cidrs,
+		Enabled:   true,
+		CreatedBy: createdBy,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// Prefixes parses the CIDRs of the range; a bare address is treated as a single host
+func (r *DiscoveryRange) Prefixes() ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(r.CIDRs))
+	for _, cidr := range r.CIDRs {
+		cidr = strings.TrimSpace(cidr)
+		if !strings.Contains(cidr, "/") {
+			addr, err := netip.ParseAddr(cidr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CIDR %q", cidr)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q", cidr)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// Addresses expands the range into the host addresses to probe. IPv4 network and
+// broadcast addresses are skipped except in /31 and /32 networks.
+func (r *DiscoveryRange) Addresses() ([]string, error) {
+	prefixes, err := r.Prefixes()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[netip.Addr]bool)
+	var addresses []string
+	for _, prefix := range prefixes {
+		hostBits := prefix.Addr().BitLen() - prefix.Bits()
+		if hostBits > 16 || len(addresses) > MaxDiscoveryAddresses {
+			return nil, fmt.Errorf("range is too large; at most %d addresses can be scanned", MaxDiscoveryAddresses)
+		}
+
+		skipEdges := prefix.Addr().Is4() && hostBits > 1
+		for addr, i := prefix.Addr(), 0; i < 1<<hostBits; addr, i = addr.Next(), i+1 {
+			if skipEdges && (i == 0 || i == (1<<hostBits)-1) {
+				continue
+			}
+			if !seen[addr] {
+				seen[addr] = true
+				addresses = append(addresses, addr.String())
+			}
+		}
+	}
+
+	if len(addresses) > MaxDiscoveryAddresses {
+		return nil, fmt.Errorf("range is too large; at most %d addresses can be scanned", MaxDiscoveryAddresses)
+	}
+	return addresses, nil
+}
+
+// Contains checks whether an IP address lies within the range
+func (r *DiscoveryRange) Contains(ip string) bool {
+	addr, err := netip.ParseAddr(strings.TrimSpace(ip))
+	if err != nil {
+		return false
+	}
+	prefixes, err := r.Prefixes()
+	if err != nil {
+		return false
+	}
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr.Unmap()) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsDue checks whether a scheduled range should be scanned at now
+func (r *DiscoveryRange) IsDue(now time.Time) bool {
+	if !r.Enabled || r.IntervalMinutes <= 0 {
+		return false
+	}
+	return r.LastRunAt == nil || !now.Before(r.LastRunAt.Add(time.Duration(r.IntervalMinutes)*time.Minute))
+}
+
+// DiscoveredService is an open port and what answers on it
+type DiscoveredService struct {
+	Port   int    `json:"port" bson:"port"`
+	Name   string `json:"name" bson:"name"`
+	Banner string `json:"banner,omitempty" bson:"banner,omitempty"`
+}
+
+// DiscoveredHost is a host that answered a discovery probe
+type DiscoveredHost struct {
+	IPAddress  string              `json:"ipAddress" bson:"ipAddress"`
+	MACAddress string              `json:"macAddress,omitempty" bson:"macAddress,omitempty"`
+	Hostname   string              `json:"hostname,omitempty" bson:"hostname,omitempty"`
+	Services   []DiscoveredService `json:"services" bson:"services"`
+	// OS is the best operating system guess of scanners that fingerprint hosts
+	OS string `json:"os,omitempty" bson:"os,omitempty"`
+}
+
+// OpenPorts returns the ports the host answered on
+func (h *DiscoveredHost) OpenPorts() []int {
+	ports := make([]int, len(h.Services))
+	for i, svc := range h.Services {
+		ports[i] = svc.Port
+	}
+	return ports
+}
+
+// hasService checks whether the host runs one of the named services
+func (h *DiscoveredHost) hasService(names ...string) bool {
+	for _, svc := range h.Services {
+		if containsString(names, svc.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// SuggestAssetType guesses the asset type of a host from its open ports
+func (h *DiscoveredHost) SuggestAssetType() AssetType {
+	for _, svc := range h.Services {
+		banner := strings.ToLower(svc.Banner)
+		if strings.Contains(banner, "cisco") || strings.Contains(banner, "junos") || strings.Contains(banner, "routeros") {
+			return NetworkType
+		}
+	}
+	switch {
+	case h.hasService("iscsi", "nfs"):
+		return StorageType
+	case h.hasService("telnet", "snmp", "netconf") && !h.hasService("http", "https", "mysql", "postgresql"):
+		return NetworkType
+	case h.hasService("rdp", "vnc") && !h.hasService("mysql", "postgresql", "mongodb", "redis", "elasticsearch"):
+		return WorkstationType
+	}
+	return ServerType
+}
+
+// ScanRun records one sweep of a discovery range
+type ScanRun struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	RangeID     primitive.ObjectID `json:"rangeId" bson:"rangeId"`
+	RangeName   string             `json:"rangeName" bson:"rangeName"`
+	Status      ScanRunStatus      `json:"status" bson:"status"`
+	TriggeredBy string             `json:"triggeredBy" bson:"triggeredBy"`
+	// Addresses is the number of addresses probed; HostsUp the number that answered
+	Addresses     int        `json:"addresses" bson:"addresses"`
+	HostsUp       int        `json:"hostsUp" bson:"hostsUp"`
+	Matched       int        `json:"matched" bson:"matched"`
+	StatusChanges int        `json:"statusChanges" bson:"statusChanges"`
+	Candidates    int        `json:"candidates" bson:"candidates"`
+	Error         string     `json:"error,omitempty" bson:"error,omitempty"`
+	StartedAt     time.Time  `json:"startedAt" bson:"startedAt"`
+	FinishedAt    *time.Time `json:"finishedAt,omitempty" bson:"finishedAt,omitempty"`
+}
+
+// NewScanRun creates a running scan of a range
+func NewScanRun(rng *DiscoveryRange, triggeredBy string) *ScanRun {
+	return &ScanRun{
+		RangeID:     rng.ID,
+		RangeName:   rng.Name,
+		Status:      ScanRunning,
+		TriggeredBy: triggeredBy,
+		StartedAt:   time.Now(),
+	}
+}
+
+// Finish ends the scan with a final status
+func (r *ScanRun) Finish(status ScanRunStatus, err error) {
+	now := time.Now()
+	r.Status = status
+	r.FinishedAt = &now
+	if err != nil {
+		r.Error = err.Error()
+	}
+}
+
+// DiscoveryCandidate is a host found by discovery that matches no asset. It waits for
+// review instead of becoming an asset automatically.
+type DiscoveryCandidate struct {
+	ID            primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	IPAddress     string              `json:"ipAddress" bson:"ipAddress"`
+	MACAddress    string              `json:"macAddress,omitempty" bson:"macAddress,omitempty"`
+	Hostname      string              `json:"hostname,omitempty" bson:"hostname,omitempty"`
+	Services      []DiscoveredService `json:"services" bson:"services"`
+	SuggestedType AssetType           `json:"suggestedType" bson:"suggestedType"`
+	RangeID       primitive.ObjectID  `json:"rangeId" bson:"rangeId"`
+	RangeName     string              `json:"rangeName" bson:"rangeName"`
+	Location      string              `json:"location" bson:"location"`
+	Status        CandidateStatus     `json:"status" bson:"status"`
+	SeenCount     int                 `json:"seenCount" bson:"seenCount"`
+	FirstSeen     time.Time           `json:"firstSeen" bson:"firstSeen"`
+	LastSeen      time.Time           `json:"lastSeen" bson:"lastSeen"`
+	// AssetID is the asset created when the candidate was approved
+	AssetID    string     `json:"assetId,omitempty" bson:"assetId,omitempty"`
+	ReviewedBy string     `json:"reviewedBy,omitempty" bson:"reviewedBy,omitempty"`
+	ReviewedAt *time.Time `json:"reviewedAt,omitempty" bson:"reviewedAt,omitempty"`
+}
+
+// NewDiscoveryCandidate creates a pending candidate for a host found in a range
+func NewDiscoveryCandidate(host DiscoveredHost, rng *DiscoveryRange) *DiscoveryCandidate {
+	now := time.Now()
+	candidate := &DiscoveryCandidate{
+		RangeID:   rng.ID,
+		RangeName: rng.Name,
+		Location:  rng.Location,
+		Status:    CandidatePending,
+		FirstSeen: now,
+	}
+	candidate.Observe(host)
+	return candidate
+}
+
+// Observe records that the candidate's host was seen again
+func (c *DiscoveryCandidate) Observe(host DiscoveredHost) {
+	c.IPAddress = host.IPAddress
+	if host.MACAddress != "" {
+		c.MACAddress = host.MACAddress
+	}
+	if host.Hostname != "" {
+		c.Hostname = host.Hostname
+	}
+	c.Services = host.Services
+	c.SuggestedType = host.SuggestAssetType()
+	c.SeenCount++
+	c.LastSeen = time.Now()
+}
+
+// ErrCandidateReviewed is returned when a candidate has already been turned into an asset
+var ErrCandidateReviewed = errors.New("candidate has already been approved")
+
+// Approve marks the candidate as turned into an asset
+func (c *DiscoveryCandidate) Approve(reviewer, assetID string) {
+	now := time.Now()
+	c.Status = CandidateApproved
+	c.AssetID = assetID
+	c.ReviewedBy = reviewer
+	c.ReviewedAt = &now
+}
+
+// Ignore marks the candidate as not worth tracking; later scans keep it ignored
+func (c *DiscoveryCandidate) Ignore(reviewer string) {
+	now := time.Now()
+	c.Status = CandidateIgnored
+	c.ReviewedBy = reviewer
+	c.ReviewedAt = &now
+}
@@ -0,0 +1,300 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// duplicateFields are the asset fields duplicate rules can compare besides CI type attributes
+var duplicateFields = map[string]bool{
+	"name":       true,
+	"hostname":   true,
+	"ipAddress":  true,
+	"macAddress": true,
+	"machineId":  true,
+}
+
+// ErrMergeSameAsset is returned when an asset is merged into itself
+var ErrMergeSameAsset = errors.New("an asset cannot be merged into itself")
+
+// ErrMergeSyncedAssets is returned when two assets synced from external systems are merged;
+// the next sync would recreate the one that was merged away
+var ErrMergeSyncedAssets = errors.New("assets synced from external systems cannot be merged")
+
+// fuzzyBlockLength is the length of the normalized prefix under which fuzzy values are compared
+const fuzzyBlockLength = 3
+
+// DuplicateRule scores assets that share a value of a field
+type DuplicateRule struct {
+	// Field is one of name, hostname, ipAddress, macAddress, machineId or attributes.<name>,
+	// e.g. attributes.serialNumber
+	Field string `json:"field"`
+	// Weight is added to the score of a pair of assets the rule matches
+	Weight float64 `json:"weight"`
+	// Fuzzy matches values whose similarity is at least MinSimilarity instead of equal values
+	Fuzzy         bool    `json:"fuzzy,omitempty"`
+	MinSimilarity float64 `json:"minSimilarity,omitempty"`
+}
+
+// DuplicatePolicy decides which pairs of assets are likely duplicates: a pair whose rule weights
+// add up to at least Threshold is proposed for merging
+type DuplicatePolicy struct {
+	Rules     []DuplicateRule `json:"rules"`
+	Threshold float64         `json:"threshold"`
+}
+
+// DefaultDuplicatePolicy returns the built-in duplicate rules. A shared hardware identifier is
+// enough on its own; names, hostnames and addresses are reused too often to count alone.
+func DefaultDuplicatePolicy() *DuplicatePolicy {
+	return &DuplicatePolicy{
+		Rules: []DuplicateRule{
+			{Field: "machineId", Weight: 1},
+			{Field: "attributes.serialNumber", Weight: 1},
+			{Field: "macAddress", Weight: 1},
+			{Field: "hostname", Weight: 0.6},
+			{Field: "name", Weight: 0.6, Fuzzy: true, MinSimilarity: 0.85},
+			{Field: "ipAddress", Weight: 0.4},
+		},
+		Threshold: 1,
+	}
+}
+
+// ParseDuplicatePolicy reads duplicate rules from JSON and validates them
+func ParseDuplicatePolicy(data []byte) (*DuplicatePolicy, error) {
+	var policy DuplicatePolicy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, err
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// Validate checks the fields, weights and similarities of the rules and the threshold
+func (p *DuplicatePolicy) Validate() error {
+	if len(p.Rules) == 0 {
+		return fmt.Errorf("at least one rule is required")
+	}
+	if p.Threshold <= 0 {
+		return fmt.Errorf("threshold must be positive")
+	}
+
+	seen := make(map[string]bool)
+	for i, rule := range p.Rules {
+		attribute, isAttribute := strings.CutPrefix(rule.Field, attributeFieldPrefix)
+		if !duplicateFields[rule.Field] && (!isAttribute || attribute == "") {
+			return fmt.Errorf("rule %d: field %q cannot be compared", i+1, rule.Field)
+		}
+		if seen[rule.Field] {
+			return fmt.Errorf("rule %d: field %s is listed twice", i+1, rule.Field)
+		}
+		seen[rule.Field] = true
+		if rule.Weight <= 0 {
+			return fmt.Errorf("rule %d: weight must be positive", i+1)
+		}
+		if rule.Fuzzy && (rule.MinSimilarity <= 0 || rule.MinSimilarity > 1) {
+			return fmt.Errorf("rule %d: minSimilarity must be between 0 and 1", i+1)
+		}
+	}
+	return nil
+}
+
+// DuplicateValues returns the normalized values of a field that identify an asset: MAC
+// addresses include those the agent reported, hostnames are compared without their domain, and
+// loopback names and addresses are ignored
+func DuplicateValues(asset *Asset, field string) []string {
+	var values []string
+	seen := make(map[string]bool)
+	add := func(value string) {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" || seen[value] {
+			return
+		}
+		seen[value] = true
+		values = append(values, value)
+	}
+
+	switch field {
+	case "name":
+		add(asset.Name)
+	case "hostname":
+		if host, _, _ := strings.Cut(asset.Hostname, "."); host != "localhost" {
+			add(host)
+		}
+	case "ipAddress":
+		if !strings.HasPrefix(asset.IPAddress, "127.") && asset.IPAddress != "::1" {
+			add(asset.IPAddress)
+		}
+	case "macAddress":
+		add(asset.MACAddress)
+		if asset.Facts != nil {
+			for _, mac := range asset.Facts.MACAddresses() {
+				add(mac)
+			}
+		}
+	case "machineId":
+		add(asset.MachineID)
+	default:
+		if name, ok := strings.CutPrefix(field, attributeFieldPrefix); ok {
+			switch value := asset.Attributes[name].(type) {
+			case string:
+				add(value)
+			case nil:
+			default:
+				add(fmt.Sprint(value))
+			}
+		}
+	}
+	return values
+}
+
+// BlockingKeys returns the keys under which an asset is compared with other assets: two assets
+// can only match a rule if they share one of its keys. Fuzzy rules block on a short prefix of
+// the normalized value.
+func (p *DuplicatePolicy) BlockingKeys(asset *Asset) []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, rule := range p.Rules {
+		for _, value := range DuplicateValues(asset, rule.Field) {
+			if rule.Fuzzy {
+				prefix := []rune(normalizeForSimilarity(value))
+				value = string(prefix[:min(len(prefix), fuzzyBlockLength)])
+				if value == "" {
+					continue
+				}
+			}
+			key := rule.Field + ":" + value
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
+// Score adds up the weights of the rules two assets match and lists what they matched on, e.g.
+// macAddress:00:11:22:33:44:55 or name~0.92 for a fuzzy match
+func (p *DuplicatePolicy) Score(a, b *Asset) (float64, []string) {
+	var score float64
+	var matchedOn []string
+	for _, rule := range p.Rules {
+		values := DuplicateValues(b, rule.Field)
+		if rule.Fuzzy {
+			best := 0.0
+			for _, x := range DuplicateValues(a, rule.Field) {
+				for _, y := range values {
+					best = max(best, Similarity(x, y))
+				}
+			}
+			if best > 0 && best >= rule.MinSimilarity {
+				score += rule.Weight
+				matchedOn = append(matchedOn, fmt.Sprintf("%s~%.2f", rule.Field, best))
+			}
+			continue
+		}
+
+		for _, x := range DuplicateValues(a, rule.Field) {
+			if slices.Contains(values, x) {
+				score += rule.Weight
+				matchedOn = append(matchedOn, rule.Field+":"+x)
+				break
+			}
+		}
+	}
+	sort.Strings(matchedOn)
+	return score, matchedOn
+}
+
+// Similarity compares two names by their edit distance once case, spaces and punctuation are
+// dropped, from 0 for nothing in common to 1 for equal names
+func Similarity(a, b string) float64 {
+	x, y := []rune(normalizeForSimilarity(a)), []rune(normalizeForSimilarity(b))
+	longest := max(len(x), len(y))
+	if longest == 0 {
+		return 0
+	}
+	return 1 - float64(editDistance(x, y))/float64(longest)
+}
+
+// normalizeForSimilarity lower-cases a value and keeps only its letters and digits
+func normalizeForSimilarity(value string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, value)
+}
+
+// editDistance computes the Levenshtein distance between two strings
+func editDistance(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// MergeFrom takes over what a duplicate of the asset knows. A field takes the duplicate's value
+// when the asset has none, or when the precedence rules rank the duplicate's source higher;
+// the field's provenance comes along. Tags are combined, and attributes are only taken from a
+// duplicate of the same type. It returns the fields taken from the duplicate.
+func (a *Asset) MergeFrom(duplicate *Asset, policy *ReconciliationPolicy) []string {
+	var taken []string
+	for _, field := range ChangedFields(a, duplicate) {
+		if field == "tags" || duplicate.IsFieldEmpty(field) {
+			continue
+		}
+		if strings.HasPrefix(field, attributeFieldPrefix) && a.Type != duplicate.Type {
+			continue
+		}
+		if !a.IsFieldEmpty(field) && !policy.Outranks(field, duplicate.FieldSourceOf(field), a.FieldSourceOf(field)) {
+			continue
+		}
+
+		a.RestoreField(field, duplicate)
+		if a.Provenance == nil {
+			a.Provenance = make(map[string]FieldSource)
+		}
+		a.Provenance[field] = duplicate.FieldSourceOf(field)
+		taken = append(taken, field)
+	}
+
+	for _, tag := range duplicate.Tags {
+		if !slices.Contains(a.Tags, tag) {
+			a.Tags = append(a.Tags, tag)
+		}
+	}
+	if a.ExternalID == "" {
+		a.ExternalID = duplicate.ExternalID
+	}
+	if a.Facts == nil {
+		a.Facts = duplicate.Facts
+	}
+	if a.PurchasePrice == 0 {
+		a.PurchasePrice = duplicate.PurchasePrice
+	}
+	if duplicate.LastScanned.After(a.LastScanned) {
+		a.LastScanned = duplicate.LastScanned
+	}
+	return taken
+}
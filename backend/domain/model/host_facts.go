@@ -0,0 +1,221 @@
+package model
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HostFacts is the inventory an agent collects on a host
+type HostFacts struct {
+	MachineID      string          `json:"machineId" bson:"machineId"`
+	Hostname       string          `json:"hostname" bson:"hostname"`
+	OS             string          `json:"os" bson:"os"`
+	Kernel         string          `json:"kernel" bson:"kernel"`
+	Architecture   string          `json:"architecture" bson:"architecture"`
+	CPU            CPUFacts        `json:"cpu" bson:"cpu"`
+	MemoryBytes    int64           `json:"memoryBytes" bson:"memoryBytes"`
+	Disks          []DiskFacts     `json:"disks" bson:"disks"`
+	NICs           []NICFacts      `json:"nics" bson:"nics"`
+	Packages       []PackageFacts  `json:"packages" bson:"packages"`
+	ListeningPorts []ListeningPort `json:"listeningPorts" bson:"listeningPorts"`
+	AgentVersion   string          `json:"agentVersion" bson:"agentVersion"`
+	CollectedAt    time.Time       `json:"collectedAt" bson:"collectedAt"`
+}
+
+// CPUFacts describes the processors of a host
+type CPUFacts struct {
+	Model string `json:"model" bson:"model"`
+	Cores int    `json:"cores" bson:"cores"`
+}
+
+// DiskFacts describes a mounted filesystem. Only the size is kept; usage changes too often to
+// be tracked as drift.
+type DiskFacts struct {
+	Device     string `json:"device" bson:"device"`
+	MountPoint string `json:"mountPoint" bson:"mountPoint"`
+	FSType     string `json:"fsType" bson:"fsType"`
+	SizeBytes  int64  `json:"sizeBytes" bson:"sizeBytes"`
+}
+
+// NICFacts describes a network interface
+type NICFacts struct {
+	Name       string   `json:"name" bson:"name"`
+	MACAddress string   `json:"macAddress" bson:"macAddress"`
+	Addresses  []string `json:"addresses" bson:"addresses"`
+}
+
+// PackageFacts describes an installed package
+type PackageFacts struct {
+	Name    string `json:"name" bson:"name"`
+	Version string `json:"version" bson:"version"`
+}
+
+// ListeningPort describes a socket accepting connections or datagrams
+type ListeningPort struct {
+	Protocol string `json:"protocol" bson:"protocol"`
+	Address  string `json:"address" bson:"address"`
+	Port     int    `json:"port" bson:"port"`
+}
+
+// Normalize sorts the fact lists so that reports can be compared and lower-cases MAC addresses
+func (f *HostFacts) Normalize() {
+	f.MachineID = strings.ToLower(strings.TrimSpace(f.MachineID))
+	f.Hostname = strings.ToLower(strings.TrimSpace(f.Hostname))
+
+	for i := range f.NICs {
+		f.NICs[i].MACAddress = strings.ToLower(f.NICs[i].MACAddress)
+		sort.Strings(f.NICs[i].Addresses)
+	}
+	sort.Slice(f.Disks, func(i, j int) bool { return f.Disks[i].MountPoint < f.Disks[j].MountPoint })
+	sort.Slice(f.NICs, func(i, j int) bool { return f.NICs[i].Name < f.NICs[j].Name })
+	sort.Slice(f.Packages, func(i, j int) bool { return f.Packages[i].Name < f.Packages[j].Name })
+	sort.Slice(f.ListeningPorts, func(i, j int) bool {
+		a, b := f.ListeningPorts[i], f.ListeningPorts[j]
+		if a.Protocol != b.Protocol {
+			return a.Protocol < b.Protocol
+		}
+		if a.Port != b.Port {
+			return a.Port < b.Port
+		}
+		return a.Address < b.Address
+	})
+}
+
+// PrimaryAddress returns the MAC and IP address of the first interface with a global IPv4
+// address, falling back to any other global address
+func (f *HostFacts) PrimaryAddress() (string, string) {
+	var mac, ip string
+	for _, nic := range f.NICs {
+		if nic.MACAddress == "" {
+			continue
+		}
+		for _, address := range nic.Addresses {
+			parsed := net.ParseIP(address)
+			if parsed == nil || !parsed.IsGlobalUnicast() {
+				continue
+			}
+			if parsed.To4() != nil {
+				return nic.MACAddress, address
+			}
+			if ip == "" {
+				mac, ip = nic.MACAddress, address
+			}
+		}
+	}
+	return mac, ip
+}
+
+// MACAddresses returns the MAC addresses of all interfaces
+func (f *HostFacts) MACAddresses() []string {
+	var macs []string
+	for _, nic := range f.NICs {
+		if nic.MACAddress != "" {
+			macs = append(macs, nic.MACAddress)
+		}
+	}
+	return macs
+}
+
+// DiffHostFacts lists the facts that differ between two reports. Lists are compared entry by
+// entry so that, for example, a single upgraded package shows up as one change.
+func DiffHostFacts(oldFacts, newFacts *HostFacts) []FieldChange {
+	if oldFacts == nil {
+		oldFacts = &HostFacts{}
+	}
+
+	var changes []FieldChange
+	scalar := func(field string, oldValue, newValue interface{}) {
+		if oldValue != newValue {
+			changes = append(changes, FieldChange{FieldName: "facts." + field, OldValue: oldValue, NewValue: newValue})
+		}
+	}
+	scalar("os", oldFacts.OS, newFacts.OS)
+	scalar("kernel", oldFacts.Kernel, newFacts.Kernel)
+	scalar("architecture", oldFacts.Architecture, newFacts.Architecture)
+	scalar("cpu.model", oldFacts.CPU.Model, newFacts.CPU.Model)
+	scalar("cpu.cores", oldFacts.CPU.Cores, newFacts.CPU.Cores)
+	scalar("memoryBytes", oldFacts.MemoryBytes, newFacts.MemoryBytes)
+
+	disk := func(d DiskFacts) (string, string) {
+		return d.MountPoint, fmt.Sprintf("%s %s %d", d.Device, d.FSType, d.SizeBytes)
+	}
+	changes = append(changes, diffEntries("facts.disks", keyed(oldFacts.Disks, disk), keyed(newFacts.Disks, disk))...)
+
+	nic := func(n NICFacts) (string, string) {
+		return n.Name, strings.TrimSpace(n.MACAddress + " " + strings.Join(n.Addresses, ","))
+	}
+	changes = append(changes, diffEntries("facts.nics", keyed(oldFacts.NICs, nic), keyed(newFacts.NICs, nic))...)
+
+	pkg := func(p PackageFacts) (string, string) { return p.Name, p.Version }
+	changes = append(changes, diffEntries("facts.packages", keyed(oldFacts.Packages, pkg), keyed(newFacts.Packages, pkg))...)
+
+	port := func(p ListeningPort) (string, string) {
+		key := fmt.Sprintf("%s/%d", p.Protocol, p.Port)
+		return key, p.Address
+	}
+	changes = append(changes, diffEntries("facts.listeningPorts", keyedAll(oldFacts.ListeningPorts, port), keyedAll(newFacts.ListeningPorts, port))...)
+
+	return changes
+}
+
+// keyed indexes list entries by a key, describing each by a value
+func keyed[T any](entries []T, describe func(T) (string, string)) map[string]string {
+	m := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		key, value := describe(entry)
+		m[key] = value
+	}
+	return m
+}
+
+// keyedAll is keyed for lists where several entries may share a key; their values are joined
+func keyedAll[T any](entries []T, describe func(T) (string, string)) map[string]string {
+	values := make(map[string][]string)
+	for _, entry := range entries {
+		key, value := describe(entry)
+		values[key] = append(values[key], value)
+	}
+	m := make(map[string]string, len(values))
+	for key, list := range values {
+		sort.Strings(list)
+		m[key] = strings.Join(list, ",")
+	}
+	return m
+}
+
+// diffEntries compares two keyed lists. Added and removed entries have a nil old or new value.
+func diffEntries(field string, oldEntries, newEntries map[string]string) []FieldChange {
+	keys := make(map[string]bool)
+	for key := range oldEntries {
+		keys[key] = true
+	}
+	for key := range newEntries {
+		keys[key] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	var changes []FieldChange
+	for _, key := range sorted {
+		oldValue, hadOld := oldEntries[key]
+		newValue, hasNew := newEntries[key]
+		if hadOld && hasNew && oldValue == newValue {
+			continue
+		}
+		change := FieldChange{FieldName: field + "." + key}
+		if hadOld {
+			change.OldValue = oldValue
+		}
+		if hasNew {
+			change.NewValue = newValue
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
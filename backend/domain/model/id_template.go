@@ -0,0 +1,207 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ID template scopes besides asset types
+const (
+	// IDScopeAsset applies to every asset type without a template of its own
+	IDScopeAsset = "asset"
+	// IDScopeWorkflow applies to workflow IDs
+	IDScopeWorkflow = "workflow"
+)
+
+// Built-in ID templates; the asset template keeps the original SRV-001 format
+const (
+	DefaultAssetIDTemplate    = "{prefix}-{seq:03}"
+	DefaultWorkflowIDTemplate = "WF-{year}-{seq:06}"
+)
+
+// Placeholders available in ID templates
+var (
+	AssetIDPlaceholders    = []string{"prefix", "type", "site", "year", "month", "seq"}
+	WorkflowIDPlaceholders = []string{"year", "month", "seq"}
+)
+
+// IDTemplate defines how IDs are generated for an asset type or for workflows
+type IDTemplate struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Scope     string             `json:"scope" bson:"scope"`
+	Template  string             `json:"template" bson:"template"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+// NewIDTemplate creates a new ID template
+func NewIDTemplate(scope, template string) *IDTemplate {
+	now := time.Now()
+	return &IDTemplate{
+		Scope:     scope,
+		Template:  template,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// IDPattern is a template with every placeholder except the sequence filled in
+type IDPattern struct {
+	Prefix string
+	Suffix string
+	Width  int
+}
+
+// Key identifies the sequence the pattern draws numbers from
+func (p IDPattern) Key() string {
+	return p.Prefix + "{seq}" + p.Suffix
+}
+
+// Format builds the ID for a sequence number
+func (p IDPattern) Format(seq int64) string {
+	return p.Prefix + fmt.Sprintf("%0*d", p.Width, seq) + p.Suffix
+}
+
+// Regexp matches the IDs of the pattern and captures their sequence number
+func (p IDPattern) Regexp() string {
+	return "^" + regexp.QuoteMeta(p.Prefix) + `(\d+)` + regexp.QuoteMeta(p.Suffix) + "$"
+}
+
+// MaxSequence returns the highest sequence number among IDs of the pattern
+func (p IDPattern) MaxSequence(ids []string) int64 {
+	re := regexp.MustCompile(p.Regexp())
+	var max int64
+	for _, id := range ids {
+		match := re.FindStringSubmatch(id)
+		if match == nil {
+			continue
+		}
+		if seq, err := strconv.ParseInt(match[1], 10, 64); err == nil && seq > max {
+			max = seq
+		}
+	}
+	return max
+}
+
+// templatePlaceholder matches {name} and {name:05}
+var templatePlaceholder = regexp.MustCompile(`\{([a-z]+)(?::(\d+))?\}`)
+
+// ValidateIDTemplate checks that a template contains exactly one {seq} placeholder and no
+// placeholders outside allowed
+func ValidateIDTemplate(template string, allowed []string) error {
+	if strings.TrimSpace(template) == "" {
+		return errors.New("template is required")
+	}
+
+	sequences := 0
+	for _, match := range templatePlaceholder.FindAllStringSubmatch(template, -1) {
+		if !containsString(allowed, match[1]) {
+			return fmt.Errorf("unknown placeholder {%s}; allowed are %s", match[1], strings.Join(allowed, ", "))
+		}
+		if match[2] != "" && match[1] != "seq" {
+			return fmt.Errorf("only {seq} takes a width, not {%s}", match[1])
+		}
+		if match[1] == "seq" {
+			sequences++
+		}
+	}
+	if sequences != 1 {
+		return errors.New("template must contain exactly one {seq} placeholder")
+	}
+
+	rest := templatePlaceholder.ReplaceAllString(template, "")
+	if strings.ContainsAny(rest, "{}") {
+		return errors.New("template has an unterminated placeholder")
+	}
+
+	return nil
+}
+
+// RenderIDTemplate fills in every placeholder except {seq}
+func RenderIDTemplate(template string, values map[string]string) (IDPattern, error) {
+	var pattern IDPattern
+	var out strings.Builder
+	seen := false
+	last := 0
+
+	for _, loc := range templatePlaceholder.FindAllStringSubmatchIndex(template, -1) {
+		out.WriteString(template[last:loc[0]])
+		last = loc[1]
+
+		name := template[loc[2]:loc[3]]
+		if name != "seq" {
+			value, ok := values[name]
+			if !ok {
+				return IDPattern{}, fmt.Errorf("no value for placeholder {%s}", name)
+			}
+			out.WriteString(value)
+			continue
+		}
+
+		if seen {
+			return IDPattern{}, errors.New("template must contain exactly one {seq} placeholder")
+		}
+		seen = true
+		pattern.Width = 1
+		if loc[4] >= 0 {
+			width, err := strconv.Atoi(template[loc[4]:loc[5]])
+			if err != nil || width > 18 {
+				return IDPattern{}, fmt.Errorf("invalid sequence width %s", template[loc[4]:loc[5]])
+			}
+			pattern.Width = width
+		}
+		pattern.Prefix = out.String()
+		out.Reset()
+	}
+	out.WriteString(template[last:])
+
+	if !seen {
+		return IDPattern{}, errors.New("template must contain exactly one {seq} placeholder")
+	}
+	pattern.Suffix = out.String()
+
+	return pattern, nil
+}
+
+// AssetIDValues returns the placeholder values for an asset created at now. prefix is the
+// short code of the asset's CI type.
+func AssetIDValues(asset *Asset, prefix string, now time.Time) map[string]string {
+	if prefix == "" {
+		prefix = "AST"
+	}
+	return map[string]string{
+		"prefix": prefix,
+		"type":   idComponent(string(asset.Type)),
+		"site":   idComponent(asset.Location),
+		"year":   now.Format("2006"),
+		"month":  now.Format("01"),
+	}
+}
+
+// WorkflowIDValues returns the placeholder values for a workflow created at now
+func WorkflowIDValues(now time.Time) map[string]string {
+	return map[string]string{
+		"year":  now.Format("2006"),
+		"month": now.Format("01"),
+	}
+}
+
+// idComponent upper-cases a value and strips everything but letters and digits
+func idComponent(value string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(value) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() == 0 {
+		return "NA"
+	}
+	return b.String()
+}
@@ -0,0 +1,201 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CI types of Kubernetes objects
+const (
+	KubernetesClusterType     AssetType = "k8s_cluster"
+	KubernetesNodeType        AssetType = "k8s_node"
+	KubernetesNamespaceType   AssetType = "k8s_namespace"
+	KubernetesDeploymentType  AssetType = "k8s_deployment"
+	KubernetesStatefulSetType AssetType = "k8s_statefulset"
+	KubernetesServiceType     AssetType = "k8s_service"
+	KubernetesIngressType     AssetType = "k8s_ingress"
+)
+
+// KubernetesKind is the kind of a Kubernetes object
+type KubernetesKind string
+
+// Kubernetes kinds read by cluster discovery
+const (
+	KindNode        KubernetesKind = "Node"
+	KindNamespace   KubernetesKind = "Namespace"
+	KindDeployment  KubernetesKind = "Deployment"
+	KindStatefulSet KubernetesKind = "StatefulSet"
+	KindService     KubernetesKind = "Service"
+	KindIngress     KubernetesKind = "Ingress"
+)
+
+// kubernetesKindTypes maps each kind to the CI type of its objects
+var kubernetesKindTypes = map[KubernetesKind]AssetType{
+	KindNode:        KubernetesNodeType,
+	KindNamespace:   KubernetesNamespaceType,
+	KindDeployment:  KubernetesDeploymentType,
+	KindStatefulSet: KubernetesStatefulSetType,
+	KindService:     KubernetesServiceType,
+	KindIngress:     KubernetesIngressType,
+}
+
+// AssetType returns the CI type of objects of the kind
+func (k KubernetesKind) AssetType() AssetType {
+	return kubernetesKindTypes[k]
+}
+
+// Namespaced checks whether objects of the kind live in a namespace
+func (k KubernetesKind) Namespaced() bool {
+	return k != KindNode && k != KindNamespace
+}
+
+// IsKubernetesType checks whether a CI type holds Kubernetes objects
+func IsKubernetesType(assetType AssetType) bool {
+	if assetType == KubernetesClusterType {
+		return true
+	}
+	for _, t := range kubernetesKindTypes {
+		if t == assetType {
+			return true
+		}
+	}
+	return false
+}
+
+// kubernetesCITypes returns the built-in CI types of Kubernetes objects
+func kubernetesCITypes() []*CIType {
+	common := func(namespaced bool, attributes ...AttributeDefinition) []AttributeDefinition {
+		defs := []AttributeDefinition{
+			{Name: "cluster", Label: "Cluster", Type: AttributeString},
+			{Name: "uid", Label: "UID", Type: AttributeString},
+			{Name: "labels", Label: "Labels", Type: AttributeString},
+		}
+		if namespaced {
+			defs = append(defs, AttributeDefinition{Name: "namespace", Label: "Namespace", Type: AttributeString})
+		}
+		return append(defs, attributes...)
+	}
+	str := func(name, label string) AttributeDefinition {
+		return AttributeDefinition{Name: name, Label: label, Type: AttributeString}
+	}
+	integer := func(name, label string) AttributeDefinition {
+		return AttributeDefinition{Name: name, Label: label, Type: AttributeInteger}
+	}
+
+	return []*CIType{
+		{Name: KubernetesClusterType, DisplayName: "Kubernetes Cluster", Prefix: "K8C", Attributes: []AttributeDefinition{
+			str("server", "API Server"), str("version", "Version"),
+		}},
+		{Name: KubernetesNodeType, DisplayName: "Kubernetes Node", Prefix: "K8N", Attributes: common(false,
+			str("kubeletVersion", "Kubelet Version"), str("osImage", "OS Image"), str("kernelVersion", "Kernel Version"),
+			str("containerRuntime", "Container Runtime"), str("architecture", "Architecture"), str("providerID", "Provider ID"),
+			str("internalIP", "Internal IP"), str("cpu", "CPU Capacity"), str("memory", "Memory Capacity"),
+		)},
+		{Name: KubernetesNamespaceType, DisplayName: "Kubernetes Namespace", Prefix: "K8NS", Attributes: common(false,
+			str("phase", "Phase"),
+		)},
+		{Name: KubernetesDeploymentType, DisplayName: "Kubernetes Deployment", Prefix: "K8D", Attributes: common(true,
+			integer("replicas", "Replicas"), integer("readyReplicas", "Ready Replicas"), str("images", "Images"),
+		)},
+		{Name: KubernetesStatefulSetType, DisplayName: "Kubernetes StatefulSet", Prefix: "K8SS", Attributes: common(true,
+			integer("replicas", "Replicas"), integer("readyReplicas", "Ready Replicas"), str("images", "Images"),
+		)},
+		{Name: KubernetesServiceType, DisplayName: "Kubernetes Service", Prefix: "K8SVC", Attributes: common(true,
+			str("serviceType", "Service Type"), str("clusterIP", "Cluster IP"), str("ports", "Ports"),
+		)},
+		{Name: KubernetesIngressType, DisplayName: "Kubernetes Ingress", Prefix: "K8I", Attributes: common(true,
+			str("ingressClass", "Ingress Class"), str("hosts", "Hosts"),
+		)},
+	}
+}
+
+// KubernetesRef names an object in a cluster
+type KubernetesRef struct {
+	Kind      KubernetesKind `json:"kind"`
+	Namespace string         `json:"namespace,omitempty"`
+	Name      string         `json:"name"`
+}
+
+// KubernetesObject is an object read from a cluster, with the attributes of its CI type
+type KubernetesObject struct {
+	Kind       KubernetesKind         `json:"kind"`
+	UID        string                 `json:"uid"`
+	Name       string                 `json:"name"`
+	Namespace  string                 `json:"namespace,omitempty"`
+	Attributes map[string]interface{} `json:"attributes"`
+	// DependsOn lists the objects this one routes to, e.g. the services behind an ingress
+	DependsOn []KubernetesRef `json:"dependsOn,omitempty"`
+
+	// MachineID, Hostname and IPAddresses identify the server a node runs on
+	MachineID   string   `json:"machineId,omitempty"`
+	Hostname    string   `json:"hostname,omitempty"`
+	IPAddresses []string `json:"ipAddresses,omitempty"`
+}
+
+// Ref returns the name of the object
+func (o *KubernetesObject) Ref() KubernetesRef {
+	return KubernetesRef{Kind: o.Kind, Namespace: o.Namespace, Name: o.Name}
+}
+
+// KubernetesSnapshot is everything read from a cluster in one sync
+type KubernetesSnapshot struct {
+	Server  string             `json:"server"`
+	Version string             `json:"version"`
+	Objects []KubernetesObject `json:"objects"`
+}
+
+// KubernetesCluster is a cluster whose objects are synced into the CMDB
+type KubernetesCluster struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name        string             `json:"name" bson:"name"`
+	Description string             `json:"description" bson:"description"`
+	// KubeconfigPath is a kubeconfig file on the server; Context selects one of its contexts
+	KubeconfigPath string `json:"kubeconfigPath" bson:"kubeconfigPath"`
+	Context        string `json:"context,omitempty" bson:"context,omitempty"`
+	// Location is given to the CIs created for the cluster
+	Location string `json:"location" bson:"location"`
+	// IntervalMinutes schedules resyncs; zero means the cluster is only synced on demand
+	IntervalMinutes int         `json:"intervalMinutes" bson:"intervalMinutes"`
+	Enabled         bool        `json:"enabled" bson:"enabled"`
+	LastSyncAt      *time.Time  `json:"lastSyncAt,omitempty" bson:"lastSyncAt,omitempty"`
+	LastSync        *SyncResult `json:"lastSync,omitempty" bson:"lastSync,omitempty"`
+	CreatedBy       string      `json:"createdBy" bson:"createdBy"`
+	CreatedAt       time.Time   `json:"createdAt" bson:"createdAt"`
+	UpdatedAt       time.Time   `json:"updatedAt" bson:"updatedAt"`
+}
+
+// NewKubernetesCluster creates a new Kubernetes cluster
+func NewKubernetesCluster(name, kubeconfigPath, createdBy string) *KubernetesCluster {
+	now := time.Now()
+	return &KubernetesCluster{
+		Name:           name,
+		KubeconfigPath: kubeconfigPath,
+		Enabled:        true,
+		CreatedBy:      createdBy,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+}
+
+// IsDue checks whether a scheduled cluster should be synced at now
+func (c *KubernetesCluster) IsDue(now time.Time) bool {
+	if !c.Enabled || c.IntervalMinutes <= 0 {
+		return false
+	}
+	return c.LastSyncAt == nil || !now.Before(c.LastSyncAt.Add(time.Duration(c.IntervalMinutes)*time.Minute))
+}
+
+// RecordSync stores the result of a sync
+func (c *KubernetesCluster) RecordSync(result *SyncResult) {
+	c.LastSyncAt = &result.StartedAt
+	c.LastSync = result
+}
+
+// ExternalID identifies the CI of an object of the cluster; an empty UID gives the cluster's own CI
+func (c *KubernetesCluster) ExternalID(uid string) string {
+	if uid == "" {
+		return "k8s:" + c.ID.Hex()
+	}
+	return "k8s:" + c.ID.Hex() + ":" + uid
+}
@@ -0,0 +1,243 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrIllegalTransition is returned when an asset status change is not allowed by the lifecycle
+var ErrIllegalTransition = errors.New("illegal lifecycle transition")
+
+// LifecycleError explains why an asset cannot move from one status to another
+type LifecycleError struct {
+	From   AssetStatus
+	To     AssetStatus
+	Reason string
+}
+
+// Error implements the error interface
+func (e *LifecycleError) Error() string {
+	return fmt.Sprintf("cannot move asset from %s to %s: %s", e.From, e.To, e.Reason)
+}
+
+// Unwrap lets errors.Is match ErrIllegalTransition
+func (e *LifecycleError) Unwrap() error {
+	return ErrIllegalTransition
+}
+
+// LifecycleState describes an asset status and the fields an asset needs to enter it
+type LifecycleState struct {
+	Status         AssetStatus `json:"status"`
+	Description    string      `json:"description"`
+	Initial        bool        `json:"initial,omitempty"`
+	Terminal       bool        `json:"terminal,omitempty"`
+	RequiredFields []string    `json:"requiredFields,omitempty"`
+}
+
+// LifecycleTransition is an allowed status change
+type LifecycleTransition struct {
+	From             AssetStatus `json:"from"`
+	To               AssetStatus `json:"to"`
+	RequiresApproval bool        `json:"requiresApproval"`
+}
+
+// Lifecycle is the asset lifecycle state machine
+type Lifecycle struct {
+	States      []LifecycleState      `json:"states"`
+	Transitions []LifecycleTransition `json:"transitions"`
+}
+
+// lifecycleFields reports whether an asset has a value for each field a state can require
+var lifecycleFields = map[string]func(asset *Asset) bool{
+	"location":      func(a *Asset) bool { return strings.TrimSpace(a.Location) != "" },
+	"owner":         func(a *Asset) bool { return strings.TrimSpace(a.Owner) != "" },
+	"department":    func(a *Asset) bool { return strings.TrimSpace(a.Department) != "" },
+	"ipAddress":     func(a *Asset) bool { return strings.TrimSpace(a.IPAddress) != "" },
+	"purchasePrice": func(a *Asset) bool { return a.PurchasePrice > 0 },
+	"disposal":      func(a *Asset) bool { return a.Disposal != nil && strings.TrimSpace(a.Disposal.Method) != "" },
+}
+
+// DefaultLifecycle returns the built-in asset lifecycle
+func DefaultLifecycle() *Lifecycle {
+	return &Lifecycle{
+		States: []LifecycleState{
+			{Status: OrderedStatus, Description: "Purchased, not yet delivered", Initial: true},
+			{Status: ReceivedStatus, Description: "Delivered, awaiting intake", Initial: true},
+			{Status: InStockStatus, Description: "In storage and available", Initial: true, RequiredFields: []string{"location"}},
+			{Status: DeployedStatus, Description: "Installed at its location", RequiredFields: []string{"location"}},
+			{Status: OnlineStatus, Description: "In service", RequiredFields: []string{"location"}},
+			{Status: OfflineStatus, Description: "Registered but not in service", Initial: true},
+			{Status: MaintenanceStatus, Description: "Temporarily out of service for maintenance"},
+			{Status: RetiredStatus, Description: "Permanently out of service, kept for reuse or disposal"},
+			{Status: DecommissionedStatus, Description: "Removed from service"},
+			{Status: DisposedStatus, Description: "Left the organisation", Terminal: true, RequiredFields: []string{"disposal"}},
+		},
+		Transitions: []LifecycleTransition{
+			{From: OrderedStatus, To: ReceivedStatus},
+			{From: ReceivedStatus, To: InStockStatus},
+			{From: ReceivedStatus, To: DeployedStatus},
+			{From: InStockStatus, To: DeployedStatus},
+			{From: InStockStatus, To: RetiredStatus, RequiresApproval: true},
+			{From: DeployedStatus, To: OnlineStatus},
+			{From: DeployedStatus, To: InStockStatus},
+			{From: OnlineStatus, To: OfflineStatus},
+			{From: OnlineStatus, To: MaintenanceStatus, RequiresApproval: true},
+			{From: OnlineStatus, To: DecommissionedStatus, RequiresApproval: true},
+			{From: OfflineStatus, To: OnlineStatus},
+			{From: OfflineStatus, To: MaintenanceStatus, RequiresApproval: true},
+			{From: OfflineStatus, To: InStockStatus},
+			{From: OfflineStatus, To: RetiredStatus, RequiresApproval: true},
+			{From: OfflineStatus, To: DecommissionedStatus, RequiresApproval: true},
+			{From: MaintenanceStatus, To: OnlineStatus},
+			{From: MaintenanceStatus, To: OfflineStatus},
+			{From: MaintenanceStatus, To: DecommissionedStatus, RequiresApproval: true},
+			{From: RetiredStatus, To: InStockStatus, RequiresApproval: true},
+			{From: RetiredStatus, To: DisposedStatus, RequiresApproval: true},
+			{From: DecommissionedStatus, To: DisposedStatus, RequiresApproval: true},
+		},
+	}
+}
+
+// ParseLifecycle decodes and validates a lifecycle definition in JSON
+func ParseLifecycle(data []byte) (*Lifecycle, error) {
+	var lifecycle Lifecycle
+	if err := json.Unmarshal(data, &lifecycle); err != nil {
+		return nil, fmt.Errorf("invalid lifecycle definition: %w", err)
+	}
+	if err := lifecycle.Validate(); err != nil {
+		return nil, err
+	}
+	return &lifecycle, nil
+}
+
+// Validate checks that every transition connects known states, that required fields are
+// known and that terminal states have no way out
+func (l *Lifecycle) Validate() error {
+	if len(l.States) == 0 {
+		return errors.New("lifecycle needs at least one state")
+	}
+
+	hasInitial := false
+	for _, state := range l.States {
+		if state.Status == "" {
+			return errors.New("lifecycle state without a status")
+		}
+		for _, field := range state.RequiredFields {
+			if _, ok := lifecycleFields[field]; !ok {
+				return fmt.Errorf("state %s requires unknown field %s", state.Status, field)
+			}
+		}
+		hasInitial = hasInitial || state.Initial
+	}
+	if !hasInitial {
+		return errors.New("lifecycle needs at least one initial state")
+	}
+
+	for _, transition := range l.Transitions {
+		from, ok := l.State(transition.From)
+		if !ok {
+			return fmt.Errorf("transition from unknown state %s", transition.From)
+		}
+		if _, ok := l.State(transition.To); !ok {
+			return fmt.Errorf("transition to unknown state %s", transition.To)
+		}
+		if from.Terminal {
+			return fmt.Errorf("terminal state %s cannot have outgoing transitions", transition.From)
+		}
+	}
+
+	return nil
+}
+
+// State returns the definition of a status
+func (l *Lifecycle) State(status AssetStatus) (LifecycleState, bool) {
+	for _, state := range l.States {
+		if state.Status == status {
+			return state, true
+		}
+	}
+	return LifecycleState{}, false
+}
+
+// AllowedTransitions returns the transitions out of a status
+func (l *Lifecycle) AllowedTransitions(from AssetStatus) []LifecycleTransition {
+	var transitions []LifecycleTransition
+	for _, transition := range l.Transitions {
+		if transition.From == from {
+			transitions = append(transitions, transition)
+		}
+	}
+	return transitions
+}
+
+// CheckInitial checks that a new asset may start in its status and has the fields that status requires
+func (l *Lifecycle) CheckInitial(asset *Asset) error {
+	state, ok := l.State(asset.Status)
+	if !ok || !state.Initial {
+		var initial []string
+		for _, candidate := range l.States {
+			if candidate.Initial {
+				initial = append(initial, string(candidate.Status))
+			}
+		}
+		return &LifecycleError{To: asset.Status, From: "new", Reason: "new assets must start in one of " + strings.Join(initial, ", ")}
+	}
+
+	if missing := missingFields(asset, state.RequiredFields); len(missing) > 0 {
+		return &LifecycleError{To: asset.Status, From: "new", Reason: "missing required fields " + strings.Join(missing, ", ")}
+	}
+
+	return nil
+}
+
+// CheckTransition checks that the asset may move to a status and has the fields that status
+// requires. It returns the transition so callers can tell whether it needs approval.
+func (l *Lifecycle) CheckTransition(asset *Asset, to AssetStatus) (*LifecycleTransition, error) {
+	from := asset.Status
+
+	target, ok := l.State(to)
+	if !ok {
+		return nil, &LifecycleError{From: from, To: to, Reason: "unknown status"}
+	}
+	if from == to {
+		return nil, &LifecycleError{From: from, To: to, Reason: "asset is already in this status"}
+	}
+
+	var transition *LifecycleTransition
+	for i := range l.Transitions {
+		if l.Transitions[i].From == from && l.Transitions[i].To == to {
+			transition = &l.Transitions[i]
+			break
+		}
+	}
+	if transition == nil {
+		var allowed []string
+		for _, candidate := range l.AllowedTransitions(from) {
+			allowed = append(allowed, string(candidate.To))
+		}
+		reason := "no transitions are allowed from " + string(from)
+		if len(allowed) > 0 {
+			reason = "allowed next statuses are " + strings.Join(allowed, ", ")
+		}
+		return nil, &LifecycleError{From: from, To: to, Reason: reason}
+	}
+
+	if missing := missingFields(asset, target.RequiredFields); len(missing) > 0 {
+		return nil, &LifecycleError{From: from, To: to, Reason: "missing required fields " + strings.Join(missing, ", ")}
+	}
+
+	return transition, nil
+}
+
+// missingFields returns the required fields the asset has no value for
+func missingFields(asset *Asset, required []string) []string {
+	var missing []string
+	for _, field := range required {
+		if present, ok := lifecycleFields[field]; ok && !present(asset) {
+			missing = append(missing, field)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,271 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Sources users authenticate with
+const (
+	// LocalAuthSource users sign in with a password kept by the CMDB; users from before single
+	// sign-on have no source and are local too
+	LocalAuthSource = "local"
+	// OIDCAuthSource users sign in through the OpenID Connect identity provider and have no
+	// password
+	OIDCAuthSource = "oidc"
+)
+
+// ErrSSODisabled is returned when single sign-on is used but no identity provider is configured
+var ErrSSODisabled = errors.New("single sign-on is not configured")
+
+// ErrSSOStateInvalid is returned for a sign-in callback that does not answer a pending sign-in,
+// such as one that expired or was already completed
+var ErrSSOStateInvalid = errors.New("sign-in request is unknown or expired")
+
+// ErrSSOUserNotAllowed is returned when none of the user's groups grants a role and there is no
+// default role
+var ErrSSOUserNotAllowed = errors.New("user is not in a group allowed to sign in")
+
+// roleRank orders roles by privilege, so that a user in several groups gets the highest role
+var roleRank = map[UserRole]int{
+	ViewerRole:   1,
+	OperatorRole: 2,
+	ManagerRole:  3,
+	AdminRole:    4,
+}
+
+// OIDCConfig configures sign-in with an OpenID Connect identity provider using the authorization
+// code flow with PKCE, and how its users are provisioned
+type OIDCConfig struct {
+	Issuer       string   `json:"issuer"`
+	ClientID     string   `json:"clientId"`
+	ClientSecret string   `json:"clientSecret,omitempty"`
+	RedirectURL  string   `json:"redirectUrl"`
+	Scopes       []string `json:"scopes,omitempty"`
+
+	// UsernameClaim and GroupsClaim name the claims read from the ID token or userinfo; a dotted
+	// name such as realm_access.roles reads a nested claim
+	UsernameClaim string `json:"usernameClaim,omitempty"`
+	GroupsClaim   string `json:"groupsClaim,omitempty"`
+
+	// GroupMappings grant roles and permissions to the members of identity provider groups.
+	// Users in no mapped group get DefaultRole, or cannot sign in when it is empty.
+	GroupMappings []OIDCGroupMapping `json:"groupMappings"`
+	DefaultRole   UserRole           `json:"defaultRole,omitempty"`
+
+	// AllowLocalUsers keeps password sign-in for local users who are not admins; local admins
+	// can always sign in with their password, as a way in when the identity provider is down
+	AllowLocalUsers bool `json:"allowLocalUsers,omitempty"`
+
+	// PostLoginURL is the page of the web app that receives the tokens after sign-in
+	PostLoginURL string `json:"postLoginUrl,omitempty"`
+}
+
+// OIDCGroupMapping grants a role, and permissions beyond those of the role, to a group
+type OIDCGroupMapping struct {
+	Group       string       `json:"group"`
+	Role        UserRole     `json:"role"`
+	Permissions []Permission `json:"permissions,omitempty"`
+}
+
+// ParseOIDCConfig reads an identity provider configuration from JSON, fills in the default
+// scopes, claims and post-login page and validates it
+func ParseOIDCConfig(data []byte) (*OIDCConfig, error) {
+	var config OIDCConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "profile", "email", "groups"}
+	}
+	if config.UsernameClaim == "" {
+		config.UsernameClaim = "preferred_username"
+	}
+	if config.GroupsClaim == "" {
+		config.GroupsClaim = "groups"
+	}
+	if config.PostLoginURL == "" {
+		config.PostLoginURL = "/"
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// Validate checks the identity provider settings and that the mappings name known roles
+func (c *OIDCConfig) Validate() error {
+	if issuer, err := url.Parse(c.Issuer); err != nil || (issuer.Scheme != "https" && issuer.Scheme != "http") || issuer.Host == "" {
+		return fmt.Errorf("issuer must be an http or https URL")
+	}
+	if c.ClientID == "" {
+		return fmt.Errorf("clientId is required")
+	}
+	if redirect, err := url.Parse(c.RedirectURL); err != nil || !redirect.IsAbs() {
+		return fmt.Errorf("redirectUrl must be an absolute URL")
+	}
+	if !containsString(c.Scopes, "openid") {
+		return fmt.Errorf("scopes must include openid")
+	}
+	if c.DefaultRole != "" && roleRank[c.DefaultRole] == 0 {
+		return fmt.Errorf("unknown default role %q", c.DefaultRole)
+	}
+	for i, mapping := range c.GroupMappings {
+		if mapping.Group == "" {
+			return fmt.Errorf("group mapping %d: group is required", i+1)
+		}
+		if roleRank[mapping.Role] == 0 {
+			return fmt.Errorf("group mapping %s: unknown role %q", mapping.Group, mapping.Role)
+		}
+	}
+	return nil
+}
+
+// MapGroups returns the role and permissions of a member of groups: the highest role any of the
+// groups grants, with the permissions of that role and those the groups add
+func (c *OIDCConfig) MapGroups(groups []string) (UserRole, []Permission, error) {
+	var role UserRole
+	var extra []Permission
+	for _, mapping := range c.GroupMappings {
+		if !containsString(groups, mapping.Group) {
+			continue
+		}
+		if roleRank[mapping.Role] > roleRank[role] {
+			role = mapping.Role
+		}
+		extra = append(extra, mapping.Permissions...)
+	}
+	if role == "" {
+		role = c.DefaultRole
+	}
+	if role == "" {
+		return "", nil, ErrSSOUserNotAllowed
+	}
+
+	return role, mergePermissions(getDefaultPermissions(role), extra), nil
+}
+
+// mergePermissions combines permissions, listing each resource once with the actions of all
+func mergePermissions(sets ...[]Permission) []Permission {
+	var merged []Permission
+	index := make(map[string]int)
+	for _, set := range sets {
+		for _, permission := range set {
+			i, ok := index[permission.Resource]
+			if !ok {
+				i = len(merged)
+				index[permission.Resource] = i
+				merged = append(merged, Permission{Resource: permission.Resource})
+			}
+			for _, action := range permission.Actions {
+				if !containsString(merged[i].Actions, action) {
+					merged[i].Actions = append(merged[i].Actions, action)
+				}
+			}
+		}
+	}
+	return merged
+}
+
+// OIDCIdentity is a user as the identity provider vouched for them in a verified ID token
+type OIDCIdentity struct {
+	Issuer   string
+	Subject  string
+	Username string
+	Email    string
+	Name     string
+	Groups   []string
+}
+
+// ExternalID identifies the user across sign-ins; the subject is only unique per issuer
+func (i *OIDCIdentity) ExternalID() string {
+	return i.Issuer + "|" + i.Subject
+}
+
+// OIDCLogin is a sign-in in progress, from the redirect to the identity provider until its
+// callback. It holds the secrets that bind the callback to it.
+type OIDCLogin struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	State        string             `json:"state" bson:"state"`
+	Nonce        string             `json:"-" bson:"nonce"`
+	CodeVerifier string             `json:"-" bson:"codeVerifier"`
+	ReturnTo     string             `json:"returnTo" bson:"returnTo"`
+	CreatedAt    time.Time          `json:"createdAt" bson:"createdAt"`
+	ExpiresAt    time.Time          `json:"expiresAt" bson:"expiresAt"`
+}
+
+// NewOIDCLogin creates a sign-in that must complete within a lifetime
+func NewOIDCLogin(state, nonce, codeVerifier, returnTo string, lifetime time.Duration) *OIDCLogin {
+	now := time.Now()
+	return &OIDCLogin{
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		ReturnTo:     returnTo,
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(lifetime),
+	}
+}
+
+// IsExpired checks if the sign-in took too long
+func (l *OIDCLogin) IsExpired() bool {
+	return time.Now().After(l.ExpiresAt)
+}
+
+// NewOIDCUser provisions a user signing in through the identity provider for the first time
+func NewOIDCUser(identity *OIDCIdentity, role UserRole, permissions []Permission) *User {
+	now := time.Now()
+	return &User{
+		Username:    identity.Username,
+		Email:       identity.Email,
+		FullName:    identity.Name,
+		Role:        role,
+		Status:      ActiveStatus,
+		Permissions: permissions,
+		AuthSource:  OIDCAuthSource,
+		ExternalID:  identity.ExternalID(),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+// IsSSO checks if the user signs in through the identity provider rather than with a password
+func (u *User) IsSSO() bool {
+	return u.AuthSource == OIDCAuthSource
+}
+
+// SyncOIDCIdentity updates a user with what the identity provider now says about them, and
+// returns the old and new values of the fields that changed
+func (u *User) SyncOIDCIdentity(identity *OIDCIdentity, role UserRole, permissions []Permission) (map[string]interface{}, map[string]interface{}) {
+	oldValue := map[string]interface{}{}
+	newValue := map[string]interface{}{}
+	if u.Email != identity.Email {
+		oldValue["email"], newValue["email"] = u.Email, identity.Email
+		u.Email = identity.Email
+	}
+	if u.FullName != identity.Name {
+		oldValue["fullName"], newValue["fullName"] = u.FullName, identity.Name
+		u.FullName = identity.Name
+	}
+	if u.Role != role {
+		oldValue["role"], newValue["role"] = u.Role, role
+		u.Role = role
+	}
+	oldPermissions, _ := json.Marshal(u.Permissions)
+	newPermissions, _ := json.Marshal(permissions)
+	if string(oldPermissions) != string(newPermissions) {
+		oldValue["permissions"], newValue["permissions"] = u.Permissions, permissions
+		u.Permissions = permissions
+	}
+	if len(newValue) > 0 {
+		u.UpdatedAt = time.Now()
+	}
+	return oldValue, newValue
+}
@@ -0,0 +1,521 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Resolution is the outcome of weighing a reported field value against the current one
+type Resolution string
+
+// ConflictStatus represents the review state of a field conflict
+type ConflictStatus string
+
+// MergeProposalStatus represents the review state of a merge proposal
+type MergeProposalStatus string
+
+// Reconciliation constants
+const (
+	// Resolutions
+	ResolutionApply    Resolution = "apply"
+	ResolutionKeep     Resolution = "keep"
+	ResolutionConflict Resolution = "conflict"
+
+	// Conflict statuses
+	ConflictOpen       ConflictStatus = "open"
+	ConflictAccepted   ConflictStatus = "accepted"
+	ConflictOverridden ConflictStatus = "overridden"
+
+	// Merge proposal statuses
+	MergeProposalOpen      MergeProposalStatus = "open"
+	MergeProposalDismissed MergeProposalStatus = "dismissed"
+	MergeProposalMerged    MergeProposalStatus = "merged"
+
+	// attributeFieldPrefix prefixes the field names of CI type attributes, e.g. attributes.cpu
+	attributeFieldPrefix = "attributes."
+)
+
+// ErrConflictResolved is returned when a conflict has already been accepted or overridden
+var ErrConflictResolved = errors.New("conflict has already been resolved")
+
+// ErrMergeProposalReviewed is returned when a merge proposal has already been reviewed
+var ErrMergeProposalReviewed = errors.New("merge proposal has already been reviewed")
+
+// reconciledSources are the sources precedence rules can rank
+var reconciledSources = map[string]bool{
+	SourceAgent:      true,
+	SourceDiscovery:  true,
+	SourceImport:     true,
+	SourceKubernetes: true,
+	SourceCloud:      true,
+	SourceManual:     true,
+}
+
+// PrecedenceRule ranks the sources allowed to set a field
+type PrecedenceRule struct {
+	// Field is a field name or a pattern such as attributes.*
+	Field string `json:"field"`
+	// Sources lists the sources, highest precedence first
+	Sources []string `json:"sources"`
+}
+
+// rank returns the position of a source in the rule, or -1 when the rule does not list it
+func (r *PrecedenceRule) rank(source string) int {
+	for i, s := range r.Sources {
+		if s == source {
+			return i
+		}
+	}
+	return -1
+}
+
+// ReconciliationPolicy decides which source wins when sources report different values for
+// an asset field. The first rule whose field matches applies.
+type ReconciliationPolicy struct {
+	Rules []PrecedenceRule `json:"rules"`
+}
+
+// DefaultReconciliationPolicy returns the built-in precedence rules: what runs on or manages a
+// machine knows its network identity best, while people know best who owns it and what it is called
+func DefaultReconciliationPolicy() *ReconciliationPolicy {
+	network := []string{SourceAgent, SourceCloud, SourceKubernetes, SourceDiscovery, SourceImport, SourceManual}
+	descriptive := []string{SourceManual, SourceCloud, SourceKubernetes, SourceAgent, SourceImport, SourceDiscovery}
+
+	return &ReconciliationPolicy{
+		Rules: []PrecedenceRule{
+			{Field: "machineId", Sources: network},
+			{Field: "ipAddress", Sources: network},
+			{Field: "macAddress", Sources: network},
+			{Field: "hostname", Sources: network},
+			{Field: "osGuess", Sources: network},
+			{Field: "name", Sources: descriptive},
+			{Field: "location", Sources: descriptive},
+			{Field: "description", Sources: descriptive},
+			{Field: "owner", Sources: []string{SourceManual}},
+			{Field: "department", Sources: []string{SourceManual}},
+		},
+	}
+}
+
+// ParseReconciliationPolicy reads precedence rules from JSON and validates them
+func ParseReconciliationPolicy(data []byte) (*ReconciliationPolicy, error) {
+	var policy ReconciliationPolicy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, err
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// Validate checks that every rule names a reconciled field and ranks known sources once
+func (p *ReconciliationPolicy) Validate() error {
+	for i, rule := range p.Rules {
+		if _, err := path.Match(rule.Field, ""); err != nil || rule.Field == "" {
+			return fmt.Errorf("rule %d: invalid field %q", i+1, rule.Field)
+		}
+		if !strings.ContainsAny(rule.Field, "*?[") && !IsReconciledField(rule.Field) {
+			return fmt.Errorf("rule %d: field %s is not reconciled", i+1, rule.Field)
+		}
+		if len(rule.Sources) == 0 {
+			return fmt.Errorf("rule %d: at least one source is required", i+1)
+		}
+
+		seen := make(map[string]bool)
+		for _, source := range rule.Sources {
+			if !reconciledSources[source] {
+				return fmt.Errorf("rule %d: unknown source %s", i+1, source)
+			}
+			if seen[source] {
+				return fmt.Errorf("rule %d: source %s is listed twice", i+1, source)
+			}
+			seen[source] = true
+		}
+	}
+	return nil
+}
+
+// Rule finds the rule for a field, or nil when no rule matches
+func (p *ReconciliationPolicy) Rule(field string) *PrecedenceRule {
+	for i := range p.Rules {
+		if ok, _ := path.Match(p.Rules[i].Field, field); ok {
+			return &p.Rules[i]
+		}
+	}
+	return nil
+}
+
+// Resolve decides what happens to a value reported by source for a field whose current value
+// came from current. Empty fields, fields the same source set and fields without a rule take the
+// reported value. Otherwise the source ranked higher wins, and a source the rule does not rank
+// leaves the decision to a reviewer.
+func (p *ReconciliationPolicy) Resolve(field string, current FieldSource, currentEmpty bool, source string) Resolution {
+	if currentEmpty || current.Source == source {
+		return ResolutionApply
+	}
+	rule := p.Rule(field)
+	if rule == nil {
+		return ResolutionApply
+	}
+
+	have, want := rule.rank(current.Source), rule.rank(source)
+	switch {
+	case have < 0 || want < 0:
+		return ResolutionConflict
+	case want <= have:
+		return ResolutionApply
+	default:
+		return ResolutionKeep
+	}
+}
+
+// Outranks checks whether the rules rank a source strictly higher than another for a field
+func (p *ReconciliationPolicy) Outranks(field string, source, other FieldSource) bool {
+	rule := p.Rule(field)
+	if rule == nil {
+		return false
+	}
+	want, have := rule.rank(source.Source), rule.rank(other.Source)
+	return want >= 0 && have >= 0 && want < have
+}
+
+// assetField reads and writes one reconciled asset field
+type assetField struct {
+	get     func(a *Asset) interface{}
+	set     func(a *Asset, value json.RawMessage) error
+	restore func(dst, src *Asset)
+}
+
+// valueField accesses a field through a pointer to it
+func valueField[T any](ref func(a *Asset) *T) assetField {
+	return assetField{
+		get: func(a *Asset) interface{} { return *ref(a) },
+		set: func(a *Asset, value json.RawMessage) error {
+			var v T
+			if err := json.Unmarshal(value, &v); err != nil {
+				return err
+			}
+			*ref(a) = v
+			return nil
+		},
+		restore: func(dst, src *Asset) { *ref(dst) = *ref(src) },
+	}
+}
+
+// attributeField accesses a CI type attribute; null removes it
+func attributeField(name string) assetField {
+	return assetField{
+		get: func(a *Asset) interface{} { return a.Attributes[name] },
+		set: func(a *Asset, value json.RawMessage) error {
+			var v interface{}
+			if err := json.Unmarshal(value, &v); err != nil {
+				return err
+			}
+			setAttribute(a, name, v)
+			return nil
+		},
+		restore: func(dst, src *Asset) { setAttribute(dst, name, src.Attributes[name]) },
+	}
+}
+
+// setAttribute sets or, for nil, removes an attribute value
+func setAttribute(a *Asset, name string, value interface{}) {
+	if value == nil {
+		delete(a.Attributes, name)
+		return
+	}
+	if a.Attributes == nil {
+		a.Attributes = make(map[string]interface{})
+	}
+	a.Attributes[name] = value
+}
+
+// reconciledFields are the asset fields that sources report, in the order they are compared
+var reconciledFields = []string{
+	"name", "location", "description", "department", "owner",
+	"machineId", "ipAddress", "macAddress", "hostname", "osGuess", "services",
+	"annualCost", "currency", "tags",
+}
+
+// assetFields accesses the reconciled fields by name
+var assetFields = map[string]assetField{
+	"name":        valueField(func(a *Asset) *string { return &a.Name }),
+	"location":    valueField(func(a *Asset) *string { return &a.Location }),
+	"description": valueField(func(a *Asset) *string { return &a.Description }),
+	"department":  valueField(func(a *Asset) *string { return &a.Department }),
+	"owner":       valueField(func(a *Asset) *string { return &a.Owner }),
+	"machineId":   valueField(func(a *Asset) *string { return &a.MachineID }),
+	"ipAddress":   valueField(func(a *Asset) *string { return &a.IPAddress }),
+	"macAddress":  valueField(func(a *Asset) *string { return &a.MACAddress }),
+	"hostname":    valueField(func(a *Asset) *string { return &a.Hostname }),
+	"osGuess":     valueField(func(a *Asset) *string { return &a.OSGuess }),
+	"services":    valueField(func(a *Asset) *[]DiscoveredService { return &a.Services }),
+	"annualCost":  valueField(func(a *Asset) *float64 { return &a.AnnualCost }),
+	"currency":    valueField(func(a *Asset) *string { return &a.Currency }),
+	"tags":        valueField(func(a *Asset) *[]string { return &a.Tags }),
+}
+
+// lookupField finds the accessor of a reconciled field
+func lookupField(field string) (assetField, bool) {
+	if name, ok := strings.CutPrefix(field, attributeFieldPrefix); ok {
+		return attributeField(name), name != ""
+	}
+	f, ok := assetFields[field]
+	return f, ok
+}
+
+// IsReconciledField checks whether sources report a field, e.g. ipAddress or attributes.cpu
+func IsReconciledField(field string) bool {
+	_, ok := lookupField(field)
+	return ok
+}
+
+// FieldValue encodes the value of a reconciled field as JSON
+func (a *Asset) FieldValue(field string) (json.RawMessage, error) {
+	f, ok := lookupField(field)
+	if !ok {
+		return nil, fmt.Errorf("field %s is not reconciled", field)
+	}
+	return json.Marshal(f.get(a))
+}
+
+// SetFieldValue sets a reconciled field from its JSON encoding
+func (a *Asset) SetFieldValue(field string, value json.RawMessage) error {
+	f, ok := lookupField(field)
+	if !ok {
+		return fmt.Errorf("field %s is not reconciled", field)
+	}
+	if err := f.set(a, value); err != nil {
+		return fmt.Errorf("invalid value for %s: %w", field, err)
+	}
+	return nil
+}
+
+// RestoreField copies the value of a reconciled field from another version of the asset
+func (a *Asset) RestoreField(field string, from *Asset) {
+	if f, ok := lookupField(field); ok {
+		f.restore(a, from)
+	}
+}
+
+// IsFieldEmpty checks whether a reconciled field has no value
+func (a *Asset) IsFieldEmpty(field string) bool {
+	value, err := a.FieldValue(field)
+	if err != nil {
+		return true
+	}
+	switch string(value) {
+	case `""`, "0", "null", "[]":
+		return true
+	}
+	return false
+}
+
+// ChangedFields lists the reconciled fields whose values differ between two versions of an asset
+func ChangedFields(oldAsset, newAsset *Asset) []string {
+	fields := append([]string(nil), reconciledFields...)
+
+	names := make(map[string]bool)
+	for name := range oldAsset.Attributes {
+		names[name] = true
+	}
+	for name := range newAsset.Attributes {
+		names[name] = true
+	}
+	attributes := make([]string, 0, len(names))
+	for name := range names {
+		attributes = append(attributes, attributeFieldPrefix+name)
+	}
+	sort.Strings(attributes)
+	fields = append(fields, attributes...)
+
+	var changed []string
+	for _, field := range fields {
+		oldValue, _ := oldAsset.FieldValue(field)
+		newValue, _ := newAsset.FieldValue(field)
+		if bytes.Equal(oldValue, newValue) || (oldAsset.IsFieldEmpty(field) && newAsset.IsFieldEmpty(field)) {
+			continue
+		}
+		changed = append(changed, field)
+	}
+	return changed
+}
+
+// FieldSourceOf returns the provenance of a field's current value. Attributes fall back to the
+// provenance of the attributes as a whole; fields without provenance count as entered manually.
+func (a *Asset) FieldSourceOf(field string) FieldSource {
+	if source, ok := a.Provenance[field]; ok {
+		return source
+	}
+	if strings.HasPrefix(field, attributeFieldPrefix) {
+		if source, ok := a.Provenance["attributes"]; ok {
+			return source
+		}
+	}
+	return FieldSource{Source: SourceManual}
+}
+
+// RecordFieldSources records a source as the provenance of every reconciled field that differs
+// from an earlier version of the asset
+func (a *Asset) RecordFieldSources(oldAsset *Asset, source, reporter string) {
+	for _, field := range ChangedFields(oldAsset, a) {
+		a.SetFieldSource(field, source, reporter)
+	}
+}
+
+// FieldConflict is a reported field value that the precedence rules could not settle. It waits
+// for a reviewer to accept the reported value or override it. Values are stored as JSON.
+type FieldConflict struct {
+	ID             primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	AssetID        primitive.ObjectID `json:"assetId" bson:"assetId"`
+	AssetCode      string             `json:"assetCode" bson:"assetCode"`
+	AssetName      string             `json:"assetName" bson:"assetName"`
+	Field          string             `json:"field" bson:"field"`
+	CurrentValue   string             `json:"currentValue" bson:"currentValue"`
+	CurrentSource  FieldSource        `json:"currentSource" bson:"currentSource"`
+	ProposedValue  string             `json:"proposedValue" bson:"proposedValue"`
+	ProposedSource FieldSource        `json:"proposedSource" bson:"proposedSource"`
+	Status         ConflictStatus     `json:"status" bson:"status"`
+	// ReportCount counts how often the source reported a value the rules could not settle
+	ReportCount int        `json:"reportCount" bson:"reportCount"`
+	ResolvedBy  string     `json:"resolvedBy,omitempty" bson:"resolvedBy,omitempty"`
+	ResolvedAt  *time.Time `json:"resolvedAt,omitempty" bson:"resolvedAt,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt" bson:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt" bson:"updatedAt"`
+}
+
+// NewFieldConflict creates an open conflict between the current value of an asset field and
+// a value reported by another source
+func NewFieldConflict(asset *Asset, field string, proposedValue json.RawMessage, proposedSource FieldSource) *FieldConflict {
+	now := time.Now()
+	conflict := &FieldConflict{
+		AssetID:   asset.ID,
+		AssetCode: asset.AssetID,
+		Field:     field,
+		Status:    ConflictOpen,
+		CreatedAt: now,
+	}
+	conflict.Report(asset, proposedValue, proposedSource)
+	return conflict
+}
+
+// Report records that the source reported a value again
+func (c *FieldConflict) Report(asset *Asset, proposedValue json.RawMessage, proposedSource FieldSource) {
+	currentValue, _ := asset.FieldValue(c.Field)
+	c.AssetName = asset.Name
+	c.CurrentValue = string(currentValue)
+	c.CurrentSource = asset.FieldSourceOf(c.Field)
+	c.ProposedValue = string(proposedValue)
+	c.ProposedSource = proposedSource
+	c.ReportCount++
+	c.UpdatedAt = time.Now()
+}
+
+// IsOpen checks whether the conflict still waits for review
+func (c *FieldConflict) IsOpen() bool {
+	return c.Status == ConflictOpen
+}
+
+// Resolve closes the conflict
+func (c *FieldConflict) Resolve(status ConflictStatus, resolvedBy string) {
+	now := time.Now()
+	c.Status = status
+	c.ResolvedBy = resolvedBy
+	c.ResolvedAt = &now
+	c.UpdatedAt = now
+}
+
+// MergeMember is one of the assets a merge proposal covers
+type MergeMember struct {
+	ID      primitive.ObjectID `json:"id" bson:"id"`
+	AssetID string             `json:"assetId" bson:"assetId"`
+	Name    string             `json:"name" bson:"name"`
+	Type    AssetType          `json:"type" bson:"type"`
+}
+
+// MergeProposal suggests that two assets are the same physical machine reported under
+// different keys
+type MergeProposal struct {
+	ID primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	// Key identifies the pair of assets, see MergeProposalKey
+	Key    string        `json:"-" bson:"key"`
+	Assets []MergeMember `json:"assets" bson:"assets"`
+	// MatchedOn lists the identifiers the assets share, e.g. macAddress:00:11:22:33:44:55
+	MatchedOn []string `json:"matchedOn" bson:"matchedOn"`
+	// Score is the sum of the weights of the duplicate rules the assets matched
+	Score      float64             `json:"score" bson:"score"`
+	Status     MergeProposalStatus `json:"status" bson:"status"`
+	ReviewedBy string              `json:"reviewedBy,omitempty" bson:"reviewedBy,omitempty"`
+	ReviewedAt *time.Time          `json:"reviewedAt,omitempty" bson:"reviewedAt,omitempty"`
+	CreatedAt  time.Time           `json:"createdAt" bson:"createdAt"`
+	UpdatedAt  time.Time           `json:"updatedAt" bson:"updatedAt"`
+}
+
+// MergeProposalKey identifies a pair of assets regardless of their order
+func MergeProposalKey(a, b primitive.ObjectID) string {
+	if b.Hex() < a.Hex() {
+		a, b = b, a
+	}
+	return a.Hex() + ":" + b.Hex()
+}
+
+// NewMergeProposal creates an open proposal to merge two assets
+func NewMergeProposal(a, b *Asset, matchedOn []string, score float64) *MergeProposal {
+	if b.ID.Hex() < a.ID.Hex() {
+		a, b = b, a
+	}
+	now := time.Now()
+	proposal := &MergeProposal{
+		Key:       MergeProposalKey(a.ID, b.ID),
+		Status:    MergeProposalOpen,
+		CreatedAt: now,
+	}
+	proposal.Observe(a, b, matchedOn, score)
+	return proposal
+}
+
+// Observe refreshes the assets, shared identifiers and score of the proposal
+func (p *MergeProposal) Observe(a, b *Asset, matchedOn []string, score float64) {
+	if b.ID.Hex() < a.ID.Hex() {
+		a, b = b, a
+	}
+	p.Assets = []MergeMember{
+		{ID: a.ID, AssetID: a.AssetID, Name: a.Name, Type: a.Type},
+		{ID: b.ID, AssetID: b.AssetID, Name: b.Name, Type: b.Type},
+	}
+	p.MatchedOn = matchedOn
+	p.Score = score
+	p.UpdatedAt = time.Now()
+}
+
+// IsOpen checks whether the proposal still waits for review
+func (p *MergeProposal) IsOpen() bool {
+	return p.Status == MergeProposalOpen
+}
+
+// Dismiss marks the assets as distinct; later detection runs keep the proposal dismissed
+func (p *MergeProposal) Dismiss(reviewer string) {
+	now := time.Now()
+	p.Status = MergeProposalDismissed
+	p.ReviewedBy = reviewer
+	p.ReviewedAt = &now
+	p.UpdatedAt = now
+}
+
+// Merge marks the proposal as carried out
+func (p *MergeProposal) Merge(reviewer string) {
+	now := time.Now()
+	p.Status = MergeProposalMerged
+	p.ReviewedBy = reviewer
+	p.ReviewedAt = &now
+	p.UpdatedAt = now
+}
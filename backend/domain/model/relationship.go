@@ -0,0 +1,82 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RelationshipType represents the type of a relationship between two assets
+type RelationshipType string
+
+// Relationship type constants. A relationship reads as "source <type> target",
+// e.g. a database runs_on a server, or a server connects_to a switch.
+const (
+	RunsOnRelationship     RelationshipType = "runs_on"
+	ConnectsToRelationship RelationshipType = "connects_to"
+	DependsOnRelationship  RelationshipType = "depends_on"
+	BackedUpByRelationship RelationshipType = "backed_up_by"
+	MountsRelationship     RelationshipType = "mounts"
+	MemberOfRelationship   RelationshipType = "member_of"
+)
+
+// RelationshipTypes lists all supported relationship types
+var RelationshipTypes = []RelationshipType{
+	RunsOnRelationship,
+	ConnectsToRelationship,
+	DependsOnRelationship,
+	BackedUpByRelationship,
+	MountsRelationship,
+	MemberOfRelationship,
+}
+
+// Relationship represents a typed, directed edge between two assets
+type Relationship struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	SourceID    primitive.ObjectID `json:"sourceId" bson:"sourceId"`
+	TargetID    primitive.ObjectID `json:"targetId" bson:"targetId"`
+	Type        RelationshipType   `json:"type" bson:"type"`
+	Description string             `json:"description" bson:"description"`
+	CreatedBy   string             `json:"createdBy" bson:"createdBy"`
+	CreatedByID string             `json:"createdById" bson:"createdById"`
+	CreatedAt   time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt   time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+// NewRelationship creates a new relationship between two assets
+func NewRelationship(sourceID, targetID primitive.ObjectID, relationshipType RelationshipType, description, createdBy, createdByID string) *Relationship {
+	now := time.Now()
+	return &Relationship{
+		SourceID:    sourceID,
+		TargetID:    targetID,
+		Type:        relationshipType,
+		Description: description,
+		CreatedBy:   createdBy,
+		CreatedByID: createdByID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+// IsValid checks if the relationship type is one of the supported types
+func (t RelationshipType) IsValid() bool {
+	for _, known := range RelationshipTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Involves checks if the relationship has the given asset on either end
+func (r *Relationship) Involves(assetID primitive.ObjectID) bool {
+	return r.SourceID == assetID || r.TargetID == assetID
+}
+
+// OtherEnd returns the asset on the opposite end of the relationship
+func (r *Relationship) OtherEnd(assetID primitive.ObjectID) primitive.ObjectID {
+	if r.SourceID == assetID {
+		return r.TargetID
+	}
+	return r.SourceID
+}
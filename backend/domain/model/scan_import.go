@@ -0,0 +1,51 @@
+package model
+
+// ImportOutcome is what an imported scan did, or would do, to an asset
+type ImportOutcome string
+
+// Import outcomes
+const (
+	ImportCreated   ImportOutcome = "created"
+	ImportUpdated   ImportOutcome = "updated"
+	ImportUnchanged ImportOutcome = "unchanged"
+	// ImportFailed is reported for new assets that could not be saved
+	ImportFailed ImportOutcome = "failed"
+)
+
+// ImportedHost is the outcome of importing one scanned host
+type ImportedHost struct {
+	IPAddress string        `json:"ipAddress"`
+	Outcome   ImportOutcome `json:"outcome"`
+	// AssetID and Name identify the matched asset; new assets have an ID once created
+	AssetID string        `json:"assetId,omitempty"`
+	Name    string        `json:"name"`
+	Type    AssetType     `json:"type"`
+	Changes []FieldChange `json:"changes,omitempty"`
+}
+
+// ScanImport summarizes the import of a scan report. A dry run lists the same outcomes
+// without saving anything.
+type ScanImport struct {
+	Format    string         `json:"format"`
+	DryRun    bool           `json:"dryRun"`
+	Hosts     []ImportedHost `json:"hosts"`
+	Created   int            `json:"created"`
+	Updated   int            `json:"updated"`
+	Unchanged int            `json:"unchanged"`
+	Failed    int            `json:"failed"`
+}
+
+// Add records the outcome for a host and counts it
+func (i *ScanImport) Add(host ImportedHost) {
+	i.Hosts = append(i.Hosts, host)
+	switch host.Outcome {
+	case ImportCreated:
+		i.Created++
+	case ImportUpdated:
+		i.Updated++
+	case ImportUnchanged:
+		i.Unchanged++
+	case ImportFailed:
+		i.Failed++
+	}
+}
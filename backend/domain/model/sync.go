@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// SyncStatus is the outcome of a sync of CIs from an external system
+type SyncStatus string
+
+// Sync statuses
+const (
+	SyncCompleted SyncStatus = "completed"
+	SyncFailed    SyncStatus = "failed"
+)
+
+// SyncResult counts what a sync from an external system, such as a Kubernetes cluster or a
+// cloud account, did to the CIs mirrored from it
+type SyncResult struct {
+	Status         SyncStatus `json:"status" bson:"status"`
+	Error          string     `json:"error,omitempty" bson:"error,omitempty"`
+	TriggeredBy    string     `json:"triggeredBy" bson:"triggeredBy"`
+	Objects        int        `json:"objects" bson:"objects"`
+	Created        int        `json:"created" bson:"created"`
+	Updated        int        `json:"updated" bson:"updated"`
+	Unchanged      int        `json:"unchanged" bson:"unchanged"`
+	Decommissioned int        `json:"decommissioned" bson:"decommissioned"`
+	Relationships  int        `json:"relationships" bson:"relationships"`
+	StartedAt      time.Time  `json:"startedAt" bson:"startedAt"`
+	FinishedAt     time.Time  `json:"finishedAt" bson:"finishedAt"`
+}
@@ -44,6 +44,10 @@ type User struct {
 	Role        UserRole           `json:"role" bson:"role"`
 	Status      UserStatus         `json:"status" bson:"status"`
 	Permissions []Permission       `json:"permissions" bson:"permissions"`
+	Manager     string             `json:"manager,omitempty" bson:"manager,omitempty"`
+	AuthSource  string             `json:"authSource,omitempty" bson:"authSource,omitempty"`
+	ExternalID  string             `json:"externalId,omitempty" bson:"externalId,omitempty"`
+	ChannelIDs  map[string]string  `json:"channelIds,omitempty" bson:"channelIds"`
 	LastLoginAt *time.Time         `json:"lastLoginAt" bson:"lastLoginAt"`
 	CreatedAt   time.Time          `json:"createdAt" bson:"createdAt"`
 	UpdatedAt   time.Time          `json:"updatedAt" bson:"updatedAt"`
@@ -96,6 +100,25 @@ func (u *User) UpdateLastLogin() {
 	u.UpdatedAt = now
 }
 
+// SetManager sets the username of the user's line manager
+func (u *User) SetManager(manager string) {
+	u.Manager = manager
+	u.UpdatedAt = time.Now()
+}
+
+// SetChannelID sets the ID of the user's account at an approval channel; an empty ID clears it
+func (u *User) SetChannelID(channel, accountID string) {
+	if accountID == "" {
+		delete(u.ChannelIDs, channel)
+	} else {
+		if u.ChannelIDs == nil {
+			u.ChannelIDs = make(map[string]string)
+		}
+		u.ChannelIDs[channel] = accountID
+	}
+	u.UpdatedAt = time.Now()
+}
+
 // IsActive checks if the user is active
 func (u *User) IsActive() bool {
 	return u.Status == ActiveStatus
@@ -148,39 +171,46 @@ func getDefaultPermissions(role UserRole) []Permission {
 			{Resource: "workflows", Actions: []string{"read", "approve", "reject"}},
 			{Resource: "users", Actions: []string{"read"}},
 			{Resource: "reports", Actions: []string{"read"}},
+			{Resource: "alerts", Actions: []string{"read", "update", "manage"}},
 		}
 	case OperatorRole:
 		return []Permission{
 			{Resource: "assets", Actions: []string{"create", "read", "update"}},
 			{Resource: "workflows", Actions: []string{"read"}},
 			{Resource: "reports", Actions: []string{"read"}},
+			{Resource: "alerts", Actions: []string{"read", "update"}},
 		}
 	case ViewerRole:
 		return []Permission{
 			{Resource: "assets", Actions: []string{"read"}},
 			{Resource: "workflows", Actions: []string{"read"}},
 			{Resource: "reports", Actions: []string{"read"}},
+			{Resource: "alerts", Actions: []string{"read"}},
 		}
 	default:
 		return []Permission{}
 	}
 }
 
-// Session represents a user session
+// Session represents a refresh token of a user. Each refresh replaces the session with a new one
+// of the same family; the old one is kept, marked rotated, so that its reuse can be detected.
 type Session struct {
 	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
 	UserID    primitive.ObjectID `json:"userId" bson:"userId"`
-	Token     string             `json:"token" bson:"token"`
+	FamilyID  primitive.ObjectID `json:"familyId" bson:"familyId"`
+	Token     string             `json:"-" bson:"token"`
 	ExpiresAt time.Time          `json:"expiresAt" bson:"expiresAt"`
+	RotatedAt *time.Time         `json:"rotatedAt,omitempty" bson:"rotatedAt,omitempty"`
 	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
 }
 
-// NewSession creates a new session
-func NewSession(userID primitive.ObjectID, token string, duration time.Duration) *Session {
+// NewSession creates a new session of a family for a refresh token, of which only the hash is kept
+func NewSession(userID, familyID primitive.ObjectID, refreshToken string, duration time.Duration) *Session {
 	now := time.Now()
 	return &Session{
 		UserID:    userID,
-		Token:     token,
+		FamilyID:  familyID,
+		Token:     HashRefreshToken(refreshToken),
 		ExpiresAt: now.Add(duration),
 		CreatedAt: now,
 	}
@@ -25,11 +25,15 @@ const (
 	AssetDecommissionType  WorkflowType = "Asset Decommission"
 	StatusChangeType       WorkflowType = "Status Change"
 	MaintenanceRequestType WorkflowType = "Maintenance Request"
+	AssetRevertType        WorkflowType = "Asset Revert"
 
 	// Workflow Statuses
 	PendingStatus  WorkflowStatus = "pending"
 	ApprovedStatus WorkflowStatus = "approved"
 	RejectedStatus WorkflowStatus = "rejected"
+	// CompletedStatus and FailedStatus record the outcome of executing an approved workflow
+	CompletedStatus WorkflowStatus = "completed"
+	FailedStatus    WorkflowStatus = "failed"
 
 	// Workflow Priorities
 	LowPriority    WorkflowPriority = "low"
@@ -40,24 +44,45 @@ const (
 
 // Workflow represents an approval workflow in the CMDB domain
 type Workflow struct {
-	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	WorkflowID   string             `json:"workflowId" bson:"workflowId"`
-	Type         WorkflowType       `json:"type" bson:"type"`
-	AssetID      string             `json:"assetId" bson:"assetId"`
-	AssetName    string             `json:"assetName" bson:"assetName"`
-	Requester    string             `json:"requester" bson:"requester"`
-	RequesterID  string             `json:"requesterId" bson:"requesterId"`
-	Priority     WorkflowPriority   `json:"priority" bson:"priority"`
-	Status       WorkflowStatus     `json:"status" bson:"status"`
-	Reason       string             `json:"reason" bson:"reason"`
-	FeishuID     string             `json:"feishuId" bson:"feishuId"`
-	ApproverID   string             `json:"approverId,omitempty" bson:"approverId,omitempty"`
-	ApproverName string             `json:"approverName,omitempty" bson:"approverName,omitempty"`
-	ApprovedAt   *time.Time         `json:"approvedAt,omitempty" bson:"approvedAt,omitempty"`
-	Comments     string             `json:"comments,omitempty" bson:"comments,omitempty"`
-	Data         interface{}        `json:"data,omitempty" bson:"data,omitempty"`
-	CreatedAt    time.Time          `json:"createdAt" bson:"createdAt"`
-	UpdatedAt    time.Time          `json:"updatedAt" bson:"updatedAt"`
+	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	WorkflowID      string             `json:"workflowId" bson:"workflowId"`
+	Type            WorkflowType       `json:"type" bson:"type"`
+	AssetID         string             `json:"assetId" bson:"assetId"`
+	AssetName       string             `json:"assetName" bson:"assetName"`
+	Requester       string             `json:"requester" bson:"requester"`
+	RequesterID     string             `json:"requesterId" bson:"requesterId"`
+	Priority        WorkflowPriority   `json:"priority" bson:"priority"`
+	Status          WorkflowStatus     `json:"status" bson:"status"`
+	Reason          string             `json:"reason" bson:"reason"`
+	ApprovalChannel string             `json:"approvalChannel,omitempty" bson:"approvalChannel,omitempty"`
+	ExternalID      string             `json:"externalId,omitempty" bson:"externalId,omitempty"`
+	PolicyID        primitive.ObjectID `json:"policyId,omitempty" bson:"policyId,omitempty"`
+	PolicyName      string             `json:"policyName,omitempty" bson:"policyName,omitempty"`
+	Stages          []ApprovalStage    `json:"stages,omitempty" bson:"stages,omitempty"`
+	CurrentStage    int                `json:"currentStage" bson:"currentStage"`
+	DueAt           *time.Time         `json:"dueAt,omitempty" bson:"dueAt,omitempty"`
+	LastRemindedAt  *time.Time         `json:"lastRemindedAt,omitempty" bson:"lastRemindedAt,omitempty"`
+	RemindersSent   int                `json:"remindersSent" bson:"remindersSent"`
+	SLABreached     bool               `json:"slaBreached" bson:"slaBreached"`
+	EscalatedAt     *time.Time         `json:"escalatedAt,omitempty" bson:"escalatedAt,omitempty"`
+	ApproverID      string             `json:"approverId,omitempty" bson:"approverId,omitempty"`
+	ApproverName    string             `json:"approverName,omitempty" bson:"approverName,omitempty"`
+	ApprovedAt      *time.Time         `json:"approvedAt,omitempty" bson:"approvedAt,omitempty"`
+	Comments        string             `json:"comments,omitempty" bson:"comments,omitempty"`
+	ExecutedAt      *time.Time         `json:"executedAt,omitempty" bson:"executedAt,omitempty"`
+	ExecutionResult string             `json:"executionResult,omitempty" bson:"executionResult,omitempty"`
+	Data            interface{}        `json:"data,omitempty" bson:"data,omitempty"`
+	CreatedAt       time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt       time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+// WorkflowSLAStats summarises SLA compliance of workflows
+type WorkflowSLAStats struct {
+	Breached                 int64            `json:"breached"`
+	BreachedPending          int64            `json:"breachedPending"`
+	BreachedByPriority       map[string]int64 `json:"breachedByPriority"`
+	Approved                 int64            `json:"approved"`
+	MeanTimeToApproveSeconds float64          `json:"meanTimeToApproveSeconds"`
 }
 
 // NewWorkflow creates a new workflow with default values
@@ -87,6 +112,48 @@ func NewWorkflow(
 	}
 }
 
+// SetApprovalChain assigns the policy and stages the workflow has to pass
+func (w *Workflow) SetApprovalChain(policyID primitive.ObjectID, policyName string, stages []ApprovalStage) {
+	w.PolicyID = policyID
+	w.PolicyName = policyName
+	w.Stages = stages
+	w.CurrentStage = 0
+	w.UpdatedAt = time.Now()
+}
+
+// CurrentApprovalStage returns the stage awaiting a decision. Workflows created before
+// approval chains existed get the default single stage.
+func (w *Workflow) CurrentApprovalStage() *ApprovalStage {
+	if len(w.Stages) == 0 {
+		w.Stages = []ApprovalStage{DefaultApprovalStage()}
+		w.CurrentStage = 0
+	}
+	if w.CurrentStage < 0 || w.CurrentStage >= len(w.Stages) {
+		return nil
+	}
+	return &w.Stages[w.CurrentStage]
+}
+
+// ApproveStage approves the current stage and moves to the next one. It reports
+// whether this was the final stage, in which case the workflow itself is approved.
+func (w *Workflow) ApproveStage(approverID, approverName, comments string) bool {
+	stage := w.CurrentApprovalStage()
+	if stage == nil {
+		return false
+	}
+
+	stage.decide(ApprovedStatus, approverID, approverName, comments)
+	w.CurrentStage++
+	w.UpdatedAt = time.Now()
+
+	if w.CurrentStage < len(w.Stages) {
+		return false
+	}
+
+	w.Approve(approverID, approverName, comments)
+	return true
+}
+
 // Approve approves the workflow and updates the UpdatedAt timestamp
 func (w *Workflow) Approve(approverID, approverName, comments string) {
 	now := time.Now()
@@ -98,8 +165,11 @@ func (w *Workflow) Approve(approverID, approverName, comments string) {
 	w.UpdatedAt = now
 }
 
-// Reject rejects the workflow and updates the UpdatedAt timestamp
+// Reject rejects the workflow, recording the decision on the current stage, and updates the UpdatedAt timestamp
 func (w *Workflow) Reject(approverID, approverName, comments string) {
+	if stage := w.CurrentApprovalStage(); stage != nil {
+		stage.decide(RejectedStatus, approverID, approverName, comments)
+	}
 	w.Status = RejectedStatus
 	w.ApproverID = approverID
 	w.ApproverName = approverName
@@ -107,9 +177,66 @@ func (w *Workflow) Reject(approverID, approverName, comments string) {
 	w.UpdatedAt = time.Now()
 }
 
-// SetFeishuID sets the Feishu ID for the workflow
-func (w *Workflow) SetFeishuID(feishuID string) {
-	w.FeishuID = feishuID
+// SetDueAt sets the time by which the workflow should be decided
+func (w *Workflow) SetDueAt(dueAt time.Time) {
+	w.DueAt = &dueAt
+	w.UpdatedAt = time.Now()
+}
+
+// IsOverdue checks if a pending workflow has passed its due time
+func (w *Workflow) IsOverdue(now time.Time) bool {
+	return w.IsPending() && w.DueAt != nil && now.After(*w.DueAt)
+}
+
+// MarkReminded records that approvers have been reminded of the workflow
+func (w *Workflow) MarkReminded(now time.Time) {
+	w.LastRemindedAt = &now
+	w.RemindersSent++
+	w.UpdatedAt = now
+}
+
+// Escalate marks the SLA as breached and lets the backup approvers decide the current stage
+func (w *Workflow) Escalate(now time.Time, users []string, roles []UserRole) {
+	if stage := w.CurrentApprovalStage(); stage != nil {
+		stage.addBackupApprovers(users, roles)
+	}
+	w.SLABreached = true
+	w.EscalatedAt = &now
+	w.UpdatedAt = now
+}
+
+// ApproveAllStages approves every remaining stage at once, approving the workflow
+func (w *Workflow) ApproveAllStages(approverID, approverName, comments string) {
+	for w.IsPending() && !w.ApproveStage(approverID, approverName, comments) {
+		if w.CurrentApprovalStage() == nil {
+			w.Approve(approverID, approverName, comments)
+		}
+	}
+}
+
+// Complete marks an approved workflow as executed and records the result
+func (w *Workflow) Complete(result string) {
+	now := time.Now()
+	w.Status = CompletedStatus
+	w.ExecutedAt = &now
+	w.ExecutionResult = result
+	w.UpdatedAt = now
+}
+
+// Fail marks an approved workflow whose execution failed and records the reason
+func (w *Workflow) Fail(reason string) {
+	now := time.Now()
+	w.Status = FailedStatus
+	w.ExecutedAt = &now
+	w.ExecutionResult = reason
+	w.UpdatedAt = now
+}
+
+// SetApprovalReference records the approval channel and the external ID the workflow was sent to
+func (w *Workflow) SetApprovalReference(channel, externalID string) {
+	w.ApprovalChannel = channel
+	w.ExternalID = externalID
+	w.UpdatedAt = time.Now()
 }
 
 // IsPending checks if the workflow is pending
@@ -122,6 +249,16 @@ func (w *Workflow) IsApproved() bool {
 	return w.Status == ApprovedStatus
 }
 
+// IsCompleted checks if the approved workflow was executed successfully
+func (w *Workflow) IsCompleted() bool {
+	return w.Status == CompletedStatus
+}
+
+// IsFailed checks if the approved workflow failed to execute
+func (w *Workflow) IsFailed() bool {
+	return w.Status == FailedStatus
+}
+
 // IsRejected checks if the workflow is rejected
 func (w *Workflow) IsRejected() bool {
 	return w.Status == RejectedStatus
@@ -161,3 +298,8 @@ func (w *Workflow) IsAssetUpdate() bool {
 func (w *Workflow) IsAssetDelete() bool {
 	return w.Type == AssetDeleteType
 }
+
+// IsAssetRevert checks if the workflow is an asset revert workflow
+func (w *Workflow) IsAssetRevert() bool {
+	return w.Type == AssetRevertType
+}
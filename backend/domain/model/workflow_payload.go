@@ -0,0 +1,159 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// WorkflowPayloadVersion is the current version of the workflow change payload
+const WorkflowPayloadVersion = 1
+
+// ErrMissingWorkflowPayload is returned when a workflow that applies a change carries no payload
+var ErrMissingWorkflowPayload = errors.New("workflow has no change payload")
+
+// AssetFields holds the editable asset fields captured by create and update workflows
+type AssetFields struct {
+	Name        string    `json:"name" bson:"name"`
+	Type        AssetType `json:"type,omitempty" bson:"type,omitempty"`
+	Location    string    `json:"location" bson:"location"`
+	Description string    `json:"description" bson:"description"`
+	// Attributes holds the CI type attributes; nil leaves them unchanged on update
+	Attributes map[string]interface{} `json:"attributes,omitempty" bson:"attributes,omitempty"`
+}
+
+// AssetFieldsOf captures the editable fields of an asset
+func AssetFieldsOf(asset *Asset) AssetFields {
+	return AssetFields{
+		Name:        asset.Name,
+		Type:        asset.Type,
+		Location:    asset.Location,
+		Description: asset.Description,
+		Attributes:  asset.Attributes,
+	}
+}
+
+// WorkflowPayload is the change a workflow applies once approved. Version identifies the
+// schema so that workflows stored by older releases can still be applied.
+type WorkflowPayload struct {
+	Version int `json:"version" bson:"version"`
+	// Original holds the fields as they were when the change was requested (updates only)
+	Original *AssetFields `json:"original,omitempty" bson:"original,omitempty"`
+	// Requested holds the fields to apply (creates and updates)
+	Requested *AssetFields `json:"requested,omitempty" bson:"requested,omitempty"`
+	// TargetStatus and Disposal describe a lifecycle transition (status changes only)
+	TargetStatus AssetStatus     `json:"targetStatus,omitempty" bson:"targetStatus,omitempty"`
+	Disposal     *DisposalRecord `json:"disposal,omitempty" bson:"disposal,omitempty"`
+	// Revert holds the fields to restore from a history revision (reverts only)
+	Revert *AssetRevert `json:"revert,omitempty" bson:"revert,omitempty"`
+}
+
+// NewAssetCreatePayload creates the payload of an asset create workflow
+func NewAssetCreatePayload(requested AssetFields) *WorkflowPayload {
+	return &WorkflowPayload{
+		Version:   WorkflowPayloadVersion,
+		Requested: &requested,
+	}
+}
+
+// NewAssetUpdatePayload creates the payload of an asset update workflow
+func NewAssetUpdatePayload(original, requested AssetFields) *WorkflowPayload {
+	return &WorkflowPayload{
+		Version:   WorkflowPayloadVersion,
+		Original:  &original,
+		Requested: &requested,
+	}
+}
+
+// NewStatusChangePayload creates the payload of a status change workflow
+func NewStatusChangePayload(targetStatus AssetStatus, disposal *DisposalRecord) *WorkflowPayload {
+	return &WorkflowPayload{
+		Version:      WorkflowPayloadVersion,
+		TargetStatus: targetStatus,
+		Disposal:     disposal,
+	}
+}
+
+// NewAssetRevertPayload creates the payload of an asset revert workflow
+func NewAssetRevertPayload(revert AssetRevert) *WorkflowPayload {
+	return &WorkflowPayload{
+		Version: WorkflowPayloadVersion,
+		Revert:  &revert,
+	}
+}
+
+// ConflictsWith reports whether an asset changed since an update was requested in any field the
+// update overwrites. Attributes count only when the update replaces them, and are compared one
+// by one by their printed values, as in the asset history, since they are decoded with
+// different number types.
+func (p *WorkflowPayload) ConflictsWith(asset *Asset) bool {
+	original := p.Original
+	if asset.Name != original.Name || asset.Location != original.Location || asset.Description != original.Description {
+		return true
+	}
+	if p.Requested.Attributes == nil {
+		return false
+	}
+	if len(asset.Attributes) != len(original.Attributes) {
+		return true
+	}
+	for name, value := range asset.Attributes {
+		originalValue, ok := original.Attributes[name]
+		if !ok || fmt.Sprint(value) != fmt.Sprint(originalValue) {
+			return true
+		}
+	}
+	return false
+}
+
+// legacyUpdatePayload is the untyped update payload stored before payloads were versioned
+type legacyUpdatePayload struct {
+	OriginalName        string `bson:"originalName"`
+	OriginalLocation    string `bson:"originalLocation"`
+	OriginalDescription string `bson:"originalDescription"`
+	NewName             string `bson:"newName"`
+	NewLocation         string `bson:"newLocation"`
+	NewDescription      string `bson:"newDescription"`
+}
+
+// Payload decodes the change payload from the workflow data. Data read back from the
+// database is a generic document, so it is round-tripped through BSON. Update payloads
+// stored before versioning are converted.
+func (w *Workflow) Payload() (*WorkflowPayload, error) {
+	if w.Data == nil {
+		return nil, ErrMissingWorkflowPayload
+	}
+	if payload, ok := w.Data.(*WorkflowPayload); ok {
+		return payload, nil
+	}
+
+	raw, err := bson.Marshal(w.Data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid workflow payload: %w", err)
+	}
+
+	var payload WorkflowPayload
+	if err := bson.Unmarshal(raw, &payload); err != nil {
+		return nil, fmt.Errorf("invalid workflow payload: %w", err)
+	}
+
+	switch payload.Version {
+	case WorkflowPayloadVersion:
+		return &payload, nil
+	case 0:
+		if w.Type != AssetUpdateType {
+			return nil, ErrMissingWorkflowPayload
+		}
+		var legacy legacyUpdatePayload
+		if err := bson.Unmarshal(raw, &legacy); err != nil {
+			return nil, fmt.Errorf("invalid workflow payload: %w", err)
+		}
+		return NewAssetUpdatePayload(
+			AssetFields{Name: legacy.OriginalName, Location: legacy.OriginalLocation, Description: legacy.OriginalDescription},
+			AssetFields{Name: legacy.NewName, Location: legacy.NewLocation, Description: legacy.NewDescription},
+		), nil
+	default:
+		return nil, fmt.Errorf("unsupported workflow payload version %d", payload.Version)
+	}
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AgentTokenRepository defines the interface for agent token data access
+type AgentTokenRepository interface {
+	// FindByID finds an agent token by its ID
+	FindByID(ctx context.Context, id primitive.ObjectID) (*model.AgentToken, error)
+
+	// FindByHash finds an agent token by the hash of its secret
+	FindByHash(ctx context.Context, tokenHash string) (*model.AgentToken, error)
+
+	// FindAll finds all agent tokens
+	FindAll(ctx context.Context) ([]*model.AgentToken, error)
+
+	// Save creates or updates an agent token
+	Save(ctx context.Context, token *model.AgentToken) error
+
+	// MarkUsed records that a token authenticated a request unless it has been revoked, and
+	// reports whether it was
+	MarkUsed(ctx context.Context, id primitive.ObjectID) (bool, error)
+}
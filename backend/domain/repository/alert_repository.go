@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AlertRepository defines the interface for alert and alert rule persistence
+type AlertRepository interface {
+	// Alert operations
+	FindByID(ctx context.Context, id primitive.ObjectID) (*model.Alert, error)
+	FindAll(ctx context.Context, filter map[string]interface{}) ([]*model.Alert, error)
+	FindOpenByRuleAndAsset(ctx context.Context, ruleID, assetID primitive.ObjectID) (*model.Alert, error)
+	Save(ctx context.Context, alert *model.Alert) error
+	UpdateCurrentValue(ctx context.Context, id primitive.ObjectID, value interface{}) error
+	MarkAcknowledged(ctx context.Context, alert *model.Alert) (bool, error)
+	MarkResolved(ctx context.Context, alert *model.Alert) (bool, error)
+	MarkClosed(ctx context.Context, alert *model.Alert) (bool, error)
+	GetAlertStats(ctx context.Context) (map[string]int64, error)
+
+	// Rule operations
+	FindRuleByID(ctx context.Context, id primitive.ObjectID) (*model.AlertRule, error)
+	FindRules(ctx context.Context, enabledOnly bool) ([]*model.AlertRule, error)
+	SaveRule(ctx context.Context, rule *model.AlertRule) error
+	DeleteRule(ctx context.Context, id primitive.ObjectID) error
+}
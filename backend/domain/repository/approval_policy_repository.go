@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ApprovalPolicyRepository defines the interface for approval policy data access
+type ApprovalPolicyRepository interface {
+	// FindByID finds an approval policy by its ID
+	FindByID(ctx context.Context, id primitive.ObjectID) (*model.ApprovalPolicy, error)
+
+	// FindAll finds all approval policies, optionally only the enabled ones
+	FindAll(ctx context.Context, enabledOnly bool) ([]*model.ApprovalPolicy, error)
+
+	// Save creates or updates an approval policy
+	Save(ctx context.Context, policy *model.ApprovalPolicy) error
+
+	// Delete deletes an approval policy by its ID
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
@@ -13,6 +13,9 @@ type AssetHistoryRepository interface {
 	// Create adds a new asset history record
 	Create(ctx context.Context, history *model.AssetHistory) error
 
+	// FindByID finds a history record by ID
+	FindByID(ctx context.Context, id primitive.ObjectID) (*model.AssetHistory, error)
+
 	// FindByAssetID finds all history records for a specific asset
 	FindByAssetID(ctx context.Context, assetID primitive.ObjectID, limit int) ([]*model.AssetHistory, error)
 
@@ -22,7 +25,7 @@ type AssetHistoryRepository interface {
 	// FindByChangeType finds history records by change type
 	FindByChangeType(ctx context.Context, assetID primitive.ObjectID, changeType string) ([]*model.AssetHistory, error)
 
-	// FindByUser finds history records by user
+	// FindByUser finds history records changed or approved by a user
 	FindByUser(ctx context.Context, userID string, limit int) ([]*model.AssetHistory, error)
 
 	// GetLatestChange gets the most recent change for an asset
@@ -33,4 +36,7 @@ type AssetHistoryRepository interface {
 
 	// DeleteOlderThan deletes history records older than the specified time
 	DeleteOlderThan(ctx context.Context, timestamp time.Time) error
+	// ReassignAsset moves the history of an asset merged into another one to the surviving
+	// asset, marking the records with the asset ID of the merged asset
+	ReassignAsset(ctx context.Context, fromID, toID primitive.ObjectID, mergedFrom string) (int64, error)
 }
@@ -36,6 +36,6 @@ type AssetRepository interface {
 	// GetAssetStats gets asset statistics by status
 	GetAssetStats(ctx context.Context) (map[string]int64, error)
 
-	// GenerateAssetID generates a unique asset ID based on type
-	GenerateAssetID(ctx context.Context, assetType string) (string, error)
+	// FindAssetIDsMatching finds the asset IDs matching a regular expression
+	FindAssetIDsMatching(ctx context.Context, pattern string) ([]string, error)
 }
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+)
+
+// AuditArchiveRepository defines the interface for the records of archived audit log segments
+type AuditArchiveRepository interface {
+	// Create adds the record of an archived segment
+	Create(ctx context.Context, archive *model.AuditArchive) error
+
+	// FindAll finds the records of all archived segments, in chain order
+	FindAll(ctx context.Context) ([]*model.AuditArchive, error)
+}
+
+// AuditArchiveStore keeps the entries of archived audit log segments
+type AuditArchiveStore interface {
+	// Write stores the entries of a segment and returns where they were stored
+	Write(ctx context.Context, archive *model.AuditArchive, entries []*model.AuditLog) (string, error)
+
+	// Read returns the entries stored for a segment, in the order they were written
+	Read(ctx context.Context, archive *model.AuditArchive) ([]*model.AuditLog, error)
+}
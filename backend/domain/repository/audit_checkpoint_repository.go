@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+)
+
+// AuditCheckpointRepository defines the interface for audit log checkpoint persistence
+type AuditCheckpointRepository interface {
+	// Create adds a new checkpoint
+	Create(ctx context.Context, checkpoint *model.AuditCheckpoint) error
+
+	// FindLatest finds the checkpoint with the highest sequence, or returns nil when there is none
+	FindLatest(ctx context.Context) (*model.AuditCheckpoint, error)
+
+	// FindLatestBefore finds the last checkpoint created before a time, or returns nil when
+	// there is none
+	FindLatestBefore(ctx context.Context, before time.Time) (*model.AuditCheckpoint, error)
+
+	// FindSequenceRange finds the checkpoints of entries from one sequence to another, in
+	// chain order
+	FindSequenceRange(ctx context.Context, from, to int64) ([]*model.AuditCheckpoint, error)
+
+	// FindRecent finds the most recent checkpoints, newest first
+	FindRecent(ctx context.Context, limit int) ([]*model.AuditCheckpoint, error)
+}
@@ -15,8 +15,8 @@ type AuditLogRepository interface {
 	// FindByUserID finds all audit logs for a specific user
 	FindByUserID(ctx context.Context, userID string, limit int) ([]*model.AuditLog, error)
 
-	// FindByResourceID finds all audit logs for a specific resource
-	FindByResourceID(ctx context.Context, resourceType, resourceID string, limit int) ([]*model.AuditLog, error)
+	// FindByResourceIDs finds all audit logs for any of the resources of a type with the given IDs
+	FindByResourceIDs(ctx context.Context, resourceType string, resourceIDs []string, limit int) ([]*model.AuditLog, error)
 
 	// FindByDateRange finds all audit logs within a date range
 	FindByDateRange(ctx context.Context, start, end time.Time, limit int) ([]*model.AuditLog, error)
@@ -30,8 +30,22 @@ type AuditLogRepository interface {
 	// Count returns the total number of audit logs matching the criteria
 	Count(ctx context.Context, criteria AuditLogSearchCriteria) (int64, error)
 
-	// DeleteOlderThan deletes audit logs older than the specified time
-	DeleteOlderThan(ctx context.Context, timestamp time.Time) error
+	// FindLatest finds the last audit log of the hash chain, or returns nil when there is none
+	FindLatest(ctx context.Context) (*model.AuditLog, error)
+
+	// FindBySequence finds the audit log with a sequence number
+	FindBySequence(ctx context.Context, sequence int64) (*model.AuditLog, error)
+
+	// FindSequenceRange finds up to limit audit logs with sequence numbers from one to another,
+	// in chain order
+	FindSequenceRange(ctx context.Context, from, to int64, limit int) ([]*model.AuditLog, error)
+
+	// DeleteThroughSequence deletes the audit logs of the hash chain up to a sequence number.
+	// Entries are only deleted once they are archived.
+	DeleteThroughSequence(ctx context.Context, sequence int64) (int64, error)
+
+	// FindReassignedTo finds the IDs of the resources of a type that were reassigned to a resource
+	FindReassignedTo(ctx context.Context, resourceType, resourceID string) ([]string, error)
 }
 
 // AuditLogSearchCriteria defines search criteria for audit logs
@@ -41,12 +55,14 @@ type AuditLogSearchCriteria struct {
 	Action       string
 	ResourceType string
 	ResourceID   string
-	StartDate    time.Time
-	EndDate      time.Time
-	Success      *bool
-	IPAddress    string
-	Page         int
-	Limit        int
-	SortBy       string
-	SortOrder    string
+	// ResourceIDs matches any of several resources instead of ResourceID
+	ResourceIDs []string
+	StartDate   time.Time
+	EndDate     time.Time
+	Success     *bool
+	IPAddress   string
+	Page        int
+	Limit       int
+	SortBy      string
+	SortOrder   string
 }
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+)
+
+// ErrAuditSpoolFull is returned when audit logs would make a spool exceed its size limit
+var ErrAuditSpoolFull = errors.New("audit spool is full")
+
+// AuditSpool buffers, in order, the audit logs an audit stream sink could not take yet
+type AuditSpool interface {
+	// Append adds audit logs to the end of the spool
+	Append(ctx context.Context, logs []*model.AuditLog) error
+
+	// Peek returns up to limit audit logs from the start of the spool without removing them
+	Peek(ctx context.Context, limit int) ([]*model.AuditLog, error)
+
+	// Discard removes audit logs from the start of the spool once they were sent
+	Discard(ctx context.Context, count int) error
+}
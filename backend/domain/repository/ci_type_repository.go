@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+)
+
+// CITypeRepository defines the interface for CI type data access
+type CITypeRepository interface {
+	// FindByName finds a CI type by its name
+	FindByName(ctx context.Context, name model.AssetType) (*model.CIType, error)
+
+	// FindAll finds all stored CI types
+	FindAll(ctx context.Context) ([]*model.CIType, error)
+
+	// Save creates or updates a CI type
+	Save(ctx context.Context, ciType *model.CIType) error
+
+	// DeleteByName deletes a CI type by its name
+	DeleteByName(ctx context.Context, name model.AssetType) error
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CloudAccountRepository defines the interface for cloud account data access
+type CloudAccountRepository interface {
+	// FindByID finds an account by its ID
+	FindByID(ctx context.Context, id primitive.ObjectID) (*model.CloudAccount, error)
+
+	// FindAll finds all accounts, optionally only the enabled ones
+	FindAll(ctx context.Context, enabledOnly bool) ([]*model.CloudAccount, error)
+
+	// Save creates or updates an account
+	Save(ctx context.Context, account *model.CloudAccount) error
+
+	// Delete deletes an account by its ID
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DiscoveryRepository defines the interface for discovery range, scan run and candidate persistence
+type DiscoveryRepository interface {
+	// Range operations
+	FindRangeByID(ctx context.Context, id primitive.ObjectID) (*model.DiscoveryRange, error)
+	FindRanges(ctx context.Context, enabledOnly bool) ([]*model.DiscoveryRange, error)
+	SaveRange(ctx context.Context, rng *model.DiscoveryRange) error
+	DeleteRange(ctx context.Context, id primitive.ObjectID) error
+
+	// Scan run operations
+	FindRunByID(ctx context.Context, id primitive.ObjectID) (*model.ScanRun, error)
+	FindRuns(ctx context.Context, filter map[string]interface{}, limit int64) ([]*model.ScanRun, error)
+	SaveRun(ctx context.Context, run *model.ScanRun) error
+	// FailRunningRuns marks runs left running by a previous process as failed
+	FailRunningRuns(ctx context.Context, reason string) (int64, error)
+
+	// Candidate operations
+	FindCandidateByID(ctx context.Context, id primitive.ObjectID) (*model.DiscoveryCandidate, error)
+	FindCandidates(ctx context.Context, filter map[string]interface{}) ([]*model.DiscoveryCandidate, error)
+	// FindCandidateByAddress finds the candidate with a MAC address or, failing that, an IP address
+	FindCandidateByAddress(ctx context.Context, ipAddress, macAddress string) (*model.DiscoveryCandidate, error)
+	SaveCandidate(ctx context.Context, candidate *model.DiscoveryCandidate) error
+}
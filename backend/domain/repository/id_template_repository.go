@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+)
+
+// IDTemplateRepository defines the interface for ID template data access
+type IDTemplateRepository interface {
+	// FindByScope finds the ID template of an asset type or of workflows
+	FindByScope(ctx context.Context, scope string) (*model.IDTemplate, error)
+
+	// FindAll finds all ID templates
+	FindAll(ctx context.Context) ([]*model.IDTemplate, error)
+
+	// Save creates or updates an ID template
+	Save(ctx context.Context, template *model.IDTemplate) error
+
+	// DeleteByScope deletes the ID template of a scope
+	DeleteByScope(ctx context.Context, scope string) error
+}
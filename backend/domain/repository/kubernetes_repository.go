@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// KubernetesClusterRepository defines the interface for Kubernetes cluster data access
+type KubernetesClusterRepository interface {
+	// FindByID finds a cluster by its ID
+	FindByID(ctx context.Context, id primitive.ObjectID) (*model.KubernetesCluster, error)
+
+	// FindAll finds all clusters, optionally only the enabled ones
+	FindAll(ctx context.Context, enabledOnly bool) ([]*model.KubernetesCluster, error)
+
+	// Save creates or updates a cluster
+	Save(ctx context.Context, cluster *model.KubernetesCluster) error
+
+	// Delete deletes a cluster by its ID
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
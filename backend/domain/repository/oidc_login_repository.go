@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+)
+
+// OIDCLoginRepository defines the interface for persisting single sign-on logins in progress
+type OIDCLoginRepository interface {
+	// Create adds a new sign-in
+	Create(ctx context.Context, login *model.OIDCLogin) error
+
+	// Consume removes the sign-in of a state and returns it, or returns nil when there is none,
+	// so that each callback is accepted once
+	Consume(ctx context.Context, state string) (*model.OIDCLogin, error)
+}
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ReconciliationRepository defines the interface for field conflict and merge proposal persistence
+type ReconciliationRepository interface {
+	// Conflict operations
+	FindConflictByID(ctx context.Context, id primitive.ObjectID) (*model.FieldConflict, error)
+	FindConflicts(ctx context.Context, filter map[string]interface{}) ([]*model.FieldConflict, error)
+	// FindOpenConflict finds the open conflict of an asset field reported by a source. It returns
+	// nil when there is none.
+	FindOpenConflict(ctx context.Context, assetID primitive.ObjectID, field, source string) (*model.FieldConflict, error)
+	SaveConflict(ctx context.Context, conflict *model.FieldConflict) error
+
+	// Merge proposal operations
+	FindMergeProposalByID(ctx context.Context, id primitive.ObjectID) (*model.MergeProposal, error)
+	FindMergeProposals(ctx context.Context, filter map[string]interface{}) ([]*model.MergeProposal, error)
+	// FindMergeProposalByKey finds the proposal for a pair of assets. It returns nil when there is none.
+	FindMergeProposalByKey(ctx context.Context, key string) (*model.MergeProposal, error)
+	SaveMergeProposal(ctx context.Context, proposal *model.MergeProposal) error
+}
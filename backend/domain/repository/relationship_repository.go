@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RelationshipRepository defines the interface for asset relationship data access
+type RelationshipRepository interface {
+	// FindByID finds a relationship by its ID
+	FindByID(ctx context.Context, id primitive.ObjectID) (*model.Relationship, error)
+
+	// FindBySourceID finds all relationships originating from an asset
+	FindBySourceID(ctx context.Context, sourceID primitive.ObjectID) ([]*model.Relationship, error)
+
+	// FindByTargetID finds all relationships pointing at an asset
+	FindByTargetID(ctx context.Context, targetID primitive.ObjectID) ([]*model.Relationship, error)
+
+	// FindByAssetID finds all relationships where the asset is either source or target
+	FindByAssetID(ctx context.Context, assetID primitive.ObjectID) ([]*model.Relationship, error)
+
+	// Exists checks if an identical relationship already exists
+	Exists(ctx context.Context, sourceID, targetID primitive.ObjectID, relationshipType model.RelationshipType) (bool, error)
+
+	// Save creates or updates a relationship
+	Save(ctx context.Context, relationship *model.Relationship) error
+
+	// Delete deletes a relationship by its ID
+	Delete(ctx context.Context, id primitive.ObjectID) error
+
+	// DeleteByAssetID deletes all relationships involving an asset
+	DeleteByAssetID(ctx context.Context, assetID primitive.ObjectID) error
+}
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+)
+
+// SequenceRepository defines the interface for named, atomically incremented counters
+type SequenceRepository interface {
+	// Next increments a sequence and returns its new value; a new sequence starts at 1
+	Next(ctx context.Context, name string) (int64, error)
+
+	// EnsureAtLeast raises a sequence to value unless it is already higher
+	EnsureAtLeast(ctx context.Context, name string, value int64) error
+}
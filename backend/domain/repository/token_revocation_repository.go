@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+)
+
+// TokenRevocationRepository defines the interface for access token revocation persistence
+type TokenRevocationRepository interface {
+	// Create adds a new revocation
+	Create(ctx context.Context, revocation *model.TokenRevocation) error
+
+	// FindActive finds the revocations that have not expired yet
+	FindActive(ctx context.Context) ([]*model.TokenRevocation, error)
+}
@@ -14,6 +14,8 @@ type UserRepository interface {
 	GetByID(ctx context.Context, id primitive.ObjectID) (*model.User, error)
 	GetByUsername(ctx context.Context, username string) (*model.User, error)
 	GetByEmail(ctx context.Context, email string) (*model.User, error)
+	GetByExternalID(ctx context.Context, externalID string) (*model.User, error)
+	GetByChannelID(ctx context.Context, channel, accountID string) (*model.User, error)
 	Update(ctx context.Context, user *model.User) error
 	Delete(ctx context.Context, id primitive.ObjectID) error
 	List(ctx context.Context, limit, offset int) ([]*model.User, error)
@@ -21,7 +23,11 @@ type UserRepository interface {
 	// Session operations
 	CreateSession(ctx context.Context, session *model.Session) error
 	GetSessionByToken(ctx context.Context, token string) (*model.Session, error)
+	// RotateSession marks the session of a token hash rotated and returns it as it was, or
+	// returns nil when there is no such session that is not rotated yet
+	RotateSession(ctx context.Context, token string) (*model.Session, error)
 	DeleteSession(ctx context.Context, token string) error
+	DeleteSessionFamily(ctx context.Context, familyID primitive.ObjectID) error
 	DeleteExpiredSessions(ctx context.Context) error
 	DeleteUserSessions(ctx context.Context, userID primitive.ObjectID) error
 }
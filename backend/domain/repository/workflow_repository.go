@@ -15,8 +15,8 @@ type WorkflowRepository interface {
 	// FindByWorkflowID finds a workflow by its workflow ID
 	FindByWorkflowID(ctx context.Context, workflowID string) (*model.Workflow, error)
 
-	// FindByFeishuID finds a workflow by its Feishu ID
-	FindByFeishuID(ctx context.Context, feishuID string) (*model.Workflow, error)
+	// FindByExternalID finds a workflow by the approval channel and external ID it was sent to
+	FindByExternalID(ctx context.Context, channel, externalID string) (*model.Workflow, error)
 
 	// FindAll finds all workflows with optional filtering
 	FindAll(ctx context.Context, filter map[string]interface{}) ([]*model.Workflow, error)
@@ -27,6 +27,10 @@ type WorkflowRepository interface {
 	// Save creates or updates a workflow
 	Save(ctx context.Context, workflow *model.Workflow) error
 
+	// UpdatePending updates a workflow unless it was decided or moved past the stage it was
+	// read at, reporting whether it was updated
+	UpdatePending(ctx context.Context, workflow *model.Workflow, stage int) (bool, error)
+
 	// Delete deletes a workflow by its ID
 	Delete(ctx context.Context, id primitive.ObjectID) error
 
@@ -39,6 +43,11 @@ type WorkflowRepository interface {
 	// GetWorkflowTypeStats gets workflow statistics by type
 	GetWorkflowTypeStats(ctx context.Context) (map[string]int64, error)
 
-	// GenerateWorkflowID generates a unique workflow ID
-	GenerateWorkflowID(ctx context.Context) (string, error)
+	// GetSLAStats gets SLA breach counts and the mean time to approve
+	GetSLAStats(ctx context.Context) (*model.WorkflowSLAStats, error)
+
+	// FindWorkflowIDsMatching finds the workflow IDs matching a regular expression
+	FindWorkflowIDsMatching(ctx context.Context, pattern string) ([]string, error)
+	// ReassignAsset points the workflows of an asset merged into another one at the surviving asset
+	ReassignAsset(ctx context.Context, fromAssetID, toAssetID, toAssetName string) (int64, error)
 }
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// agentTokenPrefix marks agent token secrets so that they are easy to recognize
+const agentTokenPrefix = "cmdb_"
+
+// Defaults for assets created from agent reports and imported scans
+const (
+	defaultAgentAssetType = "server"
+	defaultAssetLocation  = "Unknown"
+)
+
+// ErrInvalidAgentToken is returned when an agent token is unknown or revoked
+var ErrInvalidAgentToken = errors.New("invalid agent token")
+
+// ErrMachineIDRequired is returned when an agent report has no machine ID
+var ErrMachineIDRequired = errors.New("machine ID is required")
+
+// AgentReport is a host facts report pushed by an agent
+type AgentReport struct {
+	Facts model.HostFacts
+	// AssetType and Location are used when the report creates a new asset
+	AssetType string
+	Location  string
+}
+
+// AgentService authenticates inventory agents and merges the facts they report into assets
+type AgentService struct {
+	tokenRepo    repository.AgentTokenRepository
+	assetRepo    repository.AssetRepository
+	assetService *AssetService
+
+	// mu serializes reports so that two reports from a new machine create one asset
+	mu sync.Mutex
+}
+
+// NewAgentService creates a new agent service
+func NewAgentService(tokenRepo repository.AgentTokenRepository, assetRepo repository.AssetRepository, assetService *AssetService) *AgentService {
+	return &AgentService{
+		tokenRepo:    tokenRepo,
+		assetRepo:    assetRepo,
+		assetService: assetService,
+	}
+}
+
+// CreateToken creates an agent token. The secret is returned only here.
+func (s *AgentService) CreateToken(ctx context.Context, name, createdBy string) (*model.AgentToken, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", errors.New("token name is required")
+	}
+
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, "", err
+	}
+	secret := agentTokenPrefix + hex.EncodeToString(buf)
+
+	token := model.NewAgentToken(name, secret, createdBy)
+	if err := s.tokenRepo.Save(ctx, token); err != nil {
+		return nil, "", err
+	}
+
+	return token, secret, nil
+}
+
+// GetTokens gets all agent tokens
+func (s *AgentService) GetTokens(ctx context.Context) ([]*model.AgentToken, error) {
+	return s.tokenRepo.FindAll(ctx)
+}
+
+// RevokeToken revokes an agent token
+func (s *AgentService) RevokeToken(ctx context.Context, id primitive.ObjectID) (*model.AgentToken, error) {
+	token, err := s.tokenRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if token.IsRevoked() {
+		return token, nil
+	}
+
+	token.Revoke()
+	if err := s.tokenRepo.Save(ctx, token); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// Authenticate finds the token for a secret and records its use
+func (s *AgentService) Authenticate(ctx context.Context, secret string) (*model.AgentToken, error) {
+	if !strings.HasPrefix(secret, agentTokenPrefix) {
+		return nil, ErrInvalidAgentToken
+	}
+
+	token, err := s.tokenRepo.FindByHash(ctx, model.HashAgentToken(secret))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrInvalidAgentToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if token.IsRevoked() {
+		return nil, ErrInvalidAgentToken
+	}
+
+	// The token may have been revoked since it was read
+	marked, err := s.tokenRepo.MarkUsed(ctx, token.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !marked {
+		return nil, ErrInvalidAgentToken
+	}
+	token.MarkUsed()
+
+	return token, nil
+}
+
+// ReportFacts merges a facts report into the asset with the reported machine ID. A machine
+// reporting for the first time adopts an active asset with one of its MAC addresses or its
+// hostname, or else gets a new asset. Differences to the previous report are recorded in the
+// asset history under the name of the agent token.
+func (s *AgentService) ReportFacts(ctx context.Context, agentName, agentID string, report AgentReport) (*model.Asset, error) {
+	facts := report.Facts
+	facts.Normalize()
+	if facts.MachineID == "" {
+		return nil, ErrMachineIDRequired
+	}
+	if facts.CollectedAt.IsZero() {
+		facts.CollectedAt = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Changes are recorded in the asset history under the name of the agent token
+	ctx = model.WithActor(ctx, model.Actor{ID: agentID, Name: agentName})
+
+	asset, err := s.matchAsset(ctx, &facts)
+	if err != nil {
+		return nil, err
+	}
+	if asset == nil {
+		name := firstNonEmpty(facts.Hostname, facts.MachineID)
+		assetType := firstNonEmpty(report.AssetType, defaultAgentAssetType)
+		location := firstNonEmpty(report.Location, defaultAssetLocation)
+		asset, _, err = s.assetService.CreateAsset(ctx, name, assetType, location, "Registered by inventory agent", nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	oldAsset := asset.Clone()
+	s.applyFacts(asset, &facts, agentName)
+	if err := s.assetService.ReconcileFields(ctx, oldAsset, asset, model.SourceAgent, agentName); err != nil {
+		return nil, err
+	}
+
+	ctx = model.WithAssetChange(ctx, model.AssetChange{Type: model.ChangeTypeFactsUpdate, Reason: "Host facts reported by agent"})
+	if err := s.assetRepo.Save(ctx, asset); err != nil {
+		return nil, err
+	}
+
+	return asset, nil
+}
+
+// matchAsset finds the asset of a machine by its machine ID, then by MAC address and hostname
+// among active assets not yet claimed by another machine. It returns nil when none matches.
+func (s *AgentService) matchAsset(ctx context.Context, facts *model.HostFacts) (*model.Asset, error) {
+	assets, err := s.assetRepo.FindAll(ctx, map[string]interface{}{"machineId": facts.MachineID})
+	if err != nil {
+		return nil, err
+	}
+	if len(assets) > 0 {
+		return assets[0], nil
+	}
+
+	unclaimed := map[string]interface{}{"$exists": false}
+	var filters []map[string]interface{}
+	for _, mac := range facts.MACAddresses() {
+		filters = append(filters, map[string]interface{}{"macAddress": mac})
+	}
+	if facts.Hostname != "" {
+		filters = append(filters, map[string]interface{}{"hostname": facts.Hostname})
+	}
+
+	for _, filter := range filters {
+		filter["status"] = activeStatusFilter()
+		filter["machineId"] = unclaimed
+		assets, err := s.assetRepo.FindAll(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		if len(assets) > 0 {
+			return assets[0], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// applyFacts copies the reported facts and the identity fields derived from them to an asset.
+// The identity fields are reconciled with the values of other sources afterwards.
+func (s *AgentService) applyFacts(asset *model.Asset, facts *model.HostFacts, reporter string) {
+	set := func(current *string, value string) {
+		if value != "" {
+			*current = value
+		}
+	}
+
+	set(&asset.MachineID, facts.MachineID)
+	set(&asset.Hostname, facts.Hostname)
+	mac, ip := facts.PrimaryAddress()
+	set(&asset.MACAddress, mac)
+	set(&asset.IPAddress, ip)
+
+	asset.Facts = facts
+	asset.SetFieldSource("facts", model.SourceAgent, reporter)
+	asset.UpdatedAt = time.Now()
+}
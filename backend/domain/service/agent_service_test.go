@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type memoryAgentTokenRepository struct {
+	repository.AgentTokenRepository
+
+	mu     sync.Mutex
+	tokens map[primitive.ObjectID]*model.AgentToken
+	saves  int
+	// afterRead runs once a token has been read by its hash, standing in for a concurrent request
+	afterRead func(token *model.AgentToken)
+	// findErr fails lookups by hash, standing in for the database being unavailable
+	findErr error
+}
+
+func (r *memoryAgentTokenRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*model.AgentToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token, ok := r.tokens[id]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	copied := *token
+	return &copied, nil
+}
+
+func (r *memoryAgentTokenRepository) FindByHash(ctx context.Context, tokenHash string) (*model.AgentToken, error) {
+	r.mu.Lock()
+	var found *model.AgentToken
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash {
+			copied := *token
+			found = &copied
+		}
+	}
+	r.mu.Unlock()
+	if r.findErr != nil {
+		return nil, r.findErr
+	}
+	if found == nil {
+		return nil, mongo.ErrNoDocuments
+	}
+	if r.afterRead != nil {
+		r.afterRead(found)
+	}
+	return found, nil
+}
+
+func (r *memoryAgentTokenRepository) Save(ctx context.Context, token *model.AgentToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.tokens == nil {
+		r.tokens = make(map[primitive.ObjectID]*model.AgentToken)
+	}
+	if token.ID.IsZero() {
+		token.ID = primitive.NewObjectID()
+	}
+	copied := *token
+	r.tokens[token.ID] = &copied
+	r.saves++
+	return nil
+}
+
+func (r *memoryAgentTokenRepository) MarkUsed(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token, ok := r.tokens[id]
+	if !ok || token.IsRevoked() {
+		return false, nil
+	}
+	token.MarkUsed()
+	return true, nil
+}
+
+func TestAgentAuthenticate(t *testing.T) {
+	tokenRepo := &memoryAgentTokenRepository{}
+	agents := NewAgentService(tokenRepo, nil, nil)
+	ctx := context.Background()
+
+	token, secret, err := agents.CreateToken(ctx, "rack-1", "admin")
+	if err != nil {
+		t.Fatalf("CreateToken() error = %v", err)
+	}
+	saves := tokenRepo.saves
+
+	authenticated, err := agents.Authenticate(ctx, secret)
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if authenticated.ID != token.ID || authenticated.LastUsedAt == nil {
+		t.Errorf("Authenticate() = %+v, want the token marked used", authenticated)
+	}
+	if stored, _ := tokenRepo.FindByID(ctx, token.ID); stored.LastUsedAt == nil {
+		t.Error("last use of the token was not stored")
+	}
+	// Authenticating only records the last use, never the whole token
+	if tokenRepo.saves != saves {
+		t.Errorf("Authenticate() saved the token %d times", tokenRepo.saves-saves)
+	}
+
+	for _, secret := range []string{"not-a-token", "cmdb_unknown", secret + "x"} {
+		if _, err := agents.Authenticate(ctx, secret); !errors.Is(err, ErrInvalidAgentToken) {
+			t.Errorf("Authenticate(%q) error = %v, want ErrInvalidAgentToken", secret, err)
+		}
+	}
+}
+
+func TestAgentAuthenticateRepositoryError(t *testing.T) {
+	tokenRepo := &memoryAgentTokenRepository{}
+	agents := NewAgentService(tokenRepo, nil, nil)
+	ctx := context.Background()
+
+	_, secret, err := agents.CreateToken(ctx, "rack-1", "admin")
+	if err != nil {
+		t.Fatalf("CreateToken() error = %v", err)
+	}
+
+	// A failed lookup says nothing about the token, so it must not be reported as invalid
+	tokenRepo.findErr = errors.New("server selection timeout")
+	if _, err := agents.Authenticate(ctx, secret); err == nil || errors.Is(err, ErrInvalidAgentToken) {
+		t.Errorf("Authenticate() error = %v, want the repository error", err)
+	}
+}
+
+func TestAgentAuthenticateRevokedMeanwhile(t *testing.T) {
+	tokenRepo := &memoryAgentTokenRepository{}
+	agents := NewAgentService(tokenRepo, nil, nil)
+	ctx := context.Background()
+
+	token, secret, err := agents.CreateToken(ctx, "rack-1", "admin")
+	if err != nil {
+		t.Fatalf("CreateToken() error = %v", err)
+	}
+
+	// The token is revoked after the request read it, but before its use is recorded
+	tokenRepo.afterRead = func(*model.AgentToken) {
+		tokenRepo.afterRead = nil
+		if _, err := agents.RevokeToken(ctx, token.ID); err != nil {
+			t.Fatalf("RevokeToken() error = %v", err)
+		}
+	}
+	if _, err := agents.Authenticate(ctx, secret); !errors.Is(err, ErrInvalidAgentToken) {
+		t.Errorf("Authenticate() error = %v, want ErrInvalidAgentToken", err)
+	}
+
+	stored, _ := tokenRepo.FindByID(ctx, token.ID)
+	if !stored.IsRevoked() || stored.LastUsedAt != nil {
+		t.Errorf("stored token = %+v, want it revoked and never used", stored)
+	}
+}
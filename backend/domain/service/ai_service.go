@@ -596,9 +596,11 @@ func (s *AIService) translateStatus(status string) string {
 
 func (s *AIService) translateWorkflowStatus(status string) string {
 	translations := map[string]string{
-		"pending":  "待审批",
-		"approved": "已批准",
-		"rejected": "已拒绝",
+		"pending":   "待审批",
+		"approved":  "已批准",
+		"rejected":  "已拒绝",
+		"completed": "已完成",
+		"failed":    "执行失败",
 	}
 	if translation, exists := translations[status]; exists {
 		return translation
@@ -0,0 +1,611 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/repository"
+	"github.com/phuhao00/cmdb/backend/infrastructure/logging"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// AlertRuleSpec defines the editable fields of an alert rule
+type AlertRuleSpec struct {
+	Name        string
+	Description string
+	Type        string
+	Severity    string
+	Field       string
+	Operator    string
+	Threshold   interface{}
+	AssetFilter map[string]interface{}
+	Enabled     bool
+}
+
+// AlertService provides domain logic for alert rules and alerts
+type AlertService struct {
+	alertRepo repository.AlertRepository
+	assetRepo repository.AssetRepository
+
+	// evalMu serialises evaluations within this instance, so that the periodic sweep and asset
+	// change notifications do not race; across instances the store's unique index on open
+	// alerts keeps a rule from opening two alerts for one asset
+	evalMu sync.Mutex
+}
+
+// NewAlertService creates a new alert service
+func NewAlertService(alertRepo repository.AlertRepository, assetRepo repository.AssetRepository) *AlertService {
+	return &AlertService{
+		alertRepo: alertRepo,
+		assetRepo: assetRepo,
+	}
+}
+
+// CreateRule creates a new alert rule
+func (s *AlertService) CreateRule(ctx context.Context, spec AlertRuleSpec) (*model.AlertRule, error) {
+	if err := validateAlertRuleSpec(spec); err != nil {
+		return nil, err
+	}
+
+	rule := model.NewAlertRule(spec.Name, spec.Description, alertTypeOrDefault(spec.Type), model.AlertSeverity(spec.Severity))
+	rule.SetCondition(spec.Field, spec.Operator, spec.Threshold)
+	rule.Enabled = spec.Enabled
+	if spec.AssetFilter != nil {
+		rule.AssetFilter = spec.AssetFilter
+	}
+
+	if err := s.alertRepo.SaveRule(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+// UpdateRule updates an existing alert rule
+func (s *AlertService) UpdateRule(ctx context.Context, id primitive.ObjectID, spec AlertRuleSpec) (*model.AlertRule, error) {
+	if err := validateAlertRuleSpec(spec); err != nil {
+		return nil, err
+	}
+
+	rule, err := s.alertRepo.FindRuleByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	rule.Name = spec.Name
+	rule.Description = spec.Description
+	rule.Type = alertTypeOrDefault(spec.Type)
+	rule.Severity = model.AlertSeverity(spec.Severity)
+	rule.Enabled = spec.Enabled
+	rule.AssetFilter = spec.AssetFilter
+	if rule.AssetFilter == nil {
+		rule.AssetFilter = make(map[string]interface{})
+	}
+	rule.SetCondition(spec.Field, spec.Operator, spec.Threshold)
+
+	if err := s.alertRepo.SaveRule(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+// DeleteRule deletes an alert rule. Alerts it already raised are kept.
+func (s *AlertService) DeleteRule(ctx context.Context, id primitive.ObjectID) error {
+	if _, err := s.alertRepo.FindRuleByID(ctx, id); err != nil {
+		return err
+	}
+	return s.alertRepo.DeleteRule(ctx, id)
+}
+
+// GetRule gets an alert rule by its ID
+func (s *AlertService) GetRule(ctx context.Context, id primitive.ObjectID) (*model.AlertRule, error) {
+	return s.alertRepo.FindRuleByID(ctx, id)
+}
+
+// GetRules gets all alert rules
+func (s *AlertService) GetRules(ctx context.Context) ([]*model.AlertRule, error) {
+	return s.alertRepo.FindRules(ctx, false)
+}
+
+// GetAlerts gets alerts with optional filtering
+func (s *AlertService) GetAlerts(ctx context.Context, filter map[string]interface{}) ([]*model.Alert, error) {
+	return s.alertRepo.FindAll(ctx, filter)
+}
+
+// GetAlertByID gets an alert by its ID
+func (s *AlertService) GetAlertByID(ctx context.Context, id primitive.ObjectID) (*model.Alert, error) {
+	return s.alertRepo.FindByID(ctx, id)
+}
+
+// GetAlertStats gets alert statistics by status
+func (s *AlertService) GetAlertStats(ctx context.Context) (map[string]int64, error) {
+	return s.alertRepo.GetAlertStats(ctx)
+}
+
+// AcknowledgeAlert acknowledges an active alert. Only the acknowledgement is stored, and only
+// while the alert is still active, so that it cannot reopen an alert resolved meanwhile.
+func (s *AlertService) AcknowledgeAlert(ctx context.Context, id primitive.ObjectID, userID, username string) (*model.Alert, error) {
+	alert, err := s.alertRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !alert.IsActive() {
+		return nil, fmt.Errorf("cannot acknowledge alert in %s status", alert.Status)
+	}
+
+	alert.Acknowledge(userID, username)
+
+	acknowledged, err := s.alertRepo.MarkAcknowledged(ctx, alert)
+	if err != nil {
+		return nil, err
+	}
+	if !acknowledged {
+		return nil, s.alertChangedMeanwhile(ctx, id, "acknowledge")
+	}
+
+	return alert, nil
+}
+
+// ResolveAlert resolves an active or acknowledged alert, unless it was resolved or closed
+// meanwhile
+func (s *AlertService) ResolveAlert(ctx context.Context, id primitive.ObjectID, userID, username, resolution string) (*model.Alert, error) {
+	alert, err := s.alertRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !alert.IsOpen() {
+		return nil, fmt.Errorf("cannot resolve alert in %s status", alert.Status)
+	}
+
+	alert.Resolve(userID, username, resolution)
+
+	resolved, err := s.alertRepo.MarkResolved(ctx, alert)
+	if err != nil {
+		return nil, err
+	}
+	if !resolved {
+		return nil, s.alertChangedMeanwhile(ctx, id, "resolve")
+	}
+
+	return alert, nil
+}
+
+// CloseAlert closes an alert that is not already closed, leaving the rest of it as stored
+func (s *AlertService) CloseAlert(ctx context.Context, id primitive.ObjectID) (*model.Alert, error) {
+	alert, err := s.alertRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if alert.IsClosed() {
+		return nil, errors.New("alert is already closed")
+	}
+
+	alert.Close()
+
+	closed, err := s.alertRepo.MarkClosed(ctx, alert)
+	if err != nil {
+		return nil, err
+	}
+	if !closed {
+		return nil, errors.New("alert is already closed")
+	}
+
+	// Whatever else changed meanwhile, such as a resolution by the evaluator, is kept
+	return s.alertRepo.FindByID(ctx, id)
+}
+
+// alertChangedMeanwhile reports the status an alert moved to while a user was changing it
+func (s *AlertService) alertChangedMeanwhile(ctx context.Context, id primitive.ObjectID, action string) error {
+	current, err := s.alertRepo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("cannot %s alert in %s status", action, current.Status)
+}
+
+// EvaluateAll runs every enabled rule against all assets matching its filter
+func (s *AlertService) EvaluateAll(ctx context.Context) (opened int, resolved int, err error) {
+	rules, err := s.alertRepo.FindRules(ctx, true)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, rule := range rules {
+		o, r, err := s.EvaluateRule(ctx, rule)
+		if err != nil {
+			logging.Logger.Error("alert_rule_evaluation_failed",
+				zap.String("rule_id", rule.ID.Hex()),
+				zap.String("rule", rule.Name),
+				zap.Error(err),
+			)
+			continue
+		}
+		opened += o
+		resolved += r
+	}
+
+	return opened, resolved, nil
+}
+
+// EvaluateRule runs a single rule against all assets matching its filter
+func (s *AlertService) EvaluateRule(ctx context.Context, rule *model.AlertRule) (opened int, resolved int, err error) {
+	assets, err := s.assetRepo.FindAll(ctx, rule.AssetFilter)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, asset := range assets {
+		s.evalMu.Lock()
+		o, r, err := s.evaluateRuleForAsset(ctx, rule, asset, true)
+		s.evalMu.Unlock()
+		if err != nil {
+			return opened, resolved, err
+		}
+		opened += o
+		resolved += r
+	}
+
+	return opened, resolved, nil
+}
+
+// EvaluateAsset runs every enabled rule against a single asset
+func (s *AlertService) EvaluateAsset(ctx context.Context, asset *model.Asset) error {
+	s.evalMu.Lock()
+	defer s.evalMu.Unlock()
+	return s.evaluateAsset(ctx, asset)
+}
+
+// evaluateAsset runs every enabled rule against a single asset; evalMu must be held
+func (s *AlertService) evaluateAsset(ctx context.Context, asset *model.Asset) error {
+	rules, err := s.alertRepo.FindRules(ctx, true)
+	if err != nil {
+		return err
+	}
+
+	for _, rule := range rules {
+		// Scope the rule's asset filter to this asset
+		filter := make(map[string]interface{}, len(rule.AssetFilter)+1)
+		for k, v := range rule.AssetFilter {
+			filter[k] = v
+		}
+		filter["_id"] = asset.ID
+
+		matches, err := s.assetRepo.FindAll(ctx, filter)
+		if err != nil {
+			return err
+		}
+
+		if _, _, err := s.evaluateRuleForAsset(ctx, rule, asset, len(matches) > 0); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AssetChanged implements AssetChangeListener by re-evaluating rules in the background.
+// Notifications may be handled out of order, so the asset is loaded again rather than
+// evaluated as saved, and a later change is never undone by an earlier one.
+func (s *AlertService) AssetChanged(ctx context.Context, asset *model.Asset) {
+	id, assetID := asset.ID, asset.AssetID
+	go func() {
+		evalCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+		defer cancel()
+
+		if err := s.reevaluateAsset(evalCtx, id); err != nil {
+			logging.Logger.Error("alert_asset_evaluation_failed",
+				zap.String("asset_id", assetID),
+				zap.Error(err),
+			)
+		}
+	}()
+}
+
+// reevaluateAsset loads the current asset and runs every enabled rule against it
+func (s *AlertService) reevaluateAsset(ctx context.Context, id primitive.ObjectID) error {
+	s.evalMu.Lock()
+	defer s.evalMu.Unlock()
+
+	asset, err := s.assetRepo.FindByID(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// Deleted since it was saved
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return s.evaluateAsset(ctx, asset)
+}
+
+// StartEvaluationLoop periodically sweeps all enabled rules until the context is cancelled
+func (s *AlertService) StartEvaluationLoop(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				opened, resolved, err := s.EvaluateAll(ctx)
+				if err != nil {
+					logging.Logger.Error("alert_sweep_failed", zap.Error(err))
+					continue
+				}
+				logging.Logger.Info("alert_sweep_completed",
+					zap.Int("opened", opened),
+					zap.Int("resolved", resolved),
+				)
+			}
+		}
+	}()
+}
+
+// evaluateRuleForAsset opens an alert when the condition holds and none is open yet,
+// and resolves the open alert once the condition (or the rule's scope) no longer holds.
+// evalMu must be held. The open alert is only updated field by field, so that an
+// acknowledgement made since it was read is kept.
+func (s *AlertService) evaluateRuleForAsset(ctx context.Context, rule *model.AlertRule, asset *model.Asset, inScope bool) (opened int, resolved int, err error) {
+	value := assetFieldValue(asset, rule.Field)
+	triggered := false
+	if inScope {
+		triggered, err = compareValues(value, rule.Operator, rule.Threshold)
+		if err != nil {
+			return 0, 0, fmt.Errorf("rule %s: %w", rule.Name, err)
+		}
+	}
+
+	existing, err := s.alertRepo.FindOpenByRuleAndAsset(ctx, rule.ID, asset.ID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	switch {
+	case triggered && existing == nil:
+		alert := model.NewAlert(
+			asset.ID,
+			asset.Name,
+			rule.Type,
+			rule.Severity,
+			rule.Name,
+			fmt.Sprintf("%s: %s is %v (condition: %s)", asset.Name, rule.Field, value, rule.Condition),
+		)
+		alert.RuleID = rule.ID
+		alert.Condition = rule.Condition
+		alert.CurrentValue = value
+		alert.ThresholdValue = rule.Threshold
+
+		if err := s.alertRepo.Save(ctx, alert); err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				// Another instance opened it first
+				return 0, 0, nil
+			}
+			return 0, 0, err
+		}
+		return 1, 0, nil
+
+	case triggered && existing != nil:
+		// Condition still holds; keep the open alert's value current
+		return 0, 0, s.alertRepo.UpdateCurrentValue(ctx, existing.ID, value)
+
+	case !triggered && existing != nil:
+		existing.Resolve("system", "System", "Condition no longer met")
+		resolvedNow, err := s.alertRepo.MarkResolved(ctx, existing)
+		if err != nil || !resolvedNow {
+			// Resolved or closed by someone else in the meantime
+			return 0, 0, err
+		}
+		return 0, 1, nil
+	}
+
+	return 0, 0, nil
+}
+
+// validateAlertRuleSpec validates the editable fields of an alert rule
+func validateAlertRuleSpec(spec AlertRuleSpec) error {
+	if strings.TrimSpace(spec.Name) == "" {
+		return errors.New("rule name is required")
+	}
+	if strings.TrimSpace(spec.Field) == "" {
+		return errors.New("rule field is required")
+	}
+	if !model.IsValidOperator(spec.Operator) {
+		return fmt.Errorf("unknown operator: %s", spec.Operator)
+	}
+
+	switch model.AlertSeverity(spec.Severity) {
+	case model.SeverityCritical, model.SeverityHigh, model.SeverityMedium, model.SeverityLow, model.SeverityInfo:
+	default:
+		return fmt.Errorf("unknown severity: %s", spec.Severity)
+	}
+
+	return nil
+}
+
+// alertTypeOrDefault returns the alert type, defaulting to threshold
+func alertTypeOrDefault(alertType string) model.AlertType {
+	if alertType == "" {
+		return model.AlertTypeThreshold
+	}
+	return model.AlertType(alertType)
+}
+
+// assetFieldValue reads a field from an asset by its stored name; dotted paths reach into nested documents
+func assetFieldValue(asset *model.Asset, field string) interface{} {
+	raw, err := bson.Marshal(asset)
+	if err != nil {
+		return nil
+	}
+
+	var doc bson.M
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return nil
+	}
+
+	var current interface{} = doc
+	for _, part := range strings.Split(field, ".") {
+		m, ok := current.(bson.M)
+		if !ok {
+			return nil
+		}
+		current = m[part]
+	}
+
+	if dt, ok := current.(primitive.DateTime); ok {
+		return dt.Time()
+	}
+	return current
+}
+
+// compareValues applies a rule operator to an asset value and a threshold.
+// Numbers compare numerically, times compare against an RFC3339 timestamp or a
+// duration relative to now (e.g. "-168h"), arrays match eq/ne by membership,
+// and everything else compares as strings.
+func compareValues(value interface{}, operator string, threshold interface{}) (bool, error) {
+	if value == nil {
+		switch operator {
+		case model.OperatorEQ:
+			return threshold == nil, nil
+		case model.OperatorNE:
+			return threshold != nil, nil
+		default:
+			return false, nil
+		}
+	}
+
+	switch v := value.(type) {
+	case time.Time:
+		t, err := thresholdTime(threshold)
+		if err != nil {
+			return false, err
+		}
+		return compareOrdered(float64(v.UnixNano()), float64(t.UnixNano()), operator), nil
+
+	case primitive.A:
+		contains := false
+		for _, item := range v {
+			if fmt.Sprintf("%v", item) == fmt.Sprintf("%v", threshold) {
+				contains = true
+				break
+			}
+		}
+		switch operator {
+		case model.OperatorEQ:
+			return contains, nil
+		case model.OperatorNE:
+			return !contains, nil
+		default:
+			return false, fmt.Errorf("operator %s is not supported for list fields", operator)
+		}
+
+	case bool:
+		b, ok := threshold.(bool)
+		if !ok {
+			b = fmt.Sprintf("%v", threshold) == "true"
+		}
+		switch operator {
+		case model.OperatorEQ:
+			return v == b, nil
+		case model.OperatorNE:
+			return v != b, nil
+		default:
+			return false, fmt.Errorf("operator %s is not supported for boolean fields", operator)
+		}
+	}
+
+	if fv, ok := toFloat(value); ok {
+		ft, ok := toFloat(threshold)
+		if !ok {
+			return false, fmt.Errorf("threshold %v is not numeric", threshold)
+		}
+		return compareOrdered(fv, ft, operator), nil
+	}
+
+	sv := fmt.Sprintf("%v", value)
+	st := fmt.Sprintf("%v", threshold)
+	switch operator {
+	case model.OperatorEQ:
+		return sv == st, nil
+	case model.OperatorNE:
+		return sv != st, nil
+	case model.OperatorGT:
+		return sv > st, nil
+	case model.OperatorLT:
+		return sv < st, nil
+	case model.OperatorGTE:
+		return sv >= st, nil
+	case model.OperatorLTE:
+		return sv <= st, nil
+	}
+
+	return false, fmt.Errorf("unknown operator: %s", operator)
+}
+
+// compareOrdered applies an operator to two numbers
+func compareOrdered(a, b float64, operator string) bool {
+	switch operator {
+	case model.OperatorGT:
+		return a > b
+	case model.OperatorLT:
+		return a < b
+	case model.OperatorEQ:
+		return a == b
+	case model.OperatorNE:
+		return a != b
+	case model.OperatorGTE:
+		return a >= b
+	case model.OperatorLTE:
+		return a <= b
+	default:
+		return false
+	}
+}
+
+// toFloat converts numeric values to float64
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+// thresholdTime interprets a threshold as a point in time
+func thresholdTime(threshold interface{}) (time.Time, error) {
+	switch t := threshold.(type) {
+	case time.Time:
+		return t, nil
+	case primitive.DateTime:
+		return t.Time(), nil
+	case string:
+		if d, err := time.ParseDuration(t); err == nil {
+			return time.Now().Add(d), nil
+		}
+		if parsed, err := time.Parse(time.RFC3339, t); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("threshold %v is not a time or duration", threshold)
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// memoryAlertRepository applies the targeted updates of the Mongo repository: each only
+// matches alerts still in the statuses it expects
+type memoryAlertRepository struct {
+	repository.AlertRepository
+
+	mu     sync.Mutex
+	alerts map[primitive.ObjectID]model.Alert
+	// afterRead runs once after an alert is read, as another writer getting in between would
+	afterRead func()
+}
+
+func newMemoryAlertRepository(alerts ...*model.Alert) *memoryAlertRepository {
+	r := &memoryAlertRepository{alerts: make(map[primitive.ObjectID]model.Alert)}
+	for _, alert := range alerts {
+		alert.ID = primitive.NewObjectID()
+		r.alerts[alert.ID] = *alert
+	}
+	return r
+}
+
+func (r *memoryAlertRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*model.Alert, error) {
+	r.mu.Lock()
+	alert, ok := r.alerts[id]
+	afterRead := r.afterRead
+	r.afterRead = nil
+	r.mu.Unlock()
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	if afterRead != nil {
+		afterRead()
+	}
+	return &alert, nil
+}
+
+// update applies a change to an alert if it is in one of the statuses
+func (r *memoryAlertRepository) update(id primitive.ObjectID, statuses []model.AlertStatus, change func(stored *model.Alert)) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.alerts[id]
+	if !ok {
+		return false
+	}
+	for _, status := range statuses {
+		if stored.Status == status {
+			change(&stored)
+			r.alerts[id] = stored
+			return true
+		}
+	}
+	return false
+}
+
+func (r *memoryAlertRepository) MarkAcknowledged(ctx context.Context, alert *model.Alert) (bool, error) {
+	return r.update(alert.ID, []model.AlertStatus{model.AlertStatusActive}, func(stored *model.Alert) {
+		stored.Status = alert.Status
+		stored.AcknowledgedBy = alert.AcknowledgedBy
+		stored.AcknowledgedByID = alert.AcknowledgedByID
+		stored.AcknowledgedAt = alert.AcknowledgedAt
+	}), nil
+}
+
+func (r *memoryAlertRepository) MarkResolved(ctx context.Context, alert *model.Alert) (bool, error) {
+	return r.update(alert.ID, []model.AlertStatus{model.AlertStatusActive, model.AlertStatusAcknowledged}, func(stored *model.Alert) {
+		stored.Status = alert.Status
+		stored.ResolvedBy = alert.ResolvedBy
+		stored.Resolution = alert.Resolution
+	}), nil
+}
+
+func (r *memoryAlertRepository) MarkClosed(ctx context.Context, alert *model.Alert) (bool, error) {
+	return r.update(alert.ID, []model.AlertStatus{model.AlertStatusActive, model.AlertStatusAcknowledged, model.AlertStatusResolved}, func(stored *model.Alert) {
+		stored.Status = alert.Status
+	}), nil
+}
+
+func (r *memoryAlertRepository) stored(id primitive.ObjectID) model.Alert {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.alerts[id]
+}
+
+func TestAlertChangesDoNotOverwriteTheEvaluator(t *testing.T) {
+	ctx := context.Background()
+	newAlert := func() *model.Alert {
+		return &model.Alert{Title: "disk full", Status: model.AlertStatusActive}
+	}
+	// resolveMeanwhile resolves an alert the way the evaluator does, between a user's read and write
+	resolveMeanwhile := func(alertRepo *memoryAlertRepository, id primitive.ObjectID) func() {
+		return func() {
+			resolved := alertRepo.stored(id)
+			resolved.Resolve("", "system", "condition cleared")
+			alertRepo.MarkResolved(ctx, &resolved)
+		}
+	}
+
+	t.Run("acknowledge", func(t *testing.T) {
+		alert := newAlert()
+		alertRepo := newMemoryAlertRepository(alert)
+		alertService := NewAlertService(alertRepo, nil)
+
+		if _, err := alertService.AcknowledgeAlert(ctx, alert.ID, "u1", "alice"); err != nil {
+			t.Fatalf("AcknowledgeAlert() error = %v", err)
+		}
+		if stored := alertRepo.stored(alert.ID); stored.Status != model.AlertStatusAcknowledged || stored.AcknowledgedBy != "alice" {
+			t.Errorf("acknowledged alert = %s by %q, want acknowledged by alice", stored.Status, stored.AcknowledgedBy)
+		}
+
+		// An alert resolved after it was read is not reopened by the acknowledgement
+		alert = newAlert()
+		alertRepo = newMemoryAlertRepository(alert)
+		alertRepo.afterRead = resolveMeanwhile(alertRepo, alert.ID)
+		alertService = NewAlertService(alertRepo, nil)
+		if _, err := alertService.AcknowledgeAlert(ctx, alert.ID, "u1", "alice"); err == nil {
+			t.Error("AcknowledgeAlert() of an alert resolved meanwhile succeeded")
+		}
+		if stored := alertRepo.stored(alert.ID); stored.Status != model.AlertStatusResolved || stored.Resolution != "condition cleared" {
+			t.Errorf("alert = %s with resolution %q, want the evaluator's resolution kept", stored.Status, stored.Resolution)
+		}
+	})
+
+	t.Run("resolve", func(t *testing.T) {
+		alert := newAlert()
+		alertRepo := newMemoryAlertRepository(alert)
+		alertRepo.afterRead = resolveMeanwhile(alertRepo, alert.ID)
+		alertService := NewAlertService(alertRepo, nil)
+
+		if _, err := alertService.ResolveAlert(ctx, alert.ID, "u1", "alice", "fixed"); err == nil {
+			t.Error("ResolveAlert() of an alert resolved meanwhile succeeded")
+		}
+		if stored := alertRepo.stored(alert.ID); stored.ResolvedBy != "system" {
+			t.Errorf("alert resolved by %q, want the evaluator's resolution kept", stored.ResolvedBy)
+		}
+	})
+
+	t.Run("close", func(t *testing.T) {
+		alert := newAlert()
+		alertRepo := newMemoryAlertRepository(alert)
+		alertRepo.afterRead = resolveMeanwhile(alertRepo, alert.ID)
+		alertService := NewAlertService(alertRepo, nil)
+
+		closed, err := alertService.CloseAlert(ctx, alert.ID)
+		if err != nil {
+			t.Fatalf("CloseAlert() error = %v", err)
+		}
+		if closed.Status != model.AlertStatusClosed || closed.Resolution != "condition cleared" {
+			t.Errorf("closed alert = %s with resolution %q, want closed with the evaluator's resolution", closed.Status, closed.Resolution)
+		}
+	})
+}
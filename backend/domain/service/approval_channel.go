@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+)
+
+// Approval decisions reported by channel callbacks
+const (
+	ApprovalDecisionApproved = "approved"
+	ApprovalDecisionRejected = "rejected"
+)
+
+// ErrApprovalChannelNotConfigured is returned when the requested approval channel has not been configured
+var ErrApprovalChannelNotConfigured = errors.New("approval channel is not configured")
+
+// ErrInvalidApprovalCallback is returned when a callback fails signature, token or decryption checks
+var ErrInvalidApprovalCallback = errors.New("invalid approval callback")
+
+// ApprovalCallbackRequest is the raw inbound request a channel callback arrived with
+type ApprovalCallbackRequest struct {
+	Method string
+	Query  url.Values
+	Header http.Header
+	Body   []byte
+}
+
+// ApprovalCallback is a verified callback from an approval channel
+type ApprovalCallback struct {
+	// ExternalID identifies the approval on the channel side; empty for handshakes
+	ExternalID string
+	// Decision is approved, rejected or empty when the event carries no decision
+	Decision     string
+	OperatorID   string
+	OperatorName string
+	Comments     string
+
+	// Reply is written back to the channel verbatim when set (challenges, acknowledgements)
+	Reply            []byte
+	ReplyContentType string
+}
+
+// ApprovalChannel sends workflows to an external approval system and verifies its callbacks
+type ApprovalChannel interface {
+	// Name returns the channel name used in routes and stored on workflows
+	Name() string
+
+	// Submit sends the workflow for approval and returns the external ID of the approval
+	Submit(ctx context.Context, workflow *model.Workflow) (string, error)
+
+	// ParseCallback verifies and decodes an inbound callback
+	ParseCallback(req *ApprovalCallbackRequest) (*ApprovalCallback, error)
+}
+
+// ApprovalReminder is implemented by channels that can remind approvers of a workflow they were sent
+type ApprovalReminder interface {
+	// Remind sends a reminder or escalation notice for the workflow
+	Remind(ctx context.Context, workflow *model.Workflow, message string) error
+}
+
+// ApprovalUserNotifier is implemented by channels that can message users directly. Account IDs
+// are the IDs users are linked to at the channel.
+type ApprovalUserNotifier interface {
+	// NotifyUsers sends a message to the given accounts
+	NotifyUsers(ctx context.Context, accountIDs []string, message string) error
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/repository"
+	"github.com/phuhao00/cmdb/backend/infrastructure/logging"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// ApprovalPolicySpec defines the editable fields of an approval policy
+type ApprovalPolicySpec struct {
+	Name         string
+	Description  string
+	WorkflowType string
+	Priorities   []string
+	Stages       []model.ApprovalStageDefinition
+	Enabled      bool
+}
+
+// ApprovalPolicyService manages approval policies and resolves the approval chain of new workflows
+type ApprovalPolicyService struct {
+	policyRepo repository.ApprovalPolicyRepository
+	assetRepo  repository.AssetRepository
+	userRepo   repository.UserRepository
+}
+
+// NewApprovalPolicyService creates a new approval policy service
+func NewApprovalPolicyService(policyRepo repository.ApprovalPolicyRepository, assetRepo repository.AssetRepository, userRepo repository.UserRepository) *ApprovalPolicyService {
+	return &ApprovalPolicyService{
+		policyRepo: policyRepo,
+		assetRepo:  assetRepo,
+		userRepo:   userRepo,
+	}
+}
+
+// CreatePolicy creates a new approval policy
+func (s *ApprovalPolicyService) CreatePolicy(ctx context.Context, spec ApprovalPolicySpec) (*model.ApprovalPolicy, error) {
+	if err := validateApprovalPolicySpec(spec); err != nil {
+		return nil, err
+	}
+
+	policy := model.NewApprovalPolicy(spec.Name, spec.Description, model.WorkflowType(spec.WorkflowType), toWorkflowPriorities(spec.Priorities), spec.Stages)
+	policy.Enabled = spec.Enabled
+
+	if err := s.policyRepo.Save(ctx, policy); err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// UpdatePolicy updates an existing approval policy. Workflows already in flight keep the stages they were created with.
+func (s *ApprovalPolicyService) UpdatePolicy(ctx context.Context, id primitive.ObjectID, spec ApprovalPolicySpec) (*model.ApprovalPolicy, error) {
+	if err := validateApprovalPolicySpec(spec); err != nil {
+		return nil, err
+	}
+
+	policy, err := s.policyRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	policy.Name = spec.Name
+	policy.Description = spec.Description
+	policy.WorkflowType = model.WorkflowType(spec.WorkflowType)
+	policy.Priorities = toWorkflowPriorities(spec.Priorities)
+	policy.Stages = spec.Stages
+	policy.Enabled = spec.Enabled
+	policy.UpdatedAt = time.Now()
+
+	if err := s.policyRepo.Save(ctx, policy); err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// DeletePolicy deletes an approval policy
+func (s *ApprovalPolicyService) DeletePolicy(ctx context.Context, id primitive.ObjectID) error {
+	if _, err := s.policyRepo.FindByID(ctx, id); err != nil {
+		return err
+	}
+	return s.policyRepo.Delete(ctx, id)
+}
+
+// GetPolicy gets an approval policy by its ID
+func (s *ApprovalPolicyService) GetPolicy(ctx context.Context, id primitive.ObjectID) (*model.ApprovalPolicy, error) {
+	return s.policyRepo.FindByID(ctx, id)
+}
+
+// GetPolicies gets all approval policies
+func (s *ApprovalPolicyService) GetPolicies(ctx context.Context) ([]*model.ApprovalPolicy, error) {
+	return s.policyRepo.FindAll(ctx, false)
+}
+
+// AssignStages resolves the approval chain of a new workflow from the most specific enabled
+// policy matching its type and priority. Without a matching policy the workflow gets a single
+// admin or manager stage, which is how approvals worked before policies existed.
+func (s *ApprovalPolicyService) AssignStages(ctx context.Context, workflow *model.Workflow) error {
+	policies, err := s.policyRepo.FindAll(ctx, true)
+	if err != nil {
+		return err
+	}
+
+	var selected *model.ApprovalPolicy
+	for _, policy := range policies {
+		if !policy.Matches(workflow.Type, workflow.Priority) {
+			continue
+		}
+		if selected == nil || policy.Specificity() > selected.Specificity() {
+			selected = policy
+		}
+	}
+
+	if selected == nil {
+		workflow.SetApprovalChain(primitive.NilObjectID, "", []model.ApprovalStage{model.DefaultApprovalStage()})
+		return nil
+	}
+
+	stages := make([]model.ApprovalStage, 0, len(selected.Stages))
+	for _, definition := range selected.Stages {
+		stage := model.ApprovalStage{
+			Name:         definition.Name,
+			ApproverType: definition.ApproverType,
+			Roles:        definition.Roles,
+			Users:        definition.Users,
+			Status:       model.PendingStatus,
+		}
+
+		if definition.ApproverType == model.ApproverTypeOwnerManager {
+			s.resolveOwnerManager(ctx, workflow, &stage)
+		}
+
+		stages = append(stages, stage)
+	}
+
+	workflow.SetApprovalChain(selected.ID, selected.Name, stages)
+	return nil
+}
+
+// resolveOwnerManager fills in the manager of the asset owner as the stage approver. When the
+// owner or manager cannot be found the stage falls back to admins and managers so that the
+// workflow never gets stuck without anyone able to decide it.
+func (s *ApprovalPolicyService) resolveOwnerManager(ctx context.Context, workflow *model.Workflow, stage *model.ApprovalStage) {
+	manager, err := s.ownerManager(ctx, workflow.AssetID)
+	if err == nil {
+		stage.Users = []string{manager}
+		return
+	}
+
+	logging.Logger.Warn("approval_owner_manager_unresolved",
+		zap.String("workflow_id", workflow.WorkflowID),
+		zap.String("asset_id", workflow.AssetID),
+		zap.Error(err))
+	stage.Roles = []model.UserRole{model.AdminRole, model.ManagerRole}
+}
+
+// ownerManager looks up the manager of the owner of an asset
+func (s *ApprovalPolicyService) ownerManager(ctx context.Context, assetID string) (string, error) {
+	asset, err := s.assetRepo.FindByAssetID(ctx, assetID)
+	if err != nil {
+		return "", err
+	}
+	if asset.Owner == "" {
+		return "", errors.New("asset has no owner")
+	}
+
+	owner, err := s.userRepo.GetByUsername(ctx, asset.Owner)
+	if err != nil {
+		return "", fmt.Errorf("owner %s not found: %w", asset.Owner, err)
+	}
+	if owner.Manager == "" {
+		return "", fmt.Errorf("owner %s has no manager", asset.Owner)
+	}
+
+	return owner.Manager, nil
+}
+
+// validateApprovalPolicySpec checks that a policy has a name and well-formed stages
+func validateApprovalPolicySpec(spec ApprovalPolicySpec) error {
+	if strings.TrimSpace(spec.Name) == "" {
+		return errors.New("policy name is required")
+	}
+	if len(spec.Stages) == 0 {
+		return errors.New("policy needs at least one stage")
+	}
+
+	for i, stage := range spec.Stages {
+		if strings.TrimSpace(stage.Name) == "" {
+			return fmt.Errorf("stage %d: name is required", i+1)
+		}
+		if !stage.ApproverType.IsValid() {
+			return fmt.Errorf("stage %d: unknown approver type: %s", i+1, stage.ApproverType)
+		}
+		if stage.ApproverType == model.ApproverTypeRole && len(stage.Roles) == 0 {
+			return fmt.Errorf("stage %d: at least one role is required", i+1)
+		}
+		if stage.ApproverType == model.ApproverTypeUser && len(stage.Users) == 0 {
+			return fmt.Errorf("stage %d: at least one user is required", i+1)
+		}
+	}
+
+	return nil
+}
+
+// toWorkflowPriorities converts priority names to workflow priorities
+func toWorkflowPriorities(priorities []string) []model.WorkflowPriority {
+	if len(priorities) == 0 {
+		return nil
+	}
+	result := make([]model.WorkflowPriority, 0, len(priorities))
+	for _, priority := range priorities {
+		result = append(result, model.WorkflowPriority(priority))
+	}
+	return result
+}
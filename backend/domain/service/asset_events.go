@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"sync"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AssetChangeListener is notified after an asset has been persisted
+type AssetChangeListener interface {
+	AssetChanged(ctx context.Context, asset *model.Asset)
+}
+
+// AssetDeleteListener is notified after an asset has been deleted, to remove what refers to it
+type AssetDeleteListener interface {
+	AssetDeleted(ctx context.Context, id primitive.ObjectID) error
+}
+
+// ObservedAssetRepository wraps an AssetRepository and notifies listeners after
+// every successful save and delete, so that no write path can skip them
+type ObservedAssetRepository struct {
+	repository.AssetRepository
+
+	mu              sync.RWMutex
+	listeners       []AssetChangeListener
+	deleteListeners []AssetDeleteListener
+}
+
+// NewObservedAssetRepository creates a new observed asset repository
+func NewObservedAssetRepository(assetRepo repository.AssetRepository) *ObservedAssetRepository {
+	return &ObservedAssetRepository{
+		AssetRepository: assetRepo,
+	}
+}
+
+// Subscribe registers a listener for asset changes
+func (r *ObservedAssetRepository) Subscribe(listener AssetChangeListener) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.listeners = append(r.listeners, listener)
+}
+
+// SubscribeDeletes registers a listener for asset deletes
+func (r *ObservedAssetRepository) SubscribeDeletes(listener AssetDeleteListener) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deleteListeners = append(r.deleteListeners, listener)
+}
+
+// Save persists the asset and notifies all listeners on success
+func (r *ObservedAssetRepository) Save(ctx context.Context, asset *model.Asset) error {
+	if err := r.AssetRepository.Save(ctx, asset); err != nil {
+		return err
+	}
+
+	r.mu.RLock()
+	listeners := make([]AssetChangeListener, len(r.listeners))
+	copy(listeners, r.listeners)
+	r.mu.RUnlock()
+
+	for _, listener := range listeners {
+		listener.AssetChanged(ctx, asset)
+	}
+
+	return nil
+}
+
+// Delete deletes the asset and notifies all delete listeners on success. Every listener is
+// notified; the first error is returned.
+func (r *ObservedAssetRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	if err := r.AssetRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	r.mu.RLock()
+	listeners := make([]AssetDeleteListener, len(r.deleteListeners))
+	copy(listeners, r.deleteListeners)
+	r.mu.RUnlock()
+
+	var firstErr error
+	for _, listener := range listeners {
+		if err := listener.AssetDeleted(ctx, id); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/repository"
+	"github.com/phuhao00/cmdb/backend/infrastructure/logging"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// HistoryRecordingAssetRepository wraps an AssetRepository and writes an asset history record
+// with every save that changes an asset and every delete, so that no write path can skip the
+// history. The actor and the change are taken from the context of the write; see
+// model.WithActor and model.WithAssetChange.
+type HistoryRecordingAssetRepository struct {
+	repository.AssetRepository
+	assetHistoryRepo repository.AssetHistoryRepository
+}
+
+// NewHistoryRecordingAssetRepository creates a new history recording asset repository
+func NewHistoryRecordingAssetRepository(assetRepo repository.AssetRepository, assetHistoryRepo repository.AssetHistoryRepository) *HistoryRecordingAssetRepository {
+	return &HistoryRecordingAssetRepository{
+		AssetRepository:  assetRepo,
+		assetHistoryRepo: assetHistoryRepo,
+	}
+}
+
+// Save persists the asset and records what changed since the stored version
+func (r *HistoryRecordingAssetRepository) Save(ctx context.Context, asset *model.Asset) error {
+	var oldAsset *model.Asset
+	if !asset.ID.IsZero() {
+		stored, err := r.AssetRepository.FindByID(ctx, asset.ID)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return err
+		}
+		oldAsset = stored
+	}
+
+	if err := r.AssetRepository.Save(ctx, asset); err != nil {
+		return err
+	}
+
+	history := model.NewAssetChangeHistory(ctx, oldAsset, asset)
+	if oldAsset != nil && len(history.FieldChanges) == 0 {
+		return nil
+	}
+	return r.record(ctx, asset, history)
+}
+
+// Delete deletes the asset and records its last values
+func (r *HistoryRecordingAssetRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	asset, err := r.AssetRepository.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := r.AssetRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	return r.record(ctx, asset, model.NewAssetDeleteHistory(ctx, asset))
+}
+
+// record stores a history record. The write has already happened, so a failure is logged
+// with the asset before it is returned.
+func (r *HistoryRecordingAssetRepository) record(ctx context.Context, asset *model.Asset, history *model.AssetHistory) error {
+	if err := r.assetHistoryRepo.Create(ctx, history); err != nil {
+		logging.Logger.Error("asset_history_write_failed",
+			zap.String("asset_id", asset.AssetID),
+			zap.String("change_type", history.ChangeType),
+			zap.Error(err))
+		return err
+	}
+	return nil
+}
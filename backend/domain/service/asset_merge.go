@@ -0,0 +1,334 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/repository"
+	"github.com/phuhao00/cmdb/backend/infrastructure/logging"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// auditResourceAsset is the resource type of audit logs about assets
+const auditResourceAsset = "asset"
+
+// MergeResult describes a merge of a duplicate asset into the asset that survives it
+type MergeResult struct {
+	Survivor *model.Asset
+	// MergedAssetID is the asset ID of the duplicate, which no longer exists
+	MergedAssetID string
+	// TakenFields lists the fields the survivor took from the duplicate
+	TakenFields []string
+	// The number of references moved from the duplicate to the survivor
+	History       int64
+	Workflows     int64
+	AuditLogs     int64
+	Relationships int64
+}
+
+// assetPair is two assets that may be duplicates
+type assetPair struct {
+	a, b      *model.Asset
+	score     float64
+	matchedOn []string
+}
+
+// DetectDuplicates proposes merging active assets the duplicate rules score at or above their
+// threshold. Only assets sharing a blocking key are compared, so the job does not compare every
+// pair of assets. CIs synced from external systems are distinct objects there, so two of them
+// are never proposed. Dismissed pairs stay dismissed. It returns the open proposals it found.
+func (s *ReconciliationService) DetectDuplicates(ctx context.Context) ([]*model.MergeProposal, error) {
+	assets, err := s.assetRepo.FindAll(ctx, map[string]interface{}{"status": activeStatusFilter()})
+	if err != nil {
+		return nil, err
+	}
+
+	policy := s.duplicatePolicy
+	byKey := make(map[string][]*model.Asset)
+	for _, asset := range assets {
+		for _, key := range policy.BlockingKeys(asset) {
+			byKey[key] = append(byKey[key], asset)
+		}
+	}
+
+	keys := make([]string, 0, len(byKey))
+	for key := range byKey {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	compared := make(map[string]bool)
+	var pairs []*assetPair
+	for _, key := range keys {
+		group := byKey[key]
+		for i := 0; i < len(group); i++ {
+			for j := i + 1; j < len(group); j++ {
+				if group[i].ExternalID != "" && group[j].ExternalID != "" {
+					continue
+				}
+				pairKey := model.MergeProposalKey(group[i].ID, group[j].ID)
+				if compared[pairKey] {
+					continue
+				}
+				compared[pairKey] = true
+
+				score, matchedOn := policy.Score(group[i], group[j])
+				if score >= policy.Threshold {
+					pairs = append(pairs, &assetPair{a: group[i], b: group[j], score: score, matchedOn: matchedOn})
+				}
+			}
+		}
+	}
+
+	var proposals []*model.MergeProposal
+	for _, pair := range pairs {
+		proposal, err := s.reconciliationRepo.FindMergeProposalByKey(ctx, model.MergeProposalKey(pair.a.ID, pair.b.ID))
+		if err != nil {
+			return nil, err
+		}
+		if proposal == nil {
+			proposal = model.NewMergeProposal(pair.a, pair.b, pair.matchedOn, pair.score)
+			logging.Logger.Info("reconciliation_merge_proposed",
+				zap.String("asset_id", pair.a.AssetID),
+				zap.String("other_asset_id", pair.b.AssetID),
+				zap.Float64("score", pair.score),
+				zap.Strings("matched_on", pair.matchedOn))
+		} else if proposal.IsOpen() {
+			proposal.Observe(pair.a, pair.b, pair.matchedOn, pair.score)
+		} else {
+			continue
+		}
+
+		if err := s.reconciliationRepo.SaveMergeProposal(ctx, proposal); err != nil {
+			return nil, err
+		}
+		proposals = append(proposals, proposal)
+	}
+
+	return proposals, nil
+}
+
+// MergeProposal carries out an open merge proposal. The survivor must be one of the proposal's
+// assets; without one, the asset created first survives.
+func (s *ReconciliationService) MergeProposal(ctx context.Context, id, survivorID primitive.ObjectID, reviewer string) (*MergeResult, error) {
+	proposal, err := s.reconciliationRepo.FindMergeProposalByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !proposal.IsOpen() {
+		return nil, model.ErrMergeProposalReviewed
+	}
+
+	first, second := proposal.Assets[0].ID, proposal.Assets[1].ID
+	var duplicateID primitive.ObjectID
+	switch survivorID {
+	case first:
+		duplicateID = second
+	case second:
+		duplicateID = first
+	case primitive.NilObjectID:
+		survivorID, duplicateID, err = s.olderFirst(ctx, first, second)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("asset %s is not part of the merge proposal", survivorID.Hex())
+	}
+
+	return s.MergeAssets(ctx, survivorID, duplicateID, reviewer)
+}
+
+// olderFirst orders two assets by when they were created
+func (s *ReconciliationService) olderFirst(ctx context.Context, a, b primitive.ObjectID) (primitive.ObjectID, primitive.ObjectID, error) {
+	first, err := s.assetRepo.FindByID(ctx, a)
+	if err != nil {
+		return a, b, err
+	}
+	second, err := s.assetRepo.FindByID(ctx, b)
+	if err != nil {
+		return a, b, err
+	}
+	if second.CreatedAt.Before(first.CreatedAt) {
+		return b, a, nil
+	}
+	return a, b, nil
+}
+
+// MergeAssets merges a duplicate into the asset that survives it. The survivor takes the
+// duplicate's values where the precedence rules prefer them; the history, workflows, audit logs
+// and relationships of the duplicate move to the survivor, its open conflicts and merge
+// proposals are closed, and the duplicate is deleted. The survivor's history records the merge.
+func (s *ReconciliationService) MergeAssets(ctx context.Context, survivorID, duplicateID primitive.ObjectID, reviewer string) (*MergeResult, error) {
+	if survivorID == duplicateID {
+		return nil, model.ErrMergeSameAsset
+	}
+
+	survivor, err := s.assetRepo.FindByID(ctx, survivorID)
+	if err != nil {
+		return nil, err
+	}
+	duplicate, err := s.assetRepo.FindByID(ctx, duplicateID)
+	if err != nil {
+		return nil, err
+	}
+	if survivor.ExternalID != "" && duplicate.ExternalID != "" {
+		return nil, model.ErrMergeSyncedAssets
+	}
+
+	result := &MergeResult{
+		Survivor:      survivor,
+		MergedAssetID: duplicate.AssetID,
+		TakenFields:   survivor.MergeFrom(duplicate, s.policy),
+	}
+	if _, err := s.ciTypeService.ValidateAsset(ctx, survivor); err != nil {
+		return nil, err
+	}
+
+	// Every write of the merge is recorded with it in the asset history
+	reason := fmt.Sprintf("Merged %s (%s) into %s", duplicate.AssetID, duplicate.Name, survivor.AssetID)
+	ctx = model.WithAssetChange(ctx, model.AssetChange{Type: model.ChangeTypeMerge, Reason: reason})
+	if err := s.releaseIdentifiers(ctx, survivor, duplicate); err != nil {
+		return nil, err
+	}
+	survivor.UpdatedAt = time.Now()
+	if err := s.assetRepo.Save(ctx, survivor); err != nil {
+		return nil, err
+	}
+
+	// The duplicate is deleted last, so a merge that fails part way can be run again
+	if err := s.moveReferences(ctx, survivor, duplicate, result); err != nil {
+		return nil, err
+	}
+	if err := s.closeReviews(ctx, survivor, duplicate, reviewer); err != nil {
+		return nil, err
+	}
+	if err := s.assetRepo.Delete(ctx, duplicate.ID); err != nil {
+		return nil, err
+	}
+
+	logging.Logger.Info("reconciliation_assets_merged",
+		zap.String("asset_id", survivor.AssetID),
+		zap.String("merged_asset_id", duplicate.AssetID),
+		zap.String("reviewer", reviewer),
+		zap.Strings("taken_fields", result.TakenFields))
+
+	return result, nil
+}
+
+// releaseIdentifiers clears the unique identifiers the survivor takes over from the duplicate,
+// so that both can be stored until the duplicate is deleted
+func (s *ReconciliationService) releaseIdentifiers(ctx context.Context, survivor, duplicate *model.Asset) error {
+	released := false
+	if duplicate.MachineID != "" && duplicate.MachineID == survivor.MachineID {
+		duplicate.MachineID = ""
+		released = true
+	}
+	if duplicate.ExternalID != "" && duplicate.ExternalID == survivor.ExternalID {
+		duplicate.ExternalID = ""
+		released = true
+	}
+	if !released {
+		return nil
+	}
+	return s.assetRepo.Save(ctx, duplicate)
+}
+
+// moveReferences points everything that refers to the duplicate at the survivor
+func (s *ReconciliationService) moveReferences(ctx context.Context, survivor, duplicate *model.Asset, result *MergeResult) error {
+	var err error
+	if result.History, err = s.assetHistoryRepo.ReassignAsset(ctx, duplicate.ID, survivor.ID, duplicate.AssetID); err != nil {
+		return err
+	}
+	if result.Workflows, err = s.workflowRepo.ReassignAsset(ctx, duplicate.AssetID, survivor.AssetID, survivor.Name); err != nil {
+		return err
+	}
+
+	// Audit logs refer to assets by ID or by asset ID. Chained entries cannot change, so the
+	// reassignment is logged instead and the survivor's audit history includes the duplicate's.
+	actor, client := auditSource(ctx)
+	for _, ids := range [][2]string{{duplicate.ID.Hex(), survivor.ID.Hex()}, {duplicate.AssetID, survivor.AssetID}} {
+		moved, err := s.auditLogService.GetAuditLogCount(ctx, repository.AuditLogSearchCriteria{ResourceType: auditResourceAsset, ResourceID: ids[0]})
+		if err != nil {
+			return err
+		}
+		result.AuditLogs += moved
+
+		if err := s.auditLogService.LogResourceReassigned(ctx, actor.ID, actor.Name, auditResourceAsset, ids[0], duplicate.Name, ids[1], survivor.Name, client.IPAddress, client.UserAgent); err != nil {
+			return err
+		}
+	}
+
+	relationships, err := s.relationshipRepo.FindByAssetID(ctx, duplicate.ID)
+	if err != nil {
+		return err
+	}
+	for _, relationship := range relationships {
+		if relationship.SourceID == duplicate.ID {
+			relationship.SourceID = survivor.ID
+		}
+		if relationship.TargetID == duplicate.ID {
+			relationship.TargetID = survivor.ID
+		}
+
+		// Relationships between the two assets, and those the survivor already has, go away
+		drop := relationship.SourceID == relationship.TargetID
+		if !drop {
+			if drop, err = s.relationshipRepo.Exists(ctx, relationship.SourceID, relationship.TargetID, relationship.Type); err != nil {
+				return err
+			}
+		}
+		if drop {
+			err = s.relationshipRepo.Delete(ctx, relationship.ID)
+		} else {
+			err = s.relationshipRepo.Save(ctx, relationship)
+			result.Relationships++
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// closeReviews closes the open conflicts of the duplicate and its open merge proposals. The
+// proposal for the merged pair is marked merged; the others are dismissed, and the next
+// detection run compares their other assets with the survivor instead.
+func (s *ReconciliationService) closeReviews(ctx context.Context, survivor, duplicate *model.Asset, reviewer string) error {
+	conflicts, err := s.reconciliationRepo.FindConflicts(ctx, map[string]interface{}{
+		"assetId": duplicate.ID,
+		"status":  model.ConflictOpen,
+	})
+	if err != nil {
+		return err
+	}
+	for _, conflict := range conflicts {
+		conflict.Resolve(model.ConflictOverridden, reviewer)
+		if err := s.reconciliationRepo.SaveConflict(ctx, conflict); err != nil {
+			return err
+		}
+	}
+
+	proposals, err := s.reconciliationRepo.FindMergeProposals(ctx, map[string]interface{}{
+		"assets.id": duplicate.ID,
+		"status":    model.MergeProposalOpen,
+	})
+	if err != nil {
+		return err
+	}
+	mergedKey := model.MergeProposalKey(survivor.ID, duplicate.ID)
+	for _, proposal := range proposals {
+		if proposal.Key == mergedKey {
+			proposal.Merge(reviewer)
+		} else {
+			proposal.Dismiss(reviewer)
+		}
+		if err := s.reconciliationRepo.SaveMergeProposal(ctx, proposal); err != nil {
+			return err
+		}
+	}
+	return nil
+}
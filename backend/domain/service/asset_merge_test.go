@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// noReconciliationReviews has no open conflicts or merge proposals to close
+type noReconciliationReviews struct {
+	repository.ReconciliationRepository
+}
+
+func (noReconciliationReviews) FindConflicts(ctx context.Context, filter map[string]interface{}) ([]*model.FieldConflict, error) {
+	return nil, nil
+}
+
+func (noReconciliationReviews) FindMergeProposals(ctx context.Context, filter map[string]interface{}) ([]*model.MergeProposal, error) {
+	return nil, nil
+}
+
+func TestMergeAssetsMovesReferences(t *testing.T) {
+	ctx := context.Background()
+	start := time.Now().Add(-time.Hour)
+
+	survivor := model.NewAsset("web-01", model.ServerType, "DC1", "frontend")
+	survivor.ID, survivor.AssetID = primitive.NewObjectID(), "AST-0001"
+	duplicate := model.NewAsset("web-01", model.ServerType, "DC1", "")
+	duplicate.ID, duplicate.AssetID = primitive.NewObjectID(), "AST-0002"
+	database := model.NewAsset("db-01", model.ServerType, "DC1", "")
+	database.ID, database.AssetID = primitive.NewObjectID(), "AST-0003"
+	assetRepo := newMemoryAssetRepository(survivor, duplicate, database)
+
+	record := func(asset *model.Asset, changeType string, at time.Time, field string, oldValue, newValue interface{}) *model.AssetHistory {
+		history := model.NewAssetHistory(asset.ID, asset.Name, changeType, "alice", "u1", "")
+		history.Timestamp = at
+		if field != "" {
+			history.AddFieldChange(field, oldValue, newValue)
+		}
+		return history
+	}
+	created := record(survivor, model.ChangeTypeCreate, start, "", nil, nil)
+	moved := record(survivor, model.ChangeTypeUpdate, start.Add(time.Minute), "location", "DC0", "DC1")
+	duplicateMoved := record(duplicate, model.ChangeTypeUpdate, start.Add(2*time.Minute), "location", "DC9", "DC1")
+	described := record(survivor, model.ChangeTypeUpdate, start.Add(3*time.Minute), "description", "", "frontend")
+	historyRepo := &memoryAssetHistoryRepository{records: []*model.AssetHistory{created, moved, duplicateMoved, described}}
+
+	workflowRepo := &memoryWorkflowRepository{}
+	for _, asset := range []*model.Asset{duplicate, duplicate, survivor} {
+		workflowRepo.workflows = append(workflowRepo.workflows,
+			model.NewWorkflow(model.AssetUpdateType, asset.AssetID, asset.Name, "alice", "u1", model.MediumPriority, "Resize", nil))
+	}
+
+	relationship := func(source, target *model.Asset, relationshipType model.RelationshipType) *model.Relationship {
+		r := model.NewRelationship(source.ID, target.ID, relationshipType, "", "alice", "u1")
+		r.ID = primitive.NewObjectID()
+		return r
+	}
+	relationshipRepo := &memoryRelationshipRepository{relationships: []*model.Relationship{
+		relationship(duplicate, database, model.RunsOnRelationship),
+		// Between the two assets: a self-loop once merged
+		relationship(survivor, duplicate, model.ConnectsToRelationship),
+		// The survivor already has it
+		relationship(database, duplicate, model.DependsOnRelationship),
+		relationship(database, survivor, model.DependsOnRelationship),
+	}}
+
+	auditLogRepo := &memoryAuditLogRepository{}
+	auditLogService := NewAuditLogService(auditLogRepo, &memoryAuditCheckpointRepository{}, &memoryAuditArchiveRepository{}, &memoryAuditArchiveStore{}, nil)
+	if err := auditLogService.LogAssetStatusChanged(ctx, "u1", "alice", duplicate, model.AssetStatus("maintenance"), duplicate.Status, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := auditLogService.LogRequest(ctx, "u1", "alice", "asset_update", auditResourceAsset, duplicate.AssetID, "PUT", "/api/v1/assets/"+duplicate.AssetID, 200, "", "", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	reconciliation := NewReconciliationService(noReconciliationReviews{}, assetRepo, historyRepo, relationshipRepo, workflowRepo, auditLogService, NewCITypeService(builtInCITypes{}, assetRepo))
+	result, err := reconciliation.MergeAssets(ctx, survivor.ID, duplicate.ID, "admin")
+	if err != nil {
+		t.Fatalf("MergeAssets() error = %v", err)
+	}
+
+	if result.History != 1 || result.Workflows != 2 || result.AuditLogs != 2 || result.Relationships != 1 {
+		t.Errorf("MergeAssets() moved %d history records, %d workflows, %d audit logs and %d relationships; want 1, 2, 2 and 1",
+			result.History, result.Workflows, result.AuditLogs, result.Relationships)
+	}
+	if _, err := assetRepo.FindByID(ctx, duplicate.ID); err == nil {
+		t.Error("the duplicate was not deleted")
+	}
+
+	if duplicateMoved.AssetID != survivor.ID || duplicateMoved.MergedFrom != duplicate.AssetID {
+		t.Errorf("duplicate history = asset %s merged from %q, want the survivor and %s", duplicateMoved.AssetID.Hex(), duplicateMoved.MergedFrom, duplicate.AssetID)
+	}
+	for _, workflow := range workflowRepo.workflows {
+		if workflow.AssetID != survivor.AssetID || workflow.AssetName != survivor.Name {
+			t.Errorf("workflow for %s (%s), want all on the survivor", workflow.AssetID, workflow.AssetName)
+		}
+	}
+
+	type edge struct {
+		source, target primitive.ObjectID
+		kind           model.RelationshipType
+	}
+	var edges []edge
+	for _, r := range relationshipRepo.relationships {
+		edges = append(edges, edge{r.SourceID, r.TargetID, r.Type})
+	}
+	sort.Slice(edges, func(i, j int) bool { return edges[i].kind < edges[j].kind })
+	wantEdges := []edge{
+		{database.ID, survivor.ID, model.DependsOnRelationship},
+		{survivor.ID, database.ID, model.RunsOnRelationship},
+	}
+	if !reflect.DeepEqual(edges, wantEdges) {
+		t.Errorf("relationships = %+v, want %+v", edges, wantEdges)
+	}
+
+	// The duplicate's audit logs are unchanged and show up with the survivor's
+	for _, ids := range [][2]string{{survivor.ID.Hex(), duplicate.ID.Hex()}, {survivor.AssetID, duplicate.AssetID}} {
+		history, err := auditLogService.GetResourceHistory(ctx, auditResourceAsset, ids[0], 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(history) != 2 || history[0].Action != model.ResourceReassigned || history[1].ResourceID != ids[1] {
+			t.Errorf("audit history of %s = %+v, want the reassignment and the entry for %s", ids[0], history, ids[1])
+		}
+	}
+	if verification, err := auditLogService.VerifyChain(ctx, 0, 0); err != nil || !verification.Valid {
+		t.Errorf("VerifyChain() = %+v, %v; want a valid chain", verification, err)
+	}
+
+	// Reverting the survivor leaves the duplicate's changes alone, and they are no revision of it
+	assetService := NewAssetService(assetRepo, workflowRepo, historyRepo, nil, nil, nil, auditLogService)
+	preview, err := assetService.PreviewRevert(ctx, survivor.ID, moved.ID)
+	if err != nil {
+		t.Fatalf("PreviewRevert() error = %v", err)
+	}
+	wantChanges := []model.FieldChange{{FieldName: "description", OldValue: "frontend", NewValue: ""}}
+	if !reflect.DeepEqual(preview.Changes, wantChanges) {
+		t.Errorf("PreviewRevert() changes = %+v, want %+v", preview.Changes, wantChanges)
+	}
+	if _, err := assetService.PreviewRevert(ctx, survivor.ID, duplicateMoved.ID); !errors.Is(err, model.ErrInvalidRevision) {
+		t.Errorf("PreviewRevert() to a merged record error = %v, want ErrInvalidRevision", err)
+	}
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PreviewRevert shows what reverting an asset to the state captured by one of its history
+// records would change
+func (s *AssetService) PreviewRevert(ctx context.Context, id, revisionID primitive.ObjectID) (*model.RevertPreview, error) {
+	asset, err := s.assetRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.previewRevert(ctx, asset, revisionID)
+}
+
+// previewRevert rewinds an asset to the state right after one of its history records
+func (s *AssetService) previewRevert(ctx context.Context, asset *model.Asset, revisionID primitive.ObjectID) (*model.RevertPreview, error) {
+	revision, err := s.assetHistoryRepo.FindByID(ctx, revisionID)
+	if err != nil {
+		return nil, err
+	}
+	if revision.AssetID != asset.ID || revision.MergedFrom != "" {
+		return nil, model.ErrInvalidRevision
+	}
+
+	// Undo the records made after the revision, which come before it newest first
+	records, err := s.assetHistoryRepo.FindByDateRange(ctx, asset.ID, revision.Timestamp, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	var undo []*model.AssetHistory
+	found := false
+	for _, record := range records {
+		if record.ID == revision.ID {
+			found = true
+			break
+		}
+		undo = append(undo, record)
+	}
+	if !found {
+		return nil, model.ErrInvalidRevision
+	}
+
+	state, err := model.RewindAsset(asset, undo, revision.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+	return model.NewRevertPreview(asset, revision, state), nil
+}
+
+// CreateAssetRevertWorkflow requests approval to revert an asset to one of its history records.
+// The workflow carries the changes of the preview and fails if the asset changes before it is
+// approved.
+func (s *AssetService) CreateAssetRevertWorkflow(ctx context.Context, id, revisionID primitive.ObjectID, reason, requester, requesterID string) (*model.Workflow, *model.RevertPreview, error) {
+	asset, err := s.assetRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	preview, err := s.previewRevert(ctx, asset, revisionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(preview.Changes) == 0 {
+		return nil, nil, model.ErrNothingToRevert
+	}
+	if _, err := s.ciTypeService.ValidateAsset(ctx, preview.State.Asset); err != nil {
+		return nil, nil, err
+	}
+
+	if reason == "" {
+		reason = fmt.Sprintf("Revert to revision %s", revisionID.Hex())
+	}
+	workflow := model.NewWorkflow(
+		model.AssetRevertType,
+		asset.AssetID,
+		asset.Name,
+		requester,
+		requesterID,
+		model.MediumPriority,
+		reason,
+		model.NewAssetRevertPayload(model.NewAssetRevert(preview)),
+	)
+
+	// Resolve approval stages and save workflow
+	if err := s.saveNewWorkflow(ctx, workflow); err != nil {
+		return nil, nil, err
+	}
+
+	return workflow, preview, nil
+}
@@ -2,7 +2,7 @@ package service
 
 import (
 	"context"
-	"errors"
+	"fmt"
 
 	"github.com/phuhao00/cmdb/backend/domain/model"
 	"github.com/phuhao00/cmdb/backend/domain/repository"
@@ -14,33 +14,76 @@ type AssetService struct {
 	assetRepo        repository.AssetRepository
 	workflowRepo     repository.WorkflowRepository
 	assetHistoryRepo repository.AssetHistoryRepository
+	policyService    *ApprovalPolicyService
+	idService        *IDService
+	ciTypeService    *CITypeService
+	lifecycle        *model.Lifecycle
+	reconciliation   *ReconciliationService
+	auditLogService  *AuditLogService
 }
 
 // NewAssetService creates a new asset service
-func NewAssetService(assetRepo repository.AssetRepository, workflowRepo repository.WorkflowRepository, assetHistoryRepo repository.AssetHistoryRepository) *AssetService {
+func NewAssetService(assetRepo repository.AssetRepository, workflowRepo repository.WorkflowRepository, assetHistoryRepo repository.AssetHistoryRepository, policyService *ApprovalPolicyService, idService *IDService, ciTypeService *CITypeService, auditLogService *AuditLogService) *AssetService {
 	return &AssetService{
 		assetRepo:        assetRepo,
 		workflowRepo:     workflowRepo,
 		assetHistoryRepo: assetHistoryRepo,
+		policyService:    policyService,
+		idService:        idService,
+		ciTypeService:    ciTypeService,
+		lifecycle:        model.DefaultLifecycle(),
+		auditLogService:  auditLogService,
+	}
+}
+
+// SetLifecycle replaces the asset lifecycle enforced on status changes
+func (s *AssetService) SetLifecycle(lifecycle *model.Lifecycle) {
+	s.lifecycle = lifecycle
+}
+
+// GetLifecycle gets the asset lifecycle
+func (s *AssetService) GetLifecycle() *model.Lifecycle {
+	return s.lifecycle
+}
+
+// SetReconciliation sets the service that settles field values reported by different sources
+func (s *AssetService) SetReconciliation(reconciliation *ReconciliationService) {
+	s.reconciliation = reconciliation
+}
+
+// ReconcileFields settles the fields a source changed on an asset against the precedence rules
+// before the asset is saved. Without reconciliation the source simply becomes the source of
+// every changed field.
+func (s *AssetService) ReconcileFields(ctx context.Context, oldAsset, asset *model.Asset, source, reporter string) error {
+	if s.reconciliation == nil {
+		asset.RecordFieldSources(oldAsset, source, reporter)
+		return nil
+	}
+	return s.reconciliation.Reconcile(ctx, oldAsset, asset, source, reporter)
+}
+
+// PreviewFields shows which fields a source could change on an asset without recording anything
+func (s *AssetService) PreviewFields(oldAsset, asset *model.Asset, source string) {
+	if s.reconciliation != nil {
+		s.reconciliation.Preview(oldAsset, asset, source)
 	}
 }
 
 // CreateAsset creates a new asset and initiates an onboarding workflow
-func (s *AssetService) CreateAsset(ctx context.Context, name string, assetType string, location string, description string) (*model.Asset, *model.Workflow, error) {
+func (s *AssetService) CreateAsset(ctx context.Context, name string, assetType string, location string, description string, attributes map[string]interface{}) (*model.Asset, *model.Workflow, error) {
 	// Create new asset
 	asset := model.NewAsset(name, model.AssetType(assetType), location, description)
-
-	// Generate asset ID
-	assetID, err := s.assetRepo.GenerateAssetID(ctx, string(asset.Type))
+	asset.Attributes = attributes
+	ciType, err := s.validateNewAsset(ctx, asset)
 	if err != nil {
 		return nil, nil, err
 	}
-	asset.AssetID = assetID
 
-	// Save asset
-	if err := s.assetRepo.Save(ctx, asset); err != nil {
+	// Generate asset ID and save asset
+	if err := s.idService.SaveNewAsset(ctx, asset, ciType.Prefix); err != nil {
 		return nil, nil, err
 	}
+	s.auditLogService.logAssetChange(ctx, nil, asset)
 
 	// Create onboarding workflow
 	workflow := model.NewWorkflow(
@@ -54,40 +97,50 @@ func (s *AssetService) CreateAsset(ctx context.Context, name string, assetType s
 		asset,
 	)
 
-	// Generate workflow ID
-	workflowID, err := s.workflowRepo.GenerateWorkflowID(ctx)
-	if err != nil {
-		return nil, nil, err
-	}
-	workflow.WorkflowID = workflowID
-
-	// Save workflow
-	if err := s.workflowRepo.Save(ctx, workflow); err != nil {
+	// Resolve approval stages and save workflow
+	if err := s.saveNewWorkflow(ctx, workflow); err != nil {
 		return nil, nil, err
 	}
 
 	return asset, workflow, nil
 }
 
-// UpdateAsset updates an existing asset
-func (s *AssetService) UpdateAsset(ctx context.Context, id primitive.ObjectID, name string, location string, description string) (*model.Asset, error) {
+// UpdateAsset updates an existing asset. Nil attributes leave the current attributes unchanged.
+func (s *AssetService) UpdateAsset(ctx context.Context, id primitive.ObjectID, name string, location string, description string, attributes map[string]interface{}) (*model.Asset, error) {
 	// Find asset
 	asset, err := s.assetRepo.FindByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	// Update asset
+	// Update asset; values entered by users always apply
+	oldAsset := asset.Clone()
 	asset.Update(name, location, description)
+	if attributes != nil {
+		asset.Attributes = attributes
+	}
+	if _, err := s.ciTypeService.ValidateAsset(ctx, asset); err != nil {
+		return nil, err
+	}
 
-	// Save asset
-	if err := s.assetRepo.Save(ctx, asset); err != nil {
+	if err := s.saveManualChange(ctx, oldAsset, asset); err != nil {
 		return nil, err
 	}
 
 	return asset, nil
 }
 
+// saveManualChange saves an asset changed by the user of the context, who becomes the source
+// of the changed fields
+func (s *AssetService) saveManualChange(ctx context.Context, oldAsset, asset *model.Asset) error {
+	asset.RecordFieldSources(oldAsset, model.SourceManual, model.ActorFromContext(ctx).Name)
+	if err := s.assetRepo.Save(ctx, asset); err != nil {
+		return err
+	}
+	s.auditLogService.logAssetChange(ctx, oldAsset, asset)
+	return nil
+}
+
 // RequestDecommission initiates a decommission workflow for an asset
 func (s *AssetService) RequestDecommission(ctx context.Context, id primitive.ObjectID, requester string, reason string) (*model.Workflow, error) {
 	// Find asset
@@ -96,9 +149,9 @@ func (s *AssetService) RequestDecommission(ctx context.Context, id primitive.Obj
 		return nil, err
 	}
 
-	// Check if asset is already decommissioned
-	if asset.IsDecommissioned() {
-		return nil, errors.New("asset is already decommissioned")
+	// Check that the lifecycle allows decommissioning the asset
+	if _, err := s.lifecycle.CheckTransition(asset, model.DecommissionedStatus); err != nil {
+		return nil, err
 	}
 
 	// Create decommission workflow
@@ -113,15 +166,8 @@ func (s *AssetService) RequestDecommission(ctx context.Context, id primitive.Obj
 		asset,
 	)
 
-	// Generate workflow ID
-	workflowID, err := s.workflowRepo.GenerateWorkflowID(ctx)
-	if err != nil {
-		return nil, err
-	}
-	workflow.WorkflowID = workflowID
-
-	// Save workflow
-	if err := s.workflowRepo.Save(ctx, workflow); err != nil {
+	// Resolve approval stages and save workflow
+	if err := s.saveNewWorkflow(ctx, workflow); err != nil {
 		return nil, err
 	}
 
@@ -136,9 +182,13 @@ func (s *AssetService) RequestStatusChange(ctx context.Context, id primitive.Obj
 		return nil, err
 	}
 
-	// Check if asset is decommissioned
-	if asset.IsDecommissioned() {
-		return nil, errors.New("cannot change status of decommissioned asset")
+	// Toggle between online and offline, as long as the lifecycle allows it
+	target := model.OnlineStatus
+	if asset.IsOnline() {
+		target = model.OfflineStatus
+	}
+	if _, err := s.lifecycle.CheckTransition(asset, target); err != nil {
+		return nil, err
 	}
 
 	// Create status change workflow
@@ -150,21 +200,11 @@ func (s *AssetService) RequestStatusChange(ctx context.Context, id primitive.Obj
 		"user",
 		model.MediumPriority,
 		reason,
-		map[string]interface{}{
-			"oldStatus":       asset.Status,
-			"requestedAction": "status_change",
-		},
+		model.NewStatusChangePayload(target, nil),
 	)
 
-	// Generate workflow ID
-	workflowID, err := s.workflowRepo.GenerateWorkflowID(ctx)
-	if err != nil {
-		return nil, err
-	}
-	workflow.WorkflowID = workflowID
-
-	// Save workflow
-	if err := s.workflowRepo.Save(ctx, workflow); err != nil {
+	// Resolve approval stages and save workflow
+	if err := s.saveNewWorkflow(ctx, workflow); err != nil {
 		return nil, err
 	}
 
@@ -179,14 +219,9 @@ func (s *AssetService) RequestMaintenance(ctx context.Context, id primitive.Obje
 		return nil, err
 	}
 
-	// Check if asset is decommissioned
-	if asset.IsDecommissioned() {
-		return nil, errors.New("cannot request maintenance for decommissioned asset")
-	}
-
-	// Check if asset is already in maintenance
-	if asset.IsInMaintenance() {
-		return nil, errors.New("asset is already in maintenance")
+	// Check that the lifecycle allows putting the asset into maintenance
+	if _, err := s.lifecycle.CheckTransition(asset, model.MaintenanceStatus); err != nil {
+		return nil, err
 	}
 
 	// Create maintenance workflow
@@ -201,37 +236,145 @@ func (s *AssetService) RequestMaintenance(ctx context.Context, id primitive.Obje
 		asset,
 	)
 
-	// Generate workflow ID
-	workflowID, err := s.workflowRepo.GenerateWorkflowID(ctx)
+	// Resolve approval stages and save workflow
+	if err := s.saveNewWorkflow(ctx, workflow); err != nil {
+		return nil, err
+	}
+
+	return workflow, nil
+}
+
+// ChangeStatus moves an asset to another lifecycle status. Transitions that need approval
+// create a status change workflow and leave the asset unchanged until it is approved; other
+// transitions are applied immediately and recorded in the asset history.
+func (s *AssetService) ChangeStatus(ctx context.Context, id primitive.ObjectID, target model.AssetStatus, disposal *model.DisposalRecord, requester string, requesterID string, reason string) (*model.Asset, *model.Workflow, error) {
+	// Find asset
+	asset, err := s.assetRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Check the transition against the asset as it will look afterwards
+	candidate := *asset
+	if disposal != nil {
+		candidate.Disposal = disposal
+	}
+	transition, err := s.lifecycle.CheckTransition(&candidate, target)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if transition.RequiresApproval {
+		workflow := model.NewWorkflow(
+			model.StatusChangeType,
+			asset.AssetID,
+			asset.Name,
+			requester,
+			requesterID,
+			model.MediumPriority,
+			reason,
+			model.NewStatusChangePayload(target, disposal),
+		)
+
+		// Resolve approval stages and save workflow
+		if err := s.saveNewWorkflow(ctx, workflow); err != nil {
+			return nil, nil, err
+		}
+
+		return asset, workflow, nil
+	}
+
+	if disposal != nil {
+		asset.SetDisposal(disposal)
+	}
+	if err := s.applyStatus(ctx, asset, target, requester, requesterID, reason); err != nil {
+		return nil, nil, err
+	}
+
+	return asset, nil, nil
+}
+
+// RecordStatus applies a status change observed in a system the asset is synced from, such as
+// a Kubernetes object that was deleted. The lifecycle must allow the transition, but no
+// approval is requested because the change has already happened.
+func (s *AssetService) RecordStatus(ctx context.Context, id primitive.ObjectID, target model.AssetStatus, requester string, requesterID string, reason string) (*model.Asset, error) {
+	// Find asset
+	asset, err := s.assetRepo.FindByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
-	workflow.WorkflowID = workflowID
 
-	// Save workflow
-	if err := s.workflowRepo.Save(ctx, workflow); err != nil {
+	if _, err := s.lifecycle.CheckTransition(asset, target); err != nil {
+		return nil, err
+	}
+	if err := s.applyStatus(ctx, asset, target, requester, requesterID, reason); err != nil {
 		return nil, err
 	}
 
-	return workflow, nil
+	return asset, nil
 }
 
-// BulkCreateAssets creates multiple assets and initiates onboarding workflows
-func (s *AssetService) BulkCreateAssets(ctx context.Context, assets []model.Asset) (int, error) {
-	successCount := 0
+// RegisterSyncedAsset saves a new asset mirrored from a system it is synced from. Such assets
+// are already in service, so no onboarding workflow is created; the asset is brought online
+// when the lifecycle allows it without approval.
+func (s *AssetService) RegisterSyncedAsset(ctx context.Context, asset *model.Asset, requester string, requesterID string, reason string) error {
+	ciType, err := s.validateNewAsset(ctx, asset)
+	if err != nil {
+		return err
+	}
+
+	// Generate asset ID and save asset
+	ctx = model.WithActor(ctx, model.Actor{ID: requesterID, Name: requester})
+	if err := s.idService.SaveNewAsset(model.WithAssetChange(ctx, model.AssetChange{Reason: reason}), asset, ciType.Prefix); err != nil {
+		return err
+	}
+	s.auditLogService.logAssetChange(ctx, nil, asset)
+
+	transition, err := s.lifecycle.CheckTransition(asset, model.OnlineStatus)
+	if err != nil || transition.RequiresApproval {
+		return nil
+	}
+	return s.applyStatus(ctx, asset, model.OnlineStatus, requester, requesterID, reason)
+}
+
+// applyStatus moves an asset to a status and saves it, which records the change in the asset
+// history and the audit log as made by the requester
+func (s *AssetService) applyStatus(ctx context.Context, asset *model.Asset, target model.AssetStatus, requester string, requesterID string, reason string) error {
+	oldAsset := asset.Clone()
+	asset.SetStatus(target)
+
+	ctx = model.WithActor(ctx, model.Actor{ID: requesterID, Name: requester})
+	if err := s.assetRepo.Save(model.WithAssetChange(ctx, model.AssetChange{Type: model.ChangeTypeStatusChange, Reason: reason}), asset); err != nil {
+		return err
+	}
+	s.auditLogService.logAssetChange(ctx, oldAsset, asset)
+	return nil
+}
 
+// BulkCreateAssets creates multiple assets and initiates onboarding workflows. The whole
+// batch is rejected when any asset does not match its CI type schema or would start in a
+// status the lifecycle does not allow. Assets that could not be saved are left without an
+// asset ID.
+func (s *AssetService) BulkCreateAssets(ctx context.Context, assets []model.Asset) (int, error) {
+	ciTypes := make([]*model.CIType, len(assets))
 	for i := range assets {
-		// Generate asset ID
-		assetID, err := s.assetRepo.GenerateAssetID(ctx, string(assets[i].Type))
+		ciType, err := s.validateNewAsset(ctx, &assets[i])
 		if err != nil {
-			continue
+			return 0, fmt.Errorf("asset %d (%s): %w", i+1, assets[i].Name, err)
 		}
-		assets[i].AssetID = assetID
+		ciTypes[i] = ciType
+	}
+
+	successCount := 0
 
-		// Save asset
-		if err := s.assetRepo.Save(ctx, &assets[i]); err != nil {
+	for i := range assets {
+		// Generate asset ID and save asset
+		if err := s.idService.SaveNewAsset(ctx, &assets[i], ciTypes[i].Prefix); err != nil {
+			assets[i].ID = primitive.NilObjectID
+			assets[i].AssetID = ""
 			continue
 		}
+		s.auditLogService.logAssetChange(ctx, nil, &assets[i])
 
 		// Create onboarding workflow
 		workflow := model.NewWorkflow(
@@ -245,15 +388,8 @@ func (s *AssetService) BulkCreateAssets(ctx context.Context, assets []model.Asse
 			&assets[i],
 		)
 
-		// Generate workflow ID
-		workflowID, err := s.workflowRepo.GenerateWorkflowID(ctx)
-		if err != nil {
-			continue
-		}
-		workflow.WorkflowID = workflowID
-
-		// Save workflow
-		if err := s.workflowRepo.Save(ctx, workflow); err != nil {
+		// Resolve approval stages and save workflow
+		if err := s.saveNewWorkflow(ctx, workflow); err != nil {
 			continue
 		}
 
@@ -289,67 +425,64 @@ func (s *AssetService) GetAssetByID(ctx context.Context, id primitive.ObjectID)
 }
 
 // CreateAssetWithApproval creates a new asset with approval workflow
-func (s *AssetService) CreateAssetWithApproval(ctx context.Context, name string, assetType string, location string, description string, requester string, requesterID string) (*model.Asset, *model.Workflow, error) {
+func (s *AssetService) CreateAssetWithApproval(ctx context.Context, name string, assetType string, location string, description string, attributes map[string]interface{}, requester string, requesterID string) (*model.Asset, *model.Workflow, error) {
 	// Create new asset
 	asset := model.NewAsset(name, model.AssetType(assetType), location, description)
-
-	// Generate asset ID
-	assetID, err := s.assetRepo.GenerateAssetID(ctx, string(asset.Type))
+	asset.Attributes = attributes
+	ciType, err := s.validateNewAsset(ctx, asset)
 	if err != nil {
 		return nil, nil, err
 	}
-	asset.AssetID = assetID
 
-	// Save asset
-	if err := s.assetRepo.Save(ctx, asset); err != nil {
+	// Generate asset ID and save asset
+	if err := s.idService.SaveNewAsset(ctx, asset, ciType.Prefix); err != nil {
 		return nil, nil, err
 	}
+	s.auditLogService.logAssetChange(ctx, nil, asset)
 
-	// Create onboarding workflow
+	// Create the creation workflow; the asset stays offline until it is approved
 	workflow := model.NewWorkflow(
-		model.AssetOnboardingType,
+		model.AssetCreateType,
 		asset.AssetID,
 		asset.Name,
 		requester,
 		requesterID,
 		model.MediumPriority,
 		"Asset creation approval",
-		asset,
+		model.NewAssetCreatePayload(model.AssetFieldsOf(asset)),
 	)
 
-	// Generate workflow ID
-	workflowID, err := s.workflowRepo.GenerateWorkflowID(ctx)
-	if err != nil {
-		return nil, nil, err
-	}
-	workflow.WorkflowID = workflowID
-
-	// Save workflow
-	if err := s.workflowRepo.Save(ctx, workflow); err != nil {
+	// Resolve approval stages and save workflow
+	if err := s.saveNewWorkflow(ctx, workflow); err != nil {
 		return nil, nil, err
 	}
 
 	return asset, workflow, nil
 }
 
-// CreateAssetUpdateWorkflow creates an update workflow for an asset
-func (s *AssetService) CreateAssetUpdateWorkflow(ctx context.Context, id primitive.ObjectID, name string, location string, description string, requester string, requesterID string) (*model.Workflow, error) {
+// CreateAssetUpdateWorkflow creates an update workflow for an asset. Nil attributes leave the
+// current attributes unchanged.
+func (s *AssetService) CreateAssetUpdateWorkflow(ctx context.Context, id primitive.ObjectID, name string, location string, description string, attributes map[string]interface{}, requester string, requesterID string) (*model.Workflow, error) {
 	// Find asset
 	asset, err := s.assetRepo.FindByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	// Create update data
-	updateData := map[string]interface{}{
-		"originalName":        asset.Name,
-		"originalLocation":    asset.Location,
-		"originalDescription": asset.Description,
-		"newName":             name,
-		"newLocation":         location,
-		"newDescription":      description,
+	// Check the requested change against the CI type schema before asking for approval
+	candidate := *asset
+	candidate.Update(name, location, description)
+	if attributes != nil {
+		candidate.Attributes = attributes
+	}
+	if _, err := s.ciTypeService.ValidateAsset(ctx, &candidate); err != nil {
+		return nil, err
 	}
 
+	// Capture the current and requested fields
+	original := model.AssetFieldsOf(asset)
+	requested := model.AssetFieldsOf(&candidate)
+
 	// Create update workflow
 	workflow := model.NewWorkflow(
 		model.AssetUpdateType,
@@ -359,18 +492,11 @@ func (s *AssetService) CreateAssetUpdateWorkflow(ctx context.Context, id primiti
 		requesterID,
 		model.MediumPriority,
 		"Asset update approval",
-		updateData,
+		model.NewAssetUpdatePayload(original, requested),
 	)
 
-	// Generate workflow ID
-	workflowID, err := s.workflowRepo.GenerateWorkflowID(ctx)
-	if err != nil {
-		return nil, err
-	}
-	workflow.WorkflowID = workflowID
-
-	// Save workflow
-	if err := s.workflowRepo.Save(ctx, workflow); err != nil {
+	// Resolve approval stages and save workflow
+	if err := s.saveNewWorkflow(ctx, workflow); err != nil {
 		return nil, err
 	}
 
@@ -381,3 +507,28 @@ func (s *AssetService) CreateAssetUpdateWorkflow(ctx context.Context, id primiti
 func (s *AssetService) GetAssetHistory(ctx context.Context, assetID primitive.ObjectID) ([]*model.AssetHistory, error) {
 	return s.assetHistoryRepo.FindByAssetID(ctx, assetID, 100) // Limit to 100 most recent records
 }
+
+// validateNewAsset checks a new asset against its CI type schema and the lifecycle
+func (s *AssetService) validateNewAsset(ctx context.Context, asset *model.Asset) (*model.CIType, error) {
+	ciType, err := s.ciTypeService.ValidateAsset(ctx, asset)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.lifecycle.CheckInitial(asset); err != nil {
+		return nil, err
+	}
+	return ciType, nil
+}
+
+// saveNewWorkflow assigns the approval stages, generates the workflow ID and saves the workflow
+func (s *AssetService) saveNewWorkflow(ctx context.Context, workflow *model.Workflow) error {
+	if err := s.policyService.AssignStages(ctx, workflow); err != nil {
+		return err
+	}
+
+	if err := s.idService.SaveNewWorkflow(ctx, workflow); err != nil {
+		return err
+	}
+	s.auditLogService.logWorkflowCreated(ctx, workflow)
+	return nil
+}
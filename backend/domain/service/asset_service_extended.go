@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/phuhao00/cmdb/backend/domain/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // AssetSearchCriteria defines search criteria for assets
@@ -141,6 +142,56 @@ func (s *AssetService) GetOwners(ctx context.Context) ([]string, error) {
 	return owners, nil
 }
 
+// AddAssetTags adds tags to an asset
+func (s *AssetService) AddAssetTags(ctx context.Context, id primitive.ObjectID, tags []string) (*model.Asset, error) {
+	asset, err := s.assetRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	oldAsset := asset.Clone()
+	for _, tag := range tags {
+		asset.AddTag(tag)
+	}
+	if err := s.saveManualChange(ctx, oldAsset, asset); err != nil {
+		return nil, err
+	}
+
+	return asset, nil
+}
+
+// RemoveAssetTag removes a tag from an asset
+func (s *AssetService) RemoveAssetTag(ctx context.Context, id primitive.ObjectID, tag string) (*model.Asset, error) {
+	asset, err := s.assetRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	oldAsset := asset.Clone()
+	asset.RemoveTag(tag)
+	if err := s.saveManualChange(ctx, oldAsset, asset); err != nil {
+		return nil, err
+	}
+
+	return asset, nil
+}
+
+// UpdateAssetCosts updates the purchase price, annual cost and currency of an asset
+func (s *AssetService) UpdateAssetCosts(ctx context.Context, id primitive.ObjectID, purchasePrice, annualCost float64, currency string) (*model.Asset, error) {
+	asset, err := s.assetRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	oldAsset := asset.Clone()
+	asset.UpdateCosts(purchasePrice, annualCost, currency)
+	if err := s.saveManualChange(ctx, oldAsset, asset); err != nil {
+		return nil, err
+	}
+
+	return asset, nil
+}
+
 // SaveAsset saves an asset (create or update)
 func (s *AssetService) SaveAsset(ctx context.Context, asset *model.Asset) error {
 	return s.assetRepo.Save(ctx, asset)
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidTimeRange is returned when a diff is requested with its start after its end
+var ErrInvalidTimeRange = errors.New("from must not be after to")
+
+// GetAssetAsOf reconstructs the state of an asset at a point in time from its history
+func (s *AssetService) GetAssetAsOf(ctx context.Context, id primitive.ObjectID, asOf time.Time) (*model.AssetState, error) {
+	asset, err := s.assetRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	newer, err := s.assetHistoryRepo.FindByDateRange(ctx, id, asOf, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return model.ReconstructAsset(asset, newer, asOf)
+}
+
+// DiffAssetStates compares the states of an asset at two points in time
+func (s *AssetService) DiffAssetStates(ctx context.Context, id primitive.ObjectID, from, to time.Time) (*model.AssetStateDiff, error) {
+	if from.After(to) {
+		return nil, ErrInvalidTimeRange
+	}
+
+	asset, err := s.assetRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	newer, err := s.assetHistoryRepo.FindByDateRange(ctx, id, from, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	toState, err := model.ReconstructAsset(asset, newer, to)
+	if err != nil {
+		return nil, err
+	}
+	fromState, err := model.ReconstructAsset(asset, newer, from)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.AssetStateDiff{
+		From:    fromState,
+		To:      toState,
+		Changes: model.DiffAssets(fromState.Asset, toState.Asset),
+	}, nil
+}
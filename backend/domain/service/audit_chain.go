@@ -0,0 +1,505 @@
+package service
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/infrastructure/logging"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// maxChainAttempts bounds how often an audit log is chained again when another writer took
+// its sequence first
+const maxChainAttempts = 5
+
+// auditVerifyBatch is how many audit logs are loaded at a time to verify or archive them
+const auditVerifyBatch = 1000
+
+// AuditSigner signs audit log checkpoints and checks the signatures of earlier ones
+type AuditSigner struct {
+	key     ed25519.PrivateKey
+	keyID   string
+	trusted map[string]ed25519.PublicKey
+}
+
+// NewAuditSigner creates a signer from the seed of an Ed25519 key. Checkpoints signed by the
+// trusted public keys, such as keys used before a rotation, also verify.
+func NewAuditSigner(seed []byte, trusted []ed25519.PublicKey) (*AuditSigner, error) {
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("audit signing key must be %d bytes, got %d", ed25519.SeedSize, len(seed))
+	}
+
+	key := ed25519.NewKeyFromSeed(seed)
+	signer := &AuditSigner{
+		key:     key,
+		trusted: map[string]ed25519.PublicKey{},
+	}
+	for _, publicKey := range append(trusted, key.Public().(ed25519.PublicKey)) {
+		signer.trusted[auditKeyID(publicKey)] = publicKey
+	}
+	signer.keyID = auditKeyID(key.Public().(ed25519.PublicKey))
+	return signer, nil
+}
+
+// KeyID returns the ID checkpoints signed now carry
+func (s *AuditSigner) KeyID() string {
+	return s.keyID
+}
+
+// Sign signs a checkpoint
+func (s *AuditSigner) Sign(checkpoint *model.AuditCheckpoint) {
+	checkpoint.KeyID = s.keyID
+	checkpoint.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(s.key, checkpoint.SigningPayload()))
+}
+
+// Verify checks that a checkpoint was signed by a trusted key
+func (s *AuditSigner) Verify(checkpoint *model.AuditCheckpoint) error {
+	publicKey, ok := s.trusted[checkpoint.KeyID]
+	if !ok {
+		return fmt.Errorf("signed by unknown key %q", checkpoint.KeyID)
+	}
+	signature, err := base64.StdEncoding.DecodeString(checkpoint.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature encoding: %w", err)
+	}
+	if !ed25519.Verify(publicKey, checkpoint.SigningPayload(), signature) {
+		return errors.New("signature does not match")
+	}
+	return nil
+}
+
+// auditKeyID identifies a public key by the start of its SHA-256 hash
+func auditKeyID(publicKey ed25519.PublicKey) string {
+	sum := sha256.Sum256(publicKey)
+	return hex.EncodeToString(sum[:8])
+}
+
+// append adds an audit log to the end of the hash chain. Writers of this service take turns; an
+// insert that fails because another instance took the sequence is chained again.
+func (s *AuditLogService) append(ctx context.Context, log *model.AuditLog) error {
+	s.chainMu.Lock()
+	defer s.chainMu.Unlock()
+
+	for attempt := 1; ; attempt++ {
+		sequence, previousHash, err := s.chainHead(ctx)
+		if err != nil {
+			return err
+		}
+		if err := log.Chain(sequence+1, previousHash); err != nil {
+			return err
+		}
+
+		err = s.auditLogRepo.Create(ctx, log)
+		if err == nil || !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+		if attempt == maxChainAttempts {
+			return fmt.Errorf("could not append audit log to the chain after %d attempts: %w", attempt, err)
+		}
+	}
+}
+
+// chainHead returns the sequence and hash of the last entry of the chain, which may already be
+// archived. An empty chain starts after sequence 0 with no previous hash.
+func (s *AuditLogService) chainHead(ctx context.Context) (int64, string, error) {
+	latest, err := s.auditLogRepo.FindLatest(ctx)
+	if err != nil {
+		return 0, "", err
+	}
+	if latest != nil {
+		return latest.Sequence, latest.Hash, nil
+	}
+
+	archives, err := s.archiveRepo.FindAll(ctx)
+	if err != nil {
+		return 0, "", err
+	}
+	if len(archives) > 0 {
+		last := archives[len(archives)-1]
+		return last.ToSequence, last.LastHash, nil
+	}
+	return 0, "", nil
+}
+
+// CreateCheckpoint signs the hash of the last entry of the chain once the entries written since
+// the previous checkpoint verify. It returns nil when no entry was written since then.
+func (s *AuditLogService) CreateCheckpoint(ctx context.Context) (*model.AuditCheckpoint, error) {
+	latest, err := s.auditLogRepo.FindLatest(ctx)
+	if err != nil || latest == nil {
+		return nil, err
+	}
+
+	previous, err := s.checkpointRepo.FindLatest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	from := int64(1)
+	if previous != nil {
+		if previous.Sequence >= latest.Sequence {
+			return nil, nil
+		}
+		from = previous.Sequence
+	}
+
+	verification, err := s.VerifyChain(ctx, from, latest.Sequence)
+	if err != nil {
+		return nil, err
+	}
+	if !verification.Valid {
+		return nil, fmt.Errorf("%w: %d problems found between sequences %d and %d", model.ErrAuditChainBroken, len(verification.Issues), from, latest.Sequence)
+	}
+
+	checkpoint := model.NewAuditCheckpoint(latest)
+	s.signer.Sign(checkpoint)
+	if err := s.checkpointRepo.Create(ctx, checkpoint); err != nil {
+		return nil, err
+	}
+	return checkpoint, nil
+}
+
+// StartCheckpointLoop periodically checkpoints the chain until the context is cancelled
+func (s *AuditLogService) StartCheckpointLoop(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				checkpoint, err := s.CreateCheckpoint(ctx)
+				if err != nil {
+					logging.Logger.Error("audit_checkpoint_failed", zap.Error(err))
+					continue
+				}
+				if checkpoint != nil {
+					logging.Logger.Info("audit_checkpoint_created",
+						zap.Int64("sequence", checkpoint.Sequence),
+						zap.String("key_id", checkpoint.KeyID),
+					)
+				}
+			}
+		}
+	}()
+}
+
+// VerifyChain checks the entries from one sequence to another: that none are missing, that each
+// still matches its hash and follows the hash of the entry before it, and that the checkpoints
+// and archives of the range are signed and agree with the entries. Archives the range touches
+// are checked whole, from the entries read back from the archive store. A zero from starts at the
+// first entry and a zero to ends at the last entry or checkpoint, so that removed entries at the
+// end of the chain show up as missing.
+func (s *AuditLogService) VerifyChain(ctx context.Context, from, to int64) (*model.AuditVerification, error) {
+	if from < 1 {
+		from = 1
+	}
+	archives, err := s.archiveRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if to <= 0 {
+		if to, err = s.chainEnd(ctx, archives); err != nil {
+			return nil, err
+		}
+	}
+
+	verification := &model.AuditVerification{
+		FromSequence: from,
+		ToSequence:   to,
+		Valid:        true,
+		Issues:       []model.AuditChainIssue{},
+		VerifiedAt:   time.Now(),
+	}
+	if to < from {
+		return verification, nil
+	}
+
+	// Archived entries are checked against the checkpoints of their archives
+	var previousArchive *model.AuditArchive
+	for _, archive := range archives {
+		if archive.ToSequence >= from && archive.FromSequence <= to {
+			if err := s.verifyArchive(ctx, verification, archive, previousArchive); err != nil {
+				return nil, err
+			}
+		}
+		previousArchive = archive
+	}
+	if previousArchive != nil {
+		verification.ArchivedThrough = previousArchive.ToSequence
+	}
+
+	start := from
+	if start <= verification.ArchivedThrough {
+		start = verification.ArchivedThrough + 1
+	}
+	if start > to {
+		sortAuditIssues(verification)
+		return verification, nil
+	}
+
+	checkpoints, err := s.checkpointRepo.FindSequenceRange(ctx, start, to)
+	if err != nil {
+		return nil, err
+	}
+	checkpointsAt := map[int64][]*model.AuditCheckpoint{}
+	for _, checkpoint := range checkpoints {
+		verification.CheckpointsChecked++
+		if err := s.signer.Verify(checkpoint); err != nil {
+			verification.AddIssue(checkpoint.Sequence, model.AuditIssueBadSignature, err.Error())
+		}
+		checkpointsAt[checkpoint.Sequence] = append(checkpointsAt[checkpoint.Sequence], checkpoint)
+	}
+
+	previousHash, linked, err := s.hashBefore(ctx, start, previousArchive)
+	if err != nil {
+		return nil, err
+	}
+
+	next := start
+	for next <= to {
+		entries, err := s.auditLogRepo.FindSequenceRange(ctx, next, to, auditVerifyBatch)
+		if err != nil {
+			return nil, err
+		}
+		if len(entries) == 0 {
+			verification.AddMissing(next, to)
+			break
+		}
+
+		for _, entry := range entries {
+			if entry.Sequence > next {
+				verification.AddMissing(next, entry.Sequence-1)
+				linked = false
+			}
+
+			hash, err := entry.ComputeHash()
+			if err != nil {
+				return nil, err
+			}
+			if hash != entry.Hash {
+				verification.AddIssue(entry.Sequence, model.AuditIssueModified, "entry does not match its hash")
+			}
+			if linked && entry.PreviousHash != previousHash {
+				verification.AddIssue(entry.Sequence, model.AuditIssueBrokenLink, "entry does not follow the hash of the entry before it")
+			}
+			for _, checkpoint := range checkpointsAt[entry.Sequence] {
+				if checkpoint.Hash != hash {
+					verification.AddIssue(entry.Sequence, model.AuditIssueCheckpointMismatch, "entry does not match the hash of its checkpoint")
+				}
+			}
+
+			verification.Checked++
+			previousHash = entry.Hash
+			linked = true
+			next = entry.Sequence + 1
+		}
+	}
+
+	sortAuditIssues(verification)
+	return verification, nil
+}
+
+// chainEnd returns the last sequence the chain is known to reach: its last entry, checkpoint or
+// archived entry
+func (s *AuditLogService) chainEnd(ctx context.Context, archives []*model.AuditArchive) (int64, error) {
+	var end int64
+	latest, err := s.auditLogRepo.FindLatest(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if latest != nil {
+		end = latest.Sequence
+	}
+
+	checkpoint, err := s.checkpointRepo.FindLatest(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if checkpoint != nil && checkpoint.Sequence > end {
+		end = checkpoint.Sequence
+	}
+
+	if len(archives) > 0 && archives[len(archives)-1].ToSequence > end {
+		end = archives[len(archives)-1].ToSequence
+	}
+	return end, nil
+}
+
+// hashBefore returns the hash the entry with a sequence must follow, and whether it is known.
+// It is not known when the entry before is gone but not archived.
+func (s *AuditLogService) hashBefore(ctx context.Context, sequence int64, lastArchive *model.AuditArchive) (string, bool, error) {
+	if sequence == 1 {
+		return "", true, nil
+	}
+	if lastArchive != nil && lastArchive.ToSequence == sequence-1 {
+		return lastArchive.LastHash, true, nil
+	}
+
+	previous, err := s.auditLogRepo.FindBySequence(ctx, sequence-1)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	return previous.Hash, true, nil
+}
+
+// verifyArchive checks that an archive is covered by a trusted checkpoint of its last entry,
+// continues the archive before it and still holds the entries the checkpoint covers
+func (s *AuditLogService) verifyArchive(ctx context.Context, verification *model.AuditVerification, archive, previous *model.AuditArchive) error {
+	checkpoint := archive.Checkpoint
+	verification.CheckpointsChecked++
+	if err := s.signer.Verify(&checkpoint); err != nil {
+		verification.AddIssue(archive.ToSequence, model.AuditIssueBadSignature, err.Error())
+	}
+	if checkpoint.Sequence != archive.ToSequence || checkpoint.Hash != archive.LastHash {
+		verification.AddIssue(archive.ToSequence, model.AuditIssueCheckpointMismatch, "archive does not match its checkpoint")
+	}
+
+	switch {
+	case previous == nil && archive.FromSequence > 1:
+		verification.AddMissing(1, archive.FromSequence-1)
+	case previous != nil && archive.FromSequence > previous.ToSequence+1:
+		verification.AddMissing(previous.ToSequence+1, archive.FromSequence-1)
+	case previous != nil && archive.PreviousHash != previous.LastHash:
+		verification.AddIssue(archive.FromSequence, model.AuditIssueBrokenLink, "archive does not follow the archive before it")
+	}
+
+	return s.verifyArchivedEntries(ctx, verification, archive)
+}
+
+// verifyArchivedEntries hashes the entries of an archive again and checks that they lead from
+// the hash the archive follows to the hash its checkpoint signed. An archive whose file is gone
+// has lost its entries; one that cannot be read has been modified.
+func (s *AuditLogService) verifyArchivedEntries(ctx context.Context, verification *model.AuditVerification, archive *model.AuditArchive) error {
+	entries, err := s.archiveStore.Read(ctx, archive)
+	if err != nil {
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case errors.Is(err, fs.ErrNotExist):
+			verification.AddMissing(archive.FromSequence, archive.ToSequence)
+		default:
+			verification.AddIssue(archive.FromSequence, model.AuditIssueModified, "archive cannot be read: "+err.Error())
+		}
+		return nil
+	}
+
+	next := archive.FromSequence
+	previousHash, lastHash := archive.PreviousHash, ""
+	linked := true
+	for _, entry := range entries {
+		if entry.Sequence < next || entry.Sequence > archive.ToSequence {
+			verification.AddIssue(entry.Sequence, model.AuditIssueModified, "archive holds an entry out of order or outside its range")
+			continue
+		}
+		if entry.Sequence > next {
+			verification.AddMissing(next, entry.Sequence-1)
+			linked = false
+		}
+
+		hash, err := entry.ComputeHash()
+		if err != nil {
+			return err
+		}
+		if hash != entry.Hash {
+			verification.AddIssue(entry.Sequence, model.AuditIssueModified, "archived entry does not match its hash")
+		}
+		if linked && entry.PreviousHash != previousHash {
+			verification.AddIssue(entry.Sequence, model.AuditIssueBrokenLink, "archived entry does not follow the hash of the entry before it")
+		}
+
+		verification.Checked++
+		previousHash, lastHash = entry.Hash, hash
+		linked = true
+		next = entry.Sequence + 1
+	}
+
+	if next <= archive.ToSequence {
+		verification.AddMissing(next, archive.ToSequence)
+		return nil
+	}
+	if lastHash != archive.LastHash || lastHash != archive.Checkpoint.Hash {
+		verification.AddIssue(archive.ToSequence, model.AuditIssueCheckpointMismatch, "last archived entry does not match the hash of the archive's checkpoint")
+	}
+	return nil
+}
+
+// sortAuditIssues puts the issues of a verification in chain order
+func sortAuditIssues(verification *model.AuditVerification) {
+	slices.SortStableFunc(verification.Issues, func(a, b model.AuditChainIssue) int {
+		switch {
+		case a.Sequence < b.Sequence:
+			return -1
+		case a.Sequence > b.Sequence:
+			return 1
+		}
+		return 0
+	})
+}
+
+// archiveSegment moves the entries from one sequence through a checkpoint to the archive store,
+// after verifying them, and deletes them from the database
+func (s *AuditLogService) archiveSegment(ctx context.Context, from int64, previousHash string, checkpoint *model.AuditCheckpoint) (*model.AuditArchive, error) {
+	verification, err := s.VerifyChain(ctx, from, checkpoint.Sequence)
+	if err != nil {
+		return nil, err
+	}
+	if !verification.Valid {
+		return nil, fmt.Errorf("%w: %d problems found between sequences %d and %d", model.ErrAuditChainBroken, len(verification.Issues), from, checkpoint.Sequence)
+	}
+
+	var entries []*model.AuditLog
+	for next := from; next <= checkpoint.Sequence; {
+		batch, err := s.auditLogRepo.FindSequenceRange(ctx, next, checkpoint.Sequence, auditVerifyBatch)
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		entries = append(entries, batch...)
+		next = batch[len(batch)-1].Sequence + 1
+	}
+
+	archive := &model.AuditArchive{
+		FromSequence: from,
+		ToSequence:   checkpoint.Sequence,
+		PreviousHash: previousHash,
+		LastHash:     checkpoint.Hash,
+		Entries:      int64(len(entries)),
+		Checkpoint:   *checkpoint,
+		ArchivedAt:   time.Now(),
+	}
+	location, err := s.archiveStore.Write(ctx, archive, entries)
+	if err != nil {
+		return nil, err
+	}
+	archive.Location = location
+
+	if err := s.archiveRepo.Create(ctx, archive); err != nil {
+		return nil, err
+	}
+	if _, err := s.auditLogRepo.DeleteThroughSequence(ctx, checkpoint.Sequence); err != nil {
+		return nil, err
+	}
+
+	logging.Logger.Info("audit_logs_archived",
+		zap.Int64("from_sequence", archive.FromSequence),
+		zap.Int64("to_sequence", archive.ToSequence),
+		zap.String("location", archive.Location),
+	)
+	return archive, nil
+}
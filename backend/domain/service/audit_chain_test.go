@@ -0,0 +1,286 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/repository"
+)
+
+type memoryAuditCheckpointRepository struct {
+	repository.AuditCheckpointRepository
+
+	mu          sync.Mutex
+	checkpoints []*model.AuditCheckpoint
+}
+
+func (r *memoryAuditCheckpointRepository) Create(ctx context.Context, checkpoint *model.AuditCheckpoint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checkpoints = append(r.checkpoints, checkpoint)
+	return nil
+}
+
+func (r *memoryAuditCheckpointRepository) FindLatest(ctx context.Context) (*model.AuditCheckpoint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.checkpoints) == 0 {
+		return nil, nil
+	}
+	return r.checkpoints[len(r.checkpoints)-1], nil
+}
+
+func (r *memoryAuditCheckpointRepository) FindSequenceRange(ctx context.Context, from, to int64) ([]*model.AuditCheckpoint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var checkpoints []*model.AuditCheckpoint
+	for _, checkpoint := range r.checkpoints {
+		if checkpoint.Sequence >= from && checkpoint.Sequence <= to {
+			checkpoints = append(checkpoints, checkpoint)
+		}
+	}
+	return checkpoints, nil
+}
+
+type memoryAuditArchiveRepository struct {
+	repository.AuditArchiveRepository
+
+	mu       sync.Mutex
+	archives []*model.AuditArchive
+}
+
+func (r *memoryAuditArchiveRepository) Create(ctx context.Context, archive *model.AuditArchive) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.archives = append(r.archives, archive)
+	return nil
+}
+
+func (r *memoryAuditArchiveRepository) FindAll(ctx context.Context) ([]*model.AuditArchive, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*model.AuditArchive(nil), r.archives...), nil
+}
+
+// memoryAuditArchiveStore keeps copies of archived entries by location, so tests can change
+// them the way someone editing an archive file would
+type memoryAuditArchiveStore struct {
+	mu       sync.Mutex
+	segments map[string][]model.AuditLog
+}
+
+func (s *memoryAuditArchiveStore) Write(ctx context.Context, archive *model.AuditArchive, entries []*model.AuditLog) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	location := fmt.Sprintf("audit-%d-%d", archive.FromSequence, archive.ToSequence)
+	if s.segments == nil {
+		s.segments = make(map[string][]model.AuditLog)
+	}
+	for _, entry := range entries {
+		s.segments[location] = append(s.segments[location], *entry)
+	}
+	return location, nil
+}
+
+func (s *memoryAuditArchiveStore) Read(ctx context.Context, archive *model.AuditArchive) ([]*model.AuditLog, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	segment, ok := s.segments[archive.Location]
+	if !ok {
+		return nil, fs.ErrNotExist
+	}
+	entries := make([]*model.AuditLog, len(segment))
+	for i := range segment {
+		entry := segment[i]
+		entries[i] = &entry
+	}
+	return entries, nil
+}
+
+func TestVerifyChainRehashesArchives(t *testing.T) {
+	signer, err := NewAuditSigner(bytes.Repeat([]byte{7}, 32), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auditLogRepo := &memoryAuditLogRepository{}
+	store := &memoryAuditArchiveStore{}
+	auditLogService := NewAuditLogService(auditLogRepo, &memoryAuditCheckpointRepository{}, &memoryAuditArchiveRepository{}, store, signer)
+	ctx := context.Background()
+
+	appendEntries := func(count int) {
+		t.Helper()
+		for i := 0; i < count; i++ {
+			entry := model.NewAuditLog("u1", "admin", model.AssetUpdated, "asset", "a1", "web", fmt.Sprintf("change %d", i))
+			entry.SetChanges(map[string]interface{}{"status": "offline"}, map[string]interface{}{"status": "online", "ports": []interface{}{int32(22), int32(443)}})
+			if err := auditLogService.append(ctx, entry); err != nil {
+				t.Fatalf("append() error = %v", err)
+			}
+		}
+	}
+
+	// Entries 1 to 5 are archived through their checkpoint; 6 and 7 stay in the database
+	appendEntries(5)
+	checkpoint, err := auditLogService.CreateCheckpoint(ctx)
+	if err != nil {
+		t.Fatalf("CreateCheckpoint() error = %v", err)
+	}
+	appendEntries(2)
+	archive, err := auditLogService.archiveSegment(ctx, 1, "", checkpoint)
+	if err != nil {
+		t.Fatalf("archiveSegment() error = %v", err)
+	}
+
+	verification, err := auditLogService.VerifyChain(ctx, 0, 0)
+	if err != nil {
+		t.Fatalf("VerifyChain() error = %v", err)
+	}
+	if !verification.Valid || verification.Checked != 7 || verification.ArchivedThrough != 5 {
+		t.Fatalf("VerifyChain() = valid %v, %d checked, archived through %d, issues %+v; want valid, 7 and 5",
+			verification.Valid, verification.Checked, verification.ArchivedThrough, verification.Issues)
+	}
+
+	original := append([]model.AuditLog(nil), store.segments[archive.Location]...)
+	tests := []struct {
+		name   string
+		tamper func(entries []model.AuditLog) []model.AuditLog
+		want   []model.AuditChainIssue
+	}{
+		{
+			name: "edited entry",
+			tamper: func(entries []model.AuditLog) []model.AuditLog {
+				entries[2].Description = "nothing happened"
+				return entries
+			},
+			want: []model.AuditChainIssue{{Sequence: 3, Problem: model.AuditIssueModified}},
+		},
+		{
+			name: "removed entry",
+			tamper: func(entries []model.AuditLog) []model.AuditLog {
+				return append(entries[:2], entries[3:]...)
+			},
+			want: []model.AuditChainIssue{{Sequence: 3, Problem: model.AuditIssueMissing}},
+		},
+		{
+			name: "removed last entry",
+			tamper: func(entries []model.AuditLog) []model.AuditLog {
+				return entries[:4]
+			},
+			want: []model.AuditChainIssue{{Sequence: 5, Problem: model.AuditIssueMissing}},
+		},
+		{
+			// Hashing the edited entry again hides the edit from its own hash but not from the
+			// checkpoint of the archive
+			name: "edited and rehashed last entry",
+			tamper: func(entries []model.AuditLog) []model.AuditLog {
+				entries[4].Description = "nothing happened"
+				if err := entries[4].Chain(5, entries[3].Hash); err != nil {
+					t.Fatal(err)
+				}
+				return entries
+			},
+			want: []model.AuditChainIssue{{Sequence: 5, Problem: model.AuditIssueCheckpointMismatch}},
+		},
+		{
+			name: "deleted archive",
+			tamper: func(entries []model.AuditLog) []model.AuditLog {
+				return nil
+			},
+			want: []model.AuditChainIssue{{Sequence: 1, ToSequence: 5, Problem: model.AuditIssueMissing}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tampered := tt.tamper(append([]model.AuditLog(nil), original...))
+			store.segments[archive.Location] = tampered
+			if tampered == nil {
+				delete(store.segments, archive.Location)
+			}
+			defer func() { store.segments[archive.Location] = original }()
+
+			verification, err := auditLogService.VerifyChain(ctx, 0, 0)
+			if err != nil {
+				t.Fatalf("VerifyChain() error = %v", err)
+			}
+			for i := range verification.Issues {
+				verification.Issues[i].Detail = ""
+			}
+			if verification.Valid || !reflect.DeepEqual(verification.Issues, tt.want) {
+				t.Errorf("VerifyChain() = valid %v with issues %+v, want %+v", verification.Valid, verification.Issues, tt.want)
+			}
+		})
+	}
+}
+
+func TestResourceReassignmentKeepsTheChain(t *testing.T) {
+	auditLogRepo := &memoryAuditLogRepository{}
+	auditLogService := NewAuditLogService(auditLogRepo, &memoryAuditCheckpointRepository{}, &memoryAuditArchiveRepository{}, &memoryAuditArchiveStore{}, nil)
+	ctx := context.Background()
+
+	logFor := func(resourceID, description string) {
+		t.Helper()
+		entry := model.NewAuditLog("u1", "admin", model.AssetUpdated, "asset", resourceID, resourceID, description)
+		if err := auditLogService.append(ctx, entry); err != nil {
+			t.Fatalf("append() error = %v", err)
+		}
+	}
+
+	// a1 was merged into a2, which was merged into a3 in turn
+	logFor("a1", "a1 changed")
+	logFor("a2", "a2 changed")
+	logFor("a3", "a3 changed")
+	logFor("other", "other changed")
+	hashes := make([]string, len(auditLogRepo.logs))
+	for i, entry := range auditLogRepo.logs {
+		hashes[i] = entry.Hash
+	}
+	if err := auditLogService.LogResourceReassigned(ctx, "u1", "admin", "asset", "a1", "a1", "a2", "a2", "", ""); err != nil {
+		t.Fatalf("LogResourceReassigned() error = %v", err)
+	}
+	if err := auditLogService.LogResourceReassigned(ctx, "u1", "admin", "asset", "a2", "a2", "a3", "a3", "", ""); err != nil {
+		t.Fatalf("LogResourceReassigned() error = %v", err)
+	}
+
+	for i, hash := range hashes {
+		if auditLogRepo.logs[i].Hash != hash || auditLogRepo.logs[i].ResourceID != auditLogRepo.logs[i].ResourceName {
+			t.Errorf("entry %d was changed by the reassignment: %+v", i+1, auditLogRepo.logs[i])
+		}
+	}
+
+	history, err := auditLogService.GetResourceHistory(ctx, "asset", "a3", 100)
+	if err != nil {
+		t.Fatalf("GetResourceHistory() error = %v", err)
+	}
+	var descriptions []string
+	for _, entry := range history {
+		descriptions = append(descriptions, entry.Description)
+	}
+	want := []string{"Merged asset a2 into a3", "Merged asset a1 into a2", "a3 changed", "a2 changed", "a1 changed"}
+	if !reflect.DeepEqual(descriptions, want) {
+		t.Errorf("history of a3 = %q, want %q", descriptions, want)
+	}
+
+	verification, err := auditLogService.VerifyChain(ctx, 0, 0)
+	if err != nil {
+		t.Fatalf("VerifyChain() error = %v", err)
+	}
+	if !verification.Valid {
+		t.Fatalf("VerifyChain() issues = %+v, want a valid chain", verification.Issues)
+	}
+
+	// Pointing an entry at another resource is an edit like any other
+	auditLogRepo.logs[3].ResourceID, auditLogRepo.logs[3].ResourceName = "a3", "a3"
+	verification, err = auditLogService.VerifyChain(ctx, 0, 0)
+	if err != nil {
+		t.Fatalf("VerifyChain() error = %v", err)
+	}
+	if len(verification.Issues) != 1 || verification.Issues[0].Sequence != 4 || verification.Issues[0].Problem != model.AuditIssueModified {
+		t.Errorf("VerifyChain() issues = %+v, want entry 4 modified", verification.Issues)
+	}
+}
@@ -3,21 +3,36 @@ package service
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/phuhao00/cmdb/backend/domain/model"
 	"github.com/phuhao00/cmdb/backend/domain/repository"
+	"github.com/phuhao00/cmdb/backend/infrastructure/logging"
+	"go.uber.org/zap"
 )
 
-// AuditLogService handles audit log business logic
+// AuditLogService handles audit log business logic. Audit logs form a hash chain that signed
+// checkpoints seal, so that changed or removed entries can be detected.
 type AuditLogService struct {
-	auditLogRepo repository.AuditLogRepository
+	auditLogRepo   repository.AuditLogRepository
+	checkpointRepo repository.AuditCheckpointRepository
+	archiveRepo    repository.AuditArchiveRepository
+	archiveStore   repository.AuditArchiveStore
+	signer         *AuditSigner
+
+	// chainMu serializes appending to the chain
+	chainMu sync.Mutex
 }
 
 // NewAuditLogService creates a new audit log service
-func NewAuditLogService(auditLogRepo repository.AuditLogRepository) *AuditLogService {
+func NewAuditLogService(auditLogRepo repository.AuditLogRepository, checkpointRepo repository.AuditCheckpointRepository, archiveRepo repository.AuditArchiveRepository, archiveStore repository.AuditArchiveStore, signer *AuditSigner) *AuditLogService {
 	return &AuditLogService{
-		auditLogRepo: auditLogRepo,
+		auditLogRepo:   auditLogRepo,
+		checkpointRepo: checkpointRepo,
+		archiveRepo:    archiveRepo,
+		archiveStore:   archiveStore,
+		signer:         signer,
 	}
 }
 
@@ -44,7 +59,7 @@ func (s *AuditLogService) LogAssetCreated(ctx context.Context, userID, username
 	}
 	log.SetChanges(nil, newValue)
 
-	return s.auditLogRepo.Create(ctx, log)
+	return s.append(ctx, log)
 }
 
 // LogAssetUpdated logs an asset update event
@@ -66,29 +81,14 @@ func (s *AuditLogService) LogAssetUpdated(ctx context.Context, userID, username
 	oldValue := map[string]interface{}{}
 	newValue := map[string]interface{}{}
 
-	if oldAsset.Name != newAsset.Name {
-		oldValue["name"] = oldAsset.Name
-		newValue["name"] = newAsset.Name
-	}
-
-	if oldAsset.Location != newAsset.Location {
-		oldValue["location"] = oldAsset.Location
-		newValue["location"] = newAsset.Location
-	}
-
-	if oldAsset.Description != newAsset.Description {
-		oldValue["description"] = oldAsset.Description
-		newValue["description"] = newAsset.Description
-	}
-
-	if oldAsset.Status != newAsset.Status {
-		oldValue["status"] = oldAsset.Status
-		newValue["status"] = newAsset.Status
+	for _, change := range model.DiffAssets(oldAsset, newAsset) {
+		oldValue[change.FieldName] = change.OldValue
+		newValue[change.FieldName] = change.NewValue
 	}
 
 	log.SetChanges(oldValue, newValue)
 
-	return s.auditLogRepo.Create(ctx, log)
+	return s.append(ctx, log)
 }
 
 // LogAssetStatusChanged logs an asset status change event
@@ -111,7 +111,34 @@ func (s *AuditLogService) LogAssetStatusChanged(ctx context.Context, userID, use
 		map[string]interface{}{"status": newStatus},
 	)
 
-	return s.auditLogRepo.Create(ctx, log)
+	return s.append(ctx, log)
+}
+
+// LogAssetDeleted logs an asset deletion event
+func (s *AuditLogService) LogAssetDeleted(ctx context.Context, userID, username string, asset *model.Asset, ipAddress, userAgent string) error {
+	log := model.NewAuditLog(
+		userID,
+		username,
+		model.AssetDeleted,
+		"asset",
+		asset.ID.Hex(),
+		asset.Name,
+		fmt.Sprintf("Deleted asset: %s", asset.Name),
+	)
+
+	log.SetIPAddress(ipAddress)
+	log.SetUserAgent(userAgent)
+
+	oldValue := map[string]interface{}{
+		"assetId":  asset.AssetID,
+		"name":     asset.Name,
+		"type":     asset.Type,
+		"status":   asset.Status,
+		"location": asset.Location,
+	}
+	log.SetChanges(oldValue, nil)
+
+	return s.append(ctx, log)
 }
 
 // LogWorkflowCreated logs a workflow creation event
@@ -137,7 +164,7 @@ func (s *AuditLogService) LogWorkflowCreated(ctx context.Context, userID, userna
 	}
 	log.SetChanges(nil, newValue)
 
-	return s.auditLogRepo.Create(ctx, log)
+	return s.append(ctx, log)
 }
 
 // LogWorkflowApproved logs a workflow approval event
@@ -155,7 +182,7 @@ func (s *AuditLogService) LogWorkflowApproved(ctx context.Context, userID, usern
 	log.SetIPAddress(ipAddress)
 	log.SetUserAgent(userAgent)
 
-	return s.auditLogRepo.Create(ctx, log)
+	return s.append(ctx, log)
 }
 
 // LogWorkflowRejected logs a workflow rejection event
@@ -173,7 +200,30 @@ func (s *AuditLogService) LogWorkflowRejected(ctx context.Context, userID, usern
 	log.SetIPAddress(ipAddress)
 	log.SetUserAgent(userAgent)
 
-	return s.auditLogRepo.Create(ctx, log)
+	return s.append(ctx, log)
+}
+
+// LogWorkflowCompleted logs the execution of an approved workflow, which is marked failed when
+// the workflow failed
+func (s *AuditLogService) LogWorkflowCompleted(ctx context.Context, userID, username string, workflow *model.Workflow, ipAddress, userAgent string) error {
+	log := model.NewAuditLog(
+		userID,
+		username,
+		model.WorkflowCompleted,
+		"workflow",
+		workflow.ID.Hex(),
+		workflow.AssetName,
+		fmt.Sprintf("Executed workflow for asset: %s. Result: %s", workflow.AssetName, workflow.ExecutionResult),
+	)
+
+	log.SetIPAddress(ipAddress)
+	log.SetUserAgent(userAgent)
+
+	if workflow.Status == model.FailedStatus {
+		log.SetError(workflow.ExecutionResult)
+	}
+
+	return s.append(ctx, log)
 }
 
 // LogUserLogin logs a user login event
@@ -191,7 +241,26 @@ func (s *AuditLogService) LogUserLogin(ctx context.Context, userID, username, ip
 	log.SetIPAddress(ipAddress)
 	log.SetUserAgent(userAgent)
 
-	return s.auditLogRepo.Create(ctx, log)
+	return s.append(ctx, log)
+}
+
+// LogUserLoginFailed logs a failed login attempt. The user ID is empty when no user has the username.
+func (s *AuditLogService) LogUserLoginFailed(ctx context.Context, userID, username, reason, ipAddress, userAgent string) error {
+	log := model.NewAuditLog(
+		userID,
+		username,
+		model.UserLoginFailed,
+		"user",
+		userID,
+		username,
+		fmt.Sprintf("Failed login as %s", username),
+	)
+
+	log.SetIPAddress(ipAddress)
+	log.SetUserAgent(userAgent)
+	log.SetError(reason)
+
+	return s.append(ctx, log)
 }
 
 // LogUserLogout logs a user logout event
@@ -209,7 +278,90 @@ func (s *AuditLogService) LogUserLogout(ctx context.Context, userID, username, i
 	log.SetIPAddress(ipAddress)
 	log.SetUserAgent(userAgent)
 
-	return s.auditLogRepo.Create(ctx, log)
+	return s.append(ctx, log)
+}
+
+// LogRefreshTokenReused logs that a refresh token was presented again after it was exchanged,
+// which revoked the session it belonged to
+func (s *AuditLogService) LogRefreshTokenReused(ctx context.Context, userID, username, sessionID, ipAddress, userAgent string) error {
+	log := model.NewAuditLog(
+		userID,
+		username,
+		model.RefreshTokenReused,
+		"user",
+		userID,
+		username,
+		fmt.Sprintf("Refresh token of %s was reused", username),
+	)
+
+	log.SetIPAddress(ipAddress)
+	log.SetUserAgent(userAgent)
+	log.SetError(fmt.Sprintf("session %s revoked", sessionID))
+
+	return s.append(ctx, log)
+}
+
+// LogUserCreated logs a user creation event
+func (s *AuditLogService) LogUserCreated(ctx context.Context, userID, username string, user *model.User, ipAddress, userAgent string) error {
+	log := model.NewAuditLog(
+		userID,
+		username,
+		model.UserCreated,
+		"user",
+		user.ID.Hex(),
+		user.Username,
+		fmt.Sprintf("Created user: %s", user.Username),
+	)
+
+	log.SetIPAddress(ipAddress)
+	log.SetUserAgent(userAgent)
+
+	newValue := map[string]interface{}{
+		"username": user.Username,
+		"email":    user.Email,
+		"role":     user.Role,
+		"status":   user.Status,
+	}
+	log.SetChanges(nil, newValue)
+
+	return s.append(ctx, log)
+}
+
+// LogUserUpdated logs a change to a user's account, such as its status or manager
+func (s *AuditLogService) LogUserUpdated(ctx context.Context, userID, username string, user *model.User, oldValue, newValue map[string]interface{}, ipAddress, userAgent string) error {
+	log := model.NewAuditLog(
+		userID,
+		username,
+		model.UserUpdated,
+		"user",
+		user.ID.Hex(),
+		user.Username,
+		fmt.Sprintf("Updated user: %s", user.Username),
+	)
+
+	log.SetIPAddress(ipAddress)
+	log.SetUserAgent(userAgent)
+	log.SetChanges(oldValue, newValue)
+
+	return s.append(ctx, log)
+}
+
+// LogUserPasswordChanged logs a password change
+func (s *AuditLogService) LogUserPasswordChanged(ctx context.Context, userID, username string, user *model.User, ipAddress, userAgent string) error {
+	log := model.NewAuditLog(
+		userID,
+		username,
+		model.UserPasswordChanged,
+		"user",
+		user.ID.Hex(),
+		user.Username,
+		fmt.Sprintf("Changed password of user %s", user.Username),
+	)
+
+	log.SetIPAddress(ipAddress)
+	log.SetUserAgent(userAgent)
+
+	return s.append(ctx, log)
 }
 
 // LogReportGenerated logs a report generation event
@@ -227,7 +379,125 @@ func (s *AuditLogService) LogReportGenerated(ctx context.Context, userID, userna
 	log.SetIPAddress(ipAddress)
 	log.SetUserAgent(userAgent)
 
-	return s.auditLogRepo.Create(ctx, log)
+	return s.append(ctx, log)
+}
+
+// LogRequest logs an API request that changes data. The action names the operation, such as
+// asset_update; requests that failed are logged with their error.
+func (s *AuditLogService) LogRequest(ctx context.Context, userID, username string, action model.AuditAction, resourceType, resourceID, method, path string, statusCode int, errorMessage, ipAddress, userAgent string) error {
+	log := model.NewAuditLog(
+		userID,
+		username,
+		action,
+		resourceType,
+		resourceID,
+		"",
+		fmt.Sprintf("%s %s", method, path),
+	)
+
+	log.SetIPAddress(ipAddress)
+	log.SetUserAgent(userAgent)
+	log.SetRequest(method, path, statusCode)
+
+	if statusCode >= 400 {
+		log.SetError(errorMessage)
+	}
+
+	return s.append(ctx, log)
+}
+
+// LogPermissionDenied logs a request refused because the user lacks a permission or role
+func (s *AuditLogService) LogPermissionDenied(ctx context.Context, userID, username, permission, resourceType, resourceID, method, path string, statusCode int, ipAddress, userAgent string) error {
+	log := model.NewAuditLog(
+		userID,
+		username,
+		model.PermissionDenied,
+		resourceType,
+		resourceID,
+		"",
+		fmt.Sprintf("Denied %s %s: requires %s", method, path, permission),
+	)
+
+	log.SetIPAddress(ipAddress)
+	log.SetUserAgent(userAgent)
+	log.SetRequest(method, path, statusCode)
+	log.SetError("requires " + permission)
+
+	return s.append(ctx, log)
+}
+
+// LogResourceReassigned logs that a resource was merged into another one. Entries are never
+// changed once chained, so this entry is what makes the entries written for the resource part
+// of the audit history of the one it was merged into.
+func (s *AuditLogService) LogResourceReassigned(ctx context.Context, userID, username, resourceType, fromID, fromName, toID, toName, ipAddress, userAgent string) error {
+	log := model.NewAuditLog(
+		userID,
+		username,
+		model.ResourceReassigned,
+		resourceType,
+		fromID,
+		fromName,
+		fmt.Sprintf("Merged %s %s into %s", resourceType, fromName, toName),
+	)
+
+	log.SetIPAddress(ipAddress)
+	log.SetUserAgent(userAgent)
+
+	log.SetChanges(
+		map[string]interface{}{"resourceId": fromID, "resourceName": fromName},
+		map[string]interface{}{"resourceId": toID, "resourceName": toName},
+	)
+
+	return s.append(ctx, log)
+}
+
+// logAssetChange logs a saved asset change made with a context as a creation, a status change
+// or an update. oldAsset is nil for a new asset; saves that change nothing are not logged.
+func (s *AuditLogService) logAssetChange(ctx context.Context, oldAsset, asset *model.Asset) {
+	actor, client := auditSource(ctx)
+
+	if oldAsset == nil {
+		logAuditFailure(model.AssetCreated, s.LogAssetCreated(ctx, actor.ID, actor.Name, asset, client.IPAddress, client.UserAgent))
+		return
+	}
+
+	changes := model.DiffAssets(oldAsset, asset)
+	switch {
+	case len(changes) == 0:
+		// Nothing was changed
+	case len(changes) == 1 && changes[0].FieldName == "status":
+		logAuditFailure(model.AssetStatusChanged, s.LogAssetStatusChanged(ctx, actor.ID, actor.Name, asset, oldAsset.Status, asset.Status, client.IPAddress, client.UserAgent))
+	default:
+		logAuditFailure(model.AssetUpdated, s.LogAssetUpdated(ctx, actor.ID, actor.Name, oldAsset, asset, client.IPAddress, client.UserAgent))
+	}
+}
+
+// logAssetDeleted logs an asset deleted with a context
+func (s *AuditLogService) logAssetDeleted(ctx context.Context, asset *model.Asset) {
+	actor, client := auditSource(ctx)
+	logAuditFailure(model.AssetDeleted, s.LogAssetDeleted(ctx, actor.ID, actor.Name, asset, client.IPAddress, client.UserAgent))
+}
+
+// logWorkflowCreated logs a workflow created with a context
+func (s *AuditLogService) logWorkflowCreated(ctx context.Context, workflow *model.Workflow) {
+	actor, client := auditSource(ctx)
+	logAuditFailure(model.WorkflowCreated, s.LogWorkflowCreated(ctx, actor.ID, actor.Name, workflow, client.IPAddress, client.UserAgent))
+}
+
+// auditSource returns who made the request of a context and where it came from. Contexts
+// outside requests have the system actor and no client.
+func auditSource(ctx context.Context) (model.Actor, model.AuditClient) {
+	return model.ActorFromContext(ctx), model.AuditClientFromContext(ctx)
+}
+
+// logAuditFailure logs an audit log that could not be written. The audited action has already
+// happened, so the failure is not returned to whoever made it.
+func logAuditFailure(action model.AuditAction, err error) {
+	if err != nil {
+		logging.Logger.Error("audit_log_write_failed",
+			zap.String("action", string(action)),
+			zap.Error(err))
+	}
 }
 
 // GetUserActivityLogs retrieves activity logs for a specific user
@@ -235,23 +505,100 @@ func (s *AuditLogService) GetUserActivityLogs(ctx context.Context, userID string
 	return s.auditLogRepo.FindByUserID(ctx, userID, limit)
 }
 
-// GetResourceHistory retrieves the history of changes for a specific resource
+// GetResourceHistory retrieves the history of changes for a specific resource, including the
+// resources merged into it
 func (s *AuditLogService) GetResourceHistory(ctx context.Context, resourceType, resourceID string, limit int) ([]*model.AuditLog, error) {
-	return s.auditLogRepo.FindByResourceID(ctx, resourceType, resourceID, limit)
+	resourceIDs, err := s.resourceAliases(ctx, resourceType, resourceID)
+	if err != nil {
+		return nil, err
+	}
+	return s.auditLogRepo.FindByResourceIDs(ctx, resourceType, resourceIDs, limit)
 }
 
-// SearchAuditLogs searches audit logs based on criteria
+// SearchAuditLogs searches audit logs based on criteria. A resource matches the resources
+// merged into it as well.
 func (s *AuditLogService) SearchAuditLogs(ctx context.Context, criteria repository.AuditLogSearchCriteria) ([]*model.AuditLog, error) {
+	criteria, err := s.resolveResource(ctx, criteria)
+	if err != nil {
+		return nil, err
+	}
 	return s.auditLogRepo.Search(ctx, criteria)
 }
 
 // GetAuditLogCount gets the count of audit logs matching criteria
 func (s *AuditLogService) GetAuditLogCount(ctx context.Context, criteria repository.AuditLogSearchCriteria) (int64, error) {
+	criteria, err := s.resolveResource(ctx, criteria)
+	if err != nil {
+		return 0, err
+	}
 	return s.auditLogRepo.Count(ctx, criteria)
 }
 
-// CleanupOldLogs removes audit logs older than the retention period
-func (s *AuditLogService) CleanupOldLogs(ctx context.Context, retentionDays int) error {
+// resolveResource extends a search for a resource to the resources merged into it
+func (s *AuditLogService) resolveResource(ctx context.Context, criteria repository.AuditLogSearchCriteria) (repository.AuditLogSearchCriteria, error) {
+	if criteria.ResourceType == "" || criteria.ResourceID == "" {
+		return criteria, nil
+	}
+	resourceIDs, err := s.resourceAliases(ctx, criteria.ResourceType, criteria.ResourceID)
+	if err != nil {
+		return criteria, err
+	}
+	criteria.ResourceIDs = resourceIDs
+	return criteria, nil
+}
+
+// resourceAliases returns the ID of a resource and of the resources that were merged into it,
+// directly or through resources merged in turn
+func (s *AuditLogService) resourceAliases(ctx context.Context, resourceType, resourceID string) ([]string, error) {
+	resourceIDs := []string{resourceID}
+	seen := map[string]bool{resourceID: true}
+	for i := 0; i < len(resourceIDs); i++ {
+		reassigned, err := s.auditLogRepo.FindReassignedTo(ctx, resourceType, resourceIDs[i])
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range reassigned {
+			if !seen[id] {
+				seen[id] = true
+				resourceIDs = append(resourceIDs, id)
+			}
+		}
+	}
+	return resourceIDs, nil
+}
+
+// GetCheckpoints retrieves the most recent checkpoints of the chain
+func (s *AuditLogService) GetCheckpoints(ctx context.Context, limit int) ([]*model.AuditCheckpoint, error) {
+	return s.checkpointRepo.FindRecent(ctx, limit)
+}
+
+// GetArchives retrieves the records of the archived segments of the chain
+func (s *AuditLogService) GetArchives(ctx context.Context) ([]*model.AuditArchive, error) {
+	return s.archiveRepo.FindAll(ctx)
+}
+
+// CleanupOldLogs archives the audit logs older than the retention period and removes them from
+// the database. Entries are archived up to the last checkpoint made before the cutoff, so each
+// archive ends at a signed checkpoint; it returns nil when there is nothing to archive.
+func (s *AuditLogService) CleanupOldLogs(ctx context.Context, retentionDays int) (*model.AuditArchive, error) {
 	cutoffDate := time.Now().AddDate(0, 0, -retentionDays)
-	return s.auditLogRepo.DeleteOlderThan(ctx, cutoffDate)
+	checkpoint, err := s.checkpointRepo.FindLatestBefore(ctx, cutoffDate)
+	if err != nil || checkpoint == nil {
+		return nil, err
+	}
+
+	archives, err := s.archiveRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	from, previousHash := int64(1), ""
+	if len(archives) > 0 {
+		last := archives[len(archives)-1]
+		if last.ToSequence >= checkpoint.Sequence {
+			return nil, nil
+		}
+		from, previousHash = last.ToSequence+1, last.LastHash
+	}
+
+	return s.archiveSegment(ctx, from, previousHash, checkpoint)
 }
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/repository"
+	"github.com/phuhao00/cmdb/backend/infrastructure/logging"
+	"go.uber.org/zap"
+)
+
+// Audit stream delivery settings
+const (
+	// auditStreamQueueSize bounds the audit logs waiting in memory for a sink
+	auditStreamQueueSize = 1000
+	// auditStreamBatchSize bounds the audit logs sent to a sink at once
+	auditStreamBatchSize = 100
+	// auditStreamMinRetry and auditStreamMaxRetry bound the wait before a sink that is down is
+	// tried again; the wait doubles after each failure
+	auditStreamMinRetry = time.Second
+	auditStreamMaxRetry = 5 * time.Minute
+)
+
+// AuditSink ships audit logs to an external collector, such as a syslog server or a SIEM
+type AuditSink interface {
+	// Name returns the name of the sink used in logs
+	Name() string
+
+	// Send delivers audit logs, in order; an error means they must be sent again
+	Send(ctx context.Context, logs []*model.AuditLog) error
+}
+
+// StreamingAuditLogRepository wraps an AuditLogRepository and hands every audit log it
+// persists to the audit forwarders, so that no write path can skip them
+type StreamingAuditLogRepository struct {
+	repository.AuditLogRepository
+
+	forwarders []*AuditForwarder
+}
+
+// NewStreamingAuditLogRepository creates a new streaming audit log repository
+func NewStreamingAuditLogRepository(auditLogRepo repository.AuditLogRepository, forwarders ...*AuditForwarder) *StreamingAuditLogRepository {
+	return &StreamingAuditLogRepository{
+		AuditLogRepository: auditLogRepo,
+		forwarders:         forwarders,
+	}
+}
+
+// Create persists the audit log and publishes it to the forwarders on success
+func (r *StreamingAuditLogRepository) Create(ctx context.Context, log *model.AuditLog) error {
+	if err := r.AuditLogRepository.Create(ctx, log); err != nil {
+		return err
+	}
+
+	for _, forwarder := range r.forwarders {
+		forwarder.Publish(ctx, log)
+	}
+	return nil
+}
+
+// AuditForwarder streams the audit logs a sink selects to it in the background. While the sink
+// is down, audit logs are buffered in its spool and sent in order once it is back.
+type AuditForwarder struct {
+	sink   AuditSink
+	config model.AuditSinkConfig
+	spool  repository.AuditSpool
+	queue  chan *model.AuditLog
+
+	// spooled is set while the spool may hold audit logs; new ones then queue behind them
+	spooled atomic.Bool
+	// spoolMu keeps audit logs spooled by Publish and by the delivery loop in order
+	spoolMu sync.Mutex
+}
+
+// NewAuditForwarder creates a forwarder of the audit logs a sink's configuration selects
+func NewAuditForwarder(sink AuditSink, config model.AuditSinkConfig, spool repository.AuditSpool) *AuditForwarder {
+	forwarder := &AuditForwarder{
+		sink:   sink,
+		config: config,
+		spool:  spool,
+		queue:  make(chan *model.AuditLog, auditStreamQueueSize),
+	}
+	// Audit logs may be left in the spool from before a restart
+	forwarder.spooled.Store(true)
+	return forwarder
+}
+
+// Publish queues an audit log for the sink if it selects it. When the queue is full, the audit
+// log goes to the spool instead; it may then reach the sink ahead of audit logs still queued.
+func (f *AuditForwarder) Publish(ctx context.Context, log *model.AuditLog) {
+	if !f.config.Matches(log) {
+		return
+	}
+
+	select {
+	case f.queue <- log:
+	default:
+		f.spoolLogs(ctx, []*model.AuditLog{log})
+	}
+}
+
+// Start delivers audit logs to the sink until the context is cancelled. Audit logs still
+// queued then are spooled to be sent after a restart.
+func (f *AuditForwarder) Start(ctx context.Context) {
+	go func() {
+		retry := auditStreamMinRetry
+		for {
+			if f.spooled.Load() {
+				sent, err := f.sendSpooled(ctx)
+				if err != nil {
+					logging.Logger.Warn("audit_stream_send_failed",
+						zap.String("sink", f.sink.Name()),
+						zap.Duration("retry_in", retry),
+						zap.Error(err))
+					if !f.wait(ctx, retry) {
+						return
+					}
+					if retry *= 2; retry > auditStreamMaxRetry {
+						retry = auditStreamMaxRetry
+					}
+					continue
+				}
+				retry = auditStreamMinRetry
+				if sent {
+					continue
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				f.spoolQueued(context.WithoutCancel(ctx))
+				return
+			case log := <-f.queue:
+				logs := f.collect(log)
+				if f.spooled.Load() {
+					f.spoolLogs(ctx, logs)
+					continue
+				}
+				if err := f.sink.Send(ctx, logs); err != nil {
+					f.spoolLogs(ctx, logs)
+				}
+			}
+		}
+	}()
+}
+
+// sendSpooled sends the next batch of spooled audit logs and reports whether there was one
+func (f *AuditForwarder) sendSpooled(ctx context.Context) (bool, error) {
+	logs, err := f.spool.Peek(ctx, auditStreamBatchSize)
+	if err != nil {
+		return false, err
+	}
+	if len(logs) == 0 {
+		f.spoolMu.Lock()
+		defer f.spoolMu.Unlock()
+		// Publish may have spooled audit logs since the spool was read
+		if logs, err = f.spool.Peek(ctx, 1); err != nil || len(logs) > 0 {
+			return len(logs) > 0, err
+		}
+		f.spooled.Store(false)
+		return false, nil
+	}
+
+	if err := f.sink.Send(ctx, logs); err != nil {
+		return false, err
+	}
+	return true, f.spool.Discard(ctx, len(logs))
+}
+
+// wait waits before the sink is tried again, spooling the audit logs published meanwhile. It
+// reports false once the context is cancelled.
+func (f *AuditForwarder) wait(ctx context.Context, delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			f.spoolQueued(context.WithoutCancel(ctx))
+			return false
+		case <-timer.C:
+			return true
+		case log := <-f.queue:
+			f.spoolLogs(ctx, f.collect(log))
+		}
+	}
+}
+
+// collect adds the audit logs already queued after the first one, up to a batch
+func (f *AuditForwarder) collect(first *model.AuditLog) []*model.AuditLog {
+	logs := []*model.AuditLog{first}
+	for len(logs) < auditStreamBatchSize {
+		select {
+		case log := <-f.queue:
+			logs = append(logs, log)
+		default:
+			return logs
+		}
+	}
+	return logs
+}
+
+// spoolQueued spools every audit log still queued
+func (f *AuditForwarder) spoolQueued(ctx context.Context) {
+	for {
+		select {
+		case log := <-f.queue:
+			f.spoolLogs(ctx, f.collect(log))
+		default:
+			return
+		}
+	}
+}
+
+// spoolLogs buffers audit logs until the sink takes them. Audit logs the spool has no room
+// for are lost, which is logged.
+func (f *AuditForwarder) spoolLogs(ctx context.Context, logs []*model.AuditLog) {
+	f.spoolMu.Lock()
+	defer f.spoolMu.Unlock()
+
+	f.spooled.Store(true)
+	if err := f.spool.Append(ctx, logs); err != nil {
+		logging.Logger.Error("audit_stream_dropped",
+			zap.String("sink", f.sink.Name()),
+			zap.Int("count", len(logs)),
+			zap.Int64("first_sequence", logs[0].Sequence),
+			zap.Error(err))
+	}
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/infrastructure/persistence"
+)
+
+// flakySink fails a number of sends before it takes audit logs, or every send while down is set
+type flakySink struct {
+	mu       sync.Mutex
+	failures int
+	down     bool
+	sent     []int64
+}
+
+func (s *flakySink) Name() string {
+	return "flaky"
+}
+
+func (s *flakySink) Send(ctx context.Context, logs []*model.AuditLog) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.down || s.failures > 0 {
+		s.failures--
+		return errors.New("sink unavailable")
+	}
+	for _, log := range logs {
+		s.sent = append(s.sent, log.Sequence)
+	}
+	return nil
+}
+
+func (s *flakySink) sequences() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]int64(nil), s.sent...)
+}
+
+// eventually polls until check passes or the deadline is reached
+func eventually(t *testing.T, what string, check func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !check() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func streamedLog(sequence int64, resourceType string) *model.AuditLog {
+	log := model.NewAuditLog("u1", "admin", model.AssetUpdated, resourceType, "a1", "web", "changed web")
+	log.Sequence = sequence
+	return log
+}
+
+func TestAuditForwarderRecoversInOrder(t *testing.T) {
+	spool, err := persistence.NewFileAuditSpool(t.TempDir(), "flaky", 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The first send from the queue and the retry from the spool both fail
+	sink := &flakySink{failures: 2}
+	forwarder := NewAuditForwarder(sink, model.AuditSinkConfig{Name: "flaky", ResourceTypes: []string{"asset"}}, spool)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	forwarder.Start(ctx)
+
+	for sequence := int64(1); sequence <= 5; sequence++ {
+		forwarder.Publish(ctx, streamedLog(sequence, "asset"))
+		// Not selected by the sink
+		forwarder.Publish(ctx, streamedLog(100+sequence, "user"))
+	}
+
+	eventually(t, "the sink to recover", func() bool { return len(sink.sequences()) >= 5 })
+	if got, want := sink.sequences(), []int64{1, 2, 3, 4, 5}; !equalInt64s(got, want) {
+		t.Fatalf("sent = %v, want %v", got, want)
+	}
+	logs, err := spool.Peek(ctx, 10)
+	if err != nil || len(logs) != 0 {
+		t.Errorf("spool after recovery = %d audit logs, %v; want it drained", len(logs), err)
+	}
+
+	// Once drained, audit logs go straight to the sink again
+	forwarder.Publish(ctx, streamedLog(6, "asset"))
+	eventually(t, "a queued audit log", func() bool { return len(sink.sequences()) == 6 })
+}
+
+func TestAuditForwarderSendsSpoolAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	spool, err := persistence.NewFileAuditSpool(dir, "flaky", 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := model.AuditSinkConfig{Name: "flaky"}
+
+	// The sink is down until the process stops; what was published is left in the spool
+	down := &flakySink{down: true}
+	forwarder := NewAuditForwarder(down, config, spool)
+	ctx, cancel := context.WithCancel(context.Background())
+	forwarder.Start(ctx)
+	for sequence := int64(1); sequence <= 3; sequence++ {
+		forwarder.Publish(ctx, streamedLog(sequence, "asset"))
+	}
+	eventually(t, "audit logs to be spooled", func() bool {
+		logs, err := spool.Peek(ctx, 10)
+		return err == nil && len(logs) == 3
+	})
+	cancel()
+
+	// After the restart, the leftover spool is sent ahead of new audit logs
+	reopened, err := persistence.NewFileAuditSpool(dir, "flaky", 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := &flakySink{}
+	forwarder = NewAuditForwarder(sink, config, reopened)
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	forwarder.Publish(ctx, streamedLog(4, "asset"))
+	forwarder.Start(ctx)
+	forwarder.Publish(ctx, streamedLog(5, "asset"))
+
+	eventually(t, "the spool to be sent", func() bool { return len(sink.sequences()) >= 5 })
+	if got, want := sink.sequences(), []int64{1, 2, 3, 4, 5}; !equalInt64s(got, want) {
+		t.Fatalf("sent = %v, want %v", got, want)
+	}
+	if got := down.sequences(); len(got) != 0 {
+		t.Errorf("sent while down = %v", got)
+	}
+}
+
+func equalInt64s(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/phuhao00/cmdb/backend/domain/model"
@@ -12,98 +13,244 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-// AuthService provides authentication and authorization services
+// AuthTokenConfig sets how long the tokens issued at login stay valid
+type AuthTokenConfig struct {
+	// AccessTokenTTL bounds how long an access token works; revocations are kept as long
+	AccessTokenTTL time.Duration
+	// RefreshTokenTTL bounds how long a session may go without being refreshed
+	RefreshTokenTTL time.Duration
+}
+
+// DefaultAuthTokenConfig returns the default token lifetimes: 15 minutes for access tokens and
+// a week for refresh tokens
+func DefaultAuthTokenConfig() AuthTokenConfig {
+	return AuthTokenConfig{
+		AccessTokenTTL:  15 * time.Minute,
+		RefreshTokenTTL: 7 * 24 * time.Hour,
+	}
+}
+
+// AuthService provides authentication and authorization services. Users get a short-lived signed
+// access token and a refresh token, which is stored as a session and replaced on every refresh.
 type AuthService struct {
-	userRepo repository.UserRepository
+	userRepo        repository.UserRepository
+	auditLogService *AuditLogService
+	signer          *TokenSigner
+	revocations     *TokenRevocationList
+	config          AuthTokenConfig
+
+	// ssoConfig, ssoProvider and ssoLogins are set when single sign-on is enabled
+	ssoConfig   *model.OIDCConfig
+	ssoProvider OIDCProvider
+	ssoLogins   repository.OIDCLoginRepository
 }
 
 // NewAuthService creates a new auth service
-func NewAuthService(userRepo repository.UserRepository) *AuthService {
+func NewAuthService(userRepo repository.UserRepository, auditLogService *AuditLogService, signer *TokenSigner, revocations *TokenRevocationList, config AuthTokenConfig) *AuthService {
 	return &AuthService{
-		userRepo: userRepo,
+		userRepo:        userRepo,
+		auditLogService: auditLogService,
+		signer:          signer,
+		revocations:     revocations,
+		config:          config,
 	}
 }
 
-// Login authenticates a user and creates a session
-func (s *AuthService) Login(ctx context.Context, username, password string) (*model.User, string, error) {
+// Login authenticates a user and starts a session. Logins and failed attempts are audited.
+func (s *AuthService) Login(ctx context.Context, username, password string) (*model.User, *model.AuthTokens, error) {
 	// Get user by username
 	user, err := s.userRepo.GetByUsername(ctx, username)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 	if user == nil {
-		return nil, "", errors.New("invalid username or password")
+		return nil, nil, s.loginFailed(ctx, "", username, errors.New("invalid username or password"))
 	}
 
 	// Check if user is active
 	if !user.IsActive() {
-		return nil, "", errors.New("user account is not active")
+		return nil, nil, s.loginFailed(ctx, user.ID.Hex(), username, errors.New("user account is not active"))
 	}
 
 	// Validate password
 	if !user.ValidatePassword(password) {
-		return nil, "", errors.New("invalid username or password")
+		return nil, nil, s.loginFailed(ctx, user.ID.Hex(), username, errors.New("invalid username or password"))
 	}
 
-	// Generate session token
-	token, err := s.generateToken()
-	if err != nil {
-		return nil, "", err
+	// Local admins keep password sign-in as a way in when the identity provider is down
+	if err := s.passwordLoginAllowed(user); err != nil {
+		return nil, nil, s.loginFailed(ctx, user.ID.Hex(), username, err)
 	}
 
-	// Create session
-	session := model.NewSession(user.ID, token, 24*time.Hour) // 24 hours
-	if err := s.userRepo.CreateSession(ctx, session); err != nil {
-		return nil, "", err
+	// Start a new session family
+	tokens, err := s.issueTokens(ctx, user, primitive.NewObjectID())
+	if err != nil {
+		return nil, nil, err
 	}
 
 	// Update last login time
 	user.UpdateLastLogin()
 	if err := s.userRepo.Update(ctx, user); err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
-	return user, token, nil
+	client := model.AuditClientFromContext(ctx)
+	logAuditFailure(model.UserLoggedIn, s.auditLogService.LogUserLogin(ctx, user.ID.Hex(), user.Username, client.IPAddress, client.UserAgent))
+
+	return user, tokens, nil
 }
 
-// Logout invalidates a user's session
-func (s *AuthService) Logout(ctx context.Context, token string) error {
-	return s.userRepo.DeleteSession(ctx, token)
+// loginFailed audits a failed login attempt and returns its error
+func (s *AuthService) loginFailed(ctx context.Context, userID, username string, err error) error {
+	client := model.AuditClientFromContext(ctx)
+	logAuditFailure(model.UserLoginFailed, s.auditLogService.LogUserLoginFailed(ctx, userID, username, err.Error(), client.IPAddress, client.UserAgent))
+	return err
 }
 
-// ValidateToken validates a session token and returns the associated user
-func (s *AuthService) ValidateToken(ctx context.Context, token string) (*model.User, error) {
-	// Get session by token
-	session, err := s.userRepo.GetSessionByToken(ctx, token)
+// Refresh exchanges a refresh token for new tokens of the same session. A refresh token works
+// once: presenting it again means it leaked, so the whole session is revoked and audited.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*model.User, *model.AuthTokens, error) {
+	tokenHash := model.HashRefreshToken(refreshToken)
+	session, err := s.userRepo.RotateSession(ctx, tokenHash)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if session == nil {
-		return nil, errors.New("invalid token")
+		return nil, nil, s.refreshRejected(ctx, tokenHash)
 	}
-
-	// Check if session is expired
 	if session.IsExpired() {
-		// Delete expired session
-		s.userRepo.DeleteSession(ctx, token)
-		return nil, errors.New("session expired")
+		return nil, nil, model.ErrTokenExpired
 	}
 
-	// Get user
+	// The user is loaded again so that the new access token carries their current role
 	user, err := s.userRepo.GetByID(ctx, session.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user == nil || !user.IsActive() {
+		if err := s.userRepo.DeleteSessionFamily(ctx, session.FamilyID); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, errors.New("user account is not active")
+	}
+
+	tokens, err := s.issueTokens(ctx, user, session.FamilyID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, tokens, nil
+}
+
+// refreshRejected returns why a refresh token that did not rotate a session was refused. When
+// it was already rotated, its session is revoked.
+func (s *AuthService) refreshRejected(ctx context.Context, tokenHash string) error {
+	session, err := s.userRepo.GetSessionByToken(ctx, tokenHash)
+	if err != nil {
+		return err
+	}
+	if session == nil {
+		return model.ErrInvalidToken
+	}
+	if session.IsExpired() {
+		return model.ErrTokenExpired
+	}
+
+	if err := s.revokeSession(ctx, session.FamilyID, string(model.RefreshTokenReused)); err != nil {
+		return err
+	}
+
+	var username string
+	if user, err := s.userRepo.GetByID(ctx, session.UserID); err == nil && user != nil {
+		username = user.Username
+	}
+	client := model.AuditClientFromContext(ctx)
+	logAuditFailure(model.RefreshTokenReused, s.auditLogService.LogRefreshTokenReused(ctx, session.UserID.Hex(), username, session.FamilyID.Hex(), client.IPAddress, client.UserAgent))
+	return model.ErrRefreshTokenReused
+}
+
+// issueTokens creates a refresh token stored as a session of a family and an access token of
+// that session
+func (s *AuthService) issueTokens(ctx context.Context, user *model.User, familyID primitive.ObjectID) (*model.AuthTokens, error) {
+	refreshToken, err := s.generateToken()
 	if err != nil {
 		return nil, err
 	}
-	if user == nil {
-		return nil, errors.New("user not found")
+	session := model.NewSession(user.ID, familyID, refreshToken, s.config.RefreshTokenTTL)
+	if err := s.userRepo.CreateSession(ctx, session); err != nil {
+		return nil, err
 	}
 
-	// Check if user is still active
-	if !user.IsActive() {
-		return nil, errors.New("user account is not active")
+	accessToken, err := s.signer.Sign(model.NewAccessClaims(user, familyID.Hex(), s.config.AccessTokenTTL))
+	if err != nil {
+		return nil, err
 	}
 
-	return user, nil
+	return &model.AuthTokens{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		ExpiresIn:        int64(s.config.AccessTokenTTL / time.Second),
+		RefreshExpiresAt: session.ExpiresAt,
+	}, nil
+}
+
+// Logout ends the session of an access token, which stops both its refresh token and its access
+// tokens from working; the user is the actor of the context
+func (s *AuthService) Logout(ctx context.Context, token string) error {
+	claims, err := s.ValidateToken(ctx, token)
+	if err != nil {
+		return err
+	}
+	familyID, err := primitive.ObjectIDFromHex(claims.SessionID)
+	if err != nil {
+		return model.ErrInvalidToken
+	}
+	if err := s.revokeSession(ctx, familyID, "logout"); err != nil {
+		return err
+	}
+
+	actor, client := auditSource(ctx)
+	logAuditFailure(model.UserLoggedOut, s.auditLogService.LogUserLogout(ctx, actor.ID, actor.Name, client.IPAddress, client.UserAgent))
+	return nil
+}
+
+// ValidateToken checks the signature and expiry of an access token against the revocation list
+// and returns its claims. It does not query the database.
+func (s *AuthService) ValidateToken(ctx context.Context, token string) (*model.AccessClaims, error) {
+	claims, err := s.signer.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+	if s.revocations.IsRevoked(claims) {
+		return nil, model.ErrTokenRevoked
+	}
+	return claims, nil
+}
+
+// JWKS returns the public keys other services verify access tokens with
+func (s *AuthService) JWKS() model.JSONWebKeySet {
+	return s.signer.JWKS()
+}
+
+// revokeSession deletes the refresh tokens of a session family and revokes its access tokens
+func (s *AuthService) revokeSession(ctx context.Context, familyID primitive.ObjectID, reason string) error {
+	if err := s.userRepo.DeleteSessionFamily(ctx, familyID); err != nil {
+		return err
+	}
+	return s.revocations.Revoke(ctx, model.NewTokenRevocation(model.RevokedSession, familyID.Hex(), reason, s.revocationLifetime()))
+}
+
+// revokeUser deletes the refresh tokens of a user and revokes the access tokens issued so far
+func (s *AuthService) revokeUser(ctx context.Context, userID primitive.ObjectID, reason string) error {
+	if err := s.userRepo.DeleteUserSessions(ctx, userID); err != nil {
+		return err
+	}
+	return s.revocations.Revoke(ctx, model.NewTokenRevocation(model.RevokedUser, userID.Hex(), reason, s.revocationLifetime()))
+}
+
+// revocationLifetime is how long a revocation must be kept for every access token it covers to
+// have expired, allowing for clock skew
+func (s *AuthService) revocationLifetime() time.Duration {
+	return s.config.AccessTokenTTL + tokenClockSkew
 }
 
 // CreateUser creates a new user (admin only)
@@ -137,6 +284,9 @@ func (s *AuthService) CreateUser(ctx context.Context, username, email, password,
 		return nil, err
 	}
 
+	actor, client := auditSource(ctx)
+	logAuditFailure(model.UserCreated, s.auditLogService.LogUserCreated(ctx, actor.ID, actor.Name, user, client.IPAddress, client.UserAgent))
+
 	return user, nil
 }
 
@@ -150,6 +300,9 @@ func (s *AuthService) ChangePassword(ctx context.Context, userID primitive.Objec
 	if user == nil {
 		return errors.New("user not found")
 	}
+	if user.IsSSO() {
+		return errors.New("users signing in with single sign-on have no password")
+	}
 
 	// Validate old password
 	if !user.ValidatePassword(oldPassword) {
@@ -166,8 +319,11 @@ func (s *AuthService) ChangePassword(ctx context.Context, userID primitive.Objec
 		return err
 	}
 
-	// Delete all user sessions to force re-login
-	return s.userRepo.DeleteUserSessions(ctx, userID)
+	actor, client := auditSource(ctx)
+	logAuditFailure(model.UserPasswordChanged, s.auditLogService.LogUserPasswordChanged(ctx, actor.ID, actor.Name, user, client.IPAddress, client.UserAgent))
+
+	// End all sessions of the user to force re-login
+	return s.revokeUser(ctx, userID, "password_changed")
 }
 
 // GetUsers returns a list of users (admin only)
@@ -195,8 +351,96 @@ func (s *AuthService) UpdateUserStatus(ctx context.Context, userID primitive.Obj
 		return errors.New("user not found")
 	}
 
+	oldStatus := user.Status
 	user.Status = status
-	return s.userRepo.Update(ctx, user)
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+
+	s.logUserUpdated(ctx, user, "status", oldStatus, status)
+
+	// A locked or deactivated user is signed out everywhere at once
+	if !user.IsActive() {
+		return s.revokeUser(ctx, userID, "user_"+string(status))
+	}
+	return nil
+}
+
+// SetUserManager sets the line manager of a user; an empty manager clears it
+func (s *AuthService) SetUserManager(ctx context.Context, userID primitive.ObjectID, manager string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errors.New("user not found")
+	}
+
+	if manager != "" {
+		if manager == user.Username {
+			return errors.New("a user cannot be their own manager")
+		}
+		managerUser, err := s.userRepo.GetByUsername(ctx, manager)
+		if err != nil {
+			return err
+		}
+		if managerUser == nil {
+			return errors.New("manager not found")
+		}
+	}
+
+	oldManager := user.Manager
+	user.SetManager(manager)
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+
+	s.logUserUpdated(ctx, user, "manager", oldManager, manager)
+	return nil
+}
+
+// SetUserChannelID links a user to their account at an approval channel, so that decisions
+// they make there are checked against the approvers of each stage; an empty ID unlinks it
+func (s *AuthService) SetUserChannelID(ctx context.Context, userID primitive.ObjectID, channel, accountID string) error {
+	if channel == "" || strings.ContainsAny(channel, ".$") {
+		return errors.New("invalid approval channel")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errors.New("user not found")
+	}
+
+	if accountID != "" {
+		owner, err := s.userRepo.GetByChannelID(ctx, channel, accountID)
+		if err != nil {
+			return err
+		}
+		if owner != nil && owner.ID != user.ID {
+			return errors.New("the channel account is linked to another user")
+		}
+	}
+
+	oldAccountID := user.ChannelIDs[channel]
+	user.SetChannelID(channel, accountID)
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+
+	s.logUserUpdated(ctx, user, "channelIds."+channel, oldAccountID, accountID)
+	return nil
+}
+
+// logUserUpdated audits a change of one field of a user made by the actor of the context
+func (s *AuthService) logUserUpdated(ctx context.Context, user *model.User, field string, oldValue, newValue interface{}) {
+	actor, client := auditSource(ctx)
+	logAuditFailure(model.UserUpdated, s.auditLogService.LogUserUpdated(ctx, actor.ID, actor.Name, user,
+		map[string]interface{}{field: oldValue},
+		map[string]interface{}{field: newValue},
+		client.IPAddress, client.UserAgent))
 }
 
 // CleanupExpiredSessions removes expired sessions
@@ -204,7 +448,7 @@ func (s *AuthService) CleanupExpiredSessions(ctx context.Context) error {
 	return s.userRepo.DeleteExpiredSessions(ctx)
 }
 
-// generateToken generates a random session token
+// generateToken generates a random refresh token
 func (s *AuthService) generateToken() (string, error) {
 	bytes := make([]byte, 32)
 	if _, err := rand.Read(bytes); err != nil {
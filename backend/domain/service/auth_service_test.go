@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const testPassword = "correct-horse-battery"
+
+// newTestAuthService creates an auth service with its users, sessions, revocations and audit
+// log kept in memory
+func newTestAuthService(t *testing.T) (*AuthService, *memoryUserRepository, *memoryAuditLogRepository) {
+	t.Helper()
+	users := &memoryUserRepository{}
+	auditLogRepo := &memoryAuditLogRepository{}
+	auditLogService := NewAuditLogService(auditLogRepo, nil, noAuditArchives{}, nil, nil)
+	signer := newTestTokenSigner(t, "cmdb", testSigningKeys()[0])
+	revocations := NewTokenRevocationList(&memoryTokenRevocationRepository{})
+	return NewAuthService(users, auditLogService, signer, revocations, DefaultAuthTokenConfig()), users, auditLogRepo
+}
+
+func addTestUser(t *testing.T, users *memoryUserRepository, username string) *model.User {
+	t.Helper()
+	user, err := model.NewUser(username, username+"@example.com", testPassword, username, model.OperatorRole)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user.ID = primitive.NewObjectID()
+	users.users = append(users.users, user)
+	return user
+}
+
+func login(t *testing.T, auth *AuthService, username string) *model.AuthTokens {
+	t.Helper()
+	_, tokens, err := auth.Login(context.Background(), username, testPassword)
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	return tokens
+}
+
+// checkAccessToken checks whether an access token is accepted or refused with an error
+func checkAccessToken(t *testing.T, auth *AuthService, name, token string, wantErr error) {
+	t.Helper()
+	_, err := auth.ValidateToken(context.Background(), token)
+	if wantErr == nil && err != nil {
+		t.Errorf("ValidateToken(%s) error = %v", name, err)
+	}
+	if wantErr != nil && !errors.Is(err, wantErr) {
+		t.Errorf("ValidateToken(%s) error = %v, want %v", name, err, wantErr)
+	}
+}
+
+func TestRefreshTokenReuseRevokesSession(t *testing.T) {
+	auth, users, auditLogRepo := newTestAuthService(t)
+	addTestUser(t, users, "alice")
+	ctx := context.Background()
+
+	first := login(t, auth, "alice")
+	other := login(t, auth, "alice")
+
+	_, second, err := auth.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Fatal("Refresh() returned the same refresh token")
+	}
+	checkAccessToken(t, auth, "refreshed", second.AccessToken, nil)
+
+	// The rotated refresh token was copied: the session it belongs to ends
+	if _, _, err := auth.Refresh(ctx, first.RefreshToken); !errors.Is(err, model.ErrRefreshTokenReused) {
+		t.Fatalf("Refresh() with a rotated token error = %v, want ErrRefreshTokenReused", err)
+	}
+	if _, _, err := auth.Refresh(ctx, second.RefreshToken); !errors.Is(err, model.ErrInvalidToken) {
+		t.Errorf("Refresh() with the latest token of the revoked session error = %v, want ErrInvalidToken", err)
+	}
+	checkAccessToken(t, auth, "first", first.AccessToken, model.ErrTokenRevoked)
+	checkAccessToken(t, auth, "refreshed", second.AccessToken, model.ErrTokenRevoked)
+
+	// Other sessions of the user are not affected
+	checkAccessToken(t, auth, "other session", other.AccessToken, nil)
+	if _, _, err := auth.Refresh(ctx, other.RefreshToken); err != nil {
+		t.Errorf("Refresh() of another session error = %v", err)
+	}
+
+	var audited bool
+	for _, log := range auditLogRepo.logs {
+		audited = audited || log.Action == model.RefreshTokenReused
+	}
+	if !audited {
+		t.Error("refresh token reuse was not audited")
+	}
+}
+
+func TestLogoutRevokesSession(t *testing.T) {
+	auth, users, _ := newTestAuthService(t)
+	addTestUser(t, users, "alice")
+	ctx := context.Background()
+
+	session := login(t, auth, "alice")
+	other := login(t, auth, "alice")
+
+	if err := auth.Logout(ctx, session.AccessToken); err != nil {
+		t.Fatalf("Logout() error = %v", err)
+	}
+	checkAccessToken(t, auth, "logged out", session.AccessToken, model.ErrTokenRevoked)
+	if _, _, err := auth.Refresh(ctx, session.RefreshToken); !errors.Is(err, model.ErrInvalidToken) {
+		t.Errorf("Refresh() after logout error = %v, want ErrInvalidToken", err)
+	}
+	checkAccessToken(t, auth, "other session", other.AccessToken, nil)
+}
+
+func TestLockingUserRevokesTokens(t *testing.T) {
+	auth, users, _ := newTestAuthService(t)
+	alice := addTestUser(t, users, "alice")
+	addTestUser(t, users, "bob")
+	ctx := context.Background()
+
+	first := login(t, auth, "alice")
+	second := login(t, auth, "alice")
+	bob := login(t, auth, "bob")
+
+	if err := auth.UpdateUserStatus(ctx, alice.ID, model.LockedStatus); err != nil {
+		t.Fatalf("UpdateUserStatus() error = %v", err)
+	}
+	checkAccessToken(t, auth, "first", first.AccessToken, model.ErrTokenRevoked)
+	checkAccessToken(t, auth, "second", second.AccessToken, model.ErrTokenRevoked)
+	if _, _, err := auth.Refresh(ctx, second.RefreshToken); err == nil {
+		t.Error("Refresh() of a locked user succeeded")
+	}
+	if _, _, err := auth.Login(ctx, "alice", testPassword); err == nil {
+		t.Error("Login() of a locked user succeeded")
+	}
+	checkAccessToken(t, auth, "another user", bob.AccessToken, nil)
+}
+
+func TestPasswordChangeRevokesEarlierTokensOnly(t *testing.T) {
+	auth, users, _ := newTestAuthService(t)
+	alice := addTestUser(t, users, "alice")
+	ctx := context.Background()
+
+	before := login(t, auth, "alice")
+	if err := auth.ChangePassword(ctx, alice.ID, testPassword, testPassword+"-2"); err != nil {
+		t.Fatalf("ChangePassword() error = %v", err)
+	}
+	checkAccessToken(t, auth, "before the change", before.AccessToken, model.ErrTokenRevoked)
+
+	// Signing in again within the same second is not caught by the revocation
+	_, after, err := auth.Login(ctx, "alice", testPassword+"-2")
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	checkAccessToken(t, auth, "after the change", after.AccessToken, nil)
+}
+
+func TestUserRevocationCovers(t *testing.T) {
+	userID := primitive.NewObjectID().Hex()
+	revokedAt := time.Date(2026, 5, 1, 12, 0, 0, 500*int(time.Millisecond), time.UTC)
+	revocation := &model.TokenRevocation{Kind: model.RevokedUser, Value: userID, RevokedAt: revokedAt}
+
+	tests := []struct {
+		name   string
+		claims model.AccessClaims
+		want   bool
+	}{
+		{"issued earlier in the second", model.AccessClaims{Subject: userID, IssuedAt: revokedAt.Unix(), IssuedAtMs: revokedAt.UnixMilli() - 1}, true},
+		{"issued at the revocation", model.AccessClaims{Subject: userID, IssuedAt: revokedAt.Unix(), IssuedAtMs: revokedAt.UnixMilli()}, true},
+		{"issued later in the second", model.AccessClaims{Subject: userID, IssuedAt: revokedAt.Unix(), IssuedAtMs: revokedAt.UnixMilli() + 1}, false},
+		{"issued the next second", model.AccessClaims{Subject: userID, IssuedAt: revokedAt.Unix() + 1, IssuedAtMs: revokedAt.UnixMilli() + 600}, false},
+		{"without milliseconds in the second", model.AccessClaims{Subject: userID, IssuedAt: revokedAt.Unix()}, true},
+		{"without milliseconds the next second", model.AccessClaims{Subject: userID, IssuedAt: revokedAt.Unix() + 1}, false},
+		{"another user", model.AccessClaims{Subject: primitive.NewObjectID().Hex(), IssuedAt: revokedAt.Unix()}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := revocation.Covers(&tt.claims); got != tt.want {
+				t.Errorf("Covers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
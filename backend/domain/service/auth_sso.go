@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SSOLoginLifetime bounds how long a user may take to sign in at the identity provider
+const SSOLoginLifetime = 10 * time.Minute
+
+// OIDCProvider signs users in with an OpenID Connect identity provider
+type OIDCProvider interface {
+	// AuthCodeURL returns the address of the identity provider the user signs in at, for a
+	// sign-in identified by a state and nonce, with the S256 PKCE challenge of its verifier
+	AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error)
+
+	// Exchange redeems the authorization code of a callback with the PKCE verifier and returns
+	// the user the ID token vouches for, once its signature, audience, expiry and nonce check out
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (*model.OIDCIdentity, error)
+}
+
+// SetSingleSignOn enables sign-in through an OpenID Connect identity provider
+func (s *AuthService) SetSingleSignOn(config *model.OIDCConfig, provider OIDCProvider, logins repository.OIDCLoginRepository) {
+	s.ssoConfig = config
+	s.ssoProvider = provider
+	s.ssoLogins = logins
+}
+
+// SingleSignOnConfig returns the identity provider configuration, or nil when single sign-on is
+// not enabled
+func (s *AuthService) SingleSignOnConfig() *model.OIDCConfig {
+	return s.ssoConfig
+}
+
+// StartSingleSignOn begins a sign-in at the identity provider and returns the address to send the
+// user to, with the state the callback must answer. returnTo is the page of the web app to go
+// back to; only paths of the app are kept.
+func (s *AuthService) StartSingleSignOn(ctx context.Context, returnTo string) (string, string, error) {
+	if s.ssoProvider == nil {
+		return "", "", model.ErrSSODisabled
+	}
+
+	state, err := s.generateToken()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := s.generateToken()
+	if err != nil {
+		return "", "", err
+	}
+	codeVerifier, err := s.generateToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	// Anything but a local path could send the user, and their tokens, to another site
+	if !strings.HasPrefix(returnTo, "/") || strings.HasPrefix(returnTo, "//") || strings.HasPrefix(returnTo, "/\\") {
+		returnTo = "/"
+	}
+
+	if err := s.ssoLogins.Create(ctx, model.NewOIDCLogin(state, nonce, codeVerifier, returnTo, SSOLoginLifetime)); err != nil {
+		return "", "", err
+	}
+
+	challenge := sha256.Sum256([]byte(codeVerifier))
+	authURL, err := s.ssoProvider.AuthCodeURL(ctx, state, nonce, base64.RawURLEncoding.EncodeToString(challenge[:]))
+	if err != nil {
+		return "", "", err
+	}
+	return authURL, state, nil
+}
+
+// CompleteSingleSignOn finishes the sign-in of a state with the authorization code the identity
+// provider returned. The user is provisioned on their first sign-in and their role and
+// permissions follow their groups on every sign-in. It returns the page to go back to.
+func (s *AuthService) CompleteSingleSignOn(ctx context.Context, state, code string) (*model.User, *model.AuthTokens, string, error) {
+	if s.ssoProvider == nil {
+		return nil, nil, "", model.ErrSSODisabled
+	}
+
+	login, err := s.ssoLogins.Consume(ctx, state)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if login == nil || login.IsExpired() {
+		return nil, nil, "", model.ErrSSOStateInvalid
+	}
+
+	identity, err := s.ssoProvider.Exchange(ctx, code, login.CodeVerifier, login.Nonce)
+	if err != nil {
+		return nil, nil, "", s.loginFailed(ctx, "", "", fmt.Errorf("single sign-on failed: %w", err))
+	}
+
+	user, err := s.provisionUser(ctx, identity)
+	if err != nil {
+		userID := ""
+		if user != nil {
+			userID = user.ID.Hex()
+		}
+		return nil, nil, "", s.loginFailed(ctx, userID, identity.Username, err)
+	}
+
+	tokens, err := s.issueTokens(ctx, user, primitive.NewObjectID())
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	user.UpdateLastLogin()
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, nil, "", err
+	}
+
+	client := model.AuditClientFromContext(ctx)
+	logAuditFailure(model.UserLoggedIn, s.auditLogService.LogUserLogin(ctx, user.ID.Hex(), user.Username, client.IPAddress, client.UserAgent))
+
+	return user, tokens, login.ReturnTo, nil
+}
+
+// provisionUser finds or creates the user of an identity and updates their role, permissions
+// and profile from it. The user is returned with the error when they exist but may not sign in.
+func (s *AuthService) provisionUser(ctx context.Context, identity *model.OIDCIdentity) (*model.User, error) {
+	if identity.Username == "" || identity.Email == "" {
+		return nil, errors.New("identity provider did not return a username and email")
+	}
+
+	role, permissions, err := s.ssoConfig.MapGroups(identity.Groups)
+	if err != nil {
+		return nil, err
+	}
+
+	actor := model.Actor{Name: identity.Username}
+	client := model.AuditClientFromContext(ctx)
+
+	user, err := s.userRepo.GetByExternalID(ctx, identity.ExternalID())
+	if err != nil {
+		return nil, err
+	}
+	if user != nil {
+		if !user.IsActive() {
+			return user, errors.New("user account is not active")
+		}
+		oldValue, newValue := user.SyncOIDCIdentity(identity, role, permissions)
+		if len(newValue) == 0 {
+			return user, nil
+		}
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return nil, err
+		}
+		actor.ID = user.ID.Hex()
+		logAuditFailure(model.UserUpdated, s.auditLogService.LogUserUpdated(ctx, actor.ID, actor.Name, user, oldValue, newValue, client.IPAddress, client.UserAgent))
+		return user, nil
+	}
+
+	// Local accounts are never taken over by an identity provider user of the same name or email
+	if existing, err := s.userRepo.GetByUsername(ctx, identity.Username); err != nil {
+		return nil, err
+	} else if existing != nil {
+		return nil, errors.New("username already belongs to another account")
+	}
+	if existing, err := s.userRepo.GetByEmail(ctx, identity.Email); err != nil {
+		return nil, err
+	} else if existing != nil {
+		return nil, errors.New("email already belongs to another account")
+	}
+
+	user = model.NewOIDCUser(identity, role, permissions)
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	actor.ID = user.ID.Hex()
+	logAuditFailure(model.UserCreated, s.auditLogService.LogUserCreated(ctx, actor.ID, actor.Name, user, client.IPAddress, client.UserAgent))
+	return user, nil
+}
+
+// passwordLoginAllowed tells whether a user may sign in with a password. Once single sign-on is
+// enabled, only local admins may, unless local users are allowed too.
+func (s *AuthService) passwordLoginAllowed(user *model.User) error {
+	if user.IsSSO() {
+		return errors.New("user signs in with single sign-on")
+	}
+	if s.ssoConfig != nil && !s.ssoConfig.AllowLocalUsers && !user.IsAdmin() {
+		return errors.New("password sign-in is disabled; use single sign-on")
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/repository"
+	"github.com/phuhao00/cmdb/backend/infrastructure/logging"
+	"go.uber.org/zap"
+)
+
+// ErrSyncInProgress is returned when a cluster or cloud account is already being synced
+var ErrSyncInProgress = errors.New("a sync of this source is already running")
+
+// ciSyncer mirrors objects of external systems, such as Kubernetes clusters and cloud
+// accounts, into CIs. The CIs of a source share an external ID prefix.
+type ciSyncer struct {
+	assetRepo        repository.AssetRepository
+	relationshipRepo repository.RelationshipRepository
+	assetService     *AssetService
+	ciTypeService    *CITypeService
+}
+
+// ciSync tracks the CIs of one source during one sync
+type ciSync struct {
+	*ciSyncer
+	// source is recorded as the provenance of synced fields, reporter names the cluster or account
+	source   string
+	reporter string
+	reason   string
+	result   *model.SyncResult
+	existing map[string]*model.Asset
+	seen     map[string]bool
+}
+
+// begin loads the CIs whose external IDs start with prefix for a sync
+func (s *ciSyncer) begin(ctx context.Context, prefix, source, reporter, reason string, result *model.SyncResult) (*ciSync, error) {
+	existing, err := s.assetRepo.FindAll(ctx, map[string]interface{}{
+		"externalId": map[string]interface{}{"$regex": "^" + regexp.QuoteMeta(prefix)},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	byExternalID := make(map[string]*model.Asset, len(existing))
+	for _, asset := range existing {
+		byExternalID[asset.ExternalID] = asset
+	}
+
+	return &ciSync{
+		ciSyncer: s,
+		source:   source,
+		reporter: reporter,
+		reason:   reason,
+		result:   result,
+		existing: byExternalID,
+		seen:     make(map[string]bool),
+	}, nil
+}
+
+// active counts the CIs of the source that are still in service
+func (c *ciSync) active() int {
+	count := 0
+	for _, asset := range c.existing {
+		if !isRetired(asset.Status) {
+			count++
+		}
+	}
+	return count
+}
+
+// upsert creates or updates the CI with an external ID. apply sets the synced fields; a new
+// CI gets the type, location and description given here first, while the changes to an
+// existing CI are reconciled with the values of other sources.
+func (c *ciSync) upsert(ctx context.Context, externalID string, assetType model.AssetType, location, description string, apply func(asset *model.Asset)) (*model.Asset, error) {
+	c.seen[externalID] = true
+
+	asset, ok := c.existing[externalID]
+	if !ok {
+		asset = model.NewAsset("", assetType, firstNonEmpty(location, defaultAssetLocation), description)
+		asset.ExternalID = externalID
+		apply(asset)
+		asset.RecordFieldSources(&model.Asset{}, c.source, c.reporter)
+
+		if err := c.assetService.RegisterSyncedAsset(ctx, asset, c.source, "", c.reason); err != nil {
+			return nil, err
+		}
+		c.result.Created++
+		return asset, nil
+	}
+
+	oldAsset := asset.Clone()
+	apply(asset)
+	if err := c.assetService.ReconcileFields(ctx, oldAsset, asset, c.source, c.reporter); err != nil {
+		return nil, err
+	}
+	if _, err := c.ciTypeService.ValidateAsset(ctx, asset); err != nil {
+		return nil, err
+	}
+
+	if len(model.DiffAssets(oldAsset, asset)) == 0 {
+		c.result.Unchanged++
+		return asset, nil
+	}
+
+	asset.UpdatedAt = time.Now()
+	ctx = model.WithActor(ctx, model.Actor{Name: c.source})
+	if err := c.assetRepo.Save(model.WithAssetChange(ctx, model.AssetChange{Reason: c.reason}), asset); err != nil {
+		return nil, err
+	}
+	c.result.Updated++
+	return asset, nil
+}
+
+// decommissionMissing decommissions the CIs in service whose objects were not seen in this sync
+func (c *ciSync) decommissionMissing(ctx context.Context) error {
+	for externalID, asset := range c.existing {
+		if c.seen[externalID] || isRetired(asset.Status) {
+			continue
+		}
+
+		_, err := c.assetService.RecordStatus(ctx, asset.ID, model.DecommissionedStatus, c.source, "", c.reason+": object removed")
+		var lifecycleErr *model.LifecycleError
+		if errors.As(err, &lifecycleErr) {
+			logging.Logger.Warn("ci_sync_decommission_skipped",
+				zap.String("source", c.source),
+				zap.String("reporter", c.reporter),
+				zap.String("asset_id", asset.AssetID),
+				zap.Error(err))
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		c.result.Decommissioned++
+	}
+	return nil
+}
+
+// relate creates a relationship between two CIs unless it exists
+func (c *ciSync) relate(ctx context.Context, source, target *model.Asset, relationshipType model.RelationshipType, description string) error {
+	exists, err := c.relationshipRepo.Exists(ctx, source.ID, target.ID, relationshipType)
+	if err != nil || exists {
+		return err
+	}
+
+	relationship := model.NewRelationship(source.ID, target.ID, relationshipType, description, c.source, "")
+	if err := c.relationshipRepo.Save(ctx, relationship); err != nil {
+		return err
+	}
+	c.result.Relationships++
+	return nil
+}
+
+// isRetired checks whether an asset is out of service for good
+func isRetired(status model.AssetStatus) bool {
+	switch status {
+	case model.RetiredStatus, model.DecommissionedStatus, model.DisposedStatus:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/repository"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrUnknownCIType is returned for assets whose type is not defined
+var ErrUnknownCIType = errors.New("unknown CI type")
+
+// CITypeSpec defines the editable fields of a CI type
+type CITypeSpec struct {
+	Name        string
+	DisplayName string
+	Description string
+	Prefix      string
+	Attributes  []model.AttributeDefinition
+}
+
+// CITypeService manages CI types and validates assets against their schema
+type CITypeService struct {
+	ciTypeRepo repository.CITypeRepository
+	assetRepo  repository.AssetRepository
+}
+
+// NewCITypeService creates a new CI type service
+func NewCITypeService(ciTypeRepo repository.CITypeRepository, assetRepo repository.AssetRepository) *CITypeService {
+	return &CITypeService{
+		ciTypeRepo: ciTypeRepo,
+		assetRepo:  assetRepo,
+	}
+}
+
+// GetTypes gets the built-in and custom CI types; a stored definition of a built-in type replaces it
+func (s *CITypeService) GetTypes(ctx context.Context) ([]*model.CIType, error) {
+	stored, err := s.ciTypeRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[model.AssetType]*model.CIType)
+	for _, ciType := range model.BuiltInCITypes() {
+		byName[ciType.Name] = ciType
+	}
+	for _, ciType := range stored {
+		ciType.BuiltIn = isBuiltInCIType(ciType.Name)
+		byName[ciType.Name] = ciType
+	}
+
+	types := make([]*model.CIType, 0, len(byName))
+	for _, ciType := range byName {
+		types = append(types, ciType)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i].Name < types[j].Name })
+
+	return types, nil
+}
+
+// GetType gets a CI type by name
+func (s *CITypeService) GetType(ctx context.Context, name model.AssetType) (*model.CIType, error) {
+	ciType, err := s.ciTypeRepo.FindByName(ctx, name)
+	if err == nil {
+		ciType.BuiltIn = isBuiltInCIType(name)
+		return ciType, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	for _, builtIn := range model.BuiltInCITypes() {
+		if builtIn.Name == name {
+			return builtIn, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownCIType, name)
+}
+
+// CreateType defines a new CI type
+func (s *CITypeService) CreateType(ctx context.Context, spec CITypeSpec) (*model.CIType, error) {
+	ciType := model.NewCIType(model.AssetType(spec.Name), spec.DisplayName, spec.Description, spec.Prefix, spec.Attributes)
+	if err := validateCIType(ciType); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.GetType(ctx, ciType.Name); err == nil {
+		return nil, fmt.Errorf("CI type %s already exists", ciType.Name)
+	} else if !errors.Is(err, ErrUnknownCIType) {
+		return nil, err
+	}
+
+	if err := s.ciTypeRepo.Save(ctx, ciType); err != nil {
+		return nil, err
+	}
+
+	return ciType, nil
+}
+
+// UpdateType updates a CI type. The new schema applies to assets created or updated afterwards;
+// existing assets keep their attribute values.
+func (s *CITypeService) UpdateType(ctx context.Context, name model.AssetType, spec CITypeSpec) (*model.CIType, error) {
+	current, err := s.GetType(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	ciType := model.NewCIType(name, spec.DisplayName, spec.Description, spec.Prefix, spec.Attributes)
+	if err := validateCIType(ciType); err != nil {
+		return nil, err
+	}
+
+	// Built-in types are stored the first time they are redefined
+	if !current.ID.IsZero() {
+		ciType.ID = current.ID
+		ciType.CreatedAt = current.CreatedAt
+	}
+	ciType.UpdatedAt = time.Now()
+
+	if err := s.ciTypeRepo.Save(ctx, ciType); err != nil {
+		return nil, err
+	}
+
+	ciType.BuiltIn = current.BuiltIn
+	return ciType, nil
+}
+
+// DeleteType deletes a custom CI type that no asset uses. Deleting a redefined built-in type
+// restores its original schema.
+func (s *CITypeService) DeleteType(ctx context.Context, name model.AssetType) error {
+	ciType, err := s.GetType(ctx, name)
+	if err != nil {
+		return err
+	}
+	if ciType.ID.IsZero() {
+		return fmt.Errorf("built-in CI type %s cannot be deleted", name)
+	}
+
+	if !ciType.BuiltIn {
+		count, err := s.assetRepo.Count(ctx, map[string]interface{}{"type": string(name)})
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("CI type %s is used by %d assets", name, count)
+		}
+	}
+
+	return s.ciTypeRepo.DeleteByName(ctx, name)
+}
+
+// ValidateAsset checks an asset against the schema of its CI type, normalises its attribute
+// values and checks that referenced CIs exist. It returns the CI type for callers that need it.
+func (s *CITypeService) ValidateAsset(ctx context.Context, asset *model.Asset) (*model.CIType, error) {
+	ciType, err := s.GetType(ctx, asset.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	attributes, err := ciType.ValidateAttributes(asset.Attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	var violations []string
+	for _, attr := range ciType.Attributes {
+		value, ok := attributes[attr.Name].(string)
+		if attr.Type != model.AttributeReference || !ok {
+			continue
+		}
+
+		referenced, err := s.assetRepo.FindByAssetID(ctx, value)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			violations = append(violations, fmt.Sprintf("%s references unknown asset %s", attr.Name, value))
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if attr.ReferenceType != "" && referenced.Type != attr.ReferenceType {
+			violations = append(violations, fmt.Sprintf("%s must reference a %s, %s is a %s", attr.Name, attr.ReferenceType, value, referenced.Type))
+		}
+	}
+	if len(violations) > 0 {
+		return nil, &model.SchemaError{Type: ciType.Name, Violations: violations}
+	}
+
+	if len(attributes) == 0 {
+		attributes = nil
+	}
+	asset.Attributes = attributes
+	return ciType, nil
+}
+
+// validateCIType checks a CI type definition and fills in a prefix when none is given
+func validateCIType(ciType *model.CIType) error {
+	if err := ciType.Validate(); err != nil {
+		return err
+	}
+	if strings.TrimSpace(ciType.DisplayName) == "" {
+		return errors.New("display name is required")
+	}
+
+	ciType.Prefix = strings.ToUpper(strings.TrimSpace(ciType.Prefix))
+	if ciType.Prefix == "" {
+		prefix := strings.ToUpper(strings.ReplaceAll(string(ciType.Name), "_", ""))
+		if len(prefix) > 3 {
+			prefix = prefix[:3]
+		}
+		ciType.Prefix = prefix
+	}
+	for _, r := range ciType.Prefix {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return errors.New("prefix may only contain letters and digits")
+		}
+	}
+
+	return nil
+}
+
+// isBuiltInCIType checks whether a type is one of the built-in CI types
+func isBuiltInCIType(name model.AssetType) bool {
+	for _, builtIn := range model.BuiltInCITypes() {
+		if builtIn.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,387 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/repository"
+	"github.com/phuhao00/cmdb/backend/infrastructure/logging"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// ErrUnknownCloudProvider is returned for accounts of providers that are not registered
+var ErrUnknownCloudProvider = errors.New("unknown cloud provider")
+
+// CloudProvider discovers the resources of a cloud account
+type CloudProvider interface {
+	// Discover returns every instance, volume, load balancer and VPC of the account in its regions
+	Discover(ctx context.Context, account *model.CloudAccount) ([]model.CloudResource, error)
+}
+
+// CloudAccountSpec defines the editable fields of a cloud account
+type CloudAccountSpec struct {
+	Name            string
+	Description     string
+	Provider        string
+	Regions         []string
+	CredentialsFile string
+	Endpoint        string
+	FixturesDir     string
+	Prices          map[string]float64
+	Currency        string
+	TagRules        []model.TagRule
+	IntervalMinutes int
+	Enabled         bool
+}
+
+// CloudService syncs the resources of cloud accounts into CIs
+type CloudService struct {
+	ciSyncer
+	accountRepo repository.CloudAccountRepository
+	providers   map[string]CloudProvider
+
+	mu sync.Mutex
+	// syncing holds the accounts being synced
+	syncing map[primitive.ObjectID]bool
+}
+
+// NewCloudService creates a new cloud service
+func NewCloudService(accountRepo repository.CloudAccountRepository, assetRepo repository.AssetRepository, relationshipRepo repository.RelationshipRepository, assetService *AssetService, ciTypeService *CITypeService) *CloudService {
+	return &CloudService{
+		ciSyncer: ciSyncer{
+			assetRepo:        assetRepo,
+			relationshipRepo: relationshipRepo,
+			assetService:     assetService,
+			ciTypeService:    ciTypeService,
+		},
+		accountRepo: accountRepo,
+		providers:   make(map[string]CloudProvider),
+		syncing:     make(map[primitive.ObjectID]bool),
+	}
+}
+
+// RegisterProvider makes a provider available to accounts under a name
+func (s *CloudService) RegisterProvider(name string, provider CloudProvider) {
+	s.providers[name] = provider
+}
+
+// Providers returns the names of the registered providers
+func (s *CloudService) Providers() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CreateAccount creates a cloud account
+func (s *CloudService) CreateAccount(ctx context.Context, spec CloudAccountSpec, createdBy string) (*model.CloudAccount, error) {
+	if err := s.validateCloudAccountSpec(&spec); err != nil {
+		return nil, err
+	}
+
+	account := model.NewCloudAccount(spec.Name, spec.Provider, createdBy)
+	applyCloudAccountSpec(account, spec)
+
+	if err := s.accountRepo.Save(ctx, account); err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
+
+// UpdateAccount updates a cloud account. The provider of an account cannot change, since the
+// CIs synced from it are identified by the provider's resource IDs.
+func (s *CloudService) UpdateAccount(ctx context.Context, id primitive.ObjectID, spec CloudAccountSpec) (*model.CloudAccount, error) {
+	if err := s.validateCloudAccountSpec(&spec); err != nil {
+		return nil, err
+	}
+
+	account, err := s.accountRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if spec.Provider != account.Provider {
+		return nil, errors.New("the provider of an account cannot be changed")
+	}
+
+	account.Name = spec.Name
+	applyCloudAccountSpec(account, spec)
+	account.UpdatedAt = time.Now()
+
+	if err := s.accountRepo.Save(ctx, account); err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
+
+// DeleteAccount deletes a cloud account. The CIs synced from it are kept.
+func (s *CloudService) DeleteAccount(ctx context.Context, id primitive.ObjectID) error {
+	if _, err := s.accountRepo.FindByID(ctx, id); err != nil {
+		return err
+	}
+	return s.accountRepo.Delete(ctx, id)
+}
+
+// GetAccount gets a cloud account by ID
+func (s *CloudService) GetAccount(ctx context.Context, id primitive.ObjectID) (*model.CloudAccount, error) {
+	return s.accountRepo.FindByID(ctx, id)
+}
+
+// GetAccounts gets all cloud accounts
+func (s *CloudService) GetAccounts(ctx context.Context) ([]*model.CloudAccount, error) {
+	return s.accountRepo.FindAll(ctx, false)
+}
+
+// SyncAccount discovers the resources of an account and reconciles them with the account's
+// CIs, like SyncCluster does for Kubernetes. The result is stored on the account and returned
+// together with the error of a failed sync.
+func (s *CloudService) SyncAccount(ctx context.Context, id primitive.ObjectID, triggeredBy string) (*model.SyncResult, error) {
+	account, err := s.accountRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	provider, ok := s.providers[account.Provider]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCloudProvider, account.Provider)
+	}
+
+	s.mu.Lock()
+	if s.syncing[account.ID] {
+		s.mu.Unlock()
+		return nil, ErrSyncInProgress
+	}
+	s.syncing[account.ID] = true
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.syncing, account.ID)
+		s.mu.Unlock()
+	}()
+
+	result := &model.SyncResult{TriggeredBy: triggeredBy, StartedAt: time.Now()}
+	resources, syncErr := provider.Discover(ctx, account)
+	if syncErr == nil {
+		syncErr = s.reconcile(ctx, account, resources, result)
+	}
+	result.FinishedAt = time.Now()
+
+	if syncErr != nil {
+		result.Status = model.SyncFailed
+		result.Error = syncErr.Error()
+		logging.Logger.Error("cloud_sync_failed",
+			zap.String("account", account.Name),
+			zap.String("provider", account.Provider),
+			zap.Error(syncErr))
+	} else {
+		result.Status = model.SyncCompleted
+		logging.Logger.Info("cloud_sync_completed",
+			zap.String("account", account.Name),
+			zap.String("provider", account.Provider),
+			zap.Int("objects", result.Objects),
+			zap.Int("created", result.Created),
+			zap.Int("updated", result.Updated),
+			zap.Int("decommissioned", result.Decommissioned),
+			zap.Int("relationships", result.Relationships))
+	}
+
+	account.RecordSync(result)
+	if err := s.accountRepo.Save(ctx, account); err != nil {
+		return nil, err
+	}
+
+	return result, syncErr
+}
+
+// StartScheduler syncs due accounts periodically until the context is cancelled. Accounts
+// are synced one after another.
+func (s *CloudService) StartScheduler(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				s.syncDueAccounts(ctx, now)
+			}
+		}
+	}()
+}
+
+// syncDueAccounts syncs every enabled account whose interval has elapsed
+func (s *CloudService) syncDueAccounts(ctx context.Context, now time.Time) {
+	accounts, err := s.accountRepo.FindAll(ctx, true)
+	if err != nil {
+		logging.Logger.Error("cloud_schedule_failed", zap.Error(err))
+		return
+	}
+
+	for _, account := range accounts {
+		if ctx.Err() != nil {
+			return
+		}
+		if !account.IsDue(now) {
+			continue
+		}
+		// Failures are logged and recorded on the account by SyncAccount
+		_, _ = s.SyncAccount(ctx, account.ID, "scheduler")
+	}
+}
+
+// reconcile applies the discovered resources of an account to its CIs
+func (s *CloudService) reconcile(ctx context.Context, account *model.CloudAccount, resources []model.CloudResource, result *model.SyncResult) error {
+	result.Objects = len(resources)
+
+	run, err := s.begin(ctx, account.ExternalID(""), model.SourceCloud, account.Name, "Cloud sync of "+account.Name, result)
+	if err != nil {
+		return err
+	}
+	// An account that suddenly has nothing is more likely misconfigured than empty
+	if len(resources) == 0 && run.active() > 0 {
+		return errors.New("account returned no resources")
+	}
+	description := fmt.Sprintf("Synced from %s account %s", account.Provider, account.Name)
+
+	cis := make(map[string]*model.Asset, len(resources))
+	for i := range resources {
+		resource := &resources[i]
+		if resource.Kind.AssetType() == "" || resource.ID == "" || cis[resource.ID] != nil {
+			continue
+		}
+
+		attributes := make(map[string]interface{}, len(resource.Attributes)+6)
+		for name, value := range resource.Attributes {
+			attributes[name] = value
+		}
+		attributes["provider"] = account.Provider
+		attributes["account"] = account.Name
+		attributes["resourceId"] = resource.ID
+		attributes["region"] = resource.Region
+		attributes["zone"] = resource.Zone
+		attributes["state"] = resource.State
+		// Providers leave out what a resource does not have, e.g. a public IP
+		for name, value := range attributes {
+			if value == "" {
+				delete(attributes, name)
+			}
+		}
+
+		ci, err := run.upsert(ctx, account.ExternalID(resource.ID), resource.Kind.AssetType(), resource.Location(), description, func(asset *model.Asset) {
+			asset.Name = firstNonEmpty(resource.Name, resource.ID)
+			asset.IPAddress = resource.IPAddress
+			asset.Hostname = strings.ToLower(resource.Hostname)
+			asset.Location = firstNonEmpty(resource.Location(), asset.Location)
+			asset.AnnualCost = account.AnnualCost(resource)
+			asset.Currency = account.Currency
+			asset.Tags = model.ApplyTagRules(account.TagRules, resource.Tags)
+			asset.Attributes = attributes
+		})
+		if err != nil {
+			return fmt.Errorf("%s %s: %w", resource.Kind, resource.ID, err)
+		}
+		cis[resource.ID] = ci
+	}
+
+	if err := run.decommissionMissing(ctx); err != nil {
+		return err
+	}
+
+	relate := func(source, target *model.Asset, relationshipType model.RelationshipType) error {
+		return run.relate(ctx, source, target, relationshipType, fmt.Sprintf("Discovered in %s account %s", account.Provider, account.Name))
+	}
+	for i := range resources {
+		resource := &resources[i]
+		ci, ok := cis[resource.ID]
+		if !ok {
+			continue
+		}
+
+		if vpc, ok := cis[resource.VPCID]; ok && resource.Kind != model.KindVPC {
+			if err := relate(ci, vpc, model.MemberOfRelationship); err != nil {
+				return err
+			}
+		}
+		for _, instanceID := range resource.AttachedTo {
+			if instance, ok := cis[instanceID]; ok {
+				if err := relate(instance, ci, model.MountsRelationship); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateCloudAccountSpec checks an account spec and fills in its defaults
+func (s *CloudService) validateCloudAccountSpec(spec *CloudAccountSpec) error {
+	spec.Name = strings.TrimSpace(spec.Name)
+	if spec.Name == "" {
+		return errors.New("name is required")
+	}
+	if _, ok := s.providers[spec.Provider]; !ok {
+		return fmt.Errorf("%w: %q, expected one of %s", ErrUnknownCloudProvider, spec.Provider, strings.Join(s.Providers(), ", "))
+	}
+
+	regions := make([]string, 0, len(spec.Regions))
+	for _, region := range spec.Regions {
+		if region = strings.TrimSpace(region); region != "" {
+			regions = append(regions, region)
+		}
+	}
+	if len(regions) == 0 {
+		return errors.New("at least one region is required")
+	}
+	spec.Regions = regions
+
+	for key, price := range spec.Prices {
+		if price < 0 {
+			return fmt.Errorf("price of %s cannot be negative", key)
+		}
+	}
+	if spec.Currency == "" {
+		spec.Currency = "USD"
+	}
+	if spec.TagRules == nil {
+		spec.TagRules = model.DefaultTagRules
+	}
+	for _, rule := range spec.TagRules {
+		if rule.Key == "" || rule.Tag == "" {
+			return errors.New("tag rules need a key pattern and a tag")
+		}
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("tag rule %q: %w", rule.Key, err)
+		}
+	}
+	if spec.IntervalMinutes < 0 {
+		return errors.New("interval cannot be negative")
+	}
+	return nil
+}
+
+// applyCloudAccountSpec copies the optional fields of a spec to an account
+func applyCloudAccountSpec(account *model.CloudAccount, spec CloudAccountSpec) {
+	account.Description = spec.Description
+	account.Regions = spec.Regions
+	account.CredentialsFile = spec.CredentialsFile
+	account.Endpoint = spec.Endpoint
+	account.FixturesDir = spec.FixturesDir
+	account.Prices = spec.Prices
+	account.Currency = spec.Currency
+	account.TagRules = spec.TagRules
+	account.IntervalMinutes = spec.IntervalMinutes
+	account.Enabled = spec.Enabled
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type memoryCloudAccountRepository struct {
+	repository.CloudAccountRepository
+
+	mu       sync.Mutex
+	accounts map[primitive.ObjectID]*model.CloudAccount
+}
+
+func (r *memoryCloudAccountRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*model.CloudAccount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	account, ok := r.accounts[id]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	copied := *account
+	return &copied, nil
+}
+
+func (r *memoryCloudAccountRepository) Save(ctx context.Context, account *model.CloudAccount) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.accounts == nil {
+		r.accounts = make(map[primitive.ObjectID]*model.CloudAccount)
+	}
+	if account.ID.IsZero() {
+		account.ID = primitive.NewObjectID()
+	}
+	copied := *account
+	r.accounts[account.ID] = &copied
+	return nil
+}
+
+// scriptedCloudProvider returns whatever resources the test last gave it
+type scriptedCloudProvider struct {
+	resources []model.CloudResource
+}
+
+func (p *scriptedCloudProvider) Discover(ctx context.Context, account *model.CloudAccount) ([]model.CloudResource, error) {
+	return p.resources, nil
+}
+
+// webResources is an instance in a VPC behind a load balancer, with a volume attached
+func webResources() []model.CloudResource {
+	return []model.CloudResource{
+		{Kind: model.KindInstance, ID: "i-1", Name: "web-1", Region: "us-east-1", Zone: "us-east-1a", State: "running",
+			IPAddress: "10.1.0.10", Hostname: "IP-10-1-0-10.ec2.internal", Tags: map[string]string{"env": "prod"},
+			PriceKey: "t3.medium", VPCID: "vpc-1",
+			Attributes: map[string]interface{}{"instanceType": "t3.medium", "privateIp": "10.1.0.10", "publicIp": ""}},
+		{Kind: model.KindVolume, ID: "vol-1", Region: "us-east-1", Zone: "us-east-1a", State: "in-use",
+			PriceKey: "volume:gp3", Units: 4, AttachedTo: []string{"i-1"},
+			Attributes: map[string]interface{}{"sizeGiB": int64(4), "volumeType": "gp3", "attachedTo": "i-1"}},
+		{Kind: model.KindVPC, ID: "vpc-1", Name: "main", Region: "us-east-1", State: "available",
+			Attributes: map[string]interface{}{"cidr": "10.1.0.0/16"}},
+		{Kind: model.KindLoadBalancer, ID: "arn:lb/web", Name: "web", Region: "us-east-1", State: "active", VPCID: "vpc-1"},
+	}
+}
+
+func TestCloudSyncReconcilesResources(t *testing.T) {
+	assetRepo := newMemoryAssetRepository()
+	relationshipRepo := &memoryRelationshipRepository{}
+	accountRepo := &memoryCloudAccountRepository{}
+	provider := &scriptedCloudProvider{resources: webResources()}
+
+	assetService := newTestAssetService(assetRepo)
+	cloud := NewCloudService(accountRepo, assetRepo, relationshipRepo, assetService, assetService.ciTypeService)
+	cloud.RegisterProvider("aws", provider)
+	ctx := context.Background()
+	account, err := cloud.CreateAccount(ctx, CloudAccountSpec{
+		Name:     "prod",
+		Provider: "aws",
+		Regions:  []string{"us-east-1"},
+		Prices:   map[string]float64{"t3.medium": 0.125, "volume:gp3": 0.25},
+		Enabled:  true,
+	}, "admin")
+	if err != nil {
+		t.Fatalf("CreateAccount() error = %v", err)
+	}
+	ci := func(resourceID string) *model.Asset {
+		t.Helper()
+		asset := assetRepo.byExternalID(account.ExternalID(resourceID))
+		if asset == nil {
+			t.Fatalf("no CI for %q", resourceID)
+		}
+		return asset
+	}
+
+	result, err := cloud.SyncAccount(ctx, account.ID, "admin")
+	if err != nil {
+		t.Fatalf("SyncAccount() error = %v", err)
+	}
+	if result.Objects != 4 || result.Created != 4 || result.Relationships != 3 {
+		t.Errorf("first sync read %d resources, created %d CIs and %d relationships; want 4, 4 and 3",
+			result.Objects, result.Created, result.Relationships)
+	}
+
+	instance := ci("i-1")
+	wantAttributes := map[string]interface{}{
+		"instanceType": "t3.medium", "privateIp": "10.1.0.10",
+		"provider": "aws", "account": "prod", "resourceId": "i-1", "region": "us-east-1", "zone": "us-east-1a", "state": "running",
+	}
+	if instance.Name != "web-1" || instance.Type != model.CloudInstanceType || instance.Status != model.OnlineStatus ||
+		instance.Location != "us-east-1/us-east-1a" || instance.IPAddress != "10.1.0.10" || instance.Hostname != "ip-10-1-0-10.ec2.internal" {
+		t.Errorf("instance CI = %s %s %s at %s, %s %s", instance.Name, instance.Type, instance.Status, instance.Location, instance.IPAddress, instance.Hostname)
+	}
+	// Empty attributes, such as the missing public IP, are left out
+	if !reflect.DeepEqual(instance.Attributes, wantAttributes) {
+		t.Errorf("instance attributes = %v, want %v", instance.Attributes, wantAttributes)
+	}
+	if !reflect.DeepEqual(instance.Tags, []string{"env:prod"}) || instance.AnnualCost != 0.125*24*365 || instance.Currency != "USD" {
+		t.Errorf("instance tags %v and cost %v %s", instance.Tags, instance.AnnualCost, instance.Currency)
+	}
+	// The volume is priced per GiB, and resources without a name are named by their ID
+	if volume := ci("vol-1"); volume.Name != "vol-1" || volume.AnnualCost != 0.25*4*24*365 {
+		t.Errorf("volume CI %s costs %v", volume.Name, volume.AnnualCost)
+	}
+
+	relationships := []struct {
+		source, target   *model.Asset
+		relationshipType model.RelationshipType
+	}{
+		{ci("i-1"), ci("vpc-1"), model.MemberOfRelationship},
+		{ci("arn:lb/web"), ci("vpc-1"), model.MemberOfRelationship},
+		{ci("i-1"), ci("vol-1"), model.MountsRelationship},
+	}
+	for _, tt := range relationships {
+		if relationshipRepo.find(tt.source.ID, tt.target.ID, tt.relationshipType) == nil {
+			t.Errorf("no %s relationship from %s to %s", tt.relationshipType, tt.source.Name, tt.target.Name)
+		}
+	}
+
+	// The instance is terminated and the volume detached
+	resources := webResources()[1:]
+	resources[0].State = "available"
+	resources[0].AttachedTo = nil
+	provider.resources = resources
+	result, err = cloud.SyncAccount(ctx, account.ID, "scheduler")
+	if err != nil {
+		t.Fatalf("SyncAccount() error = %v", err)
+	}
+	if result.Decommissioned != 1 || result.Updated != 1 || result.Unchanged != 2 {
+		t.Errorf("second sync = %+v, want 1 decommissioned, 1 updated and 2 unchanged", result)
+	}
+	if instance := ci("i-1"); instance.Status != model.DecommissionedStatus {
+		t.Errorf("CI of the terminated instance is %s, want it kept as decommissioned", instance.Status)
+	}
+	if state := ci("vol-1").Attributes["state"]; state != "available" {
+		t.Errorf("volume state = %v after the sync", state)
+	}
+
+	// An account that suddenly returns nothing fails the sync instead of decommissioning everything
+	before := assetRepo.all()
+	provider.resources = nil
+	result, err = cloud.SyncAccount(ctx, account.ID, "scheduler")
+	if err == nil || result.Status != model.SyncFailed {
+		t.Fatalf("SyncAccount() of an empty account = %v, %v; want a failed sync", result, err)
+	}
+	for i, asset := range assetRepo.all() {
+		if asset.Status != before[i].Status {
+			t.Errorf("%s went from %s to %s after a failed sync", asset.Name, before[i].Status, asset.Status)
+		}
+	}
+
+	// Accounts of providers that are no longer registered cannot be synced
+	account.Provider = "gcp"
+	if err := accountRepo.Save(ctx, account); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cloud.SyncAccount(ctx, account.ID, "admin"); !errors.Is(err, ErrUnknownCloudProvider) {
+		t.Errorf("SyncAccount() of an unknown provider error = %v", err)
+	}
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+)
+
+// ErrUnknownReportFormat is returned when a scan report is in no registered format
+var ErrUnknownReportFormat = errors.New("unknown scan report format")
+
+// ScanReportParser reads the hosts from the output of an external scanner such as nmap
+type ScanReportParser interface {
+	// Detect reports whether the data looks like output of this format
+	Detect(data []byte) bool
+	// Parse returns the hosts that are up, with their open services
+	Parse(data []byte) ([]model.DiscoveredHost, error)
+}
+
+// ScanImportSpec controls the import of a scan report
+type ScanImportSpec struct {
+	// Format is a registered report format; it is detected when empty
+	Format string
+	// Location is given to assets created by the import
+	Location string
+	// DryRun reports what the import would do without saving anything
+	DryRun bool
+}
+
+// RegisterReportFormat makes a scan report format available for import
+func (s *DiscoveryService) RegisterReportFormat(format string, parser ScanReportParser) {
+	if _, exists := s.parsers[format]; !exists {
+		s.reportFormats = append(s.reportFormats, format)
+	}
+	s.parsers[format] = parser
+}
+
+// ReportFormats lists the scan report formats available for import
+func (s *DiscoveryService) ReportFormats() []string {
+	return append([]string(nil), s.reportFormats...)
+}
+
+// ImportScan merges the hosts of a scan report into assets. Hosts are matched to assets like
+// hosts found by discovery scans. Matched assets get a changed IP address, missing MAC
+// address and hostname, OS guess and open services; hosts without an asset become new assets
+// through BulkCreateAssets. Every change is recorded in the asset history with the format as
+// the change reason, e.g. "nmap import".
+func (s *DiscoveryService) ImportScan(ctx context.Context, data []byte, spec ScanImportSpec, requester, requesterID string) (*model.ScanImport, error) {
+	format, parser, err := s.reportParser(spec.Format, data)
+	if err != nil {
+		return nil, err
+	}
+	hosts, err := parser.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s report: %w", format, err)
+	}
+
+	reason := format + " import"
+	location := firstNonEmpty(spec.Location, defaultAssetLocation)
+	result := &model.ScanImport{Format: format, DryRun: spec.DryRun, Hosts: []model.ImportedHost{}}
+
+	var updates []*model.Asset
+	var newAssets []model.Asset
+	var newHosts []model.ImportedHost
+	seen := make(map[string]bool)
+
+	for _, host := range hosts {
+		if seen[host.IPAddress] {
+			continue
+		}
+		seen[host.IPAddress] = true
+
+		asset, matchedBy, err := s.matchAsset(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+
+		if asset == nil {
+			name := firstNonEmpty(host.Hostname, host.IPAddress)
+			newAsset := model.NewAsset(name, host.SuggestAssetType(), location, "Imported from "+format+" scan")
+			applyImportedHost(newAsset, host, "")
+			newAsset.RecordFieldSources(&model.Asset{}, model.SourceImport, format)
+			newAssets = append(newAssets, *newAsset)
+
+			newHosts = append(newHosts, model.ImportedHost{
+				IPAddress: host.IPAddress,
+				Outcome:   model.ImportCreated,
+				Name:      newAsset.Name,
+				Type:      newAsset.Type,
+				Changes:   model.DiffAssets(&model.Asset{}, newAsset),
+			})
+			continue
+		}
+
+		// A dry run shows what the precedence rules would let the import change without
+		// queueing conflicts
+		oldAsset := asset.Clone()
+		applyImportedHost(asset, host, matchedBy)
+		if spec.DryRun {
+			s.assetService.PreviewFields(oldAsset, asset, model.SourceImport)
+		} else if err := s.assetService.ReconcileFields(ctx, oldAsset, asset, model.SourceImport, format); err != nil {
+			return nil, err
+		}
+		changes := model.DiffAssets(oldAsset, asset)
+
+		imported := model.ImportedHost{
+			IPAddress: host.IPAddress,
+			Outcome:   model.ImportUnchanged,
+			AssetID:   asset.AssetID,
+			Name:      asset.Name,
+			Type:      asset.Type,
+		}
+		if len(changes) > 0 {
+			imported.Outcome = model.ImportUpdated
+			imported.Changes = changes
+			updates = append(updates, asset)
+		}
+		result.Add(imported)
+	}
+
+	if spec.DryRun {
+		for _, host := range newHosts {
+			result.Add(host)
+		}
+		return result, nil
+	}
+
+	// The asset history records every write as made by the requester, with the format as the reason
+	ctx = model.WithActor(ctx, model.Actor{ID: requesterID, Name: requester})
+	ctx = model.WithAssetChange(ctx, model.AssetChange{Reason: reason})
+
+	// Create new assets first: BulkCreateAssets rejects the whole batch when one is invalid,
+	// and nothing should be saved then
+	if len(newAssets) > 0 {
+		if _, err := s.assetService.BulkCreateAssets(ctx, newAssets); err != nil {
+			return nil, err
+		}
+	}
+	for i := range newAssets {
+		host := newHosts[i]
+		if newAssets[i].AssetID == "" {
+			host.Outcome = model.ImportFailed
+			result.Add(host)
+			continue
+		}
+		host.AssetID = newAssets[i].AssetID
+		result.Add(host)
+	}
+
+	for _, asset := range updates {
+		if err := s.assetRepo.Save(ctx, asset); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// reportParser finds the parser of a format, or detects the format of the data
+func (s *DiscoveryService) reportParser(format string, data []byte) (string, ScanReportParser, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format != "" {
+		parser, ok := s.parsers[format]
+		if !ok {
+			return "", nil, fmt.Errorf("%w: %s", ErrUnknownReportFormat, format)
+		}
+		return format, parser, nil
+	}
+
+	for _, name := range s.reportFormats {
+		if s.parsers[name].Detect(data) {
+			return name, s.parsers[name], nil
+		}
+	}
+	return "", nil, ErrUnknownReportFormat
+}
+
+// applyImportedHost copies what a scan found out about a host to its asset. The changed fields
+// are reconciled with the values of other sources afterwards.
+func applyImportedHost(asset *model.Asset, host model.DiscoveredHost, matchedBy string) {
+	changed := false
+	set := func(current *string, value string) {
+		if value == "" || *current == value {
+			return
+		}
+		*current = value
+		changed = true
+	}
+
+	// Hosts matched by MAC or hostname may have moved to another address
+	if matchedBy != "ipAddress" {
+		set(&asset.IPAddress, host.IPAddress)
+	}
+	if asset.MACAddress == "" {
+		set(&asset.MACAddress, host.MACAddress)
+	}
+	if asset.Hostname == "" {
+		set(&asset.Hostname, host.Hostname)
+	}
+	set(&asset.OSGuess, host.OS)
+
+	if len(host.Services) > 0 {
+		services := append([]model.DiscoveredService(nil), host.Services...)
+		sort.Slice(services, func(i, j int) bool { return services[i].Port < services[j].Port })
+		if !equalServices(asset.Services, services) {
+			asset.Services = services
+			changed = true
+		}
+	}
+
+	if changed {
+		asset.UpdatedAt = time.Now()
+	}
+}
+
+// equalServices compares two service lists
+func equalServices(a, b []model.DiscoveredService) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,638 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/repository"
+	"github.com/phuhao00/cmdb/backend/infrastructure/logging"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// discoveryRequester is recorded as the requester of status changes made by discovery
+const discoveryRequester = "discovery"
+
+// Discovery range defaults
+const (
+	defaultDiscoveryRateLimit   = 100
+	defaultDiscoveryConcurrency = 32
+	defaultDiscoveryTimeout     = 1000
+)
+
+// ErrScanInProgress is returned when a range is already being scanned
+var ErrScanInProgress = errors.New("a scan of this range is already running")
+
+// ErrScanNotRunning is returned when cancelling a scan that is not running
+var ErrScanNotRunning = errors.New("scan is not running")
+
+// ScanRequest describes the probes a scanner should send
+type ScanRequest struct {
+	Addresses   []string
+	Ports       []int
+	ICMP        bool
+	RateLimit   int
+	Concurrency int
+	Timeout     time.Duration
+}
+
+// HostScanner probes addresses and reports the hosts that answer
+type HostScanner interface {
+	// Scan probes every address until done or the context is cancelled. found is called
+	// once per responding host, never concurrently.
+	Scan(ctx context.Context, request ScanRequest, found func(host model.DiscoveredHost)) error
+}
+
+// DiscoveryRangeSpec defines the editable fields of a discovery range
+type DiscoveryRangeSpec struct {
+	Name            string
+	Description     string
+	CIDRs           []string
+	Ports           []int
+	Location        string
+	ICMP            bool
+	IntervalMinutes int
+	RateLimit       int
+	Concurrency     int
+	TimeoutMillis   int
+	Enabled         bool
+}
+
+// CandidateApprovalSpec overrides what the asset created from a candidate looks like
+type CandidateApprovalSpec struct {
+	Name        string
+	Type        string
+	Location    string
+	Description string
+}
+
+// DiscoveryService sweeps discovery ranges and reconciles the hosts found with assets
+type DiscoveryService struct {
+	discoveryRepo repository.DiscoveryRepository
+	assetRepo     repository.AssetRepository
+	assetService  *AssetService
+	scanner       HostScanner
+
+	// reportFormats lists the registered scan report formats in registration order
+	reportFormats []string
+	parsers       map[string]ScanReportParser
+
+	mu sync.Mutex
+	// running maps the ID of each running scan to its cancel function; scanning maps ranges to their run
+	running  map[primitive.ObjectID]context.CancelFunc
+	scanning map[primitive.ObjectID]primitive.ObjectID
+}
+
+// NewDiscoveryService creates a new discovery service
+func NewDiscoveryService(discoveryRepo repository.DiscoveryRepository, assetRepo repository.AssetRepository, assetService *AssetService, scanner HostScanner) *DiscoveryService {
+	return &DiscoveryService{
+		discoveryRepo: discoveryRepo,
+		assetRepo:     assetRepo,
+		assetService:  assetService,
+		scanner:       scanner,
+		parsers:       make(map[string]ScanReportParser),
+		running:       make(map[primitive.ObjectID]context.CancelFunc),
+		scanning:      make(map[primitive.ObjectID]primitive.ObjectID),
+	}
+}
+
+// CreateRange creates a discovery range
+func (s *DiscoveryService) CreateRange(ctx context.Context, spec DiscoveryRangeSpec, createdBy string) (*model.DiscoveryRange, error) {
+	if err := validateDiscoveryRangeSpec(&spec); err != nil {
+		return nil, err
+	}
+
+	rng := model.NewDiscoveryRange(spec.Name, spec.CIDRs, createdBy)
+	applyDiscoveryRangeSpec(rng, spec)
+
+	if err := s.discoveryRepo.SaveRange(ctx, rng); err != nil {
+		return nil, err
+	}
+
+	return rng, nil
+}
+
+// UpdateRange updates a discovery range
+func (s *DiscoveryService) UpdateRange(ctx context.Context, id primitive.ObjectID, spec DiscoveryRangeSpec) (*model.DiscoveryRange, error) {
+	if err := validateDiscoveryRangeSpec(&spec); err != nil {
+		return nil, err
+	}
+
+	rng, err := s.discoveryRepo.FindRangeByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	rng.Name = spec.Name
+	rng.CIDRs = spec.CIDRs
+	applyDiscoveryRangeSpec(rng, spec)
+	rng.UpdatedAt = time.Now()
+
+	if err := s.discoveryRepo.SaveRange(ctx, rng); err != nil {
+		return nil, err
+	}
+
+	return rng, nil
+}
+
+// DeleteRange cancels any running scan of a range and deletes it
+func (s *DiscoveryService) DeleteRange(ctx context.Context, id primitive.ObjectID) error {
+	if _, err := s.discoveryRepo.FindRangeByID(ctx, id); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	if runID, ok := s.scanning[id]; ok {
+		s.running[runID]()
+	}
+	s.mu.Unlock()
+
+	return s.discoveryRepo.DeleteRange(ctx, id)
+}
+
+// GetRange gets a discovery range by ID
+func (s *DiscoveryService) GetRange(ctx context.Context, id primitive.ObjectID) (*model.DiscoveryRange, error) {
+	return s.discoveryRepo.FindRangeByID(ctx, id)
+}
+
+// GetRanges gets all discovery ranges
+func (s *DiscoveryService) GetRanges(ctx context.Context) ([]*model.DiscoveryRange, error) {
+	return s.discoveryRepo.FindRanges(ctx, false)
+}
+
+// GetRuns gets the most recent scan runs with optional filtering
+func (s *DiscoveryService) GetRuns(ctx context.Context, filter map[string]interface{}, limit int64) ([]*model.ScanRun, error) {
+	return s.discoveryRepo.FindRuns(ctx, filter, limit)
+}
+
+// GetRun gets a scan run by ID
+func (s *DiscoveryService) GetRun(ctx context.Context, id primitive.ObjectID) (*model.ScanRun, error) {
+	return s.discoveryRepo.FindRunByID(ctx, id)
+}
+
+// GetCandidates gets discovery candidates with optional filtering
+func (s *DiscoveryService) GetCandidates(ctx context.Context, filter map[string]interface{}) ([]*model.DiscoveryCandidate, error) {
+	return s.discoveryRepo.FindCandidates(ctx, filter)
+}
+
+// StartScan starts a scan of a range in the background and returns the running scan
+func (s *DiscoveryService) StartScan(ctx context.Context, rangeID primitive.ObjectID, triggeredBy string) (*model.ScanRun, error) {
+	rng, err := s.discoveryRepo.FindRangeByID(ctx, rangeID)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses, err := rng.Addresses()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.scanning[rng.ID]; ok {
+		return nil, ErrScanInProgress
+	}
+
+	run := model.NewScanRun(rng, triggeredBy)
+	run.Addresses = len(addresses)
+	if err := s.discoveryRepo.SaveRun(ctx, run); err != nil {
+		return nil, err
+	}
+
+	now := run.StartedAt
+	rng.LastRunAt = &now
+	if err := s.discoveryRepo.SaveRange(ctx, rng); err != nil {
+		return nil, err
+	}
+
+	// The scan outlives the request that started it
+	scanCtx, cancel := context.WithCancel(context.Background())
+	s.running[run.ID] = cancel
+	s.scanning[rng.ID] = run.ID
+
+	go s.executeScan(scanCtx, rng, run, addresses)
+
+	return run, nil
+}
+
+// CancelScan cancels a running scan. The scan stops after its in-flight probes and is
+// recorded as cancelled.
+func (s *DiscoveryService) CancelScan(ctx context.Context, runID primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cancel, ok := s.running[runID]
+	if !ok {
+		return ErrScanNotRunning
+	}
+	cancel()
+	return nil
+}
+
+// StartScheduler starts the scans of due ranges periodically until the context is cancelled.
+// Runs left running by a previous process are marked as failed first.
+func (s *DiscoveryService) StartScheduler(ctx context.Context, interval time.Duration) {
+	if count, err := s.discoveryRepo.FailRunningRuns(ctx, "interrupted by restart"); err != nil {
+		logging.Logger.Error("discovery_interrupted_runs_failed", zap.Error(err))
+	} else if count > 0 {
+		logging.Logger.Warn("discovery_interrupted_runs", zap.Int64("count", count))
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				s.cancelAll()
+				return
+			case now := <-ticker.C:
+				s.startDueScans(ctx, now)
+			}
+		}
+	}()
+}
+
+// startDueScans starts the scans of every enabled range whose interval has elapsed
+func (s *DiscoveryService) startDueScans(ctx context.Context, now time.Time) {
+	ranges, err := s.discoveryRepo.FindRanges(ctx, true)
+	if err != nil {
+		logging.Logger.Error("discovery_schedule_failed", zap.Error(err))
+		return
+	}
+
+	for _, rng := range ranges {
+		if !rng.IsDue(now) {
+			continue
+		}
+		if _, err := s.StartScan(ctx, rng.ID, "scheduler"); err != nil && !errors.Is(err, ErrScanInProgress) {
+			logging.Logger.Error("discovery_scheduled_scan_failed",
+				zap.String("range", rng.Name),
+				zap.Error(err))
+		}
+	}
+}
+
+// cancelAll cancels every running scan
+func (s *DiscoveryService) cancelAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, cancel := range s.running {
+		cancel()
+	}
+}
+
+// executeScan runs a scan to completion, reconciling each host as it is found. Assets in
+// the range that did not answer are only marked offline when the scan completed.
+func (s *DiscoveryService) executeScan(ctx context.Context, rng *model.DiscoveryRange, run *model.ScanRun, addresses []string) {
+	defer func() {
+		s.mu.Lock()
+		s.running[run.ID]()
+		delete(s.running, run.ID)
+		delete(s.scanning, rng.ID)
+		s.mu.Unlock()
+	}()
+
+	logging.Logger.Info("discovery_scan_started",
+		zap.String("run_id", run.ID.Hex()),
+		zap.String("range", rng.Name),
+		zap.Int("addresses", len(addresses)))
+
+	// Scans run in the background, so their changes are the discovery's own
+	ctx = model.WithActor(ctx, model.Actor{Name: discoveryRequester})
+	ctx = model.WithAssetChange(ctx, model.AssetChange{Reason: "Discovery scan of " + rng.Name})
+
+	seen := make(map[string]bool)
+	err := s.scanner.Scan(ctx, ScanRequest{
+		Addresses:   addresses,
+		Ports:       rng.Ports,
+		ICMP:        rng.ICMP,
+		RateLimit:   rng.RateLimit,
+		Concurrency: rng.Concurrency,
+		Timeout:     time.Duration(rng.TimeoutMillis) * time.Millisecond,
+	}, func(host model.DiscoveredHost) {
+		seen[host.IPAddress] = true
+		run.HostsUp++
+		s.reconcileHost(ctx, rng, run, host)
+	})
+
+	switch {
+	case ctx.Err() != nil:
+		run.Finish(model.ScanCancelled, nil)
+	case err != nil:
+		run.Finish(model.ScanFailed, err)
+	default:
+		s.markUnreachable(ctx, rng, run, seen)
+		run.Finish(model.ScanCompleted, nil)
+	}
+
+	// The scan context may be cancelled; the outcome is still recorded
+	if err := s.discoveryRepo.SaveRun(context.Background(), run); err != nil {
+		logging.Logger.Error("discovery_run_save_failed", zap.String("run_id", run.ID.Hex()), zap.Error(err))
+	}
+
+	logging.Logger.Info("discovery_scan_finished",
+		zap.String("run_id", run.ID.Hex()),
+		zap.String("range", rng.Name),
+		zap.String("status", string(run.Status)),
+		zap.Int("hosts_up", run.HostsUp),
+		zap.Int("matched", run.Matched),
+		zap.Int("status_changes", run.StatusChanges),
+		zap.Int("candidates", run.Candidates))
+}
+
+// reconcileHost updates the asset a host belongs to, or queues the host for review
+func (s *DiscoveryService) reconcileHost(ctx context.Context, rng *model.DiscoveryRange, run *model.ScanRun, host model.DiscoveredHost) {
+	asset, matchedBy, err := s.matchAsset(ctx, host)
+	if err != nil {
+		logging.Logger.Error("discovery_match_failed", zap.String("ip_address", host.IPAddress), zap.Error(err))
+		return
+	}
+
+	if asset == nil {
+		if err := s.queueCandidate(ctx, rng, run, host); err != nil {
+			logging.Logger.Error("discovery_candidate_save_failed", zap.String("ip_address", host.IPAddress), zap.Error(err))
+		}
+		return
+	}
+	run.Matched++
+
+	// Hosts matched by MAC or hostname may have moved to another address
+	oldAsset := asset.Clone()
+	if matchedBy != "ipAddress" {
+		asset.IPAddress = host.IPAddress
+	}
+	if asset.MACAddress == "" && host.MACAddress != "" {
+		asset.MACAddress = host.MACAddress
+	}
+	if asset.Hostname == "" && host.Hostname != "" {
+		asset.Hostname = host.Hostname
+	}
+	if err := s.assetService.ReconcileFields(ctx, oldAsset, asset, model.SourceDiscovery, rng.Name); err != nil {
+		logging.Logger.Error("discovery_reconcile_failed", zap.String("asset_id", asset.AssetID), zap.Error(err))
+		return
+	}
+	asset.UpdateLastScanned()
+
+	if err := s.assetRepo.Save(ctx, asset); err != nil {
+		logging.Logger.Error("discovery_asset_save_failed", zap.String("asset_id", asset.AssetID), zap.Error(err))
+		return
+	}
+
+	s.setReachability(ctx, run, asset, model.OnlineStatus)
+}
+
+// markUnreachable marks online assets in the range that did not answer as offline
+func (s *DiscoveryService) markUnreachable(ctx context.Context, rng *model.DiscoveryRange, run *model.ScanRun, seen map[string]bool) {
+	assets, err := s.assetRepo.FindAll(ctx, map[string]interface{}{"status": string(model.OnlineStatus)})
+	if err != nil {
+		logging.Logger.Error("discovery_unreachable_lookup_failed", zap.Error(err))
+		return
+	}
+
+	for _, asset := range assets {
+		if asset.IPAddress == "" || seen[asset.IPAddress] || !rng.Contains(asset.IPAddress) {
+			continue
+		}
+
+		asset.UpdateLastScanned()
+		if err := s.assetRepo.Save(ctx, asset); err != nil {
+			logging.Logger.Error("discovery_asset_save_failed", zap.String("asset_id", asset.AssetID), zap.Error(err))
+			continue
+		}
+		s.setReachability(ctx, run, asset, model.OfflineStatus)
+	}
+}
+
+// setReachability flips an asset between online and offline. Other statuses, such as
+// maintenance, are left alone, as are transitions the lifecycle forbids or gates behind approval.
+func (s *DiscoveryService) setReachability(ctx context.Context, run *model.ScanRun, asset *model.Asset, target model.AssetStatus) {
+	from := model.OfflineStatus
+	if target == model.OfflineStatus {
+		from = model.OnlineStatus
+	}
+	if asset.Status != from {
+		return
+	}
+
+	transition, err := s.assetService.GetLifecycle().CheckTransition(asset, target)
+	if err != nil || transition.RequiresApproval {
+		logging.Logger.Debug("discovery_status_unchanged",
+			zap.String("asset_id", asset.AssetID),
+			zap.String("status", string(asset.Status)),
+			zap.String("target", string(target)),
+			zap.Error(err))
+		return
+	}
+
+	reason := fmt.Sprintf("Host %s answered discovery scan of %s", asset.IPAddress, run.RangeName)
+	if target == model.OfflineStatus {
+		reason = fmt.Sprintf("Host %s did not answer discovery scan of %s", asset.IPAddress, run.RangeName)
+	}
+
+	if _, _, err := s.assetService.ChangeStatus(ctx, asset.ID, target, nil, discoveryRequester, "", reason); err != nil {
+		logging.Logger.Error("discovery_status_change_failed", zap.String("asset_id", asset.AssetID), zap.Error(err))
+		return
+	}
+	run.StatusChanges++
+}
+
+// matchAsset finds the asset a host belongs to by MAC address, then IP address, then
+// hostname. Retired, decommissioned and disposed assets are not matched so that reused
+// addresses are not attributed to them.
+func (s *DiscoveryService) matchAsset(ctx context.Context, host model.DiscoveredHost) (*model.Asset, string, error) {
+	active := activeStatusFilter()
+
+	keys := []struct{ field, value string }{
+		{"macAddress", host.MACAddress},
+		{"ipAddress", host.IPAddress},
+		{"hostname", host.Hostname},
+	}
+	for _, key := range keys {
+		if key.value == "" {
+			continue
+		}
+		assets, err := s.assetRepo.FindAll(ctx, map[string]interface{}{key.field: key.value, "status": active})
+		if err != nil {
+			return nil, "", err
+		}
+		if len(assets) > 0 {
+			return assets[0], key.field, nil
+		}
+	}
+
+	return nil, "", nil
+}
+
+// activeStatusFilter matches assets still in service, which are the only ones hosts are matched to
+func activeStatusFilter() map[string]interface{} {
+	return map[string]interface{}{"$nin": []string{
+		string(model.RetiredStatus),
+		string(model.DecommissionedStatus),
+		string(model.DisposedStatus),
+	}}
+}
+
+// queueCandidate records an unknown host for review. A host seen before updates its
+// existing candidate, which keeps its review status.
+func (s *DiscoveryService) queueCandidate(ctx context.Context, rng *model.DiscoveryRange, run *model.ScanRun, host model.DiscoveredHost) error {
+	candidate, err := s.discoveryRepo.FindCandidateByAddress(ctx, host.IPAddress, host.MACAddress)
+	if err != nil {
+		return err
+	}
+
+	if candidate == nil {
+		candidate = model.NewDiscoveryCandidate(host, rng)
+		run.Candidates++
+	} else {
+		candidate.Observe(host)
+		// The asset created from this candidate no longer matches, so it needs review again
+		if candidate.Status == model.CandidateApproved {
+			candidate.Status = model.CandidatePending
+			run.Candidates++
+		}
+	}
+
+	return s.discoveryRepo.SaveCandidate(ctx, candidate)
+}
+
+// ApproveCandidate turns a candidate into an asset. The name defaults to the hostname or IP
+// address, the type to the suggested type and the location to that of the range.
+func (s *DiscoveryService) ApproveCandidate(ctx context.Context, id primitive.ObjectID, spec CandidateApprovalSpec, reviewer string) (*model.Asset, error) {
+	candidate, err := s.discoveryRepo.FindCandidateByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if candidate.Status == model.CandidateApproved {
+		return nil, model.ErrCandidateReviewed
+	}
+
+	name := firstNonEmpty(spec.Name, candidate.Hostname, candidate.IPAddress)
+	assetType := firstNonEmpty(spec.Type, string(candidate.SuggestedType))
+	location := firstNonEmpty(spec.Location, candidate.Location)
+	description := firstNonEmpty(spec.Description, "Discovered by network scan of "+candidate.RangeName)
+
+	asset, _, err := s.assetService.CreateAsset(ctx, name, assetType, location, description, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	asset.IPAddress = candidate.IPAddress
+	asset.MACAddress = candidate.MACAddress
+	asset.Hostname = candidate.Hostname
+	asset.UpdateLastScanned()
+	if err := s.assetRepo.Save(ctx, asset); err != nil {
+		return nil, err
+	}
+
+	candidate.Approve(reviewer, asset.AssetID)
+	if err := s.discoveryRepo.SaveCandidate(ctx, candidate); err != nil {
+		return nil, err
+	}
+
+	return asset, nil
+}
+
+// IgnoreCandidate marks a candidate as not worth tracking
+func (s *DiscoveryService) IgnoreCandidate(ctx context.Context, id primitive.ObjectID, reviewer string) (*model.DiscoveryCandidate, error) {
+	candidate, err := s.discoveryRepo.FindCandidateByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if candidate.Status == model.CandidateApproved {
+		return nil, model.ErrCandidateReviewed
+	}
+
+	candidate.Ignore(reviewer)
+	if err := s.discoveryRepo.SaveCandidate(ctx, candidate); err != nil {
+		return nil, err
+	}
+
+	return candidate, nil
+}
+
+// validateDiscoveryRangeSpec checks a range and fills in default ports and limits
+func validateDiscoveryRangeSpec(spec *DiscoveryRangeSpec) error {
+	if strings.TrimSpace(spec.Name) == "" {
+		return errors.New("name is required")
+	}
+	if len(spec.CIDRs) == 0 {
+		return errors.New("at least one CIDR is required")
+	}
+	if _, err := (&model.DiscoveryRange{CIDRs: spec.CIDRs}).Addresses(); err != nil {
+		return err
+	}
+
+	if len(spec.Ports) == 0 {
+		spec.Ports = model.DefaultDiscoveryPorts
+	}
+	seen := make(map[int]bool)
+	ports := make([]int, 0, len(spec.Ports))
+	for _, port := range spec.Ports {
+		if port < 1 || port > 65535 {
+			return fmt.Errorf("invalid port %d", port)
+		}
+		if !seen[port] {
+			seen[port] = true
+			ports = append(ports, port)
+		}
+	}
+	sort.Ints(ports)
+	spec.Ports = ports
+
+	if spec.IntervalMinutes < 0 {
+		return errors.New("interval cannot be negative")
+	}
+	if spec.RateLimit == 0 {
+		spec.RateLimit = defaultDiscoveryRateLimit
+	}
+	if spec.RateLimit < 1 || spec.RateLimit > 10000 {
+		return errors.New("rate limit must be between 1 and 10000 probes per second")
+	}
+	if spec.Concurrency == 0 {
+		spec.Concurrency = defaultDiscoveryConcurrency
+	}
+	if spec.Concurrency < 1 || spec.Concurrency > 1024 {
+		return errors.New("concurrency must be between 1 and 1024")
+	}
+	if spec.TimeoutMillis == 0 {
+		spec.TimeoutMillis = defaultDiscoveryTimeout
+	}
+	if spec.TimeoutMillis < 50 || spec.TimeoutMillis > 30000 {
+		return errors.New("timeout must be between 50 and 30000 milliseconds")
+	}
+
+	return nil
+}
+
+// applyDiscoveryRangeSpec copies the settings of a validated spec onto a range
+func applyDiscoveryRangeSpec(rng *model.DiscoveryRange, spec DiscoveryRangeSpec) {
+	rng.Description = spec.Description
+	rng.Ports = spec.Ports
+	rng.Location = spec.Location
+	rng.ICMP = spec.ICMP
+	rng.IntervalMinutes = spec.IntervalMinutes
+	rng.RateLimit = spec.RateLimit
+	rng.Concurrency = spec.Concurrency
+	rng.TimeoutMillis = spec.TimeoutMillis
+	rng.Enabled = spec.Enabled
+}
+
+// firstNonEmpty returns the first value that is not blank
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return value
+		}
+	}
+	return ""
+}
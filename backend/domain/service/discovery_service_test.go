@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type memoryDiscoveryRepository struct {
+	repository.DiscoveryRepository
+
+	mu         sync.Mutex
+	ranges     map[primitive.ObjectID]*model.DiscoveryRange
+	candidates []*model.DiscoveryCandidate
+	// finished receives each run once it is no longer running
+	finished chan model.ScanRun
+}
+
+func newMemoryDiscoveryRepository() *memoryDiscoveryRepository {
+	return &memoryDiscoveryRepository{
+		ranges:   make(map[primitive.ObjectID]*model.DiscoveryRange),
+		finished: make(chan model.ScanRun, 1),
+	}
+}
+
+func (r *memoryDiscoveryRepository) FindRangeByID(ctx context.Context, id primitive.ObjectID) (*model.DiscoveryRange, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rng, ok := r.ranges[id]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	copied := *rng
+	return &copied, nil
+}
+
+func (r *memoryDiscoveryRepository) SaveRange(ctx context.Context, rng *model.DiscoveryRange) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if rng.ID.IsZero() {
+		rng.ID = primitive.NewObjectID()
+	}
+	copied := *rng
+	r.ranges[rng.ID] = &copied
+	return nil
+}
+
+func (r *memoryDiscoveryRepository) SaveRun(ctx context.Context, run *model.ScanRun) error {
+	if run.ID.IsZero() {
+		run.ID = primitive.NewObjectID()
+	}
+	if run.Status != model.ScanRunning {
+		r.finished <- *run
+	}
+	return nil
+}
+
+func (r *memoryDiscoveryRepository) FindCandidateByAddress(ctx context.Context, ipAddress, macAddress string) (*model.DiscoveryCandidate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, candidate := range r.candidates {
+		if (macAddress != "" && candidate.MACAddress == macAddress) || candidate.IPAddress == ipAddress {
+			copied := *candidate
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryDiscoveryRepository) SaveCandidate(ctx context.Context, candidate *model.DiscoveryCandidate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *candidate
+	if candidate.ID.IsZero() {
+		candidate.ID = primitive.NewObjectID()
+		copied.ID = candidate.ID
+		r.candidates = append(r.candidates, &copied)
+		return nil
+	}
+	for i := range r.candidates {
+		if r.candidates[i].ID == candidate.ID {
+			r.candidates[i] = &copied
+		}
+	}
+	return nil
+}
+
+// scriptedScanner reports the hosts it was given that are among the addresses scanned
+type scriptedScanner struct {
+	hosts []model.DiscoveredHost
+}
+
+func (s *scriptedScanner) Scan(ctx context.Context, request ScanRequest, found func(host model.DiscoveredHost)) error {
+	addresses := make(map[string]bool, len(request.Addresses))
+	for _, address := range request.Addresses {
+		addresses[address] = true
+	}
+	for _, host := range s.hosts {
+		if addresses[host.IPAddress] {
+			found(host)
+		}
+	}
+	return nil
+}
+
+func newDiscoveredAsset(name string, status model.AssetStatus, apply func(asset *model.Asset)) *model.Asset {
+	asset := model.NewAsset(name, model.ServerType, "Office", "")
+	asset.Status = status
+	apply(asset)
+	return asset
+}
+
+func TestDiscoveryScanReconcilesHosts(t *testing.T) {
+	web := newDiscoveredAsset("web", model.OfflineStatus, func(a *model.Asset) { a.IPAddress = "10.0.0.1" })
+	db := newDiscoveredAsset("db", model.OnlineStatus, func(a *model.Asset) {
+		a.IPAddress = "10.0.0.9"
+		a.MACAddress = "aa:bb:cc:dd:ee:02"
+	})
+	printer := newDiscoveredAsset("printer", model.OnlineStatus, func(a *model.Asset) { a.IPAddress = "10.0.0.3" })
+	nas := newDiscoveredAsset("nas", model.OnlineStatus, func(a *model.Asset) { a.Hostname = "nas" })
+	retired := newDiscoveredAsset("old", model.RetiredStatus, func(a *model.Asset) { a.IPAddress = "10.0.0.4" })
+	outside := newDiscoveredAsset("remote", model.OnlineStatus, func(a *model.Asset) { a.IPAddress = "10.0.1.1" })
+	assetRepo := newMemoryAssetRepository(web, db, printer, nas, retired, outside)
+
+	scanner := &scriptedScanner{hosts: []model.DiscoveredHost{
+		{IPAddress: "10.0.0.1"},
+		{IPAddress: "10.0.0.2", MACAddress: "aa:bb:cc:dd:ee:02"},
+		{IPAddress: "10.0.0.4"},
+		{IPAddress: "10.0.0.5", Hostname: "nas"},
+		{IPAddress: "10.0.0.6", Services: []model.DiscoveredService{{Port: 22, Name: "ssh"}}},
+	}}
+
+	discoveryRepo := newMemoryDiscoveryRepository()
+	discovery := NewDiscoveryService(discoveryRepo, assetRepo, newTestAssetService(assetRepo), scanner)
+	ctx := context.Background()
+	rng, err := discovery.CreateRange(ctx, DiscoveryRangeSpec{Name: "office", CIDRs: []string{"10.0.0.0/28"}, Ports: []int{22}}, "admin")
+	if err != nil {
+		t.Fatalf("CreateRange() error = %v", err)
+	}
+
+	scanOnce := func() model.ScanRun {
+		t.Helper()
+		if _, err := discovery.StartScan(ctx, rng.ID, "admin"); err != nil {
+			t.Fatalf("StartScan() error = %v", err)
+		}
+		select {
+		case run := <-discoveryRepo.finished:
+			if run.Status != model.ScanCompleted {
+				t.Fatalf("scan finished %s: %s", run.Status, run.Error)
+			}
+			return run
+		case <-time.After(5 * time.Second):
+			t.Fatal("scan did not finish")
+			return model.ScanRun{}
+		}
+	}
+
+	run := scanOnce()
+	if run.HostsUp != 5 || run.Matched != 3 || run.Candidates != 2 || run.StatusChanges != 2 {
+		t.Errorf("run = %d up, %d matched, %d candidates, %d status changes; want 5, 3, 2, 2",
+			run.HostsUp, run.Matched, run.Candidates, run.StatusChanges)
+	}
+
+	tests := []struct {
+		asset      *model.Asset
+		status     model.AssetStatus
+		ipAddress  string
+		wasScanned bool
+	}{
+		// Answered at its address, so it comes online
+		{web, model.OnlineStatus, "10.0.0.1", true},
+		// Matched by MAC address after it moved
+		{db, model.OnlineStatus, "10.0.0.2", true},
+		// In the range but silent, so it goes offline
+		{printer, model.OfflineStatus, "10.0.0.3", true},
+		// Matched by hostname, which gives it the address it answered at
+		{nas, model.OnlineStatus, "10.0.0.5", true},
+		// Retired assets are not matched, even when their address answers
+		{retired, model.RetiredStatus, "10.0.0.4", false},
+		// Outside the range, so its silence means nothing
+		{outside, model.OnlineStatus, "10.0.1.1", false},
+	}
+	for _, tt := range tests {
+		got, err := assetRepo.FindByID(ctx, tt.asset.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Status != tt.status || got.IPAddress != tt.ipAddress || got.LastScanned.IsZero() == tt.wasScanned {
+			t.Errorf("%s = %s at %q, scanned %s; want %s at %q, scanned %v",
+				tt.asset.Name, got.Status, got.IPAddress, got.LastScanned, tt.status, tt.ipAddress, tt.wasScanned)
+		}
+	}
+	if db, _ := assetRepo.FindByID(ctx, db.ID); db.Provenance["ipAddress"].Source != model.SourceDiscovery {
+		t.Errorf("provenance of the new address = %+v, want discovery", db.Provenance["ipAddress"])
+	}
+
+	// Unknown hosts are queued for review instead of becoming assets
+	if len(discoveryRepo.candidates) != 2 {
+		t.Fatalf("candidates = %d, want 2", len(discoveryRepo.candidates))
+	}
+	for _, candidate := range discoveryRepo.candidates {
+		if candidate.Status != model.CandidatePending {
+			t.Errorf("candidate %s is %s, want pending", candidate.IPAddress, candidate.Status)
+		}
+	}
+	if count, _ := assetRepo.FindAll(ctx, map[string]interface{}{}); len(count) != 6 {
+		t.Errorf("assets = %d after the scan, want the 6 there were", len(count))
+	}
+
+	// Hosts seen again update their candidates rather than queueing new ones
+	run = scanOnce()
+	if run.Candidates != 0 || len(discoveryRepo.candidates) != 2 {
+		t.Errorf("second scan queued %d candidates, %d in total; want 0 and 2", run.Candidates, len(discoveryRepo.candidates))
+	}
+}
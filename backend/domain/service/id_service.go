@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// maxIDAttempts bounds how often a new ID is drawn when the previous one was already taken
+const maxIDAttempts = 5
+
+// IDTemplateSpec defines the editable fields of an ID template
+type IDTemplateSpec struct {
+	Scope    string
+	Template string
+}
+
+// IDService generates asset and workflow IDs from templates and atomic sequences
+type IDService struct {
+	sequenceRepo repository.SequenceRepository
+	templateRepo repository.IDTemplateRepository
+	assetRepo    repository.AssetRepository
+	workflowRepo repository.WorkflowRepository
+}
+
+// NewIDService creates a new ID service
+func NewIDService(sequenceRepo repository.SequenceRepository, templateRepo repository.IDTemplateRepository, assetRepo repository.AssetRepository, workflowRepo repository.WorkflowRepository) *IDService {
+	return &IDService{
+		sequenceRepo: sequenceRepo,
+		templateRepo: templateRepo,
+		assetRepo:    assetRepo,
+		workflowRepo: workflowRepo,
+	}
+}
+
+// GetTemplates gets all configured ID templates
+func (s *IDService) GetTemplates(ctx context.Context) ([]*model.IDTemplate, error) {
+	return s.templateRepo.FindAll(ctx)
+}
+
+// SetTemplate creates or replaces the ID template of a scope. Existing IDs are kept; the new
+// template applies to assets and workflows created afterwards.
+func (s *IDService) SetTemplate(ctx context.Context, spec IDTemplateSpec) (*model.IDTemplate, error) {
+	if err := validateIDTemplateSpec(spec); err != nil {
+		return nil, err
+	}
+
+	template, err := s.templateRepo.FindByScope(ctx, spec.Scope)
+	switch {
+	case err == nil:
+		template.Template = spec.Template
+		template.UpdatedAt = time.Now()
+	case errors.Is(err, mongo.ErrNoDocuments):
+		template = model.NewIDTemplate(spec.Scope, spec.Template)
+	default:
+		return nil, err
+	}
+
+	if err := s.templateRepo.Save(ctx, template); err != nil {
+		return nil, err
+	}
+
+	return template, nil
+}
+
+// DeleteTemplate deletes the ID template of a scope, restoring the built-in template
+func (s *IDService) DeleteTemplate(ctx context.Context, scope string) error {
+	return s.templateRepo.DeleteByScope(ctx, scope)
+}
+
+// SaveNewAsset gives a new asset an ID and inserts it. If the ID is already taken, for
+// example by an asset created before sequences were used, the sequence is moved past the
+// existing IDs and another one is drawn. prefix fills the {prefix} placeholder.
+func (s *IDService) SaveNewAsset(ctx context.Context, asset *model.Asset, prefix string) error {
+	template, err := s.templateFor(ctx, string(asset.Type), model.DefaultAssetIDTemplate)
+	if err != nil {
+		return err
+	}
+
+	pattern, err := model.RenderIDTemplate(template, model.AssetIDValues(asset, prefix, time.Now()))
+	if err != nil {
+		return err
+	}
+	key := "assets:" + pattern.Key()
+
+	for attempt := 1; ; attempt++ {
+		seq, err := s.sequenceRepo.Next(ctx, key)
+		if err != nil {
+			return err
+		}
+		asset.AssetID = pattern.Format(seq)
+
+		err = s.assetRepo.Save(ctx, asset)
+		if err == nil || !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+
+		// The failed insert assigned an object ID; clear it so the next attempt inserts again
+		asset.ID = primitive.NilObjectID
+		if attempt == maxIDAttempts {
+			return fmt.Errorf("could not allocate a unique asset ID after %d attempts: %w", attempt, err)
+		}
+
+		ids, err := s.assetRepo.FindAssetIDsMatching(ctx, pattern.Regexp())
+		if err != nil {
+			return err
+		}
+		if err := s.sequenceRepo.EnsureAtLeast(ctx, key, pattern.MaxSequence(ids)); err != nil {
+			return err
+		}
+	}
+}
+
+// SaveNewWorkflow gives a new workflow an ID and inserts it, retrying like SaveNewAsset
+func (s *IDService) SaveNewWorkflow(ctx context.Context, workflow *model.Workflow) error {
+	template, err := s.templateFor(ctx, model.IDScopeWorkflow, model.DefaultWorkflowIDTemplate)
+	if err != nil {
+		return err
+	}
+
+	pattern, err := model.RenderIDTemplate(template, model.WorkflowIDValues(time.Now()))
+	if err != nil {
+		return err
+	}
+	key := "workflows:" + pattern.Key()
+
+	for attempt := 1; ; attempt++ {
+		seq, err := s.sequenceRepo.Next(ctx, key)
+		if err != nil {
+			return err
+		}
+		workflow.WorkflowID = pattern.Format(seq)
+
+		err = s.workflowRepo.Save(ctx, workflow)
+		if err == nil || !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+
+		// The failed insert assigned an object ID; clear it so the next attempt inserts again
+		workflow.ID = primitive.NilObjectID
+		if attempt == maxIDAttempts {
+			return fmt.Errorf("could not allocate a unique workflow ID after %d attempts: %w", attempt, err)
+		}
+
+		ids, err := s.workflowRepo.FindWorkflowIDsMatching(ctx, pattern.Regexp())
+		if err != nil {
+			return err
+		}
+		if err := s.sequenceRepo.EnsureAtLeast(ctx, key, pattern.MaxSequence(ids)); err != nil {
+			return err
+		}
+	}
+}
+
+// templateFor returns the template configured for a scope. Asset types without a template
+// of their own fall back to the shared asset template and then to the built-in one.
+func (s *IDService) templateFor(ctx context.Context, scope string, fallback string) (string, error) {
+	scopes := []string{scope}
+	if scope != model.IDScopeWorkflow {
+		scopes = append(scopes, model.IDScopeAsset)
+	}
+
+	for _, candidate := range scopes {
+		template, err := s.templateRepo.FindByScope(ctx, candidate)
+		if err == nil {
+			return template.Template, nil
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return "", err
+		}
+	}
+
+	return fallback, nil
+}
+
+// validateIDTemplateSpec checks the scope and the placeholders allowed in it
+func validateIDTemplateSpec(spec IDTemplateSpec) error {
+	if strings.TrimSpace(spec.Scope) == "" {
+		return errors.New("scope is required")
+	}
+
+	allowed := model.AssetIDPlaceholders
+	if spec.Scope == model.IDScopeWorkflow {
+		allowed = model.WorkflowIDPlaceholders
+	}
+	if err := model.ValidateIDTemplate(spec.Template, allowed); err != nil {
+		return err
+	}
+
+	// Render once with sample values to catch widths the generator cannot format
+	_, err := model.RenderIDTemplate(spec.Template, map[string]string{
+		"prefix": "AST", "type": "SERVER", "site": "SITE", "year": "2000", "month": "01",
+	})
+	return err
+}
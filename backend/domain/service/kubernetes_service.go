@@ -0,0 +1,367 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/repository"
+	"github.com/phuhao00/cmdb/backend/infrastructure/logging"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// KubernetesReader reads the objects of a cluster
+type KubernetesReader interface {
+	// Read returns every node, namespace, deployment, statefulset, service and ingress of the cluster
+	Read(ctx context.Context, cluster *model.KubernetesCluster) (*model.KubernetesSnapshot, error)
+}
+
+// KubernetesClusterSpec defines the editable fields of a Kubernetes cluster
+type KubernetesClusterSpec struct {
+	Name            string
+	Description     string
+	KubeconfigPath  string
+	Context         string
+	Location        string
+	IntervalMinutes int
+	Enabled         bool
+}
+
+// KubernetesService syncs the objects of Kubernetes clusters into CIs
+type KubernetesService struct {
+	ciSyncer
+	clusterRepo repository.KubernetesClusterRepository
+	reader      KubernetesReader
+
+	mu sync.Mutex
+	// syncing holds the clusters being synced
+	syncing map[primitive.ObjectID]bool
+}
+
+// NewKubernetesService creates a new Kubernetes service
+func NewKubernetesService(clusterRepo repository.KubernetesClusterRepository, assetRepo repository.AssetRepository, relationshipRepo repository.RelationshipRepository, assetService *AssetService, ciTypeService *CITypeService, reader KubernetesReader) *KubernetesService {
+	return &KubernetesService{
+		ciSyncer: ciSyncer{
+			assetRepo:        assetRepo,
+			relationshipRepo: relationshipRepo,
+			assetService:     assetService,
+			ciTypeService:    ciTypeService,
+		},
+		clusterRepo: clusterRepo,
+		reader:      reader,
+		syncing:     make(map[primitive.ObjectID]bool),
+	}
+}
+
+// CreateCluster creates a Kubernetes cluster
+func (s *KubernetesService) CreateCluster(ctx context.Context, spec KubernetesClusterSpec, createdBy string) (*model.KubernetesCluster, error) {
+	if err := validateKubernetesClusterSpec(&spec); err != nil {
+		return nil, err
+	}
+
+	cluster := model.NewKubernetesCluster(spec.Name, spec.KubeconfigPath, createdBy)
+	applyKubernetesClusterSpec(cluster, spec)
+
+	if err := s.clusterRepo.Save(ctx, cluster); err != nil {
+		return nil, err
+	}
+
+	return cluster, nil
+}
+
+// UpdateCluster updates a Kubernetes cluster
+func (s *KubernetesService) UpdateCluster(ctx context.Context, id primitive.ObjectID, spec KubernetesClusterSpec) (*model.KubernetesCluster, error) {
+	if err := validateKubernetesClusterSpec(&spec); err != nil {
+		return nil, err
+	}
+
+	cluster, err := s.clusterRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	cluster.Name = spec.Name
+	cluster.KubeconfigPath = spec.KubeconfigPath
+	applyKubernetesClusterSpec(cluster, spec)
+	cluster.UpdatedAt = time.Now()
+
+	if err := s.clusterRepo.Save(ctx, cluster); err != nil {
+		return nil, err
+	}
+
+	return cluster, nil
+}
+
+// DeleteCluster deletes a Kubernetes cluster. The CIs synced from it are kept.
+func (s *KubernetesService) DeleteCluster(ctx context.Context, id primitive.ObjectID) error {
+	if _, err := s.clusterRepo.FindByID(ctx, id); err != nil {
+		return err
+	}
+	return s.clusterRepo.Delete(ctx, id)
+}
+
+// GetCluster gets a Kubernetes cluster by ID
+func (s *KubernetesService) GetCluster(ctx context.Context, id primitive.ObjectID) (*model.KubernetesCluster, error) {
+	return s.clusterRepo.FindByID(ctx, id)
+}
+
+// GetClusters gets all Kubernetes clusters
+func (s *KubernetesService) GetClusters(ctx context.Context) ([]*model.KubernetesCluster, error) {
+	return s.clusterRepo.FindAll(ctx, false)
+}
+
+// SyncCluster reads the objects of a cluster and reconciles them with the cluster's CIs. New
+// objects become CIs, changed objects update theirs, and CIs of objects that are gone are
+// decommissioned rather than deleted. A cluster that cannot be read is left unchanged. The
+// result is stored on the cluster and returned together with the error of a failed sync.
+func (s *KubernetesService) SyncCluster(ctx context.Context, id primitive.ObjectID, triggeredBy string) (*model.SyncResult, error) {
+	cluster, err := s.clusterRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if s.syncing[cluster.ID] {
+		s.mu.Unlock()
+		return nil, ErrSyncInProgress
+	}
+	s.syncing[cluster.ID] = true
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.syncing, cluster.ID)
+		s.mu.Unlock()
+	}()
+
+	result := &model.SyncResult{TriggeredBy: triggeredBy, StartedAt: time.Now()}
+	snapshot, syncErr := s.reader.Read(ctx, cluster)
+	if syncErr == nil {
+		syncErr = s.reconcile(ctx, cluster, snapshot, result)
+	}
+	result.FinishedAt = time.Now()
+
+	if syncErr != nil {
+		result.Status = model.SyncFailed
+		result.Error = syncErr.Error()
+		logging.Logger.Error("kubernetes_sync_failed",
+			zap.String("cluster", cluster.Name),
+			zap.Error(syncErr))
+	} else {
+		result.Status = model.SyncCompleted
+		logging.Logger.Info("kubernetes_sync_completed",
+			zap.String("cluster", cluster.Name),
+			zap.Int("objects", result.Objects),
+			zap.Int("created", result.Created),
+			zap.Int("updated", result.Updated),
+			zap.Int("decommissioned", result.Decommissioned),
+			zap.Int("relationships", result.Relationships))
+	}
+
+	cluster.RecordSync(result)
+	if err := s.clusterRepo.Save(ctx, cluster); err != nil {
+		return nil, err
+	}
+
+	return result, syncErr
+}
+
+// StartScheduler syncs due clusters periodically until the context is cancelled. Clusters
+// are synced one after another.
+func (s *KubernetesService) StartScheduler(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				s.syncDueClusters(ctx, now)
+			}
+		}
+	}()
+}
+
+// syncDueClusters syncs every enabled cluster whose interval has elapsed
+func (s *KubernetesService) syncDueClusters(ctx context.Context, now time.Time) {
+	clusters, err := s.clusterRepo.FindAll(ctx, true)
+	if err != nil {
+		logging.Logger.Error("kubernetes_schedule_failed", zap.Error(err))
+		return
+	}
+
+	for _, cluster := range clusters {
+		if ctx.Err() != nil {
+			return
+		}
+		if !cluster.IsDue(now) {
+			continue
+		}
+		// Failures are logged and recorded on the cluster by SyncCluster
+		_, _ = s.SyncCluster(ctx, cluster.ID, "scheduler")
+	}
+}
+
+// reconcile applies a snapshot of a cluster to its CIs
+func (s *KubernetesService) reconcile(ctx context.Context, cluster *model.KubernetesCluster, snapshot *model.KubernetesSnapshot, result *model.SyncResult) error {
+	// A cluster always has namespaces; an empty snapshot would decommission every CI
+	if len(snapshot.Objects) == 0 {
+		return errors.New("cluster returned no objects")
+	}
+	result.Objects = len(snapshot.Objects)
+
+	run, err := s.begin(ctx, cluster.ExternalID(""), model.SourceKubernetes, cluster.Name, "Kubernetes sync of "+cluster.Name, result)
+	if err != nil {
+		return err
+	}
+	description := "Synced from Kubernetes cluster " + cluster.Name
+	relate := func(source, target *model.Asset, relationshipType model.RelationshipType) error {
+		return run.relate(ctx, source, target, relationshipType, "Discovered in Kubernetes cluster "+cluster.Name)
+	}
+
+	clusterCI, err := run.upsert(ctx, cluster.ExternalID(""), model.KubernetesClusterType, cluster.Location, description, func(asset *model.Asset) {
+		asset.Name = cluster.Name
+		asset.Attributes = map[string]interface{}{
+			"server":  snapshot.Server,
+			"version": snapshot.Version,
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	cis := make(map[model.KubernetesRef]*model.Asset, len(snapshot.Objects))
+	for i := range snapshot.Objects {
+		obj := &snapshot.Objects[i]
+		if obj.Kind.AssetType() == "" || obj.UID == "" {
+			continue
+		}
+
+		attributes := make(map[string]interface{}, len(obj.Attributes)+3)
+		for name, value := range obj.Attributes {
+			attributes[name] = value
+		}
+		attributes["cluster"] = cluster.Name
+		attributes["uid"] = obj.UID
+		name := cluster.Name + "/" + obj.Name
+		if obj.Kind.Namespaced() {
+			attributes["namespace"] = obj.Namespace
+			name = cluster.Name + "/" + obj.Namespace + "/" + obj.Name
+		}
+
+		ci, err := run.upsert(ctx, cluster.ExternalID(obj.UID), obj.Kind.AssetType(), cluster.Location, description, func(asset *model.Asset) {
+			asset.Name = name
+			asset.Attributes = attributes
+		})
+		if err != nil {
+			return fmt.Errorf("%s %s: %w", obj.Kind, obj.Name, err)
+		}
+		cis[obj.Ref()] = ci
+	}
+
+	if err := run.decommissionMissing(ctx); err != nil {
+		return err
+	}
+
+	for i := range snapshot.Objects {
+		obj := &snapshot.Objects[i]
+		ci, ok := cis[obj.Ref()]
+		if !ok {
+			continue
+		}
+
+		switch {
+		case obj.Kind == model.KindNode:
+			if err := relate(ci, clusterCI, model.MemberOfRelationship); err != nil {
+				return err
+			}
+			host, err := s.findNodeHost(ctx, obj)
+			if err != nil {
+				return err
+			}
+			if host != nil {
+				if err := relate(ci, host, model.RunsOnRelationship); err != nil {
+					return err
+				}
+			}
+		case obj.Kind == model.KindNamespace:
+			if err := relate(ci, clusterCI, model.MemberOfRelationship); err != nil {
+				return err
+			}
+		case obj.Kind.Namespaced():
+			namespace, ok := cis[model.KubernetesRef{Kind: model.KindNamespace, Name: obj.Namespace}]
+			if ok {
+				if err := relate(ci, namespace, model.MemberOfRelationship); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, ref := range obj.DependsOn {
+			if target, ok := cis[ref]; ok {
+				if err := relate(ci, target, model.DependsOnRelationship); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// findNodeHost finds the active server asset a node runs on by machine ID, IP address or hostname
+func (s *KubernetesService) findNodeHost(ctx context.Context, node *model.KubernetesObject) (*model.Asset, error) {
+	keys := []struct{ field, value string }{{"machineId", strings.ToLower(node.MachineID)}}
+	for _, ip := range node.IPAddresses {
+		keys = append(keys, struct{ field, value string }{"ipAddress", ip})
+	}
+	keys = append(keys, struct{ field, value string }{"hostname", strings.ToLower(node.Hostname)})
+
+	for _, key := range keys {
+		if key.value == "" {
+			continue
+		}
+		assets, err := s.assetRepo.FindAll(ctx, map[string]interface{}{key.field: key.value, "status": activeStatusFilter()})
+		if err != nil {
+			return nil, err
+		}
+		for _, asset := range assets {
+			if !model.IsKubernetesType(asset.Type) {
+				return asset, nil
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// validateKubernetesClusterSpec checks a cluster spec
+func validateKubernetesClusterSpec(spec *KubernetesClusterSpec) error {
+	spec.Name = strings.TrimSpace(spec.Name)
+	if spec.Name == "" {
+		return errors.New("name is required")
+	}
+	if strings.TrimSpace(spec.KubeconfigPath) == "" {
+		return errors.New("kubeconfig path is required")
+	}
+	if spec.IntervalMinutes < 0 {
+		return errors.New("interval cannot be negative")
+	}
+	return nil
+}
+
+// applyKubernetesClusterSpec copies the optional fields of a spec to a cluster
+func applyKubernetesClusterSpec(cluster *model.KubernetesCluster, spec KubernetesClusterSpec) {
+	cluster.Description = spec.Description
+	cluster.Context = spec.Context
+	cluster.Location = spec.Location
+	cluster.IntervalMinutes = spec.IntervalMinutes
+	cluster.Enabled = spec.Enabled
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type memoryKubernetesClusterRepository struct {
+	repository.KubernetesClusterRepository
+
+	mu       sync.Mutex
+	clusters map[primitive.ObjectID]*model.KubernetesCluster
+}
+
+func (r *memoryKubernetesClusterRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*model.KubernetesCluster, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cluster, ok := r.clusters[id]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	copied := *cluster
+	return &copied, nil
+}
+
+func (r *memoryKubernetesClusterRepository) Save(ctx context.Context, cluster *model.KubernetesCluster) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.clusters == nil {
+		r.clusters = make(map[primitive.ObjectID]*model.KubernetesCluster)
+	}
+	if cluster.ID.IsZero() {
+		cluster.ID = primitive.NewObjectID()
+	}
+	copied := *cluster
+	r.clusters[cluster.ID] = &copied
+	return nil
+}
+
+// scriptedKubernetesReader returns whatever snapshot the test last gave it
+type scriptedKubernetesReader struct {
+	snapshot *model.KubernetesSnapshot
+}
+
+func (r *scriptedKubernetesReader) Read(ctx context.Context, cluster *model.KubernetesCluster) (*model.KubernetesSnapshot, error) {
+	return r.snapshot, nil
+}
+
+// shopSnapshot is a cluster with one node, a namespace and a deployment behind a service
+func shopSnapshot() *model.KubernetesSnapshot {
+	return &model.KubernetesSnapshot{Server: "https://k8s.example.com", Version: "v1.29.4", Objects: []model.KubernetesObject{
+		{Kind: model.KindNode, UID: "node-1", Name: "worker-1", Attributes: map[string]interface{}{"cpu": "4"},
+			Hostname: "worker-1", IPAddresses: []string{"10.0.0.11"}},
+		{Kind: model.KindNamespace, UID: "ns-1", Name: "shop", Attributes: map[string]interface{}{"phase": "Active"}},
+		{Kind: model.KindDeployment, UID: "deploy-1", Name: "web", Namespace: "shop",
+			Attributes: map[string]interface{}{"replicas": int64(3), "images": "shop/web:1.4.2"}},
+		{Kind: model.KindService, UID: "svc-1", Name: "web", Namespace: "shop",
+			Attributes: map[string]interface{}{"serviceType": "ClusterIP", "ports": "80/TCP"},
+			DependsOn:  []model.KubernetesRef{{Kind: model.KindDeployment, Namespace: "shop", Name: "web"}}},
+	}}
+}
+
+func TestKubernetesSyncReconcilesObjects(t *testing.T) {
+	host := model.NewAsset("worker-1-host", model.ServerType, "DC1", "")
+	host.Status = model.OnlineStatus
+	host.IPAddress = "10.0.0.11"
+	assetRepo := newMemoryAssetRepository(host)
+	relationshipRepo := &memoryRelationshipRepository{}
+	clusterRepo := &memoryKubernetesClusterRepository{}
+	reader := &scriptedKubernetesReader{snapshot: shopSnapshot()}
+
+	assetService := newTestAssetService(assetRepo)
+	kubernetes := NewKubernetesService(clusterRepo, assetRepo, relationshipRepo, assetService, assetService.ciTypeService, reader)
+	ctx := context.Background()
+	cluster, err := kubernetes.CreateCluster(ctx, KubernetesClusterSpec{Name: "prod", KubeconfigPath: "/etc/kubeconfig", Location: "DC1", Enabled: true}, "admin")
+	if err != nil {
+		t.Fatalf("CreateCluster() error = %v", err)
+	}
+	ci := func(uid string) *model.Asset {
+		t.Helper()
+		asset := assetRepo.byExternalID(cluster.ExternalID(uid))
+		if asset == nil {
+			t.Fatalf("no CI for %q", uid)
+		}
+		return asset
+	}
+
+	result, err := kubernetes.SyncCluster(ctx, cluster.ID, "admin")
+	if err != nil {
+		t.Fatalf("SyncCluster() error = %v", err)
+	}
+	if result.Objects != 4 || result.Created != 5 || result.Relationships != 6 {
+		t.Errorf("first sync read %d objects, created %d CIs and %d relationships; want 4, 5 and 6",
+			result.Objects, result.Created, result.Relationships)
+	}
+
+	service := ci("svc-1")
+	if service.Name != "prod/shop/web" || service.Type != model.KubernetesServiceType || service.Status != model.OnlineStatus ||
+		service.Attributes["cluster"] != "prod" || service.Attributes["namespace"] != "shop" || service.Attributes["uid"] != "svc-1" {
+		t.Errorf("service CI = %s %s %s %v", service.Name, service.Type, service.Status, service.Attributes)
+	}
+	if node := ci("node-1"); node.Name != "prod/worker-1" || node.Provenance["attributes.cpu"].Source != model.SourceKubernetes {
+		t.Errorf("node CI = %s with provenance %+v", node.Name, node.Provenance)
+	}
+
+	relationships := []struct {
+		source, target   *model.Asset
+		relationshipType model.RelationshipType
+	}{
+		{ci("node-1"), ci(""), model.MemberOfRelationship},
+		// The node is matched to the server it runs on by its address
+		{ci("node-1"), host, model.RunsOnRelationship},
+		{ci("ns-1"), ci(""), model.MemberOfRelationship},
+		{ci("deploy-1"), ci("ns-1"), model.MemberOfRelationship},
+		{ci("svc-1"), ci("ns-1"), model.MemberOfRelationship},
+		{ci("svc-1"), ci("deploy-1"), model.DependsOnRelationship},
+	}
+	for _, tt := range relationships {
+		if relationshipRepo.find(tt.source.ID, tt.target.ID, tt.relationshipType) == nil {
+			t.Errorf("no %s relationship from %s to %s", tt.relationshipType, tt.source.Name, tt.target.Name)
+		}
+	}
+
+	// Syncing the same objects again changes nothing
+	result, err = kubernetes.SyncCluster(ctx, cluster.ID, "scheduler")
+	if err != nil {
+		t.Fatalf("SyncCluster() error = %v", err)
+	}
+	if result.Unchanged != 5 || result.Created != 0 || result.Updated != 0 || result.Relationships != 0 {
+		t.Errorf("second sync = %+v, want 5 unchanged", result)
+	}
+
+	// The deployment is deleted and the service changed
+	snapshot := shopSnapshot()
+	snapshot.Objects[3].Attributes["ports"] = "80/TCP,443/TCP"
+	snapshot.Objects = append(snapshot.Objects[:2], snapshot.Objects[3])
+	reader.snapshot = snapshot
+	result, err = kubernetes.SyncCluster(ctx, cluster.ID, "scheduler")
+	if err != nil {
+		t.Fatalf("SyncCluster() error = %v", err)
+	}
+	if result.Decommissioned != 1 || result.Updated != 1 {
+		t.Errorf("third sync decommissioned %d and updated %d CIs, want 1 and 1", result.Decommissioned, result.Updated)
+	}
+	if deployment := ci("deploy-1"); deployment.Status != model.DecommissionedStatus {
+		t.Errorf("CI of the deleted deployment is %s, want it kept as decommissioned", deployment.Status)
+	}
+	if ports := ci("svc-1").Attributes["ports"]; ports != "80/TCP,443/TCP" {
+		t.Errorf("service ports = %v after the sync", ports)
+	}
+
+	// A cluster that returns nothing fails the sync instead of decommissioning everything
+	before := assetRepo.all()
+	reader.snapshot = &model.KubernetesSnapshot{Server: "https://k8s.example.com"}
+	result, err = kubernetes.SyncCluster(ctx, cluster.ID, "scheduler")
+	if err == nil || result.Status != model.SyncFailed {
+		t.Fatalf("SyncCluster() of an empty cluster = %v, %v; want a failed sync", result, err)
+	}
+	for i, asset := range assetRepo.all() {
+		if asset.Status != before[i].Status {
+			t.Errorf("%s went from %s to %s after a failed sync", asset.Name, before[i].Status, asset.Status)
+		}
+	}
+	if stored, _ := clusterRepo.FindByID(ctx, cluster.ID); stored.LastSync == nil || stored.LastSync.Status != model.SyncFailed {
+		t.Errorf("cluster recorded %+v, want the failed sync", stored.LastSync)
+	}
+}
@@ -0,0 +1,565 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/repository"
+	"github.com/phuhao00/cmdb/backend/infrastructure/logging"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	logging.Logger = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+// The in-memory repositories below implement what the services under test call. Each embeds
+// its interface, so calling anything else panics and shows what a new test needs.
+
+// matchesFilter reports whether a document matches a filter the way the Mongo repositories
+// apply it: top-level fields compared for equality or with $nin and $regex
+func matchesFilter(document interface{}, filter map[string]interface{}) bool {
+	raw, err := bson.Marshal(document)
+	if err != nil {
+		panic(err)
+	}
+	var fields bson.M
+	if err := bson.Unmarshal(raw, &fields); err != nil {
+		panic(err)
+	}
+
+	for name, condition := range filter {
+		value := fields[name]
+		operators, ok := condition.(map[string]interface{})
+		if !ok {
+			if fmt.Sprint(value) != fmt.Sprint(condition) {
+				return false
+			}
+			continue
+		}
+		for operator, operand := range operators {
+			switch operator {
+			case "$nin":
+				for _, excluded := range operand.([]string) {
+					if fmt.Sprint(value) == excluded {
+						return false
+					}
+				}
+			case "$regex":
+				text, _ := value.(string)
+				if !regexp.MustCompile(operand.(string)).MatchString(text) {
+					return false
+				}
+			default:
+				panic("unsupported filter operator " + operator)
+			}
+		}
+	}
+	return true
+}
+
+// memoryAssetRepository keeps copies of assets, so changes only count once saved
+type memoryAssetRepository struct {
+	repository.AssetRepository
+
+	mu     sync.Mutex
+	assets map[primitive.ObjectID]*model.Asset
+}
+
+func newMemoryAssetRepository(assets ...*model.Asset) *memoryAssetRepository {
+	r := &memoryAssetRepository{assets: make(map[primitive.ObjectID]*model.Asset)}
+	for _, asset := range assets {
+		r.Save(context.Background(), asset)
+	}
+	return r
+}
+
+func (r *memoryAssetRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*model.Asset, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	asset, ok := r.assets[id]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	return asset.Clone(), nil
+}
+
+func (r *memoryAssetRepository) FindByAssetID(ctx context.Context, assetID string) (*model.Asset, error) {
+	for _, asset := range r.all() {
+		if asset.AssetID == assetID {
+			return asset, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (r *memoryAssetRepository) FindAll(ctx context.Context, filter map[string]interface{}) ([]*model.Asset, error) {
+	var assets []*model.Asset
+	for _, asset := range r.all() {
+		if matchesFilter(asset, filter) {
+			assets = append(assets, asset)
+		}
+	}
+	return assets, nil
+}
+
+func (r *memoryAssetRepository) Save(ctx context.Context, asset *model.Asset) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if asset.ID.IsZero() {
+		asset.ID = primitive.NewObjectID()
+	}
+	r.assets[asset.ID] = asset.Clone()
+	return nil
+}
+
+func (r *memoryAssetRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.assets, id)
+	return nil
+}
+
+// all returns copies of every asset in the order they were created
+func (r *memoryAssetRepository) all() []*model.Asset {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	assets := make([]*model.Asset, 0, len(r.assets))
+	for _, asset := range r.assets {
+		assets = append(assets, asset.Clone())
+	}
+	sort.Slice(assets, func(i, j int) bool { return assets[i].ID.Hex() < assets[j].ID.Hex() })
+	return assets
+}
+
+// byExternalID returns the stored asset with an external ID, or nil
+func (r *memoryAssetRepository) byExternalID(externalID string) *model.Asset {
+	for _, asset := range r.all() {
+		if asset.ExternalID == externalID {
+			return asset
+		}
+	}
+	return nil
+}
+
+type memorySequenceRepository struct {
+	mu        sync.Mutex
+	sequences map[string]int64
+}
+
+func (r *memorySequenceRepository) Next(ctx context.Context, name string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.sequences == nil {
+		r.sequences = make(map[string]int64)
+	}
+	r.sequences[name]++
+	return r.sequences[name], nil
+}
+
+func (r *memorySequenceRepository) EnsureAtLeast(ctx context.Context, name string, value int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.sequences[name] < value {
+		r.sequences[name] = value
+	}
+	return nil
+}
+
+// noIDTemplates leaves every scope on the built-in ID template
+type noIDTemplates struct {
+	repository.IDTemplateRepository
+}
+
+func (noIDTemplates) FindByScope(ctx context.Context, scope string) (*model.IDTemplate, error) {
+	return nil, mongo.ErrNoDocuments
+}
+
+// builtInCITypes stores no CI types, so only the built-in ones exist
+type builtInCITypes struct {
+	repository.CITypeRepository
+}
+
+func (builtInCITypes) FindByName(ctx context.Context, name model.AssetType) (*model.CIType, error) {
+	return nil, mongo.ErrNoDocuments
+}
+
+type memoryAuditLogRepository struct {
+	repository.AuditLogRepository
+
+	mu   sync.Mutex
+	logs []*model.AuditLog
+}
+
+func (r *memoryAuditLogRepository) Create(ctx context.Context, log *model.AuditLog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.logs = append(r.logs, log)
+	return nil
+}
+
+func (r *memoryAuditLogRepository) FindLatest(ctx context.Context) (*model.AuditLog, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.logs) == 0 {
+		return nil, nil
+	}
+	return r.logs[len(r.logs)-1], nil
+}
+
+func (r *memoryAuditLogRepository) FindSequenceRange(ctx context.Context, from, to int64, limit int) ([]*model.AuditLog, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var logs []*model.AuditLog
+	for _, log := range r.logs {
+		if log.Sequence >= from && log.Sequence <= to && len(logs) < limit {
+			logs = append(logs, log)
+		}
+	}
+	return logs, nil
+}
+
+func (r *memoryAuditLogRepository) DeleteThroughSequence(ctx context.Context, sequence int64) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kept := r.logs[:0]
+	for _, log := range r.logs {
+		if log.Sequence > sequence {
+			kept = append(kept, log)
+		}
+	}
+	deleted := int64(len(r.logs) - len(kept))
+	r.logs = kept
+	return deleted, nil
+}
+
+func (r *memoryAuditLogRepository) FindByResourceIDs(ctx context.Context, resourceType string, resourceIDs []string, limit int) ([]*model.AuditLog, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var logs []*model.AuditLog
+	for i := len(r.logs) - 1; i >= 0 && len(logs) < limit; i-- {
+		if r.logs[i].ResourceType == resourceType && slices.Contains(resourceIDs, r.logs[i].ResourceID) {
+			logs = append(logs, r.logs[i])
+		}
+	}
+	return logs, nil
+}
+
+// Count supports the resource criteria only
+func (r *memoryAuditLogRepository) Count(ctx context.Context, criteria repository.AuditLogSearchCriteria) (int64, error) {
+	resourceIDs := criteria.ResourceIDs
+	if len(resourceIDs) == 0 {
+		resourceIDs = []string{criteria.ResourceID}
+	}
+	logs, err := r.FindByResourceIDs(ctx, criteria.ResourceType, resourceIDs, math.MaxInt)
+	return int64(len(logs)), err
+}
+
+func (r *memoryAuditLogRepository) FindReassignedTo(ctx context.Context, resourceType, resourceID string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ids []string
+	for _, log := range r.logs {
+		if log.Action == model.ResourceReassigned && log.ResourceType == resourceType &&
+			log.NewValue["resourceId"] == resourceID && !slices.Contains(ids, log.ResourceID) {
+			ids = append(ids, log.ResourceID)
+		}
+	}
+	return ids, nil
+}
+
+type memoryAssetHistoryRepository struct {
+	repository.AssetHistoryRepository
+
+	mu      sync.Mutex
+	records []*model.AssetHistory
+}
+
+func (r *memoryAssetHistoryRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*model.AssetHistory, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, record := range r.records {
+		if record.ID == id {
+			copied := *record
+			return &copied, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+// FindByDateRange returns the records newest first, like the Mongo repository
+func (r *memoryAssetHistoryRepository) FindByDateRange(ctx context.Context, assetID primitive.ObjectID, start, end time.Time) ([]*model.AssetHistory, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var records []*model.AssetHistory
+	for _, record := range r.records {
+		if record.AssetID == assetID && !record.Timestamp.Before(start) && !record.Timestamp.After(end) {
+			copied := *record
+			records = append(records, &copied)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Timestamp.After(records[j].Timestamp) })
+	return records, nil
+}
+
+func (r *memoryAssetHistoryRepository) ReassignAsset(ctx context.Context, fromID, toID primitive.ObjectID, mergedFrom string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var moved int64
+	for _, record := range r.records {
+		if record.AssetID != fromID {
+			continue
+		}
+		if record.MergedFrom == "" {
+			record.MergedFrom = mergedFrom
+		}
+		record.AssetID = toID
+		moved++
+	}
+	return moved, nil
+}
+
+type memoryWorkflowRepository struct {
+	repository.WorkflowRepository
+
+	mu        sync.Mutex
+	workflows []*model.Workflow
+}
+
+func (r *memoryWorkflowRepository) ReassignAsset(ctx context.Context, fromAssetID, toAssetID, toAssetName string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var moved int64
+	for _, workflow := range r.workflows {
+		if workflow.AssetID == fromAssetID {
+			workflow.AssetID, workflow.AssetName = toAssetID, toAssetName
+			moved++
+		}
+	}
+	return moved, nil
+}
+
+type noAuditArchives struct {
+	repository.AuditArchiveRepository
+}
+
+func (noAuditArchives) FindAll(ctx context.Context) ([]*model.AuditArchive, error) {
+	return nil, nil
+}
+
+// memoryRelationshipRepository keeps copies of relationships, so changes only count once saved
+type memoryRelationshipRepository struct {
+	repository.RelationshipRepository
+
+	mu            sync.Mutex
+	relationships []*model.Relationship
+}
+
+func (r *memoryRelationshipRepository) FindByAssetID(ctx context.Context, assetID primitive.ObjectID) ([]*model.Relationship, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var relationships []*model.Relationship
+	for _, relationship := range r.relationships {
+		if relationship.SourceID == assetID || relationship.TargetID == assetID {
+			copied := *relationship
+			relationships = append(relationships, &copied)
+		}
+	}
+	return relationships, nil
+}
+
+func (r *memoryRelationshipRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.relationships = slices.DeleteFunc(r.relationships, func(relationship *model.Relationship) bool {
+		return relationship.ID == id
+	})
+	return nil
+}
+
+func (r *memoryRelationshipRepository) Exists(ctx context.Context, sourceID, targetID primitive.ObjectID, relationshipType model.RelationshipType) (bool, error) {
+	return r.find(sourceID, targetID, relationshipType) != nil, nil
+}
+
+func (r *memoryRelationshipRepository) Save(ctx context.Context, relationship *model.Relationship) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if relationship.ID.IsZero() {
+		relationship.ID = primitive.NewObjectID()
+	}
+	copied := *relationship
+	for i, existing := range r.relationships {
+		if existing.ID == relationship.ID {
+			r.relationships[i] = &copied
+			return nil
+		}
+	}
+	r.relationships = append(r.relationships, &copied)
+	return nil
+}
+
+// find returns the relationship between two assets, or nil
+func (r *memoryRelationshipRepository) find(sourceID, targetID primitive.ObjectID, relationshipType model.RelationshipType) *model.Relationship {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, relationship := range r.relationships {
+		if relationship.SourceID == sourceID && relationship.TargetID == targetID && relationship.Type == relationshipType {
+			return relationship
+		}
+	}
+	return nil
+}
+
+type memoryUserRepository struct {
+	repository.UserRepository
+
+	mu       sync.Mutex
+	users    []*model.User
+	sessions []*model.Session
+}
+
+func (r *memoryUserRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range r.users {
+		if user.ID == id {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryUserRepository) GetByUsername(ctx context.Context, username string) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range r.users {
+		if user.Username == username {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryUserRepository) Update(ctx context.Context, user *model.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.users {
+		if r.users[i].ID == user.ID {
+			copied := *user
+			r.users[i] = &copied
+			return nil
+		}
+	}
+	return mongo.ErrNoDocuments
+}
+
+func (r *memoryUserRepository) CreateSession(ctx context.Context, session *model.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session.ID = primitive.NewObjectID()
+	copied := *session
+	r.sessions = append(r.sessions, &copied)
+	return nil
+}
+
+func (r *memoryUserRepository) GetSessionByToken(ctx context.Context, token string) (*model.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, session := range r.sessions {
+		if session.Token == token {
+			copied := *session
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryUserRepository) RotateSession(ctx context.Context, token string) (*model.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, session := range r.sessions {
+		if session.Token == token && session.RotatedAt == nil {
+			copied := *session
+			now := time.Now()
+			session.RotatedAt = &now
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryUserRepository) DeleteSessionFamily(ctx context.Context, familyID primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sessions = slices.DeleteFunc(r.sessions, func(session *model.Session) bool { return session.FamilyID == familyID })
+	return nil
+}
+
+func (r *memoryUserRepository) DeleteUserSessions(ctx context.Context, userID primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sessions = slices.DeleteFunc(r.sessions, func(session *model.Session) bool { return session.UserID == userID })
+	return nil
+}
+
+func (r *memoryUserRepository) List(ctx context.Context, limit, offset int) ([]*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var users []*model.User
+	for i := offset; i < len(r.users) && len(users) < limit; i++ {
+		copied := *r.users[i]
+		users = append(users, &copied)
+	}
+	return users, nil
+}
+
+type memoryTokenRevocationRepository struct {
+	repository.TokenRevocationRepository
+
+	mu          sync.Mutex
+	revocations []*model.TokenRevocation
+}
+
+func (r *memoryTokenRevocationRepository) Create(ctx context.Context, revocation *model.TokenRevocation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	revocation.ID = primitive.NewObjectID()
+	r.revocations = append(r.revocations, revocation)
+	return nil
+}
+
+func (r *memoryTokenRevocationRepository) FindActive(ctx context.Context) ([]*model.TokenRevocation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var active []*model.TokenRevocation
+	for _, revocation := range r.revocations {
+		if revocation.ExpiresAt.After(time.Now()) {
+			active = append(active, revocation)
+		}
+	}
+	return active, nil
+}
+
+// newTestAssetService creates an asset service on an asset repository, with asset IDs, CI
+// types and the audit log kept in memory
+func newTestAssetService(assetRepo repository.AssetRepository) *AssetService {
+	idService := NewIDService(&memorySequenceRepository{}, noIDTemplates{}, assetRepo, nil)
+	ciTypeService := NewCITypeService(builtInCITypes{}, assetRepo)
+	auditLogService := NewAuditLogService(&memoryAuditLogRepository{}, nil, noAuditArchives{}, nil, nil)
+	return NewAssetService(assetRepo, nil, nil, nil, idService, ciTypeService, auditLogService)
+}
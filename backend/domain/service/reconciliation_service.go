@@ -0,0 +1,267 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/repository"
+	"github.com/phuhao00/cmdb/backend/infrastructure/logging"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// ReconciliationService settles conflicting field values reported by different sources and
+// finds assets that are the same machine reported under different keys
+type ReconciliationService struct {
+	reconciliationRepo repository.ReconciliationRepository
+	assetRepo          repository.AssetRepository
+	assetHistoryRepo   repository.AssetHistoryRepository
+	relationshipRepo   repository.RelationshipRepository
+	workflowRepo       repository.WorkflowRepository
+	auditLogService    *AuditLogService
+	ciTypeService      *CITypeService
+	policy             *model.ReconciliationPolicy
+	duplicatePolicy    *model.DuplicatePolicy
+}
+
+// NewReconciliationService creates a new reconciliation service with the default precedence and
+// duplicate rules
+func NewReconciliationService(reconciliationRepo repository.ReconciliationRepository, assetRepo repository.AssetRepository, assetHistoryRepo repository.AssetHistoryRepository, relationshipRepo repository.RelationshipRepository, workflowRepo repository.WorkflowRepository, auditLogService *AuditLogService, ciTypeService *CITypeService) *ReconciliationService {
+	return &ReconciliationService{
+		reconciliationRepo: reconciliationRepo,
+		assetRepo:          assetRepo,
+		assetHistoryRepo:   assetHistoryRepo,
+		relationshipRepo:   relationshipRepo,
+		workflowRepo:       workflowRepo,
+		auditLogService:    auditLogService,
+		ciTypeService:      ciTypeService,
+		policy:             model.DefaultReconciliationPolicy(),
+		duplicatePolicy:    model.DefaultDuplicatePolicy(),
+	}
+}
+
+// SetPolicy replaces the precedence rules
+func (s *ReconciliationService) SetPolicy(policy *model.ReconciliationPolicy) {
+	s.policy = policy
+}
+
+// GetPolicy gets the precedence rules
+func (s *ReconciliationService) GetPolicy() *model.ReconciliationPolicy {
+	return s.policy
+}
+
+// SetDuplicatePolicy replaces the duplicate rules
+func (s *ReconciliationService) SetDuplicatePolicy(policy *model.DuplicatePolicy) {
+	s.duplicatePolicy = policy
+}
+
+// GetDuplicatePolicy gets the duplicate rules
+func (s *ReconciliationService) GetDuplicatePolicy() *model.DuplicatePolicy {
+	return s.duplicatePolicy
+}
+
+// Reconcile settles the fields a source changed on an asset before the asset is saved. oldAsset
+// is the asset as stored. Fields the source may set record it as their source; fields held by a
+// source of higher precedence get their stored value back, and so do fields the rules cannot
+// settle, which are queued for review.
+func (s *ReconciliationService) Reconcile(ctx context.Context, oldAsset, asset *model.Asset, source, reporter string) error {
+	// The caller's copy of the asset may share its provenance with the stored version
+	asset.Provenance = maps.Clone(asset.Provenance)
+
+	for _, field := range model.ChangedFields(oldAsset, asset) {
+		current := oldAsset.FieldSourceOf(field)
+		switch s.policy.Resolve(field, current, oldAsset.IsFieldEmpty(field), source) {
+		case model.ResolutionApply:
+			asset.SetFieldSource(field, source, reporter)
+		case model.ResolutionKeep:
+			asset.RestoreField(field, oldAsset)
+			logging.Logger.Debug("reconciliation_value_kept",
+				zap.String("asset_id", asset.AssetID),
+				zap.String("field", field),
+				zap.String("source", source),
+				zap.String("current_source", current.Source))
+		case model.ResolutionConflict:
+			proposed, err := asset.FieldValue(field)
+			if err != nil {
+				return err
+			}
+			asset.RestoreField(field, oldAsset)
+			proposedSource := model.FieldSource{Source: source, Reporter: reporter, ReportedAt: time.Now()}
+			if err := s.queueConflict(ctx, asset, field, proposed, proposedSource); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Preview settles the fields a source changed on an asset like Reconcile, but neither records
+// sources nor queues conflicts
+func (s *ReconciliationService) Preview(oldAsset, asset *model.Asset, source string) {
+	for _, field := range model.ChangedFields(oldAsset, asset) {
+		if s.policy.Resolve(field, oldAsset.FieldSourceOf(field), oldAsset.IsFieldEmpty(field), source) != model.ResolutionApply {
+			asset.RestoreField(field, oldAsset)
+		}
+	}
+}
+
+// queueConflict records a value the rules could not settle. A source reporting again for the
+// same field updates its open conflict.
+func (s *ReconciliationService) queueConflict(ctx context.Context, asset *model.Asset, field string, proposed json.RawMessage, source model.FieldSource) error {
+	conflict, err := s.reconciliationRepo.FindOpenConflict(ctx, asset.ID, field, source.Source)
+	if err != nil {
+		return err
+	}
+	if conflict == nil {
+		conflict = model.NewFieldConflict(asset, field, proposed, source)
+		logging.Logger.Info("reconciliation_conflict_queued",
+			zap.String("asset_id", asset.AssetID),
+			zap.String("field", field),
+			zap.String("source", source.Source),
+			zap.String("current_source", conflict.CurrentSource.Source))
+	} else {
+		conflict.Report(asset, proposed, source)
+	}
+
+	return s.reconciliationRepo.SaveConflict(ctx, conflict)
+}
+
+// GetConflicts gets field conflicts with optional filtering
+func (s *ReconciliationService) GetConflicts(ctx context.Context, filter map[string]interface{}) ([]*model.FieldConflict, error) {
+	return s.reconciliationRepo.FindConflicts(ctx, filter)
+}
+
+// GetConflict gets a field conflict by ID
+func (s *ReconciliationService) GetConflict(ctx context.Context, id primitive.ObjectID) (*model.FieldConflict, error) {
+	return s.reconciliationRepo.FindConflictByID(ctx, id)
+}
+
+// AcceptConflict applies the reported value of a conflict to its asset, with the reporting
+// source as the field's source
+func (s *ReconciliationService) AcceptConflict(ctx context.Context, id primitive.ObjectID, reviewer string) (*model.FieldConflict, error) {
+	conflict, err := s.openConflict(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	reason := fmt.Sprintf("Accepted %s value of %s", conflict.ProposedSource.Source, conflict.Field)
+	value := json.RawMessage(conflict.ProposedValue)
+	if err := s.applyResolution(ctx, conflict, value, conflict.ProposedSource.Source, conflict.ProposedSource.Reporter, reason); err != nil {
+		return nil, err
+	}
+
+	conflict.Resolve(model.ConflictAccepted, reviewer)
+	if err := s.reconciliationRepo.SaveConflict(ctx, conflict); err != nil {
+		return nil, err
+	}
+
+	return conflict, nil
+}
+
+// OverrideConflict rejects the reported value of a conflict. The asset keeps its current value
+// unless the reviewer gives another one, which is then recorded as entered manually.
+func (s *ReconciliationService) OverrideConflict(ctx context.Context, id primitive.ObjectID, value json.RawMessage, reviewer string) (*model.FieldConflict, error) {
+	conflict, err := s.openConflict(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(value) > 0 {
+		reason := fmt.Sprintf("Overrode %s value of %s", conflict.ProposedSource.Source, conflict.Field)
+		if err := s.applyResolution(ctx, conflict, value, model.SourceManual, reviewer, reason); err != nil {
+			return nil, err
+		}
+	}
+
+	conflict.Resolve(model.ConflictOverridden, reviewer)
+	if err := s.reconciliationRepo.SaveConflict(ctx, conflict); err != nil {
+		return nil, err
+	}
+
+	return conflict, nil
+}
+
+// openConflict finds a conflict that still waits for review
+func (s *ReconciliationService) openConflict(ctx context.Context, id primitive.ObjectID) (*model.FieldConflict, error) {
+	conflict, err := s.reconciliationRepo.FindConflictByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !conflict.IsOpen() {
+		return nil, model.ErrConflictResolved
+	}
+	return conflict, nil
+}
+
+// applyResolution sets the field of a conflict on its asset and saves it, which records the
+// change in the asset history with the reason
+func (s *ReconciliationService) applyResolution(ctx context.Context, conflict *model.FieldConflict, value json.RawMessage, source, reporter, reason string) error {
+	asset, err := s.assetRepo.FindByID(ctx, conflict.AssetID)
+	if err != nil {
+		return err
+	}
+
+	oldAsset := asset.Clone()
+	if err := asset.SetFieldValue(conflict.Field, value); err != nil {
+		return err
+	}
+	if _, err := s.ciTypeService.ValidateAsset(ctx, asset); err != nil {
+		return err
+	}
+	asset.SetFieldSource(conflict.Field, source, reporter)
+	asset.UpdatedAt = time.Now()
+
+	if len(model.DiffAssets(oldAsset, asset)) == 0 {
+		return nil
+	}
+	return s.assetRepo.Save(model.WithAssetChange(ctx, model.AssetChange{Reason: reason}), asset)
+}
+
+// GetMergeProposals gets merge proposals with optional filtering
+func (s *ReconciliationService) GetMergeProposals(ctx context.Context, filter map[string]interface{}) ([]*model.MergeProposal, error) {
+	return s.reconciliationRepo.FindMergeProposals(ctx, filter)
+}
+
+// DismissMergeProposal marks the assets of a proposal as distinct machines
+func (s *ReconciliationService) DismissMergeProposal(ctx context.Context, id primitive.ObjectID, reviewer string) (*model.MergeProposal, error) {
+	proposal, err := s.reconciliationRepo.FindMergeProposalByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !proposal.IsOpen() {
+		return nil, model.ErrMergeProposalReviewed
+	}
+
+	proposal.Dismiss(reviewer)
+	if err := s.reconciliationRepo.SaveMergeProposal(ctx, proposal); err != nil {
+		return nil, err
+	}
+
+	return proposal, nil
+}
+
+// StartScheduler runs duplicate detection periodically until ctx is cancelled
+func (s *ReconciliationService) StartScheduler(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				proposals, err := s.DetectDuplicates(ctx)
+				if err != nil {
+					logging.Logger.Error("reconciliation_detection_failed", zap.Error(err))
+					continue
+				}
+				logging.Logger.Debug("reconciliation_detection_finished", zap.Int("open_proposals", len(proposals)))
+			}
+		}
+	}()
+}
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ImpactDirection controls which way the relationship graph is walked
+type ImpactDirection string
+
+// Impact direction constants. Every relationship means "source relies on target",
+// so upstream follows outgoing edges and downstream follows incoming edges.
+const (
+	// ImpactUpstream walks what the asset depends on
+	ImpactUpstream ImpactDirection = "upstream"
+	// ImpactDownstream walks what depends on the asset, i.e. what breaks if it goes down
+	ImpactDownstream ImpactDirection = "downstream"
+	// ImpactBoth walks both directions
+	ImpactBoth ImpactDirection = "both"
+)
+
+// Impact analysis depth limits
+const (
+	DefaultImpactDepth = 3
+	MaxImpactDepth     = 10
+)
+
+// ImpactNode represents an asset reached while walking the relationship graph
+type ImpactNode struct {
+	Asset        *model.Asset
+	Depth        int
+	ParentID     primitive.ObjectID
+	Relationship *model.Relationship
+}
+
+// ImpactAnalysis is the result of walking the relationship graph from an asset
+type ImpactAnalysis struct {
+	Asset      *model.Asset
+	Depth      int
+	Upstream   []*ImpactNode
+	Downstream []*ImpactNode
+}
+
+// RelationshipService provides domain logic for asset relationships
+type RelationshipService struct {
+	relationshipRepo repository.RelationshipRepository
+	assetRepo        repository.AssetRepository
+}
+
+// NewRelationshipService creates a new relationship service
+func NewRelationshipService(relationshipRepo repository.RelationshipRepository, assetRepo repository.AssetRepository) *RelationshipService {
+	return &RelationshipService{
+		relationshipRepo: relationshipRepo,
+		assetRepo:        assetRepo,
+	}
+}
+
+// CreateRelationship creates a directed relationship between two assets
+func (s *RelationshipService) CreateRelationship(ctx context.Context, sourceID, targetID primitive.ObjectID, relationshipType string, description, createdBy, createdByID string) (*model.Relationship, error) {
+	relType := model.RelationshipType(relationshipType)
+	if !relType.IsValid() {
+		return nil, fmt.Errorf("unknown relationship type: %s", relationshipType)
+	}
+
+	if sourceID == targetID {
+		return nil, errors.New("an asset cannot have a relationship with itself")
+	}
+
+	// Both ends must exist
+	if _, err := s.assetRepo.FindByID(ctx, sourceID); err != nil {
+		return nil, fmt.Errorf("source asset not found: %w", err)
+	}
+	if _, err := s.assetRepo.FindByID(ctx, targetID); err != nil {
+		return nil, fmt.Errorf("target asset not found: %w", err)
+	}
+
+	// Check for duplicates
+	exists, err := s.relationshipRepo.Exists(ctx, sourceID, targetID, relType)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, errors.New("relationship already exists")
+	}
+
+	relationship := model.NewRelationship(sourceID, targetID, relType, description, createdBy, createdByID)
+
+	// Save relationship
+	if err := s.relationshipRepo.Save(ctx, relationship); err != nil {
+		return nil, err
+	}
+
+	return relationship, nil
+}
+
+// DeleteRelationship deletes a relationship belonging to an asset
+func (s *RelationshipService) DeleteRelationship(ctx context.Context, assetID, relationshipID primitive.ObjectID) error {
+	relationship, err := s.relationshipRepo.FindByID(ctx, relationshipID)
+	if err != nil {
+		return err
+	}
+
+	if !relationship.Involves(assetID) {
+		return errors.New("relationship does not belong to this asset")
+	}
+
+	return s.relationshipRepo.Delete(ctx, relationshipID)
+}
+
+// AssetDeleted removes the relationships of a deleted asset, so that impact analysis does not
+// walk edges to an asset that no longer exists
+func (s *RelationshipService) AssetDeleted(ctx context.Context, id primitive.ObjectID) error {
+	return s.relationshipRepo.DeleteByAssetID(ctx, id)
+}
+
+// GetAssetRelationships gets all relationships where the asset is either source or target
+func (s *RelationshipService) GetAssetRelationships(ctx context.Context, assetID primitive.ObjectID) ([]*model.Relationship, error) {
+	if _, err := s.assetRepo.FindByID(ctx, assetID); err != nil {
+		return nil, err
+	}
+	return s.relationshipRepo.FindByAssetID(ctx, assetID)
+}
+
+// AnalyzeImpact walks the relationship graph from an asset up to the given depth
+func (s *RelationshipService) AnalyzeImpact(ctx context.Context, assetID primitive.ObjectID, direction ImpactDirection, depth int) (*ImpactAnalysis, error) {
+	switch direction {
+	case ImpactUpstream, ImpactDownstream, ImpactBoth:
+	case "":
+		direction = ImpactBoth
+	default:
+		return nil, fmt.Errorf("unknown impact direction: %s", direction)
+	}
+
+	if depth <= 0 {
+		depth = DefaultImpactDepth
+	}
+	if depth > MaxImpactDepth {
+		depth = MaxImpactDepth
+	}
+
+	asset, err := s.assetRepo.FindByID(ctx, assetID)
+	if err != nil {
+		return nil, err
+	}
+
+	analysis := &ImpactAnalysis{
+		Asset: asset,
+		Depth: depth,
+	}
+
+	if direction == ImpactUpstream || direction == ImpactBoth {
+		analysis.Upstream, err = s.walk(ctx, assetID, ImpactUpstream, depth)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if direction == ImpactDownstream || direction == ImpactBoth {
+		analysis.Downstream, err = s.walk(ctx, assetID, ImpactDownstream, depth)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return analysis, nil
+}
+
+// walk performs a breadth-first traversal in one direction, visiting each asset once
+func (s *RelationshipService) walk(ctx context.Context, rootID primitive.ObjectID, direction ImpactDirection, maxDepth int) ([]*ImpactNode, error) {
+	visited := map[primitive.ObjectID]bool{rootID: true}
+	frontier := []primitive.ObjectID{rootID}
+	nodes := make([]*ImpactNode, 0)
+
+	for depth := 1; depth <= maxDepth && len(frontier) > 0; depth++ {
+		var next []primitive.ObjectID
+
+		for _, currentID := range frontier {
+			var edges []*model.Relationship
+			var err error
+			if direction == ImpactUpstream {
+				edges, err = s.relationshipRepo.FindBySourceID(ctx, currentID)
+			} else {
+				edges, err = s.relationshipRepo.FindByTargetID(ctx, currentID)
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			for _, edge := range edges {
+				neighbourID := edge.OtherEnd(currentID)
+				if visited[neighbourID] {
+					continue
+				}
+				visited[neighbourID] = true
+
+				asset, err := s.assetRepo.FindByID(ctx, neighbourID)
+				if err != nil {
+					// Dangling edge to a removed asset; skip it
+					continue
+				}
+
+				nodes = append(nodes, &ImpactNode{
+					Asset:        asset,
+					Depth:        depth,
+					ParentID:     currentID,
+					Relationship: edge,
+				})
+				next = append(next, neighbourID)
+			}
+		}
+
+		frontier = next
+	}
+
+	return nodes, nil
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/repository"
+	"github.com/phuhao00/cmdb/backend/infrastructure/logging"
+	"go.uber.org/zap"
+)
+
+// TokenRevocationList keeps the active access token revocations in memory, so that access tokens
+// are checked without a database query. Revocations made by other instances are picked up when
+// the list is reloaded.
+type TokenRevocationList struct {
+	repo repository.TokenRevocationRepository
+
+	mu       sync.RWMutex
+	sessions map[string]*model.TokenRevocation
+	// users holds the latest revocation of each user
+	users map[string]*model.TokenRevocation
+}
+
+// NewTokenRevocationList creates a new, empty token revocation list
+func NewTokenRevocationList(repo repository.TokenRevocationRepository) *TokenRevocationList {
+	return &TokenRevocationList{
+		repo:     repo,
+		sessions: map[string]*model.TokenRevocation{},
+		users:    map[string]*model.TokenRevocation{},
+	}
+}
+
+// Revoke persists a revocation and applies it at once
+func (l *TokenRevocationList) Revoke(ctx context.Context, revocation *model.TokenRevocation) error {
+	if err := l.repo.Create(ctx, revocation); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.add(revocation)
+	return nil
+}
+
+// IsRevoked reports whether an access token was revoked
+func (l *TokenRevocationList) IsRevoked(claims *model.AccessClaims) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if revocation, ok := l.sessions[claims.SessionID]; ok && revocation.Covers(claims) {
+		return true
+	}
+	if revocation, ok := l.users[claims.Subject]; ok && revocation.Covers(claims) {
+		return true
+	}
+	return false
+}
+
+// Load reads the active revocations, dropping the expired ones
+func (l *TokenRevocationList) Load(ctx context.Context) error {
+	revocations, err := l.repo.FindActive(ctx)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Revocations applied while the list was read are kept until they expire
+	for _, revocation := range l.sessions {
+		revocations = append(revocations, revocation)
+	}
+	for _, revocation := range l.users {
+		revocations = append(revocations, revocation)
+	}
+
+	now := time.Now()
+	l.sessions = map[string]*model.TokenRevocation{}
+	l.users = map[string]*model.TokenRevocation{}
+	for _, revocation := range revocations {
+		if revocation.ExpiresAt.After(now) {
+			l.add(revocation)
+		}
+	}
+	return nil
+}
+
+// StartRefreshLoop reloads the revocations periodically until the context is cancelled
+func (l *TokenRevocationList) StartRefreshLoop(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := l.Load(ctx); err != nil {
+					logging.Logger.Error("token_revocations_load_failed", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
+
+// add applies a revocation; the caller holds the lock
+func (l *TokenRevocationList) add(revocation *model.TokenRevocation) {
+	switch revocation.Kind {
+	case model.RevokedSession:
+		l.sessions[revocation.Value] = revocation
+	case model.RevokedUser:
+		if latest, ok := l.users[revocation.Value]; !ok || revocation.RevokedAt.After(latest.RevokedAt) {
+			l.users[revocation.Value] = revocation
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+)
+
+// tokenClockSkew is how far the clocks of the services that verify tokens may be off
+const tokenClockSkew = 30 * time.Second
+
+// minTokenKeyBits is the smallest RSA key tokens may be signed with
+const minTokenKeyBits = 2048
+
+// jwtHeader is the JOSE header of a signed access token
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+	Kid string `json:"kid"`
+}
+
+// TokenSigner signs access tokens as RS256 JSON Web Tokens and verifies them. Tokens signed by
+// any of its keys verify, so that a new signing key can be published before it is used and the
+// previous one kept until its tokens have expired.
+type TokenSigner struct {
+	issuer string
+	key    *rsa.PrivateKey
+	keyID  string
+	keys   map[string]*rsa.PublicKey
+	jwks   model.JSONWebKeySet
+}
+
+// NewTokenSigner creates a signer of the tokens of an issuer that signs with an RSA key, and also
+// accepts and publishes the verification keys
+func NewTokenSigner(issuer string, signingKey *rsa.PrivateKey, verificationKeys []*rsa.PublicKey) (*TokenSigner, error) {
+	signer := &TokenSigner{
+		issuer: issuer,
+		key:    signingKey,
+		keys:   map[string]*rsa.PublicKey{},
+	}
+	for _, publicKey := range append([]*rsa.PublicKey{&signingKey.PublicKey}, verificationKeys...) {
+		if publicKey.N.BitLen() < minTokenKeyBits {
+			return nil, fmt.Errorf("token keys must have at least %d bits, got %d", minTokenKeyBits, publicKey.N.BitLen())
+		}
+		jwk := newJSONWebKey(publicKey)
+		if _, ok := signer.keys[jwk.Kid]; ok {
+			continue
+		}
+		signer.keys[jwk.Kid] = publicKey
+		signer.jwks.Keys = append(signer.jwks.Keys, jwk)
+	}
+	signer.keyID = signer.jwks.Keys[0].Kid
+	return signer, nil
+}
+
+// KeyID returns the ID tokens signed now carry
+func (s *TokenSigner) KeyID() string {
+	return s.keyID
+}
+
+// JWKS returns the public keys tokens are verified with, the signing key first
+func (s *TokenSigner) JWKS() model.JSONWebKeySet {
+	return model.JSONWebKeySet{Keys: append([]model.JSONWebKey(nil), s.jwks.Keys...)}
+}
+
+// Sign signs the claims of an access token as issued by the signer's issuer
+func (s *TokenSigner) Sign(claims *model.AccessClaims) (string, error) {
+	claims.Issuer = s.issuer
+	header, err := json.Marshal(jwtHeader{Alg: "RS256", Typ: "JWT", Kid: s.keyID})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// Verify checks the signature, issuer and validity period of an access token and returns its
+// claims
+func (s *TokenSigner) Verify(token string) (*model.AccessClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, model.ErrInvalidToken
+	}
+
+	var header jwtHeader
+	if err := decodeTokenPart(parts[0], &header); err != nil {
+		return nil, err
+	}
+	// Only RS256 is accepted, whatever the token claims, so that it cannot pick a weaker check
+	if header.Alg != "RS256" {
+		return nil, fmt.Errorf("%w: unsupported algorithm %q", model.ErrInvalidToken, header.Alg)
+	}
+	publicKey, ok := s.keys[header.Kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown key %q", model.ErrInvalidToken, header.Kid)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, model.ErrInvalidToken
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest[:], signature); err != nil {
+		return nil, fmt.Errorf("%w: signature does not match", model.ErrInvalidToken)
+	}
+
+	var claims model.AccessClaims
+	if err := decodeTokenPart(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if claims.Issuer != s.issuer {
+		return nil, fmt.Errorf("%w: issued by %q", model.ErrInvalidToken, claims.Issuer)
+	}
+	now := time.Now()
+	if now.Add(tokenClockSkew).Unix() < claims.NotBefore {
+		return nil, fmt.Errorf("%w: not valid yet", model.ErrInvalidToken)
+	}
+	if now.Add(-tokenClockSkew).Unix() >= claims.ExpiresAt {
+		return nil, model.ErrTokenExpired
+	}
+	return &claims, nil
+}
+
+// decodeTokenPart decodes the header or claims of a token
+func decodeTokenPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return model.ErrInvalidToken
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return model.ErrInvalidToken
+	}
+	return nil
+}
+
+// newJSONWebKey describes an RSA public key as a JWK identified by its RFC 7638 thumbprint
+func newJSONWebKey(publicKey *rsa.PublicKey) model.JSONWebKey {
+	n := base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+	e := base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+
+	// The thumbprint covers the required members in lexicographic order, without whitespace
+	thumbprint := sha256.Sum256([]byte(`{"e":"` + e + `","kty":"RSA","n":"` + n + `"}`))
+	return model.JSONWebKey{
+		Kty: "RSA",
+		Use: "sig",
+		Alg: "RS256",
+		Kid: base64.RawURLEncoding.EncodeToString(thumbprint[:]),
+		N:   n,
+		E:   e,
+	}
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// testSigningKeys are generated once for the tests that sign tokens
+var testSigningKeys = sync.OnceValue(func() []*rsa.PrivateKey {
+	keys := make([]*rsa.PrivateKey, 3)
+	for i := range keys {
+		key, err := rsa.GenerateKey(rand.Reader, minTokenKeyBits)
+		if err != nil {
+			panic(err)
+		}
+		keys[i] = key
+	}
+	return keys
+})
+
+func newTestTokenSigner(t *testing.T, issuer string, key *rsa.PrivateKey, verificationKeys ...*rsa.PublicKey) *TokenSigner {
+	t.Helper()
+	signer, err := NewTokenSigner(issuer, key, verificationKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+// forgeToken builds a token with a header of its own and the signature of another token
+func forgeToken(t *testing.T, header jwtHeader, token string) string {
+	t.Helper()
+	data, err := json.Marshal(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(token, ".")
+	return base64.RawURLEncoding.EncodeToString(data) + "." + parts[1] + "." + parts[2]
+}
+
+func TestTokenSignerVerify(t *testing.T) {
+	keys := testSigningKeys()
+	signer := newTestTokenSigner(t, "cmdb", keys[0])
+
+	user := &model.User{ID: primitive.NewObjectID(), Username: "alice", Role: model.OperatorRole}
+	sign := func(t *testing.T, signer *TokenSigner, adjust func(*model.AccessClaims)) string {
+		t.Helper()
+		claims := model.NewAccessClaims(user, primitive.NewObjectID().Hex(), 15*time.Minute)
+		adjust(claims)
+		token, err := signer.Sign(claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	unchanged := func(*model.AccessClaims) {}
+
+	tests := []struct {
+		name    string
+		token   func(t *testing.T) string
+		wantErr error
+	}{
+		{
+			name:  "valid",
+			token: func(t *testing.T) string { return sign(t, signer, unchanged) },
+		},
+		{
+			name: "signed by a verification key",
+			token: func(t *testing.T) string {
+				previous := newTestTokenSigner(t, "cmdb", keys[1])
+				return sign(t, previous, unchanged)
+			},
+		},
+		{
+			name: "HS256",
+			token: func(t *testing.T) string {
+				return forgeToken(t, jwtHeader{Alg: "HS256", Typ: "JWT", Kid: signer.KeyID()}, sign(t, signer, unchanged))
+			},
+			wantErr: model.ErrInvalidToken,
+		},
+		{
+			name: "none",
+			token: func(t *testing.T) string {
+				token := forgeToken(t, jwtHeader{Alg: "none", Typ: "JWT", Kid: signer.KeyID()}, sign(t, signer, unchanged))
+				return token[:strings.LastIndex(token, ".")+1]
+			},
+			wantErr: model.ErrInvalidToken,
+		},
+		{
+			name: "unknown key",
+			token: func(t *testing.T) string {
+				return sign(t, newTestTokenSigner(t, "cmdb", keys[2]), unchanged)
+			},
+			wantErr: model.ErrInvalidToken,
+		},
+		{
+			name: "unknown key ID for a known key",
+			token: func(t *testing.T) string {
+				return forgeToken(t, jwtHeader{Alg: "RS256", Typ: "JWT", Kid: "other"}, sign(t, signer, unchanged))
+			},
+			wantErr: model.ErrInvalidToken,
+		},
+		{
+			name: "wrong issuer",
+			token: func(t *testing.T) string {
+				return sign(t, newTestTokenSigner(t, "someone-else", keys[0]), unchanged)
+			},
+			wantErr: model.ErrInvalidToken,
+		},
+		{
+			name: "tampered claims",
+			token: func(t *testing.T) string {
+				token := sign(t, signer, unchanged)
+				admin := sign(t, signer, func(claims *model.AccessClaims) { claims.Role = model.AdminRole })
+				parts, adminParts := strings.Split(token, "."), strings.Split(admin, ".")
+				return parts[0] + "." + adminParts[1] + "." + parts[2]
+			},
+			wantErr: model.ErrInvalidToken,
+		},
+		{
+			name: "expired",
+			token: func(t *testing.T) string {
+				return sign(t, signer, func(claims *model.AccessClaims) {
+					claims.ExpiresAt = time.Now().Add(-time.Minute).Unix()
+				})
+			},
+			wantErr: model.ErrTokenExpired,
+		},
+		{
+			name: "expired within the clock skew",
+			token: func(t *testing.T) string {
+				return sign(t, signer, func(claims *model.AccessClaims) {
+					claims.ExpiresAt = time.Now().Add(-tokenClockSkew / 2).Unix()
+				})
+			},
+		},
+		{
+			name: "not valid yet",
+			token: func(t *testing.T) string {
+				return sign(t, signer, func(claims *model.AccessClaims) {
+					claims.NotBefore = time.Now().Add(time.Minute).Unix()
+				})
+			},
+			wantErr: model.ErrInvalidToken,
+		},
+	}
+
+	// The signer accepts the previous key while new tokens are signed with its own
+	verifier := newTestTokenSigner(t, "cmdb", keys[0], &keys[1].PublicKey)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := verifier.Verify(tt.token(t))
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("Verify() error = %v", err)
+				}
+				if claims.Subject != user.ID.Hex() || claims.Role != model.OperatorRole {
+					t.Errorf("Verify() claims = %+v", claims)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
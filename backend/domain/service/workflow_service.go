@@ -4,25 +4,117 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"time"
+	"sort"
+	"strings"
 
 	"github.com/phuhao00/cmdb/backend/domain/model"
 	"github.com/phuhao00/cmdb/backend/domain/repository"
+	"github.com/phuhao00/cmdb/backend/infrastructure/logging"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
 )
 
+// ErrNotStageApprover is returned when a user may not decide the current approval stage of a workflow
+var ErrNotStageApprover = errors.New("user is not an approver for the current approval stage")
+
+// ErrWorkflowDecidedConcurrently is returned when a workflow was decided by someone else while
+// a decision on it was being made
+var ErrWorkflowDecidedConcurrently = errors.New("workflow was decided concurrently")
+
+// ErrUnlinkedChannelApprover is returned when an operator who is not linked to a user decides
+// a workflow with several approval stages through a channel
+var ErrUnlinkedChannelApprover = errors.New("channel operator is not linked to a user")
+
+// Approver identifies who decides an approval stage. Decisions relayed by an approval
+// channel carry the channel name, and the user linked to the operator's account there if
+// any; the SLA sweep uses the "sla" channel for its timeout decisions.
+type Approver struct {
+	ID       string
+	Username string
+	Name     string
+	Role     model.UserRole
+	Channel  string
+}
+
 // WorkflowService provides domain logic for workflows
 type WorkflowService struct {
-	workflowRepo repository.WorkflowRepository
-	assetRepo    repository.AssetRepository
+	workflowRepo  repository.WorkflowRepository
+	assetRepo     repository.AssetRepository
+	policyService *ApprovalPolicyService
+	idService     *IDService
+	ciTypeService *CITypeService
+	lifecycle     *model.Lifecycle
+	userRepo      repository.UserRepository
+
+	auditLogService *AuditLogService
+
+	approvalChannels map[string]ApprovalChannel
+	defaultChannel   string
+
+	slaConfig WorkflowSLAConfig
 }
 
 // NewWorkflowService creates a new workflow service
-func NewWorkflowService(workflowRepo repository.WorkflowRepository, assetRepo repository.AssetRepository) *WorkflowService {
+func NewWorkflowService(workflowRepo repository.WorkflowRepository, assetRepo repository.AssetRepository, policyService *ApprovalPolicyService, idService *IDService, ciTypeService *CITypeService, auditLogService *AuditLogService) *WorkflowService {
 	return &WorkflowService{
-		workflowRepo: workflowRepo,
-		assetRepo:    assetRepo,
+		workflowRepo:  workflowRepo,
+		assetRepo:     assetRepo,
+		policyService: policyService,
+		idService:     idService,
+		ciTypeService: ciTypeService,
+		lifecycle:     model.DefaultLifecycle(),
+
+		auditLogService: auditLogService,
+
+		approvalChannels: make(map[string]ApprovalChannel),
+		slaConfig:        DefaultWorkflowSLAConfig(),
+	}
+}
+
+// SetUserRepository sets where the users linked to approval channel operators are looked up.
+// Without it only workflows with a single approval stage can be decided through a channel.
+func (s *WorkflowService) SetUserRepository(userRepo repository.UserRepository) {
+	s.userRepo = userRepo
+}
+
+// SetLifecycle replaces the asset lifecycle enforced when approved workflows change an asset status
+func (s *WorkflowService) SetLifecycle(lifecycle *model.Lifecycle) {
+	s.lifecycle = lifecycle
+}
+
+// RegisterApprovalChannel makes an approval channel available for submissions and callbacks.
+// The first registered channel becomes the default.
+func (s *WorkflowService) RegisterApprovalChannel(channel ApprovalChannel) {
+	s.approvalChannels[channel.Name()] = channel
+	if s.defaultChannel == "" {
+		s.defaultChannel = channel.Name()
+	}
+}
+
+// SetDefaultApprovalChannel sets the channel used when a submission does not name one
+func (s *WorkflowService) SetDefaultApprovalChannel(name string) error {
+	if _, ok := s.approvalChannels[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrApprovalChannelNotConfigured, name)
+	}
+	s.defaultChannel = name
+	return nil
+}
+
+// HasApprovalChannel reports whether an approval channel with the given name is configured
+func (s *WorkflowService) HasApprovalChannel(name string) bool {
+	_, ok := s.approvalChannels[name]
+	return ok
+}
+
+// GetApprovalChannels returns the names of the configured approval channels and the default one
+func (s *WorkflowService) GetApprovalChannels() ([]string, string) {
+	names := make([]string, 0, len(s.approvalChannels))
+	for name := range s.approvalChannels {
+		names = append(names, name)
 	}
+	sort.Strings(names)
+	return names, s.defaultChannel
 }
 
 // CreateWorkflow creates a new workflow
@@ -45,96 +137,243 @@ func (s *WorkflowService) CreateWorkflow(ctx context.Context, workflowType strin
 		data,
 	)
 
-	// Generate workflow ID
-	workflowID, err := s.workflowRepo.GenerateWorkflowID(ctx)
-	if err != nil {
+	// Resolve the approval stages from the matching policy
+	if err := s.policyService.AssignStages(ctx, workflow); err != nil {
 		return nil, err
 	}
-	workflow.WorkflowID = workflowID
 
-	// Save workflow
-	if err := s.workflowRepo.Save(ctx, workflow); err != nil {
+	// Generate workflow ID and save workflow
+	if err := s.idService.SaveNewWorkflow(ctx, workflow); err != nil {
 		return nil, err
 	}
+	s.auditLogService.logWorkflowCreated(ctx, workflow)
 
 	return workflow, nil
 }
 
-// ApproveWorkflow approves a workflow and executes the associated action
-func (s *WorkflowService) ApproveWorkflow(ctx context.Context, workflowID, approverID, approverName, comments string) error {
-	objectID, err := primitive.ObjectIDFromHex(workflowID)
+// ApproveWorkflow approves the current approval stage of a workflow. The associated action
+// is executed once the final stage is approved; otherwise the workflow moves on to the next
+// stage and, if it came from an approval channel, is submitted there again.
+func (s *WorkflowService) ApproveWorkflow(ctx context.Context, workflowID string, approver Approver, comments string) error {
+	workflow, err := s.findPendingWorkflow(ctx, workflowID)
 	if err != nil {
 		return err
 	}
 
-	// Find workflow
-	workflow, err := s.workflowRepo.FindByID(ctx, objectID)
+	// Check the approver may decide the current stage
+	if err := s.checkStageApprover(workflow, approver); err != nil {
+		return err
+	}
+
+	// Approve the current stage
+	stage := workflow.CurrentStage
+	final := workflow.ApproveStage(approver.ID, approver.Name, comments)
+
+	// Save workflow, unless another decision got there first
+	if err := s.savePendingWorkflow(ctx, workflow, stage); err != nil {
+		return err
+	}
+	client := model.AuditClientFromContext(ctx)
+	logAuditFailure(model.WorkflowApproved, s.auditLogService.LogWorkflowApproved(ctx, approver.ID, approver.Name, workflow, client.IPAddress, client.UserAgent))
+
+	if !final {
+		if workflow.ApprovalChannel != "" {
+			// The next stage is decided in the same channel; a failed submission leaves it
+			// to be decided in the CMDB
+			_, _ = s.SubmitForApproval(ctx, workflow, workflow.ApprovalChannel)
+		}
+		return nil
+	}
+
+	// Execute approved action
+	return s.completeApprovedWorkflow(ctx, workflow)
+}
+
+// RejectWorkflow rejects the current approval stage, which rejects the whole workflow
+func (s *WorkflowService) RejectWorkflow(ctx context.Context, workflowID string, approver Approver, comments string) error {
+	workflow, err := s.findPendingWorkflow(ctx, workflowID)
 	if err != nil {
 		return err
 	}
 
-	// Check if workflow is already processed
-	if !workflow.IsPending() {
-		return errors.New("workflow is already processed")
+	// Check the approver may decide the current stage
+	if err := s.checkStageApprover(workflow, approver); err != nil {
+		return err
 	}
 
-	// Approve workflow
-	workflow.Approve(approverID, approverName, comments)
+	// Reject workflow
+	stage := workflow.CurrentStage
+	workflow.Reject(approver.ID, approver.Name, comments)
 
-	// Save workflow
-	if err := s.workflowRepo.Save(ctx, workflow); err != nil {
+	// Save workflow, unless another decision got there first
+	if err := s.savePendingWorkflow(ctx, workflow, stage); err != nil {
 		return err
 	}
+	client := model.AuditClientFromContext(ctx)
+	logAuditFailure(model.WorkflowRejected, s.auditLogService.LogWorkflowRejected(ctx, approver.ID, approver.Name, workflow, comments, client.IPAddress, client.UserAgent))
 
-	// Execute approved action
-	return s.executeApprovedAction(ctx, workflow)
+	return nil
 }
 
-// RejectWorkflow rejects a workflow
-func (s *WorkflowService) RejectWorkflow(ctx context.Context, workflowID, approverID, approverName, comments string) error {
-	objectID, err := primitive.ObjectIDFromHex(workflowID)
+// savePendingWorkflow saves a workflow read while pending at a stage, failing with
+// ErrWorkflowDecidedConcurrently when it has been decided or moved on since
+func (s *WorkflowService) savePendingWorkflow(ctx context.Context, workflow *model.Workflow, stage int) error {
+	updated, err := s.workflowRepo.UpdatePending(ctx, workflow, stage)
 	if err != nil {
 		return err
 	}
+	if !updated {
+		return ErrWorkflowDecidedConcurrently
+	}
+	return nil
+}
+
+// findPendingWorkflow finds a workflow by its hex ID and checks that it still awaits a decision
+func (s *WorkflowService) findPendingWorkflow(ctx context.Context, workflowID string) (*model.Workflow, error) {
+	objectID, err := primitive.ObjectIDFromHex(workflowID)
+	if err != nil {
+		return nil, err
+	}
 
 	// Find workflow
 	workflow, err := s.workflowRepo.FindByID(ctx, objectID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Check if workflow is already processed
 	if !workflow.IsPending() {
-		return errors.New("workflow is already processed")
+		return nil, errors.New("workflow is already processed")
 	}
 
-	// Reject workflow
-	workflow.Reject(approverID, approverName, comments)
+	return workflow, nil
+}
 
-	// Save workflow
-	if err := s.workflowRepo.Save(ctx, workflow); err != nil {
-		return err
+// checkStageApprover checks that the approver may decide the current stage of a workflow.
+// Operators of a channel who are not linked to a user are trusted on single-stage workflows
+// only, where the channel's own approvers stand in for the stage.
+func (s *WorkflowService) checkStageApprover(workflow *model.Workflow, approver Approver) error {
+	if approver.Channel == slaChannel {
+		return nil
+	}
+	if approver.Channel != "" && approver.Username == "" {
+		if len(workflow.Stages) > 1 {
+			return ErrUnlinkedChannelApprover
+		}
+		return nil
+	}
+
+	stage := workflow.CurrentApprovalStage()
+	if stage == nil || !stage.CanBeDecidedBy(approver.Username, approver.Role) {
+		return ErrNotStageApprover
 	}
 
 	return nil
 }
 
-// HandleFeishuWebhook handles a webhook from Feishu
-func (s *WorkflowService) HandleFeishuWebhook(ctx context.Context, workflowID string, status string) error {
-	// Find workflow by Feishu ID (workflowID from webhook is actually Feishu ID)
-	workflow, err := s.workflowRepo.FindByFeishuID(ctx, workflowID)
+// HandleApprovalCallback verifies a callback from an approval channel and applies its decision
+// to the workflow it belongs to. The returned callback carries any reply the channel expects.
+func (s *WorkflowService) HandleApprovalCallback(ctx context.Context, channelName string, req *ApprovalCallbackRequest) (*ApprovalCallback, error) {
+	channel, ok := s.approvalChannels[channelName]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrApprovalChannelNotConfigured, channelName)
+	}
+
+	callback, err := channel.ParseCallback(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Process based on status
-	if status == "approved" {
-		return s.ApproveWorkflow(ctx, workflow.ID.Hex(), "feishu-system", "Feishu System", "Approved via Feishu")
-	} else if status == "rejected" {
-		return s.RejectWorkflow(ctx, workflow.ID.Hex(), "feishu-system", "Feishu System", "Rejected via Feishu")
+	// Handshakes and events without a decision need no workflow
+	if callback.ExternalID == "" || callback.Decision == "" {
+		return callback, nil
 	}
 
-	return nil
+	workflow, err := s.workflowRepo.FindByExternalID(ctx, channelName, callback.ExternalID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			// Not one of ours (or already purged); acknowledge so the channel stops retrying
+			logging.Logger.Warn("approval_callback_unknown_workflow",
+				zap.String("channel", channelName),
+				zap.String("external_id", callback.ExternalID))
+			return callback, nil
+		}
+		return nil, err
+	}
+
+	// Callbacks are retried, so decisions on already processed workflows are ignored
+	if !workflow.IsPending() {
+		return callback, nil
+	}
+
+	approver, err := s.channelApprover(ctx, channelName, callback)
+	if err != nil {
+		return nil, err
+	}
+
+	switch callback.Decision {
+	case ApprovalDecisionApproved:
+		comments := callback.Comments
+		if comments == "" {
+			comments = "Approved via " + channelName
+		}
+		err = s.ApproveWorkflow(ctx, workflow.ID.Hex(), approver, comments)
+	case ApprovalDecisionRejected:
+		comments := callback.Comments
+		if comments == "" {
+			comments = "Rejected via " + channelName
+		}
+		err = s.RejectWorkflow(ctx, workflow.ID.Hex(), approver, comments)
+	default:
+		err = fmt.Errorf("%w: unknown decision %q", ErrInvalidApprovalCallback, callback.Decision)
+	}
+	if errors.Is(err, ErrNotStageApprover) || errors.Is(err, ErrUnlinkedChannelApprover) || errors.Is(err, ErrWorkflowDecidedConcurrently) {
+		// Acknowledged so the channel stops retrying; the workflow stays pending
+		logging.Logger.Warn("approval_callback_refused",
+			zap.String("channel", channelName),
+			zap.String("workflow_id", workflow.WorkflowID),
+			zap.String("operator_id", callback.OperatorID),
+			zap.Error(err))
+		return callback, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return callback, nil
+}
+
+// channelApprover identifies the operator of a channel decision, as the user linked to their
+// account at the channel when there is one
+func (s *WorkflowService) channelApprover(ctx context.Context, channelName string, callback *ApprovalCallback) (Approver, error) {
+	if s.userRepo != nil && callback.OperatorID != "" {
+		user, err := s.userRepo.GetByChannelID(ctx, channelName, callback.OperatorID)
+		if err != nil {
+			return Approver{}, err
+		}
+		if user != nil && user.IsActive() {
+			return Approver{
+				ID:       user.ID.Hex(),
+				Username: user.Username,
+				Name:     user.FullName,
+				Role:     user.Role,
+				Channel:  channelName,
+			}, nil
+		}
+	}
+
+	approver := Approver{
+		ID:      channelName + "-system",
+		Name:    callback.OperatorName,
+		Channel: channelName,
+	}
+	if callback.OperatorID != "" {
+		approver.ID = channelName + ":" + callback.OperatorID
+	}
+	if approver.Name == "" {
+		approver.Name = strings.ToUpper(channelName[:1]) + channelName[1:] + " System"
+	}
+	return approver, nil
 }
 
 // GetWorkflowStats gets workflow statistics by status
@@ -152,54 +391,229 @@ func (s *WorkflowService) GetAssetWorkflowHistory(ctx context.Context, assetID s
 	return s.workflowRepo.FindByAssetID(ctx, assetID)
 }
 
-// executeApprovedAction executes the action associated with an approved workflow
-func (s *WorkflowService) executeApprovedAction(ctx context.Context, workflow *model.Workflow) error {
+// completeApprovedWorkflow executes an approved workflow and records the outcome as completed or failed
+func (s *WorkflowService) completeApprovedWorkflow(ctx context.Context, workflow *model.Workflow) error {
+	result, execErr := s.executeApprovedAction(ctx, workflow)
+	if execErr != nil {
+		workflow.Fail(execErr.Error())
+		logging.Logger.Error("workflow_execution_failed",
+			zap.String("workflow_id", workflow.WorkflowID),
+			zap.String("type", string(workflow.Type)),
+			zap.Error(execErr))
+	} else {
+		workflow.Complete(result)
+	}
+
+	if err := s.workflowRepo.Save(ctx, workflow); err != nil {
+		return err
+	}
+	client := model.AuditClientFromContext(ctx)
+	logAuditFailure(model.WorkflowCompleted, s.auditLogService.LogWorkflowCompleted(ctx, workflow.ApproverID, workflow.ApproverName, workflow, client.IPAddress, client.UserAgent))
+
+	if execErr != nil {
+		return fmt.Errorf("workflow approved but execution failed: %w", execErr)
+	}
+	return nil
+}
+
+// executeApprovedAction applies the change of an approved workflow to its asset and records
+// it in the asset history and the audit log, attributed to the requester and the approver. It returns a short
+// description of what was done.
+func (s *WorkflowService) executeApprovedAction(ctx context.Context, workflow *model.Workflow) (string, error) {
 	// Find asset
 	asset, err := s.assetRepo.FindByAssetID(ctx, workflow.AssetID)
 	if err != nil {
-		return err
+		return "", err
+	}
+	before := *asset
+
+	// The change is made by the requester and recorded with the approval
+	ctx = model.WithActor(ctx, model.Actor{ID: workflow.RequesterID, Name: workflow.Requester})
+	change := model.AssetChange{
+		Type:         model.ChangeTypeStatusChange,
+		Reason:       workflow.Reason,
+		WorkflowID:   workflow.WorkflowID,
+		ApprovedBy:   workflow.ApproverName,
+		ApprovedByID: workflow.ApproverID,
 	}
 
 	// Execute action based on workflow type
-	if workflow.IsAssetOnboarding() {
+	switch {
+	case workflow.IsAssetOnboarding():
 		// Set asset status to online
-		asset.SetStatus(model.OnlineStatus)
-	} else if workflow.IsAssetDecommission() {
+		if err := s.transition(asset, model.OnlineStatus); err != nil {
+			return "", err
+		}
+	case workflow.IsAssetDecommission():
 		// Set asset status to decommissioned
-		asset.SetStatus(model.DecommissionedStatus)
-	} else if workflow.IsStatusChange() {
-		// Toggle asset status between online and offline
-		if asset.IsOnline() {
-			asset.SetStatus(model.OfflineStatus)
-		} else {
-			asset.SetStatus(model.OnlineStatus)
-		}
-	} else if workflow.IsMaintenanceRequest() {
+		if err := s.transition(asset, model.DecommissionedStatus); err != nil {
+			return "", err
+		}
+	case workflow.IsStatusChange():
+		// Move to the requested status; workflows without one toggle between online and offline
+		payload, err := workflow.Payload()
+		if err != nil && !errors.Is(err, model.ErrMissingWorkflowPayload) {
+			return "", err
+		}
+		target := model.OnlineStatus
+		if payload != nil && payload.TargetStatus != "" {
+			target = payload.TargetStatus
+			if payload.Disposal != nil {
+				asset.Disposal = payload.Disposal
+			}
+		} else if asset.IsOnline() {
+			target = model.OfflineStatus
+		}
+		if err := s.transition(asset, target); err != nil {
+			return "", err
+		}
+	case workflow.IsMaintenanceRequest():
 		// Set asset status to maintenance
-		asset.SetStatus(model.MaintenanceStatus)
-	} else {
-		return fmt.Errorf("unknown workflow type: %s", workflow.Type)
+		if err := s.transition(asset, model.MaintenanceStatus); err != nil {
+			return "", err
+		}
+	case workflow.IsAssetCreate():
+		// Apply the requested fields to the registered asset and bring it online
+		payload, err := workflow.Payload()
+		if err != nil {
+			return "", err
+		}
+		if payload.Requested == nil {
+			return "", model.ErrMissingWorkflowPayload
+		}
+		asset.Update(payload.Requested.Name, payload.Requested.Location, payload.Requested.Description)
+		if payload.Requested.Type != "" {
+			asset.Type = payload.Requested.Type
+		}
+		if payload.Requested.Attributes != nil {
+			asset.Attributes = payload.Requested.Attributes
+		}
+		if _, err := s.ciTypeService.ValidateAsset(ctx, asset); err != nil {
+			return "", err
+		}
+		if err := s.transition(asset, model.OnlineStatus); err != nil {
+			return "", err
+		}
+		change.Type = model.ChangeTypeCreate
+	case workflow.IsAssetUpdate():
+		// Apply the requested fields unless the asset changed since the update was requested
+		payload, err := workflow.Payload()
+		if err != nil {
+			return "", err
+		}
+		if payload.Original == nil || payload.Requested == nil {
+			return "", model.ErrMissingWorkflowPayload
+		}
+		if payload.ConflictsWith(asset) {
+			return "", errors.New("asset was changed after the update was requested")
+		}
+		asset.Update(payload.Requested.Name, payload.Requested.Location, payload.Requested.Description)
+		if payload.Requested.Attributes != nil {
+			asset.Attributes = payload.Requested.Attributes
+		}
+		// The schema may have changed while the workflow was pending
+		if _, err := s.ciTypeService.ValidateAsset(ctx, asset); err != nil {
+			return "", err
+		}
+		asset.RecordFieldSources(&before, model.SourceManual, workflow.Requester)
+		change.Type = model.ChangeTypeUpdate
+	case workflow.IsAssetRevert():
+		// Restore the fields of the revision unless the asset changed since the revert was requested
+		payload, err := workflow.Payload()
+		if err != nil {
+			return "", err
+		}
+		if payload.Revert == nil {
+			return "", model.ErrMissingWorkflowPayload
+		}
+		reverted := asset.Clone()
+		if err := payload.Revert.Apply(reverted); err != nil {
+			return "", err
+		}
+		// The status still has to follow the lifecycle
+		target := reverted.Status
+		reverted.Status = before.Status
+		*asset = *reverted
+		if target != asset.Status {
+			if err := s.transition(asset, target); err != nil {
+				return "", err
+			}
+		}
+		if _, err := s.ciTypeService.ValidateAsset(ctx, asset); err != nil {
+			return "", err
+		}
+		asset.RecordFieldSources(&before, model.SourceManual, workflow.Requester)
+		change.Type = model.ChangeTypeRevert
+		change.RevertedTo = payload.Revert.RevisionID
+	case workflow.IsAssetDelete():
+		// Delete the asset, keeping its last values in the history
+		if err := s.assetRepo.Delete(model.WithAssetChange(ctx, change), asset.ID); err != nil {
+			return "", err
+		}
+		s.auditLogService.logAssetDeleted(ctx, asset)
+		return fmt.Sprintf("Asset %s deleted", asset.AssetID), nil
+	default:
+		return "", fmt.Errorf("unknown workflow type: %s", workflow.Type)
+	}
+
+	// Save asset, which records the change in the asset history
+	changes := model.DiffAssets(&before, asset)
+	if err := s.assetRepo.Save(model.WithAssetChange(ctx, change), asset); err != nil {
+		return "", err
 	}
+	s.auditLogService.logAssetChange(ctx, &before, asset)
 
-	// Save asset
-	return s.assetRepo.Save(ctx, asset)
+	changed := make([]string, len(changes))
+	for i, fieldChange := range changes {
+		changed[i] = fieldChange.FieldName
+	}
+	if len(changed) == 0 {
+		return fmt.Sprintf("Asset %s already up to date", asset.AssetID), nil
+	}
+	return fmt.Sprintf("Asset %s changed: %s", asset.AssetID, strings.Join(changed, ", ")), nil
 }
 
-// SubmitToFeishu submits a workflow to Feishu (simulated)
-func (s *WorkflowService) SubmitToFeishu(ctx context.Context, workflow *model.Workflow) (string, error) {
-	// Simulate Feishu API call
-	// In real implementation, this would make HTTP request to Feishu API
-	feishuID := fmt.Sprintf("FEISHU-%d", time.Now().Unix())
+// transition moves the asset to a status if the lifecycle allows it. Approval has already been
+// given, so whether the transition requires it no longer matters.
+func (s *WorkflowService) transition(asset *model.Asset, to model.AssetStatus) error {
+	if _, err := s.lifecycle.CheckTransition(asset, to); err != nil {
+		return err
+	}
+	asset.SetStatus(to)
+	return nil
+}
+
+// SubmitForApproval sends the workflow to an approval channel and records the channel and
+// external ID on it. An empty channel name selects the default channel; ErrApprovalChannelNotConfigured
+// is returned when no matching channel has been configured.
+func (s *WorkflowService) SubmitForApproval(ctx context.Context, workflow *model.Workflow, channelName string) (string, error) {
+	if channelName == "" {
+		channelName = s.defaultChannel
+	}
+
+	channel, ok := s.approvalChannels[channelName]
+	if !ok {
+		return "", ErrApprovalChannelNotConfigured
+	}
+
+	externalID, err := channel.Submit(ctx, workflow)
+	if err != nil {
+		logging.Logger.Error("approval_submit_failed",
+			zap.String("channel", channelName),
+			zap.String("workflow_id", workflow.WorkflowID),
+			zap.Error(err))
+		return "", err
+	}
 
-	// Update workflow with Feishu ID
-	workflow.SetFeishuID(feishuID)
+	// Update workflow with the approval reference
+	workflow.SetApprovalReference(channelName, externalID)
 
 	// Save workflow
 	if err := s.workflowRepo.Save(ctx, workflow); err != nil {
 		return "", err
 	}
 
-	return feishuID, nil
+	return externalID, nil
 }
 
 // GetWorkflows gets all workflows with optional filtering
@@ -0,0 +1,369 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/infrastructure/logging"
+	"go.uber.org/zap"
+)
+
+// SLA timeout actions
+const (
+	SLATimeoutNone    = ""
+	SLATimeoutApprove = "approve"
+	SLATimeoutReject  = "reject"
+)
+
+// slaChannel is the channel name recorded on decisions made by the SLA sweep
+const slaChannel = "sla"
+
+// WorkflowSLAConfig defines how long workflows may stay pending and what happens when they do
+type WorkflowSLAConfig struct {
+	// Targets is the time to decision per priority
+	Targets map[model.WorkflowPriority]time.Duration
+	// EscalationUsers and EscalationRoles may decide the current stage once the SLA is breached
+	EscalationUsers []string
+	EscalationRoles []model.UserRole
+	// TimeoutAction is applied to workflows still pending Timeout after creation; empty disables it
+	TimeoutAction string
+	Timeout       time.Duration
+}
+
+// DefaultWorkflowSLAConfig returns the default SLA targets: hours for urgent work, days for low priority
+func DefaultWorkflowSLAConfig() WorkflowSLAConfig {
+	return WorkflowSLAConfig{
+		Targets: map[model.WorkflowPriority]time.Duration{
+			model.UrgentPriority: 4 * time.Hour,
+			model.HighPriority:   24 * time.Hour,
+			model.MediumPriority: 72 * time.Hour,
+			model.LowPriority:    7 * 24 * time.Hour,
+		},
+		EscalationRoles: []model.UserRole{model.AdminRole},
+	}
+}
+
+// Target returns the SLA target for a priority, falling back to the medium priority target
+func (c WorkflowSLAConfig) Target(priority model.WorkflowPriority) time.Duration {
+	if target, ok := c.Targets[priority]; ok && target > 0 {
+		return target
+	}
+	return c.Targets[model.MediumPriority]
+}
+
+// Validate checks the timeout action
+func (c WorkflowSLAConfig) Validate() error {
+	switch c.TimeoutAction {
+	case SLATimeoutNone, SLATimeoutApprove, SLATimeoutReject:
+	default:
+		return fmt.Errorf("unknown SLA timeout action: %s", c.TimeoutAction)
+	}
+	if c.TimeoutAction != SLATimeoutNone && c.Timeout <= 0 {
+		return fmt.Errorf("SLA timeout action %s needs a timeout", c.TimeoutAction)
+	}
+	return nil
+}
+
+// SetSLAConfig sets the SLA targets, escalation group and timeout action
+func (s *WorkflowService) SetSLAConfig(config WorkflowSLAConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	s.slaConfig = config
+	return nil
+}
+
+// GetWorkflowSLAStats gets SLA breach counts and the mean time to approve
+func (s *WorkflowService) GetWorkflowSLAStats(ctx context.Context) (*model.WorkflowSLAStats, error) {
+	return s.workflowRepo.GetSLAStats(ctx)
+}
+
+// CheckSLAs sweeps pending workflows once. It sets missing due dates, reminds approvers
+// every half SLA target, escalates breached workflows to the backup approvers and applies
+// the timeout action. It returns how many workflows were reminded, escalated and decided.
+func (s *WorkflowService) CheckSLAs(ctx context.Context, now time.Time) (reminded, escalated, decided int, err error) {
+	workflows, err := s.GetPendingWorkflows(ctx)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	for _, workflow := range workflows {
+		action, err := s.checkWorkflowSLA(ctx, workflow, now)
+		if err != nil {
+			logging.Logger.Error("workflow_sla_check_failed",
+				zap.String("workflow_id", workflow.WorkflowID),
+				zap.Error(err))
+			continue
+		}
+
+		switch action {
+		case "reminded":
+			reminded++
+		case "escalated":
+			escalated++
+		case "decided":
+			decided++
+		}
+	}
+
+	return reminded, escalated, decided, nil
+}
+
+// StartSLALoop periodically checks pending workflows against their SLA until the context is cancelled
+func (s *WorkflowService) StartSLALoop(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				reminded, escalated, decided, err := s.CheckSLAs(ctx, now)
+				if err != nil {
+					logging.Logger.Error("workflow_sla_sweep_failed", zap.Error(err))
+					continue
+				}
+				if reminded+escalated+decided > 0 {
+					logging.Logger.Info("workflow_sla_sweep_completed",
+						zap.Int("reminded", reminded),
+						zap.Int("escalated", escalated),
+						zap.Int("decided", decided),
+					)
+				}
+			}
+		}
+	}()
+}
+
+// checkWorkflowSLA applies the SLA rules to a single pending workflow
+func (s *WorkflowService) checkWorkflowSLA(ctx context.Context, workflow *model.Workflow, now time.Time) (string, error) {
+	target := s.slaConfig.Target(workflow.Priority)
+	if target <= 0 {
+		return "", nil
+	}
+
+	// A workflow decided after it was read is left alone
+	stage := workflow.CurrentStage
+	save := func() (bool, error) {
+		err := s.savePendingWorkflow(ctx, workflow, stage)
+		if errors.Is(err, ErrWorkflowDecidedConcurrently) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
+	// Workflows created before SLAs were tracked get their due date on the first sweep
+	if workflow.DueAt == nil {
+		workflow.SetDueAt(workflow.CreatedAt.Add(target))
+		if saved, err := save(); !saved {
+			return "", err
+		}
+	}
+
+	if s.slaConfig.TimeoutAction != SLATimeoutNone && now.Sub(workflow.CreatedAt) >= s.slaConfig.Timeout {
+		return "decided", s.applySLATimeout(ctx, workflow)
+	}
+
+	if workflow.IsOverdue(now) && !workflow.SLABreached {
+		workflow.Escalate(now, s.slaConfig.EscalationUsers, s.slaConfig.EscalationRoles)
+		workflow.MarkReminded(now)
+		if saved, err := save(); !saved {
+			return "", err
+		}
+
+		s.notifyApprovers(ctx, workflow, "workflow_sla_escalated",
+			fmt.Sprintf("Workflow %s breached its %s SLA and was escalated", workflow.WorkflowID, target))
+		return "escalated", nil
+	}
+
+	lastReminder := workflow.CreatedAt
+	if workflow.LastRemindedAt != nil {
+		lastReminder = *workflow.LastRemindedAt
+	}
+	if now.Sub(lastReminder) < target/2 {
+		return "", nil
+	}
+
+	workflow.MarkReminded(now)
+	if saved, err := save(); !saved {
+		return "", err
+	}
+
+	message := fmt.Sprintf("Workflow %s is awaiting approval, due %s", workflow.WorkflowID, workflow.DueAt.Format(time.RFC3339))
+	if workflow.SLABreached {
+		message = fmt.Sprintf("Workflow %s is overdue since %s", workflow.WorkflowID, workflow.DueAt.Format(time.RFC3339))
+	}
+	s.notifyApprovers(ctx, workflow, "workflow_sla_reminder", message)
+	return "reminded", nil
+}
+
+// applySLATimeout approves or rejects a workflow that stayed pending past the timeout
+func (s *WorkflowService) applySLATimeout(ctx context.Context, workflow *model.Workflow) error {
+	approver := Approver{
+		ID:      slaChannel + "-system",
+		Name:    "SLA Timeout",
+		Channel: slaChannel,
+	}
+	decision := ApprovalDecisionApproved
+	if s.slaConfig.TimeoutAction == SLATimeoutReject {
+		decision = ApprovalDecisionRejected
+	}
+	comments := fmt.Sprintf("Automatically %s after %s without a decision", decision, s.slaConfig.Timeout)
+
+	logging.Logger.Warn("workflow_sla_timeout",
+		zap.String("workflow_id", workflow.WorkflowID),
+		zap.String("action", s.slaConfig.TimeoutAction))
+
+	if s.slaConfig.TimeoutAction == SLATimeoutReject {
+		err := s.RejectWorkflow(ctx, workflow.ID.Hex(), approver, comments)
+		if errors.Is(err, ErrWorkflowDecidedConcurrently) {
+			return nil
+		}
+		return err
+	}
+
+	// The action only runs when the timeout decision is the one that was saved
+	stage := workflow.CurrentStage
+	workflow.ApproveAllStages(approver.ID, approver.Name, comments)
+	if err := s.savePendingWorkflow(ctx, workflow, stage); err != nil {
+		if errors.Is(err, ErrWorkflowDecidedConcurrently) {
+			return nil
+		}
+		return err
+	}
+	return s.completeApprovedWorkflow(ctx, workflow)
+}
+
+// userPageSize is how many users are read at a time when looking for stage approvers
+const userPageSize = 200
+
+// notifyApprovers logs a reminder for the approvers of the current stage and sends it to them.
+// Channels that support reminders get it on the approval they were sent; otherwise, or when
+// that fails, the stage approvers are messaged at the channels their accounts are linked to.
+func (s *WorkflowService) notifyApprovers(ctx context.Context, workflow *model.Workflow, event, message string) {
+	fields := []zap.Field{
+		zap.String("workflow_id", workflow.WorkflowID),
+		zap.String("priority", string(workflow.Priority)),
+		zap.String("message", message),
+	}
+	stage := workflow.CurrentApprovalStage()
+	if stage != nil {
+		fields = append(fields,
+			zap.String("stage", stage.Name),
+			zap.Strings("users", append(append([]string{}, stage.Users...), stage.BackupUsers...)),
+		)
+	}
+	logging.Logger.Info(event, fields...)
+
+	if s.remindThroughChannel(ctx, workflow, message) {
+		return
+	}
+	if stage == nil || !s.notifyStageApprovers(ctx, workflow, stage, message) {
+		logging.Logger.Warn("workflow_sla_reminder_undelivered",
+			zap.String("workflow_id", workflow.WorkflowID),
+			zap.String("channel", workflow.ApprovalChannel))
+	}
+}
+
+// remindThroughChannel sends the reminder on the approval the workflow's channel holds and
+// reports whether it was delivered
+func (s *WorkflowService) remindThroughChannel(ctx context.Context, workflow *model.Workflow, message string) bool {
+	channel, ok := s.approvalChannels[workflow.ApprovalChannel]
+	if !ok || workflow.ExternalID == "" {
+		return false
+	}
+	reminder, ok := channel.(ApprovalReminder)
+	if !ok {
+		return false
+	}
+	if err := reminder.Remind(ctx, workflow, message); err != nil {
+		logging.Logger.Error("workflow_sla_notify_failed",
+			zap.String("workflow_id", workflow.WorkflowID),
+			zap.String("channel", workflow.ApprovalChannel),
+			zap.Error(err))
+		return false
+	}
+	return true
+}
+
+// notifyStageApprovers messages each active approver of the stage once, at the workflow's
+// channel if they are linked there and at the first other channel they are linked to
+// otherwise. It reports whether anyone was messaged.
+func (s *WorkflowService) notifyStageApprovers(ctx context.Context, workflow *model.Workflow, stage *model.ApprovalStage, message string) bool {
+	if s.userRepo == nil {
+		return false
+	}
+
+	approvers, err := s.stageApprovers(ctx, stage)
+	if err != nil {
+		logging.Logger.Error("workflow_sla_approver_lookup_failed",
+			zap.String("workflow_id", workflow.WorkflowID),
+			zap.Error(err))
+		return false
+	}
+
+	names, _ := s.GetApprovalChannels()
+	order := make([]string, 0, len(names)+1)
+	if _, ok := s.approvalChannels[workflow.ApprovalChannel]; ok {
+		order = append(order, workflow.ApprovalChannel)
+	}
+	for _, name := range names {
+		if name != workflow.ApprovalChannel {
+			order = append(order, name)
+		}
+	}
+
+	accounts := make(map[string][]string)
+	for _, approver := range approvers {
+		for _, name := range order {
+			if _, ok := s.approvalChannels[name].(ApprovalUserNotifier); !ok {
+				continue
+			}
+			if accountID := approver.ChannelIDs[name]; accountID != "" {
+				accounts[name] = append(accounts[name], accountID)
+				break
+			}
+		}
+	}
+
+	delivered := false
+	for _, name := range order {
+		if len(accounts[name]) == 0 {
+			continue
+		}
+		notifier := s.approvalChannels[name].(ApprovalUserNotifier)
+		if err := notifier.NotifyUsers(ctx, accounts[name], message); err != nil {
+			logging.Logger.Error("workflow_sla_notify_failed",
+				zap.String("workflow_id", workflow.WorkflowID),
+				zap.String("channel", name),
+				zap.Error(err))
+			continue
+		}
+		delivered = true
+	}
+	return delivered
+}
+
+// stageApprovers returns the active users the stage asks to decide it
+func (s *WorkflowService) stageApprovers(ctx context.Context, stage *model.ApprovalStage) ([]*model.User, error) {
+	var approvers []*model.User
+	for offset := 0; ; offset += userPageSize {
+		users, err := s.userRepo.List(ctx, userPageSize, offset)
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range users {
+			if user.IsActive() && stage.IsApprover(user.Username, user.Role) {
+				approvers = append(approvers, user)
+			}
+		}
+		if len(users) < userPageSize {
+			return approvers, nil
+		}
+	}
+}
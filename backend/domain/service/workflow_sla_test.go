@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+)
+
+// testChannel records the reminders and direct messages it is asked to send
+type testChannel struct {
+	name      string
+	remindErr error
+	reminders []string
+	notified  []string
+}
+
+func (c *testChannel) Name() string { return c.name }
+
+func (c *testChannel) Submit(ctx context.Context, workflow *model.Workflow) (string, error) {
+	return "", errors.New("not implemented")
+}
+
+func (c *testChannel) ParseCallback(req *ApprovalCallbackRequest) (*ApprovalCallback, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c *testChannel) NotifyUsers(ctx context.Context, accountIDs []string, message string) error {
+	c.notified = append(c.notified, accountIDs...)
+	return nil
+}
+
+// testReminderChannel can also remind approvers on the approval it holds
+type testReminderChannel struct {
+	testChannel
+}
+
+func (c *testReminderChannel) Remind(ctx context.Context, workflow *model.Workflow, message string) error {
+	if c.remindErr != nil {
+		return c.remindErr
+	}
+	c.reminders = append(c.reminders, workflow.ExternalID)
+	return nil
+}
+
+func TestNotifyApprovers(t *testing.T) {
+	user := func(username string, role model.UserRole, status model.UserStatus, channelIDs map[string]string) *model.User {
+		return &model.User{Username: username, Role: role, Status: status, ChannelIDs: channelIDs}
+	}
+
+	// More users than one page, so approvers are found past the first
+	users := &memoryUserRepository{}
+	for i := 0; i < userPageSize; i++ {
+		users.users = append(users.users, user(fmt.Sprintf("viewer%d", i), model.ViewerRole, model.ActiveStatus, map[string]string{"slack": "U-viewer"}))
+	}
+	users.users = append(users.users,
+		user("alice", model.OperatorRole, model.ActiveStatus, map[string]string{"slack": "U-alice", "feishu": "ou_alice"}),
+		user("bob", model.ManagerRole, model.ActiveStatus, map[string]string{"slack": "U-bob"}),
+		user("carol", model.ManagerRole, model.InactiveStatus, map[string]string{"slack": "U-carol"}),
+		user("dave", model.ManagerRole, model.ActiveStatus, nil),
+		user("root", model.AdminRole, model.ActiveStatus, map[string]string{"slack": "U-root"}),
+	)
+
+	tests := []struct {
+		name      string
+		channel   string
+		remindErr error
+		stage     model.ApprovalStage
+		// wantReminded is whether the reminder went to the approval the channel holds
+		wantReminded bool
+		wantNotified map[string][]string
+	}{
+		{
+			name:         "channel reminder",
+			channel:      "webhook",
+			stage:        model.ApprovalStage{Users: []string{"alice"}},
+			wantReminded: true,
+			wantNotified: map[string][]string{},
+		},
+		{
+			name:         "no channel, named users",
+			stage:        model.ApprovalStage{Users: []string{"alice"}, BackupUsers: []string{"bob"}},
+			wantNotified: map[string][]string{"feishu": {"ou_alice"}, "slack": {"U-bob"}},
+		},
+		{
+			name:         "no channel, roles",
+			stage:        model.ApprovalStage{Roles: []model.UserRole{model.ManagerRole}},
+			wantNotified: map[string][]string{"slack": {"U-bob"}},
+		},
+		{
+			name:         "workflow channel first",
+			channel:      "slack",
+			stage:        model.ApprovalStage{Users: []string{"alice"}},
+			wantNotified: map[string][]string{"slack": {"U-alice"}},
+		},
+		{
+			name:         "failed reminder falls back to the approvers",
+			channel:      "webhook",
+			remindErr:    errors.New("unavailable"),
+			stage:        model.ApprovalStage{Users: []string{"alice"}},
+			wantNotified: map[string][]string{"feishu": {"ou_alice"}},
+		},
+		{
+			name:         "nobody linked",
+			stage:        model.ApprovalStage{Users: []string{"dave", "carol"}},
+			wantNotified: map[string][]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			webhook := &testReminderChannel{testChannel{name: "webhook", remindErr: tt.remindErr}}
+			channels := []*testChannel{{name: "slack"}, {name: "feishu"}}
+
+			workflowService := NewWorkflowService(nil, nil, nil, nil, nil, nil)
+			workflowService.SetUserRepository(users)
+			workflowService.RegisterApprovalChannel(webhook)
+			for _, channel := range channels {
+				workflowService.RegisterApprovalChannel(channel)
+			}
+
+			workflow := model.NewWorkflow(model.AssetUpdateType, "AST-0001", "web-01", "alice", "u1", model.HighPriority, "Resize", nil)
+			workflow.WorkflowID = "WF-0001"
+			workflow.Stages = []model.ApprovalStage{tt.stage}
+			if tt.channel != "" {
+				workflow.ApprovalChannel, workflow.ExternalID = tt.channel, "EXT-1"
+			}
+
+			workflowService.notifyApprovers(context.Background(), workflow, "workflow_sla_reminder", "Workflow WF-0001 is awaiting approval")
+
+			if reminded := len(webhook.reminders) > 0; reminded != tt.wantReminded {
+				t.Errorf("reminded on the approval = %v, want %v", reminded, tt.wantReminded)
+			}
+			notified := make(map[string][]string)
+			for _, channel := range append(channels, &webhook.testChannel) {
+				if len(channel.notified) > 0 {
+					sort.Strings(channel.notified)
+					notified[channel.name] = channel.notified
+				}
+			}
+			if !reflect.DeepEqual(notified, tt.wantNotified) {
+				t.Errorf("notified = %v, want %v", notified, tt.wantNotified)
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package approval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/service"
+)
+
+// DingTalkChannelName is the approval channel name for DingTalk
+const DingTalkChannelName = "dingtalk"
+
+// DingTalkConfig holds the DingTalk app and OA approval settings
+type DingTalkConfig struct {
+	APIURL           string
+	AppKey           string
+	AppSecret        string
+	ProcessCode      string
+	OriginatorUserID string
+	DeptID           int64
+	CallbackToken    string
+	CallbackAESKey   string
+	HTTPClient       *http.Client
+}
+
+// DingTalkConfigFromEnv reads the DingTalk configuration from environment variables
+func DingTalkConfigFromEnv() DingTalkConfig {
+	deptID, _ := strconv.ParseInt(os.Getenv("DINGTALK_DEPT_ID"), 10, 64)
+
+	return DingTalkConfig{
+		APIURL:           envOrDefault("DINGTALK_API_URL", "https://oapi.dingtalk.com"),
+		AppKey:           os.Getenv("DINGTALK_APP_KEY"),
+		AppSecret:        os.Getenv("DINGTALK_APP_SECRET"),
+		ProcessCode:      os.Getenv("DINGTALK_PROCESS_CODE"),
+		OriginatorUserID: os.Getenv("DINGTALK_ORIGINATOR_USER_ID"),
+		DeptID:           deptID,
+		CallbackToken:    os.Getenv("DINGTALK_CALLBACK_TOKEN"),
+		CallbackAESKey:   os.Getenv("DINGTALK_CALLBACK_AES_KEY"),
+	}
+}
+
+// Enabled reports whether the DingTalk channel is configured
+func (c DingTalkConfig) Enabled() bool {
+	return c.AppKey != "" && c.AppSecret != "" && c.ProcessCode != "" &&
+		c.CallbackToken != "" && c.CallbackAESKey != ""
+}
+
+// DingTalkChannel starts DingTalk OA approval instances and handles their change events
+type DingTalkChannel struct {
+	config     DingTalkConfig
+	httpClient *http.Client
+	crypt      *msgCrypt
+	token      tokenCache
+}
+
+// NewDingTalkChannel creates a new DingTalk approval channel
+func NewDingTalkChannel(config DingTalkConfig) (*DingTalkChannel, error) {
+	crypt, err := newMsgCrypt(config.CallbackToken, config.CallbackAESKey, config.AppKey)
+	if err != nil {
+		return nil, err
+	}
+
+	config.APIURL = strings.TrimRight(config.APIURL, "/")
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = defaultHTTPClient()
+	}
+
+	return &DingTalkChannel{config: config, httpClient: httpClient, crypt: crypt}, nil
+}
+
+var (
+	_ service.ApprovalChannel  = (*DingTalkChannel)(nil)
+	_ service.ApprovalReminder = (*DingTalkChannel)(nil)
+)
+
+// Name returns the channel name
+func (c *DingTalkChannel) Name() string {
+	return DingTalkChannelName
+}
+
+// Submit creates an OA approval instance and returns its process instance ID
+func (c *DingTalkChannel) Submit(ctx context.Context, workflow *model.Workflow) (string, error) {
+	token, err := c.token.get(ctx, c.fetchToken)
+	if err != nil {
+		return "", err
+	}
+
+	var values []map[string]string
+	for _, field := range FormFields(workflow) {
+		values = append(values, map[string]string{"name": field.Label, "value": field.Value})
+	}
+
+	request := map[string]interface{}{
+		"process_code":          c.config.ProcessCode,
+		"originator_user_id":    c.config.OriginatorUserID,
+		"dept_id":               c.config.DeptID,
+		"form_component_values": values,
+	}
+
+	var response struct {
+		ErrCode           int    `json:"errcode"`
+		ErrMsg            string `json:"errmsg"`
+		ProcessInstanceID string `json:"process_instance_id"`
+	}
+	endpoint := c.config.APIURL + "/topapi/processinstance/create?access_token=" + url.QueryEscape(token)
+	if err := doJSON(ctx, c.httpClient, http.MethodPost, endpoint, nil, request, &response); err != nil {
+		return "", err
+	}
+	if response.ErrCode != 0 {
+		return "", fmt.Errorf("dingtalk create process instance failed: %d %s", response.ErrCode, response.ErrMsg)
+	}
+
+	return response.ProcessInstanceID, nil
+}
+
+// Remind comments on the process instance as its originator, which notifies its approvers
+func (c *DingTalkChannel) Remind(ctx context.Context, workflow *model.Workflow, message string) error {
+	token, err := c.token.get(ctx, c.fetchToken)
+	if err != nil {
+		return err
+	}
+
+	request := map[string]interface{}{
+		"request": map[string]string{
+			"process_instance_id": workflow.ExternalID,
+			"text":                message,
+			"comment_userid":      c.config.OriginatorUserID,
+		},
+	}
+
+	var response struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	endpoint := c.config.APIURL + "/topapi/process/instance/comment/add?access_token=" + url.QueryEscape(token)
+	if err := doJSON(ctx, c.httpClient, http.MethodPost, endpoint, nil, request, &response); err != nil {
+		return err
+	}
+	if response.ErrCode != 0 {
+		return fmt.Errorf("dingtalk add comment failed: %d %s", response.ErrCode, response.ErrMsg)
+	}
+
+	return nil
+}
+
+// ParseCallback verifies and decrypts a DingTalk event callback. Every callback, including
+// the URL check, must be answered with an encrypted "success".
+func (c *DingTalkChannel) ParseCallback(req *service.ApprovalCallbackRequest) (*service.ApprovalCallback, error) {
+	var envelope struct {
+		Encrypt string `json:"encrypt"`
+	}
+	if err := json.Unmarshal(req.Body, &envelope); err != nil {
+		return nil, fmt.Errorf("%w: %v", service.ErrInvalidApprovalCallback, err)
+	}
+
+	signature := req.Query.Get("signature")
+	if signature == "" {
+		signature = req.Query.Get("msg_signature")
+	}
+	timestamp, nonce := req.Query.Get("timestamp"), req.Query.Get("nonce")
+
+	if err := checkTimestamp(timestamp); err != nil {
+		return nil, fmt.Errorf("%w: %v", service.ErrInvalidApprovalCallback, err)
+	}
+	if !c.crypt.verify(signature, timestamp, nonce, envelope.Encrypt) {
+		return nil, fmt.Errorf("%w: signature mismatch", service.ErrInvalidApprovalCallback)
+	}
+
+	plain, err := c.crypt.decrypt(envelope.Encrypt)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", service.ErrInvalidApprovalCallback, err)
+	}
+
+	var event struct {
+		EventType         string `json:"EventType"`
+		ProcessInstanceID string `json:"processInstanceId"`
+		Type              string `json:"type"`
+		Result            string `json:"result"`
+		StaffID           string `json:"staffId"`
+		Remark            string `json:"remark"`
+	}
+	if err := json.Unmarshal(plain, &event); err != nil {
+		return nil, fmt.Errorf("%w: %v", service.ErrInvalidApprovalCallback, err)
+	}
+
+	reply, err := c.successReply()
+	if err != nil {
+		return nil, err
+	}
+
+	callback := &service.ApprovalCallback{
+		Reply:            reply,
+		ReplyContentType: "application/json",
+	}
+	if event.EventType != "bpms_instance_change" {
+		return callback, nil
+	}
+
+	switch {
+	case event.Type == "finish" && event.Result == "agree":
+		callback.Decision = service.ApprovalDecisionApproved
+	case event.Type == "finish" && event.Result == "refuse", event.Type == "terminate":
+		callback.Decision = service.ApprovalDecisionRejected
+	default:
+		return callback, nil
+	}
+
+	callback.ExternalID = event.ProcessInstanceID
+	callback.OperatorID = event.StaffID
+	callback.Comments = event.Remark
+
+	return callback, nil
+}
+
+// successReply builds the encrypted acknowledgement DingTalk expects
+func (c *DingTalkChannel) successReply() ([]byte, error) {
+	encrypted, err := c.crypt.encrypt([]byte("success"))
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	nonce := randomString(8)
+
+	return json.Marshal(map[string]string{
+		"msg_signature": c.crypt.signature(timestamp, nonce, encrypted),
+		"timeStamp":     timestamp,
+		"nonce":         nonce,
+		"encrypt":       encrypted,
+	})
+}
+
+// fetchToken requests a new app access token
+func (c *DingTalkChannel) fetchToken(ctx context.Context) (string, time.Duration, error) {
+	query := url.Values{"appkey": {c.config.AppKey}, "appsecret": {c.config.AppSecret}}
+
+	var response struct {
+		ErrCode     int    `json:"errcode"`
+		ErrMsg      string `json:"errmsg"`
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := doJSON(ctx, c.httpClient, http.MethodGet, c.config.APIURL+"/gettoken?"+query.Encode(), nil, nil, &response); err != nil {
+		return "", 0, err
+	}
+	if response.ErrCode != 0 {
+		return "", 0, fmt.Errorf("dingtalk gettoken failed: %d %s", response.ErrCode, response.ErrMsg)
+	}
+
+	return response.AccessToken, time.Duration(response.ExpiresIn) * time.Second, nil
+}
@@ -0,0 +1,90 @@
+package approval
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+)
+
+var priorityLabels = map[model.WorkflowPriority]string{
+	model.UrgentPriority: "紧急",
+	model.HighPriority:   "高",
+	model.MediumPriority: "中",
+	model.LowPriority:    "低",
+}
+
+var typeLabels = map[model.WorkflowType]string{
+	model.AssetCreateType:        "资产创建",
+	model.AssetUpdateType:        "资产变更",
+	model.AssetDeleteType:        "资产删除",
+	model.AssetOnboardingType:    "资产上线",
+	model.AssetDecommissionType:  "资产下线",
+	model.StatusChangeType:       "状态变更",
+	model.MaintenanceRequestType: "维护申请",
+	model.AssetRevertType:        "资产回滚",
+}
+
+// FormField is a single labelled value describing a workflow to approvers
+type FormField struct {
+	Key   string
+	Label string
+	Value string
+}
+
+// Form field keys, shared by every channel so approval templates can be set up consistently
+const (
+	FieldWorkflowID = "workflow_id"
+	FieldType       = "workflow_type"
+	FieldAsset      = "asset"
+	FieldPriority   = "priority"
+	FieldReason     = "reason"
+	FieldRequester  = "requester"
+)
+
+// PriorityLabel returns the approver-facing label for a workflow priority
+func PriorityLabel(priority model.WorkflowPriority) string {
+	if label, ok := priorityLabels[priority]; ok {
+		return label
+	}
+	return string(priority)
+}
+
+// TypeLabel returns the approver-facing label for a workflow type
+func TypeLabel(workflowType model.WorkflowType) string {
+	if label, ok := typeLabels[workflowType]; ok {
+		return label
+	}
+	return string(workflowType)
+}
+
+// FormFields maps a workflow to the fields shown to approvers
+func FormFields(workflow *model.Workflow) []FormField {
+	asset := workflow.AssetID
+	if workflow.AssetName != "" {
+		asset = fmt.Sprintf("%s (%s)", workflow.AssetName, workflow.AssetID)
+	}
+
+	return []FormField{
+		{Key: FieldWorkflowID, Label: "工单编号", Value: workflow.WorkflowID},
+		{Key: FieldType, Label: "类型", Value: TypeLabel(workflow.Type)},
+		{Key: FieldAsset, Label: "资产", Value: asset},
+		{Key: FieldPriority, Label: "优先级", Value: PriorityLabel(workflow.Priority)},
+		{Key: FieldReason, Label: "原因", Value: workflow.Reason},
+		{Key: FieldRequester, Label: "申请人", Value: workflow.Requester},
+	}
+}
+
+// Summary renders the workflow fields as one "label: value" line each
+func Summary(workflow *model.Workflow) string {
+	var b strings.Builder
+	for i, field := range FormFields(workflow) {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(field.Label)
+		b.WriteString(": ")
+		b.WriteString(field.Value)
+	}
+	return b.String()
+}
@@ -0,0 +1,127 @@
+package approval
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// callbackMaxAge bounds how old a signed callback may be before it is treated as a replay
+const callbackMaxAge = 5 * time.Minute
+
+// tokenRefreshMargin is how long before expiry a cached access token is refreshed
+const tokenRefreshMargin = 5 * time.Minute
+
+func defaultHTTPClient() *http.Client {
+	return &http.Client{Timeout: 10 * time.Second}
+}
+
+// doJSON sends a request with an optional JSON body and decodes the JSON response
+func doJSON(ctx context.Context, client *http.Client, method, url string, header http.Header, body interface{}, out interface{}) error {
+	var reader *bytes.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return err
+	}
+	for key, values := range header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("request to %s failed with status %d", url, resp.StatusCode)
+	}
+	if out == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// tokenCache caches an access token until shortly before it expires
+type tokenCache struct {
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// get returns the cached token or fetches a new one; fetch returns the token and its lifetime
+func (c *tokenCache) get(ctx context.Context, fetch func(ctx context.Context) (string, time.Duration, error)) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && time.Now().Before(c.expiry) {
+		return c.token, nil
+	}
+
+	token, lifetime, err := fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	c.token = token
+	c.expiry = time.Now().Add(lifetime - tokenRefreshMargin)
+
+	return c.token, nil
+}
+
+// checkTimestamp rejects callbacks whose unix timestamp (seconds or milliseconds) is outside the allowed window
+func checkTimestamp(timestamp string) error {
+	value, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %q", timestamp)
+	}
+
+	at := time.Unix(value, 0)
+	if value > 1e12 {
+		at = time.UnixMilli(value)
+	}
+
+	if age := time.Since(at); age > callbackMaxAge || age < -callbackMaxAge {
+		return fmt.Errorf("timestamp outside allowed window")
+	}
+
+	return nil
+}
+
+// randomString returns a random hex string of n bytes
+func randomString(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("%x", b)
+}
+
+// envOrDefault returns the environment variable or a default when unset
+func envOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,125 @@
+package approval
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// msgCrypt implements the callback encryption scheme shared by DingTalk and WeCom:
+// AES-256-CBC keyed by the base64 EncodingAESKey, with the IV taken from the key and a
+// plaintext of random(16) | length(4) | message | receiver ID, signed with SHA-1 over the
+// sorted token, timestamp, nonce and ciphertext.
+type msgCrypt struct {
+	token      string
+	key        []byte
+	receiverID string
+}
+
+// newMsgCrypt creates a msgCrypt from the 43 character EncodingAESKey
+func newMsgCrypt(token, encodingAESKey, receiverID string) (*msgCrypt, error) {
+	key, err := base64.StdEncoding.DecodeString(encodingAESKey + "=")
+	if err != nil {
+		return nil, fmt.Errorf("invalid encoding aes key: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, errors.New("invalid encoding aes key length")
+	}
+
+	return &msgCrypt{token: token, key: key, receiverID: receiverID}, nil
+}
+
+// signature computes the SHA-1 signature of the sorted callback parameters
+func (m *msgCrypt) signature(timestamp, nonce, encrypted string) string {
+	parts := []string{m.token, timestamp, nonce, encrypted}
+	sort.Strings(parts)
+	sum := sha1.Sum([]byte(strings.Join(parts, "")))
+	return hex.EncodeToString(sum[:])
+}
+
+// verify checks a callback signature in constant time
+func (m *msgCrypt) verify(signature, timestamp, nonce, encrypted string) bool {
+	expected := m.signature(timestamp, nonce, encrypted)
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(signature)) == 1
+}
+
+// decrypt decrypts a callback payload and checks that it was meant for this receiver
+func (m *msgCrypt) decrypt(encrypted string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return nil, errors.New("invalid ciphertext length")
+	}
+
+	block, err := aes.NewCipher(m.key)
+	if err != nil {
+		return nil, err
+	}
+
+	plain := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, m.key[:aes.BlockSize]).CryptBlocks(plain, data)
+
+	// The padding block size is 32 bytes in this scheme, not the AES block size
+	padding := int(plain[len(plain)-1])
+	if padding == 0 || padding > 32 || padding > len(plain) {
+		return nil, errors.New("invalid padding")
+	}
+	plain = plain[:len(plain)-padding]
+
+	if len(plain) < 20 {
+		return nil, errors.New("plaintext too short")
+	}
+	length := int(binary.BigEndian.Uint32(plain[16:20]))
+	if 20+length > len(plain) {
+		return nil, errors.New("invalid message length")
+	}
+
+	message := plain[20 : 20+length]
+	receiverID := string(plain[20+length:])
+	if m.receiverID != "" && receiverID != m.receiverID {
+		return nil, errors.New("receiver id mismatch")
+	}
+
+	return message, nil
+}
+
+// encrypt encrypts a reply payload for this receiver
+func (m *msgCrypt) encrypt(message []byte) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	buf.Write(random)
+	length := make([]byte, 4)
+	binary.BigEndian.PutUint32(length, uint32(len(message)))
+	buf.Write(length)
+	buf.Write(message)
+	buf.WriteString(m.receiverID)
+
+	padding := 32 - buf.Len()%32
+	buf.Write(bytes.Repeat([]byte{byte(padding)}, padding))
+
+	block, err := aes.NewCipher(m.key)
+	if err != nil {
+		return "", err
+	}
+
+	out := make([]byte, buf.Len())
+	cipher.NewCBCEncrypter(block, m.key[:aes.BlockSize]).CryptBlocks(out, buf.Bytes())
+
+	return base64.StdEncoding.EncodeToString(out), nil
+}
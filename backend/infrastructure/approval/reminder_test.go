@@ -0,0 +1,167 @@
+package approval
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+)
+
+const testAESKey = "AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8"
+
+// apiRecorder answers API calls with canned JSON responses by path and records the request bodies
+type apiRecorder struct {
+	responses map[string]interface{}
+
+	mu       sync.Mutex
+	requests map[string][]map[string]interface{}
+}
+
+func newAPIRecorder(t *testing.T, responses map[string]interface{}) (*apiRecorder, string) {
+	recorder := &apiRecorder{responses: responses, requests: make(map[string][]map[string]interface{})}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response, ok := recorder.responses[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		recorder.mu.Lock()
+		recorder.requests[r.URL.Path] = append(recorder.requests[r.URL.Path], body)
+		recorder.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(server.Close)
+	return recorder, server.URL
+}
+
+func (r *apiRecorder) bodies(path string) []map[string]interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.requests[path]
+}
+
+func testWorkflow(externalID string) *model.Workflow {
+	workflow := model.NewWorkflow(model.AssetUpdateType, "AST-0001", "web-01", "alice", "u1", model.HighPriority, "Resize", nil)
+	workflow.WorkflowID = "WF-0001"
+	workflow.ExternalID = externalID
+	return workflow
+}
+
+func TestDingTalkRemind(t *testing.T) {
+	recorder, apiURL := newAPIRecorder(t, map[string]interface{}{
+		"/gettoken":                            map[string]interface{}{"errcode": 0, "access_token": "token", "expires_in": 7200},
+		"/topapi/process/instance/comment/add": map[string]interface{}{"errcode": 0, "result": true},
+	})
+	channel, err := NewDingTalkChannel(DingTalkConfig{
+		APIURL:           apiURL,
+		AppKey:           "app-key",
+		AppSecret:        "app-secret",
+		OriginatorUserID: "originator",
+		CallbackToken:    "token",
+		CallbackAESKey:   testAESKey,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := channel.Remind(context.Background(), testWorkflow("PROC-1"), "Workflow WF-0001 is overdue"); err != nil {
+		t.Fatalf("Remind() error = %v", err)
+	}
+
+	want := []map[string]interface{}{{"request": map[string]interface{}{
+		"process_instance_id": "PROC-1",
+		"text":                "Workflow WF-0001 is overdue",
+		"comment_userid":      "originator",
+	}}}
+	if got := recorder.bodies("/topapi/process/instance/comment/add"); !reflect.DeepEqual(got, want) {
+		t.Errorf("comment requests = %v, want %v", got, want)
+	}
+}
+
+func TestWeComRemind(t *testing.T) {
+	recorder, apiURL := newAPIRecorder(t, map[string]interface{}{
+		"/cgi-bin/gettoken": map[string]interface{}{"errcode": 0, "access_token": "token", "expires_in": 7200},
+		"/cgi-bin/oa/getapprovaldetail": map[string]interface{}{
+			"errcode": 0,
+			"info": map[string]interface{}{
+				"sp_no": "SP-1",
+				"sp_record": []interface{}{
+					map[string]interface{}{"sp_status": 2, "details": []interface{}{
+						map[string]interface{}{"approver": map[string]string{"userid": "first"}, "sp_status": 2},
+					}},
+					map[string]interface{}{"sp_status": 1, "details": []interface{}{
+						map[string]interface{}{"approver": map[string]string{"userid": "bob"}, "sp_status": 1},
+						map[string]interface{}{"approver": map[string]string{"userid": "carol"}, "sp_status": 1},
+						map[string]interface{}{"approver": map[string]string{"userid": "dave"}, "sp_status": 2},
+					}},
+					map[string]interface{}{"sp_status": 1, "details": []interface{}{}},
+				},
+			},
+		},
+		"/cgi-bin/message/send": map[string]interface{}{"errcode": 0},
+	})
+	config := WeComConfig{
+		APIURL:         apiURL,
+		CorpID:         "corp",
+		Secret:         "secret",
+		CallbackToken:  "token",
+		CallbackAESKey: testAESKey,
+	}
+
+	// Without an agent to send from there is no way to reach the approvers
+	channel, err := NewWeComChannel(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := channel.Remind(context.Background(), testWorkflow("SP-1"), "Workflow WF-0001 is overdue"); err == nil {
+		t.Error("Remind() without an agent ID succeeded")
+	}
+
+	config.AgentID = 1000002
+	channel, err = NewWeComChannel(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := channel.Remind(context.Background(), testWorkflow("SP-1"), "Workflow WF-0001 is overdue"); err != nil {
+		t.Fatalf("Remind() error = %v", err)
+	}
+
+	if got := recorder.bodies("/cgi-bin/oa/getapprovaldetail"); len(got) == 0 || got[len(got)-1]["sp_no"] != "SP-1" {
+		t.Errorf("approval detail requests = %v, want sp_no SP-1", got)
+	}
+	want := []map[string]interface{}{{
+		"touser":  "bob|carol",
+		"msgtype": "text",
+		"agentid": float64(1000002),
+		"text":    map[string]interface{}{"content": "Workflow WF-0001 is overdue"},
+	}}
+	if got := recorder.bodies("/cgi-bin/message/send"); !reflect.DeepEqual(got, want) {
+		t.Errorf("message requests = %v, want %v", got, want)
+	}
+}
+
+func TestSlackNotifyUsers(t *testing.T) {
+	recorder, apiURL := newAPIRecorder(t, map[string]interface{}{
+		"/chat.postMessage": map[string]interface{}{"ok": true},
+	})
+	channel := NewSlackChannel(SlackConfig{APIURL: apiURL, BotToken: "xoxb-test", SigningSecret: "secret", Channel: "C1"})
+
+	if err := channel.NotifyUsers(context.Background(), []string{"U1", "U2"}, "Workflow WF-0001 is overdue"); err != nil {
+		t.Fatalf("NotifyUsers() error = %v", err)
+	}
+
+	want := []map[string]interface{}{
+		{"channel": "U1", "text": "Workflow WF-0001 is overdue"},
+		{"channel": "U2", "text": "Workflow WF-0001 is overdue"},
+	}
+	if got := recorder.bodies("/chat.postMessage"); !reflect.DeepEqual(got, want) {
+		t.Errorf("message requests = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,255 @@
+package approval
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/service"
+)
+
+// SlackChannelName is the approval channel name for Slack
+const SlackChannelName = "slack"
+
+// Slack interactive message action IDs
+const (
+	slackApproveAction = "approve_workflow"
+	slackRejectAction  = "reject_workflow"
+)
+
+// SlackConfig holds the Slack app settings
+type SlackConfig struct {
+	APIURL        string
+	BotToken      string
+	SigningSecret string
+	Channel       string
+	HTTPClient    *http.Client
+}
+
+// SlackConfigFromEnv reads the Slack configuration from environment variables
+func SlackConfigFromEnv() SlackConfig {
+	return SlackConfig{
+		APIURL:        envOrDefault("SLACK_API_URL", "https://slack.com/api"),
+		BotToken:      os.Getenv("SLACK_BOT_TOKEN"),
+		SigningSecret: os.Getenv("SLACK_SIGNING_SECRET"),
+		Channel:       os.Getenv("SLACK_APPROVAL_CHANNEL"),
+	}
+}
+
+// Enabled reports whether the Slack channel is configured
+func (c SlackConfig) Enabled() bool {
+	return c.BotToken != "" && c.SigningSecret != "" && c.Channel != ""
+}
+
+// SlackChannel posts interactive approval messages to a Slack channel
+type SlackChannel struct {
+	config     SlackConfig
+	httpClient *http.Client
+}
+
+// NewSlackChannel creates a new Slack approval channel
+func NewSlackChannel(config SlackConfig) *SlackChannel {
+	config.APIURL = strings.TrimRight(config.APIURL, "/")
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = defaultHTTPClient()
+	}
+	return &SlackChannel{config: config, httpClient: httpClient}
+}
+
+var (
+	_ service.ApprovalChannel      = (*SlackChannel)(nil)
+	_ service.ApprovalReminder     = (*SlackChannel)(nil)
+	_ service.ApprovalUserNotifier = (*SlackChannel)(nil)
+)
+
+// Name returns the channel name
+func (c *SlackChannel) Name() string {
+	return SlackChannelName
+}
+
+// Submit posts an approval message with approve and reject buttons. The external ID is
+// "<channel id>:<message ts>", which interaction payloads carry back.
+func (c *SlackChannel) Submit(ctx context.Context, workflow *model.Workflow) (string, error) {
+	var fields []map[string]string
+	for _, field := range FormFields(workflow) {
+		fields = append(fields, map[string]string{
+			"type": "mrkdwn",
+			"text": fmt.Sprintf("*%s*\n%s", field.Label, field.Value),
+		})
+	}
+
+	message := map[string]interface{}{
+		"channel": c.config.Channel,
+		"text":    fmt.Sprintf("Approval requested: %s %s", workflow.WorkflowID, workflow.Type),
+		"blocks": []interface{}{
+			map[string]interface{}{
+				"type": "section",
+				"text": map[string]string{
+					"type": "mrkdwn",
+					"text": fmt.Sprintf("*%s* requests approval for *%s*", workflow.Requester, workflow.Type),
+				},
+			},
+			map[string]interface{}{
+				"type":   "section",
+				"fields": fields,
+			},
+			map[string]interface{}{
+				"type": "actions",
+				"elements": []interface{}{
+					map[string]interface{}{
+						"type":      "button",
+						"action_id": slackApproveAction,
+						"style":     "primary",
+						"value":     workflow.WorkflowID,
+						"text":      map[string]string{"type": "plain_text", "text": "Approve"},
+					},
+					map[string]interface{}{
+						"type":      "button",
+						"action_id": slackRejectAction,
+						"style":     "danger",
+						"value":     workflow.WorkflowID,
+						"text":      map[string]string{"type": "plain_text", "text": "Reject"},
+					},
+				},
+			},
+		},
+	}
+
+	var response struct {
+		OK      bool   `json:"ok"`
+		Error   string `json:"error"`
+		Channel string `json:"channel"`
+		TS      string `json:"ts"`
+	}
+	header := http.Header{"Authorization": []string{"Bearer " + c.config.BotToken}}
+	if err := doJSON(ctx, c.httpClient, http.MethodPost, c.config.APIURL+"/chat.postMessage", header, message, &response); err != nil {
+		return "", err
+	}
+	if !response.OK {
+		return "", fmt.Errorf("slack chat.postMessage failed: %s", response.Error)
+	}
+
+	return response.Channel + ":" + response.TS, nil
+}
+
+// Remind replies in the thread of the approval message
+func (c *SlackChannel) Remind(ctx context.Context, workflow *model.Workflow, message string) error {
+	channel, ts, ok := strings.Cut(workflow.ExternalID, ":")
+	if !ok {
+		return fmt.Errorf("invalid slack external id %q", workflow.ExternalID)
+	}
+
+	var response struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	header := http.Header{"Authorization": []string{"Bearer " + c.config.BotToken}}
+	body := map[string]interface{}{
+		"channel":   channel,
+		"thread_ts": ts,
+		"text":      message,
+	}
+	if err := doJSON(ctx, c.httpClient, http.MethodPost, c.config.APIURL+"/chat.postMessage", header, body, &response); err != nil {
+		return err
+	}
+	if !response.OK {
+		return fmt.Errorf("slack chat.postMessage failed: %s", response.Error)
+	}
+
+	return nil
+}
+
+// NotifyUsers sends the message to each user ID as a direct message from the bot
+func (c *SlackChannel) NotifyUsers(ctx context.Context, userIDs []string, message string) error {
+	header := http.Header{"Authorization": []string{"Bearer " + c.config.BotToken}}
+	for _, userID := range userIDs {
+		var response struct {
+			OK    bool   `json:"ok"`
+			Error string `json:"error"`
+		}
+		body := map[string]interface{}{
+			"channel": userID,
+			"text":    message,
+		}
+		if err := doJSON(ctx, c.httpClient, http.MethodPost, c.config.APIURL+"/chat.postMessage", header, body, &response); err != nil {
+			return err
+		}
+		if !response.OK {
+			return fmt.Errorf("slack chat.postMessage to %s failed: %s", userID, response.Error)
+		}
+	}
+
+	return nil
+}
+
+// ParseCallback verifies the Slack request signature and decodes a block_actions interaction
+func (c *SlackChannel) ParseCallback(req *service.ApprovalCallbackRequest) (*service.ApprovalCallback, error) {
+	timestamp := req.Header.Get("X-Slack-Request-Timestamp")
+	if err := checkTimestamp(timestamp); err != nil {
+		return nil, fmt.Errorf("%w: %v", service.ErrInvalidApprovalCallback, err)
+	}
+
+	mac := hmac.New(sha256.New, []byte(c.config.SigningSecret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(req.Body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(req.Header.Get("X-Slack-Signature"))) {
+		return nil, fmt.Errorf("%w: signature mismatch", service.ErrInvalidApprovalCallback)
+	}
+
+	form, err := url.ParseQuery(string(req.Body))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", service.ErrInvalidApprovalCallback, err)
+	}
+
+	var payload struct {
+		Type string `json:"type"`
+		User struct {
+			ID       string `json:"id"`
+			Username string `json:"username"`
+			Name     string `json:"name"`
+		} `json:"user"`
+		Container struct {
+			ChannelID string `json:"channel_id"`
+			MessageTS string `json:"message_ts"`
+		} `json:"container"`
+		Actions []struct {
+			ActionID string `json:"action_id"`
+		} `json:"actions"`
+	}
+	if err := json.Unmarshal([]byte(form.Get("payload")), &payload); err != nil {
+		return nil, fmt.Errorf("%w: %v", service.ErrInvalidApprovalCallback, err)
+	}
+
+	callback := &service.ApprovalCallback{}
+	if payload.Type != "block_actions" || len(payload.Actions) == 0 {
+		return callback, nil
+	}
+
+	switch payload.Actions[0].ActionID {
+	case slackApproveAction:
+		callback.Decision = service.ApprovalDecisionApproved
+	case slackRejectAction:
+		callback.Decision = service.ApprovalDecisionRejected
+	default:
+		return callback, nil
+	}
+
+	callback.ExternalID = payload.Container.ChannelID + ":" + payload.Container.MessageTS
+	callback.OperatorID = payload.User.ID
+	callback.OperatorName = payload.User.Username
+	if callback.OperatorName == "" {
+		callback.OperatorName = payload.User.Name
+	}
+
+	return callback, nil
+}
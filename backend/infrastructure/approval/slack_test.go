@@ -0,0 +1,100 @@
+package approval
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/service"
+)
+
+// slackSignature signs a request body the way Slack does
+func slackSignature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestSlackParseCallback(t *testing.T) {
+	channel := NewSlackChannel(SlackConfig{BotToken: "xoxb-test", SigningSecret: "signing-secret", Channel: "C1"})
+	interaction := func(action string) []byte {
+		payload := `{"type":"block_actions","user":{"id":"U1","username":"alice","name":"Alice"},` +
+			`"container":{"channel_id":"C1","message_ts":"1700000000.000100"},"actions":[{"action_id":"` + action + `"}]}`
+		return []byte(url.Values{"payload": {payload}}.Encode())
+	}
+	body := interaction(slackApproveAction)
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	stale := strconv.FormatInt(time.Now().Add(-10*time.Minute).Unix(), 10)
+
+	signed := func(timestamp, secret string, signedBody []byte) http.Header {
+		header := http.Header{}
+		header.Set("X-Slack-Request-Timestamp", timestamp)
+		header.Set("X-Slack-Signature", slackSignature(secret, timestamp, signedBody))
+		return header
+	}
+
+	tests := []struct {
+		name    string
+		header  http.Header
+		body    []byte
+		wantErr bool
+	}{
+		{name: "valid signature", header: signed(now, "signing-secret", body), body: body},
+		{name: "tampered body", header: signed(now, "signing-secret", body), body: interaction(slackRejectAction), wantErr: true},
+		{name: "signed with another secret", header: signed(now, "other-secret", body), body: body, wantErr: true},
+		{name: "stale timestamp", header: signed(stale, "signing-secret", body), body: body, wantErr: true},
+		{
+			name: "missing signature header",
+			header: func() http.Header {
+				header := signed(now, "signing-secret", body)
+				header.Del("X-Slack-Signature")
+				return header
+			}(),
+			body:    body,
+			wantErr: true,
+		},
+		{
+			name: "missing timestamp header",
+			header: func() http.Header {
+				header := signed(now, "signing-secret", body)
+				header.Del("X-Slack-Request-Timestamp")
+				return header
+			}(),
+			body:    body,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			callback, err := channel.ParseCallback(&service.ApprovalCallbackRequest{Method: http.MethodPost, Header: tt.header, Body: tt.body})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCallback() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, service.ErrInvalidApprovalCallback) {
+					t.Errorf("ParseCallback() error = %v, want ErrInvalidApprovalCallback", err)
+				}
+				return
+			}
+
+			want := &service.ApprovalCallback{
+				ExternalID:   "C1:1700000000.000100",
+				Decision:     service.ApprovalDecisionApproved,
+				OperatorID:   "U1",
+				OperatorName: "alice",
+			}
+			if !reflect.DeepEqual(callback, want) {
+				t.Errorf("ParseCallback() = %+v, want %+v", callback, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package approval
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/service"
+)
+
+// WebhookChannelName is the approval channel name for the generic JSON webhook
+const WebhookChannelName = "webhook"
+
+// Generic webhook signature headers, used in both directions
+const (
+	WebhookTimestampHeader = "X-CMDB-Timestamp"
+	WebhookSignatureHeader = "X-CMDB-Signature"
+)
+
+// WebhookConfig holds the generic webhook settings
+type WebhookConfig struct {
+	URL        string
+	Secret     string
+	HTTPClient *http.Client
+}
+
+// WebhookConfigFromEnv reads the generic webhook configuration from environment variables
+func WebhookConfigFromEnv() WebhookConfig {
+	return WebhookConfig{
+		URL:    os.Getenv("APPROVAL_WEBHOOK_URL"),
+		Secret: os.Getenv("APPROVAL_WEBHOOK_SECRET"),
+	}
+}
+
+// Enabled reports whether the generic webhook channel is configured
+func (c WebhookConfig) Enabled() bool {
+	return c.URL != "" && c.Secret != ""
+}
+
+// WebhookChannel posts approval requests to an arbitrary HTTP endpoint and accepts
+// decisions back as signed JSON
+type WebhookChannel struct {
+	config     WebhookConfig
+	httpClient *http.Client
+}
+
+// NewWebhookChannel creates a new generic webhook approval channel
+func NewWebhookChannel(config WebhookConfig) *WebhookChannel {
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = defaultHTTPClient()
+	}
+	return &WebhookChannel{config: config, httpClient: httpClient}
+}
+
+var (
+	_ service.ApprovalChannel  = (*WebhookChannel)(nil)
+	_ service.ApprovalReminder = (*WebhookChannel)(nil)
+)
+
+// WebhookRequest is the outbound approval request body
+type WebhookRequest struct {
+	Event     string            `json:"event"`
+	ID        string            `json:"id"`
+	Workflow  *model.Workflow   `json:"workflow"`
+	Fields    map[string]string `json:"fields"`
+	Message   string            `json:"message,omitempty"`
+	Timestamp time.Time         `json:"timestamp"`
+}
+
+// WebhookDecision is the inbound decision body
+type WebhookDecision struct {
+	ExternalID   string `json:"externalId"`
+	Decision     string `json:"decision"`
+	OperatorID   string `json:"operatorId"`
+	OperatorName string `json:"operatorName"`
+	Comments     string `json:"comments"`
+}
+
+// Name returns the channel name
+func (c *WebhookChannel) Name() string {
+	return WebhookChannelName
+}
+
+// Submit posts the workflow to the configured URL. The receiver may answer with
+// {"externalId": "..."}; otherwise the workflow's object ID is used as the external ID.
+func (c *WebhookChannel) Submit(ctx context.Context, workflow *model.Workflow) (string, error) {
+	data, err := c.send(ctx, "approval.requested", workflow, "")
+	if err != nil {
+		return "", err
+	}
+
+	var response struct {
+		ExternalID string `json:"externalId"`
+	}
+	if len(data) > 0 {
+		_ = json.Unmarshal(data, &response)
+	}
+	if response.ExternalID != "" {
+		return response.ExternalID, nil
+	}
+
+	return workflow.ID.Hex(), nil
+}
+
+// Remind posts an approval.reminder event for the workflow
+func (c *WebhookChannel) Remind(ctx context.Context, workflow *model.Workflow, message string) error {
+	_, err := c.send(ctx, "approval.reminder", workflow, message)
+	return err
+}
+
+// send posts a signed event to the configured URL and returns the response body
+func (c *WebhookChannel) send(ctx context.Context, event string, workflow *model.Workflow, message string) ([]byte, error) {
+	fields := make(map[string]string)
+	for _, field := range FormFields(workflow) {
+		fields[field.Key] = field.Value
+	}
+
+	body, err := json.Marshal(WebhookRequest{
+		Event:     event,
+		ID:        workflow.ID.Hex(),
+		Workflow:  workflow,
+		Fields:    fields,
+		Message:   message,
+		Timestamp: time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(c.config.Secret, timestamp, body))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("approval webhook failed with status %d", resp.StatusCode)
+	}
+
+	// The response body is optional, so read errors are not fatal
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	return data, nil
+}
+
+// ParseCallback verifies the HMAC signature and decodes a decision
+func (c *WebhookChannel) ParseCallback(req *service.ApprovalCallbackRequest) (*service.ApprovalCallback, error) {
+	timestamp := req.Header.Get(WebhookTimestampHeader)
+	if err := checkTimestamp(timestamp); err != nil {
+		return nil, fmt.Errorf("%w: %v", service.ErrInvalidApprovalCallback, err)
+	}
+
+	expected := SignWebhook(c.config.Secret, timestamp, req.Body)
+	if !hmac.Equal([]byte(expected), []byte(req.Header.Get(WebhookSignatureHeader))) {
+		return nil, fmt.Errorf("%w: signature mismatch", service.ErrInvalidApprovalCallback)
+	}
+
+	var decision WebhookDecision
+	if err := json.Unmarshal(req.Body, &decision); err != nil {
+		return nil, fmt.Errorf("%w: %v", service.ErrInvalidApprovalCallback, err)
+	}
+
+	if decision.Decision != service.ApprovalDecisionApproved && decision.Decision != service.ApprovalDecisionRejected {
+		return nil, fmt.Errorf("%w: decision must be approved or rejected", service.ErrInvalidApprovalCallback)
+	}
+
+	return &service.ApprovalCallback{
+		ExternalID:   decision.ExternalID,
+		Decision:     decision.Decision,
+		OperatorID:   decision.OperatorID,
+		OperatorName: decision.OperatorName,
+		Comments:     decision.Comments,
+	}, nil
+}
+
+// SignWebhook computes "sha256=<hex hmac>" over "<timestamp>.<body>"
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,94 @@
+package approval
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/service"
+)
+
+func TestWebhookParseCallback(t *testing.T) {
+	channel := NewWebhookChannel(WebhookConfig{URL: "https://approvals.example.com/hook", Secret: "webhook-secret"})
+	body := []byte(`{"externalId":"wf-1","decision":"approved","operatorId":"u1","operatorName":"alice","comments":"ok"}`)
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	stale := strconv.FormatInt(time.Now().Add(-10*time.Minute).Unix(), 10)
+
+	signed := func(timestamp, secret string, signedBody []byte) http.Header {
+		header := http.Header{}
+		header.Set(WebhookTimestampHeader, timestamp)
+		header.Set(WebhookSignatureHeader, SignWebhook(secret, timestamp, signedBody))
+		return header
+	}
+
+	tests := []struct {
+		name    string
+		header  http.Header
+		body    []byte
+		wantErr bool
+	}{
+		{name: "valid signature", header: signed(now, "webhook-secret", body), body: body},
+		{
+			name:   "timestamp in milliseconds",
+			header: signed(strconv.FormatInt(time.Now().UnixMilli(), 10), "webhook-secret", body),
+			body:   body,
+		},
+		{
+			name:    "tampered body",
+			header:  signed(now, "webhook-secret", body),
+			body:    []byte(`{"externalId":"wf-1","decision":"rejected","operatorId":"u1","operatorName":"alice","comments":"ok"}`),
+			wantErr: true,
+		},
+		{name: "signed with another secret", header: signed(now, "other-secret", body), body: body, wantErr: true},
+		{name: "stale timestamp", header: signed(stale, "webhook-secret", body), body: body, wantErr: true},
+		{
+			name: "missing signature header",
+			header: func() http.Header {
+				header := signed(now, "webhook-secret", body)
+				header.Del(WebhookSignatureHeader)
+				return header
+			}(),
+			body:    body,
+			wantErr: true,
+		},
+		{
+			name: "missing timestamp header",
+			header: func() http.Header {
+				header := signed(now, "webhook-secret", body)
+				header.Del(WebhookTimestampHeader)
+				return header
+			}(),
+			body:    body,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			callback, err := channel.ParseCallback(&service.ApprovalCallbackRequest{Method: http.MethodPost, Header: tt.header, Body: tt.body})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCallback() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, service.ErrInvalidApprovalCallback) {
+					t.Errorf("ParseCallback() error = %v, want ErrInvalidApprovalCallback", err)
+				}
+				return
+			}
+
+			want := &service.ApprovalCallback{
+				ExternalID:   "wf-1",
+				Decision:     service.ApprovalDecisionApproved,
+				OperatorID:   "u1",
+				OperatorName: "alice",
+				Comments:     "ok",
+			}
+			if !reflect.DeepEqual(callback, want) {
+				t.Errorf("ParseCallback() = %+v, want %+v", callback, want)
+			}
+		})
+	}
+}
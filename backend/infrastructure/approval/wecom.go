@@ -0,0 +1,328 @@
+package approval
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/service"
+)
+
+// WeComChannelName is the approval channel name for WeCom (WeChat Work)
+const WeComChannelName = "wecom"
+
+// WeCom approval statuses (SpStatus) reported in sys_approval_change events and approval details
+const (
+	weComStatusPending          = 1
+	weComStatusApproved         = 2
+	weComStatusRejected         = 3
+	weComStatusRevoked          = 4
+	weComStatusRevokedAfterPass = 6
+	weComStatusDeleted          = 7
+)
+
+// WeComConfig holds the WeCom app and approval template settings
+type WeComConfig struct {
+	APIURL           string
+	CorpID           string
+	Secret           string
+	TemplateID       string
+	CreatorUserID    string
+	SummaryControlID string
+	CallbackToken    string
+	CallbackAESKey   string
+	// AgentID is the app that sends reminders; without it reminders are not sent
+	AgentID    int64
+	HTTPClient *http.Client
+}
+
+// WeComConfigFromEnv reads the WeCom configuration from environment variables
+func WeComConfigFromEnv() WeComConfig {
+	agentID, _ := strconv.ParseInt(os.Getenv("WECOM_AGENT_ID"), 10, 64)
+
+	return WeComConfig{
+		APIURL:           envOrDefault("WECOM_API_URL", "https://qyapi.weixin.qq.com"),
+		CorpID:           os.Getenv("WECOM_CORP_ID"),
+		Secret:           os.Getenv("WECOM_SECRET"),
+		TemplateID:       os.Getenv("WECOM_TEMPLATE_ID"),
+		CreatorUserID:    os.Getenv("WECOM_CREATOR_USER_ID"),
+		SummaryControlID: os.Getenv("WECOM_SUMMARY_CONTROL_ID"),
+		CallbackToken:    os.Getenv("WECOM_CALLBACK_TOKEN"),
+		CallbackAESKey:   os.Getenv("WECOM_CALLBACK_AES_KEY"),
+		AgentID:          agentID,
+	}
+}
+
+// Enabled reports whether the WeCom channel is configured
+func (c WeComConfig) Enabled() bool {
+	return c.CorpID != "" && c.Secret != "" && c.TemplateID != "" &&
+		c.CallbackToken != "" && c.CallbackAESKey != ""
+}
+
+// WeComChannel submits WeCom OA approval applications and handles their change events
+type WeComChannel struct {
+	config     WeComConfig
+	httpClient *http.Client
+	crypt      *msgCrypt
+	token      tokenCache
+}
+
+// NewWeComChannel creates a new WeCom approval channel
+func NewWeComChannel(config WeComConfig) (*WeComChannel, error) {
+	crypt, err := newMsgCrypt(config.CallbackToken, config.CallbackAESKey, config.CorpID)
+	if err != nil {
+		return nil, err
+	}
+
+	config.APIURL = strings.TrimRight(config.APIURL, "/")
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = defaultHTTPClient()
+	}
+
+	return &WeComChannel{config: config, httpClient: httpClient, crypt: crypt}, nil
+}
+
+var (
+	_ service.ApprovalChannel      = (*WeComChannel)(nil)
+	_ service.ApprovalReminder     = (*WeComChannel)(nil)
+	_ service.ApprovalUserNotifier = (*WeComChannel)(nil)
+)
+
+// Name returns the channel name
+func (c *WeComChannel) Name() string {
+	return WeComChannelName
+}
+
+// Submit creates an approval application from the template and returns its approval number (sp_no)
+func (c *WeComChannel) Submit(ctx context.Context, workflow *model.Workflow) (string, error) {
+	token, err := c.token.get(ctx, c.fetchToken)
+	if err != nil {
+		return "", err
+	}
+
+	summary := Summary(workflow)
+	request := map[string]interface{}{
+		"creator_userid":        c.config.CreatorUserID,
+		"template_id":           c.config.TemplateID,
+		"use_template_approver": 1,
+		"apply_data": map[string]interface{}{
+			"contents": []interface{}{
+				map[string]interface{}{
+					"control": "Textarea",
+					"id":      c.config.SummaryControlID,
+					"value":   map[string]string{"text": summary},
+				},
+			},
+		},
+		"summary_list": []interface{}{
+			map[string]interface{}{
+				"summary_info": []map[string]string{
+					{"text": fmt.Sprintf("%s %s", workflow.WorkflowID, TypeLabel(workflow.Type)), "lang": "zh_CN"},
+				},
+			},
+		},
+	}
+
+	var response struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+		SpNo    string `json:"sp_no"`
+	}
+	endpoint := c.config.APIURL + "/cgi-bin/oa/applyevent?access_token=" + url.QueryEscape(token)
+	if err := doJSON(ctx, c.httpClient, http.MethodPost, endpoint, nil, request, &response); err != nil {
+		return "", err
+	}
+	if response.ErrCode != 0 {
+		return "", fmt.Errorf("wecom applyevent failed: %d %s", response.ErrCode, response.ErrMsg)
+	}
+
+	return response.SpNo, nil
+}
+
+// Remind messages the approvers the application is waiting for. WeCom has no API to comment
+// on an application, so they are looked up in its approval detail and sent an app message.
+func (c *WeComChannel) Remind(ctx context.Context, workflow *model.Workflow, message string) error {
+	token, err := c.token.get(ctx, c.fetchToken)
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+		Info    struct {
+			SpRecord []struct {
+				SpStatus int `json:"sp_status"`
+				Details  []struct {
+					Approver struct {
+						UserID string `json:"userid"`
+					} `json:"approver"`
+					SpStatus int `json:"sp_status"`
+				} `json:"details"`
+			} `json:"sp_record"`
+		} `json:"info"`
+	}
+	endpoint := c.config.APIURL + "/cgi-bin/oa/getapprovaldetail?access_token=" + url.QueryEscape(token)
+	if err := doJSON(ctx, c.httpClient, http.MethodPost, endpoint, nil, map[string]string{"sp_no": workflow.ExternalID}, &response); err != nil {
+		return err
+	}
+	if response.ErrCode != 0 {
+		return fmt.Errorf("wecom getapprovaldetail failed: %d %s", response.ErrCode, response.ErrMsg)
+	}
+
+	var approvers []string
+	for _, node := range response.Info.SpRecord {
+		if node.SpStatus != weComStatusPending {
+			continue
+		}
+		for _, detail := range node.Details {
+			if detail.SpStatus == weComStatusPending && detail.Approver.UserID != "" {
+				approvers = append(approvers, detail.Approver.UserID)
+			}
+		}
+	}
+	if len(approvers) == 0 {
+		return fmt.Errorf("wecom application %s has no pending approvers", workflow.ExternalID)
+	}
+
+	return c.NotifyUsers(ctx, approvers, message)
+}
+
+// NotifyUsers sends the message to the given user IDs as a text message from the app
+func (c *WeComChannel) NotifyUsers(ctx context.Context, userIDs []string, message string) error {
+	if c.config.AgentID == 0 {
+		return errors.New("wecom agent id is not configured")
+	}
+
+	token, err := c.token.get(ctx, c.fetchToken)
+	if err != nil {
+		return err
+	}
+
+	request := map[string]interface{}{
+		"touser":  strings.Join(userIDs, "|"),
+		"msgtype": "text",
+		"agentid": c.config.AgentID,
+		"text":    map[string]string{"content": message},
+	}
+
+	var response struct {
+		ErrCode     int    `json:"errcode"`
+		ErrMsg      string `json:"errmsg"`
+		InvalidUser string `json:"invaliduser"`
+	}
+	endpoint := c.config.APIURL + "/cgi-bin/message/send?access_token=" + url.QueryEscape(token)
+	if err := doJSON(ctx, c.httpClient, http.MethodPost, endpoint, nil, request, &response); err != nil {
+		return err
+	}
+	if response.ErrCode != 0 {
+		return fmt.Errorf("wecom message send failed: %d %s", response.ErrCode, response.ErrMsg)
+	}
+	if response.InvalidUser != "" && len(strings.Split(response.InvalidUser, "|")) == len(userIDs) {
+		return fmt.Errorf("wecom message send reached no user: invalid users %s", response.InvalidUser)
+	}
+
+	return nil
+}
+
+// ParseCallback verifies and decrypts a WeCom callback. GET requests are URL verification
+// and are answered with the decrypted echostr.
+func (c *WeComChannel) ParseCallback(req *service.ApprovalCallbackRequest) (*service.ApprovalCallback, error) {
+	signature := req.Query.Get("msg_signature")
+	timestamp, nonce := req.Query.Get("timestamp"), req.Query.Get("nonce")
+
+	if req.Method == http.MethodGet {
+		echo := req.Query.Get("echostr")
+		if !c.crypt.verify(signature, timestamp, nonce, echo) {
+			return nil, fmt.Errorf("%w: signature mismatch", service.ErrInvalidApprovalCallback)
+		}
+		plain, err := c.crypt.decrypt(echo)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", service.ErrInvalidApprovalCallback, err)
+		}
+		return &service.ApprovalCallback{Reply: plain, ReplyContentType: "text/plain"}, nil
+	}
+
+	var envelope struct {
+		Encrypt string `xml:"Encrypt"`
+	}
+	if err := xml.Unmarshal(req.Body, &envelope); err != nil {
+		return nil, fmt.Errorf("%w: %v", service.ErrInvalidApprovalCallback, err)
+	}
+
+	if err := checkTimestamp(timestamp); err != nil {
+		return nil, fmt.Errorf("%w: %v", service.ErrInvalidApprovalCallback, err)
+	}
+	if !c.crypt.verify(signature, timestamp, nonce, envelope.Encrypt) {
+		return nil, fmt.Errorf("%w: signature mismatch", service.ErrInvalidApprovalCallback)
+	}
+
+	plain, err := c.crypt.decrypt(envelope.Encrypt)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", service.ErrInvalidApprovalCallback, err)
+	}
+
+	var event struct {
+		FromUserName string `xml:"FromUserName"`
+		MsgType      string `xml:"MsgType"`
+		Event        string `xml:"Event"`
+		ApprovalInfo struct {
+			SpNo     string `xml:"SpNo"`
+			SpStatus int    `xml:"SpStatus"`
+		} `xml:"ApprovalInfo"`
+	}
+	if err := xml.Unmarshal(plain, &event); err != nil {
+		return nil, fmt.Errorf("%w: %v", service.ErrInvalidApprovalCallback, err)
+	}
+
+	callback := &service.ApprovalCallback{
+		Reply:            []byte("success"),
+		ReplyContentType: "text/plain",
+	}
+	if event.MsgType != "event" || event.Event != "sys_approval_change" {
+		return callback, nil
+	}
+
+	switch event.ApprovalInfo.SpStatus {
+	case weComStatusApproved:
+		callback.Decision = service.ApprovalDecisionApproved
+	case weComStatusRejected, weComStatusRevoked, weComStatusRevokedAfterPass, weComStatusDeleted:
+		callback.Decision = service.ApprovalDecisionRejected
+	default:
+		return callback, nil
+	}
+
+	callback.ExternalID = event.ApprovalInfo.SpNo
+	callback.OperatorID = event.FromUserName
+
+	return callback, nil
+}
+
+// fetchToken requests a new corp access token
+func (c *WeComChannel) fetchToken(ctx context.Context) (string, time.Duration, error) {
+	query := url.Values{"corpid": {c.config.CorpID}, "corpsecret": {c.config.Secret}}
+
+	var response struct {
+		ErrCode     int    `json:"errcode"`
+		ErrMsg      string `json:"errmsg"`
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := doJSON(ctx, c.httpClient, http.MethodGet, c.config.APIURL+"/cgi-bin/gettoken?"+query.Encode(), nil, nil, &response); err != nil {
+		return "", 0, err
+	}
+	if response.ErrCode != 0 {
+		return "", 0, fmt.Errorf("wecom gettoken failed: %d %s", response.ErrCode, response.ErrMsg)
+	}
+
+	return response.AccessToken, time.Duration(response.ExpiresIn) * time.Second, nil
+}
@@ -0,0 +1,199 @@
+// Package auditstream forwards audit logs to syslog collectors and HTTP endpoints such as a SIEM
+package auditstream
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+)
+
+// Identity of the events in syslog and CEF
+const (
+	appName       = "cmdb"
+	cefVendor     = "CMDB"
+	cefProduct    = "CMDB"
+	cefVersion    = "1.0"
+	syslogVersion = 1
+	// sdID names the structured data element, under the enterprise number set aside for
+	// documentation by RFC 5612
+	sdID = "cmdb@32473"
+	// syslogFacility is facility 13, log audit
+	syslogFacility = 13
+)
+
+// Syslog severities of audit logs
+const (
+	severityWarning = 4
+	severityNotice  = 5
+)
+
+// formatEvent renders an audit log as the body of a message in a format
+func formatEvent(format string, log *model.AuditLog) ([]byte, error) {
+	switch format {
+	case model.AuditFormatJSON:
+		return json.Marshal(log)
+	case model.AuditFormatCEF:
+		return []byte(formatCEF(log)), nil
+	case model.AuditFormatRFC5424:
+		return []byte(log.Description), nil
+	}
+	return nil, fmt.Errorf("unknown audit format %q", format)
+}
+
+// formatSyslog renders an audit log as an RFC 5424 syslog message. The fields of the audit log
+// are sent as structured data, and the message is the audit log in the sink's format.
+func formatSyslog(format, hostname string, log *model.AuditLog) ([]byte, error) {
+	message, err := formatEvent(format, log)
+	if err != nil {
+		return nil, err
+	}
+
+	severity := severityNotice
+	if !log.Success || log.Action == model.PermissionDenied {
+		severity = severityWarning
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<%d>%d %s %s %s %d %s ",
+		syslogFacility*8+severity,
+		syslogVersion,
+		log.Timestamp.UTC().Format(time.RFC3339Nano),
+		syslogHeaderField(hostname, 255),
+		appName,
+		os.Getpid(),
+		syslogHeaderField(string(log.Action), 32),
+	)
+	b.WriteString(structuredData(log))
+	if len(message) > 0 {
+		b.WriteByte(' ')
+		b.Write(message)
+	}
+	return []byte(b.String()), nil
+}
+
+// structuredData renders the fields of an audit log as an RFC 5424 SD element
+func structuredData(log *model.AuditLog) string {
+	params := [][2]string{
+		{"sequence", strconv.FormatInt(log.Sequence, 10)},
+		{"hash", log.Hash},
+		{"userId", log.UserID},
+		{"username", log.Username},
+		{"resourceType", log.ResourceType},
+		{"resourceId", log.ResourceID},
+		{"resourceName", log.ResourceName},
+		{"ipAddress", log.IPAddress},
+		{"method", log.Method},
+		{"path", log.Path},
+		{"success", strconv.FormatBool(log.Success)},
+		{"error", log.ErrorMessage},
+	}
+	if log.StatusCode != 0 {
+		params = append(params, [2]string{"statusCode", strconv.Itoa(log.StatusCode)})
+	}
+
+	var b strings.Builder
+	b.WriteString("[" + sdID)
+	for _, param := range params {
+		if param[1] == "" {
+			continue
+		}
+		b.WriteString(" " + param[0] + `="` + sdEscaper.Replace(param[1]) + `"`)
+	}
+	b.WriteString("]")
+	return b.String()
+}
+
+// sdEscaper escapes the characters RFC 5424 reserves in structured data values
+var sdEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+// syslogHeaderField fits a value into a syslog header field: printable ASCII without spaces,
+// or - when empty
+func syslogHeaderField(value string, maxLength int) string {
+	field := strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return '_'
+		}
+		return r
+	}, value)
+	if field == "" {
+		return "-"
+	}
+	if len(field) > maxLength {
+		field = field[:maxLength]
+	}
+	return field
+}
+
+// formatCEF renders an audit log as a Common Event Format event
+func formatCEF(log *model.AuditLog) string {
+	severity := 3
+	if !log.Success || log.Action == model.PermissionDenied {
+		severity = 7
+	}
+	outcome := "success"
+	if !log.Success {
+		outcome = "failure"
+	}
+
+	extensions := [][2]string{
+		{"rt", strconv.FormatInt(log.Timestamp.UnixMilli(), 10)},
+		{"suid", log.UserID},
+		{"suser", log.Username},
+		{"src", log.IPAddress},
+		{"requestClientApplication", log.UserAgent},
+		{"requestMethod", log.Method},
+		{"request", log.Path},
+		{"outcome", outcome},
+		{"reason", log.ErrorMessage},
+	}
+	// Custom fields carry their name in a label
+	custom := [][3]string{
+		{"cs1", "resourceType", log.ResourceType},
+		{"cs2", "resourceId", log.ResourceID},
+		{"cs3", "resourceName", log.ResourceName},
+		{"cs4", "hash", log.Hash},
+		{"cn1", "sequence", strconv.FormatInt(log.Sequence, 10)},
+	}
+	if log.StatusCode != 0 {
+		custom = append(custom, [3]string{"cn2", "statusCode", strconv.Itoa(log.StatusCode)})
+	}
+	for _, field := range custom {
+		if field[2] != "" {
+			extensions = append(extensions, [2]string{field[0] + "Label", field[1]}, [2]string{field[0], field[2]})
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "CEF:0|%s|%s|%s|%s|%s|%d|",
+		cefHeaderEscaper.Replace(cefVendor),
+		cefHeaderEscaper.Replace(cefProduct),
+		cefHeaderEscaper.Replace(cefVersion),
+		cefHeaderEscaper.Replace(string(log.Action)),
+		cefHeaderEscaper.Replace(log.Description),
+		severity,
+	)
+	first := true
+	for _, extension := range extensions {
+		if extension[1] == "" {
+			continue
+		}
+		if !first {
+			b.WriteByte(' ')
+		}
+		first = false
+		b.WriteString(extension[0] + "=" + cefValueEscaper.Replace(extension[1]))
+	}
+	return b.String()
+}
+
+// cefHeaderEscaper and cefValueEscaper escape the characters CEF reserves in header fields
+// and extension values
+var (
+	cefHeaderEscaper = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\r", " ", "\n", " ")
+	cefValueEscaper  = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\r", `\r`, "\n", `\n`)
+)
@@ -0,0 +1,76 @@
+package auditstream
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+)
+
+// HTTPSink posts batches of audit logs to an HTTP endpoint, one event per line: JSON objects
+// as application/x-ndjson, or CEF events as text/plain
+type HTTPSink struct {
+	config     model.AuditSinkConfig
+	httpClient *http.Client
+}
+
+// NewHTTPSink creates a new HTTP sink
+func NewHTTPSink(config model.AuditSinkConfig) (*HTTPSink, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if config.CAFile != "" || config.CertFile != "" || config.InsecureSkipVerify {
+		tlsConfig, err := newTLSConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	return &HTTPSink{
+		config:     config,
+		httpClient: &http.Client{Timeout: 10 * time.Second, Transport: transport},
+	}, nil
+}
+
+// Name returns the name of the sink
+func (s *HTTPSink) Name() string {
+	return s.config.Name
+}
+
+// Send posts audit logs to the endpoint; any status other than 2xx fails the batch
+func (s *HTTPSink) Send(ctx context.Context, logs []*model.AuditLog) error {
+	var body bytes.Buffer
+	for _, log := range logs {
+		event, err := formatEvent(s.config.Format, log)
+		if err != nil {
+			return err
+		}
+		body.Write(event)
+		body.WriteByte('\n')
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.URL, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if s.config.Format == model.AuditFormatCEF {
+		req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	for key, value := range s.config.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("request to %s failed with status %d", s.config.URL, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+package auditstream
+
+import (
+	"fmt"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/service"
+)
+
+// NewSink creates the sink a configuration describes
+func NewSink(config model.AuditSinkConfig) (service.AuditSink, error) {
+	switch config.Type {
+	case model.AuditSinkSyslog:
+		return NewSyslogSink(config)
+	case model.AuditSinkHTTP:
+		return NewHTTPSink(config)
+	}
+	return nil, fmt.Errorf("unknown audit sink type %q", config.Type)
+}
@@ -0,0 +1,143 @@
+package auditstream
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+)
+
+// syslogTimeout bounds connecting to a collector and writing a batch to it
+const syslogTimeout = 10 * time.Second
+
+// maxUDPMessage is the size UDP messages are cut to, so that large changes still fit a datagram
+const maxUDPMessage = 8192
+
+// SyslogSink sends audit logs as RFC 5424 messages over UDP, TCP or TLS. Over TCP and TLS
+// messages are framed by octet counting, as RFC 6587 and RFC 5425 describe.
+type SyslogSink struct {
+	config    model.AuditSinkConfig
+	hostname  string
+	tlsConfig *tls.Config
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewSyslogSink creates a new syslog sink
+func NewSyslogSink(config model.AuditSinkConfig) (*SyslogSink, error) {
+	hostname, _ := os.Hostname()
+	sink := &SyslogSink{
+		config:   config,
+		hostname: hostname,
+	}
+
+	if config.Network == "tls" {
+		tlsConfig, err := newTLSConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		sink.tlsConfig = tlsConfig
+	}
+	return sink, nil
+}
+
+// Name returns the name of the sink
+func (s *SyslogSink) Name() string {
+	return s.config.Name
+}
+
+// Send writes audit logs to the collector, connecting first if needed. The connection is
+// dropped after a failed write and made again for the next batch.
+func (s *SyslogSink) Send(ctx context.Context, logs []*model.AuditLog) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		conn, err := s.dial(ctx)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+
+	if err := s.write(logs); err != nil {
+		s.conn.Close()
+		s.conn = nil
+		return err
+	}
+	return nil
+}
+
+func (s *SyslogSink) write(logs []*model.AuditLog) error {
+	if err := s.conn.SetWriteDeadline(time.Now().Add(syslogTimeout)); err != nil {
+		return err
+	}
+
+	for _, log := range logs {
+		message, err := formatSyslog(s.config.Format, s.hostname, log)
+		if err != nil {
+			return err
+		}
+		if s.config.Network == "udp" {
+			message = message[:min(len(message), maxUDPMessage)]
+		} else {
+			message = append([]byte(strconv.Itoa(len(message))+" "), message...)
+		}
+		if _, err := s.conn.Write(message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SyslogSink) dial(ctx context.Context) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: syslogTimeout}
+	switch s.config.Network {
+	case "udp", "tcp":
+		return dialer.DialContext(ctx, s.config.Network, s.config.Address)
+	case "tls":
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: s.tlsConfig}
+		return tlsDialer.DialContext(ctx, "tcp", s.config.Address)
+	}
+	return nil, fmt.Errorf("unknown syslog network %q", s.config.Network)
+}
+
+// newTLSConfig builds the TLS settings of a sink: the CA that signed the collector's
+// certificate, and the certificate of this server if the collector asks for one
+func newTLSConfig(config model.AuditSinkConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         config.ServerName,
+		InsecureSkipVerify: config.InsecureSkipVerify,
+	}
+
+	if config.CAFile != "" {
+		data, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, errors.New("no certificates found in " + config.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if config.CertFile != "" {
+		certificate, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+	return tlsConfig, nil
+}
@@ -0,0 +1,184 @@
+package cloud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+)
+
+func TestAliyunProviderFixtures(t *testing.T) {
+	account := model.NewCloudAccount("prod", "aliyun", "admin")
+	account.Regions = []string{"cn-hangzhou"}
+	account.FixturesDir = "testdata/aliyun"
+
+	resources, err := NewAliyunProvider(time.Second).Discover(context.Background(), account)
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+
+	const vpcID = "vpc-bp1main000000000001"
+	want := []model.CloudResource{
+		{
+			// Instances on the classic network have inner and public addresses instead
+			Kind: model.KindInstance, ID: "i-bp1old0000000000001", Name: "legacy", Region: "cn-hangzhou", Zone: "cn-hangzhou-b",
+			State: "Stopped", IPAddress: "10.80.0.5", Hostname: "legacy", PriceKey: "ecs.t5-lc1m1.small",
+			Attributes: map[string]interface{}{
+				"instanceType": "ecs.t5-lc1m1.small", "image": "centos_7_9_x64_20G_alibase_20230919.vhd", "vpcId": "",
+				"privateIp": "10.80.0.5", "publicIp": "121.40.1.5",
+			},
+		},
+		{
+			// The EIP is the public address of an instance in a VPC
+			Kind: model.KindInstance, ID: "i-bp1web0000000000001", Name: "web-1", Region: "cn-hangzhou", Zone: "cn-hangzhou-h",
+			State: "Running", IPAddress: "172.16.0.10", Hostname: "iZbp1web0000000000001Z",
+			Tags: map[string]string{"env": "prod"}, PriceKey: "ecs.g6.large", VPCID: vpcID,
+			Attributes: map[string]interface{}{
+				"instanceType": "ecs.g6.large", "image": "ubuntu_22_04_x64_20G_alibase_20240130.vhd", "vpcId": vpcID,
+				"privateIp": "172.16.0.10", "publicIp": "47.98.1.10",
+			},
+		},
+		{
+			Kind: model.KindLoadBalancer, ID: "lb-bp1web000000000001", Name: "web", Region: "cn-hangzhou", Zone: "cn-hangzhou-h",
+			State: "active", IPAddress: "172.16.0.100", Tags: map[string]string{"team": "shop"},
+			PriceKey: "load_balancer:slb.s2.small", VPCID: vpcID,
+			Attributes: map[string]interface{}{"scheme": "intranet", "loadBalancerType": "slb.s2.small", "vpcId": vpcID},
+		},
+		{
+			Kind: model.KindVolume, ID: "d-bp1data000000000001", Name: "web-1-data", Region: "cn-hangzhou", Zone: "cn-hangzhou-h",
+			State: "In_use", Tags: map[string]string{"env": "prod"}, PriceKey: "volume:cloud_essd", Units: 40,
+			AttachedTo: []string{"i-bp1web0000000000001"},
+			Attributes: map[string]interface{}{"sizeGiB": int64(40), "volumeType": "cloud_essd", "attachedTo": "i-bp1web0000000000001"},
+		},
+		{
+			Kind: model.KindVolume, ID: "d-bp1spare00000000001", Region: "cn-hangzhou", Zone: "cn-hangzhou-h",
+			State: "Available", PriceKey: "volume:cloud_efficiency", Units: 20,
+			Attributes: map[string]interface{}{"sizeGiB": int64(20), "volumeType": "cloud_efficiency", "attachedTo": ""},
+		},
+		{
+			// VPCs name the fields of their tags Key and Value
+			Kind: model.KindVPC, ID: vpcID, Name: "main", Region: "cn-hangzhou", State: "Available",
+			Tags:       map[string]string{"env": "prod"},
+			Attributes: map[string]interface{}{"cidr": "172.16.0.0/12"},
+		},
+	}
+	checkResources(t, resources, want)
+}
+
+func TestAliyunSignature(t *testing.T) {
+	// The example of the RPC signature method in the Alibaba Cloud documentation
+	query := url.Values{
+		"AccessKeyId":      {"testid"},
+		"Action":           {"DescribeRegions"},
+		"Format":           {"XML"},
+		"SignatureMethod":  {"HMAC-SHA1"},
+		"SignatureNonce":   {"3ee8c1b8-83d3-44af-a94f-4e0ad82fd6cf"},
+		"SignatureVersion": {"1.0"},
+		"Timestamp":        {"2016-02-23T12:46:24Z"},
+		"Version":          {"2014-05-26"},
+	}
+	client := &aliyunClient{accessKeySecret: "testsecret"}
+	if got := client.signature(http.MethodGet, query); got != "OLeaidS1JvxuMvnyHOwuJ+uX5qY=" {
+		t.Errorf("signature() = %s, want OLeaidS1JvxuMvnyHOwuJ+uX5qY=", got)
+	}
+}
+
+// mockAliyun answers the RPC actions the provider calls. It has more instances than fit a page.
+type mockAliyun struct {
+	instances int
+}
+
+func (m *mockAliyun) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	signature := query.Get("Signature")
+	query.Del("Signature")
+	client := &aliyunClient{accessKeySecret: "secret"}
+	if signature != client.signature(http.MethodGet, query) || query.Get("AccessKeyId") != "LTAIexample" {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"Code": "SignatureDoesNotMatch", "Message": "Specified signature is not matched with our calculation."}`)
+		return
+	}
+	if query.Get("SecurityToken") != "sts" || query.Get("RegionId") != "cn-shanghai" || query.Get("Format") != "JSON" ||
+		query.Get("PageSize") != strconv.Itoa(aliyunPageSize) {
+		http.Error(w, "unexpected parameters "+query.Encode(), http.StatusBadRequest)
+		return
+	}
+	page, _ := strconv.Atoi(query.Get("PageNumber"))
+
+	var out interface{}
+	switch action := query.Get("Action"); {
+	case action == "DescribeInstances" && query.Get("Version") == aliyunECS.version:
+		var instances []map[string]interface{}
+		for i := (page-1)*aliyunPageSize + 1; i <= m.instances && i <= page*aliyunPageSize; i++ {
+			instances = append(instances, map[string]interface{}{
+				"InstanceId": fmt.Sprintf("i-%03d", i),
+				"Status":     "Running",
+				"VpcAttributes": map[string]interface{}{
+					"PrivateIpAddress": map[string]interface{}{"IpAddress": []string{fmt.Sprintf("172.16.1.%d", i)}},
+				},
+			})
+		}
+		out = map[string]interface{}{"Instances": map[string]interface{}{"Instance": instances}}
+	case action == "DescribeDisks" && query.Get("Version") == aliyunECS.version:
+		out = map[string]interface{}{"Disks": map[string]interface{}{"Disk": []map[string]interface{}{
+			{"DiskId": "d-1", "Size": 40, "Category": "cloud_essd", "InstanceId": "i-001"},
+		}}}
+	case action == "DescribeLoadBalancers" && query.Get("Version") == aliyunSLB.version:
+		out = map[string]interface{}{"LoadBalancers": map[string]interface{}{"LoadBalancer": []map[string]interface{}{}}}
+	case action == "DescribeVpcs" && query.Get("Version") == aliyunVPC.version:
+		out = map[string]interface{}{"Vpcs": map[string]interface{}{"Vpc": []map[string]interface{}{
+			{"VpcId": "vpc-1", "CidrBlock": "172.16.0.0/12"},
+		}}}
+	default:
+		http.Error(w, "unexpected action "+action+" "+query.Get("Version"), http.StatusBadRequest)
+		return
+	}
+	json.NewEncoder(w).Encode(out)
+}
+
+func TestAliyunProviderMockEndpoint(t *testing.T) {
+	server := httptest.NewServer(&mockAliyun{instances: aliyunPageSize + 1})
+	defer server.Close()
+
+	account := model.NewCloudAccount("prod", "aliyun", "admin")
+	account.Regions = []string{"cn-shanghai"}
+	account.Endpoint = server.URL
+	account.CredentialsFile = writeCredentials(t, map[string]string{
+		"ALIBABA_CLOUD_ACCESS_KEY_ID":     "LTAIexample",
+		"ALIBABA_CLOUD_ACCESS_KEY_SECRET": "secret",
+		"ALIBABA_CLOUD_SECURITY_TOKEN":    "sts",
+	})
+
+	resources, err := NewAliyunProvider(5*time.Second).Discover(context.Background(), account)
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+	counts := make(map[model.CloudResourceKind]int)
+	for _, resource := range resources {
+		counts[resource.Kind]++
+	}
+	// The last instance is on the second page
+	if counts[model.KindInstance] != aliyunPageSize+1 || counts[model.KindVolume] != 1 || counts[model.KindVPC] != 1 {
+		t.Errorf("discovered %v, want %d instances, a volume and a VPC", counts, aliyunPageSize+1)
+	}
+	if last := resources[aliyunPageSize]; last.ID != "i-051" || last.IPAddress != "172.16.1.51" {
+		t.Errorf("last instance = %+v", last)
+	}
+
+	account.CredentialsFile = writeCredentials(t, map[string]string{
+		"ALIBABA_CLOUD_ACCESS_KEY_ID":     "LTAIexample",
+		"ALIBABA_CLOUD_ACCESS_KEY_SECRET": "wrong",
+	})
+	_, err = NewAliyunProvider(5*time.Second).Discover(context.Background(), account)
+	if err == nil || !strings.Contains(err.Error(), "SignatureDoesNotMatch") {
+		t.Errorf("Discover() with a wrong secret error = %v, want the API error", err)
+	}
+}
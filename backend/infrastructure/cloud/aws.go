@@ -0,0 +1,469 @@
+package cloud
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+)
+
+// API versions of the AWS query APIs called
+const (
+	ec2APIVersion   = "2016-11-15"
+	elbv2APIVersion = "2015-12-01"
+)
+
+// awsPageSize is the number of resources requested per call
+const awsPageSize = 400
+
+// awsCaller calls an action of an AWS query API and decodes its response
+type awsCaller interface {
+	call(ctx context.Context, region, service, version, action string, params url.Values, out interface{}) error
+}
+
+// AWSProvider discovers EC2 instances, EBS volumes, VPCs and Elastic Load Balancing (v2) load
+// balancers. Credentials are AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and, for temporary
+// credentials, AWS_SESSION_TOKEN.
+type AWSProvider struct {
+	// Timeout limits each request to the API
+	Timeout time.Duration
+}
+
+// NewAWSProvider creates a new AWS provider
+func NewAWSProvider(timeout time.Duration) *AWSProvider {
+	return &AWSProvider{
+		Timeout: timeout,
+	}
+}
+
+// Discover discovers the resources of an account in each of its regions. Recorded responses
+// are those of the AWS CLI with JSON output, e.g. aws ec2 describe-instances, stored as
+// <region>/ec2/DescribeInstances.json, <region>/ec2/DescribeVolumes.json,
+// <region>/ec2/DescribeVpcs.json, <region>/elasticloadbalancing/DescribeLoadBalancers.json
+// and <region>/elasticloadbalancing/DescribeTags.json.
+func (p *AWSProvider) Discover(ctx context.Context, account *model.CloudAccount) ([]model.CloudResource, error) {
+	var caller awsCaller
+	if account.FixturesDir != "" {
+		caller = &awsFixtures{dir: account.FixturesDir}
+	} else {
+		creds, err := loadCredentials(account)
+		if err != nil {
+			return nil, err
+		}
+		values, err := creds.require("AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY")
+		if err != nil {
+			return nil, err
+		}
+		caller = &awsClient{
+			accessKeyID:     values[0],
+			secretAccessKey: values[1],
+			sessionToken:    creds("AWS_SESSION_TOKEN"),
+			endpoint:        strings.TrimRight(account.Endpoint, "/"),
+			client:          &http.Client{Timeout: p.Timeout},
+		}
+	}
+
+	var resources []model.CloudResource
+	for _, region := range account.Regions {
+		found, err := discoverAWSRegion(ctx, caller, region)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", region, err)
+		}
+		resources = append(resources, found...)
+	}
+	sortResources(resources)
+	return resources, nil
+}
+
+// awsTag is a tag of an EC2 resource
+type awsTag struct {
+	Key   string `json:"Key" xml:"key"`
+	Value string `json:"Value" xml:"value"`
+}
+
+// elbTag is a tag of a load balancer
+type elbTag struct {
+	Key   string `json:"Key" xml:"Key"`
+	Value string `json:"Value" xml:"Value"`
+}
+
+// The responses of the calls below are decoded from the XML of the API as well as from the
+// JSON of the AWS CLI, which names fields differently.
+
+type ec2Instance struct {
+	InstanceID   string `json:"InstanceId" xml:"instanceId"`
+	InstanceType string `json:"InstanceType" xml:"instanceType"`
+	ImageID      string `json:"ImageId" xml:"imageId"`
+	State        struct {
+		Name string `json:"Name" xml:"name"`
+	} `json:"State" xml:"instanceState"`
+	PrivateIPAddress string `json:"PrivateIpAddress" xml:"privateIpAddress"`
+	PublicIPAddress  string `json:"PublicIpAddress" xml:"ipAddress"`
+	PrivateDNSName   string `json:"PrivateDnsName" xml:"privateDnsName"`
+	Placement        struct {
+		AvailabilityZone string `json:"AvailabilityZone" xml:"availabilityZone"`
+	} `json:"Placement" xml:"placement"`
+	VpcID string   `json:"VpcId" xml:"vpcId"`
+	Tags  []awsTag `json:"Tags" xml:"tagSet>item"`
+}
+
+type describeInstancesOutput struct {
+	Reservations []struct {
+		Instances []ec2Instance `json:"Instances" xml:"instancesSet>item"`
+	} `json:"Reservations" xml:"reservationSet>item"`
+	NextToken string `json:"NextToken" xml:"nextToken"`
+}
+
+type ec2Volume struct {
+	VolumeID         string `json:"VolumeId" xml:"volumeId"`
+	Size             int64  `json:"Size" xml:"size"`
+	VolumeType       string `json:"VolumeType" xml:"volumeType"`
+	State            string `json:"State" xml:"status"`
+	AvailabilityZone string `json:"AvailabilityZone" xml:"availabilityZone"`
+	Attachments      []struct {
+		InstanceID string `json:"InstanceId" xml:"instanceId"`
+	} `json:"Attachments" xml:"attachmentSet>item"`
+	Tags []awsTag `json:"Tags" xml:"tagSet>item"`
+}
+
+type describeVolumesOutput struct {
+	Volumes   []ec2Volume `json:"Volumes" xml:"volumeSet>item"`
+	NextToken string      `json:"NextToken" xml:"nextToken"`
+}
+
+type ec2VPC struct {
+	VpcID     string   `json:"VpcId" xml:"vpcId"`
+	CidrBlock string   `json:"CidrBlock" xml:"cidrBlock"`
+	State     string   `json:"State" xml:"state"`
+	Tags      []awsTag `json:"Tags" xml:"tagSet>item"`
+}
+
+type describeVpcsOutput struct {
+	Vpcs      []ec2VPC `json:"Vpcs" xml:"vpcSet>item"`
+	NextToken string   `json:"NextToken" xml:"nextToken"`
+}
+
+type elbLoadBalancer struct {
+	LoadBalancerArn  string `json:"LoadBalancerArn" xml:"LoadBalancerArn"`
+	LoadBalancerName string `json:"LoadBalancerName" xml:"LoadBalancerName"`
+	DNSName          string `json:"DNSName" xml:"DNSName"`
+	Scheme           string `json:"Scheme" xml:"Scheme"`
+	Type             string `json:"Type" xml:"Type"`
+	VpcID            string `json:"VpcId" xml:"VpcId"`
+	State            struct {
+		Code string `json:"Code" xml:"Code"`
+	} `json:"State" xml:"State"`
+	AvailabilityZones []struct {
+		ZoneName string `json:"ZoneName" xml:"ZoneName"`
+	} `json:"AvailabilityZones" xml:"AvailabilityZones>member"`
+}
+
+type describeLoadBalancersOutput struct {
+	LoadBalancers []elbLoadBalancer `json:"LoadBalancers" xml:"DescribeLoadBalancersResult>LoadBalancers>member"`
+	NextMarker    string            `json:"NextMarker" xml:"DescribeLoadBalancersResult>NextMarker"`
+}
+
+type describeTagsOutput struct {
+	TagDescriptions []struct {
+		ResourceArn string   `json:"ResourceArn" xml:"ResourceArn"`
+		Tags        []elbTag `json:"Tags" xml:"Tags>member"`
+	} `json:"TagDescriptions" xml:"DescribeTagsResult>TagDescriptions>member"`
+}
+
+// discoverAWSRegion discovers the resources of a region
+func discoverAWSRegion(ctx context.Context, caller awsCaller, region string) ([]model.CloudResource, error) {
+	var resources []model.CloudResource
+	ec2Call := func(action, token string, out interface{}) error {
+		params := url.Values{"MaxResults": {fmt.Sprint(awsPageSize)}}
+		if token != "" {
+			params.Set("NextToken", token)
+		}
+		return caller.call(ctx, region, "ec2", ec2APIVersion, action, params, out)
+	}
+
+	for token := ""; ; {
+		var out describeInstancesOutput
+		if err := ec2Call("DescribeInstances", token, &out); err != nil {
+			return nil, err
+		}
+		for _, reservation := range out.Reservations {
+			for _, instance := range reservation.Instances {
+				// Terminated instances stay listed for a while after they are gone
+				if instance.State.Name == "terminated" {
+					continue
+				}
+				tags := tagMap(instance.Tags, func(t awsTag) (string, string) { return t.Key, t.Value })
+				resources = append(resources, model.CloudResource{
+					Kind:      model.KindInstance,
+					ID:        instance.InstanceID,
+					Name:      tags["Name"],
+					Region:    region,
+					Zone:      instance.Placement.AvailabilityZone,
+					State:     instance.State.Name,
+					IPAddress: instance.PrivateIPAddress,
+					Hostname:  instance.PrivateDNSName,
+					Tags:      tags,
+					PriceKey:  instance.InstanceType,
+					VPCID:     instance.VpcID,
+					Attributes: map[string]interface{}{
+						"instanceType": instance.InstanceType,
+						"image":        instance.ImageID,
+						"vpcId":        instance.VpcID,
+						"privateIp":    instance.PrivateIPAddress,
+						"publicIp":     instance.PublicIPAddress,
+					},
+				})
+			}
+		}
+		if token = out.NextToken; token == "" {
+			break
+		}
+	}
+
+	for token := ""; ; {
+		var out describeVolumesOutput
+		if err := ec2Call("DescribeVolumes", token, &out); err != nil {
+			return nil, err
+		}
+		for _, volume := range out.Volumes {
+			tags := tagMap(volume.Tags, func(t awsTag) (string, string) { return t.Key, t.Value })
+			var attachedTo []string
+			for _, attachment := range volume.Attachments {
+				attachedTo = append(attachedTo, attachment.InstanceID)
+			}
+			resources = append(resources, model.CloudResource{
+				Kind:       model.KindVolume,
+				ID:         volume.VolumeID,
+				Name:       tags["Name"],
+				Region:     region,
+				Zone:       volume.AvailabilityZone,
+				State:      volume.State,
+				Tags:       tags,
+				PriceKey:   "volume:" + volume.VolumeType,
+				Units:      float64(volume.Size),
+				AttachedTo: attachedTo,
+				Attributes: map[string]interface{}{
+					"sizeGiB":    volume.Size,
+					"volumeType": volume.VolumeType,
+					"attachedTo": strings.Join(attachedTo, ","),
+				},
+			})
+		}
+		if token = out.NextToken; token == "" {
+			break
+		}
+	}
+
+	for token := ""; ; {
+		var out describeVpcsOutput
+		if err := ec2Call("DescribeVpcs", token, &out); err != nil {
+			return nil, err
+		}
+		for _, vpc := range out.Vpcs {
+			tags := tagMap(vpc.Tags, func(t awsTag) (string, string) { return t.Key, t.Value })
+			resources = append(resources, model.CloudResource{
+				Kind:       model.KindVPC,
+				ID:         vpc.VpcID,
+				Name:       tags["Name"],
+				Region:     region,
+				State:      vpc.State,
+				Tags:       tags,
+				Attributes: map[string]interface{}{"cidr": vpc.CidrBlock},
+			})
+		}
+		if token = out.NextToken; token == "" {
+			break
+		}
+	}
+
+	var loadBalancers []elbLoadBalancer
+	for marker := ""; ; {
+		params := url.Values{"PageSize": {fmt.Sprint(awsPageSize)}}
+		if marker != "" {
+			params.Set("Marker", marker)
+		}
+		var out describeLoadBalancersOutput
+		if err := caller.call(ctx, region, "elasticloadbalancing", elbv2APIVersion, "DescribeLoadBalancers", params, &out); err != nil {
+			return nil, err
+		}
+		loadBalancers = append(loadBalancers, out.LoadBalancers...)
+		if marker = out.NextMarker; marker == "" {
+			break
+		}
+	}
+
+	lbTags, err := describeELBTags(ctx, caller, region, loadBalancers)
+	if err != nil {
+		return nil, err
+	}
+	for _, lb := range loadBalancers {
+		// Load balancers spanning zones are located in their region only
+		zone := ""
+		if len(lb.AvailabilityZones) == 1 {
+			zone = lb.AvailabilityZones[0].ZoneName
+		}
+		resources = append(resources, model.CloudResource{
+			Kind:     model.KindLoadBalancer,
+			ID:       lb.LoadBalancerArn,
+			Name:     lb.LoadBalancerName,
+			Region:   region,
+			Zone:     zone,
+			State:    lb.State.Code,
+			Hostname: lb.DNSName,
+			Tags:     lbTags[lb.LoadBalancerArn],
+			PriceKey: "load_balancer:" + lb.Type,
+			VPCID:    lb.VpcID,
+			Attributes: map[string]interface{}{
+				"dnsName":          lb.DNSName,
+				"scheme":           lb.Scheme,
+				"loadBalancerType": lb.Type,
+				"vpcId":            lb.VpcID,
+			},
+		})
+	}
+
+	return resources, nil
+}
+
+// describeELBTags reads the tags of load balancers, which DescribeLoadBalancers leaves out
+func describeELBTags(ctx context.Context, caller awsCaller, region string, loadBalancers []elbLoadBalancer) (map[string]map[string]string, error) {
+	// DescribeTags accepts up to 20 load balancers per call
+	const batchSize = 20
+
+	tags := make(map[string]map[string]string, len(loadBalancers))
+	for start := 0; start < len(loadBalancers); start += batchSize {
+		params := url.Values{}
+		for i, lb := range loadBalancers[start:min(start+batchSize, len(loadBalancers))] {
+			params.Set(fmt.Sprintf("ResourceArns.member.%d", i+1), lb.LoadBalancerArn)
+		}
+		var out describeTagsOutput
+		if err := caller.call(ctx, region, "elasticloadbalancing", elbv2APIVersion, "DescribeTags", params, &out); err != nil {
+			return nil, err
+		}
+		for _, description := range out.TagDescriptions {
+			tags[description.ResourceArn] = tagMap(description.Tags, func(t elbTag) (string, string) { return t.Key, t.Value })
+		}
+	}
+	return tags, nil
+}
+
+// awsClient calls the live AWS query APIs, or a mock of them at endpoint
+type awsClient struct {
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+	endpoint        string
+	client          *http.Client
+}
+
+// call posts an action to the API of a service in a region, signed with Signature Version 4
+func (c *awsClient) call(ctx context.Context, region, service, version, action string, params url.Values, out interface{}) error {
+	form := url.Values{"Action": {action}, "Version": {version}}
+	for name, values := range params {
+		form[name] = values
+	}
+	body := []byte(form.Encode())
+
+	endpoint := c.endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.%s.amazonaws.com", service, region)
+		if strings.HasPrefix(region, "cn-") {
+			endpoint += ".cn"
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	c.sign(req, body, region, service, time.Now())
+
+	data, _, err := send(c.client, req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", service, action, err)
+	}
+	if err := xml.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("%s %s: %w", service, action, err)
+	}
+	return nil
+}
+
+// sign adds a Signature Version 4 authorization to a request
+func (c *awsClient) sign(req *http.Request, body []byte, region, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	headers := []string{"content-type", "host", "x-amz-date"}
+	values := map[string]string{
+		"content-type": req.Header.Get("Content-Type"),
+		"host":         req.URL.Host,
+		"x-amz-date":   amzDate,
+	}
+	if c.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", c.sessionToken)
+		headers = append(headers, "x-amz-security-token")
+		values["x-amz-security-token"] = c.sessionToken
+	}
+
+	var canonicalHeaders strings.Builder
+	for _, name := range headers {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(values[name]) + "\n")
+	}
+	signedHeaders := strings.Join(headers, ";")
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method, path, req.URL.RawQuery, canonicalHeaders.String(), signedHeaders, hexSHA256(body),
+	}, "\n")
+	scope := strings.Join([]string{date, region, service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256", amzDate, scope, hexSHA256([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := []byte("AWS4" + c.secretAccessKey)
+	for _, part := range []string{date, region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.accessKeyID, scope, signedHeaders, signature))
+}
+
+// awsFixtures reads responses recorded with the AWS CLI. Recordings hold a single page; the
+// DescribeTags recording holds the tags of every load balancer.
+type awsFixtures struct {
+	dir string
+}
+
+// call reads the recorded response of an action
+func (f *awsFixtures) call(ctx context.Context, region, service, version, action string, params url.Values, out interface{}) error {
+	if params.Get("NextToken") != "" || params.Get("Marker") != "" {
+		return nil
+	}
+	return readFixture(f.dir, region, service, action, out)
+}
+
+func hexSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
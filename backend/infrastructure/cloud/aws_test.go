@@ -0,0 +1,324 @@
+package cloud
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+)
+
+func TestAWSProviderFixtures(t *testing.T) {
+	account := model.NewCloudAccount("prod", "aws", "admin")
+	account.Regions = []string{"us-east-1"}
+	account.FixturesDir = "testdata/aws"
+
+	resources, err := NewAWSProvider(time.Second).Discover(context.Background(), account)
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+
+	const lbARN = "arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/web/50dc6c495c0c9188"
+	// The terminated instance is left out, and resources are ordered by kind and ID
+	want := []model.CloudResource{
+		{
+			Kind: model.KindInstance, ID: "i-0a1b2c3d4e5f60001", Name: "web-1", Region: "us-east-1", Zone: "us-east-1a",
+			State: "running", IPAddress: "10.1.0.10", Hostname: "ip-10-1-0-10.ec2.internal",
+			Tags:     map[string]string{"Name": "web-1", "env": "prod"},
+			PriceKey: "t3.medium", VPCID: "vpc-0a1b2c3d",
+			Attributes: map[string]interface{}{
+				"instanceType": "t3.medium", "image": "ami-0c55b159cbfafe1f0", "vpcId": "vpc-0a1b2c3d",
+				"privateIp": "10.1.0.10", "publicIp": "54.210.1.10",
+			},
+		},
+		{
+			Kind: model.KindInstance, ID: "i-0a1b2c3d4e5f60003", Region: "us-east-1", Zone: "us-east-1b",
+			State: "stopped", IPAddress: "10.1.0.20", Hostname: "ip-10-1-0-20.ec2.internal",
+			PriceKey: "m5.large", VPCID: "vpc-0a1b2c3d",
+			Attributes: map[string]interface{}{
+				"instanceType": "m5.large", "image": "ami-0f9fc25dd2506cf6d", "vpcId": "vpc-0a1b2c3d",
+				"privateIp": "10.1.0.20", "publicIp": "",
+			},
+		},
+		{
+			// Spanning two zones, the load balancer is located in its region only
+			Kind: model.KindLoadBalancer, ID: lbARN, Name: "web", Region: "us-east-1",
+			State: "active", Hostname: "web-1234567890.us-east-1.elb.amazonaws.com",
+			Tags:     map[string]string{"env": "prod"},
+			PriceKey: "load_balancer:application", VPCID: "vpc-0a1b2c3d",
+			Attributes: map[string]interface{}{
+				"dnsName": "web-1234567890.us-east-1.elb.amazonaws.com", "scheme": "internet-facing",
+				"loadBalancerType": "application", "vpcId": "vpc-0a1b2c3d",
+			},
+		},
+		{
+			Kind: model.KindVolume, ID: "vol-0a1b2c3d4e5f60001", Name: "web-1-data", Region: "us-east-1", Zone: "us-east-1a",
+			State: "in-use", Tags: map[string]string{"Name": "web-1-data"},
+			PriceKey: "volume:gp3", Units: 100, AttachedTo: []string{"i-0a1b2c3d4e5f60001"},
+			Attributes: map[string]interface{}{"sizeGiB": int64(100), "volumeType": "gp3", "attachedTo": "i-0a1b2c3d4e5f60001"},
+		},
+		{
+			Kind: model.KindVPC, ID: "vpc-0a1b2c3d", Name: "main", Region: "us-east-1", State: "available",
+			Tags:       map[string]string{"Name": "main"},
+			Attributes: map[string]interface{}{"cidr": "10.1.0.0/16"},
+		},
+	}
+	checkResources(t, resources, want)
+}
+
+// checkResources compares discovered resources one by one
+func checkResources(t *testing.T, got, want []model.CloudResource) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("discovered %d resources, want %d:\n%+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("resource %d =\n%+v\nwant\n%+v", i, got[i], want[i])
+		}
+	}
+}
+
+// writeCredentials writes a credentials file and clears the same variables from the environment
+func writeCredentials(t *testing.T, values map[string]string) string {
+	t.Helper()
+	for name := range values {
+		t.Setenv(name, "")
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// verifyAWSSignature checks the Signature Version 4 authorization of a request the way AWS
+// does, from the request as it was received
+func verifyAWSSignature(r *http.Request, body []byte, accessKeyID, secretAccessKey string) (region, service string, err error) {
+	authorization := r.Header.Get("Authorization")
+	var credential, signedHeaders, signature string
+	for _, part := range strings.Split(strings.TrimPrefix(authorization, "AWS4-HMAC-SHA256 "), ", ") {
+		name, value, _ := strings.Cut(part, "=")
+		switch name {
+		case "Credential":
+			credential = value
+		case "SignedHeaders":
+			signedHeaders = value
+		case "Signature":
+			signature = value
+		}
+	}
+	scope := strings.Split(credential, "/")
+	if len(scope) != 5 || scope[0] != accessKeyID || scope[4] != "aws4_request" {
+		return "", "", fmt.Errorf("credential %q", credential)
+	}
+	date, region, service := scope[1], scope[2], scope[3]
+	amzDate := r.Header.Get("X-Amz-Date")
+	if !strings.HasPrefix(amzDate, date) {
+		return "", "", fmt.Errorf("date %q outside scope %q", amzDate, date)
+	}
+
+	var canonicalHeaders strings.Builder
+	for _, name := range strings.Split(signedHeaders, ";") {
+		value := r.Header.Get(name)
+		if name == "host" {
+			value = r.Host
+		}
+		canonicalHeaders.WriteString(name + ":" + value + "\n")
+	}
+	canonicalRequest := strings.Join([]string{
+		r.Method, r.URL.EscapedPath(), r.URL.RawQuery, canonicalHeaders.String(), signedHeaders, hexSHA256(body),
+	}, "\n")
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256", amzDate, strings.Join(scope[1:], "/"), hexSHA256([]byte(canonicalRequest)),
+	}, "\n")
+	key := []byte("AWS4" + secretAccessKey)
+	for _, part := range scope[1:] {
+		key = hmacSHA256(key, part)
+	}
+	if expected := hex.EncodeToString(hmacSHA256(key, stringToSign)); signature != expected {
+		return "", "", fmt.Errorf("signature %q, want %q", signature, expected)
+	}
+	return region, service, nil
+}
+
+// mockAWS answers the query API actions the provider calls with XML responses. Instances and
+// load balancers are returned over two pages.
+type mockAWS struct {
+	loadBalancers int
+	// tagCalls counts the DescribeTags calls
+	tagCalls int
+}
+
+func (m *mockAWS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	region, service, err := verifyAWSSignature(r, body, "AKIDEXAMPLE", "wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY")
+	if err == nil && r.Header.Get("X-Amz-Security-Token") != "session" {
+		err = fmt.Errorf("session token %q", r.Header.Get("X-Amz-Security-Token"))
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprintf(w, "<ErrorResponse><Error><Code>SignatureDoesNotMatch</Code><Message>%s</Message></Error></ErrorResponse>", err)
+		return
+	}
+	form, _ := url.ParseQuery(string(body))
+	if region != "eu-west-1" {
+		http.Error(w, "unexpected region "+region, http.StatusBadRequest)
+		return
+	}
+
+	action := form.Get("Action")
+	switch {
+	case service == "ec2" && form.Get("Version") == ec2APIVersion && form.Get("MaxResults") == "400":
+		switch action {
+		case "DescribeInstances":
+			if form.Get("NextToken") == "" {
+				fmt.Fprint(w, `<DescribeInstancesResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/">
+<reservationSet><item><instancesSet><item>
+  <instanceId>i-1</instanceId><instanceType>t3.small</instanceType><imageId>ami-1</imageId>
+  <instanceState><code>16</code><name>running</name></instanceState>
+  <privateDnsName>ip-10-2-0-10.eu-west-1.compute.internal</privateDnsName>
+  <privateIpAddress>10.2.0.10</privateIpAddress><ipAddress>52.1.1.10</ipAddress>
+  <placement><availabilityZone>eu-west-1a</availabilityZone></placement><vpcId>vpc-1</vpcId>
+  <tagSet><item><key>Name</key><value>api-1</value></item></tagSet>
+</item></instancesSet></item></reservationSet>
+<nextToken>page-2</nextToken>
+</DescribeInstancesResponse>`)
+				return
+			}
+			fmt.Fprint(w, `<DescribeInstancesResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/">
+<reservationSet><item><instancesSet><item>
+  <instanceId>i-2</instanceId><instanceType>t3.small</instanceType>
+  <instanceState><code>16</code><name>running</name></instanceState>
+  <privateIpAddress>10.2.0.11</privateIpAddress>
+  <placement><availabilityZone>eu-west-1b</availabilityZone></placement><vpcId>vpc-1</vpcId>
+</item></instancesSet></item></reservationSet>
+</DescribeInstancesResponse>`)
+		case "DescribeVolumes":
+			fmt.Fprint(w, `<DescribeVolumesResponse><volumeSet><item>
+  <volumeId>vol-1</volumeId><size>20</size><volumeType>gp3</volumeType><status>in-use</status>
+  <availabilityZone>eu-west-1a</availabilityZone>
+  <attachmentSet><item><instanceId>i-1</instanceId></item></attachmentSet>
+</item></volumeSet></DescribeVolumesResponse>`)
+		case "DescribeVpcs":
+			fmt.Fprint(w, `<DescribeVpcsResponse><vpcSet><item>
+  <vpcId>vpc-1</vpcId><cidrBlock>10.2.0.0/16</cidrBlock><state>available</state>
+</item></vpcSet></DescribeVpcsResponse>`)
+		default:
+			http.Error(w, "unexpected action "+action, http.StatusBadRequest)
+		}
+	case service == "elasticloadbalancing" && form.Get("Version") == elbv2APIVersion:
+		switch action {
+		case "DescribeLoadBalancers":
+			// The first page holds all load balancers but the last
+			first, last, marker := 1, m.loadBalancers-1, "page-2"
+			if form.Get("Marker") != "" {
+				first, last, marker = m.loadBalancers, m.loadBalancers, ""
+			}
+			fmt.Fprint(w, "<DescribeLoadBalancersResponse><DescribeLoadBalancersResult><LoadBalancers>")
+			for i := first; i <= last; i++ {
+				fmt.Fprintf(w, `<member><LoadBalancerArn>arn:lb/%d</LoadBalancerArn><LoadBalancerName>lb-%d</LoadBalancerName>
+<DNSName>lb-%d.elb.amazonaws.com</DNSName><Scheme>internal</Scheme><Type>network</Type><VpcId>vpc-1</VpcId>
+<State><Code>active</Code></State><AvailabilityZones><member><ZoneName>eu-west-1a</ZoneName></member></AvailabilityZones></member>`, i, i, i)
+			}
+			fmt.Fprintf(w, "</LoadBalancers><NextMarker>%s</NextMarker></DescribeLoadBalancersResult></DescribeLoadBalancersResponse>", marker)
+		case "DescribeTags":
+			m.tagCalls++
+			fmt.Fprint(w, "<DescribeTagsResponse><DescribeTagsResult><TagDescriptions>")
+			for i := 1; form.Get(fmt.Sprintf("ResourceArns.member.%d", i)) != ""; i++ {
+				arn := form.Get(fmt.Sprintf("ResourceArns.member.%d", i))
+				fmt.Fprintf(w, "<member><ResourceArn>%s</ResourceArn><Tags><member><Key>lb</Key><Value>%s</Value></member></Tags></member>", arn, arn)
+			}
+			fmt.Fprint(w, "</TagDescriptions></DescribeTagsResult></DescribeTagsResponse>")
+		default:
+			http.Error(w, "unexpected action "+action, http.StatusBadRequest)
+		}
+	default:
+		http.Error(w, fmt.Sprintf("unexpected call of %s %s", service, form.Get("Version")), http.StatusBadRequest)
+	}
+}
+
+func TestAWSProviderMockEndpoint(t *testing.T) {
+	// More load balancers than DescribeTags accepts in one call
+	mock := &mockAWS{loadBalancers: 21}
+	server := httptest.NewServer(mock)
+	defer server.Close()
+
+	account := model.NewCloudAccount("prod", "aws", "admin")
+	account.Regions = []string{"eu-west-1"}
+	account.Endpoint = server.URL + "/"
+	account.CredentialsFile = writeCredentials(t, map[string]string{
+		"AWS_ACCESS_KEY_ID":     "AKIDEXAMPLE",
+		"AWS_SECRET_ACCESS_KEY": "wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY",
+		"AWS_SESSION_TOKEN":     "session",
+	})
+
+	resources, err := NewAWSProvider(5*time.Second).Discover(context.Background(), account)
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+
+	counts := make(map[model.CloudResourceKind]int)
+	byID := make(map[string]model.CloudResource)
+	for _, resource := range resources {
+		counts[resource.Kind]++
+		byID[resource.ID] = resource
+	}
+	wantCounts := map[model.CloudResourceKind]int{
+		model.KindInstance: 2, model.KindVolume: 1, model.KindVPC: 1, model.KindLoadBalancer: 21,
+	}
+	if !reflect.DeepEqual(counts, wantCounts) {
+		t.Errorf("discovered %v, want %v", counts, wantCounts)
+	}
+	if mock.tagCalls != 2 {
+		t.Errorf("DescribeTags called %d times for 21 load balancers, want 2", mock.tagCalls)
+	}
+
+	instance := byID["i-1"]
+	if instance.Name != "api-1" || instance.IPAddress != "10.2.0.10" || instance.Zone != "eu-west-1a" ||
+		instance.Attributes["publicIp"] != "52.1.1.10" || instance.Hostname != "ip-10-2-0-10.eu-west-1.compute.internal" {
+		t.Errorf("instance decoded from XML = %+v", instance)
+	}
+	if volume := byID["vol-1"]; volume.Units != 20 || !reflect.DeepEqual(volume.AttachedTo, []string{"i-1"}) {
+		t.Errorf("volume decoded from XML = %+v", volume)
+	}
+	if lb := byID["arn:lb/21"]; lb.Zone != "eu-west-1a" || lb.Tags["lb"] != "arn:lb/21" || lb.State != "active" {
+		t.Errorf("load balancer of the second page = %+v", lb)
+	}
+
+	// A rejected signature fails the discovery
+	account.CredentialsFile = writeCredentials(t, map[string]string{
+		"AWS_ACCESS_KEY_ID":     "AKIDEXAMPLE",
+		"AWS_SECRET_ACCESS_KEY": "wrong",
+	})
+	if _, err := NewAWSProvider(5*time.Second).Discover(context.Background(), account); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Discover() with a wrong secret error = %v, want 403", err)
+	}
+}
+
+func TestAWSProviderMissingCredentials(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+
+	account := model.NewCloudAccount("prod", "aws", "admin")
+	account.Regions = []string{"us-east-1"}
+	_, err := NewAWSProvider(time.Second).Discover(context.Background(), account)
+	if err == nil || err.Error() != "missing credentials: AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY" {
+		t.Errorf("Discover() error = %v, want both variables missing", err)
+	}
+}
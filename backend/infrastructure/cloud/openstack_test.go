@@ -0,0 +1,198 @@
+package cloud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+)
+
+func TestOpenStackProviderFixtures(t *testing.T) {
+	account := model.NewCloudAccount("lab", "openstack", "admin")
+	account.Regions = []string{"RegionOne"}
+	account.FixturesDir = "testdata/openstack"
+
+	resources, err := NewOpenStackProvider(time.Second).Discover(context.Background(), account)
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+
+	const (
+		web       = "4f1c6a2e-0000-4000-8000-000000000001"
+		networkID = "b6f5d1c2-0000-4000-8000-000000000001"
+	)
+	want := []model.CloudResource{
+		{
+			// IPv6 addresses are skipped and the floating address is the public one
+			Kind: model.KindInstance, ID: web, Name: "web-1", Region: "RegionOne", Zone: "nova",
+			State: "ACTIVE", IPAddress: "192.168.0.10", Tags: map[string]string{"role": "web"},
+			PriceKey: "m1.small", VPCID: networkID,
+			Attributes: map[string]interface{}{
+				"instanceType": "m1.small", "image": "70a599e0-31e7-49b7-b260-868f441e862b", "vpcId": networkID,
+				"privateIp": "192.168.0.10", "publicIp": "203.0.113.10",
+			},
+		},
+		{
+			// Booted from a volume, and listed before flavors had names
+			Kind: model.KindInstance, ID: "4f1c6a2e-0000-4000-8000-000000000002", Name: "db-1", Region: "RegionOne", Zone: "nova",
+			State: "SHUTOFF", IPAddress: "192.168.0.20", Tags: map[string]string{},
+			PriceKey: "m1.large", VPCID: networkID,
+			Attributes: map[string]interface{}{
+				"instanceType": "m1.large", "image": "", "vpcId": networkID, "privateIp": "192.168.0.20", "publicIp": "",
+			},
+		},
+		{
+			Kind: model.KindLoadBalancer, ID: "7a0e5b3d-0000-4000-8000-000000000001", Name: "web-lb", Region: "RegionOne",
+			State: "ONLINE", IPAddress: "192.168.0.100", Tags: map[string]string{"team": "shop"},
+			PriceKey: "load_balancer:amphora", VPCID: networkID,
+			Attributes: map[string]interface{}{"loadBalancerType": "amphora", "vpcId": networkID},
+		},
+		{
+			Kind: model.KindVolume, ID: "9c3d7e1f-0000-4000-8000-000000000001", Name: "web-1-data", Region: "RegionOne", Zone: "nova",
+			State: "in-use", PriceKey: "volume:__DEFAULT__", Units: 10, AttachedTo: []string{web},
+			Attributes: map[string]interface{}{"sizeGiB": int64(10), "volumeType": "__DEFAULT__", "attachedTo": web},
+		},
+		{
+			// The external network is not the project's, so only the private one is a VPC
+			Kind: model.KindVPC, ID: networkID, Name: "private", Region: "RegionOne", State: "ACTIVE",
+			Tags:       map[string]string{"env": "prod", "web": ""},
+			Attributes: map[string]interface{}{"cidr": "192.168.0.0/24,fd00::/64"},
+		},
+	}
+	checkResources(t, resources, want)
+}
+
+// mockOpenStack is a Keystone whose catalog lists a compute, network and volume service in
+// RegionOne, but no load balancer service. Servers are listed over two pages.
+type mockOpenStack struct {
+	url string
+}
+
+func (m *mockOpenStack) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost && r.URL.Path == "/identity/v3/auth/tokens" {
+		var body struct {
+			Auth struct {
+				Identity struct {
+					Methods  []string `json:"methods"`
+					Password struct {
+						User struct {
+							Name     string            `json:"name"`
+							Password string            `json:"password"`
+							Domain   map[string]string `json:"domain"`
+						} `json:"user"`
+					} `json:"password"`
+				} `json:"identity"`
+				Scope struct {
+					Project struct {
+						Name   string            `json:"name"`
+						Domain map[string]string `json:"domain"`
+					} `json:"project"`
+				} `json:"scope"`
+			} `json:"auth"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		identity, project := body.Auth.Identity, body.Auth.Scope.Project
+		if len(identity.Methods) != 1 || identity.Methods[0] != "password" || identity.Password.User.Name != "demo" ||
+			identity.Password.User.Password != "secret" || identity.Password.User.Domain["name"] != "Default" ||
+			project.Name != "shop" || project.Domain["name"] != "Default" {
+			http.Error(w, `{"error": {"code": 401, "title": "Unauthorized"}}`, http.StatusUnauthorized)
+			return
+		}
+
+		endpoint := func(iface, region, url string) map[string]string {
+			return map[string]string{"interface": iface, "region_id": region, "url": url}
+		}
+		w.Header().Set("X-Subject-Token", "token")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{"token": map[string]interface{}{"catalog": []map[string]interface{}{
+			{"type": "compute", "endpoints": []map[string]string{
+				endpoint("internal", "RegionOne", "http://compute.internal:8774/v2.1"),
+				endpoint("public", "RegionTwo", "http://compute.region-two:8774/v2.1"),
+				endpoint("public", "RegionOne", m.url+"/compute/v2.1/"),
+			}},
+			{"type": "network", "endpoints": []map[string]string{endpoint("public", "RegionOne", m.url+"/network")}},
+			{"type": "volumev3", "endpoints": []map[string]string{endpoint("public", "RegionOne", m.url+"/volume/v3/project")}},
+		}}})
+		return
+	}
+
+	if r.Header.Get("X-Auth-Token") != "token" {
+		http.Error(w, `{"error": {"code": 401}}`, http.StatusUnauthorized)
+		return
+	}
+	switch r.URL.Path {
+	case "/compute/v2.1/servers/detail":
+		if r.Header.Get("X-OpenStack-Nova-API-Version") != novaMicroversion {
+			http.Error(w, "missing microversion", http.StatusBadRequest)
+			return
+		}
+		if r.URL.Query().Get("marker") == "" {
+			fmt.Fprintf(w, `{"servers": [{"id": "s-1", "name": "web-1", "status": "ACTIVE", "flavor": {"original_name": "m1.small"},
+"addresses": {"private": [{"addr": "192.168.0.10", "version": 4, "OS-EXT-IPS:type": "fixed"}]}}],
+"servers_links": [{"rel": "next", "href": "%s/compute/v2.1/servers/detail?marker=s-1"}]}`, m.url)
+			return
+		}
+		fmt.Fprint(w, `{"servers": [{"id": "s-2", "name": "web-2", "status": "ACTIVE", "flavor": {"original_name": "m1.small"},
+"addresses": {"private": [{"addr": "192.168.0.11", "version": 4, "OS-EXT-IPS:type": "fixed"}]}}]}`)
+	case "/network/v2.0/subnets":
+		fmt.Fprint(w, `{"subnets": [{"network_id": "n-1", "cidr": "192.168.0.0/24"}]}`)
+	case "/network/v2.0/networks":
+		fmt.Fprint(w, `{"networks": [{"id": "n-1", "name": "private", "status": "ACTIVE"}]}`)
+	case "/volume/v3/project/volumes/detail":
+		fmt.Fprint(w, `{"volumes": [{"id": "v-1", "size": 10, "status": "in-use", "attachments": [{"server_id": "s-2"}]}]}`)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestOpenStackProviderMockEndpoint(t *testing.T) {
+	mock := &mockOpenStack{}
+	server := httptest.NewServer(mock)
+	defer server.Close()
+	mock.url = server.URL
+
+	for _, name := range []string{"OS_AUTH_URL", "OS_APPLICATION_CREDENTIAL_ID", "OS_PROJECT_ID", "OS_USER_DOMAIN_NAME", "OS_PROJECT_DOMAIN_NAME", "OS_INTERFACE"} {
+		t.Setenv(name, "")
+	}
+	account := model.NewCloudAccount("lab", "openstack", "admin")
+	account.Regions = []string{"RegionOne"}
+	account.Endpoint = server.URL + "/identity"
+	account.CredentialsFile = writeCredentials(t, map[string]string{
+		"OS_USERNAME":     "demo",
+		"OS_PASSWORD":     "secret",
+		"OS_PROJECT_NAME": "shop",
+	})
+
+	resources, err := NewOpenStackProvider(5*time.Second).Discover(context.Background(), account)
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+
+	// The load balancer service is missing from the catalog, so none are listed
+	var ids []string
+	for _, resource := range resources {
+		ids = append(ids, string(resource.Kind)+":"+resource.ID)
+	}
+	if got, want := strings.Join(ids, " "), "instance:s-1 instance:s-2 volume:v-1 vpc:n-1"; got != want {
+		t.Errorf("discovered %s, want %s", got, want)
+	}
+	if resources[1].VPCID != "n-1" || resources[1].IPAddress != "192.168.0.11" {
+		t.Errorf("server of the second page = %+v", resources[1])
+	}
+
+	account.CredentialsFile = writeCredentials(t, map[string]string{
+		"OS_USERNAME":     "demo",
+		"OS_PASSWORD":     "wrong",
+		"OS_PROJECT_NAME": "shop",
+	})
+	_, err = NewOpenStackProvider(5*time.Second).Discover(context.Background(), account)
+	if err == nil || !strings.HasPrefix(err.Error(), "keystone:") || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Discover() with a wrong password error = %v, want a keystone 401", err)
+	}
+}
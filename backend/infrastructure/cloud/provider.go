@@ -0,0 +1,124 @@
+// Package cloud discovers the resources of cloud provider accounts for the CMDB. Each provider
+// reads a live API, a mock of it given as the account's endpoint, or responses recorded from
+// it in the account's fixture directory.
+package cloud
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+)
+
+// maxErrorBody limits how much of an error response is quoted in errors
+const maxErrorBody = 1024
+
+// credentials looks up the credential variables of an account, e.g. AWS_ACCESS_KEY_ID. They
+// are read from the account's credentials file, a JSON object of variable names to values,
+// and otherwise from the environment.
+type credentials func(name string) string
+
+// loadCredentials loads the credentials of an account
+func loadCredentials(account *model.CloudAccount) (credentials, error) {
+	values := map[string]string{}
+	if account.CredentialsFile != "" {
+		data, err := os.ReadFile(account.CredentialsFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &values); err != nil {
+			return nil, fmt.Errorf("%s: %w", account.CredentialsFile, err)
+		}
+	}
+
+	return func(name string) string {
+		if value, ok := values[name]; ok {
+			return value
+		}
+		return os.Getenv(name)
+	}, nil
+}
+
+// require returns the values of credential variables that must be set
+func (c credentials) require(names ...string) ([]string, error) {
+	values := make([]string, len(names))
+	var missing []string
+	for i, name := range names {
+		values[i] = c(name)
+		if values[i] == "" {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing credentials: %s", strings.Join(missing, ", "))
+	}
+	return values, nil
+}
+
+// readFixture decodes a recorded response, the file <dir>/<region>/<service>/<name>.json.
+// Missing files are treated as empty responses.
+func readFixture(dir, region, service, name string, out interface{}) error {
+	file := filepath.Join(dir, region, service, filepath.FromSlash(strings.TrimPrefix(name, "/"))+".json")
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	return nil
+}
+
+// send sends a request and returns the body of a successful response
+func send(client *http.Client, req *http.Request) ([]byte, http.Header, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return nil, nil, fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return body, resp.Header, nil
+}
+
+// tagMap converts the key/value pairs providers list tags as to a map
+func tagMap[T any](tags []T, pair func(T) (string, string)) map[string]string {
+	if len(tags) == 0 {
+		return nil
+	}
+	result := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		key, value := pair(tag)
+		if key != "" {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+// sortResources orders resources by kind and ID so that syncs are reproducible
+func sortResources(resources []model.CloudResource) {
+	sort.SliceStable(resources, func(i, j int) bool {
+		if resources[i].Kind != resources[j].Kind {
+			return resources[i].Kind < resources[j].Kind
+		}
+		return resources[i].ID < resources[j].ID
+	})
+}
@@ -0,0 +1,93 @@
+package discovery
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// ICMP message types
+const (
+	icmpEchoReply   = 0
+	icmpEchoRequest = 8
+)
+
+// icmpSequence numbers echo requests so replies to earlier probes are not mistaken for new ones
+var icmpSequence uint32
+
+// ping sends an ICMP echo request to an IPv4 address and waits for the reply. It needs raw
+// socket privileges (root or CAP_NET_RAW).
+func ping(ctx context.Context, address string, timeout time.Duration) bool {
+	dialer := net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "ip4:icmp", address)
+	if err != nil {
+		return false
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	conn.SetDeadline(deadline)
+
+	id := uint16(os.Getpid())
+	seq := uint16(atomic.AddUint32(&icmpSequence, 1))
+	if _, err := conn.Write(echoRequest(id, seq)); err != nil {
+		return false
+	}
+
+	ipConn, ok := conn.(*net.IPConn)
+	if !ok {
+		return false
+	}
+
+	buf := make([]byte, 1500)
+	for {
+		// ReadFrom strips the IPv4 header that raw sockets deliver
+		n, _, err := ipConn.ReadFrom(buf)
+		if err != nil {
+			return false
+		}
+		if isEchoReply(buf[:n], id, seq) {
+			return true
+		}
+	}
+}
+
+// echoRequest builds an ICMP echo request message
+func echoRequest(id, seq uint16) []byte {
+	msg := make([]byte, 16)
+	msg[0] = icmpEchoRequest
+	binary.BigEndian.PutUint16(msg[4:], id)
+	binary.BigEndian.PutUint16(msg[6:], seq)
+	copy(msg[8:], "cmdbscan")
+	binary.BigEndian.PutUint16(msg[2:], checksum(msg))
+	return msg
+}
+
+// isEchoReply checks whether a message is the reply to the echo request with id and seq
+func isEchoReply(msg []byte, id, seq uint16) bool {
+	return len(msg) >= 8 &&
+		msg[0] == icmpEchoReply &&
+		binary.BigEndian.Uint16(msg[4:]) == id &&
+		binary.BigEndian.Uint16(msg[6:]) == seq
+}
+
+// checksum computes the Internet checksum of an ICMP message
+func checksum(msg []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(msg); i += 2 {
+		sum += uint32(msg[i])<<8 | uint32(msg[i+1])
+	}
+	if len(msg)%2 == 1 {
+		sum += uint32(msg[len(msg)-1]) << 8
+	}
+	for sum>>16 != 0 {
+		sum = sum&0xffff + sum>>16
+	}
+	return ^uint16(sum)
+}
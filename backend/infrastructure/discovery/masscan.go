@@ -0,0 +1,114 @@
+package discovery
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+)
+
+// masscanRecord is one line of masscan JSON output (-oJ or -oD). Each record has one port;
+// banner records carry a service instead of a status.
+type masscanRecord struct {
+	IP    string `json:"ip"`
+	Ports []struct {
+		Port    int    `json:"port"`
+		Proto   string `json:"proto"`
+		Status  string `json:"status"`
+		Service struct {
+			Name   string `json:"name"`
+			Banner string `json:"banner"`
+		} `json:"service"`
+	} `json:"ports"`
+}
+
+// MasscanParser reads masscan JSON output. masscan reports neither MAC addresses, hostnames
+// nor operating systems, so only addresses and open TCP ports are imported.
+type MasscanParser struct{}
+
+// Detect reports whether the data is masscan JSON output
+func (MasscanParser) Detect(data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	return len(trimmed) > 0 && (trimmed[0] == '[' || trimmed[0] == '{') && bytes.Contains(trimmed, []byte(`"ports"`))
+}
+
+// Parse returns the hosts in masscan JSON output
+func (MasscanParser) Parse(data []byte) ([]model.DiscoveredHost, error) {
+	records, err := readMasscanRecords(data)
+	if err != nil {
+		return nil, err
+	}
+
+	services := make(map[string]map[int]model.DiscoveredService)
+	var order []string
+	for _, record := range records {
+		if record.IP == "" {
+			continue
+		}
+		if services[record.IP] == nil {
+			services[record.IP] = make(map[int]model.DiscoveredService)
+			order = append(order, record.IP)
+		}
+		for _, port := range record.Ports {
+			if port.Proto != "tcp" || (port.Status != "" && port.Status != "open") {
+				continue
+			}
+			svc, exists := services[record.IP][port.Port]
+			if !exists {
+				svc = model.DiscoveredService{Port: port.Port, Name: model.ServiceName(port.Port)}
+			}
+			if port.Service.Banner != "" {
+				svc.Banner = strings.Join(strings.Fields(port.Service.Banner), " ")
+				if svc.Name == "unknown" && strings.HasPrefix(svc.Banner, "SSH-") {
+					svc.Name = "ssh"
+				}
+			}
+			services[record.IP][port.Port] = svc
+		}
+	}
+
+	hosts := make([]model.DiscoveredHost, 0, len(order))
+	for _, ip := range order {
+		host := model.DiscoveredHost{IPAddress: ip, Services: []model.DiscoveredService{}}
+		for _, svc := range services[ip] {
+			host.Services = append(host.Services, svc)
+		}
+		sort.Slice(host.Services, func(i, j int) bool { return host.Services[i].Port < host.Services[j].Port })
+		hosts = append(hosts, host)
+	}
+
+	return hosts, nil
+}
+
+// readMasscanRecords reads a JSON array of records or, failing that, one record per line.
+// Some masscan versions write a trailing comma after the last record of an array, and -oD
+// writes one record per line without an array.
+func readMasscanRecords(data []byte) ([]masscanRecord, error) {
+	var records []masscanRecord
+	if err := json.Unmarshal(data, &records); err == nil {
+		return records, nil
+	}
+
+	records = nil
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(strings.TrimSpace(scanner.Text()), ",")
+		if line == "" || line == "[" || line == "]" {
+			continue
+		}
+		var record masscanRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			// Older versions close the array with an unquoted {finished: 1}
+			if strings.Contains(line, "finished") {
+				continue
+			}
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
@@ -0,0 +1,78 @@
+package discovery
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+)
+
+func TestMasscanParserParse(t *testing.T) {
+	want := []model.DiscoveredHost{
+		{IPAddress: "10.0.0.1", Services: []model.DiscoveredService{
+			{Port: 80, Name: model.ServiceName(80)},
+			{Port: 2222, Name: "ssh", Banner: "SSH-2.0-OpenSSH_9.6"},
+		}},
+		{IPAddress: "10.0.0.2", Services: []model.DiscoveredService{
+			{Port: 443, Name: model.ServiceName(443)},
+		}},
+	}
+
+	tests := []struct {
+		name   string
+		report string
+	}{
+		{
+			name: "array",
+			report: `[
+{"ip": "10.0.0.1", "timestamp": "1700000000", "ports": [{"port": 2222, "proto": "tcp", "status": "open", "reason": "syn-ack", "ttl": 64}]},
+{"ip": "10.0.0.1", "timestamp": "1700000000", "ports": [{"port": 80, "proto": "tcp", "status": "open", "reason": "syn-ack", "ttl": 64}]},
+{"ip": "10.0.0.2", "timestamp": "1700000000", "ports": [{"port": 443, "proto": "tcp", "status": "open", "reason": "syn-ack", "ttl": 64}]},
+{"ip": "10.0.0.1", "timestamp": "1700000001", "ports": [{"port": 2222, "proto": "tcp", "service": {"name": "ssh", "banner": "SSH-2.0-OpenSSH_9.6"}}]},
+{"ip": "10.0.0.2", "timestamp": "1700000001", "ports": [{"port": 161, "proto": "udp", "status": "open"}]}
+]`,
+		},
+		{
+			name: "array with a trailing comma",
+			report: `[
+{"ip": "10.0.0.1", "ports": [{"port": 2222, "proto": "tcp", "status": "open"}]},
+{"ip": "10.0.0.1", "ports": [{"port": 80, "proto": "tcp", "status": "open"}]},
+{"ip": "10.0.0.2", "ports": [{"port": 443, "proto": "tcp", "status": "open"}]},
+{"ip": "10.0.0.1", "ports": [{"port": 2222, "proto": "tcp", "service": {"name": "ssh", "banner": "SSH-2.0-OpenSSH_9.6"}}]},
+]`,
+		},
+		{
+			name: "one record per line",
+			report: `{"ip": "10.0.0.1", "ports": [{"port": 2222, "proto": "tcp", "status": "open"}]}
+{"ip": "10.0.0.1", "ports": [{"port": 80, "proto": "tcp", "status": "open"}]}
+{"ip": "10.0.0.2", "ports": [{"port": 443, "proto": "tcp", "status": "open"}]}
+{"ip": "10.0.0.2", "ports": [{"port": 8443, "proto": "tcp", "status": "closed"}]}
+{"ip": "10.0.0.1", "ports": [{"port": 2222, "proto": "tcp", "service": {"name": "ssh", "banner": "SSH-2.0-OpenSSH_9.6"}}]}
+{finished: 1}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := MasscanParser{}
+			if !parser.Detect([]byte(tt.report)) {
+				t.Fatal("Detect() = false for masscan JSON")
+			}
+
+			hosts, err := parser.Parse([]byte(tt.report))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(hosts, want) {
+				t.Errorf("Parse() =\n%+v\nwant\n%+v", hosts, want)
+			}
+		})
+	}
+}
+
+func TestMasscanParserRejectsInvalidLines(t *testing.T) {
+	if _, err := (MasscanParser{}).Parse([]byte("{\"ip\": \"10.0.0.1\", \"ports\": [\nnot json\n")); err == nil {
+		t.Error("Parse() of invalid lines succeeded")
+	}
+}
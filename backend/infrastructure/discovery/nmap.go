@@ -0,0 +1,124 @@
+package discovery
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+)
+
+// nmapRun is the part of nmap XML output (-oX) that is imported
+type nmapRun struct {
+	XMLName xml.Name   `xml:"nmaprun"`
+	Hosts   []nmapHost `xml:"host"`
+}
+
+type nmapHost struct {
+	Status struct {
+		State string `xml:"state,attr"`
+	} `xml:"status"`
+	Addresses []struct {
+		Addr     string `xml:"addr,attr"`
+		AddrType string `xml:"addrtype,attr"`
+	} `xml:"address"`
+	Hostnames []struct {
+		Name string `xml:"name,attr"`
+		Type string `xml:"type,attr"`
+	} `xml:"hostnames>hostname"`
+	Ports []struct {
+		Protocol string `xml:"protocol,attr"`
+		PortID   int    `xml:"portid,attr"`
+		State    struct {
+			State string `xml:"state,attr"`
+		} `xml:"state"`
+		Service struct {
+			Name      string `xml:"name,attr"`
+			Product   string `xml:"product,attr"`
+			Version   string `xml:"version,attr"`
+			ExtraInfo string `xml:"extrainfo,attr"`
+			Tunnel    string `xml:"tunnel,attr"`
+		} `xml:"service"`
+	} `xml:"ports>port"`
+	OSMatches []struct {
+		Name     string `xml:"name,attr"`
+		Accuracy int    `xml:"accuracy,attr"`
+	} `xml:"os>osmatch"`
+}
+
+// NmapParser reads nmap XML output. Only hosts that are up and open TCP ports are imported.
+type NmapParser struct{}
+
+// Detect reports whether the data is nmap XML output
+func (NmapParser) Detect(data []byte) bool {
+	head := data
+	if len(head) > 1024 {
+		head = head[:1024]
+	}
+	return bytes.Contains(head, []byte("<nmaprun"))
+}
+
+// Parse returns the hosts in nmap XML output
+func (NmapParser) Parse(data []byte) ([]model.DiscoveredHost, error) {
+	var run nmapRun
+	if err := xml.Unmarshal(data, &run); err != nil {
+		return nil, err
+	}
+
+	var hosts []model.DiscoveredHost
+	for _, h := range run.Hosts {
+		if h.Status.State != "up" {
+			continue
+		}
+
+		host := model.DiscoveredHost{Services: []model.DiscoveredService{}}
+		for _, address := range h.Addresses {
+			switch address.AddrType {
+			case "ipv4", "ipv6":
+				if host.IPAddress == "" {
+					host.IPAddress = address.Addr
+				}
+			case "mac":
+				host.MACAddress = strings.ToLower(address.Addr)
+			}
+		}
+		if host.IPAddress == "" {
+			continue
+		}
+
+		// Names given by the user win over reverse DNS
+		for _, name := range h.Hostnames {
+			if host.Hostname == "" || name.Type == "user" {
+				host.Hostname = strings.ToLower(strings.TrimSuffix(name.Name, "."))
+			}
+		}
+
+		for _, port := range h.Ports {
+			if port.Protocol != "tcp" || port.State.State != "open" {
+				continue
+			}
+			name := port.Service.Name
+			if port.Service.Tunnel == "ssl" && name == "http" {
+				name = "https"
+			}
+			if name == "" {
+				name = model.ServiceName(port.PortID)
+			}
+			banner := strings.Join(strings.Fields(port.Service.Product+" "+port.Service.Version+" "+port.Service.ExtraInfo), " ")
+			host.Services = append(host.Services, model.DiscoveredService{Port: port.PortID, Name: name, Banner: banner})
+		}
+
+		// nmap lists OS matches best first; keep the guess with the highest accuracy
+		best := -1
+		for _, match := range h.OSMatches {
+			if match.Accuracy > best {
+				best = match.Accuracy
+				host.OS = match.Name
+			}
+		}
+
+		hosts = append(hosts, host)
+	}
+
+	return hosts, nil
+}
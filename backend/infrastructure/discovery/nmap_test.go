@@ -0,0 +1,79 @@
+package discovery
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+)
+
+const nmapReport = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE nmaprun>
+<nmaprun scanner="nmap" args="nmap -O -sV -oX - 10.0.0.0/30" version="7.94">
+<host>
+  <status state="up" reason="arp-response"/>
+  <address addr="10.0.0.1" addrtype="ipv4"/>
+  <address addr="AA:BB:CC:DD:EE:01" addrtype="mac" vendor="Dell"/>
+  <hostnames>
+    <hostname name="web-01.example.com." type="PTR"/>
+    <hostname name="WEB-01" type="user"/>
+  </hostnames>
+  <ports>
+    <port protocol="tcp" portid="22"><state state="open"/><service name="ssh" product="OpenSSH" version="9.6p1" extrainfo="Ubuntu  Linux"/></port>
+    <port protocol="tcp" portid="443"><state state="open"/><service name="http" tunnel="ssl" product="nginx"/></port>
+    <port protocol="tcp" portid="8080"><state state="filtered"/><service name="http-proxy"/></port>
+    <port protocol="tcp" portid="3306"><state state="open"/></port>
+    <port protocol="udp" portid="161"><state state="open"/><service name="snmp"/></port>
+  </ports>
+  <os>
+    <osmatch name="Linux 4.15 - 5.8" accuracy="96"/>
+    <osmatch name="Linux 5.0 - 5.4" accuracy="98"/>
+  </os>
+</host>
+<host>
+  <status state="down" reason="no-response"/>
+  <address addr="10.0.0.2" addrtype="ipv4"/>
+</host>
+<host>
+  <status state="up" reason="echo-reply"/>
+  <address addr="AA:BB:CC:DD:EE:03" addrtype="mac"/>
+</host>
+</nmaprun>
+`
+
+func TestNmapParserParse(t *testing.T) {
+	parser := NmapParser{}
+	if !parser.Detect([]byte(nmapReport)) {
+		t.Fatal("Detect() = false for nmap XML")
+	}
+	if parser.Detect([]byte(`[{"ip": "10.0.0.1", "ports": []}]`)) {
+		t.Error("Detect() = true for masscan JSON")
+	}
+
+	hosts, err := parser.Parse([]byte(nmapReport))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	// Hosts that are down or have no IP address are skipped
+	want := []model.DiscoveredHost{{
+		IPAddress:  "10.0.0.1",
+		MACAddress: "aa:bb:cc:dd:ee:01",
+		Hostname:   "web-01",
+		OS:         "Linux 5.0 - 5.4",
+		Services: []model.DiscoveredService{
+			{Port: 22, Name: "ssh", Banner: "OpenSSH 9.6p1 Ubuntu Linux"},
+			{Port: 443, Name: "https", Banner: "nginx"},
+			{Port: 3306, Name: model.ServiceName(3306)},
+		},
+	}}
+	if !reflect.DeepEqual(hosts, want) {
+		t.Errorf("Parse() =\n%+v\nwant\n%+v", hosts, want)
+	}
+}
+
+func TestNmapParserRejectsInvalidXML(t *testing.T) {
+	if _, err := (NmapParser{}).Parse([]byte(`<nmaprun><host>`)); err == nil {
+		t.Error("Parse() of truncated XML succeeded")
+	}
+}
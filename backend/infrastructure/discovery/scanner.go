@@ -0,0 +1,277 @@
+package discovery
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unicode"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/service"
+)
+
+// Banner grabbing limits
+const (
+	maxBannerLength = 128
+	bannerTimeout   = 500 * time.Millisecond
+)
+
+// silentServices wait for the client to speak first, so no banner is read from them
+var silentServices = map[string]bool{
+	"http": true, "https": true, "http-alt": true, "https-alt": true, "msrpc": true, "smb": true,
+	"rdp": true, "postgresql": true, "mongodb": true, "redis": true, "elasticsearch": true,
+	"kubernetes": true, "jetdirect": true, "iscsi": true, "nfs": true, "winrm": true,
+}
+
+// Scanner probes hosts with TCP connects and, when privileged, ICMP echo requests
+type Scanner struct {
+	// Resolver resolves hostnames by reverse DNS; nil uses the default resolver
+	Resolver *net.Resolver
+	// ARPTable is read for the MAC addresses of hosts on local networks
+	ARPTable string
+
+	icmpOnce      sync.Once
+	icmpAvailable bool
+}
+
+// NewScanner creates a new network scanner
+func NewScanner() *Scanner {
+	return &Scanner{
+		Resolver: net.DefaultResolver,
+		ARPTable: "/proc/net/arp",
+	}
+}
+
+// ICMPAvailable reports whether the process may send ICMP echo requests. Without raw socket
+// privileges ranges with ICMP enabled are probed with TCP only.
+func (s *Scanner) ICMPAvailable() bool {
+	s.icmpOnce.Do(func() {
+		conn, err := net.ListenPacket("ip4:icmp", "0.0.0.0")
+		if err == nil {
+			conn.Close()
+			s.icmpAvailable = true
+		}
+	})
+	return s.icmpAvailable
+}
+
+// Scan probes every address and reports the hosts that answer. A host is up when it replies
+// to an echo request or accepts or actively refuses a TCP connection.
+func (s *Scanner) Scan(ctx context.Context, request service.ScanRequest, found func(host model.DiscoveredHost)) error {
+	limiter := newRateLimiter(request.RateLimit)
+	defer limiter.stop()
+
+	useICMP := request.ICMP && s.ICMPAvailable()
+	timeout := request.Timeout
+	if timeout <= 0 {
+		timeout = time.Second
+	}
+	workers := request.Concurrency
+	if workers <= 0 {
+		workers = 1
+	}
+	if workers > len(request.Addresses) {
+		workers = len(request.Addresses)
+	}
+
+	addresses := make(chan string)
+	results := make(chan model.DiscoveredHost)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for address := range addresses {
+				host, ok := s.probeHost(ctx, address, request.Ports, useICMP, timeout, limiter)
+				if !ok {
+					continue
+				}
+				select {
+				case results <- host:
+				case <-ctx.Done():
+				}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(addresses)
+		for _, address := range request.Addresses {
+			select {
+			case addresses <- address:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Results are reported from this goroutine only
+	for host := range results {
+		found(host)
+	}
+
+	return ctx.Err()
+}
+
+// probeHost probes one address and fingerprints its open ports
+func (s *Scanner) probeHost(ctx context.Context, address string, ports []int, useICMP bool, timeout time.Duration, limiter *rateLimiter) (model.DiscoveredHost, bool) {
+	host := model.DiscoveredHost{IPAddress: address, Services: []model.DiscoveredService{}}
+	alive := false
+
+	ip := net.ParseIP(address)
+	if useICMP && ip.To4() != nil {
+		if limiter.wait(ctx) != nil {
+			return host, false
+		}
+		alive = ping(ctx, address, timeout)
+	}
+
+	for _, port := range ports {
+		if limiter.wait(ctx) != nil {
+			return host, false
+		}
+		svc, open, up := probePort(ctx, address, port, timeout)
+		alive = alive || up
+		if open {
+			host.Services = append(host.Services, svc)
+		}
+	}
+
+	if !alive {
+		return host, false
+	}
+
+	host.Hostname = s.lookupHostname(ctx, address, timeout)
+	host.MACAddress = s.lookupMAC(address)
+	return host, true
+}
+
+// probePort connects to a port. A refused connection still proves the host is up.
+func probePort(ctx context.Context, address string, port int, timeout time.Duration) (model.DiscoveredService, bool, bool) {
+	svc := model.DiscoveredService{Port: port, Name: model.ServiceName(port)}
+
+	dialer := net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(address, strconv.Itoa(port)))
+	if err != nil {
+		return svc, false, errors.Is(err, syscall.ECONNREFUSED)
+	}
+	defer conn.Close()
+
+	if !silentServices[svc.Name] {
+		svc.Banner = readBanner(conn)
+		if svc.Name == "unknown" && strings.HasPrefix(svc.Banner, "SSH-") {
+			svc.Name = "ssh"
+		}
+	}
+	return svc, true, true
+}
+
+// readBanner reads the first line a service sends on connect
+func readBanner(conn net.Conn) string {
+	conn.SetReadDeadline(time.Now().Add(bannerTimeout))
+
+	buf := make([]byte, maxBannerLength)
+	n, _ := conn.Read(buf)
+	line, _, _ := strings.Cut(string(buf[:n]), "\n")
+	line = strings.Map(func(r rune) rune {
+		if unicode.IsPrint(r) {
+			return r
+		}
+		return -1
+	}, line)
+	return strings.TrimSpace(line)
+}
+
+// lookupHostname resolves the hostname of an address by reverse DNS
+func (s *Scanner) lookupHostname(ctx context.Context, address string, timeout time.Duration) string {
+	resolver := s.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	lookupCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	names, err := resolver.LookupAddr(lookupCtx, address)
+	if err != nil || len(names) == 0 {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSuffix(names[0], "."))
+}
+
+// lookupMAC finds the MAC address of an address in the kernel ARP table. Only hosts on
+// directly attached networks have one.
+func (s *Scanner) lookupMAC(address string) string {
+	if s.ARPTable == "" {
+		return ""
+	}
+	entries, err := readARPTable(s.ARPTable)
+	if err != nil {
+		return ""
+	}
+	return entries[address]
+}
+
+// readARPTable parses a Linux /proc/net/arp file into IP to MAC address pairs
+func readARPTable(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	entries := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		// IP address, HW type, Flags, HW address, Mask, Device
+		if len(fields) < 4 || fields[2] == "0x0" || fields[3] == "00:00:00:00:00:00" {
+			continue
+		}
+		entries[fields[0]] = strings.ToLower(fields[3])
+	}
+	return entries, scanner.Err()
+}
+
+// rateLimiter spaces probes evenly to stay under a number of probes per second
+type rateLimiter struct {
+	ticker *time.Ticker
+}
+
+// newRateLimiter creates a rate limiter; a non-positive rate means one probe per millisecond
+func newRateLimiter(perSecond int) *rateLimiter {
+	interval := time.Millisecond
+	if perSecond > 0 {
+		interval = time.Second / time.Duration(perSecond)
+	}
+	return &rateLimiter{ticker: time.NewTicker(interval)}
+}
+
+// wait blocks until the next probe may be sent or the context is cancelled
+func (l *rateLimiter) wait(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-l.ticker.C:
+		return nil
+	}
+}
+
+// stop releases the limiter
+func (l *rateLimiter) stop() {
+	l.ticker.Stop()
+}
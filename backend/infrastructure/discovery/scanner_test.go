@@ -0,0 +1,179 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/service"
+)
+
+// listen starts a loopback TCP listener that writes a banner to every connection
+func listen(t *testing.T, banner string) int {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Write([]byte(banner))
+			conn.Close()
+		}
+	}()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+// closedPort returns a loopback port nothing listens on
+func closedPort(t *testing.T) int {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+	return port
+}
+
+// newTestScanner creates a scanner that neither resolves names nor reads the ARP table
+func newTestScanner() *Scanner {
+	return &Scanner{Resolver: &net.Resolver{
+		PreferGo: true,
+		Dial: func(context.Context, string, string) (net.Conn, error) {
+			return nil, errors.New("no DNS in tests")
+		},
+	}}
+}
+
+// scan runs a scan and collects the hosts found by address
+func scan(ctx context.Context, scanner *Scanner, request service.ScanRequest) (map[string]model.DiscoveredHost, error) {
+	hosts := make(map[string]model.DiscoveredHost)
+	err := scanner.Scan(ctx, request, func(host model.DiscoveredHost) {
+		hosts[host.IPAddress] = host
+	})
+	return hosts, err
+}
+
+func TestScanLoopback(t *testing.T) {
+	sshPort := listen(t, "SSH-2.0-OpenSSH_9.6\r\nignored")
+	closed := closedPort(t)
+
+	hosts, err := scan(context.Background(), newTestScanner(), service.ScanRequest{
+		// 127.0.0.2 is loopback too but nothing listens there, so it only refuses connections
+		Addresses:   []string{"127.0.0.1", "127.0.0.2"},
+		Ports:       []int{sshPort, closed},
+		Concurrency: 2,
+		RateLimit:   1000,
+		Timeout:     time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	want := []model.DiscoveredService{{Port: sshPort, Name: "ssh", Banner: "SSH-2.0-OpenSSH_9.6"}}
+	if got := hosts["127.0.0.1"].Services; !reflect.DeepEqual(got, want) {
+		t.Errorf("services of 127.0.0.1 = %+v, want %+v", got, want)
+	}
+
+	refusing, ok := hosts["127.0.0.2"]
+	if !ok {
+		t.Fatal("127.0.0.2 refused connections but was not reported up")
+	}
+	if len(refusing.Services) != 0 {
+		t.Errorf("services of 127.0.0.2 = %+v, want none", refusing.Services)
+	}
+}
+
+func TestScanRateLimit(t *testing.T) {
+	closed := closedPort(t)
+
+	start := time.Now()
+	_, err := scan(context.Background(), newTestScanner(), service.ScanRequest{
+		Addresses:   []string{"127.0.0.1"},
+		Ports:       []int{closed, closed, closed, closed, closed},
+		Concurrency: 4,
+		RateLimit:   20,
+		Timeout:     time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	// Five probes at 20 per second cannot finish before the fifth tick
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("five probes took %s at 20 probes per second", elapsed)
+	}
+}
+
+func TestScanCancel(t *testing.T) {
+	closed := closedPort(t)
+
+	addresses := make([]string, 256)
+	for i := range addresses {
+		addresses[i] = fmt.Sprintf("127.0.1.%d", i)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	found := 0
+	start := time.Now()
+	err := newTestScanner().Scan(ctx, service.ScanRequest{
+		Addresses:   addresses,
+		Ports:       []int{closed},
+		Concurrency: 1,
+		RateLimit:   10,
+		Timeout:     time.Second,
+	}, func(model.DiscoveredHost) {
+		found++
+		cancel()
+	})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Scan() error = %v, want context.Canceled", err)
+	}
+	if found != 1 {
+		t.Errorf("found %d hosts after cancelling at the first", found)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("cancelled scan took %s", elapsed)
+	}
+}
+
+func TestReadARPTable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "arp")
+	table := `IP address       HW type     Flags       HW address            Mask     Device
+10.0.0.1         0x1         0x2         AA:BB:CC:DD:EE:01     *        eth0
+10.0.0.2         0x1         0x0         aa:bb:cc:dd:ee:02     *        eth0
+10.0.0.3         0x1         0x2         00:00:00:00:00:00     *        eth0
+`
+	if err := os.WriteFile(path, []byte(table), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := readARPTable(path)
+	if err != nil {
+		t.Fatalf("readARPTable() error = %v", err)
+	}
+	want := map[string]string{"10.0.0.1": "aa:bb:cc:dd:ee:01"}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("readARPTable() = %v, want %v", entries, want)
+	}
+
+	scanner := &Scanner{ARPTable: path}
+	if mac := scanner.lookupMAC("10.0.0.2"); mac != "" {
+		t.Errorf("lookupMAC() of an incomplete entry = %q, want none", mac)
+	}
+}
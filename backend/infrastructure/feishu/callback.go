@@ -0,0 +1,216 @@
+package feishu
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/service"
+)
+
+// Callback request headers set by Feishu
+const (
+	HeaderTimestamp = "X-Lark-Request-Timestamp"
+	HeaderNonce     = "X-Lark-Request-Nonce"
+	HeaderSignature = "X-Lark-Signature"
+)
+
+// Approval instance statuses reported in callbacks
+const (
+	StatusPending  = "PENDING"
+	StatusApproved = "APPROVED"
+	StatusRejected = "REJECTED"
+	StatusCanceled = "CANCELED"
+	StatusDeleted  = "DELETED"
+)
+
+// callbackMaxAge bounds how old a signed callback may be before it is treated as a replay
+const callbackMaxAge = 5 * time.Minute
+
+// callbackEnvelope covers both the v1 and v2 event schemas as well as URL verification
+type callbackEnvelope struct {
+	Encrypt   string `json:"encrypt"`
+	Challenge string `json:"challenge"`
+	Token     string `json:"token"`
+	Type      string `json:"type"`
+	Header    struct {
+		Token     string `json:"token"`
+		EventType string `json:"event_type"`
+	} `json:"header"`
+	Event struct {
+		Type         string `json:"type"`
+		InstanceCode string `json:"instance_code"`
+		Status       string `json:"status"`
+		OpenID       string `json:"open_id"`
+		UserID       string `json:"user_id"`
+	} `json:"event"`
+}
+
+// ParseCallback verifies the signature of a callback request, decrypts it and extracts the event.
+// With an encrypt key every callback must be encrypted and every event signed; Feishu sends only
+// the URL verification unsigned, which is still encrypted with the key. Without an encrypt key the
+// verification token must match.
+func (c *Client) ParseCallback(req *service.ApprovalCallbackRequest) (*service.ApprovalCallback, error) {
+	if !c.config.CallbacksVerifiable() {
+		return nil, fmt.Errorf("%w: no encrypt key or verification token configured", service.ErrInvalidApprovalCallback)
+	}
+
+	timestamp, nonce, signature := req.Header.Get(HeaderTimestamp), req.Header.Get(HeaderNonce), req.Header.Get(HeaderSignature)
+	body := req.Body
+
+	var envelope callbackEnvelope
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, fmt.Errorf("%w: %v", service.ErrInvalidApprovalCallback, err)
+	}
+
+	if envelope.Encrypt != "" {
+		if c.config.EncryptKey == "" {
+			return nil, fmt.Errorf("%w: encrypted callback but no encrypt key configured", service.ErrInvalidApprovalCallback)
+		}
+
+		plain, err := Decrypt(envelope.Encrypt, c.config.EncryptKey)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", service.ErrInvalidApprovalCallback, err)
+		}
+
+		envelope = callbackEnvelope{}
+		if err := json.Unmarshal(plain, &envelope); err != nil {
+			return nil, fmt.Errorf("%w: %v", service.ErrInvalidApprovalCallback, err)
+		}
+	} else if c.config.EncryptKey != "" {
+		// With an encrypt key configured Feishu always encrypts, so plaintext bodies are forged
+		return nil, fmt.Errorf("%w: callback is not encrypted", service.ErrInvalidApprovalCallback)
+	}
+
+	if c.config.EncryptKey != "" && (signature != "" || envelope.Type != "url_verification") {
+		if signature == "" {
+			return nil, fmt.Errorf("%w: missing signature", service.ErrInvalidApprovalCallback)
+		}
+		if err := c.verifySignature(timestamp, nonce, signature, body); err != nil {
+			return nil, err
+		}
+	}
+
+	token := envelope.Token
+	if token == "" {
+		token = envelope.Header.Token
+	}
+	if c.config.VerificationToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(c.config.VerificationToken)) != 1 {
+		return nil, fmt.Errorf("%w: verification token mismatch", service.ErrInvalidApprovalCallback)
+	}
+
+	if envelope.Type == "url_verification" {
+		reply, err := json.Marshal(map[string]string{"challenge": envelope.Challenge})
+		if err != nil {
+			return nil, err
+		}
+		return &service.ApprovalCallback{Reply: reply, ReplyContentType: "application/json"}, nil
+	}
+
+	callback := &service.ApprovalCallback{
+		ExternalID: envelope.Event.InstanceCode,
+		OperatorID: envelope.Event.OpenID,
+	}
+	if callback.OperatorID == "" {
+		callback.OperatorID = envelope.Event.UserID
+	}
+
+	switch envelope.Event.Status {
+	case StatusApproved:
+		callback.Decision = service.ApprovalDecisionApproved
+	case StatusRejected:
+		callback.Decision = service.ApprovalDecisionRejected
+	case StatusCanceled, StatusDeleted:
+		callback.Decision = service.ApprovalDecisionRejected
+		callback.Comments = "Approval instance " + strings.ToLower(envelope.Event.Status) + " in Feishu"
+	}
+
+	return callback, nil
+}
+
+// verifySignature checks the request signature and rejects stale timestamps
+func (c *Client) verifySignature(timestamp, nonce, signature string, body []byte) error {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid timestamp", service.ErrInvalidApprovalCallback)
+	}
+	if age := time.Since(time.Unix(seconds, 0)); age > callbackMaxAge || age < -callbackMaxAge {
+		return fmt.Errorf("%w: timestamp outside allowed window", service.ErrInvalidApprovalCallback)
+	}
+
+	expected := Sign(timestamp, nonce, c.config.EncryptKey, body)
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(signature)) != 1 {
+		return fmt.Errorf("%w: signature mismatch", service.ErrInvalidApprovalCallback)
+	}
+
+	return nil
+}
+
+// Sign computes the callback signature Feishu sends in the X-Lark-Signature header
+func Sign(timestamp, nonce, encryptKey string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(timestamp + nonce + encryptKey))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Decrypt decrypts an encrypted callback body. The key is the SHA-256 of the encrypt key
+// and the first block of the ciphertext is the IV.
+func Decrypt(encrypted, encryptKey string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 2*aes.BlockSize || len(data)%aes.BlockSize != 0 {
+		return nil, errors.New("invalid ciphertext length")
+	}
+
+	key := sha256.Sum256([]byte(encryptKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+
+	iv, ciphertext := data[:aes.BlockSize], data[aes.BlockSize:]
+	plain := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, ciphertext)
+
+	padding := int(plain[len(plain)-1])
+	if padding == 0 || padding > aes.BlockSize || !bytes.Equal(plain[len(plain)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return nil, errors.New("invalid padding")
+	}
+
+	return plain[:len(plain)-padding], nil
+}
+
+// Encrypt encrypts a callback body the way Feishu does; used by the stub server
+func Encrypt(plain []byte, encryptKey string, iv []byte) (string, error) {
+	if len(iv) != aes.BlockSize {
+		return "", errors.New("iv must be one block long")
+	}
+
+	key := sha256.Sum256([]byte(encryptKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return "", err
+	}
+
+	padding := aes.BlockSize - len(plain)%aes.BlockSize
+	padded := append(append([]byte{}, plain...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+
+	out := make([]byte, aes.BlockSize+len(padded))
+	copy(out, iv)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(out[aes.BlockSize:], padded)
+
+	return base64.StdEncoding.EncodeToString(out), nil
+}
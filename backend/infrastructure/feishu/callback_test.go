@@ -0,0 +1,231 @@
+package feishu
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func testConfig() Config {
+	return Config{
+		AppID:             "cli_test",
+		AppSecret:         "secret",
+		ApprovalCode:      "APPROVAL-CMDB",
+		InitiatorOpenID:   "ou_initiator",
+		EncryptKey:        "encrypt-key",
+		VerificationToken: "verification-token",
+	}
+}
+
+// TestApprovalRoundTrip submits a workflow to the stub server, decides it there and checks that
+// the signed, encrypted callback resolves to the workflow with the decision
+func TestApprovalRoundTrip(t *testing.T) {
+	config := testConfig()
+
+	// The callback endpoint resolves instance codes the way FindByFeishuID does
+	var workflows sync.Map
+	callbacks := make(chan *service.ApprovalCallback, 1)
+	var client *Client
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		callback, err := client.ParseCallback(&service.ApprovalCallbackRequest{Method: r.Method, Header: r.Header, Body: body})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if _, ok := workflows.Load(callback.ExternalID); !ok {
+			http.Error(w, "unknown instance", http.StatusNotFound)
+			return
+		}
+		callbacks <- callback
+	}))
+	defer webhook.Close()
+
+	stub := httptest.NewServer(NewStubServer(config, webhook.URL))
+	defer stub.Close()
+	stubServer := stub.Config.Handler.(*StubServer)
+
+	config.BaseURL = stub.URL
+	client = NewClient(config)
+
+	tests := []struct {
+		status   string
+		decision string
+	}{
+		{StatusApproved, service.ApprovalDecisionApproved},
+		{StatusRejected, service.ApprovalDecisionRejected},
+		{StatusCanceled, service.ApprovalDecisionRejected},
+	}
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			workflow := model.NewWorkflow(model.AssetUpdateType, "AST-0001", "web-01", "alice", "u1", model.HighPriority, "Resize", nil)
+			workflow.ID = primitive.NewObjectID()
+
+			instanceCode, err := client.Submit(context.Background(), workflow)
+			if err != nil {
+				t.Fatalf("Submit() error = %v", err)
+			}
+			workflows.Store(instanceCode, workflow)
+
+			// Retries of the same workflow stage reuse the instance
+			again, err := client.Submit(context.Background(), workflow)
+			if err != nil || again != instanceCode {
+				t.Fatalf("resubmitted instance = %q, %v; want %q", again, err, instanceCode)
+			}
+
+			instance, ok := stubServer.Instance(instanceCode)
+			if !ok {
+				t.Fatalf("instance %s not recorded", instanceCode)
+			}
+			if instance.OpenID != config.InitiatorOpenID || len(instance.Form) == 0 {
+				t.Fatalf("instance = %+v", instance)
+			}
+
+			if err := stubServer.Decide(context.Background(), instanceCode, tt.status); err != nil {
+				t.Fatalf("Decide() error = %v", err)
+			}
+			callback := <-callbacks
+			if callback.ExternalID != instanceCode || callback.Decision != tt.decision || callback.OperatorID != config.InitiatorOpenID {
+				t.Errorf("callback = %+v, want decision %s for %s", callback, tt.decision, instanceCode)
+			}
+		})
+	}
+}
+
+// TestParseCallbackRejectsForgeries checks that callbacks without valid credentials are refused
+func TestParseCallbackRejectsForgeries(t *testing.T) {
+	event := []byte(`{"type":"event_callback","token":"verification-token","event":{"instance_code":"STUB-1","status":"APPROVED"}}`)
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	stale := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+
+	encrypted := func(t *testing.T, plain []byte, key string) []byte {
+		ciphertext, err := Encrypt(plain, key, make([]byte, 16))
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := json.Marshal(map[string]string{"encrypt": ciphertext})
+		return body
+	}
+	signed := func(body []byte, timestamp, key string) http.Header {
+		header := http.Header{}
+		header.Set(HeaderTimestamp, timestamp)
+		header.Set(HeaderNonce, "nonce")
+		header.Set(HeaderSignature, Sign(timestamp, "nonce", key, body))
+		return header
+	}
+
+	tests := []struct {
+		name    string
+		config  func(Config) Config
+		body    func(t *testing.T) []byte
+		header  func(body []byte) http.Header
+		wantErr bool
+	}{
+		{
+			name:   "signed and encrypted",
+			config: func(c Config) Config { return c },
+			body:   func(t *testing.T) []byte { return encrypted(t, event, "encrypt-key") },
+			header: func(body []byte) http.Header { return signed(body, now, "encrypt-key") },
+		},
+		{
+			name:    "unsigned event with encrypt key",
+			config:  func(c Config) Config { return c },
+			body:    func(t *testing.T) []byte { return encrypted(t, event, "encrypt-key") },
+			header:  func([]byte) http.Header { return http.Header{} },
+			wantErr: true,
+		},
+		{
+			name:    "signed with another key",
+			config:  func(c Config) Config { return c },
+			body:    func(t *testing.T) []byte { return encrypted(t, event, "encrypt-key") },
+			header:  func(body []byte) http.Header { return signed(body, now, "other-key") },
+			wantErr: true,
+		},
+		{
+			name:    "stale timestamp",
+			config:  func(c Config) Config { return c },
+			body:    func(t *testing.T) []byte { return encrypted(t, event, "encrypt-key") },
+			header:  func(body []byte) http.Header { return signed(body, stale, "encrypt-key") },
+			wantErr: true,
+		},
+		{
+			name:    "plaintext with encrypt key",
+			config:  func(c Config) Config { return c },
+			body:    func(*testing.T) []byte { return event },
+			header:  func(body []byte) http.Header { return signed(body, now, "encrypt-key") },
+			wantErr: true,
+		},
+		{
+			name:   "token only",
+			config: func(c Config) Config { c.EncryptKey = ""; return c },
+			body:   func(*testing.T) []byte { return event },
+			header: func([]byte) http.Header { return http.Header{} },
+		},
+		{
+			name: "wrong token",
+			config: func(c Config) Config {
+				c.EncryptKey, c.VerificationToken = "", "another-token"
+				return c
+			},
+			body:    func(*testing.T) []byte { return event },
+			header:  func([]byte) http.Header { return http.Header{} },
+			wantErr: true,
+		},
+		{
+			name: "no callback credentials",
+			config: func(c Config) Config {
+				c.EncryptKey, c.VerificationToken = "", ""
+				return c
+			},
+			body:    func(*testing.T) []byte { return event },
+			header:  func([]byte) http.Header { return http.Header{} },
+			wantErr: true,
+		},
+		{
+			name:   "unsigned URL verification encrypted with the key",
+			config: func(c Config) Config { return c },
+			body: func(t *testing.T) []byte {
+				return encrypted(t, []byte(`{"type":"url_verification","challenge":"abc","token":"verification-token"}`), "encrypt-key")
+			},
+			header: func([]byte) http.Header { return http.Header{} },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient(tt.config(testConfig()))
+			body := tt.body(t)
+			_, err := client.ParseCallback(&service.ApprovalCallbackRequest{Method: http.MethodPost, Header: tt.header(body), Body: body})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCallback() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, service.ErrInvalidApprovalCallback) {
+				t.Errorf("ParseCallback() error = %v, want ErrInvalidApprovalCallback", err)
+			}
+		})
+	}
+}
+
+func TestConfigEnabledRequiresCallbackCredentials(t *testing.T) {
+	config := testConfig()
+	config.EncryptKey, config.VerificationToken = "", ""
+	if config.Enabled() {
+		t.Error("Enabled() = true without an encrypt key or verification token")
+	}
+
+	config.VerificationToken = "verification-token"
+	if !config.Enabled() {
+		t.Error("Enabled() = false with a verification token")
+	}
+}
@@ -0,0 +1,319 @@
+package feishu
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/service"
+	"github.com/phuhao00/cmdb/backend/infrastructure/approval"
+)
+
+// DefaultBaseURL is the Feishu open platform endpoint
+const DefaultBaseURL = "https://open.feishu.cn"
+
+// ChannelName is the approval channel name for Feishu
+const ChannelName = "feishu"
+
+// tokenRefreshMargin is how long before expiry a cached tenant token is refreshed
+const tokenRefreshMargin = 5 * time.Minute
+
+// Config holds the Feishu application credentials and approval settings
+type Config struct {
+	BaseURL           string
+	AppID             string
+	AppSecret         string
+	ApprovalCode      string
+	InitiatorOpenID   string
+	EncryptKey        string
+	VerificationToken string
+	HTTPClient        *http.Client
+}
+
+// ConfigFromEnv reads the Feishu configuration from environment variables
+func ConfigFromEnv() Config {
+	baseURL := os.Getenv("FEISHU_BASE_URL")
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	return Config{
+		BaseURL:           baseURL,
+		AppID:             os.Getenv("FEISHU_APP_ID"),
+		AppSecret:         os.Getenv("FEISHU_APP_SECRET"),
+		ApprovalCode:      os.Getenv("FEISHU_APPROVAL_CODE"),
+		InitiatorOpenID:   os.Getenv("FEISHU_INITIATOR_OPEN_ID"),
+		EncryptKey:        os.Getenv("FEISHU_ENCRYPT_KEY"),
+		VerificationToken: os.Getenv("FEISHU_VERIFICATION_TOKEN"),
+	}
+}
+
+// Enabled reports whether enough configuration is present to create approval instances and to
+// verify their callbacks. Without an encrypt key or verification token anyone could post a
+// decision to the public webhook, so the channel stays off.
+func (c Config) Enabled() bool {
+	return c.AppID != "" && c.AppSecret != "" && c.ApprovalCode != "" && c.CallbacksVerifiable()
+}
+
+// CallbacksVerifiable reports whether callbacks can be told apart from forgeries
+func (c Config) CallbacksVerifiable() bool {
+	return c.EncryptKey != "" || c.VerificationToken != ""
+}
+
+// Client is a Feishu approval API client
+type Client struct {
+	config     Config
+	httpClient *http.Client
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+// NewClient creates a new Feishu client
+func NewClient(config Config) *Client {
+	if config.BaseURL == "" {
+		config.BaseURL = DefaultBaseURL
+	}
+	config.BaseURL = strings.TrimRight(config.BaseURL, "/")
+
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &Client{
+		config:     config,
+		httpClient: httpClient,
+	}
+}
+
+var (
+	_ service.ApprovalChannel      = (*Client)(nil)
+	_ service.ApprovalReminder     = (*Client)(nil)
+	_ service.ApprovalUserNotifier = (*Client)(nil)
+)
+
+// apiResponse is the envelope shared by all Feishu open API responses
+type apiResponse struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+type tokenResponse struct {
+	apiResponse
+	TenantAccessToken string `json:"tenant_access_token"`
+	Expire            int    `json:"expire"`
+}
+
+// FormWidget is a single approval form field value; widget IDs are the approval.Field* keys
+type FormWidget struct {
+	ID    string `json:"id"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type createInstanceRequest struct {
+	ApprovalCode string `json:"approval_code"`
+	OpenID       string `json:"open_id,omitempty"`
+	Form         string `json:"form"`
+	UUID         string `json:"uuid,omitempty"`
+}
+
+type createInstanceResponse struct {
+	apiResponse
+	Data struct {
+		InstanceCode string `json:"instance_code"`
+	} `json:"data"`
+}
+
+// textContent is the content of text comments and messages, sent JSON-encoded as a string
+type textContent struct {
+	Text string `json:"text"`
+}
+
+type createCommentRequest struct {
+	Content string `json:"content"`
+}
+
+type sendMessageRequest struct {
+	ReceiveID string `json:"receive_id"`
+	MsgType   string `json:"msg_type"`
+	Content   string `json:"content"`
+}
+
+// Name returns the channel name
+func (c *Client) Name() string {
+	return ChannelName
+}
+
+// Submit creates an approval instance for the workflow and returns its instance code
+func (c *Client) Submit(ctx context.Context, workflow *model.Workflow) (string, error) {
+	token, err := c.tenantAccessToken(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	form, err := json.Marshal(BuildForm(workflow))
+	if err != nil {
+		return "", err
+	}
+
+	request := createInstanceRequest{
+		ApprovalCode: c.config.ApprovalCode,
+		OpenID:       c.config.InitiatorOpenID,
+		Form:         string(form),
+	}
+	// The workflow ID makes retries idempotent on the Feishu side; later approval
+	// stages get their own instance
+	if !workflow.ID.IsZero() {
+		request.UUID = workflow.ID.Hex()
+		if workflow.CurrentStage > 0 {
+			request.UUID = fmt.Sprintf("%s-%d", request.UUID, workflow.CurrentStage)
+		}
+	}
+
+	var response createInstanceResponse
+	if err := c.post(ctx, "/open-apis/approval/v4/instances", token, request, &response); err != nil {
+		return "", err
+	}
+	if response.Code != 0 {
+		return "", fmt.Errorf("feishu create instance failed: %d %s", response.Code, response.Msg)
+	}
+	if response.Data.InstanceCode == "" {
+		return "", errors.New("feishu create instance returned no instance code")
+	}
+
+	return response.Data.InstanceCode, nil
+}
+
+// Remind comments on the approval instance as the initiator, which notifies its approvers
+func (c *Client) Remind(ctx context.Context, workflow *model.Workflow, message string) error {
+	token, err := c.tenantAccessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	content, err := json.Marshal(textContent{Text: message})
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{"user_id_type": {"open_id"}, "user_id": {c.config.InitiatorOpenID}}
+	path := "/open-apis/approval/v4/instances/" + url.PathEscape(workflow.ExternalID) + "/comments?" + query.Encode()
+	var response apiResponse
+	if err := c.post(ctx, path, token, createCommentRequest{Content: string(content)}, &response); err != nil {
+		return err
+	}
+	if response.Code != 0 {
+		return fmt.Errorf("feishu create comment failed: %d %s", response.Code, response.Msg)
+	}
+
+	return nil
+}
+
+// NotifyUsers sends the message to each open ID as a direct message from the app
+func (c *Client) NotifyUsers(ctx context.Context, openIDs []string, message string) error {
+	token, err := c.tenantAccessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	content, err := json.Marshal(textContent{Text: message})
+	if err != nil {
+		return err
+	}
+
+	for _, openID := range openIDs {
+		request := sendMessageRequest{ReceiveID: openID, MsgType: "text", Content: string(content)}
+		var response apiResponse
+		if err := c.post(ctx, "/open-apis/im/v1/messages?receive_id_type=open_id", token, request, &response); err != nil {
+			return err
+		}
+		if response.Code != 0 {
+			return fmt.Errorf("feishu send message to %s failed: %d %s", openID, response.Code, response.Msg)
+		}
+	}
+
+	return nil
+}
+
+// BuildForm maps a workflow to the approval form widgets
+func BuildForm(workflow *model.Workflow) []FormWidget {
+	fields := approval.FormFields(workflow)
+	widgets := make([]FormWidget, len(fields))
+	for i, field := range fields {
+		widgetType := "input"
+		if field.Key == approval.FieldReason {
+			widgetType = "textarea"
+		}
+		widgets[i] = FormWidget{ID: field.Key, Type: widgetType, Value: field.Value}
+	}
+	return widgets
+}
+
+// tenantAccessToken returns a cached tenant access token, fetching a new one when it is close to expiry
+func (c *Client) tenantAccessToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && time.Now().Before(c.tokenExpiry) {
+		return c.token, nil
+	}
+
+	request := map[string]string{
+		"app_id":     c.config.AppID,
+		"app_secret": c.config.AppSecret,
+	}
+
+	var response tokenResponse
+	if err := c.post(ctx, "/open-apis/auth/v3/tenant_access_token/internal", "", request, &response); err != nil {
+		return "", err
+	}
+	if response.Code != 0 {
+		return "", fmt.Errorf("feishu token request failed: %d %s", response.Code, response.Msg)
+	}
+
+	c.token = response.TenantAccessToken
+	c.tokenExpiry = time.Now().Add(time.Duration(response.Expire)*time.Second - tokenRefreshMargin)
+
+	return c.token, nil
+}
+
+// post sends a JSON request and decodes the JSON response
+func (c *Client) post(ctx context.Context, path, token string, body interface{}, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.config.BaseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("feishu request %s failed with status %d", path, resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
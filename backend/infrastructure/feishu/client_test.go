@@ -0,0 +1,51 @@
+package feishu
+
+import (
+	"context"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRemindAndNotifyUsers(t *testing.T) {
+	config := testConfig()
+	stub := httptest.NewServer(NewStubServer(config, ""))
+	defer stub.Close()
+	stubServer := stub.Config.Handler.(*StubServer)
+
+	config.BaseURL = stub.URL
+	client := NewClient(config)
+	ctx := context.Background()
+
+	workflow := model.NewWorkflow(model.AssetUpdateType, "AST-0001", "web-01", "alice", "u1", model.HighPriority, "Resize", nil)
+	workflow.ID = primitive.NewObjectID()
+	instanceCode, err := client.Submit(ctx, workflow)
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	workflow.ExternalID = instanceCode
+
+	if err := client.Remind(ctx, workflow, "Workflow is overdue"); err != nil {
+		t.Fatalf("Remind() error = %v", err)
+	}
+	instance, _ := stubServer.Instance(instanceCode)
+	if !reflect.DeepEqual(instance.Comments, []string{"Workflow is overdue"}) {
+		t.Errorf("instance comments = %q, want the reminder", instance.Comments)
+	}
+
+	workflow.ExternalID = "STUB-UNKNOWN"
+	if err := client.Remind(ctx, workflow, "Workflow is overdue"); err == nil {
+		t.Error("Remind() on an unknown instance succeeded")
+	}
+
+	if err := client.NotifyUsers(ctx, []string{"ou_bob", "ou_carol"}, "Workflow is overdue"); err != nil {
+		t.Fatalf("NotifyUsers() error = %v", err)
+	}
+	want := []StubMessage{{OpenID: "ou_bob", Text: "Workflow is overdue"}, {OpenID: "ou_carol", Text: "Workflow is overdue"}}
+	if got := stubServer.Messages(); !reflect.DeepEqual(got, want) {
+		t.Errorf("messages = %+v, want %+v", got, want)
+	}
+}
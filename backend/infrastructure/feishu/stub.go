@@ -0,0 +1,336 @@
+package feishu
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StubInstance is an approval instance recorded by the stub server
+type StubInstance struct {
+	InstanceCode string       `json:"instanceCode"`
+	UUID         string       `json:"uuid"`
+	OpenID       string       `json:"openId"`
+	Form         []FormWidget `json:"form"`
+	Status       string       `json:"status"`
+	Comments     []string     `json:"comments,omitempty"`
+}
+
+// StubMessage is a direct message recorded by the stub server
+type StubMessage struct {
+	OpenID string `json:"openId"`
+	Text   string `json:"text"`
+}
+
+// StubServer is a minimal stand-in for the Feishu open API. It issues tenant tokens,
+// records approval instances and sends signed, encrypted callbacks, so the approval
+// round trip can run without a Feishu tenant.
+type StubServer struct {
+	config      Config
+	callbackURL string
+	httpClient  *http.Client
+
+	mu        sync.Mutex
+	sequence  int
+	instances map[string]*StubInstance
+	byUUID    map[string]string
+	messages  []StubMessage
+}
+
+// NewStubServer creates a stub server accepting the credentials in config and
+// delivering callbacks to callbackURL
+func NewStubServer(config Config, callbackURL string) *StubServer {
+	return &StubServer{
+		config:      config,
+		callbackURL: callbackURL,
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+		instances:   make(map[string]*StubInstance),
+		byUUID:      make(map[string]string),
+	}
+}
+
+// ServeHTTP implements http.Handler
+func (s *StubServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/open-apis/auth/v3/tenant_access_token/internal":
+		s.handleToken(w, r)
+	case r.Method == http.MethodPost && r.URL.Path == "/open-apis/approval/v4/instances":
+		s.handleCreateInstance(w, r)
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/open-apis/approval/v4/instances/") && strings.HasSuffix(r.URL.Path, "/comments"):
+		s.handleComment(w, r, strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/open-apis/approval/v4/instances/"), "/comments"))
+	case r.Method == http.MethodPost && r.URL.Path == "/open-apis/im/v1/messages":
+		s.handleMessage(w, r)
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/stub/instances/"):
+		s.handleGetInstance(w, strings.TrimPrefix(r.URL.Path, "/stub/instances/"))
+	case r.Method == http.MethodPost && r.URL.Path == "/stub/decide":
+		s.handleDecide(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// Instance returns a recorded approval instance
+func (s *StubServer) Instance(instanceCode string) (*StubInstance, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	instance, ok := s.instances[instanceCode]
+	if !ok {
+		return nil, false
+	}
+	copied := *instance
+	copied.Comments = append([]string(nil), instance.Comments...)
+	return &copied, true
+}
+
+// Messages returns the direct messages sent so far
+func (s *StubServer) Messages() []StubMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]StubMessage(nil), s.messages...)
+}
+
+// Decide sets the status of an instance and delivers the callback for it
+func (s *StubServer) Decide(ctx context.Context, instanceCode, status string) error {
+	s.mu.Lock()
+	instance, ok := s.instances[instanceCode]
+	if ok {
+		instance.Status = status
+	}
+	s.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("unknown instance %s", instanceCode)
+	}
+
+	event := map[string]interface{}{
+		"uuid":  instance.UUID,
+		"token": s.config.VerificationToken,
+		"ts":    strconv.FormatInt(time.Now().Unix(), 10),
+		"type":  "event_callback",
+		"event": map[string]interface{}{
+			"type":          "approval_instance",
+			"approval_code": s.config.ApprovalCode,
+			"instance_code": instanceCode,
+			"status":        status,
+			"open_id":       instance.OpenID,
+		},
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	nonce := randomHex(8)
+
+	if s.config.EncryptKey != "" {
+		iv := make([]byte, 16)
+		if _, err := rand.Read(iv); err != nil {
+			return err
+		}
+		encrypted, err := Encrypt(body, s.config.EncryptKey, iv)
+		if err != nil {
+			return err
+		}
+		if body, err = json.Marshal(map[string]string{"encrypt": encrypted}); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.callbackURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderNonce, nonce)
+	if s.config.EncryptKey != "" {
+		req.Header.Set(HeaderSignature, Sign(timestamp, nonce, s.config.EncryptKey, body))
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("callback rejected with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (s *StubServer) handleToken(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		AppID     string `json:"app_id"`
+		AppSecret string `json:"app_secret"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeStubJSON(w, map[string]interface{}{"code": 9499, "msg": err.Error()})
+		return
+	}
+
+	if request.AppID != s.config.AppID || request.AppSecret != s.config.AppSecret {
+		writeStubJSON(w, map[string]interface{}{"code": 10014, "msg": "app secret invalid"})
+		return
+	}
+
+	writeStubJSON(w, map[string]interface{}{
+		"code":                0,
+		"msg":                 "ok",
+		"tenant_access_token": s.tenantToken(),
+		"expire":              7200,
+	})
+}
+
+func (s *StubServer) handleCreateInstance(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+s.tenantToken() {
+		writeStubJSON(w, map[string]interface{}{"code": 99991663, "msg": "invalid access token"})
+		return
+	}
+
+	var request createInstanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeStubJSON(w, map[string]interface{}{"code": 9499, "msg": err.Error()})
+		return
+	}
+
+	if request.ApprovalCode != s.config.ApprovalCode {
+		writeStubJSON(w, map[string]interface{}{"code": 1390001, "msg": "approval code not found"})
+		return
+	}
+
+	var form []FormWidget
+	if err := json.Unmarshal([]byte(request.Form), &form); err != nil {
+		writeStubJSON(w, map[string]interface{}{"code": 1390001, "msg": "invalid form"})
+		return
+	}
+
+	s.mu.Lock()
+	instanceCode, exists := s.byUUID[request.UUID]
+	if !exists || request.UUID == "" {
+		s.sequence++
+		instanceCode = fmt.Sprintf("STUB-%06d", s.sequence)
+		s.instances[instanceCode] = &StubInstance{
+			InstanceCode: instanceCode,
+			UUID:         request.UUID,
+			OpenID:       request.OpenID,
+			Form:         form,
+			Status:       StatusPending,
+		}
+		if request.UUID != "" {
+			s.byUUID[request.UUID] = instanceCode
+		}
+	}
+	s.mu.Unlock()
+
+	writeStubJSON(w, map[string]interface{}{
+		"code": 0,
+		"msg":  "ok",
+		"data": map[string]string{"instance_code": instanceCode},
+	})
+}
+
+func (s *StubServer) handleComment(w http.ResponseWriter, r *http.Request, instanceCode string) {
+	if r.Header.Get("Authorization") != "Bearer "+s.tenantToken() {
+		writeStubJSON(w, map[string]interface{}{"code": 99991663, "msg": "invalid access token"})
+		return
+	}
+
+	var request createCommentRequest
+	var content textContent
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || json.Unmarshal([]byte(request.Content), &content) != nil {
+		writeStubJSON(w, map[string]interface{}{"code": 9499, "msg": "invalid comment"})
+		return
+	}
+
+	s.mu.Lock()
+	instance, ok := s.instances[instanceCode]
+	if ok {
+		instance.Comments = append(instance.Comments, content.Text)
+	}
+	s.mu.Unlock()
+	if !ok {
+		writeStubJSON(w, map[string]interface{}{"code": 1390003, "msg": "instance not found"})
+		return
+	}
+
+	writeStubJSON(w, map[string]interface{}{"code": 0, "msg": "ok"})
+}
+
+func (s *StubServer) handleMessage(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+s.tenantToken() {
+		writeStubJSON(w, map[string]interface{}{"code": 99991663, "msg": "invalid access token"})
+		return
+	}
+
+	var request sendMessageRequest
+	var content textContent
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || json.Unmarshal([]byte(request.Content), &content) != nil ||
+		r.URL.Query().Get("receive_id_type") != "open_id" || request.MsgType != "text" {
+		writeStubJSON(w, map[string]interface{}{"code": 230001, "msg": "invalid message"})
+		return
+	}
+
+	s.mu.Lock()
+	s.messages = append(s.messages, StubMessage{OpenID: request.ReceiveID, Text: content.Text})
+	s.mu.Unlock()
+
+	writeStubJSON(w, map[string]interface{}{"code": 0, "msg": "ok"})
+}
+
+func (s *StubServer) handleGetInstance(w http.ResponseWriter, instanceCode string) {
+	instance, ok := s.Instance(instanceCode)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		writeStubJSON(w, map[string]string{"error": "instance not found"})
+		return
+	}
+	writeStubJSON(w, instance)
+}
+
+func (s *StubServer) handleDecide(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		InstanceCode string `json:"instanceCode"`
+		Status       string `json:"status"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		writeStubJSON(w, map[string]string{"error": err.Error()})
+		return
+	}
+
+	if err := s.Decide(r.Context(), request.InstanceCode, request.Status); err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		writeStubJSON(w, map[string]string{"error": err.Error()})
+		return
+	}
+
+	writeStubJSON(w, map[string]string{"message": "callback delivered"})
+}
+
+// tenantToken derives a stable fake token from the app credentials
+func (s *StubServer) tenantToken() string {
+	return "t-stub-" + s.config.AppID
+}
+
+func writeStubJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("%x", b)
+}
@@ -0,0 +1,85 @@
+package hostfacts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+)
+
+// Report is the body of a facts report
+type Report struct {
+	Facts     *model.HostFacts `json:"facts"`
+	AssetType string           `json:"assetType,omitempty"`
+	Location  string           `json:"location,omitempty"`
+}
+
+// ReportResult identifies the asset a report was merged into
+type ReportResult struct {
+	ID      string `json:"id"`
+	AssetID string `json:"assetId"`
+	Name    string `json:"name"`
+}
+
+// Client pushes facts reports to the CMDB server
+type Client struct {
+	ServerURL  string
+	Token      string
+	HTTPClient *http.Client
+}
+
+// NewClient creates a new client for a server URL such as http://cmdb:8080
+func NewClient(serverURL, token string) *Client {
+	return &Client{
+		ServerURL:  strings.TrimSuffix(serverURL, "/"),
+		Token:      token,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Send pushes a report to the server
+func (c *Client) Send(ctx context.Context, report Report) (*ReportResult, error) {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.ServerURL+"/api/agent/facts", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var failure struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(data, &failure) == nil && failure.Error != "" {
+			return nil, fmt.Errorf("server returned %d: %s", resp.StatusCode, failure.Error)
+		}
+		return nil, fmt.Errorf("server returned %d", resp.StatusCode)
+	}
+
+	var result ReportResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,284 @@
+package hostfacts
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+)
+
+// Version is reported as the agent version with every set of facts
+const Version = "1.0.0"
+
+// systemMachineIDFiles hold the machine ID generated by systemd or D-Bus at install time
+var systemMachineIDFiles = []string{"/etc/machine-id", "/var/lib/dbus/machine-id"}
+
+// Collector gathers the facts of the host it runs on. Facts that cannot be read on the
+// platform are left empty.
+type Collector struct {
+	// StateFile persists a generated machine ID on hosts without a system machine ID
+	StateFile string
+}
+
+// NewCollector creates a new host facts collector
+func NewCollector(stateFile string) *Collector {
+	return &Collector{
+		StateFile: stateFile,
+	}
+}
+
+// Collect gathers the facts of the host
+func (c *Collector) Collect(ctx context.Context) (*model.HostFacts, error) {
+	machineID, err := c.machineID()
+	if err != nil {
+		return nil, err
+	}
+
+	hostname, _ := os.Hostname()
+	facts := &model.HostFacts{
+		MachineID:      machineID,
+		Hostname:       hostname,
+		OS:             osName(),
+		Kernel:         readFirstLine("/proc/sys/kernel/osrelease"),
+		Architecture:   runtime.GOARCH,
+		CPU:            model.CPUFacts{Model: cpuModel(), Cores: runtime.NumCPU()},
+		MemoryBytes:    memoryBytes(),
+		Disks:          disks(),
+		NICs:           nics(),
+		Packages:       packages(ctx),
+		ListeningPorts: listeningPorts(),
+		AgentVersion:   Version,
+		CollectedAt:    time.Now(),
+	}
+	facts.Normalize()
+
+	return facts, nil
+}
+
+// machineID reads the system machine ID, falling back to one generated and saved on first use
+func (c *Collector) machineID() (string, error) {
+	for _, path := range systemMachineIDFiles {
+		if id := readFirstLine(path); id != "" {
+			return id, nil
+		}
+	}
+
+	if c.StateFile == "" {
+		return "", errors.New("no machine ID found and no state file configured")
+	}
+	if id := readFirstLine(c.StateFile); id != "" {
+		return id, nil
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	id := hex.EncodeToString(buf)
+	if err := os.MkdirAll(filepath.Dir(c.StateFile), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(c.StateFile, []byte(id+"\n"), 0o644); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// osName reads the distribution name from os-release
+func osName() string {
+	values := readKeyValues("/etc/os-release", "=")
+	if name := strings.Trim(values["PRETTY_NAME"], `"`); name != "" {
+		return name
+	}
+	return runtime.GOOS
+}
+
+// cpuModel reads the processor model from /proc/cpuinfo
+func cpuModel() string {
+	values := readKeyValues("/proc/cpuinfo", ":")
+	return firstNonEmpty(values["model name"], values["Model"], values["cpu model"])
+}
+
+// memoryBytes reads the total memory from /proc/meminfo
+func memoryBytes() int64 {
+	values := readKeyValues("/proc/meminfo", ":")
+	kb, _ := strconv.ParseInt(strings.TrimSuffix(values["MemTotal"], " kB"), 10, 64)
+	return kb * 1024
+}
+
+// disks lists the block device filesystems in /proc/mounts
+func disks() []model.DiskFacts {
+	file, err := os.Open("/proc/mounts")
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var result []model.DiskFacts
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// Device, mount point, filesystem type, options, dump, pass
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || !strings.HasPrefix(fields[0], "/dev/") || strings.HasPrefix(fields[0], "/dev/loop") {
+			continue
+		}
+		mountPoint := unescapeMount(fields[1])
+		if seen[mountPoint] {
+			continue
+		}
+		seen[mountPoint] = true
+		result = append(result, model.DiskFacts{
+			Device:     fields[0],
+			MountPoint: mountPoint,
+			FSType:     fields[2],
+			SizeBytes:  filesystemSize(mountPoint),
+		})
+	}
+	return result
+}
+
+// unescapeMount decodes the octal escapes /proc/mounts uses for spaces and tabs
+func unescapeMount(path string) string {
+	return strings.NewReplacer(`\040`, " ", `\011`, "\t", `\012`, "\n", `\134`, `\`).Replace(path)
+}
+
+// nics lists the network interfaces other than loopback
+func nics() []model.NICFacts {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+
+	var result []model.NICFacts
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		nic := model.NICFacts{Name: iface.Name, MACAddress: iface.HardwareAddr.String(), Addresses: []string{}}
+		addresses, _ := iface.Addrs()
+		for _, address := range addresses {
+			if ipNet, ok := address.(*net.IPNet); ok {
+				nic.Addresses = append(nic.Addresses, ipNet.IP.String())
+			}
+		}
+		if nic.MACAddress == "" && len(nic.Addresses) == 0 {
+			continue
+		}
+		result = append(result, nic)
+	}
+	return result
+}
+
+// packageManagers list installed packages as name and version separated by a tab
+var packageManagers = [][]string{
+	{"dpkg-query", "-W", "-f", "${Package}\t${Version}\n"},
+	{"rpm", "-qa", "--queryformat", "%{NAME}\t%{VERSION}-%{RELEASE}\n"},
+	{"apk", "list", "--installed"},
+}
+
+// packages lists the packages installed by the first package manager found
+func packages(ctx context.Context) []model.PackageFacts {
+	for _, command := range packageManagers {
+		if _, err := exec.LookPath(command[0]); err != nil {
+			continue
+		}
+		output, err := exec.CommandContext(ctx, command[0], command[1:]...).Output()
+		if err != nil {
+			continue
+		}
+		if command[0] == "apk" {
+			return parseAPKPackages(string(output))
+		}
+		return parsePackages(string(output))
+	}
+	return nil
+}
+
+// parsePackages parses tab separated package names and versions
+func parsePackages(output string) []model.PackageFacts {
+	var result []model.PackageFacts
+	for _, line := range strings.Split(output, "\n") {
+		name, version, ok := strings.Cut(strings.TrimSpace(line), "\t")
+		if !ok || name == "" {
+			continue
+		}
+		result = append(result, model.PackageFacts{Name: name, Version: version})
+	}
+	return result
+}
+
+// parseAPKPackages parses "name-version-release arch {origin} (license) [installed]" lines
+func parseAPKPackages(output string) []model.PackageFacts {
+	var result []model.PackageFacts
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		// The version starts at the second to last dash
+		parts := strings.Split(fields[0], "-")
+		if len(parts) < 3 {
+			continue
+		}
+		result = append(result, model.PackageFacts{
+			Name:    strings.Join(parts[:len(parts)-2], "-"),
+			Version: strings.Join(parts[len(parts)-2:], "-"),
+		})
+	}
+	return result
+}
+
+// readFirstLine reads the first line of a file, or returns an empty string
+func readFirstLine(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	line, _, _ := strings.Cut(string(data), "\n")
+	return strings.TrimSpace(line)
+}
+
+// readKeyValues reads "key<sep>value" lines; the first occurrence of a key wins
+func readKeyValues(path, sep string) map[string]string {
+	values := make(map[string]string)
+	file, err := os.Open(path)
+	if err != nil {
+		return values
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), sep)
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		if _, exists := values[key]; !exists {
+			values[key] = strings.TrimSpace(value)
+		}
+	}
+	return values
+}
+
+// firstNonEmpty returns the first non-empty string
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,14 @@
+//go:build linux
+
+package hostfacts
+
+import "syscall"
+
+// filesystemSize returns the size of the filesystem mounted at a path
+func filesystemSize(mountPoint string) int64 {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(mountPoint, &stat); err != nil {
+		return 0
+	}
+	return int64(stat.Blocks) * int64(stat.Bsize)
+}
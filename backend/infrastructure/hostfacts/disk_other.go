@@ -0,0 +1,8 @@
+//go:build !linux
+
+package hostfacts
+
+// filesystemSize is not collected outside Linux, where mounts are not read either
+func filesystemSize(mountPoint string) int64 {
+	return 0
+}
@@ -0,0 +1,94 @@
+package hostfacts
+
+import (
+	"bufio"
+	"encoding/hex"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+)
+
+// Socket states in /proc/net; unconnected UDP sockets are reported as closed
+const (
+	tcpListen = "0A"
+	udpClose  = "07"
+)
+
+// socketTables are the /proc/net tables of listening sockets and the state they are listed in
+var socketTables = []struct {
+	path, protocol, state string
+}{
+	{"/proc/net/tcp", "tcp", tcpListen},
+	{"/proc/net/tcp6", "tcp6", tcpListen},
+	{"/proc/net/udp", "udp", udpClose},
+	{"/proc/net/udp6", "udp6", udpClose},
+}
+
+// listeningPorts lists the sockets waiting for connections or datagrams
+func listeningPorts() []model.ListeningPort {
+	var result []model.ListeningPort
+	seen := make(map[model.ListeningPort]bool)
+	for _, table := range socketTables {
+		for _, port := range readSocketTable(table.path, table.protocol, table.state) {
+			if !seen[port] {
+				seen[port] = true
+				result = append(result, port)
+			}
+		}
+	}
+	return result
+}
+
+// readSocketTable parses a /proc/net socket table, keeping the sockets in a state
+func readSocketTable(path, protocol, state string) []model.ListeningPort {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var result []model.ListeningPort
+	scanner := bufio.NewScanner(file)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		// sl, local address, remote address, state, ...
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || fields[3] != state {
+			continue
+		}
+		address, port, ok := parseSocketAddress(fields[1])
+		if !ok {
+			continue
+		}
+		result = append(result, model.ListeningPort{Protocol: protocol, Address: address, Port: port})
+	}
+	return result
+}
+
+// parseSocketAddress decodes a hex "address:port" pair. Addresses are stored as 32-bit
+// words in host byte order, which is little endian on the platforms the agent runs on.
+func parseSocketAddress(value string) (string, int, bool) {
+	hexAddress, hexPort, ok := strings.Cut(value, ":")
+	if !ok {
+		return "", 0, false
+	}
+	port, err := strconv.ParseUint(hexPort, 16, 16)
+	if err != nil {
+		return "", 0, false
+	}
+	raw, err := hex.DecodeString(hexAddress)
+	if err != nil || (len(raw) != net.IPv4len && len(raw) != net.IPv6len) {
+		return "", 0, false
+	}
+
+	ip := make(net.IP, len(raw))
+	for word := 0; word < len(raw); word += 4 {
+		for i := 0; i < 4; i++ {
+			ip[word+i] = raw[word+3-i]
+		}
+	}
+	return ip.String(), int(port), true
+}
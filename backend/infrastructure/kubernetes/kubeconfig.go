@@ -0,0 +1,183 @@
+package kubernetes
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// kubeconfig is the part of a kubeconfig file needed to reach an API server
+type kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Clusters       []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server                   string `yaml:"server"`
+			CertificateAuthority     string `yaml:"certificate-authority"`
+			CertificateAuthorityData string `yaml:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Users []struct {
+		Name string `yaml:"name"`
+		User struct {
+			ClientCertificate     string `yaml:"client-certificate"`
+			ClientCertificateData string `yaml:"client-certificate-data"`
+			ClientKey             string `yaml:"client-key"`
+			ClientKeyData         string `yaml:"client-key-data"`
+			Token                 string `yaml:"token"`
+			TokenFile             string `yaml:"tokenFile"`
+			Username              string `yaml:"username"`
+			Password              string `yaml:"password"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+	Contexts []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster string `yaml:"cluster"`
+			User    string `yaml:"user"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+}
+
+// Config is how to reach and authenticate to an API server
+type Config struct {
+	Server    string
+	TLSConfig *tls.Config
+	// Token, or Username and Password, authenticate requests; client certificates are in TLSConfig
+	Token    string
+	Username string
+	Password string
+}
+
+// LoadKubeconfig reads the API server and credentials of a context from a kubeconfig file. The
+// current context is used when contextName is empty. Only static credentials are supported:
+// client certificates, bearer tokens and basic auth. Relative file paths are resolved against
+// the directory of the kubeconfig.
+func LoadKubeconfig(path, contextName string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var kc kubeconfig
+	if err := yaml.Unmarshal(data, &kc); err != nil {
+		return nil, fmt.Errorf("invalid kubeconfig: %w", err)
+	}
+	dir := filepath.Dir(path)
+
+	if contextName == "" {
+		contextName = kc.CurrentContext
+	}
+	var clusterName, userName string
+	found := false
+	for _, c := range kc.Contexts {
+		if c.Name == contextName {
+			clusterName, userName, found = c.Context.Cluster, c.Context.User, true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("context %q not found in kubeconfig", contextName)
+	}
+
+	config := &Config{TLSConfig: &tls.Config{MinVersion: tls.VersionTLS12}}
+
+	found = false
+	for _, c := range kc.Clusters {
+		if c.Name != clusterName {
+			continue
+		}
+		found = true
+		config.Server = strings.TrimSuffix(c.Cluster.Server, "/")
+		config.TLSConfig.InsecureSkipVerify = c.Cluster.InsecureSkipTLSVerify
+
+		ca, err := readData(dir, c.Cluster.CertificateAuthority, c.Cluster.CertificateAuthorityData)
+		if err != nil {
+			return nil, fmt.Errorf("certificate authority: %w", err)
+		}
+		if ca != nil {
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(ca) {
+				return nil, errors.New("certificate authority contains no certificates")
+			}
+			config.TLSConfig.RootCAs = pool
+		}
+		break
+	}
+	if !found {
+		return nil, fmt.Errorf("cluster %q not found in kubeconfig", clusterName)
+	}
+	if config.Server == "" {
+		return nil, fmt.Errorf("cluster %q has no server", clusterName)
+	}
+
+	for _, u := range kc.Users {
+		if u.Name != userName {
+			continue
+		}
+		cert, err := readData(dir, u.User.ClientCertificate, u.User.ClientCertificateData)
+		if err != nil {
+			return nil, fmt.Errorf("client certificate: %w", err)
+		}
+		key, err := readData(dir, u.User.ClientKey, u.User.ClientKeyData)
+		if err != nil {
+			return nil, fmt.Errorf("client key: %w", err)
+		}
+		if cert != nil || key != nil {
+			pair, err := tls.X509KeyPair(cert, key)
+			if err != nil {
+				return nil, fmt.Errorf("client certificate: %w", err)
+			}
+			config.TLSConfig.Certificates = []tls.Certificate{pair}
+		}
+
+		config.Token = u.User.Token
+		if config.Token == "" && u.User.TokenFile != "" {
+			token, err := os.ReadFile(resolvePath(dir, u.User.TokenFile))
+			if err != nil {
+				return nil, fmt.Errorf("token file: %w", err)
+			}
+			config.Token = strings.TrimSpace(string(token))
+		}
+		config.Username = u.User.Username
+		config.Password = u.User.Password
+		break
+	}
+
+	return config, nil
+}
+
+// Transport returns an HTTP transport that trusts the cluster's certificate authority
+func (c *Config) Transport() http.RoundTripper {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = c.TLSConfig
+	return transport
+}
+
+// readData returns the contents of a kubeconfig field given as a file path or as base64 data
+func readData(dir, path, data string) ([]byte, error) {
+	if data != "" {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	if path != "" {
+		return os.ReadFile(resolvePath(dir, path))
+	}
+	return nil, nil
+}
+
+// resolvePath resolves a path relative to the kubeconfig directory
+func resolvePath(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
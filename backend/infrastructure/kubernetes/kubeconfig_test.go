@@ -0,0 +1,130 @@
+package kubernetes
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+current-context: prod
+clusters:
+- name: prod
+  cluster:
+    server: https://prod.example.com:6443/
+- name: staging
+  cluster:
+    server: https://staging.example.com:6443
+    insecure-skip-tls-verify: true
+- name: empty
+  cluster: {}
+users:
+- name: admin
+  user:
+    token: prod-token
+- name: ci
+  user:
+    tokenFile: tokens/ci
+- name: basic
+  user:
+    username: kube
+    password: hunter2
+contexts:
+- name: prod
+  context:
+    cluster: prod
+    user: admin
+- name: staging
+  context:
+    cluster: staging
+    user: ci
+- name: basic
+  context:
+    cluster: prod
+    user: basic
+- name: orphan
+  context:
+    cluster: gone
+    user: admin
+- name: serverless
+  context:
+    cluster: empty
+    user: admin
+`
+
+func TestLoadKubeconfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config")
+	if err := os.WriteFile(path, []byte(testKubeconfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// tokenFile is relative to the kubeconfig
+	if err := os.MkdirAll(filepath.Join(dir, "tokens"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "tokens", "ci"), []byte("ci-token\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		context  string
+		server   string
+		token    string
+		username string
+		password string
+		insecure bool
+		err      string
+	}{
+		{context: "", server: "https://prod.example.com:6443", token: "prod-token"},
+		{context: "staging", server: "https://staging.example.com:6443", token: "ci-token", insecure: true},
+		{context: "basic", server: "https://prod.example.com:6443", username: "kube", password: "hunter2"},
+		{context: "missing", err: `context "missing" not found`},
+		{context: "orphan", err: `cluster "gone" not found`},
+		{context: "serverless", err: `cluster "empty" has no server`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.context, func(t *testing.T) {
+			config, err := LoadKubeconfig(path, tt.context)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("LoadKubeconfig() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadKubeconfig() error = %v", err)
+			}
+			if config.Server != tt.server || config.Token != tt.token || config.Username != tt.username || config.Password != tt.password {
+				t.Errorf("LoadKubeconfig() = %s with token %q, user %q:%q; want %s with token %q, user %q:%q",
+					config.Server, config.Token, config.Username, config.Password, tt.server, tt.token, tt.username, tt.password)
+			}
+			if config.TLSConfig.InsecureSkipVerify != tt.insecure {
+				t.Errorf("InsecureSkipVerify = %v, want %v", config.TLSConfig.InsecureSkipVerify, tt.insecure)
+			}
+		})
+	}
+}
+
+func TestLoadKubeconfigRejectsInvalidCA(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	kubeconfig := `current-context: test
+clusters:
+- name: test
+  cluster:
+    server: https://example.com
+    certificate-authority-data: bm90IGEgY2VydGlmaWNhdGU=
+contexts:
+- name: test
+  context:
+    cluster: test
+`
+	if err := os.WriteFile(path, []byte(kubeconfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadKubeconfig(path, ""); err == nil {
+		t.Error("LoadKubeconfig() accepted a certificate authority without certificates")
+	}
+}
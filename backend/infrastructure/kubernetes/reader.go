@@ -0,0 +1,417 @@
+// Package kubernetes reads the objects of a Kubernetes cluster for the CMDB, from a live API
+// server given by a kubeconfig or from responses recorded from one.
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+)
+
+// API paths of the objects read from a cluster
+const (
+	versionPath      = "/version"
+	nodesPath        = "/api/v1/nodes"
+	namespacesPath   = "/api/v1/namespaces"
+	deploymentsPath  = "/apis/apps/v1/deployments"
+	statefulSetsPath = "/apis/apps/v1/statefulsets"
+	servicesPath     = "/api/v1/services"
+	ingressesPath    = "/apis/networking.k8s.io/v1/ingresses"
+)
+
+// Reader reads clusters through the Kubernetes API
+type Reader struct {
+	// Timeout limits each request to the API server
+	Timeout time.Duration
+}
+
+// NewReader creates a new Kubernetes reader
+func NewReader(timeout time.Duration) *Reader {
+	return &Reader{
+		Timeout: timeout,
+	}
+}
+
+// Read reads a cluster. When the cluster's kubeconfig path is a directory it is read as
+// responses recorded from an API server instead, see ReadFixtures.
+func (r *Reader) Read(ctx context.Context, cluster *model.KubernetesCluster) (*model.KubernetesSnapshot, error) {
+	if info, err := os.Stat(cluster.KubeconfigPath); err == nil && info.IsDir() {
+		return r.ReadFixtures(ctx, cluster.KubeconfigPath)
+	}
+
+	config, err := LoadKubeconfig(cluster.KubeconfigPath, cluster.Context)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := readSnapshot(ctx, newAPIClient(config, r.Timeout))
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Server = config.Server
+	return snapshot, nil
+}
+
+// ReadFixtures reads a cluster from responses recorded from an API server. The directory holds
+// one JSON file per API path, as returned by e.g. kubectl get --raw /api/v1/nodes:
+//
+//	version.json
+//	api/v1/nodes.json
+//	api/v1/namespaces.json
+//	api/v1/services.json
+//	apis/apps/v1/deployments.json
+//	apis/apps/v1/statefulsets.json
+//	apis/networking.k8s.io/v1/ingresses.json
+func (r *Reader) ReadFixtures(ctx context.Context, dir string) (*model.KubernetesSnapshot, error) {
+	snapshot, err := readSnapshot(ctx, &fixtureClient{dir: dir})
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Server = "fixtures:" + dir
+	return snapshot, nil
+}
+
+// readSnapshot reads every object of the supported kinds
+func readSnapshot(ctx context.Context, g getter) (*model.KubernetesSnapshot, error) {
+	var version struct {
+		GitVersion string `json:"gitVersion"`
+	}
+	if err := g.get(ctx, versionPath, nil, &version); err != nil {
+		return nil, err
+	}
+
+	nodes, err := list[node](ctx, g, nodesPath)
+	if err != nil {
+		return nil, err
+	}
+	namespaces, err := list[namespace](ctx, g, namespacesPath)
+	if err != nil {
+		return nil, err
+	}
+	deployments, err := list[workload](ctx, g, deploymentsPath)
+	if err != nil {
+		return nil, err
+	}
+	statefulSets, err := list[workload](ctx, g, statefulSetsPath)
+	if err != nil {
+		return nil, err
+	}
+	services, err := list[service](ctx, g, servicesPath)
+	if err != nil {
+		return nil, err
+	}
+	ingresses, err := list[ingress](ctx, g, ingressesPath)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &model.KubernetesSnapshot{Version: version.GitVersion}
+	for _, n := range nodes {
+		snapshot.Objects = append(snapshot.Objects, n.object())
+	}
+	for _, ns := range namespaces {
+		snapshot.Objects = append(snapshot.Objects, ns.object())
+	}
+	for _, d := range deployments {
+		snapshot.Objects = append(snapshot.Objects, d.object(model.KindDeployment))
+	}
+	for _, s := range statefulSets {
+		snapshot.Objects = append(snapshot.Objects, s.object(model.KindStatefulSet))
+	}
+
+	workloads := make([]model.KubernetesObject, 0, len(deployments)+len(statefulSets))
+	workloadLabels := make([]map[string]string, 0, cap(workloads))
+	for _, d := range deployments {
+		workloads = append(workloads, model.KubernetesObject{Kind: model.KindDeployment, Name: d.Metadata.Name, Namespace: d.Metadata.Namespace})
+		workloadLabels = append(workloadLabels, d.Spec.Template.Metadata.Labels)
+	}
+	for _, s := range statefulSets {
+		workloads = append(workloads, model.KubernetesObject{Kind: model.KindStatefulSet, Name: s.Metadata.Name, Namespace: s.Metadata.Namespace})
+		workloadLabels = append(workloadLabels, s.Spec.Template.Metadata.Labels)
+	}
+
+	for _, s := range services {
+		obj := s.object()
+		// A service routes to the workloads whose pods match its selector
+		if len(s.Spec.Selector) > 0 {
+			for i, w := range workloads {
+				if w.Namespace == s.Metadata.Namespace && matchesSelector(workloadLabels[i], s.Spec.Selector) {
+					obj.DependsOn = append(obj.DependsOn, w.Ref())
+				}
+			}
+		}
+		snapshot.Objects = append(snapshot.Objects, obj)
+	}
+	for _, i := range ingresses {
+		snapshot.Objects = append(snapshot.Objects, i.object())
+	}
+
+	return snapshot, nil
+}
+
+// objectMeta is the metadata common to all objects
+type objectMeta struct {
+	Name        string            `json:"name"`
+	Namespace   string            `json:"namespace"`
+	UID         string            `json:"uid"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// object creates an object of a kind with the common attributes
+func (m objectMeta) object(kind model.KubernetesKind) model.KubernetesObject {
+	obj := model.KubernetesObject{
+		Kind:       kind,
+		UID:        m.UID,
+		Name:       m.Name,
+		Attributes: map[string]interface{}{},
+	}
+	if kind.Namespaced() {
+		obj.Namespace = m.Namespace
+	}
+	setString(obj.Attributes, "labels", joinMap(m.Labels))
+	return obj
+}
+
+// node is a Kubernetes node
+type node struct {
+	Metadata objectMeta `json:"metadata"`
+	Spec     struct {
+		ProviderID string `json:"providerID"`
+	} `json:"spec"`
+	Status struct {
+		Capacity  map[string]string `json:"capacity"`
+		Addresses []struct {
+			Type    string `json:"type"`
+			Address string `json:"address"`
+		} `json:"addresses"`
+		NodeInfo struct {
+			MachineID               string `json:"machineID"`
+			KernelVersion           string `json:"kernelVersion"`
+			OSImage                 string `json:"osImage"`
+			ContainerRuntimeVersion string `json:"containerRuntimeVersion"`
+			KubeletVersion          string `json:"kubeletVersion"`
+			Architecture            string `json:"architecture"`
+		} `json:"nodeInfo"`
+	} `json:"status"`
+}
+
+func (n node) object() model.KubernetesObject {
+	obj := n.Metadata.object(model.KindNode)
+	info := n.Status.NodeInfo
+	setString(obj.Attributes, "kubeletVersion", info.KubeletVersion)
+	setString(obj.Attributes, "osImage", info.OSImage)
+	setString(obj.Attributes, "kernelVersion", info.KernelVersion)
+	setString(obj.Attributes, "containerRuntime", info.ContainerRuntimeVersion)
+	setString(obj.Attributes, "architecture", info.Architecture)
+	setString(obj.Attributes, "providerID", n.Spec.ProviderID)
+	setString(obj.Attributes, "cpu", n.Status.Capacity["cpu"])
+	setString(obj.Attributes, "memory", n.Status.Capacity["memory"])
+
+	obj.MachineID = info.MachineID
+	obj.Hostname = n.Metadata.Name
+	for _, address := range n.Status.Addresses {
+		switch address.Type {
+		case "Hostname":
+			obj.Hostname = address.Address
+		case "InternalIP":
+			if _, ok := obj.Attributes["internalIP"]; !ok {
+				setString(obj.Attributes, "internalIP", address.Address)
+			}
+			obj.IPAddresses = append(obj.IPAddresses, address.Address)
+		case "ExternalIP":
+			obj.IPAddresses = append(obj.IPAddresses, address.Address)
+		}
+	}
+	return obj
+}
+
+// namespace is a Kubernetes namespace
+type namespace struct {
+	Metadata objectMeta `json:"metadata"`
+	Status   struct {
+		Phase string `json:"phase"`
+	} `json:"status"`
+}
+
+func (ns namespace) object() model.KubernetesObject {
+	obj := ns.Metadata.object(model.KindNamespace)
+	setString(obj.Attributes, "phase", ns.Status.Phase)
+	return obj
+}
+
+// workload is a deployment or statefulset
+type workload struct {
+	Metadata objectMeta `json:"metadata"`
+	Spec     struct {
+		Replicas *int64 `json:"replicas"`
+		Template struct {
+			Metadata struct {
+				Labels map[string]string `json:"labels"`
+			} `json:"metadata"`
+			Spec struct {
+				Containers []struct {
+					Image string `json:"image"`
+				} `json:"containers"`
+			} `json:"spec"`
+		} `json:"template"`
+	} `json:"spec"`
+	Status struct {
+		ReadyReplicas int64 `json:"readyReplicas"`
+	} `json:"status"`
+}
+
+func (w workload) object(kind model.KubernetesKind) model.KubernetesObject {
+	obj := w.Metadata.object(kind)
+	// Replicas defaults to 1 when not set
+	replicas := int64(1)
+	if w.Spec.Replicas != nil {
+		replicas = *w.Spec.Replicas
+	}
+	obj.Attributes["replicas"] = replicas
+	obj.Attributes["readyReplicas"] = w.Status.ReadyReplicas
+
+	var images []string
+	for _, container := range w.Spec.Template.Spec.Containers {
+		images = append(images, container.Image)
+	}
+	setString(obj.Attributes, "images", joinSorted(images))
+	return obj
+}
+
+// service is a Kubernetes service
+type service struct {
+	Metadata objectMeta `json:"metadata"`
+	Spec     struct {
+		Type      string            `json:"type"`
+		ClusterIP string            `json:"clusterIP"`
+		Selector  map[string]string `json:"selector"`
+		Ports     []struct {
+			Port     int    `json:"port"`
+			Protocol string `json:"protocol"`
+		} `json:"ports"`
+	} `json:"spec"`
+}
+
+func (s service) object() model.KubernetesObject {
+	obj := s.Metadata.object(model.KindService)
+	setString(obj.Attributes, "serviceType", s.Spec.Type)
+	setString(obj.Attributes, "clusterIP", s.Spec.ClusterIP)
+
+	var ports []string
+	for _, port := range s.Spec.Ports {
+		protocol := port.Protocol
+		if protocol == "" {
+			protocol = "TCP"
+		}
+		ports = append(ports, fmt.Sprintf("%d/%s", port.Port, protocol))
+	}
+	setString(obj.Attributes, "ports", joinSorted(ports))
+	return obj
+}
+
+// ingressBackend is where an ingress sends traffic
+type ingressBackend struct {
+	Service *struct {
+		Name string `json:"name"`
+	} `json:"service"`
+}
+
+// ingress is a Kubernetes ingress
+type ingress struct {
+	Metadata objectMeta `json:"metadata"`
+	Spec     struct {
+		IngressClassName *string         `json:"ingressClassName"`
+		DefaultBackend   *ingressBackend `json:"defaultBackend"`
+		Rules            []struct {
+			Host string `json:"host"`
+			HTTP *struct {
+				Paths []struct {
+					Backend ingressBackend `json:"backend"`
+				} `json:"paths"`
+			} `json:"http"`
+		} `json:"rules"`
+	} `json:"spec"`
+}
+
+func (i ingress) object() model.KubernetesObject {
+	obj := i.Metadata.object(model.KindIngress)
+
+	class := i.Metadata.Annotations["kubernetes.io/ingress.class"]
+	if i.Spec.IngressClassName != nil {
+		class = *i.Spec.IngressClassName
+	}
+	setString(obj.Attributes, "ingressClass", class)
+
+	var hosts []string
+	backends := make(map[string]bool)
+	addBackend := func(backend *ingressBackend) {
+		if backend != nil && backend.Service != nil && backend.Service.Name != "" {
+			backends[backend.Service.Name] = true
+		}
+	}
+	addBackend(i.Spec.DefaultBackend)
+	for _, rule := range i.Spec.Rules {
+		if rule.Host != "" {
+			hosts = append(hosts, rule.Host)
+		}
+		if rule.HTTP != nil {
+			for _, path := range rule.HTTP.Paths {
+				addBackend(&path.Backend)
+			}
+		}
+	}
+	setString(obj.Attributes, "hosts", joinSorted(hosts))
+
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		obj.DependsOn = append(obj.DependsOn, model.KubernetesRef{Kind: model.KindService, Namespace: obj.Namespace, Name: name})
+	}
+	return obj
+}
+
+// matchesSelector checks whether labels contain every label of a selector
+func matchesSelector(labels, selector map[string]string) bool {
+	for key, value := range selector {
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// setString sets a string attribute unless the value is empty
+func setString(attributes map[string]interface{}, name, value string) {
+	if value != "" {
+		attributes[name] = value
+	}
+}
+
+// joinMap describes a label map as sorted key=value pairs
+func joinMap(m map[string]string) string {
+	pairs := make([]string, 0, len(m))
+	for key, value := range m {
+		pairs = append(pairs, key+"="+value)
+	}
+	return joinSorted(pairs)
+}
+
+// joinSorted joins the unique values of a list in order
+func joinSorted(values []string) string {
+	sort.Strings(values)
+	unique := values[:0]
+	for i, value := range values {
+		if i == 0 || value != values[i-1] {
+			unique = append(unique, value)
+		}
+	}
+	return strings.Join(unique, ",")
+}
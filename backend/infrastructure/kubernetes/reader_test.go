@@ -0,0 +1,231 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+)
+
+// fixtureDir holds responses recorded from a cluster with one node and a small shop namespace
+const fixtureDir = "testdata/cluster"
+
+// fixtureObjects are the objects read from fixtureDir
+var fixtureObjects = []model.KubernetesObject{
+	{
+		Kind: model.KindNode, UID: "0b7c3c5e-1111-4d1a-9f0e-000000000001", Name: "worker-1",
+		Attributes: map[string]interface{}{
+			"labels":           "kubernetes.io/hostname=worker-1,kubernetes.io/os=linux",
+			"kubeletVersion":   "v1.29.4",
+			"osImage":          "Debian GNU/Linux 12 (bookworm)",
+			"kernelVersion":    "6.1.0-18-amd64",
+			"containerRuntime": "containerd://1.7.13",
+			"architecture":     "amd64",
+			"providerID":       "aws:///us-east-1a/i-0abc",
+			"cpu":              "4",
+			"memory":           "16331484Ki",
+			// The first internal address of the node
+			"internalIP": "10.0.0.11",
+		},
+		MachineID:   "ec2a3b0e7f9d4c1e8a6b5c4d3e2f1a0b",
+		Hostname:    "worker-1.internal",
+		IPAddresses: []string{"10.0.0.11", "10.0.0.12", "203.0.113.11"},
+	},
+	{
+		Kind: model.KindNamespace, UID: "0b7c3c5e-2222-4d1a-9f0e-000000000001", Name: "shop",
+		Attributes: map[string]interface{}{"labels": "kubernetes.io/metadata.name=shop", "phase": "Active"},
+	},
+	{
+		Kind: model.KindDeployment, UID: "0b7c3c5e-3333-4d1a-9f0e-000000000001", Name: "web", Namespace: "shop",
+		Attributes: map[string]interface{}{
+			"labels":        "app=web",
+			"replicas":      int64(3),
+			"readyReplicas": int64(2),
+			"images":        "envoyproxy/envoy:v1.29.2,registry.example.com/shop/web:1.4.2",
+		},
+	},
+	{
+		// Replicas defaults to 1 when the spec leaves it out
+		Kind: model.KindDeployment, UID: "0b7c3c5e-3333-4d1a-9f0e-000000000002", Name: "worker", Namespace: "shop",
+		Attributes: map[string]interface{}{
+			"replicas":      int64(1),
+			"readyReplicas": int64(0),
+			"images":        "registry.example.com/shop/worker:1.4.2",
+		},
+	},
+	{
+		Kind: model.KindStatefulSet, UID: "0b7c3c5e-4444-4d1a-9f0e-000000000001", Name: "db", Namespace: "shop",
+		Attributes: map[string]interface{}{
+			"replicas":      int64(1),
+			"readyReplicas": int64(1),
+			"images":        "postgres:16",
+		},
+	},
+	{
+		Kind: model.KindService, UID: "0b7c3c5e-5555-4d1a-9f0e-000000000001", Name: "web", Namespace: "shop",
+		Attributes: map[string]interface{}{"serviceType": "ClusterIP", "clusterIP": "10.96.10.10", "ports": "80/TCP,9090/TCP"},
+		DependsOn:  []model.KubernetesRef{{Kind: model.KindDeployment, Namespace: "shop", Name: "web"}},
+	},
+	{
+		Kind: model.KindService, UID: "0b7c3c5e-5555-4d1a-9f0e-000000000002", Name: "db", Namespace: "shop",
+		Attributes: map[string]interface{}{"serviceType": "ClusterIP", "clusterIP": "None", "ports": "5432/TCP"},
+		DependsOn:  []model.KubernetesRef{{Kind: model.KindStatefulSet, Namespace: "shop", Name: "db"}},
+	},
+	{
+		// Without a selector a service routes to no workload
+		Kind: model.KindService, UID: "0b7c3c5e-5555-4d1a-9f0e-000000000003", Name: "external", Namespace: "shop",
+		Attributes: map[string]interface{}{"serviceType": "ExternalName"},
+	},
+	{
+		// ingressClassName wins over the legacy annotation, and each backend is listed once
+		Kind: model.KindIngress, UID: "0b7c3c5e-6666-4d1a-9f0e-000000000001", Name: "shop", Namespace: "shop",
+		Attributes: map[string]interface{}{"ingressClass": "nginx", "hosts": "shop.example.com,www.example.com"},
+		DependsOn: []model.KubernetesRef{
+			{Kind: model.KindService, Namespace: "shop", Name: "external"},
+			{Kind: model.KindService, Namespace: "shop", Name: "web"},
+		},
+	},
+}
+
+// checkSnapshot compares a snapshot with the objects recorded in fixtureDir
+func checkSnapshot(t *testing.T, snapshot *model.KubernetesSnapshot, server string) {
+	t.Helper()
+	if snapshot.Server != server || snapshot.Version != "v1.29.4" {
+		t.Errorf("snapshot of %q at %q, want %q at v1.29.4", snapshot.Server, snapshot.Version, server)
+	}
+	if len(snapshot.Objects) != len(fixtureObjects) {
+		t.Fatalf("read %d objects, want %d", len(snapshot.Objects), len(fixtureObjects))
+	}
+	for i, want := range fixtureObjects {
+		if got := snapshot.Objects[i]; !reflect.DeepEqual(got, want) {
+			t.Errorf("object %d =\n%+v\nwant\n%+v", i, got, want)
+		}
+	}
+}
+
+func TestReadFixtures(t *testing.T) {
+	cluster := model.NewKubernetesCluster("shop", fixtureDir, "admin")
+	snapshot, err := NewReader(time.Second).Read(context.Background(), cluster)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	checkSnapshot(t, snapshot, "fixtures:"+fixtureDir)
+}
+
+func TestReadFixturesMissingFiles(t *testing.T) {
+	// Only the version was recorded, so every list is empty
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "version.json"), []byte(`{"gitVersion": "v1.30.0"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := NewReader(time.Second).ReadFixtures(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("ReadFixtures() error = %v", err)
+	}
+	if snapshot.Version != "v1.30.0" || len(snapshot.Objects) != 0 {
+		t.Errorf("ReadFixtures() = %s with %d objects, want v1.30.0 with none", snapshot.Version, len(snapshot.Objects))
+	}
+}
+
+// newFakeAPIServer serves the recorded fixtures over TLS to requests with a bearer token. Lists
+// are returned one object per page, so reading them follows continue tokens.
+func newFakeAPIServer(t *testing.T, token string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			http.Error(w, `{"kind":"Status","message":"Unauthorized","code":401}`, http.StatusUnauthorized)
+			return
+		}
+		data, err := os.ReadFile(filepath.Join(fixtureDir, filepath.FromSlash(strings.TrimPrefix(r.URL.Path, "/"))+".json"))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Path == versionPath {
+			w.Write(data)
+			return
+		}
+
+		if r.URL.Query().Get("limit") != strconv.Itoa(pageSize) {
+			http.Error(w, "list requested without a limit", http.StatusBadRequest)
+			return
+		}
+		var list struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		if err := json.Unmarshal(data, &list); err != nil {
+			t.Error(err)
+			return
+		}
+		index := 0
+		if next := r.URL.Query().Get("continue"); next != "" {
+			index, _ = strconv.Atoi(next)
+		}
+		page := map[string]interface{}{"metadata": map[string]string{}, "items": list.Items[index : index+1]}
+		if index+1 < len(list.Items) {
+			page["metadata"] = map[string]string{"continue": strconv.Itoa(index + 1)}
+		}
+		json.NewEncoder(w).Encode(page)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// writeKubeconfig writes a kubeconfig that trusts the fake API server and sends a token
+func writeKubeconfig(t *testing.T, server *httptest.Server, token string) string {
+	t.Helper()
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	kubeconfig := fmt.Sprintf(`apiVersion: v1
+kind: Config
+current-context: test
+clusters:
+- name: test
+  cluster:
+    server: %s/
+    certificate-authority-data: %s
+users:
+- name: reader
+  user:
+    token: %s
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: reader
+`, server.URL, base64.StdEncoding.EncodeToString(ca), token)
+
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+	if err := os.WriteFile(path, []byte(kubeconfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadAPIServer(t *testing.T) {
+	server := newFakeAPIServer(t, "s3cret")
+	reader := NewReader(5 * time.Second)
+
+	cluster := model.NewKubernetesCluster("shop", writeKubeconfig(t, server, "s3cret"), "admin")
+	snapshot, err := reader.Read(context.Background(), cluster)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	checkSnapshot(t, snapshot, server.URL)
+
+	cluster.KubeconfigPath = writeKubeconfig(t, server, "wrong")
+	if _, err := reader.Read(context.Background(), cluster); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Read() with a rejected token error = %v, want 401", err)
+	}
+}
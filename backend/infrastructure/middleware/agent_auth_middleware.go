@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/phuhao00/cmdb/backend/application"
+	"github.com/phuhao00/cmdb/backend/domain/service"
+	"github.com/phuhao00/cmdb/backend/infrastructure/logging"
+	"go.uber.org/zap"
+)
+
+// AgentAuthMiddleware authenticates inventory agents by their agent tokens
+type AgentAuthMiddleware struct {
+	agentApp *application.AgentApplication
+}
+
+// NewAgentAuthMiddleware creates a new agent authentication middleware
+func NewAgentAuthMiddleware(agentApp *application.AgentApplication) *AgentAuthMiddleware {
+	return &AgentAuthMiddleware{
+		agentApp: agentApp,
+	}
+}
+
+// RequireAgentToken middleware ensures the request carries a valid agent token
+func (m *AgentAuthMiddleware) RequireAgentToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Extract token from "Bearer <token>" format
+		tokenParts := strings.Split(c.GetHeader("Authorization"), " ")
+		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Agent token required"})
+			c.Abort()
+			return
+		}
+
+		agent, err := m.agentApp.Authenticate(c.Request.Context(), tokenParts[1])
+		if errors.Is(err, service.ErrInvalidAgentToken) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid agent token"})
+			c.Abort()
+			return
+		}
+		if err != nil {
+			logging.Logger.Error("agent_authentication_failed", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to authenticate agent token"})
+			c.Abort()
+			return
+		}
+
+		// Set agent in context
+		c.Set("agent", agent)
+		c.Next()
+	}
+}
@@ -0,0 +1,189 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/phuhao00/cmdb/backend/application"
+	"github.com/phuhao00/cmdb/backend/infrastructure/logging"
+	"go.uber.org/zap"
+)
+
+// auditedKey marks a request whose audit log has already been written, such as one refused
+// by AuthMiddleware
+const auditedKey = "audited"
+
+// maxAuditedErrorBody bounds how much of an error response is kept to find its message
+const maxAuditedErrorBody = 4096
+
+// auditResources names the resources of the API by the path their routes start with. Other
+// routes are named after their first path segment.
+var auditResources = []struct {
+	prefix   string
+	resource string
+}{
+	{"/api/assets", "asset"},
+	{"/api/workflows", "workflow"},
+	{"/api/approval-policies", "approval_policy"},
+	{"/api/alerts", "alert"},
+	{"/api/alert-rules", "alert_rule"},
+	{"/api/reports", "report"},
+	{"/api/audit-logs", "audit_log"},
+	{"/api/auth", "user"},
+	{"/api/users", "user"},
+	{"/api/ci-types", "ci_type"},
+	{"/api/discovery/ranges", "discovery_range"},
+	{"/api/discovery/runs", "discovery_run"},
+	{"/api/discovery/candidates", "discovery_candidate"},
+	{"/api/kubernetes/clusters", "kubernetes_cluster"},
+	{"/api/cloud/accounts", "cloud_account"},
+	{"/api/reconciliation/conflicts", "reconciliation_conflict"},
+	{"/api/reconciliation/merge-proposals", "merge_proposal"},
+	{"/api/agent-tokens", "agent_token"},
+	{"/api/id-templates", "id_template"},
+}
+
+// AuditMiddleware records the requests of authenticated users in the audit log
+type AuditMiddleware struct {
+	auditLogApp *application.AuditLogApplication
+}
+
+// NewAuditMiddleware creates a new audit middleware
+func NewAuditMiddleware(auditLogApp *application.AuditLogApplication) *AuditMiddleware {
+	return &AuditMiddleware{
+		auditLogApp: auditLogApp,
+	}
+}
+
+// RecordRequests passes the client of every request on to the audit logs written while serving
+// it, and audits each request of an authenticated user that changes data once it is served,
+// whether it succeeded or not. It must run before RequireAuth.
+func (m *AuditMiddleware) RecordRequests() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(application.WithClient(c.Request.Context(), c.ClientIP(), c.Request.UserAgent()))
+
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead || c.Request.Method == http.MethodOptions {
+			c.Next()
+			return
+		}
+
+		writer := &auditResponseWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		user, ok := c.Get("user")
+		if !ok || c.GetBool(auditedKey) {
+			return
+		}
+
+		dto := requestAudit(c)
+		if dto.StatusCode >= http.StatusBadRequest {
+			dto.Error = writer.errorMessage()
+		}
+
+		// The request is over, but its audit log must still be written
+		ctx := context.WithoutCancel(c.Request.Context())
+		if err := m.auditLogApp.LogRequest(ctx, user.(*application.UserDTO), dto); err != nil {
+			logging.Logger.Error("audit_log_write_failed",
+				zap.String("action", dto.Action),
+				zap.String("path", dto.Path),
+				zap.Error(err))
+		}
+	}
+}
+
+// requestAudit describes the request of a context for the audit log. The action names the
+// resource and the operation: the static path segments after the resource, followed by create,
+// update or delete for requests to the resource itself or to one of its items.
+func requestAudit(c *gin.Context) application.RequestAuditDTO {
+	path := c.FullPath()
+	resource, rest := auditResource(path)
+
+	var operation []string
+	segments := strings.Split(strings.Trim(rest, "/"), "/")
+	endsWithParam := false
+	for _, segment := range segments {
+		endsWithParam = strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*")
+		if segment != "" && !endsWithParam {
+			operation = append(operation, segment)
+		}
+	}
+	if len(operation) == 0 || endsWithParam {
+		switch c.Request.Method {
+		case http.MethodPost:
+			operation = append(operation, "create")
+		case http.MethodDelete:
+			operation = append(operation, "delete")
+		default:
+			operation = append(operation, "update")
+		}
+	}
+
+	dto := application.RequestAuditDTO{
+		Action:       strings.ReplaceAll(resource+"_"+strings.Join(operation, "_"), "-", "_"),
+		ResourceType: resource,
+		Method:       c.Request.Method,
+		Path:         path,
+		StatusCode:   c.Writer.Status(),
+	}
+	if len(c.Params) > 0 {
+		dto.ResourceID = c.Params[0].Value
+	}
+	return dto
+}
+
+// auditResource returns the resource a route acts on and the rest of the route after it
+func auditResource(path string) (string, string) {
+	for _, entry := range auditResources {
+		if path == entry.prefix || strings.HasPrefix(path, entry.prefix+"/") {
+			return entry.resource, strings.TrimPrefix(path, entry.prefix)
+		}
+	}
+
+	resource, rest, _ := strings.Cut(strings.TrimPrefix(path, "/api/"), "/")
+	return strings.ReplaceAll(resource, "-", "_"), rest
+}
+
+// auditResponseWriter keeps the start of error responses to audit their error messages
+type auditResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Write writes the response, keeping it when it is an error
+func (w *auditResponseWriter) Write(data []byte) (int, error) {
+	w.keep(data)
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString writes the response, keeping it when it is an error
+func (w *auditResponseWriter) WriteString(s string) (int, error) {
+	w.keep([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+// keep adds written data to the kept response if it is an error
+func (w *auditResponseWriter) keep(data []byte) {
+	if w.Status() < http.StatusBadRequest || w.body.Len() >= maxAuditedErrorBody {
+		return
+	}
+	if room := maxAuditedErrorBody - w.body.Len(); len(data) > room {
+		data = data[:room]
+	}
+	w.body.Write(data)
+}
+
+// errorMessage returns the error of an error response, which handlers send as {"error": "..."}
+func (w *auditResponseWriter) errorMessage() string {
+	var response struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(w.body.Bytes(), &response) == nil && response.Error != "" {
+		return response.Error
+	}
+	return http.StatusText(w.Status())
+}
@@ -1,22 +1,28 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/phuhao00/cmdb/backend/application"
+	"github.com/phuhao00/cmdb/backend/infrastructure/logging"
+	"go.uber.org/zap"
 )
 
-// AuthMiddleware provides authentication and authorization middleware
+// AuthMiddleware provides authentication and authorization middleware. Requests refused for
+// lacking a permission or role are audited.
 type AuthMiddleware struct {
-	authApp *application.AuthApplication
+	authApp     *application.AuthApplication
+	auditLogApp *application.AuditLogApplication
 }
 
 // NewAuthMiddleware creates a new authentication middleware
-func NewAuthMiddleware(authApp *application.AuthApplication) *AuthMiddleware {
+func NewAuthMiddleware(authApp *application.AuthApplication, auditLogApp *application.AuditLogApplication) *AuthMiddleware {
 	return &AuthMiddleware{
-		authApp: authApp,
+		authApp:     authApp,
+		auditLogApp: auditLogApp,
 	}
 }
 
@@ -52,6 +58,7 @@ func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 		// Set user in context
 		c.Set("user", user)
 		c.Set("userID", user.ID)
+		c.Request = c.Request.WithContext(application.WithUser(c.Request.Context(), user))
 		c.Next()
 	}
 }
@@ -70,8 +77,7 @@ func (m *AuthMiddleware) RequirePermission(resource, action string) gin.HandlerF
 
 		// Check if user has the required permission
 		if !m.hasPermission(userDTO, resource, action) {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
-			c.Abort()
+			m.deny(c, userDTO, resource+":"+action, "Insufficient permissions")
 			return
 		}
 
@@ -93,8 +99,7 @@ func (m *AuthMiddleware) RequireRole(role string) gin.HandlerFunc {
 
 		// Check if user has the required role
 		if userDTO.Role != role && userDTO.Role != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient role privileges"})
-			c.Abort()
+			m.deny(c, userDTO, "role "+role, "Insufficient role privileges")
 			return
 		}
 
@@ -116,8 +121,7 @@ func (m *AuthMiddleware) RequireApprovalPermission() gin.HandlerFunc {
 
 		// Only admin and manager can approve workflows
 		if userDTO.Role != "admin" && userDTO.Role != "manager" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Only administrators and managers can approve workflows"})
-			c.Abort()
+			m.deny(c, userDTO, "role admin or manager", "Only administrators and managers can approve workflows")
 			return
 		}
 
@@ -125,6 +129,22 @@ func (m *AuthMiddleware) RequireApprovalPermission() gin.HandlerFunc {
 	}
 }
 
+// deny refuses a request the user lacks a permission for and audits the attempt
+func (m *AuthMiddleware) deny(c *gin.Context, user *application.UserDTO, permission, message string) {
+	c.JSON(http.StatusForbidden, gin.H{"error": message})
+	c.Abort()
+
+	// AuditMiddleware need not audit the request again
+	c.Set(auditedKey, true)
+	dto := requestAudit(c)
+	if err := m.auditLogApp.LogPermissionDenied(context.WithoutCancel(c.Request.Context()), user, permission, dto); err != nil {
+		logging.Logger.Error("audit_log_write_failed",
+			zap.String("action", "permission_denied"),
+			zap.String("path", dto.Path),
+			zap.Error(err))
+	}
+}
+
 // hasPermission checks if user has the required permission
 func (m *AuthMiddleware) hasPermission(user *application.UserDTO, resource, action string) bool {
 	// Admin has all permissions
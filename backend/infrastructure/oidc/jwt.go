@@ -0,0 +1,163 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// jsonWebKey is a key of an identity provider's JWKS; only RSA and P-256 keys are used
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Kid string `json:"kid,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// jsonWebKeySet is the JWKS an identity provider signs ID tokens with
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+// publicKey decodes the key; keys of other types or for encryption are skipped with an error
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	if k.Use != "" && k.Use != "sig" {
+		return nil, fmt.Errorf("key %q is not a signing key", k.Kid)
+	}
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+// jwtHeader is the JOSE header of an ID token
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+	Kid string `json:"kid,omitempty"`
+}
+
+// parseJWT splits a signed token and decodes its header and claims, without checking the
+// signature
+func parseJWT(token string) (jwtHeader, map[string]interface{}, error) {
+	var header jwtHeader
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return header, nil, errors.New("malformed token")
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return header, nil, errors.New("malformed token header")
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return header, nil, errors.New("malformed token header")
+	}
+
+	data, err = base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return header, nil, errors.New("malformed token claims")
+	}
+	var claims map[string]interface{}
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	// Numbers are kept exact so that times and IDs are not rounded
+	decoder.UseNumber()
+	if err := decoder.Decode(&claims); err != nil {
+		return header, nil, errors.New("malformed token claims")
+	}
+	return header, claims, nil
+}
+
+// verifyJWTSignature checks the signature of a token with a key of the algorithm the header
+// names. Only RS256 and ES256 are accepted, so that a token cannot pick none or a shared secret.
+func verifyJWTSignature(token string, alg string, key crypto.PublicKey) error {
+	index := strings.LastIndex(token, ".")
+	signature, err := base64.RawURLEncoding.DecodeString(token[index+1:])
+	if err != nil {
+		return errors.New("malformed token signature")
+	}
+	digest := sha256.Sum256([]byte(token[:index]))
+
+	switch alg {
+	case "RS256":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("RS256 token signed with a key that is not RSA")
+		}
+		if err := rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, digest[:], signature); err != nil {
+			return errors.New("token signature does not match")
+		}
+	case "ES256":
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return errors.New("ES256 token signed with a key that is not EC")
+		}
+		if len(signature) != 64 {
+			return errors.New("malformed token signature")
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(ecKey, digest[:], r, s) {
+			return errors.New("token signature does not match")
+		}
+	default:
+		return fmt.Errorf("unsupported token algorithm %q", alg)
+	}
+	return nil
+}
+
+// signJWT signs claims with an RSA key as an RS256 token
+func signJWT(key *rsa.PrivateKey, kid string, claims interface{}) (string, error) {
+	header, err := json.Marshal(jwtHeader{Alg: "RS256", Typ: "JWT", Kid: kid})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
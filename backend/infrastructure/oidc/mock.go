@@ -0,0 +1,346 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"html/template"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// mockCodeLifetime bounds how long an authorization code of the mock provider can be redeemed
+const mockCodeLifetime = time.Minute
+
+// mockTokenLifetime is how long the ID and access tokens of the mock provider are valid
+const mockTokenLifetime = 5 * time.Minute
+
+// mockUser is who signed in at the mock provider
+type mockUser struct {
+	Username string
+	Email    string
+	Name     string
+	Groups   []string
+}
+
+// claims returns the claims of the user as an ID token or userinfo carries them
+func (u mockUser) claims() map[string]interface{} {
+	return map[string]interface{}{
+		"sub":                u.Username,
+		"preferred_username": u.Username,
+		"email":              u.Email,
+		"email_verified":     true,
+		"name":               u.Name,
+		"groups":             u.Groups,
+	}
+}
+
+// mockCode is an authorization code waiting to be redeemed
+type mockCode struct {
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+	user          mockUser
+	expiresAt     time.Time
+}
+
+// MockProvider is an in-process OpenID Connect identity provider for development and testing. It
+// signs in anyone under any username and groups, entered in a form or passed as the username and
+// groups parameters of the authorization request, and enforces PKCE like a real provider would.
+type MockProvider struct {
+	issuer   string
+	clientID string
+	key      *rsa.PrivateKey
+	keyID    string
+
+	mu           sync.Mutex
+	codes        map[string]mockCode
+	accessTokens map[string]mockUser
+}
+
+// NewMockProvider creates a mock identity provider of an issuer for a client. It serves the
+// discovery document, authorization, token, JWKS and userinfo endpoints under the issuer's path.
+func NewMockProvider(issuer, clientID string) (*MockProvider, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	thumbprint := sha256.Sum256(key.PublicKey.N.Bytes())
+	return &MockProvider{
+		issuer:       strings.TrimSuffix(issuer, "/"),
+		clientID:     clientID,
+		key:          key,
+		keyID:        base64.RawURLEncoding.EncodeToString(thumbprint[:8]),
+		codes:        make(map[string]mockCode),
+		accessTokens: make(map[string]mockUser),
+	}, nil
+}
+
+// Issuer returns the issuer the provider's tokens name
+func (m *MockProvider) Issuer() string {
+	return m.issuer
+}
+
+// ServeHTTP serves the endpoints of the provider, relative to the issuer's path
+func (m *MockProvider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch strings.TrimSuffix(r.URL.Path, "/") {
+	case "/.well-known/openid-configuration":
+		m.serveDiscovery(w)
+	case "/authorize":
+		m.serveAuthorize(w, r)
+	case "/token":
+		m.serveToken(w, r)
+	case "/jwks":
+		m.serveJWKS(w)
+	case "/userinfo":
+		m.serveUserinfo(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (m *MockProvider) serveDiscovery(w http.ResponseWriter) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                m.issuer,
+		"authorization_endpoint":                m.issuer + "/authorize",
+		"token_endpoint":                        m.issuer + "/token",
+		"userinfo_endpoint":                     m.issuer + "/userinfo",
+		"jwks_uri":                              m.issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+		"scopes_supported":                      []string{"openid", "profile", "email", "groups"},
+	})
+}
+
+func (m *MockProvider) serveJWKS(w http.ResponseWriter) {
+	writeJSON(w, http.StatusOK, jsonWebKeySet{Keys: []jsonWebKey{{
+		Kty: "RSA",
+		Use: "sig",
+		Alg: "RS256",
+		Kid: m.keyID,
+		N:   base64.RawURLEncoding.EncodeToString(m.key.PublicKey.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(m.key.PublicKey.E)).Bytes()),
+	}}})
+}
+
+// mockLoginPage asks who to sign in as, carrying the authorization request along
+var mockLoginPage = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Mock identity provider</title></head>
+<body style="font-family: sans-serif; max-width: 24em; margin: 4em auto">
+<h2>Mock identity provider</h2>
+<p>Sign in as any user. Groups are separated by commas.</p>
+<form method="get" action="">
+{{range $name, $values := .}}{{range $values}}<input type="hidden" name="{{$name}}" value="{{.}}">
+{{end}}{{end}}<p><label>Username<br><input name="username" required autofocus></label></p>
+<p><label>Email<br><input name="email" type="email"></label></p>
+<p><label>Groups<br><input name="groups" placeholder="cmdb-admins"></label></p>
+<p><button type="submit">Sign in</button></p>
+</form>
+</body>
+</html>
+`))
+
+func (m *MockProvider) serveAuthorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	redirectURI := query.Get("redirect_uri")
+	if query.Get("client_id") != m.clientID || redirectURI == "" {
+		http.Error(w, "unknown client or missing redirect_uri", http.StatusBadRequest)
+		return
+	}
+	if query.Get("response_type") != "code" {
+		redirectError(w, r, redirectURI, query.Get("state"), "unsupported_response_type")
+		return
+	}
+	if query.Get("code_challenge") == "" || query.Get("code_challenge_method") != "S256" {
+		redirectError(w, r, redirectURI, query.Get("state"), "invalid_request")
+		return
+	}
+
+	username := strings.TrimSpace(query.Get("username"))
+	if username == "" {
+		for _, field := range []string{"username", "email", "groups"} {
+			query.Del(field)
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		mockLoginPage.Execute(w, query)
+		return
+	}
+
+	user := mockUser{Username: username, Email: strings.TrimSpace(query.Get("email")), Name: username}
+	if user.Email == "" {
+		user.Email = username + "@example.com"
+	}
+	for _, group := range strings.Split(query.Get("groups"), ",") {
+		if group = strings.TrimSpace(group); group != "" {
+			user.Groups = append(user.Groups, group)
+		}
+	}
+
+	code := randomToken()
+	m.mu.Lock()
+	m.codes[code] = mockCode{
+		redirectURI:   redirectURI,
+		nonce:         query.Get("nonce"),
+		codeChallenge: query.Get("code_challenge"),
+		user:          user,
+		expiresAt:     time.Now().Add(mockCodeLifetime),
+	}
+	m.mu.Unlock()
+
+	http.Redirect(w, r, withQuery(redirectURI, url.Values{"code": {code}, "state": {query.Get("state")}}), http.StatusFound)
+}
+
+func (m *MockProvider) serveToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		tokenError(w, "invalid_request", err.Error())
+		return
+	}
+
+	clientID := r.PostForm.Get("client_id")
+	if username, _, ok := r.BasicAuth(); ok {
+		clientID, _ = url.QueryUnescape(username)
+	}
+	if clientID != m.clientID {
+		tokenError(w, "invalid_client", "unknown client")
+		return
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		tokenError(w, "unsupported_grant_type", "only authorization_code is supported")
+		return
+	}
+
+	// A code is redeemed once, whether or not the request is valid
+	m.mu.Lock()
+	code, ok := m.codes[r.PostForm.Get("code")]
+	delete(m.codes, r.PostForm.Get("code"))
+	m.mu.Unlock()
+	if !ok || time.Now().After(code.expiresAt) {
+		tokenError(w, "invalid_grant", "unknown or expired code")
+		return
+	}
+	if r.PostForm.Get("redirect_uri") != code.redirectURI {
+		tokenError(w, "invalid_grant", "redirect_uri does not match")
+		return
+	}
+	challenge := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if subtle.ConstantTimeCompare([]byte(base64.RawURLEncoding.EncodeToString(challenge[:])), []byte(code.codeChallenge)) != 1 {
+		tokenError(w, "invalid_grant", "code_verifier does not match the code_challenge")
+		return
+	}
+
+	now := time.Now()
+	claims := code.user.claims()
+	claims["iss"] = m.issuer
+	claims["aud"] = m.clientID
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(mockTokenLifetime).Unix()
+	if code.nonce != "" {
+		claims["nonce"] = code.nonce
+	}
+	idToken, err := signJWT(m.key, m.keyID, claims)
+	if err != nil {
+		tokenError(w, "server_error", err.Error())
+		return
+	}
+
+	accessToken := randomToken()
+	m.mu.Lock()
+	m.accessTokens[accessToken] = code.user
+	m.mu.Unlock()
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   int(mockTokenLifetime.Seconds()),
+		"id_token":     idToken,
+	})
+}
+
+func (m *MockProvider) serveUserinfo(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	user, ok := m.accessTokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+	m.mu.Unlock()
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_token"})
+		return
+	}
+	writeJSON(w, http.StatusOK, user.claims())
+}
+
+// MockServer runs a mock identity provider on a local port, with its own issuer
+type MockServer struct {
+	*MockProvider
+	server *http.Server
+}
+
+// StartMockProvider starts a mock identity provider for a client on a free local port
+func StartMockProvider(clientID string) (*MockServer, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	provider, err := NewMockProvider("http://"+listener.Addr().String(), clientID)
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	server := &http.Server{Handler: provider, ReadHeaderTimeout: 10 * time.Second}
+	go server.Serve(listener)
+	return &MockServer{MockProvider: provider, server: server}, nil
+}
+
+// URL returns the address of the provider, which is also its issuer
+func (s *MockServer) URL() string {
+	return s.issuer
+}
+
+// Close stops the provider
+func (s *MockServer) Close() error {
+	return s.server.Close()
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func tokenError(w http.ResponseWriter, code, description string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{"error": code, "error_description": description})
+}
+
+func redirectError(w http.ResponseWriter, r *http.Request, redirectURI, state, code string) {
+	http.Redirect(w, r, withQuery(redirectURI, url.Values{"error": {code}, "state": {state}}), http.StatusFound)
+}
+
+// withQuery adds parameters to an address that may already have some
+func withQuery(address string, values url.Values) string {
+	if strings.Contains(address, "?") {
+		return address + "&" + values.Encode()
+	}
+	return address + "?" + values.Encode()
+}
+
+func randomToken() string {
+	data := make([]byte, 32)
+	rand.Read(data)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
@@ -0,0 +1,399 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+)
+
+// clockSkew is how far the identity provider's clock may be off
+const clockSkew = time.Minute
+
+// keyRefreshInterval bounds how often the JWKS is fetched again for a token signed by an unknown
+// key, such as after the identity provider rotated its keys
+const keyRefreshInterval = time.Minute
+
+// maxResponseBytes bounds the responses read from the identity provider
+const maxResponseBytes = 1 << 20
+
+// discoveryDocument holds the parts of the OpenID Provider metadata the sign-in uses
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// tokenResponse is the answer of the token endpoint to an authorization code
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Provider signs users in with an OpenID Connect identity provider, found through its discovery
+// document, using the authorization code flow with PKCE
+type Provider struct {
+	config     model.OIDCConfig
+	httpClient *http.Client
+
+	mu            sync.Mutex
+	discovery     *discoveryDocument
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+}
+
+// NewProvider creates a new identity provider client. The discovery document is fetched on the
+// first sign-in, so that the CMDB starts while the identity provider is down.
+func NewProvider(config model.OIDCConfig, timeout time.Duration) *Provider {
+	return &Provider{
+		config:     config,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// AuthCodeURL returns the authorization endpoint address the user signs in at
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.config.ClientID},
+		"redirect_uri":          {p.config.RedirectURL},
+		"scope":                 {strings.Join(p.config.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+	}
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return discovery.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Exchange redeems an authorization code and returns the identity of the verified ID token. The
+// groups claim is read from the userinfo endpoint when the ID token does not carry it.
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*model.OIDCIdentity, error) {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURL},
+		"code_verifier": {codeVerifier},
+	}
+	if p.config.ClientSecret == "" {
+		form.Set("client_id", p.config.ClientID)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.config.ClientSecret != "" {
+		// client_secret_basic encodes the credentials before joining them
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	var token tokenResponse
+	status, err := p.doJSON(req, &token)
+	if err != nil {
+		return nil, err
+	}
+	if token.Error != "" {
+		return nil, fmt.Errorf("token request failed: %s %s", token.Error, token.ErrorDescription)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("token request failed with status %d", status)
+	}
+	if token.IDToken == "" {
+		return nil, errors.New("token response has no ID token")
+	}
+
+	claims, err := p.verifyIDToken(ctx, discovery, token.IDToken, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	if claimValue(claims, p.config.GroupsClaim) == nil && discovery.UserinfoEndpoint != "" && token.AccessToken != "" {
+		userinfo, err := p.userinfo(ctx, discovery, token.AccessToken)
+		if err != nil {
+			return nil, err
+		}
+		// Userinfo only adds to the ID token, and only for the same user
+		if userinfo["sub"] == claims["sub"] {
+			for name, value := range userinfo {
+				if _, ok := claims[name]; !ok {
+					claims[name] = value
+				}
+			}
+		}
+	}
+
+	return p.identity(discovery.Issuer, claims), nil
+}
+
+// verifyIDToken checks the signature, issuer, audience, expiry and nonce of an ID token and
+// returns its claims
+func (p *Provider) verifyIDToken(ctx context.Context, discovery *discoveryDocument, idToken, nonce string) (map[string]interface{}, error) {
+	header, claims, err := parseJWT(idToken)
+	if err != nil {
+		return nil, err
+	}
+	key, err := p.key(ctx, discovery, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyJWTSignature(idToken, header.Alg, key); err != nil {
+		return nil, err
+	}
+
+	if claims["iss"] != discovery.Issuer {
+		return nil, fmt.Errorf("ID token issued by %v", claims["iss"])
+	}
+	if sub, _ := claims["sub"].(string); sub == "" {
+		return nil, errors.New("ID token has no subject")
+	}
+	audiences := stringValues(claims["aud"])
+	if !contains(audiences, p.config.ClientID) {
+		return nil, errors.New("ID token is not for this client")
+	}
+	if len(audiences) > 1 && claims["azp"] != nil && claims["azp"] != p.config.ClientID {
+		return nil, errors.New("ID token was issued to another client")
+	}
+	if claims["nonce"] != nonce {
+		return nil, errors.New("ID token nonce does not match")
+	}
+
+	now := time.Now()
+	expiresAt, ok := numericDate(claims["exp"])
+	if !ok || !now.Add(-clockSkew).Before(expiresAt) {
+		return nil, errors.New("ID token expired")
+	}
+	if issuedAt, ok := numericDate(claims["iat"]); !ok || issuedAt.After(now.Add(clockSkew)) {
+		return nil, errors.New("ID token issue time is missing or in the future")
+	}
+	return claims, nil
+}
+
+// identity reads the user from verified claims
+func (p *Provider) identity(issuer string, claims map[string]interface{}) *model.OIDCIdentity {
+	identity := &model.OIDCIdentity{Issuer: issuer}
+	identity.Subject, _ = claims["sub"].(string)
+	identity.Username, _ = claimValue(claims, p.config.UsernameClaim).(string)
+	identity.Email, _ = claims["email"].(string)
+	identity.Name, _ = claims["name"].(string)
+	identity.Groups = stringValues(claimValue(claims, p.config.GroupsClaim))
+
+	// An unverified email could be anyone's; it is not used to match or create accounts
+	if verified, ok := claims["email_verified"].(bool); ok && !verified {
+		identity.Email = ""
+	}
+	if identity.Username == "" {
+		identity.Username = identity.Email
+	}
+	if identity.Name == "" {
+		identity.Name = identity.Username
+	}
+	return identity
+}
+
+// discover fetches the discovery document of the issuer once
+func (p *Provider) discover(ctx context.Context) (*discoveryDocument, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	address := strings.TrimSuffix(p.config.Issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, address, nil)
+	if err != nil {
+		return nil, err
+	}
+	var discovery discoveryDocument
+	status, err := p.doJSON(req, &discovery)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("discovery request to %s failed with status %d", address, status)
+	}
+	if discovery.Issuer != p.config.Issuer {
+		return nil, fmt.Errorf("discovery document is for issuer %q, not %q", discovery.Issuer, p.config.Issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, errors.New("discovery document lacks the authorization, token or JWKS endpoint")
+	}
+
+	p.discovery = &discovery
+	return p.discovery, nil
+}
+
+// key returns the signing key of an ID token, fetching the JWKS again when the key is unknown
+func (p *Provider) key(ctx context.Context, discovery *discoveryDocument, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key := p.lookupKey(kid); key != nil {
+		return key, nil
+	}
+	if time.Since(p.keysFetchedAt) < keyRefreshInterval {
+		return nil, fmt.Errorf("ID token signed by unknown key %q", kid)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discovery.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	var keySet jsonWebKeySet
+	status, err := p.doJSON(req, &keySet)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("JWKS request failed with status %d", status)
+	}
+
+	p.keys = make(map[string]crypto.PublicKey)
+	for _, jwk := range keySet.Keys {
+		// Keys the CMDB cannot use are skipped rather than failing the others
+		if key, err := jwk.publicKey(); err == nil {
+			p.keys[jwk.Kid] = key
+		}
+	}
+	p.keysFetchedAt = time.Now()
+
+	if key := p.lookupKey(kid); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("ID token signed by unknown key %q", kid)
+}
+
+// lookupKey finds a key by ID; a token without a key ID may use the only key there is
+func (p *Provider) lookupKey(kid string) crypto.PublicKey {
+	if key, ok := p.keys[kid]; ok {
+		return key
+	}
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key
+		}
+	}
+	return nil
+}
+
+// userinfo fetches the claims of the userinfo endpoint
+func (p *Provider) userinfo(ctx context.Context, discovery *discoveryDocument, accessToken string) (map[string]interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discovery.UserinfoEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	var claims map[string]interface{}
+	status, err := p.doJSON(req, &claims)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("userinfo request failed with status %d", status)
+	}
+	return claims, nil
+}
+
+// doJSON sends a request and decodes its JSON response, whatever the status
+func (p *Provider) doJSON(req *http.Request, v interface{}) (int, error) {
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if err := json.Unmarshal(data, v); err != nil && resp.StatusCode == http.StatusOK {
+		return resp.StatusCode, fmt.Errorf("invalid response from %s: %w", req.URL.Host, err)
+	}
+	return resp.StatusCode, nil
+}
+
+// claimValue reads a claim by name; a dotted name reads a nested claim
+func claimValue(claims map[string]interface{}, name string) interface{} {
+	if value, ok := claims[name]; ok {
+		return value
+	}
+	var value interface{} = claims
+	for _, part := range strings.Split(name, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[part]
+	}
+	return value
+}
+
+// stringValues reads a claim that is a string or a list of strings
+func stringValues(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var values []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// numericDate reads a time claim, in seconds since the epoch
+func numericDate(value interface{}) (time.Time, bool) {
+	number, ok := value.(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	seconds, err := number.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(seconds), 0), true
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,232 @@
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+)
+
+const (
+	testClientID    = "cmdb"
+	testRedirectURL = "https://cmdb.example.com/api/v1/auth/oidc/callback"
+)
+
+// startMockProvider starts a mock identity provider and a client of it
+func startMockProvider(t *testing.T) (*MockServer, *Provider) {
+	t.Helper()
+	server, err := StartMockProvider(testClientID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Close() })
+
+	provider := NewProvider(model.OIDCConfig{
+		Issuer:        server.URL(),
+		ClientID:      testClientID,
+		RedirectURL:   testRedirectURL,
+		Scopes:        []string{"openid", "profile", "email", "groups"},
+		UsernameClaim: "preferred_username",
+		GroupsClaim:   "groups",
+	}, 5*time.Second)
+	return server, provider
+}
+
+// codeChallenge returns the S256 PKCE challenge of a verifier
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// authorize follows an authorization address as a browser signing in as a user would, and
+// returns the query of the callback the provider redirects to
+func authorize(t *testing.T, authURL, username, groups string) url.Values {
+	t.Helper()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL + "&" + url.Values{"username": {username}, "groups": {groups}}.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorization request answered %s, want a redirect", resp.Status)
+	}
+
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(callback.String(), testRedirectURL+"?") {
+		t.Fatalf("redirected to %s, want the redirect URL", callback)
+	}
+	return callback.Query()
+}
+
+func TestProviderSignIn(t *testing.T) {
+	server, provider := startMockProvider(t)
+	ctx := context.Background()
+
+	authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", codeChallenge("verifier-1"))
+	if err != nil {
+		t.Fatalf("AuthCodeURL() error = %v", err)
+	}
+	request, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{
+		"client_id":             testClientID,
+		"redirect_uri":          testRedirectURL,
+		"state":                 "state-1",
+		"nonce":                 "nonce-1",
+		"code_challenge":        codeChallenge("verifier-1"),
+		"code_challenge_method": "S256",
+	} {
+		if got := request.Query().Get(name); got != want {
+			t.Errorf("authorization request %s = %q, want %q", name, got, want)
+		}
+	}
+
+	callback := authorize(t, authURL, "alice", "cmdb-ops, cmdb-viewers")
+	if callback.Get("state") != "state-1" {
+		t.Errorf("callback state = %q, want state-1", callback.Get("state"))
+	}
+
+	identity, err := provider.Exchange(ctx, callback.Get("code"), "verifier-1", "nonce-1")
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+	want := &model.OIDCIdentity{
+		Issuer:   server.URL(),
+		Subject:  "alice",
+		Username: "alice",
+		Email:    "alice@example.com",
+		Name:     "alice",
+		Groups:   []string{"cmdb-ops", "cmdb-viewers"},
+	}
+	if !reflect.DeepEqual(identity, want) {
+		t.Errorf("Exchange() = %+v, want %+v", identity, want)
+	}
+
+	// A code is only redeemed once
+	if _, err := provider.Exchange(ctx, callback.Get("code"), "verifier-1", "nonce-1"); err == nil || !strings.Contains(err.Error(), "invalid_grant") {
+		t.Errorf("second Exchange() error = %v, want invalid_grant", err)
+	}
+}
+
+func TestProviderExchangeChecksVerifierAndNonce(t *testing.T) {
+	_, provider := startMockProvider(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		verifier string
+		nonce    string
+		want     string
+	}{
+		{"another verifier", "verifier-2", "nonce-1", "code_verifier does not match"},
+		{"no verifier", "", "nonce-1", "code_verifier does not match"},
+		{"another nonce", "verifier-1", "nonce-2", "nonce does not match"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", codeChallenge("verifier-1"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			callback := authorize(t, authURL, "mallory", "")
+
+			_, err = provider.Exchange(ctx, callback.Get("code"), tt.verifier, tt.nonce)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Exchange() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMockProviderRequiresS256Challenge(t *testing.T) {
+	server, _ := startMockProvider(t)
+
+	for name, query := range map[string]url.Values{
+		"no challenge":    {},
+		"plain challenge": {"code_challenge": {"verifier-1"}, "code_challenge_method": {"plain"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			query.Set("response_type", "code")
+			query.Set("client_id", testClientID)
+			query.Set("redirect_uri", testRedirectURL)
+			query.Set("state", "state-1")
+
+			callback := authorize(t, server.URL()+"/authorize?"+query.Encode(), "alice", "")
+			if callback.Get("error") != "invalid_request" || callback.Get("code") != "" {
+				t.Errorf("callback = %v, want invalid_request and no code", callback)
+			}
+		})
+	}
+}
+
+func TestProviderGroupMapping(t *testing.T) {
+	_, provider := startMockProvider(t)
+	ctx := context.Background()
+
+	config := model.OIDCConfig{GroupMappings: []model.OIDCGroupMapping{
+		{Group: "cmdb-admins", Role: model.AdminRole},
+		{Group: "cmdb-ops", Role: model.OperatorRole, Permissions: []model.Permission{{Resource: "workflows", Actions: []string{"approve"}}}},
+		{Group: "cmdb-viewers", Role: model.ViewerRole},
+	}}
+
+	tests := []struct {
+		name        string
+		groups      string
+		defaultRole model.UserRole
+		role        model.UserRole
+		err         error
+	}{
+		{"highest role of the groups", "cmdb-viewers,cmdb-admins", "", model.AdminRole, nil},
+		{"one group", "cmdb-ops", "", model.OperatorRole, nil},
+		{"unmapped groups get the default role", "sales", model.ViewerRole, model.ViewerRole, nil},
+		{"unmapped groups without a default role", "sales", "", "", model.ErrSSOUserNotAllowed},
+		{"no groups", "", "", "", model.ErrSSOUserNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", codeChallenge("verifier-1"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			callback := authorize(t, authURL, "bob", tt.groups)
+			identity, err := provider.Exchange(ctx, callback.Get("code"), "verifier-1", "nonce-1")
+			if err != nil {
+				t.Fatalf("Exchange() error = %v", err)
+			}
+
+			config.DefaultRole = tt.defaultRole
+			role, permissions, err := config.MapGroups(identity.Groups)
+			if !errors.Is(err, tt.err) || role != tt.role {
+				t.Fatalf("MapGroups(%v) = %q, %v; want %q, %v", identity.Groups, role, err, tt.role, tt.err)
+			}
+			if role == model.OperatorRole && !hasPermission(permissions, "workflows", "approve") {
+				t.Errorf("permissions of cmdb-ops = %+v, want workflow approval added", permissions)
+			}
+		})
+	}
+}
+
+func hasPermission(permissions []model.Permission, resource, action string) bool {
+	for _, permission := range permissions {
+		if permission.Resource == resource && contains(permission.Actions, action) {
+			return true
+		}
+	}
+	return false
+}
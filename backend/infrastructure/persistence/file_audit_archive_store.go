@@ -0,0 +1,101 @@
+package persistence
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+)
+
+// FileAuditArchiveStore implements AuditArchiveStore by writing each archived segment to a
+// gzipped file of JSON lines in a directory
+type FileAuditArchiveStore struct {
+	dir string
+}
+
+// NewFileAuditArchiveStore creates a new audit archive store in a directory
+func NewFileAuditArchiveStore(dir string) *FileAuditArchiveStore {
+	return &FileAuditArchiveStore{
+		dir: dir,
+	}
+}
+
+// Write stores the entries of a segment, one JSON object per line, and returns the file's path.
+// The file is only put in place once it is complete.
+func (s *FileAuditArchiveStore) Write(ctx context.Context, archive *model.AuditArchive, entries []*model.AuditLog) (string, error) {
+	if err := os.MkdirAll(s.dir, 0o750); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(s.dir, fmt.Sprintf("audit-%d-%d.jsonl.gz", archive.FromSequence, archive.ToSequence))
+	file, err := os.CreateTemp(s.dir, ".audit-*.tmp")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	writer := gzip.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		if err := encoder.Encode(entry); err != nil {
+			return "", err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+	if err := file.Sync(); err != nil {
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(file.Name(), path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Read decodes the entries of a segment from the file Write stored them in. Numbers are kept as
+// written, so integers too large for a float64 still hash as they did when archived.
+func (s *FileAuditArchiveStore) Read(ctx context.Context, archive *model.AuditArchive) ([]*model.AuditLog, error) {
+	file, err := os.Open(archive.Location)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", archive.Location, err)
+	}
+	defer reader.Close()
+
+	var entries []*model.AuditLog
+	decoder := json.NewDecoder(reader)
+	decoder.UseNumber()
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var entry model.AuditLog
+		err := decoder.Decode(&entry)
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", archive.Location, err)
+		}
+		entries = append(entries, &entry)
+	}
+}
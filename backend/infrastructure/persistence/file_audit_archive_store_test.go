@@ -0,0 +1,87 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+)
+
+func TestFileAuditArchiveStoreRoundTrip(t *testing.T) {
+	store := NewFileAuditArchiveStore(filepath.Join(t.TempDir(), "archive"))
+	ctx := context.Background()
+
+	// Values are chosen to survive JSON the way BSON stored them: nested documents, arrays,
+	// integers past what a float64 holds exactly and fractions
+	var entries []*model.AuditLog
+	previousHash := ""
+	for sequence := int64(1); sequence <= 3; sequence++ {
+		entry := model.NewAuditLog("u1", "admin", model.AssetUpdated, "asset", "a1", "web", "changed web")
+		entry.Timestamp = time.Date(2026, 3, 1, 12, 0, 0, 123456789, time.UTC)
+		entry.SetChanges(
+			map[string]interface{}{"status": "offline", "cost": 12.5},
+			map[string]interface{}{
+				"status": "online",
+				"ports":  []interface{}{int32(22), int32(443)},
+				"bytes":  int64(1<<60 + 1),
+				"owner":  map[string]interface{}{"team": "ops", "oncall": true},
+			},
+		)
+		entry.SetRequest("PUT", "/api/v1/assets/a1", 200)
+		if err := entry.Chain(sequence, previousHash); err != nil {
+			t.Fatal(err)
+		}
+		previousHash = entry.Hash
+		entries = append(entries, entry)
+	}
+
+	archive := &model.AuditArchive{FromSequence: 1, ToSequence: 3}
+	location, err := store.Write(ctx, archive, entries)
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	archive.Location = location
+
+	read, err := store.Read(ctx, archive)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if len(read) != len(entries) {
+		t.Fatalf("Read() = %d entries, want %d", len(read), len(entries))
+	}
+	for i, entry := range read {
+		hash, err := entry.ComputeHash()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entry.Sequence != entries[i].Sequence || entry.Hash != entries[i].Hash || hash != entry.Hash {
+			t.Errorf("entry %d read back as sequence %d hashing to %s, want sequence %d hashing to %s",
+				i, entry.Sequence, hash, entries[i].Sequence, entries[i].Hash)
+		}
+	}
+}
+
+func TestFileAuditArchiveStoreReadErrors(t *testing.T) {
+	dir := t.TempDir()
+	store := NewFileAuditArchiveStore(dir)
+	ctx := context.Background()
+
+	_, err := store.Read(ctx, &model.AuditArchive{Location: filepath.Join(dir, "gone.jsonl.gz")})
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Read() of a missing file error = %v, want fs.ErrNotExist", err)
+	}
+
+	corrupt := filepath.Join(dir, "corrupt.jsonl.gz")
+	if err := os.WriteFile(corrupt, []byte("not gzip"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, err = store.Read(ctx, &model.AuditArchive{Location: corrupt})
+	if err == nil || errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Read() of a corrupt file error = %v, want a read error", err)
+	}
+}
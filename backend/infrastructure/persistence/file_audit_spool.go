@@ -0,0 +1,303 @@
+package persistence
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/repository"
+)
+
+// FileAuditSpool implements AuditSpool with a file of JSON lines in a directory. Sent audit
+// logs are skipped by an offset kept next to it, and dropped from the file once it is drained
+// or more than half of it was sent. Lines that do not decode are moved to a rejected file
+// next to it rather than holding up the audit logs behind them.
+type FileAuditSpool struct {
+	mu           sync.Mutex
+	path         string
+	offsetPath   string
+	rejectedPath string
+	maxBytes     int64
+}
+
+// NewFileAuditSpool creates the spool of a sink in a directory, holding up to maxBytes
+func NewFileAuditSpool(dir, name string, maxBytes int64) (*FileAuditSpool, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+
+	return &FileAuditSpool{
+		path:         filepath.Join(dir, name+".jsonl"),
+		offsetPath:   filepath.Join(dir, name+".offset"),
+		rejectedPath: filepath.Join(dir, name+".rejected"),
+		maxBytes:     maxBytes,
+	}, nil
+}
+
+// Append adds audit logs to the end of the spool
+func (s *FileAuditSpool) Append(ctx context.Context, logs []*model.AuditLog) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	for _, log := range logs {
+		if err := encoder.Encode(log); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	size, err := trimTornLine(file)
+	if err != nil {
+		return err
+	}
+	if size+int64(data.Len()) > s.maxBytes {
+		return repository.ErrAuditSpoolFull
+	}
+
+	if _, err := file.Write(data.Bytes()); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+// Peek returns up to limit audit logs from the start of the spool without removing them
+func (s *FileAuditSpool) Peek(ctx context.Context, limit int) ([]*model.AuditLog, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var logs []*model.AuditLog
+	err := s.readLines(func(line []byte) bool {
+		if log, err := decodeSpooledLog(line); err == nil {
+			logs = append(logs, log)
+		}
+		return len(logs) < limit
+	})
+	return logs, err
+}
+
+// Discard removes audit logs from the start of the spool once they were sent
+func (s *FileAuditSpool) Discard(ctx context.Context, count int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	offset, err := s.offset()
+	if err != nil {
+		return err
+	}
+	// Lines Peek skipped are counted past but not as audit logs
+	var rejected bytes.Buffer
+	discarded := 0
+	err = s.readLines(func(line []byte) bool {
+		if discarded >= count {
+			return false
+		}
+		offset += int64(len(line)) + 1
+		if _, err := decodeSpooledLog(line); err != nil {
+			rejected.Write(line)
+			rejected.WriteByte('\n')
+		} else {
+			discarded++
+		}
+		return discarded < count
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.reject(rejected.Bytes()); err != nil {
+		return err
+	}
+
+	size, err := s.size()
+	if err != nil {
+		return err
+	}
+	switch {
+	case offset >= size:
+		if err := os.Remove(s.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return s.writeOffset(0)
+	case offset > size/2:
+		return s.compact(offset)
+	}
+	return s.writeOffset(offset)
+}
+
+// readLines calls fn with the complete lines after the offset until it returns false. A line
+// cut short by a crash while it was appended is ignored.
+func (s *FileAuditSpool) readLines(fn func(line []byte) bool) error {
+	offset, err := s.offset()
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if !fn(bytes.TrimSuffix(line, []byte("\n"))) {
+			return nil
+		}
+	}
+}
+
+// decodeSpooledLog decodes a line of the spool
+func decodeSpooledLog(line []byte) (*model.AuditLog, error) {
+	var log model.AuditLog
+	if err := json.Unmarshal(line, &log); err != nil {
+		return nil, err
+	}
+	return &log, nil
+}
+
+// reject appends lines that do not decode to the rejected file, where they can be looked into
+func (s *FileAuditSpool) reject(lines []byte) error {
+	if len(lines) == 0 {
+		return nil
+	}
+
+	file, err := os.OpenFile(s.rejectedPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err := file.Write(lines); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+// trimTornLine truncates a line cut short by a crash while it was appended, which the next
+// append would otherwise run into, and returns the size of the spool without it
+func trimTornLine(file *os.File) (int64, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	// Searches back from the end for the last complete line
+	size := info.Size()
+	buf := make([]byte, 4096)
+	for end := size; end > 0; {
+		start := end - int64(len(buf))
+		if start < 0 {
+			start = 0
+		}
+		chunk := buf[:end-start]
+		if _, err := file.ReadAt(chunk, start); err != nil {
+			return 0, err
+		}
+		if i := bytes.LastIndexByte(chunk, '\n'); i >= 0 {
+			lineEnd := start + int64(i) + 1
+			if lineEnd == size {
+				return size, nil
+			}
+			return lineEnd, file.Truncate(lineEnd)
+		}
+		end = start
+	}
+	if size == 0 {
+		return 0, nil
+	}
+	return 0, file.Truncate(0)
+}
+
+// compact rewrites the spool without the audit logs before an offset
+func (s *FileAuditSpool) compact(offset int64) error {
+	source, err := os.Open(s.path)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	if _, err := source.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	target, err := os.CreateTemp(filepath.Dir(s.path), ".spool-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(target.Name())
+	defer target.Close()
+
+	if _, err := io.Copy(target, source); err != nil {
+		return err
+	}
+	if err := target.Sync(); err != nil {
+		return err
+	}
+	if err := target.Close(); err != nil {
+		return err
+	}
+
+	// The offset is reset first: should the rename fail, audit logs are sent twice, not lost
+	if err := s.writeOffset(0); err != nil {
+		return err
+	}
+	return os.Rename(target.Name(), s.path)
+}
+
+func (s *FileAuditSpool) size() (int64, error) {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+func (s *FileAuditSpool) offset() (int64, error) {
+	data, err := os.ReadFile(s.offsetPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+}
+
+func (s *FileAuditSpool) writeOffset(offset int64) error {
+	temp := s.offsetPath + ".tmp"
+	if err := os.WriteFile(temp, []byte(strconv.FormatInt(offset, 10)+"\n"), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(temp, s.offsetPath)
+}
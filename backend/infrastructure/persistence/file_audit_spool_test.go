@@ -0,0 +1,242 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/repository"
+)
+
+func spooledLogs(from, to int64) []*model.AuditLog {
+	var logs []*model.AuditLog
+	for sequence := from; sequence <= to; sequence++ {
+		log := model.NewAuditLog("u1", "admin", model.AssetUpdated, "asset", "a1", "web", "changed web")
+		log.Sequence = sequence
+		logs = append(logs, log)
+	}
+	return logs
+}
+
+// peekSequences returns the sequences of up to limit spooled audit logs
+func peekSequences(t *testing.T, spool *FileAuditSpool, limit int) []int64 {
+	t.Helper()
+	logs, err := spool.Peek(context.Background(), limit)
+	if err != nil {
+		t.Fatalf("Peek() error = %v", err)
+	}
+	sequences := []int64{}
+	for _, log := range logs {
+		sequences = append(sequences, log.Sequence)
+	}
+	return sequences
+}
+
+func equalSequences(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestFileAuditSpoolOrderAndDiscard(t *testing.T) {
+	dir := t.TempDir()
+	spool, err := NewFileAuditSpool(dir, "siem", 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if got := peekSequences(t, spool, 10); len(got) != 0 {
+		t.Fatalf("Peek() on an empty spool = %v", got)
+	}
+	for _, batch := range [][]*model.AuditLog{spooledLogs(1, 3), spooledLogs(4, 6), spooledLogs(7, 8)} {
+		if err := spool.Append(ctx, batch); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	if got, want := peekSequences(t, spool, 5), []int64{1, 2, 3, 4, 5}; !equalSequences(got, want) {
+		t.Fatalf("Peek(5) = %v, want %v", got, want)
+	}
+
+	// Less than half is sent, so the file stays and the offset skips the sent audit logs
+	size, _ := spool.size()
+	if err := spool.Discard(ctx, 2); err != nil {
+		t.Fatalf("Discard() error = %v", err)
+	}
+	if got, _ := spool.size(); got != size {
+		t.Errorf("size after a small discard = %d, want %d", got, size)
+	}
+	if offset, _ := spool.offset(); offset == 0 {
+		t.Error("offset after a small discard = 0")
+	}
+	if got, want := peekSequences(t, spool, 3), []int64{3, 4, 5}; !equalSequences(got, want) {
+		t.Fatalf("Peek(3) after Discard(2) = %v, want %v", got, want)
+	}
+
+	// Past half, the sent audit logs are dropped from the file
+	if err := spool.Discard(ctx, 3); err != nil {
+		t.Fatalf("Discard() error = %v", err)
+	}
+	if offset, _ := spool.offset(); offset != 0 {
+		t.Errorf("offset after compaction = %d, want 0", offset)
+	}
+	if got, _ := spool.size(); got >= size/2 {
+		t.Errorf("size after compaction = %d, want less than %d", got, size/2)
+	}
+	if got, want := peekSequences(t, spool, 10), []int64{6, 7, 8}; !equalSequences(got, want) {
+		t.Fatalf("Peek() after compaction = %v, want %v", got, want)
+	}
+
+	// A drained spool removes its file
+	if err := spool.Discard(ctx, 3); err != nil {
+		t.Fatalf("Discard() error = %v", err)
+	}
+	if _, err := os.Stat(spool.path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("spool file after draining: %v", err)
+	}
+	if got := peekSequences(t, spool, 10); len(got) != 0 {
+		t.Errorf("Peek() after draining = %v", got)
+	}
+}
+
+func TestFileAuditSpoolReopen(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	spool, err := NewFileAuditSpool(dir, "siem", 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := spool.Append(ctx, spooledLogs(1, 10)); err != nil {
+		t.Fatal(err)
+	}
+	if err := spool.Discard(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	// A restart picks up where the previous process stopped
+	reopened, err := NewFileAuditSpool(dir, "siem", 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := peekSequences(t, reopened, 3), []int64{3, 4, 5}; !equalSequences(got, want) {
+		t.Fatalf("Peek() after reopening = %v, want %v", got, want)
+	}
+	if err := reopened.Append(ctx, spooledLogs(11, 11)); err != nil {
+		t.Fatal(err)
+	}
+	if got := peekSequences(t, reopened, 100); len(got) != 9 || got[8] != 11 {
+		t.Errorf("Peek() after appending = %v, want 3 through 11", got)
+	}
+}
+
+func TestFileAuditSpoolFull(t *testing.T) {
+	spool, err := NewFileAuditSpool(t.TempDir(), "siem", 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if err := spool.Append(ctx, spooledLogs(1, 1)); err != nil {
+		t.Fatal(err)
+	}
+	// Room for one more audit log like the first
+	size, _ := spool.size()
+	spool.maxBytes = 2 * size
+	if err := spool.Append(ctx, spooledLogs(2, 10)); !errors.Is(err, repository.ErrAuditSpoolFull) {
+		t.Fatalf("Append() past maxBytes error = %v, want ErrAuditSpoolFull", err)
+	}
+	if got, want := peekSequences(t, spool, 10), []int64{1}; !equalSequences(got, want) {
+		t.Errorf("Peek() after a refused append = %v, want %v", got, want)
+	}
+}
+
+func TestFileAuditSpoolTornLine(t *testing.T) {
+	spool, err := NewFileAuditSpool(t.TempDir(), "siem", 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if err := spool.Append(ctx, spooledLogs(1, 2)); err != nil {
+		t.Fatal(err)
+	}
+	// A crash cut the next append short
+	file, err := os.OpenFile(spool.path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.WriteString(`{"sequence":3,"userId":"u`); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	if got, want := peekSequences(t, spool, 10), []int64{1, 2}; !equalSequences(got, want) {
+		t.Fatalf("Peek() with a torn line = %v, want %v", got, want)
+	}
+
+	// The torn line is dropped rather than glued to the next audit log
+	if err := spool.Append(ctx, spooledLogs(4, 5)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := peekSequences(t, spool, 10), []int64{1, 2, 4, 5}; !equalSequences(got, want) {
+		t.Fatalf("Peek() after appending past a torn line = %v, want %v", got, want)
+	}
+	if _, err := os.Stat(spool.rejectedPath); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("rejected file after a torn line: %v", err)
+	}
+}
+
+func TestFileAuditSpoolRejectsUndecodableLines(t *testing.T) {
+	dir := t.TempDir()
+	spool, err := NewFileAuditSpool(dir, "siem", 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if err := spool.Append(ctx, spooledLogs(1, 1)); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.OpenFile(spool.path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.WriteString("not json\n{\"sequence\":\"two\"}\n"); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	if err := spool.Append(ctx, spooledLogs(3, 4)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The batch skips the lines that do not decode instead of failing
+	if got, want := peekSequences(t, spool, 2), []int64{1, 3}; !equalSequences(got, want) {
+		t.Fatalf("Peek(2) = %v, want %v", got, want)
+	}
+	if err := spool.Discard(ctx, 2); err != nil {
+		t.Fatalf("Discard() error = %v", err)
+	}
+	if got, want := peekSequences(t, spool, 10), []int64{4}; !equalSequences(got, want) {
+		t.Fatalf("Peek() after Discard(2) = %v, want %v", got, want)
+	}
+
+	rejected, err := os.ReadFile(filepath.Join(dir, "siem.rejected"))
+	if err != nil {
+		t.Fatalf("reading rejected lines: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSuffix(string(rejected), "\n"), "\n"); len(lines) != 2 || lines[0] != "not json" {
+		t.Errorf("rejected lines = %q", lines)
+	}
+}
@@ -0,0 +1,101 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoDBAgentTokenRepository implements the AgentTokenRepository interface using MongoDB
+type MongoDBAgentTokenRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoDBAgentTokenRepository creates a new MongoDB agent token repository
+func NewMongoDBAgentTokenRepository(db *mongo.Database) repository.AgentTokenRepository {
+	collection := db.Collection("agent_tokens")
+
+	// Create indexes
+	indexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "tokenHash", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+
+	_, err := collection.Indexes().CreateOne(context.Background(), indexModel)
+	if err != nil {
+		// Log error but continue
+		fmt.Printf("Error creating agent token index: %v\n", err)
+	}
+
+	return &MongoDBAgentTokenRepository{
+		collection: collection,
+	}
+}
+
+// FindByID finds an agent token by its ID
+func (r *MongoDBAgentTokenRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*model.AgentToken, error) {
+	var token model.AgentToken
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&token)
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// FindByHash finds an agent token by the hash of its secret
+func (r *MongoDBAgentTokenRepository) FindByHash(ctx context.Context, tokenHash string) (*model.AgentToken, error) {
+	var token model.AgentToken
+	err := r.collection.FindOne(ctx, bson.M{"tokenHash": tokenHash}).Decode(&token)
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// FindAll finds all agent tokens
+func (r *MongoDBAgentTokenRepository) FindAll(ctx context.Context) ([]*model.AgentToken, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var tokens []*model.AgentToken
+	if err := cursor.All(ctx, &tokens); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// Save creates or updates an agent token
+func (r *MongoDBAgentTokenRepository) Save(ctx context.Context, token *model.AgentToken) error {
+	if token.ID.IsZero() {
+		token.ID = primitive.NewObjectID()
+	}
+
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": token.ID}, token, options.Replace().SetUpsert(true))
+	return err
+}
+
+// MarkUsed sets the last use of a token that is not revoked, and reports whether it was set.
+// Only that field is written, so a revocation saved meanwhile is never overwritten.
+func (r *MongoDBAgentTokenRepository) MarkUsed(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "revokedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"lastUsedAt": time.Now()}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
@@ -0,0 +1,326 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// openAlertStatuses are the statuses of alerts that still need attention
+var openAlertStatuses = []model.AlertStatus{
+	model.AlertStatusActive,
+	model.AlertStatusAcknowledged,
+}
+
+// MongoDBAlertRepository implements the AlertRepository interface using MongoDB
+type MongoDBAlertRepository struct {
+	alertCollection *mongo.Collection
+	ruleCollection  *mongo.Collection
+}
+
+// NewMongoDBAlertRepository creates a new MongoDB alert repository
+func NewMongoDBAlertRepository(db *mongo.Database) repository.AlertRepository {
+	alertCollection := db.Collection("alerts")
+	ruleCollection := db.Collection("alert_rules")
+
+	// Create indexes
+	_, err := alertCollection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "ruleId", Value: 1}, {Key: "assetId", Value: 1}, {Key: "status", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "createdAt", Value: -1}},
+		},
+	})
+	if err != nil {
+		// Log error but continue
+		fmt.Printf("Error creating alert indexes: %v\n", err)
+	}
+
+	// A rule has at most one open alert per asset, whichever instance evaluates it. Duplicates
+	// left from before the index are closed first, since they would keep it from being built,
+	// and without it nothing stops the evaluators from raising more.
+	if err := closeDuplicateOpenAlerts(context.Background(), alertCollection); err != nil {
+		panic(fmt.Sprintf("closing duplicate open alerts: %v", err))
+	}
+	_, err = alertCollection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: bson.D{{Key: "ruleId", Value: 1}, {Key: "assetId", Value: 1}},
+		Options: options.Index().
+			SetName("open_alert_per_rule_and_asset").
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{
+				"ruleId": bson.M{"$exists": true},
+				"status": bson.M{"$in": openAlertStatuses},
+			}),
+	})
+	if err != nil {
+		panic(fmt.Sprintf("creating open alert index: %v", err))
+	}
+
+	_, err = ruleCollection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: bson.D{{Key: "enabled", Value: 1}},
+	})
+	if err != nil {
+		fmt.Printf("Error creating alert rule indexes: %v\n", err)
+	}
+
+	return &MongoDBAlertRepository{
+		alertCollection: alertCollection,
+		ruleCollection:  ruleCollection,
+	}
+}
+
+// closeDuplicateOpenAlerts keeps the first open alert of each rule and asset open and closes
+// the others
+func closeDuplicateOpenAlerts(ctx context.Context, collection *mongo.Collection) error {
+	cursor, err := collection.Aggregate(ctx, []bson.M{
+		{"$match": bson.M{"ruleId": bson.M{"$exists": true}, "status": bson.M{"$in": openAlertStatuses}}},
+		{"$sort": bson.M{"createdAt": 1}},
+		{"$group": bson.M{
+			"_id": bson.M{"ruleId": "$ruleId", "assetId": "$assetId"},
+			"ids": bson.M{"$push": "$_id"},
+		}},
+		{"$match": bson.M{"ids.1": bson.M{"$exists": true}}},
+	})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var groups []struct {
+		IDs []primitive.ObjectID `bson:"ids"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return err
+	}
+	for _, group := range groups {
+		_, err := collection.UpdateMany(ctx,
+			bson.M{"_id": bson.M{"$in": group.IDs[1:]}, "status": bson.M{"$in": openAlertStatuses}},
+			bson.M{"$set": bson.M{
+				"status":     model.AlertStatusClosed,
+				"resolution": "Closed as a duplicate of " + group.IDs[0].Hex(),
+				"updatedAt":  time.Now(),
+			}},
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindByID finds an alert by its ID
+func (r *MongoDBAlertRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*model.Alert, error) {
+	var alert model.Alert
+	err := r.alertCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&alert)
+	if err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+// FindAll finds all alerts with optional filtering
+func (r *MongoDBAlertRepository) FindAll(ctx context.Context, filter map[string]interface{}) ([]*model.Alert, error) {
+	// Convert map to bson.M
+	bsonFilter := bson.M{}
+	for k, v := range filter {
+		bsonFilter[k] = v
+	}
+
+	// Set default sort by createdAt descending
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+
+	cursor, err := r.alertCollection.Find(ctx, bsonFilter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var alerts []*model.Alert
+	if err := cursor.All(ctx, &alerts); err != nil {
+		return nil, err
+	}
+
+	return alerts, nil
+}
+
+// FindOpenByRuleAndAsset finds the active or acknowledged alert raised by a rule for an asset
+func (r *MongoDBAlertRepository) FindOpenByRuleAndAsset(ctx context.Context, ruleID, assetID primitive.ObjectID) (*model.Alert, error) {
+	filter := bson.M{
+		"ruleId":  ruleID,
+		"assetId": assetID,
+		"status":  bson.M{"$in": openAlertStatuses},
+	}
+
+	var alert model.Alert
+	err := r.alertCollection.FindOne(ctx, filter).Decode(&alert)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &alert, nil
+}
+
+// Save creates or updates an alert
+func (r *MongoDBAlertRepository) Save(ctx context.Context, alert *model.Alert) error {
+	if alert.ID.IsZero() {
+		alert.ID = primitive.NewObjectID()
+	}
+
+	_, err := r.alertCollection.ReplaceOne(ctx, bson.M{"_id": alert.ID}, alert, options.Replace().SetUpsert(true))
+	return err
+}
+
+// UpdateCurrentValue sets the current value of an open alert, leaving its other fields as stored
+func (r *MongoDBAlertRepository) UpdateCurrentValue(ctx context.Context, id primitive.ObjectID, value interface{}) error {
+	_, err := r.alertCollection.UpdateOne(ctx,
+		bson.M{"_id": id, "status": bson.M{"$in": openAlertStatuses}},
+		bson.M{"$set": bson.M{"currentValue": value, "updatedAt": time.Now()}},
+	)
+	return err
+}
+
+// MarkAcknowledged stores the acknowledgement of an alert if it is still active, and reports
+// whether it was
+func (r *MongoDBAlertRepository) MarkAcknowledged(ctx context.Context, alert *model.Alert) (bool, error) {
+	result, err := r.alertCollection.UpdateOne(ctx,
+		bson.M{"_id": alert.ID, "status": model.AlertStatusActive},
+		bson.M{"$set": bson.M{
+			"status":           alert.Status,
+			"acknowledgedBy":   alert.AcknowledgedBy,
+			"acknowledgedById": alert.AcknowledgedByID,
+			"acknowledgedAt":   alert.AcknowledgedAt,
+			"updatedAt":        alert.UpdatedAt,
+		}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// MarkResolved stores the resolution of an alert if it is still open, and reports whether it was
+func (r *MongoDBAlertRepository) MarkResolved(ctx context.Context, alert *model.Alert) (bool, error) {
+	result, err := r.alertCollection.UpdateOne(ctx,
+		bson.M{"_id": alert.ID, "status": bson.M{"$in": openAlertStatuses}},
+		bson.M{"$set": bson.M{
+			"status":       alert.Status,
+			"resolvedBy":   alert.ResolvedBy,
+			"resolvedById": alert.ResolvedByID,
+			"resolvedAt":   alert.ResolvedAt,
+			"resolution":   alert.Resolution,
+			"updatedAt":    alert.UpdatedAt,
+		}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// MarkClosed closes an alert if it is not closed yet, and reports whether it was not
+func (r *MongoDBAlertRepository) MarkClosed(ctx context.Context, alert *model.Alert) (bool, error) {
+	result, err := r.alertCollection.UpdateOne(ctx,
+		bson.M{"_id": alert.ID, "status": bson.M{"$ne": model.AlertStatusClosed}},
+		bson.M{"$set": bson.M{"status": alert.Status, "updatedAt": alert.UpdatedAt}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// GetAlertStats gets alert statistics by status
+func (r *MongoDBAlertRepository) GetAlertStats(ctx context.Context) (map[string]int64, error) {
+	pipeline := []bson.M{
+		{
+			"$group": bson.M{
+				"_id":   "$status",
+				"count": bson.M{"$sum": 1},
+			},
+		},
+	}
+
+	cursor, err := r.alertCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []bson.M
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	stats := make(map[string]int64)
+	var total int64 = 0
+
+	for _, result := range results {
+		status := result["_id"].(string)
+		count := result["count"].(int32)
+		stats[status] = int64(count)
+		total += int64(count)
+	}
+
+	stats["total"] = total
+
+	return stats, nil
+}
+
+// FindRuleByID finds an alert rule by its ID
+func (r *MongoDBAlertRepository) FindRuleByID(ctx context.Context, id primitive.ObjectID) (*model.AlertRule, error) {
+	var rule model.AlertRule
+	err := r.ruleCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&rule)
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// FindRules finds all alert rules, optionally only the enabled ones
+func (r *MongoDBAlertRepository) FindRules(ctx context.Context, enabledOnly bool) ([]*model.AlertRule, error) {
+	filter := bson.M{}
+	if enabledOnly {
+		filter["enabled"] = true
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}})
+
+	cursor, err := r.ruleCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rules []*model.AlertRule
+	if err := cursor.All(ctx, &rules); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// SaveRule creates or updates an alert rule
+func (r *MongoDBAlertRepository) SaveRule(ctx context.Context, rule *model.AlertRule) error {
+	if rule.ID.IsZero() {
+		rule.ID = primitive.NewObjectID()
+	}
+
+	_, err := r.ruleCollection.ReplaceOne(ctx, bson.M{"_id": rule.ID}, rule, options.Replace().SetUpsert(true))
+	return err
+}
+
+// DeleteRule deletes an alert rule by its ID
+func (r *MongoDBAlertRepository) DeleteRule(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.ruleCollection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
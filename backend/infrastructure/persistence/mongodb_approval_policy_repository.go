@@ -0,0 +1,93 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoDBApprovalPolicyRepository implements the ApprovalPolicyRepository interface using MongoDB
+type MongoDBApprovalPolicyRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoDBApprovalPolicyRepository creates a new MongoDB approval policy repository
+func NewMongoDBApprovalPolicyRepository(db *mongo.Database) repository.ApprovalPolicyRepository {
+	collection := db.Collection("approval_policies")
+
+	// Create indexes
+	indexModels := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "name", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "workflowType", Value: 1}, {Key: "enabled", Value: 1}},
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(context.Background(), indexModels)
+	if err != nil {
+		// Log error but continue
+		fmt.Printf("Error creating approval policy indexes: %v\n", err)
+	}
+
+	return &MongoDBApprovalPolicyRepository{
+		collection: collection,
+	}
+}
+
+// FindByID finds an approval policy by its ID
+func (r *MongoDBApprovalPolicyRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*model.ApprovalPolicy, error) {
+	var policy model.ApprovalPolicy
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&policy)
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// FindAll finds all approval policies, optionally only the enabled ones
+func (r *MongoDBApprovalPolicyRepository) FindAll(ctx context.Context, enabledOnly bool) ([]*model.ApprovalPolicy, error) {
+	filter := bson.M{}
+	if enabledOnly {
+		filter["enabled"] = true
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var policies []*model.ApprovalPolicy
+	if err := cursor.All(ctx, &policies); err != nil {
+		return nil, err
+	}
+
+	return policies, nil
+}
+
+// Save creates or updates an approval policy
+func (r *MongoDBApprovalPolicyRepository) Save(ctx context.Context, policy *model.ApprovalPolicy) error {
+	if policy.ID.IsZero() {
+		policy.ID = primitive.NewObjectID()
+	}
+
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": policy.ID}, policy, options.Replace().SetUpsert(true))
+	return err
+}
+
+// Delete deletes an approval policy by its ID
+func (r *MongoDBApprovalPolicyRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
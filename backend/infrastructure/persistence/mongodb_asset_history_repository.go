@@ -57,6 +57,15 @@ func (r *MongoAssetHistoryRepository) Create(ctx context.Context, history *model
 	return err
 }
 
+// FindByID finds a history record by ID
+func (r *MongoAssetHistoryRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*model.AssetHistory, error) {
+	var history model.AssetHistory
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&history); err != nil {
+		return nil, err
+	}
+	return &history, nil
+}
+
 // FindByAssetID finds all history records for a specific asset
 func (r *MongoAssetHistoryRepository) FindByAssetID(ctx context.Context, assetID primitive.ObjectID, limit int) ([]*model.AssetHistory, error) {
 	filter := bson.M{"assetId": assetID}
@@ -90,7 +99,8 @@ func (r *MongoAssetHistoryRepository) FindByDateRange(ctx context.Context, asset
 		},
 	}
 
-	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: -1}})
+	// Records made in the same millisecond are ordered by their IDs
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: -1}, {Key: "_id", Value: -1}})
 
 	cursor, err := r.collection.Find(ctx, filter, opts)
 	if err != nil {
@@ -129,9 +139,12 @@ func (r *MongoAssetHistoryRepository) FindByChangeType(ctx context.Context, asse
 	return histories, nil
 }
 
-// FindByUser finds history records by user
+// FindByUser finds history records changed or approved by a user
 func (r *MongoAssetHistoryRepository) FindByUser(ctx context.Context, userID string, limit int) ([]*model.AssetHistory, error) {
-	filter := bson.M{"changedById": userID}
+	filter := bson.M{"$or": bson.A{
+		bson.M{"changedById": userID},
+		bson.M{"approvedById": userID},
+	}}
 	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: -1}})
 
 	if limit > 0 {
@@ -186,3 +199,24 @@ func (r *MongoAssetHistoryRepository) DeleteOlderThan(ctx context.Context, times
 	_, err := r.collection.DeleteMany(ctx, filter)
 	return err
 }
+
+// ReassignAsset moves the history of an asset merged into another one to the surviving asset.
+// Records moved by an earlier merge keep the asset ID they were first recorded under.
+func (r *MongoAssetHistoryRepository) ReassignAsset(ctx context.Context, fromID, toID primitive.ObjectID, mergedFrom string) (int64, error) {
+	_, err := r.collection.UpdateMany(ctx,
+		bson.M{"assetId": fromID, "mergedFrom": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"mergedFrom": mergedFrom}},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := r.collection.UpdateMany(ctx,
+		bson.M{"assetId": fromID},
+		bson.M{"$set": bson.M{"assetId": toID}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
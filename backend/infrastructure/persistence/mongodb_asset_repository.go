@@ -34,6 +34,34 @@ func NewMongoDBAssetRepository(db *mongo.Database) repository.AssetRepository {
 		fmt.Printf("Error creating asset index: %v\n", err)
 	}
 
+	// Discovery matches hosts to assets by these fields
+	_, err = collection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{Keys: bson.D{{Key: "ipAddress", Value: 1}}},
+		{Keys: bson.D{{Key: "macAddress", Value: 1}}},
+		{Keys: bson.D{{Key: "hostname", Value: 1}}},
+	})
+	if err != nil {
+		fmt.Printf("Error creating asset discovery indexes: %v\n", err)
+	}
+
+	// Agents report facts keyed by a machine ID that identifies one asset
+	_, err = collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "machineId", Value: 1}},
+		Options: options.Index().SetUnique(true).SetSparse(true),
+	})
+	if err != nil {
+		fmt.Printf("Error creating asset machine ID index: %v\n", err)
+	}
+
+	// Assets synced from external systems are found again by their external ID
+	_, err = collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "externalId", Value: 1}},
+		Options: options.Index().SetUnique(true).SetSparse(true),
+	})
+	if err != nil {
+		fmt.Printf("Error creating asset external ID index: %v\n", err)
+	}
+
 	return &MongoDBAssetRepository{
 		collection: collection,
 	}
@@ -238,25 +266,26 @@ func (r *MongoDBAssetRepository) GetAssetStats(ctx context.Context) (map[string]
 	return stats, nil
 }
 
-// GenerateAssetID generates a unique asset ID based on type
-func (r *MongoDBAssetRepository) GenerateAssetID(ctx context.Context, assetType string) (string, error) {
-	prefix := map[string]string{
-		"server":      "SRV",
-		"network":     "NET",
-		"storage":     "STG",
-		"workstation": "WS",
-	}
+// FindAssetIDsMatching finds the asset IDs matching a regular expression
+func (r *MongoDBAssetRepository) FindAssetIDsMatching(ctx context.Context, pattern string) ([]string, error) {
+	opts := options.Find().SetProjection(bson.M{"assetId": 1})
 
-	typePrefix := prefix[assetType]
-	if typePrefix == "" {
-		typePrefix = "AST"
+	cursor, err := r.collection.Find(ctx, bson.M{"assetId": bson.M{"$regex": pattern}}, opts)
+	if err != nil {
+		return nil, err
 	}
+	defer cursor.Close(ctx)
 
-	// Get count of assets of this type
-	count, err := r.collection.CountDocuments(ctx, bson.M{"type": assetType})
-	if err != nil {
-		return "", err
+	var results []struct {
+		AssetID string `bson:"assetId"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
 	}
 
-	return fmt.Sprintf("%s-%03d", typePrefix, count+1), nil
+	ids := make([]string, len(results))
+	for i, result := range results {
+		ids[i] = result.AssetID
+	}
+	return ids, nil
 }
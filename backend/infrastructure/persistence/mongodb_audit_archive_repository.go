@@ -0,0 +1,62 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+)
+
+// MongoAuditArchiveRepository implements AuditArchiveRepository using MongoDB
+type MongoAuditArchiveRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoAuditArchiveRepository creates a new MongoDB audit archive repository
+func NewMongoAuditArchiveRepository(db *mongo.Database) *MongoAuditArchiveRepository {
+	collection := db.Collection("audit_archives")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "fromSequence", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+
+	return &MongoAuditArchiveRepository{
+		collection: collection,
+	}
+}
+
+// Create adds the record of an archived segment
+func (r *MongoAuditArchiveRepository) Create(ctx context.Context, archive *model.AuditArchive) error {
+	if archive.ID.IsZero() {
+		archive.ID = primitive.NewObjectID()
+	}
+
+	_, err := r.collection.InsertOne(ctx, archive)
+	return err
+}
+
+// FindAll finds the records of all archived segments, in chain order
+func (r *MongoAuditArchiveRepository) FindAll(ctx context.Context) ([]*model.AuditArchive, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "fromSequence", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var archives []*model.AuditArchive
+	if err := cursor.All(ctx, &archives); err != nil {
+		return nil, err
+	}
+	return archives, nil
+}
@@ -0,0 +1,108 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+)
+
+// MongoAuditCheckpointRepository implements AuditCheckpointRepository using MongoDB
+type MongoAuditCheckpointRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoAuditCheckpointRepository creates a new MongoDB audit checkpoint repository
+func NewMongoAuditCheckpointRepository(db *mongo.Database) *MongoAuditCheckpointRepository {
+	collection := db.Collection("audit_checkpoints")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "sequence", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "createdAt", Value: -1}},
+		},
+	}
+
+	collection.Indexes().CreateMany(ctx, indexes)
+
+	return &MongoAuditCheckpointRepository{
+		collection: collection,
+	}
+}
+
+// Create adds a new checkpoint
+func (r *MongoAuditCheckpointRepository) Create(ctx context.Context, checkpoint *model.AuditCheckpoint) error {
+	if checkpoint.ID.IsZero() {
+		checkpoint.ID = primitive.NewObjectID()
+	}
+
+	_, err := r.collection.InsertOne(ctx, checkpoint)
+	return err
+}
+
+// FindLatest finds the checkpoint with the highest sequence, or returns nil when there is none
+func (r *MongoAuditCheckpointRepository) FindLatest(ctx context.Context) (*model.AuditCheckpoint, error) {
+	opts := options.FindOne().SetSort(bson.D{{Key: "sequence", Value: -1}})
+	return r.findOne(ctx, bson.M{}, opts)
+}
+
+// FindLatestBefore finds the last checkpoint created before a time, or returns nil when there is none
+func (r *MongoAuditCheckpointRepository) FindLatestBefore(ctx context.Context, before time.Time) (*model.AuditCheckpoint, error) {
+	filter := bson.M{"createdAt": bson.M{"$lt": before}}
+	opts := options.FindOne().SetSort(bson.D{{Key: "sequence", Value: -1}})
+	return r.findOne(ctx, filter, opts)
+}
+
+// FindSequenceRange finds the checkpoints of entries from one sequence to another, in chain order
+func (r *MongoAuditCheckpointRepository) FindSequenceRange(ctx context.Context, from, to int64) ([]*model.AuditCheckpoint, error) {
+	filter := bson.M{
+		"sequence": bson.M{
+			"$gte": from,
+			"$lte": to,
+		},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "sequence", Value: 1}})
+	return r.find(ctx, filter, opts)
+}
+
+// FindRecent finds the most recent checkpoints, newest first
+func (r *MongoAuditCheckpointRepository) FindRecent(ctx context.Context, limit int) ([]*model.AuditCheckpoint, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "sequence", Value: -1}}).SetLimit(int64(limit))
+	return r.find(ctx, bson.M{}, opts)
+}
+
+func (r *MongoAuditCheckpointRepository) findOne(ctx context.Context, filter bson.M, opts *options.FindOneOptions) (*model.AuditCheckpoint, error) {
+	var checkpoint model.AuditCheckpoint
+	err := r.collection.FindOne(ctx, filter, opts).Decode(&checkpoint)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &checkpoint, nil
+}
+
+func (r *MongoAuditCheckpointRepository) find(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*model.AuditCheckpoint, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var checkpoints []*model.AuditCheckpoint
+	if err := cursor.All(ctx, &checkpoints); err != nil {
+		return nil, err
+	}
+	return checkpoints, nil
+}
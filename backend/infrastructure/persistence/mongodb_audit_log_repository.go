@@ -42,6 +42,12 @@ func NewMongoAuditLogRepository(db *mongo.Database) *MongoAuditLogRepository {
 		{
 			Keys: bson.D{{Key: "username", Value: 1}},
 		},
+		{
+			// Entries written before the hash chain have no sequence
+			Keys: bson.D{{Key: "sequence", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"sequence": bson.M{"$exists": true}}),
+		},
 	}
 
 	collection.Indexes().CreateMany(ctx, indexes)
@@ -80,11 +86,11 @@ func (r *MongoAuditLogRepository) FindByUserID(ctx context.Context, userID strin
 	return logs, nil
 }
 
-// FindByResourceID finds all audit logs for a specific resource
-func (r *MongoAuditLogRepository) FindByResourceID(ctx context.Context, resourceType, resourceID string, limit int) ([]*model.AuditLog, error) {
+// FindByResourceIDs finds all audit logs for any of the resources of a type with the given IDs
+func (r *MongoAuditLogRepository) FindByResourceIDs(ctx context.Context, resourceType string, resourceIDs []string, limit int) ([]*model.AuditLog, error) {
 	filter := bson.M{
 		"resourceType": resourceType,
-		"resourceId":   resourceID,
+		"resourceId":   bson.M{"$in": resourceIDs},
 	}
 	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: -1}}).SetLimit(int64(limit))
 
@@ -165,7 +171,9 @@ func (r *MongoAuditLogRepository) Search(ctx context.Context, criteria repositor
 		filter["resourceType"] = criteria.ResourceType
 	}
 
-	if criteria.ResourceID != "" {
+	if len(criteria.ResourceIDs) > 0 {
+		filter["resourceId"] = bson.M{"$in": criteria.ResourceIDs}
+	} else if criteria.ResourceID != "" {
 		filter["resourceId"] = criteria.ResourceID
 	}
 
@@ -239,7 +247,9 @@ func (r *MongoAuditLogRepository) Count(ctx context.Context, criteria repository
 		filter["resourceType"] = criteria.ResourceType
 	}
 
-	if criteria.ResourceID != "" {
+	if len(criteria.ResourceIDs) > 0 {
+		filter["resourceId"] = bson.M{"$in": criteria.ResourceIDs}
+	} else if criteria.ResourceID != "" {
 		filter["resourceId"] = criteria.ResourceID
 	}
 
@@ -265,14 +275,87 @@ func (r *MongoAuditLogRepository) Count(ctx context.Context, criteria repository
 	return r.collection.CountDocuments(ctx, filter)
 }
 
-// DeleteOlderThan deletes audit logs older than the specified time
-func (r *MongoAuditLogRepository) DeleteOlderThan(ctx context.Context, timestamp time.Time) error {
+// FindLatest finds the last audit log of the hash chain, or returns nil when there is none
+func (r *MongoAuditLogRepository) FindLatest(ctx context.Context) (*model.AuditLog, error) {
+	filter := bson.M{"sequence": bson.M{"$exists": true}}
+	opts := options.FindOne().SetSort(bson.D{{Key: "sequence", Value: -1}})
+
+	var log model.AuditLog
+	err := r.collection.FindOne(ctx, filter, opts).Decode(&log)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &log, nil
+}
+
+// FindBySequence finds the audit log with a sequence number
+func (r *MongoAuditLogRepository) FindBySequence(ctx context.Context, sequence int64) (*model.AuditLog, error) {
+	var log model.AuditLog
+	if err := r.collection.FindOne(ctx, bson.M{"sequence": sequence}).Decode(&log); err != nil {
+		return nil, err
+	}
+	return &log, nil
+}
+
+// FindSequenceRange finds up to limit audit logs with sequence numbers from one to another, in chain order
+func (r *MongoAuditLogRepository) FindSequenceRange(ctx context.Context, from, to int64, limit int) ([]*model.AuditLog, error) {
 	filter := bson.M{
-		"timestamp": bson.M{
-			"$lt": timestamp,
+		"sequence": bson.M{
+			"$gte": from,
+			"$lte": to,
 		},
 	}
+	opts := options.Find().SetSort(bson.D{{Key: "sequence", Value: 1}}).SetLimit(int64(limit))
 
-	_, err := r.collection.DeleteMany(ctx, filter)
-	return err
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var logs []*model.AuditLog
+	if err := cursor.All(ctx, &logs); err != nil {
+		return nil, err
+	}
+
+	return logs, nil
+}
+
+// DeleteThroughSequence deletes the audit logs of the hash chain up to a sequence number
+func (r *MongoAuditLogRepository) DeleteThroughSequence(ctx context.Context, sequence int64) (int64, error) {
+	filter := bson.M{
+		"sequence": bson.M{
+			"$lte": sequence,
+		},
+	}
+
+	result, err := r.collection.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+// FindReassignedTo finds the IDs of the resources of a type that were reassigned to a resource
+func (r *MongoAuditLogRepository) FindReassignedTo(ctx context.Context, resourceType, resourceID string) ([]string, error) {
+	values, err := r.collection.Distinct(ctx, "resourceId", bson.M{
+		"action":              model.ResourceReassigned,
+		"resourceType":        resourceType,
+		"newValue.resourceId": resourceID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(values))
+	for _, value := range values {
+		if id, ok := value.(string); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
 }
@@ -0,0 +1,83 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoDBCITypeRepository implements the CITypeRepository interface using MongoDB
+type MongoDBCITypeRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoDBCITypeRepository creates a new MongoDB CI type repository
+func NewMongoDBCITypeRepository(db *mongo.Database) repository.CITypeRepository {
+	collection := db.Collection("ci_types")
+
+	// Create indexes
+	indexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+
+	_, err := collection.Indexes().CreateOne(context.Background(), indexModel)
+	if err != nil {
+		// Log error but continue
+		fmt.Printf("Error creating CI type index: %v\n", err)
+	}
+
+	return &MongoDBCITypeRepository{
+		collection: collection,
+	}
+}
+
+// FindByName finds a CI type by its name
+func (r *MongoDBCITypeRepository) FindByName(ctx context.Context, name model.AssetType) (*model.CIType, error) {
+	var ciType model.CIType
+	err := r.collection.FindOne(ctx, bson.M{"name": name}).Decode(&ciType)
+	if err != nil {
+		return nil, err
+	}
+	return &ciType, nil
+}
+
+// FindAll finds all stored CI types
+func (r *MongoDBCITypeRepository) FindAll(ctx context.Context) ([]*model.CIType, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var ciTypes []*model.CIType
+	if err := cursor.All(ctx, &ciTypes); err != nil {
+		return nil, err
+	}
+
+	return ciTypes, nil
+}
+
+// Save creates or updates a CI type
+func (r *MongoDBCITypeRepository) Save(ctx context.Context, ciType *model.CIType) error {
+	if ciType.ID.IsZero() {
+		ciType.ID = primitive.NewObjectID()
+	}
+
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": ciType.ID}, ciType, options.Replace().SetUpsert(true))
+	return err
+}
+
+// DeleteByName deletes a CI type by its name
+func (r *MongoDBCITypeRepository) DeleteByName(ctx context.Context, name model.AssetType) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"name": name})
+	return err
+}
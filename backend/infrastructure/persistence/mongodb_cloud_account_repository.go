@@ -0,0 +1,88 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoDBCloudAccountRepository implements the CloudAccountRepository interface using MongoDB
+type MongoDBCloudAccountRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoDBCloudAccountRepository creates a new MongoDB cloud account repository
+func NewMongoDBCloudAccountRepository(db *mongo.Database) repository.CloudAccountRepository {
+	collection := db.Collection("cloud_accounts")
+
+	// Create indexes
+	indexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+
+	_, err := collection.Indexes().CreateOne(context.Background(), indexModel)
+	if err != nil {
+		// Log error but continue
+		fmt.Printf("Error creating cloud account index: %v\n", err)
+	}
+
+	return &MongoDBCloudAccountRepository{
+		collection: collection,
+	}
+}
+
+// FindByID finds an account by its ID
+func (r *MongoDBCloudAccountRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*model.CloudAccount, error) {
+	var account model.CloudAccount
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&account)
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// FindAll finds all accounts, optionally only the enabled ones
+func (r *MongoDBCloudAccountRepository) FindAll(ctx context.Context, enabledOnly bool) ([]*model.CloudAccount, error) {
+	filter := bson.M{}
+	if enabledOnly {
+		filter["enabled"] = true
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var accounts []*model.CloudAccount
+	if err := cursor.All(ctx, &accounts); err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
+}
+
+// Save creates or updates an account
+func (r *MongoDBCloudAccountRepository) Save(ctx context.Context, account *model.CloudAccount) error {
+	if account.ID.IsZero() {
+		account.ID = primitive.NewObjectID()
+	}
+
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": account.ID}, account, options.Replace().SetUpsert(true))
+	return err
+}
+
+// Delete deletes an account by its ID
+func (r *MongoDBCloudAccountRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
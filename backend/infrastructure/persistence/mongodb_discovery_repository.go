@@ -0,0 +1,241 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoDBDiscoveryRepository implements the DiscoveryRepository interface using MongoDB
+type MongoDBDiscoveryRepository struct {
+	rangeCollection     *mongo.Collection
+	runCollection       *mongo.Collection
+	candidateCollection *mongo.Collection
+}
+
+// NewMongoDBDiscoveryRepository creates a new MongoDB discovery repository
+func NewMongoDBDiscoveryRepository(db *mongo.Database) repository.DiscoveryRepository {
+	rangeCollection := db.Collection("discovery_ranges")
+	runCollection := db.Collection("discovery_runs")
+	candidateCollection := db.Collection("discovery_candidates")
+
+	// Create indexes
+	_, err := runCollection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "rangeId", Value: 1}, {Key: "startedAt", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}},
+		},
+	})
+	if err != nil {
+		// Log error but continue
+		fmt.Printf("Error creating discovery run indexes: %v\n", err)
+	}
+
+	_, err = candidateCollection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "ipAddress", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "macAddress", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "lastSeen", Value: -1}},
+		},
+	})
+	if err != nil {
+		fmt.Printf("Error creating discovery candidate indexes: %v\n", err)
+	}
+
+	return &MongoDBDiscoveryRepository{
+		rangeCollection:     rangeCollection,
+		runCollection:       runCollection,
+		candidateCollection: candidateCollection,
+	}
+}
+
+// FindRangeByID finds a discovery range by its ID
+func (r *MongoDBDiscoveryRepository) FindRangeByID(ctx context.Context, id primitive.ObjectID) (*model.DiscoveryRange, error) {
+	var rng model.DiscoveryRange
+	err := r.rangeCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&rng)
+	if err != nil {
+		return nil, err
+	}
+	return &rng, nil
+}
+
+// FindRanges finds all discovery ranges, optionally only the enabled ones
+func (r *MongoDBDiscoveryRepository) FindRanges(ctx context.Context, enabledOnly bool) ([]*model.DiscoveryRange, error) {
+	filter := bson.M{}
+	if enabledOnly {
+		filter["enabled"] = true
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+
+	cursor, err := r.rangeCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var ranges []*model.DiscoveryRange
+	if err := cursor.All(ctx, &ranges); err != nil {
+		return nil, err
+	}
+
+	return ranges, nil
+}
+
+// SaveRange creates or updates a discovery range
+func (r *MongoDBDiscoveryRepository) SaveRange(ctx context.Context, rng *model.DiscoveryRange) error {
+	if rng.ID.IsZero() {
+		rng.ID = primitive.NewObjectID()
+	}
+
+	_, err := r.rangeCollection.ReplaceOne(ctx, bson.M{"_id": rng.ID}, rng, options.Replace().SetUpsert(true))
+	return err
+}
+
+// DeleteRange deletes a discovery range by its ID
+func (r *MongoDBDiscoveryRepository) DeleteRange(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.rangeCollection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+// FindRunByID finds a scan run by its ID
+func (r *MongoDBDiscoveryRepository) FindRunByID(ctx context.Context, id primitive.ObjectID) (*model.ScanRun, error) {
+	var run model.ScanRun
+	err := r.runCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&run)
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// FindRuns finds the most recent scan runs with optional filtering
+func (r *MongoDBDiscoveryRepository) FindRuns(ctx context.Context, filter map[string]interface{}, limit int64) ([]*model.ScanRun, error) {
+	bsonFilter := bson.M{}
+	for k, v := range filter {
+		bsonFilter[k] = v
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "startedAt", Value: -1}})
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+
+	cursor, err := r.runCollection.Find(ctx, bsonFilter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var runs []*model.ScanRun
+	if err := cursor.All(ctx, &runs); err != nil {
+		return nil, err
+	}
+
+	return runs, nil
+}
+
+// SaveRun creates or updates a scan run
+func (r *MongoDBDiscoveryRepository) SaveRun(ctx context.Context, run *model.ScanRun) error {
+	if run.ID.IsZero() {
+		run.ID = primitive.NewObjectID()
+	}
+
+	_, err := r.runCollection.ReplaceOne(ctx, bson.M{"_id": run.ID}, run, options.Replace().SetUpsert(true))
+	return err
+}
+
+// FailRunningRuns marks runs left running by a previous process as failed
+func (r *MongoDBDiscoveryRepository) FailRunningRuns(ctx context.Context, reason string) (int64, error) {
+	result, err := r.runCollection.UpdateMany(ctx,
+		bson.M{"status": model.ScanRunning},
+		bson.M{"$set": bson.M{
+			"status":     model.ScanFailed,
+			"error":      reason,
+			"finishedAt": time.Now(),
+		}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// FindCandidateByID finds a discovery candidate by its ID
+func (r *MongoDBDiscoveryRepository) FindCandidateByID(ctx context.Context, id primitive.ObjectID) (*model.DiscoveryCandidate, error) {
+	var candidate model.DiscoveryCandidate
+	err := r.candidateCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&candidate)
+	if err != nil {
+		return nil, err
+	}
+	return &candidate, nil
+}
+
+// FindCandidates finds discovery candidates with optional filtering, most recently seen first
+func (r *MongoDBDiscoveryRepository) FindCandidates(ctx context.Context, filter map[string]interface{}) ([]*model.DiscoveryCandidate, error) {
+	bsonFilter := bson.M{}
+	for k, v := range filter {
+		bsonFilter[k] = v
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "lastSeen", Value: -1}})
+
+	cursor, err := r.candidateCollection.Find(ctx, bsonFilter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var candidates []*model.DiscoveryCandidate
+	if err := cursor.All(ctx, &candidates); err != nil {
+		return nil, err
+	}
+
+	return candidates, nil
+}
+
+// FindCandidateByAddress finds the candidate with a MAC address or, failing that, an IP
+// address. It returns nil when there is none.
+func (r *MongoDBDiscoveryRepository) FindCandidateByAddress(ctx context.Context, ipAddress, macAddress string) (*model.DiscoveryCandidate, error) {
+	var filters []bson.M
+	if macAddress != "" {
+		filters = append(filters, bson.M{"macAddress": macAddress})
+	}
+	if ipAddress != "" {
+		filters = append(filters, bson.M{"ipAddress": ipAddress})
+	}
+
+	for _, filter := range filters {
+		var candidate model.DiscoveryCandidate
+		err := r.candidateCollection.FindOne(ctx, filter).Decode(&candidate)
+		if err == nil {
+			return &candidate, nil
+		}
+		if err != mongo.ErrNoDocuments {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// SaveCandidate creates or updates a discovery candidate
+func (r *MongoDBDiscoveryRepository) SaveCandidate(ctx context.Context, candidate *model.DiscoveryCandidate) error {
+	if candidate.ID.IsZero() {
+		candidate.ID = primitive.NewObjectID()
+	}
+
+	_, err := r.candidateCollection.ReplaceOne(ctx, bson.M{"_id": candidate.ID}, candidate, options.Replace().SetUpsert(true))
+	return err
+}
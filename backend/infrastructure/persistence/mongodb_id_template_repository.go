@@ -0,0 +1,89 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoDBIDTemplateRepository implements the IDTemplateRepository interface using MongoDB
+type MongoDBIDTemplateRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoDBIDTemplateRepository creates a new MongoDB ID template repository
+func NewMongoDBIDTemplateRepository(db *mongo.Database) repository.IDTemplateRepository {
+	collection := db.Collection("id_templates")
+
+	// Create indexes
+	indexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "scope", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+
+	_, err := collection.Indexes().CreateOne(context.Background(), indexModel)
+	if err != nil {
+		// Log error but continue
+		fmt.Printf("Error creating ID template index: %v\n", err)
+	}
+
+	return &MongoDBIDTemplateRepository{
+		collection: collection,
+	}
+}
+
+// FindByScope finds the ID template of an asset type or of workflows
+func (r *MongoDBIDTemplateRepository) FindByScope(ctx context.Context, scope string) (*model.IDTemplate, error) {
+	var template model.IDTemplate
+	err := r.collection.FindOne(ctx, bson.M{"scope": scope}).Decode(&template)
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// FindAll finds all ID templates
+func (r *MongoDBIDTemplateRepository) FindAll(ctx context.Context) ([]*model.IDTemplate, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "scope", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var templates []*model.IDTemplate
+	if err := cursor.All(ctx, &templates); err != nil {
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+// Save creates or updates an ID template
+func (r *MongoDBIDTemplateRepository) Save(ctx context.Context, template *model.IDTemplate) error {
+	if template.ID.IsZero() {
+		template.ID = primitive.NewObjectID()
+	}
+
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": template.ID}, template, options.Replace().SetUpsert(true))
+	return err
+}
+
+// DeleteByScope deletes the ID template of a scope
+func (r *MongoDBIDTemplateRepository) DeleteByScope(ctx context.Context, scope string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"scope": scope})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoDBKubernetesClusterRepository implements the KubernetesClusterRepository interface using MongoDB
+type MongoDBKubernetesClusterRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoDBKubernetesClusterRepository creates a new MongoDB Kubernetes cluster repository
+func NewMongoDBKubernetesClusterRepository(db *mongo.Database) repository.KubernetesClusterRepository {
+	collection := db.Collection("kubernetes_clusters")
+
+	// Create indexes
+	indexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+
+	_, err := collection.Indexes().CreateOne(context.Background(), indexModel)
+	if err != nil {
+		// Log error but continue
+		fmt.Printf("Error creating Kubernetes cluster index: %v\n", err)
+	}
+
+	return &MongoDBKubernetesClusterRepository{
+		collection: collection,
+	}
+}
+
+// FindByID finds a cluster by its ID
+func (r *MongoDBKubernetesClusterRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*model.KubernetesCluster, error) {
+	var cluster model.KubernetesCluster
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&cluster)
+	if err != nil {
+		return nil, err
+	}
+	return &cluster, nil
+}
+
+// FindAll finds all clusters, optionally only the enabled ones
+func (r *MongoDBKubernetesClusterRepository) FindAll(ctx context.Context, enabledOnly bool) ([]*model.KubernetesCluster, error) {
+	filter := bson.M{}
+	if enabledOnly {
+		filter["enabled"] = true
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var clusters []*model.KubernetesCluster
+	if err := cursor.All(ctx, &clusters); err != nil {
+		return nil, err
+	}
+
+	return clusters, nil
+}
+
+// Save creates or updates a cluster
+func (r *MongoDBKubernetesClusterRepository) Save(ctx context.Context, cluster *model.KubernetesCluster) error {
+	if cluster.ID.IsZero() {
+		cluster.ID = primitive.NewObjectID()
+	}
+
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": cluster.ID}, cluster, options.Replace().SetUpsert(true))
+	return err
+}
+
+// Delete deletes a cluster by its ID
+func (r *MongoDBKubernetesClusterRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
@@ -0,0 +1,67 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+)
+
+// MongoOIDCLoginRepository implements OIDCLoginRepository using MongoDB. Sign-ins that were never
+// completed are removed by a TTL index.
+type MongoOIDCLoginRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoOIDCLoginRepository creates a new MongoDB single sign-on login repository
+func NewMongoOIDCLoginRepository(db *mongo.Database) *MongoOIDCLoginRepository {
+	collection := db.Collection("oidc_logins")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "state", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+
+	collection.Indexes().CreateMany(ctx, indexes)
+
+	return &MongoOIDCLoginRepository{
+		collection: collection,
+	}
+}
+
+// Create adds a new sign-in
+func (r *MongoOIDCLoginRepository) Create(ctx context.Context, login *model.OIDCLogin) error {
+	if login.ID.IsZero() {
+		login.ID = primitive.NewObjectID()
+	}
+
+	_, err := r.collection.InsertOne(ctx, login)
+	return err
+}
+
+// Consume removes the sign-in of a state and returns it, or returns nil when there is none
+func (r *MongoOIDCLoginRepository) Consume(ctx context.Context, state string) (*model.OIDCLogin, error) {
+	var login model.OIDCLogin
+	err := r.collection.FindOneAndDelete(ctx, bson.M{"state": state}).Decode(&login)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &login, nil
+}
@@ -0,0 +1,175 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoDBReconciliationRepository implements the ReconciliationRepository interface using MongoDB
+type MongoDBReconciliationRepository struct {
+	conflictCollection *mongo.Collection
+	proposalCollection *mongo.Collection
+}
+
+// NewMongoDBReconciliationRepository creates a new MongoDB reconciliation repository
+func NewMongoDBReconciliationRepository(db *mongo.Database) repository.ReconciliationRepository {
+	conflictCollection := db.Collection("field_conflicts")
+	proposalCollection := db.Collection("merge_proposals")
+
+	// Create indexes
+	_, err := conflictCollection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "assetId", Value: 1}, {Key: "field", Value: 1}, {Key: "proposedSource.source", Value: 1}, {Key: "status", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "updatedAt", Value: -1}},
+		},
+	})
+	if err != nil {
+		// Log error but continue
+		fmt.Printf("Error creating field conflict indexes: %v\n", err)
+	}
+
+	_, err = proposalCollection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "key", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "updatedAt", Value: -1}},
+		},
+	})
+	if err != nil {
+		fmt.Printf("Error creating merge proposal indexes: %v\n", err)
+	}
+
+	return &MongoDBReconciliationRepository{
+		conflictCollection: conflictCollection,
+		proposalCollection: proposalCollection,
+	}
+}
+
+// FindConflictByID finds a field conflict by its ID
+func (r *MongoDBReconciliationRepository) FindConflictByID(ctx context.Context, id primitive.ObjectID) (*model.FieldConflict, error) {
+	var conflict model.FieldConflict
+	err := r.conflictCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&conflict)
+	if err != nil {
+		return nil, err
+	}
+	return &conflict, nil
+}
+
+// FindConflicts finds field conflicts with optional filtering, most recently reported first
+func (r *MongoDBReconciliationRepository) FindConflicts(ctx context.Context, filter map[string]interface{}) ([]*model.FieldConflict, error) {
+	bsonFilter := bson.M{}
+	for k, v := range filter {
+		bsonFilter[k] = v
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "updatedAt", Value: -1}})
+
+	cursor, err := r.conflictCollection.Find(ctx, bsonFilter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var conflicts []*model.FieldConflict
+	if err := cursor.All(ctx, &conflicts); err != nil {
+		return nil, err
+	}
+
+	return conflicts, nil
+}
+
+// FindOpenConflict finds the open conflict of an asset field reported by a source. It returns
+// nil when there is none.
+func (r *MongoDBReconciliationRepository) FindOpenConflict(ctx context.Context, assetID primitive.ObjectID, field, source string) (*model.FieldConflict, error) {
+	var conflict model.FieldConflict
+	err := r.conflictCollection.FindOne(ctx, bson.M{
+		"assetId":               assetID,
+		"field":                 field,
+		"proposedSource.source": source,
+		"status":                model.ConflictOpen,
+	}).Decode(&conflict)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &conflict, nil
+}
+
+// SaveConflict creates or updates a field conflict
+func (r *MongoDBReconciliationRepository) SaveConflict(ctx context.Context, conflict *model.FieldConflict) error {
+	if conflict.ID.IsZero() {
+		conflict.ID = primitive.NewObjectID()
+	}
+
+	_, err := r.conflictCollection.ReplaceOne(ctx, bson.M{"_id": conflict.ID}, conflict, options.Replace().SetUpsert(true))
+	return err
+}
+
+// FindMergeProposalByID finds a merge proposal by its ID
+func (r *MongoDBReconciliationRepository) FindMergeProposalByID(ctx context.Context, id primitive.ObjectID) (*model.MergeProposal, error) {
+	var proposal model.MergeProposal
+	err := r.proposalCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&proposal)
+	if err != nil {
+		return nil, err
+	}
+	return &proposal, nil
+}
+
+// FindMergeProposals finds merge proposals with optional filtering, most recently updated first
+func (r *MongoDBReconciliationRepository) FindMergeProposals(ctx context.Context, filter map[string]interface{}) ([]*model.MergeProposal, error) {
+	bsonFilter := bson.M{}
+	for k, v := range filter {
+		bsonFilter[k] = v
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "updatedAt", Value: -1}})
+
+	cursor, err := r.proposalCollection.Find(ctx, bsonFilter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var proposals []*model.MergeProposal
+	if err := cursor.All(ctx, &proposals); err != nil {
+		return nil, err
+	}
+
+	return proposals, nil
+}
+
+// FindMergeProposalByKey finds the proposal for a pair of assets. It returns nil when there is none.
+func (r *MongoDBReconciliationRepository) FindMergeProposalByKey(ctx context.Context, key string) (*model.MergeProposal, error) {
+	var proposal model.MergeProposal
+	err := r.proposalCollection.FindOne(ctx, bson.M{"key": key}).Decode(&proposal)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &proposal, nil
+}
+
+// SaveMergeProposal creates or updates a merge proposal
+func (r *MongoDBReconciliationRepository) SaveMergeProposal(ctx context.Context, proposal *model.MergeProposal) error {
+	if proposal.ID.IsZero() {
+		proposal.ID = primitive.NewObjectID()
+	}
+
+	_, err := r.proposalCollection.ReplaceOne(ctx, bson.M{"_id": proposal.ID}, proposal, options.Replace().SetUpsert(true))
+	return err
+}
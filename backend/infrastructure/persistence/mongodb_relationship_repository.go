@@ -0,0 +1,136 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoDBRelationshipRepository implements the RelationshipRepository interface using MongoDB
+type MongoDBRelationshipRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoDBRelationshipRepository creates a new MongoDB relationship repository
+func NewMongoDBRelationshipRepository(db *mongo.Database) repository.RelationshipRepository {
+	collection := db.Collection("asset_relationships")
+
+	// Create indexes
+	indexModels := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "sourceId", Value: 1}, {Key: "targetId", Value: 1}, {Key: "type", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "targetId", Value: 1}},
+		},
+	}
+
+	_, err := collection.Indexes().CreateMany(context.Background(), indexModels)
+	if err != nil {
+		// Log error but continue
+		fmt.Printf("Error creating relationship indexes: %v\n", err)
+	}
+
+	return &MongoDBRelationshipRepository{
+		collection: collection,
+	}
+}
+
+// FindByID finds a relationship by its ID
+func (r *MongoDBRelationshipRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*model.Relationship, error) {
+	var relationship model.Relationship
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&relationship)
+	if err != nil {
+		return nil, err
+	}
+	return &relationship, nil
+}
+
+// FindBySourceID finds all relationships originating from an asset
+func (r *MongoDBRelationshipRepository) FindBySourceID(ctx context.Context, sourceID primitive.ObjectID) ([]*model.Relationship, error) {
+	return r.find(ctx, bson.M{"sourceId": sourceID})
+}
+
+// FindByTargetID finds all relationships pointing at an asset
+func (r *MongoDBRelationshipRepository) FindByTargetID(ctx context.Context, targetID primitive.ObjectID) ([]*model.Relationship, error) {
+	return r.find(ctx, bson.M{"targetId": targetID})
+}
+
+// FindByAssetID finds all relationships where the asset is either source or target
+func (r *MongoDBRelationshipRepository) FindByAssetID(ctx context.Context, assetID primitive.ObjectID) ([]*model.Relationship, error) {
+	return r.find(ctx, bson.M{
+		"$or": []bson.M{
+			{"sourceId": assetID},
+			{"targetId": assetID},
+		},
+	})
+}
+
+// Exists checks if an identical relationship already exists
+func (r *MongoDBRelationshipRepository) Exists(ctx context.Context, sourceID, targetID primitive.ObjectID, relationshipType model.RelationshipType) (bool, error) {
+	count, err := r.collection.CountDocuments(ctx, bson.M{
+		"sourceId": sourceID,
+		"targetId": targetID,
+		"type":     relationshipType,
+	})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// Save creates or updates a relationship
+func (r *MongoDBRelationshipRepository) Save(ctx context.Context, relationship *model.Relationship) error {
+	if relationship.ID.IsZero() {
+		// Create new relationship
+		relationship.ID = primitive.NewObjectID()
+		_, err := r.collection.InsertOne(ctx, relationship)
+		return err
+	}
+
+	// Update existing relationship
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": relationship.ID}, relationship)
+	return err
+}
+
+// Delete deletes a relationship by its ID
+func (r *MongoDBRelationshipRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+// DeleteByAssetID deletes all relationships involving an asset
+func (r *MongoDBRelationshipRepository) DeleteByAssetID(ctx context.Context, assetID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{
+		"$or": []bson.M{
+			{"sourceId": assetID},
+			{"targetId": assetID},
+		},
+	})
+	return err
+}
+
+// find runs a query sorted by creation time
+func (r *MongoDBRelationshipRepository) find(ctx context.Context, filter bson.M) ([]*model.Relationship, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var relationships []*model.Relationship
+	if err := cursor.All(ctx, &relationships); err != nil {
+		return nil, err
+	}
+
+	return relationships, nil
+}
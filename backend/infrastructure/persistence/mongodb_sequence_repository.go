@@ -0,0 +1,44 @@
+package persistence
+
+import (
+	"context"
+
+	"github.com/phuhao00/cmdb/backend/domain/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoDBSequenceRepository implements the SequenceRepository interface using MongoDB.
+// Each sequence is a document keyed by its name and incremented with $inc, so concurrent
+// callers never receive the same value.
+type MongoDBSequenceRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoDBSequenceRepository creates a new MongoDB sequence repository
+func NewMongoDBSequenceRepository(db *mongo.Database) repository.SequenceRepository {
+	return &MongoDBSequenceRepository{
+		collection: db.Collection("sequences"),
+	}
+}
+
+// Next increments a sequence and returns its new value; a new sequence starts at 1
+func (r *MongoDBSequenceRepository) Next(ctx context.Context, name string) (int64, error) {
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var result struct {
+		Value int64 `bson:"value"`
+	}
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": name}, bson.M{"$inc": bson.M{"value": int64(1)}}, opts).Decode(&result)
+	if err != nil {
+		return 0, err
+	}
+	return result.Value, nil
+}
+
+// EnsureAtLeast raises a sequence to value unless it is already higher
+func (r *MongoDBSequenceRepository) EnsureAtLeast(ctx context.Context, name string, value int64) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": name}, bson.M{"$max": bson.M{"value": value}}, options.Update().SetUpsert(true))
+	return err
+}
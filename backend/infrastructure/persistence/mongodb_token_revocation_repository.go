@@ -0,0 +1,65 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+)
+
+// MongoTokenRevocationRepository implements TokenRevocationRepository using MongoDB. Expired
+// revocations are removed by a TTL index.
+type MongoTokenRevocationRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoTokenRevocationRepository creates a new MongoDB token revocation repository
+func NewMongoTokenRevocationRepository(db *mongo.Database) *MongoTokenRevocationRepository {
+	collection := db.Collection("token_revocations")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+
+	collection.Indexes().CreateMany(ctx, indexes)
+
+	return &MongoTokenRevocationRepository{
+		collection: collection,
+	}
+}
+
+// Create adds a new revocation
+func (r *MongoTokenRevocationRepository) Create(ctx context.Context, revocation *model.TokenRevocation) error {
+	if revocation.ID.IsZero() {
+		revocation.ID = primitive.NewObjectID()
+	}
+
+	_, err := r.collection.InsertOne(ctx, revocation)
+	return err
+}
+
+// FindActive finds the revocations that have not expired yet
+func (r *MongoTokenRevocationRepository) FindActive(ctx context.Context) ([]*model.TokenRevocation, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"expiresAt": bson.M{"$gt": time.Now()}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var revocations []*model.TokenRevocation
+	if err := cursor.All(ctx, &revocations); err != nil {
+		return nil, err
+	}
+	return revocations, nil
+}
@@ -34,6 +34,10 @@ func NewMongoDBUserRepository(database *mongo.Database) repository.UserRepositor
 			Keys:    bson.D{{Key: "email", Value: 1}},
 			Options: options.Index().SetUnique(true),
 		},
+		{
+			Keys:    bson.D{{Key: "externalId", Value: 1}},
+			Options: options.Index().SetUnique(true).SetSparse(true),
+		},
 	})
 
 	_, _ = sessionCollection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
@@ -45,6 +49,9 @@ func NewMongoDBUserRepository(database *mongo.Database) repository.UserRepositor
 			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
 			Options: options.Index().SetExpireAfterSeconds(0),
 		},
+		{
+			Keys: bson.D{{Key: "familyId", Value: 1}},
+		},
 	})
 
 	return &MongoDBUserRepository{
@@ -103,6 +110,32 @@ func (r *MongoDBUserRepository) GetByEmail(ctx context.Context, email string) (*
 	return &user, nil
 }
 
+// GetByExternalID retrieves a user provisioned from an identity provider by their ID there
+func (r *MongoDBUserRepository) GetByExternalID(ctx context.Context, externalID string) (*model.User, error) {
+	var user model.User
+	err := r.userCollection.FindOne(ctx, bson.M{"externalId": externalID}).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetByChannelID retrieves the user whose account at an approval channel has an ID
+func (r *MongoDBUserRepository) GetByChannelID(ctx context.Context, channel, accountID string) (*model.User, error) {
+	var user model.User
+	err := r.userCollection.FindOne(ctx, bson.M{"channelIds." + channel: accountID}).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
 // Update updates a user
 func (r *MongoDBUserRepository) Update(ctx context.Context, user *model.User) error {
 	user.UpdatedAt = time.Now()
@@ -125,7 +158,7 @@ func (r *MongoDBUserRepository) List(ctx context.Context, limit, offset int) ([]
 	cursor, err := r.userCollection.Find(
 		ctx,
 		bson.M{},
-		options.Find().SetLimit(int64(limit)).SetSkip(int64(offset)),
+		options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(limit)).SetSkip(int64(offset)),
 	)
 	if err != nil {
 		return nil, err
@@ -167,6 +200,24 @@ func (r *MongoDBUserRepository) GetSessionByToken(ctx context.Context, token str
 	return &session, nil
 }
 
+// RotateSession marks the session of a token hash rotated, unless it already is, and returns it
+// as it was before
+func (r *MongoDBUserRepository) RotateSession(ctx context.Context, token string) (*model.Session, error) {
+	var session model.Session
+	err := r.sessionCollection.FindOneAndUpdate(
+		ctx,
+		bson.M{"token": token, "rotatedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"rotatedAt": time.Now()}},
+	).Decode(&session)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
 // DeleteSession deletes a session by token
 func (r *MongoDBUserRepository) DeleteSession(ctx context.Context, token string) error {
 	_, err := r.sessionCollection.DeleteOne(ctx, bson.M{"token": token})
@@ -186,3 +237,9 @@ func (r *MongoDBUserRepository) DeleteUserSessions(ctx context.Context, userID p
 	_, err := r.sessionCollection.DeleteMany(ctx, bson.M{"userId": userID})
 	return err
 }
+
+// DeleteSessionFamily deletes every session of a family, rotated or not
+func (r *MongoDBUserRepository) DeleteSessionFamily(ctx context.Context, familyID primitive.ObjectID) error {
+	_, err := r.sessionCollection.DeleteMany(ctx, bson.M{"familyId": familyID})
+	return err
+}
@@ -3,7 +3,6 @@ package persistence
 import (
 	"context"
 	"fmt"
-	"time"
 
 	"github.com/phuhao00/cmdb/backend/domain/model"
 	"github.com/phuhao00/cmdb/backend/domain/repository"
@@ -29,8 +28,9 @@ func NewMongoDBWorkflowRepository(db *mongo.Database) repository.WorkflowReposit
 			Options: options.Index().SetUnique(true),
 		},
 		{
-			Keys:    bson.D{{Key: "feishuId", Value: 1}},
-			Options: options.Index().SetUnique(true).SetSparse(true),
+			Keys: bson.D{{Key: "approvalChannel", Value: 1}, {Key: "externalId", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"externalId": bson.M{"$exists": true}}),
 		},
 		{
 			Keys: bson.D{{Key: "assetId", Value: 1}},
@@ -68,10 +68,10 @@ func (r *MongoDBWorkflowRepository) FindByWorkflowID(ctx context.Context, workfl
 	return &workflow, nil
 }
 
-// FindByFeishuID finds a workflow by its Feishu ID
-func (r *MongoDBWorkflowRepository) FindByFeishuID(ctx context.Context, feishuID string) (*model.Workflow, error) {
+// FindByExternalID finds a workflow by the approval channel and external ID it was sent to
+func (r *MongoDBWorkflowRepository) FindByExternalID(ctx context.Context, channel, externalID string) (*model.Workflow, error) {
 	var workflow model.Workflow
-	err := r.collection.FindOne(ctx, bson.M{"feishuId": feishuID}).Decode(&workflow)
+	err := r.collection.FindOne(ctx, bson.M{"approvalChannel": channel, "externalId": externalID}).Decode(&workflow)
 	if err != nil {
 		return nil, err
 	}
@@ -136,6 +136,29 @@ func (r *MongoDBWorkflowRepository) Save(ctx context.Context, workflow *model.Wo
 	return err
 }
 
+// UpdatePending updates a workflow unless it was decided or moved past the stage it was read at.
+// Workflows stored before approval chains existed have no currentStage and are at the first stage.
+func (r *MongoDBWorkflowRepository) UpdatePending(ctx context.Context, workflow *model.Workflow, stage int) (bool, error) {
+	filter := bson.M{
+		"_id":          workflow.ID,
+		"status":       model.PendingStatus,
+		"currentStage": stage,
+	}
+	if stage == 0 {
+		delete(filter, "currentStage")
+		filter["$or"] = []bson.M{
+			{"currentStage": 0},
+			{"currentStage": bson.M{"$exists": false}},
+		}
+	}
+
+	result, err := r.collection.ReplaceOne(ctx, filter, workflow)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
 // Delete deletes a workflow by its ID
 func (r *MongoDBWorkflowRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
 	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
@@ -223,7 +246,107 @@ func (r *MongoDBWorkflowRepository) GetWorkflowTypeStats(ctx context.Context) (m
 	return stats, nil
 }
 
-// GenerateWorkflowID generates a unique workflow ID
-func (r *MongoDBWorkflowRepository) GenerateWorkflowID(ctx context.Context) (string, error) {
-	return fmt.Sprintf("WF-%d", time.Now().Unix()), nil
+// GetSLAStats gets SLA breach counts by priority and the mean time from creation to approval
+func (r *MongoDBWorkflowRepository) GetSLAStats(ctx context.Context) (*model.WorkflowSLAStats, error) {
+	stats := &model.WorkflowSLAStats{
+		BreachedByPriority: make(map[string]int64),
+	}
+
+	breachPipeline := []bson.M{
+		{"$match": bson.M{"slaBreached": true}},
+		{
+			"$group": bson.M{
+				"_id":     "$priority",
+				"count":   bson.M{"$sum": 1},
+				"pending": bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$status", model.PendingStatus}}, 1, 0}}},
+			},
+		},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, breachPipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	var breaches []struct {
+		Priority string `bson:"_id"`
+		Count    int64  `bson:"count"`
+		Pending  int64  `bson:"pending"`
+	}
+	if err := cursor.All(ctx, &breaches); err != nil {
+		return nil, err
+	}
+
+	for _, breach := range breaches {
+		stats.BreachedByPriority[breach.Priority] = breach.Count
+		stats.Breached += breach.Count
+		stats.BreachedPending += breach.Pending
+	}
+
+	approvalPipeline := []bson.M{
+		{"$match": bson.M{"approvedAt": bson.M{"$exists": true}}},
+		{
+			"$group": bson.M{
+				"_id":    nil,
+				"count":  bson.M{"$sum": 1},
+				"meanMs": bson.M{"$avg": bson.M{"$subtract": bson.A{"$approvedAt", "$createdAt"}}},
+			},
+		},
+	}
+
+	cursor, err = r.collection.Aggregate(ctx, approvalPipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	var approvals []struct {
+		Count  int64   `bson:"count"`
+		MeanMs float64 `bson:"meanMs"`
+	}
+	if err := cursor.All(ctx, &approvals); err != nil {
+		return nil, err
+	}
+
+	if len(approvals) > 0 {
+		stats.Approved = approvals[0].Count
+		stats.MeanTimeToApproveSeconds = approvals[0].MeanMs / 1000
+	}
+
+	return stats, nil
+}
+
+// FindWorkflowIDsMatching finds the workflow IDs matching a regular expression
+func (r *MongoDBWorkflowRepository) FindWorkflowIDsMatching(ctx context.Context, pattern string) ([]string, error) {
+	opts := options.Find().SetProjection(bson.M{"workflowId": 1})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"workflowId": bson.M{"$regex": pattern}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		WorkflowID string `bson:"workflowId"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(results))
+	for i, result := range results {
+		ids[i] = result.WorkflowID
+	}
+	return ids, nil
+}
+
+// ReassignAsset points the workflows of an asset merged into another one at the surviving asset
+func (r *MongoDBWorkflowRepository) ReassignAsset(ctx context.Context, fromAssetID, toAssetID, toAssetName string) (int64, error) {
+	result, err := r.collection.UpdateMany(ctx,
+		bson.M{"assetId": fromAssetID},
+		bson.M{"$set": bson.M{"assetId": toAssetID, "assetName": toAssetName}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
 }
@@ -0,0 +1,63 @@
+package persistence
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestUpdatePendingLegacyWorkflow(t *testing.T) {
+	database := testDatabase(t)
+	workflowRepo := NewMongoDBWorkflowRepository(database)
+	ctx := context.Background()
+
+	// Stored before approval chains existed: no stages and no currentStage field
+	id := primitive.NewObjectID()
+	_, err := database.Collection("workflows").InsertOne(ctx, bson.M{
+		"_id":        id,
+		"workflowId": "WF-LEGACY",
+		"type":       model.AssetUpdateType,
+		"status":     model.PendingStatus,
+		"priority":   model.MediumPriority,
+		"requester":  "alice",
+		"createdAt":  time.Now(),
+		"updatedAt":  time.Now(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	workflow, err := workflowRepo.FindByID(ctx, id)
+	if err != nil {
+		t.Fatalf("FindByID() error = %v", err)
+	}
+	stage := workflow.CurrentStage
+	if !workflow.ApproveStage("u1", "bob", "ok") {
+		t.Fatal("ApproveStage() of the only stage did not approve the workflow")
+	}
+
+	updated, err := workflowRepo.UpdatePending(ctx, workflow, stage)
+	if err != nil {
+		t.Fatalf("UpdatePending() error = %v", err)
+	}
+	if !updated {
+		t.Fatal("UpdatePending() of a pending workflow without currentStage = false, want true")
+	}
+
+	stored, err := workflowRepo.FindByID(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != model.ApprovedStatus || stored.CurrentStage != 1 {
+		t.Errorf("stored workflow = %s at stage %d, want approved at stage 1", stored.Status, stored.CurrentStage)
+	}
+
+	// Once decided it is not pending at any stage
+	if updated, err := workflowRepo.UpdatePending(ctx, workflow, stage); err != nil || updated {
+		t.Errorf("UpdatePending() of a decided workflow = %v, %v; want false", updated, err)
+	}
+}
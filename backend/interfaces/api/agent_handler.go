@@ -0,0 +1,110 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/phuhao00/cmdb/backend/application"
+	"github.com/phuhao00/cmdb/backend/domain/service"
+)
+
+// maxAgentReportSize bounds the body of a facts report; package lists make up most of it
+const maxAgentReportSize = 8 << 20
+
+// AgentHandler handles HTTP requests from inventory agents and for managing agent tokens
+type AgentHandler struct {
+	agentApp *application.AgentApplication
+}
+
+// NewAgentHandler creates a new agent handler
+func NewAgentHandler(agentApp *application.AgentApplication) *AgentHandler {
+	return &AgentHandler{
+		agentApp: agentApp,
+	}
+}
+
+// RegisterRoutes registers the agent token routes
+func (h *AgentHandler) RegisterRoutes(router *gin.RouterGroup) {
+	tokens := router.Group("/agent-tokens")
+	{
+		tokens.GET("", h.GetTokens)
+		tokens.POST("", h.CreateToken)
+		tokens.DELETE("/:id", h.RevokeToken)
+	}
+}
+
+// ReportFacts handles POST /agent/facts
+func (h *AgentHandler) ReportFacts(c *gin.Context) {
+	agent, exists := c.Get("agent")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Agent token required"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxAgentReportSize)
+
+	var dto application.AgentReportDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.agentApp.ReportFacts(c.Request.Context(), agent.(*application.AgentTokenDTO), dto)
+	if err != nil {
+		status := assetErrorStatus(err)
+		if errors.Is(err, service.ErrMachineIDRequired) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetTokens handles GET /agent-tokens
+func (h *AgentHandler) GetTokens(c *gin.Context) {
+	tokens, err := h.agentApp.GetTokens(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// CreateToken handles POST /agent-tokens
+func (h *AgentHandler) CreateToken(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	var dto application.AgentTokenCreateDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userDTO := user.(*application.UserDTO)
+	token, err := h.agentApp.CreateToken(c.Request.Context(), dto, userDTO.Username)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, token)
+}
+
+// RevokeToken handles DELETE /agent-tokens/:id
+func (h *AgentHandler) RevokeToken(c *gin.Context) {
+	token, err := h.agentApp.RevokeToken(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Agent token not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, token)
+}
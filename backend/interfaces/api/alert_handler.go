@@ -0,0 +1,216 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/phuhao00/cmdb/backend/application"
+)
+
+// AlertHandler handles HTTP requests for alerts and alert rules
+type AlertHandler struct {
+	alertApp *application.AlertApplication
+}
+
+// NewAlertHandler creates a new alert handler
+func NewAlertHandler(alertApp *application.AlertApplication) *AlertHandler {
+	return &AlertHandler{
+		alertApp: alertApp,
+	}
+}
+
+// RegisterRoutes registers the alert routes
+func (h *AlertHandler) RegisterRoutes(router *gin.RouterGroup) {
+	alerts := router.Group("/alerts")
+	{
+		alerts.GET("", h.GetAlerts)
+		alerts.GET("/stats", h.GetAlertStats)
+		alerts.GET("/:id", h.GetAlertByID)
+		alerts.PUT("/:id/acknowledge", h.AcknowledgeAlert)
+		alerts.PUT("/:id/resolve", h.ResolveAlert)
+		alerts.PUT("/:id/close", h.CloseAlert)
+	}
+
+	rules := router.Group("/alert-rules")
+	{
+		rules.GET("", h.GetRules)
+		rules.POST("", h.CreateRule)
+		rules.POST("/evaluate", h.EvaluateRules)
+		rules.GET("/:id", h.GetRuleByID)
+		rules.PUT("/:id", h.UpdateRule)
+		rules.DELETE("/:id", h.DeleteRule)
+	}
+}
+
+// GetAlerts handles GET /alerts
+func (h *AlertHandler) GetAlerts(c *gin.Context) {
+	var filter application.AlertFilterDTO
+
+	// Bind query parameters
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	alerts, err := h.alertApp.GetAlerts(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, alerts)
+}
+
+// GetAlertStats handles GET /alerts/stats
+func (h *AlertHandler) GetAlertStats(c *gin.Context) {
+	stats, err := h.alertApp.GetAlertStats(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetAlertByID handles GET /alerts/:id
+func (h *AlertHandler) GetAlertByID(c *gin.Context) {
+	id := c.Param("id")
+
+	alert, err := h.alertApp.GetAlertByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Alert not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, alert)
+}
+
+// AcknowledgeAlert handles PUT /alerts/:id/acknowledge
+func (h *AlertHandler) AcknowledgeAlert(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	userDTO := user.(*application.UserDTO)
+	alert, err := h.alertApp.AcknowledgeAlert(c.Request.Context(), c.Param("id"), userDTO.ID, userDTO.Username)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, alert)
+}
+
+// ResolveAlert handles PUT /alerts/:id/resolve
+func (h *AlertHandler) ResolveAlert(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	var dto application.ResolveAlertDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userDTO := user.(*application.UserDTO)
+	alert, err := h.alertApp.ResolveAlert(c.Request.Context(), c.Param("id"), userDTO.ID, userDTO.Username, dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, alert)
+}
+
+// CloseAlert handles PUT /alerts/:id/close
+func (h *AlertHandler) CloseAlert(c *gin.Context) {
+	alert, err := h.alertApp.CloseAlert(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, alert)
+}
+
+// GetRules handles GET /alert-rules
+func (h *AlertHandler) GetRules(c *gin.Context) {
+	rules, err := h.alertApp.GetRules(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// GetRuleByID handles GET /alert-rules/:id
+func (h *AlertHandler) GetRuleByID(c *gin.Context) {
+	rule, err := h.alertApp.GetRuleByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Alert rule not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// CreateRule handles POST /alert-rules
+func (h *AlertHandler) CreateRule(c *gin.Context) {
+	var dto application.AlertRuleSaveDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := h.alertApp.CreateRule(c.Request.Context(), dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// UpdateRule handles PUT /alert-rules/:id
+func (h *AlertHandler) UpdateRule(c *gin.Context) {
+	var dto application.AlertRuleSaveDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := h.alertApp.UpdateRule(c.Request.Context(), c.Param("id"), dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteRule handles DELETE /alert-rules/:id
+func (h *AlertHandler) DeleteRule(c *gin.Context) {
+	if err := h.alertApp.DeleteRule(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Alert rule deleted successfully"})
+}
+
+// EvaluateRules handles POST /alert-rules/evaluate
+func (h *AlertHandler) EvaluateRules(c *gin.Context) {
+	result, err := h.alertApp.EvaluateRules(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
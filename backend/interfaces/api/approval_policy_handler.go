@@ -0,0 +1,98 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/phuhao00/cmdb/backend/application"
+)
+
+// ApprovalPolicyHandler handles HTTP requests for approval policies
+type ApprovalPolicyHandler struct {
+	policyApp *application.ApprovalPolicyApplication
+}
+
+// NewApprovalPolicyHandler creates a new approval policy handler
+func NewApprovalPolicyHandler(policyApp *application.ApprovalPolicyApplication) *ApprovalPolicyHandler {
+	return &ApprovalPolicyHandler{
+		policyApp: policyApp,
+	}
+}
+
+// RegisterRoutes registers the approval policy routes
+func (h *ApprovalPolicyHandler) RegisterRoutes(router *gin.RouterGroup) {
+	policies := router.Group("/approval-policies")
+	{
+		policies.GET("", h.GetPolicies)
+		policies.POST("", h.CreatePolicy)
+		policies.GET("/:id", h.GetPolicyByID)
+		policies.PUT("/:id", h.UpdatePolicy)
+		policies.DELETE("/:id", h.DeletePolicy)
+	}
+}
+
+// GetPolicies handles GET /approval-policies
+func (h *ApprovalPolicyHandler) GetPolicies(c *gin.Context) {
+	policies, err := h.policyApp.GetPolicies(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, policies)
+}
+
+// GetPolicyByID handles GET /approval-policies/:id
+func (h *ApprovalPolicyHandler) GetPolicyByID(c *gin.Context) {
+	policy, err := h.policyApp.GetPolicyByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Approval policy not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// CreatePolicy handles POST /approval-policies
+func (h *ApprovalPolicyHandler) CreatePolicy(c *gin.Context) {
+	var dto application.ApprovalPolicySaveDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy, err := h.policyApp.CreatePolicy(c.Request.Context(), dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, policy)
+}
+
+// UpdatePolicy handles PUT /approval-policies/:id
+func (h *ApprovalPolicyHandler) UpdatePolicy(c *gin.Context) {
+	var dto application.ApprovalPolicySaveDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy, err := h.policyApp.UpdatePolicy(c.Request.Context(), c.Param("id"), dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// DeletePolicy handles DELETE /approval-policies/:id
+func (h *ApprovalPolicyHandler) DeletePolicy(c *gin.Context) {
+	if err := h.policyApp.DeletePolicy(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Approval policy deleted successfully"})
+}
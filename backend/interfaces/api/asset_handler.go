@@ -2,12 +2,16 @@ package api
 
 import (
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"net/http"
+	"sort"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/phuhao00/cmdb/backend/application"
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/service"
 )
 
 // AssetHandler handles HTTP requests for assets
@@ -39,6 +43,8 @@ func (h *AssetHandler) RegisterRoutes(router *gin.RouterGroup) {
 		assets.GET("/costs", h.GetAssetCosts)
 		assets.GET("/critical", h.GetCriticalAssets)
 		assets.PUT("/:id/costs", h.UpdateAssetCosts)
+		assets.PUT("/:id/status", h.ChangeStatus)
+		assets.GET("/lifecycle", h.GetLifecycle)
 
 		// Tag management endpoints
 		assets.POST("/:id/tags", h.AddTags)
@@ -55,6 +61,10 @@ func (h *AssetHandler) RegisterRoutes(router *gin.RouterGroup) {
 
 		// History endpoints
 		assets.GET("/:id/history", h.GetAssetHistory)
+		assets.GET("/:id/as-of", h.GetAssetAsOf)
+		assets.GET("/:id/diff", h.DiffAssetStates)
+		assets.GET("/:id/revisions/:revisionId/revert-preview", h.PreviewRevert)
+		assets.POST("/:id/revisions/:revisionId/revert", h.RevertAsset)
 	}
 }
 
@@ -125,7 +135,7 @@ func (h *AssetHandler) CreateAsset(c *gin.Context) {
 
 	asset, err := h.assetApp.CreateAssetWithApproval(c.Request.Context(), createDTO)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(assetErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -158,7 +168,7 @@ func (h *AssetHandler) UpdateAsset(c *gin.Context) {
 
 	err := h.assetApp.UpdateAssetWithApproval(c.Request.Context(), id, updateDTO)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(assetErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -180,7 +190,7 @@ func (h *AssetHandler) RequestDecommission(c *gin.Context) {
 
 	err := h.assetApp.RequestDecommission(c.Request.Context(), id, user.(*application.UserDTO).Username, user.(*application.UserDTO).ID, "Asset decommission request")
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(assetErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -189,6 +199,60 @@ func (h *AssetHandler) RequestDecommission(c *gin.Context) {
 	})
 }
 
+// ChangeStatus handles PUT /assets/:id/status
+func (h *AssetHandler) ChangeStatus(c *gin.Context) {
+	// Get user from context (set by auth middleware)
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	id := c.Param("id")
+
+	var dto application.AssetStatusChangeDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	asset, workflow, err := h.assetApp.ChangeAssetStatus(c.Request.Context(), id, user.(*application.UserDTO), dto)
+	if err != nil {
+		c.JSON(assetErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if workflow != nil {
+		c.JSON(http.StatusAccepted, gin.H{
+			"workflow": workflow,
+			"message":  "Status change submitted for approval",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"asset":   asset,
+		"message": "Asset status changed",
+	})
+}
+
+// GetLifecycle handles GET /assets/lifecycle
+func (h *AssetHandler) GetLifecycle(c *gin.Context) {
+	c.JSON(http.StatusOK, h.assetApp.GetLifecycle())
+}
+
+// assetErrorStatus reports illegal lifecycle transitions as conflicts and assets that do not
+// match their CI type schema as bad requests
+func assetErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, model.ErrIllegalTransition):
+		return http.StatusConflict
+	case errors.Is(err, model.ErrSchemaViolation), errors.Is(err, service.ErrUnknownCIType):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
 // GetAssetStats handles GET /assets/stats
 func (h *AssetHandler) GetAssetStats(c *gin.Context) {
 	stats, err := h.assetApp.GetAssetStats(c.Request.Context())
@@ -211,7 +275,7 @@ func (h *AssetHandler) BulkCreateAssets(c *gin.Context) {
 
 	count, err := h.assetApp.BulkCreateAssets(c.Request.Context(), createDTOs)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(assetErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -423,8 +487,22 @@ func (h *AssetHandler) ExportAssets(c *gin.Context) {
 	writer := csv.NewWriter(c.Writer)
 	defer writer.Flush()
 
+	// One column per CI type attribute used by any exported asset
+	attributeSet := make(map[string]bool)
+	for _, asset := range assets {
+		for name := range asset.Attributes {
+			attributeSet[name] = true
+		}
+	}
+	attributes := make([]string, 0, len(attributeSet))
+	for name := range attributeSet {
+		attributes = append(attributes, name)
+	}
+	sort.Strings(attributes)
+
 	// Write CSV headers
-	headers := []string{"Asset ID", "Name", "Type", "Status", "Location", "Department", "Owner", "IP Address", "Cost", "Purchase Date", "Warranty Expiry", "Description", "Serial Number", "Manufacturer", "Model", "Created At", "Updated At"}
+	headers := []string{"Asset ID", "Name", "Type", "Status", "Location", "Department", "Owner", "IP Address", "Purchase Price", "Annual Cost", "Currency", "Description", "Created At", "Updated At"}
+	headers = append(headers, attributes...)
 	if err := writer.Write(headers); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to write CSV headers"})
 		return
@@ -441,16 +519,21 @@ func (h *AssetHandler) ExportAssets(c *gin.Context) {
 			asset.Department,
 			asset.Owner,
 			asset.IPAddress,
-			fmt.Sprintf("%.2f", asset.Cost),
-			asset.PurchaseDate.Format("2006-01-02"),
-			asset.WarrantyExpiry.Format("2006-01-02"),
+			fmt.Sprintf("%.2f", asset.PurchasePrice),
+			fmt.Sprintf("%.2f", asset.AnnualCost),
+			asset.Currency,
 			asset.Description,
-			asset.SerialNumber,
-			asset.Manufacturer,
-			asset.Model,
 			asset.CreatedAt.Format("2006-01-02 15:04:05"),
 			asset.UpdatedAt.Format("2006-01-02 15:04:05"),
 		}
+		for _, name := range attributes {
+			value, ok := asset.Attributes[name]
+			if !ok || value == nil {
+				record = append(record, "")
+				continue
+			}
+			record = append(record, fmt.Sprint(value))
+		}
 		if err := writer.Write(record); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to write CSV record"})
 			return
@@ -470,3 +553,121 @@ func (h *AssetHandler) GetAssetHistory(c *gin.Context) {
 
 	c.JSON(http.StatusOK, histories)
 }
+
+// parsePointInTime parses an RFC 3339 timestamp, or a date standing for the end of that day in UTC
+func parsePointInTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	day, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, expected RFC 3339 or YYYY-MM-DD", value)
+	}
+	return day.Add(24*time.Hour - time.Nanosecond), nil
+}
+
+// assetStateErrorStatus reports invalid time ranges as bad requests, and assets that cannot be
+// found, or did not exist yet, as not found
+func assetStateErrorStatus(err error) int {
+	if errors.Is(err, service.ErrInvalidTimeRange) {
+		return http.StatusBadRequest
+	}
+	return http.StatusNotFound
+}
+
+// GetAssetAsOf handles GET /assets/:id/as-of?time=
+func (h *AssetHandler) GetAssetAsOf(c *gin.Context) {
+	asOf, err := parsePointInTime(c.Query("time"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	state, err := h.assetApp.GetAssetAsOf(c.Request.Context(), c.Param("id"), asOf)
+	if err != nil {
+		c.JSON(assetStateErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, state)
+}
+
+// DiffAssetStates handles GET /assets/:id/diff?from=&to=; to defaults to now
+func (h *AssetHandler) DiffAssetStates(c *gin.Context) {
+	from, err := parsePointInTime(c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	to := time.Now()
+	if value := c.Query("to"); value != "" {
+		if to, err = parsePointInTime(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	diff, err := h.assetApp.DiffAssetStates(c.Request.Context(), c.Param("id"), from, to)
+	if err != nil {
+		c.JSON(assetStateErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
+
+// revertErrorStatus reports revisions that cannot be reverted to as bad requests and reverts
+// the lifecycle or the CI type schema reject like other asset changes
+func revertErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, model.ErrInvalidRevision):
+		return http.StatusBadRequest
+	case errors.Is(err, model.ErrNothingToRevert):
+		return http.StatusConflict
+	case errors.Is(err, model.ErrIllegalTransition), errors.Is(err, model.ErrSchemaViolation), errors.Is(err, service.ErrUnknownCIType):
+		return assetErrorStatus(err)
+	}
+	return assetStateErrorStatus(err)
+}
+
+// PreviewRevert handles GET /assets/:id/revisions/:revisionId/revert-preview
+func (h *AssetHandler) PreviewRevert(c *gin.Context) {
+	preview, err := h.assetApp.PreviewRevert(c.Request.Context(), c.Param("id"), c.Param("revisionId"))
+	if err != nil {
+		c.JSON(revertErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
+
+// RevertAsset handles POST /assets/:id/revisions/:revisionId/revert
+func (h *AssetHandler) RevertAsset(c *gin.Context) {
+	// Get user from context (set by auth middleware)
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	// The reason is optional
+	var dto application.AssetRevertDTO
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&dto); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	workflow, preview, err := h.assetApp.RevertAsset(c.Request.Context(), c.Param("id"), c.Param("revisionId"), user.(*application.UserDTO), dto)
+	if err != nil {
+		c.JSON(revertErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"workflow": workflow,
+		"changes":  preview.Changes,
+		"message":  "Revert submitted for approval",
+	})
+}
@@ -1,12 +1,14 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/phuhao00/cmdb/backend/application"
+	"github.com/phuhao00/cmdb/backend/domain/model"
 )
 
 // AuditLogHandler handles audit log related HTTP requests
@@ -29,6 +31,9 @@ func (h *AuditLogHandler) RegisterRoutes(router *gin.RouterGroup) {
 		auditLogs.GET("/user/:userId", h.GetUserActivityLogs)
 		auditLogs.GET("/resource/:resourceType/:resourceId", h.GetResourceHistory)
 		auditLogs.GET("/stats", h.GetAuditLogStats)
+		auditLogs.GET("/verify", h.VerifyAuditLogs)
+		auditLogs.GET("/checkpoints", h.GetCheckpoints)
+		auditLogs.GET("/archives", h.GetArchives)
 		auditLogs.DELETE("/cleanup", h.CleanupOldLogs)
 	}
 }
@@ -152,6 +157,56 @@ func (h *AuditLogHandler) GetAuditLogStats(c *gin.Context) {
 	c.JSON(http.StatusOK, stats)
 }
 
+// VerifyAuditLogs handles GET /api/v1/audit-logs/verify
+func (h *AuditLogHandler) VerifyAuditLogs(c *gin.Context) {
+	var fromSequence, toSequence int64
+	var err error
+	if from := c.Query("fromSequence"); from != "" {
+		if fromSequence, err = strconv.ParseInt(from, 10, 64); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid fromSequence"})
+			return
+		}
+	}
+	if to := c.Query("toSequence"); to != "" {
+		if toSequence, err = strconv.ParseInt(to, 10, 64); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid toSequence"})
+			return
+		}
+	}
+
+	verification, err := h.auditLogApp.VerifyAuditLogs(c.Request.Context(), fromSequence, toSequence)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, verification)
+}
+
+// GetCheckpoints handles GET /api/v1/audit-logs/checkpoints
+func (h *AuditLogHandler) GetCheckpoints(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	checkpoints, err := h.auditLogApp.GetCheckpoints(c.Request.Context(), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"checkpoints": checkpoints})
+}
+
+// GetArchives handles GET /api/v1/audit-logs/archives
+func (h *AuditLogHandler) GetArchives(c *gin.Context) {
+	archives, err := h.auditLogApp.GetArchives(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"archives": archives})
+}
+
 // CleanupOldLogs handles DELETE /api/v1/audit-logs/cleanup
 func (h *AuditLogHandler) CleanupOldLogs(c *gin.Context) {
 	// Get user from context
@@ -170,14 +225,19 @@ func (h *AuditLogHandler) CleanupOldLogs(c *gin.Context) {
 	// Parse retention days
 	retentionDays, _ := strconv.Atoi(c.DefaultQuery("retentionDays", "90"))
 
-	err := h.auditLogApp.CleanupOldLogs(c.Request.Context(), retentionDays)
+	archive, err := h.auditLogApp.CleanupOldLogs(c.Request.Context(), retentionDays)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		status := http.StatusInternalServerError
+		if errors.Is(err, model.ErrAuditChainBroken) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":       "Audit logs cleaned up successfully",
 		"retentionDays": retentionDays,
+		"archive":       archive,
 	})
 }
@@ -1,13 +1,23 @@
 package api
 
 import (
+	"crypto/subtle"
+	"errors"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/phuhao00/cmdb/backend/application"
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/service"
 )
 
+// ssoStateCookie ties a single sign-on callback to the browser that started the sign-in, so
+// that nobody can sign a victim in to an account of theirs by sending them a callback link
+const ssoStateCookie = "sso_state"
+
 // AuthHandler handles HTTP requests for authentication
 type AuthHandler struct {
 	authApp *application.AuthApplication
@@ -20,11 +30,17 @@ func NewAuthHandler(authApp *application.AuthApplication) *AuthHandler {
 	}
 }
 
-// RegisterRoutes registers the public auth routes (login only)
+// RegisterRoutes registers the public auth routes (login, single sign-on, token refresh and
+// signing keys)
 func (h *AuthHandler) RegisterRoutes(router *gin.RouterGroup) {
 	auth := router.Group("/auth")
 	{
 		auth.POST("/login", h.Login)
+		auth.POST("/refresh", h.Refresh)
+		auth.GET("/jwks", h.GetJWKS)
+		auth.GET("/oidc/config", h.GetSingleSignOnConfig)
+		auth.GET("/oidc/login", h.StartSingleSignOn)
+		auth.GET("/oidc/callback", h.SingleSignOnCallback)
 	}
 }
 
@@ -62,11 +78,109 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 
 	// Set token in cookie for web clients
-	c.SetCookie("auth_token", response.Token, 86400, "/", "", false, true)
+	c.SetCookie("auth_token", response.Token, int(response.ExpiresIn), "/", "", false, true)
 
 	c.JSON(http.StatusOK, response)
 }
 
+// Refresh handles POST /auth/refresh
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var refreshDTO application.RefreshDTO
+	if err := c.ShouldBindJSON(&refreshDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.authApp.Refresh(c.Request.Context(), refreshDTO)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.SetCookie("auth_token", response.Token, int(response.ExpiresIn), "/", "", false, true)
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetJWKS handles GET /auth/jwks and /.well-known/jwks.json
+func (h *AuthHandler) GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.authApp.JWKS())
+}
+
+// GetSingleSignOnConfig handles GET /auth/oidc/config
+func (h *AuthHandler) GetSingleSignOnConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"enabled": h.authApp.SingleSignOnEnabled()})
+}
+
+// StartSingleSignOn handles GET /auth/oidc/login by sending the browser to the identity provider
+func (h *AuthHandler) StartSingleSignOn(c *gin.Context) {
+	authURL, state, err := h.authApp.StartSingleSignOn(c.Request.Context(), c.Query("returnTo"))
+	if errors.Is(err, model.ErrSSODisabled) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Lax still sends the cookie on the top-level redirect back from the identity provider. Its
+	// path is the root, since proxies in front of the API may serve it under another prefix.
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(ssoStateCookie, state, int(service.SSOLoginLifetime.Seconds()), "/", "", false, true)
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// SingleSignOnCallback handles GET /auth/oidc/callback, where the identity provider sends the
+// browser back. Only the browser that started the sign-in may finish it. The browser goes on to
+// the web app with the tokens, or the error, in the URL fragment, which is not sent to servers or
+// kept in their logs.
+func (h *AuthHandler) SingleSignOnCallback(c *gin.Context) {
+	startedState, _ := c.Cookie(ssoStateCookie)
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(ssoStateCookie, "", -1, "/", "", false, true)
+
+	if errorCode := c.Query("error"); errorCode != "" {
+		h.redirectAfterSingleSignOn(c, url.Values{"error": {strings.TrimSpace(errorCode + " " + c.Query("error_description"))}})
+		return
+	}
+
+	state := c.Query("state")
+	if startedState == "" || subtle.ConstantTimeCompare([]byte(startedState), []byte(state)) != 1 {
+		h.redirectAfterSingleSignOn(c, url.Values{"error": {model.ErrSSOStateInvalid.Error()}})
+		return
+	}
+
+	response, returnTo, err := h.authApp.CompleteSingleSignOn(c.Request.Context(), state, c.Query("code"))
+	if errors.Is(err, model.ErrSSODisabled) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.redirectAfterSingleSignOn(c, url.Values{"error": {err.Error()}})
+		return
+	}
+
+	c.SetCookie("auth_token", response.Token, int(response.ExpiresIn), "/", "", false, true)
+
+	h.redirectAfterSingleSignOn(c, url.Values{
+		"token":        {response.Token},
+		"tokenType":    {response.TokenType},
+		"expiresIn":    {strconv.FormatInt(response.ExpiresIn, 10)},
+		"refreshToken": {response.RefreshToken},
+		"returnTo":     {returnTo},
+	})
+}
+
+// redirectAfterSingleSignOn sends the browser to the web app with the outcome of a sign-in
+func (h *AuthHandler) redirectAfterSingleSignOn(c *gin.Context, fragment url.Values) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Referrer-Policy", "no-referrer")
+	c.Redirect(http.StatusFound, h.authApp.PostLoginURL()+"#"+fragment.Encode())
+}
+
 // Logout handles POST /auth/logout
 func (h *AuthHandler) Logout(c *gin.Context) {
 	token := h.extractToken(c)
@@ -89,12 +203,19 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 
 // GetCurrentUser handles GET /auth/me
 func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
-	user, exists := c.Get("user")
+	userID, exists := c.Get("userID")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
 		return
 	}
 
+	// The access token only carries part of the user, so the full record is loaded
+	user, err := h.authApp.GetUserByID(c.Request.Context(), userID.(string))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
 	c.JSON(http.StatusOK, user)
 }
 
@@ -196,6 +317,44 @@ func (h *AuthHandler) UpdateUserStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "User status updated successfully"})
 }
 
+// UpdateUserManager handles PUT /users/:id/manager (admin only)
+func (h *AuthHandler) UpdateUserManager(c *gin.Context) {
+	id := c.Param("id")
+
+	var updateManagerDTO application.UpdateUserManagerDTO
+	if err := c.ShouldBindJSON(&updateManagerDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.authApp.UpdateUserManager(c.Request.Context(), id, updateManagerDTO)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User manager updated successfully"})
+}
+
+// UpdateUserChannelID handles PUT /users/:id/channel-ids (admin only)
+func (h *AuthHandler) UpdateUserChannelID(c *gin.Context) {
+	id := c.Param("id")
+
+	var updateChannelIDDTO application.UpdateUserChannelIDDTO
+	if err := c.ShouldBindJSON(&updateChannelIDDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.authApp.UpdateUserChannelID(c.Request.Context(), id, updateChannelIDDTO)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User channel account updated successfully"})
+}
+
 // extractToken extracts token from request
 func (h *AuthHandler) extractToken(c *gin.Context) string {
 	// Try Authorization header first
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/phuhao00/cmdb/backend/application"
+)
+
+// CITypeHandler handles HTTP requests for CI types
+type CITypeHandler struct {
+	ciTypeApp *application.CITypeApplication
+}
+
+// NewCITypeHandler creates a new CI type handler
+func NewCITypeHandler(ciTypeApp *application.CITypeApplication) *CITypeHandler {
+	return &CITypeHandler{
+		ciTypeApp: ciTypeApp,
+	}
+}
+
+// RegisterRoutes registers the CI type routes
+func (h *CITypeHandler) RegisterRoutes(router *gin.RouterGroup) {
+	ciTypes := router.Group("/ci-types")
+	{
+		ciTypes.GET("", h.GetTypes)
+		ciTypes.POST("", h.CreateType)
+		ciTypes.GET("/:name", h.GetType)
+		ciTypes.PUT("/:name", h.UpdateType)
+		ciTypes.DELETE("/:name", h.DeleteType)
+	}
+}
+
+// GetTypes handles GET /ci-types
+func (h *CITypeHandler) GetTypes(c *gin.Context) {
+	ciTypes, err := h.ciTypeApp.GetTypes(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, ciTypes)
+}
+
+// GetType handles GET /ci-types/:name
+func (h *CITypeHandler) GetType(c *gin.Context) {
+	ciType, err := h.ciTypeApp.GetType(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "CI type not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ciType)
+}
+
+// CreateType handles POST /ci-types
+func (h *CITypeHandler) CreateType(c *gin.Context) {
+	var dto application.CITypeSaveDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ciType, err := h.ciTypeApp.CreateType(c.Request.Context(), dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, ciType)
+}
+
+// UpdateType handles PUT /ci-types/:name
+func (h *CITypeHandler) UpdateType(c *gin.Context) {
+	var dto application.CITypeSaveDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ciType, err := h.ciTypeApp.UpdateType(c.Request.Context(), c.Param("name"), dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, ciType)
+}
+
+// DeleteType handles DELETE /ci-types/:name
+func (h *CITypeHandler) DeleteType(c *gin.Context) {
+	if err := h.ciTypeApp.DeleteType(c.Request.Context(), c.Param("name")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "CI type deleted successfully"})
+}
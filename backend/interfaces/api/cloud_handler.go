@@ -0,0 +1,137 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/phuhao00/cmdb/backend/application"
+	"github.com/phuhao00/cmdb/backend/domain/service"
+)
+
+// CloudHandler handles HTTP requests for cloud inventory
+type CloudHandler struct {
+	cloudApp *application.CloudApplication
+}
+
+// NewCloudHandler creates a new cloud handler
+func NewCloudHandler(cloudApp *application.CloudApplication) *CloudHandler {
+	return &CloudHandler{
+		cloudApp: cloudApp,
+	}
+}
+
+// RegisterRoutes registers the cloud routes
+func (h *CloudHandler) RegisterRoutes(router *gin.RouterGroup) {
+	cloud := router.Group("/cloud")
+	{
+		cloud.GET("/providers", h.GetProviders)
+		cloud.GET("/accounts", h.GetAccounts)
+		cloud.POST("/accounts", h.CreateAccount)
+		cloud.GET("/accounts/:id", h.GetAccount)
+		cloud.PUT("/accounts/:id", h.UpdateAccount)
+		cloud.DELETE("/accounts/:id", h.DeleteAccount)
+		cloud.POST("/accounts/:id/sync", h.SyncAccount)
+	}
+}
+
+// GetProviders handles GET /cloud/providers
+func (h *CloudHandler) GetProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, h.cloudApp.GetProviders())
+}
+
+// GetAccounts handles GET /cloud/accounts
+func (h *CloudHandler) GetAccounts(c *gin.Context) {
+	accounts, err := h.cloudApp.GetAccounts(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, accounts)
+}
+
+// GetAccount handles GET /cloud/accounts/:id
+func (h *CloudHandler) GetAccount(c *gin.Context) {
+	account, err := h.cloudApp.GetAccount(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cloud account not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
+
+// CreateAccount handles POST /cloud/accounts
+func (h *CloudHandler) CreateAccount(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	var dto application.CloudAccountSaveDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userDTO := user.(*application.UserDTO)
+	account, err := h.cloudApp.CreateAccount(c.Request.Context(), dto, userDTO.Username)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, account)
+}
+
+// UpdateAccount handles PUT /cloud/accounts/:id
+func (h *CloudHandler) UpdateAccount(c *gin.Context) {
+	var dto application.CloudAccountSaveDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	account, err := h.cloudApp.UpdateAccount(c.Request.Context(), c.Param("id"), dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
+
+// DeleteAccount handles DELETE /cloud/accounts/:id
+func (h *CloudHandler) DeleteAccount(c *gin.Context) {
+	if err := h.cloudApp.DeleteAccount(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Cloud account deleted successfully"})
+}
+
+// SyncAccount handles POST /cloud/accounts/:id/sync. A sync that fails after it started is
+// reported as 502 with its result.
+func (h *CloudHandler) SyncAccount(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	userDTO := user.(*application.UserDTO)
+	result, err := h.cloudApp.SyncAccount(c.Request.Context(), c.Param("id"), userDTO.Username)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, result)
+	case result != nil:
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "sync": result})
+	case errors.Is(err, service.ErrSyncInProgress):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
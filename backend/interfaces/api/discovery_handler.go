@@ -0,0 +1,282 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/phuhao00/cmdb/backend/application"
+	"github.com/phuhao00/cmdb/backend/domain/model"
+	"github.com/phuhao00/cmdb/backend/domain/service"
+)
+
+// maxScanReportSize bounds the size of an imported scan report
+const maxScanReportSize = 64 << 20
+
+// DiscoveryHandler handles HTTP requests for network discovery
+type DiscoveryHandler struct {
+	discoveryApp *application.DiscoveryApplication
+}
+
+// NewDiscoveryHandler creates a new discovery handler
+func NewDiscoveryHandler(discoveryApp *application.DiscoveryApplication) *DiscoveryHandler {
+	return &DiscoveryHandler{
+		discoveryApp: discoveryApp,
+	}
+}
+
+// RegisterRoutes registers the discovery routes
+func (h *DiscoveryHandler) RegisterRoutes(router *gin.RouterGroup) {
+	discovery := router.Group("/discovery")
+	{
+		discovery.GET("/ranges", h.GetRanges)
+		discovery.POST("/ranges", h.CreateRange)
+		discovery.GET("/ranges/:id", h.GetRange)
+		discovery.PUT("/ranges/:id", h.UpdateRange)
+		discovery.DELETE("/ranges/:id", h.DeleteRange)
+		discovery.POST("/ranges/:id/scan", h.StartScan)
+		discovery.GET("/runs", h.GetRuns)
+		discovery.GET("/runs/:id", h.GetRun)
+		discovery.POST("/runs/:id/cancel", h.CancelScan)
+		discovery.GET("/candidates", h.GetCandidates)
+		discovery.POST("/candidates/:id/approve", h.ApproveCandidate)
+		discovery.POST("/candidates/:id/ignore", h.IgnoreCandidate)
+		discovery.POST("/import", h.ImportScan)
+	}
+}
+
+// discoveryErrorStatus maps discovery errors to HTTP status codes
+func discoveryErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrScanInProgress), errors.Is(err, service.ErrScanNotRunning), errors.Is(err, model.ErrCandidateReviewed):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrUnknownReportFormat):
+		return http.StatusBadRequest
+	}
+	if status := assetErrorStatus(err); status != http.StatusInternalServerError {
+		return status
+	}
+	return http.StatusBadRequest
+}
+
+// GetRanges handles GET /discovery/ranges
+func (h *DiscoveryHandler) GetRanges(c *gin.Context) {
+	ranges, err := h.discoveryApp.GetRanges(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, ranges)
+}
+
+// GetRange handles GET /discovery/ranges/:id
+func (h *DiscoveryHandler) GetRange(c *gin.Context) {
+	rng, err := h.discoveryApp.GetRange(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Discovery range not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rng)
+}
+
+// CreateRange handles POST /discovery/ranges
+func (h *DiscoveryHandler) CreateRange(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	var dto application.DiscoveryRangeSaveDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userDTO := user.(*application.UserDTO)
+	rng, err := h.discoveryApp.CreateRange(c.Request.Context(), dto, userDTO.Username)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, rng)
+}
+
+// UpdateRange handles PUT /discovery/ranges/:id
+func (h *DiscoveryHandler) UpdateRange(c *gin.Context) {
+	var dto application.DiscoveryRangeSaveDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rng, err := h.discoveryApp.UpdateRange(c.Request.Context(), c.Param("id"), dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rng)
+}
+
+// DeleteRange handles DELETE /discovery/ranges/:id
+func (h *DiscoveryHandler) DeleteRange(c *gin.Context) {
+	if err := h.discoveryApp.DeleteRange(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Discovery range deleted successfully"})
+}
+
+// StartScan handles POST /discovery/ranges/:id/scan
+func (h *DiscoveryHandler) StartScan(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	userDTO := user.(*application.UserDTO)
+	run, err := h.discoveryApp.StartScan(c.Request.Context(), c.Param("id"), userDTO.Username)
+	if err != nil {
+		c.JSON(discoveryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, run)
+}
+
+// GetRuns handles GET /discovery/runs
+func (h *DiscoveryHandler) GetRuns(c *gin.Context) {
+	var filter application.ScanRunFilterDTO
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	runs, err := h.discoveryApp.GetRuns(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, runs)
+}
+
+// GetRun handles GET /discovery/runs/:id
+func (h *DiscoveryHandler) GetRun(c *gin.Context) {
+	run, err := h.discoveryApp.GetRun(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Scan not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+// CancelScan handles POST /discovery/runs/:id/cancel
+func (h *DiscoveryHandler) CancelScan(c *gin.Context) {
+	if err := h.discoveryApp.CancelScan(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(discoveryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Scan cancellation requested"})
+}
+
+// GetCandidates handles GET /discovery/candidates
+func (h *DiscoveryHandler) GetCandidates(c *gin.Context) {
+	var filter application.CandidateFilterDTO
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	candidates, err := h.discoveryApp.GetCandidates(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, candidates)
+}
+
+// ApproveCandidate handles POST /discovery/candidates/:id/approve
+func (h *DiscoveryHandler) ApproveCandidate(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	// The body is optional; every field has a default
+	var dto application.CandidateApproveDTO
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&dto); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	userDTO := user.(*application.UserDTO)
+	asset, err := h.discoveryApp.ApproveCandidate(c.Request.Context(), c.Param("id"), dto, userDTO.Username)
+	if err != nil {
+		c.JSON(discoveryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, asset)
+}
+
+// IgnoreCandidate handles POST /discovery/candidates/:id/ignore
+func (h *DiscoveryHandler) IgnoreCandidate(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	userDTO := user.(*application.UserDTO)
+	candidate, err := h.discoveryApp.IgnoreCandidate(c.Request.Context(), c.Param("id"), userDTO.Username)
+	if err != nil {
+		c.JSON(discoveryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, candidate)
+}
+
+// ImportScan handles POST /discovery/import. The body is an nmap XML or masscan JSON report.
+func (h *DiscoveryHandler) ImportScan(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	var dto application.ScanImportDTO
+	if err := c.ShouldBindQuery(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxScanReportSize))
+	if err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		return
+	}
+
+	userDTO := user.(*application.UserDTO)
+	result, err := h.discoveryApp.ImportScan(c.Request.Context(), data, dto, userDTO.Username, userDTO.ID)
+	if err != nil {
+		c.JSON(discoveryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
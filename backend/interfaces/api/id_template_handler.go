@@ -0,0 +1,68 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/phuhao00/cmdb/backend/application"
+)
+
+// IDTemplateHandler handles HTTP requests for ID templates
+type IDTemplateHandler struct {
+	templateApp *application.IDTemplateApplication
+}
+
+// NewIDTemplateHandler creates a new ID template handler
+func NewIDTemplateHandler(templateApp *application.IDTemplateApplication) *IDTemplateHandler {
+	return &IDTemplateHandler{
+		templateApp: templateApp,
+	}
+}
+
+// RegisterRoutes registers the ID template routes
+func (h *IDTemplateHandler) RegisterRoutes(router *gin.RouterGroup) {
+	templates := router.Group("/id-templates")
+	{
+		templates.GET("", h.GetTemplates)
+		templates.PUT("/:scope", h.SetTemplate)
+		templates.DELETE("/:scope", h.DeleteTemplate)
+	}
+}
+
+// GetTemplates handles GET /id-templates
+func (h *IDTemplateHandler) GetTemplates(c *gin.Context) {
+	templates, err := h.templateApp.GetTemplates(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+// SetTemplate handles PUT /id-templates/:scope
+func (h *IDTemplateHandler) SetTemplate(c *gin.Context) {
+	var dto application.IDTemplateSaveDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template, err := h.templateApp.SetTemplate(c.Request.Context(), c.Param("scope"), dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// DeleteTemplate handles DELETE /id-templates/:scope
+func (h *IDTemplateHandler) DeleteTemplate(c *gin.Context) {
+	if err := h.templateApp.DeleteTemplate(c.Request.Context(), c.Param("scope")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ID template not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "ID template deleted successfully"})
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/phuhao00/cmdb/backend/application"
+	"github.com/phuhao00/cmdb/backend/domain/service"
+)
+
+// KubernetesHandler handles HTTP requests for Kubernetes discovery
+type KubernetesHandler struct {
+	kubernetesApp *application.KubernetesApplication
+}
+
+// NewKubernetesHandler creates a new Kubernetes handler
+func NewKubernetesHandler(kubernetesApp *application.KubernetesApplication) *KubernetesHandler {
+	return &KubernetesHandler{
+		kubernetesApp: kubernetesApp,
+	}
+}
+
+// RegisterRoutes registers the Kubernetes routes
+func (h *KubernetesHandler) RegisterRoutes(router *gin.RouterGroup) {
+	clusters := router.Group("/kubernetes/clusters")
+	{
+		clusters.GET("", h.GetClusters)
+		clusters.POST("", h.CreateCluster)
+		clusters.GET("/:id", h.GetCluster)
+		clusters.PUT("/:id", h.UpdateCluster)
+		clusters.DELETE("/:id", h.DeleteCluster)
+		clusters.POST("/:id/sync", h.SyncCluster)
+	}
+}
+
+// GetClusters handles GET /kubernetes/clusters
+func (h *KubernetesHandler) GetClusters(c *gin.Context) {
+	clusters, err := h.kubernetesApp.GetClusters(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, clusters)
+}
+
+// GetCluster handles GET /kubernetes/clusters/:id
+func (h *KubernetesHandler) GetCluster(c *gin.Context) {
+	cluster, err := h.kubernetesApp.GetCluster(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Kubernetes cluster not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, cluster)
+}
+
+// CreateCluster handles POST /kubernetes/clusters
+func (h *KubernetesHandler) CreateCluster(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	var dto application.KubernetesClusterSaveDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userDTO := user.(*application.UserDTO)
+	cluster, err := h.kubernetesApp.CreateCluster(c.Request.Context(), dto, userDTO.Username)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, cluster)
+}
+
+// UpdateCluster handles PUT /kubernetes/clusters/:id
+func (h *KubernetesHandler) UpdateCluster(c *gin.Context) {
+	var dto application.KubernetesClusterSaveDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cluster, err := h.kubernetesApp.UpdateCluster(c.Request.Context(), c.Param("id"), dto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, cluster)
+}
+
+// DeleteCluster handles DELETE /kubernetes/clusters/:id
+func (h *KubernetesHandler) DeleteCluster(c *gin.Context) {
+	if err := h.kubernetesApp.DeleteCluster(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Kubernetes cluster deleted successfully"})
+}
+
+// SyncCluster handles POST /kubernetes/clusters/:id/sync. A sync that fails after it started
+// is reported as 502 with its result.
+func (h *KubernetesHandler) SyncCluster(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	userDTO := user.(*application.UserDTO)
+	result, err := h.kubernetesApp.SyncCluster(c.Request.Context(), c.Param("id"), userDTO.Username)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, result)
+	case result != nil:
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "sync": result})
+	case errors.Is(err, service.ErrSyncInProgress):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,232 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/phuhao00/cmdb/backend/application"
+	"github.com/phuhao00/cmdb/backend/domain/model"
+)
+
+// ReconciliationHandler handles HTTP requests for source reconciliation
+type ReconciliationHandler struct {
+	reconciliationApp *application.ReconciliationApplication
+}
+
+// NewReconciliationHandler creates a new reconciliation handler
+func NewReconciliationHandler(reconciliationApp *application.ReconciliationApplication) *ReconciliationHandler {
+	return &ReconciliationHandler{
+		reconciliationApp: reconciliationApp,
+	}
+}
+
+// RegisterRoutes registers the reconciliation routes
+func (h *ReconciliationHandler) RegisterRoutes(router *gin.RouterGroup) {
+	reconciliation := router.Group("/reconciliation")
+	{
+		reconciliation.GET("/rules", h.GetRules)
+		reconciliation.GET("/duplicate-rules", h.GetDuplicateRules)
+		reconciliation.GET("/conflicts", h.GetConflicts)
+		reconciliation.GET("/conflicts/:id", h.GetConflict)
+		reconciliation.POST("/conflicts/:id/accept", h.AcceptConflict)
+		reconciliation.POST("/conflicts/:id/override", h.OverrideConflict)
+		reconciliation.GET("/merge-proposals", h.GetMergeProposals)
+		reconciliation.POST("/merge-proposals/detect", h.DetectDuplicates)
+		reconciliation.POST("/merge-proposals/:id/dismiss", h.DismissMergeProposal)
+		reconciliation.POST("/merge-proposals/:id/merge", h.MergeProposal)
+		reconciliation.POST("/merge", h.MergeAssets)
+	}
+}
+
+// reconciliationErrorStatus maps reconciliation errors to HTTP status codes
+func reconciliationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, model.ErrConflictResolved), errors.Is(err, model.ErrMergeProposalReviewed), errors.Is(err, model.ErrMergeSyncedAssets):
+		return http.StatusConflict
+	}
+	if status := assetErrorStatus(err); status != http.StatusInternalServerError {
+		return status
+	}
+	return http.StatusBadRequest
+}
+
+// GetRules handles GET /reconciliation/rules
+func (h *ReconciliationHandler) GetRules(c *gin.Context) {
+	c.JSON(http.StatusOK, h.reconciliationApp.GetPolicy())
+}
+
+// GetDuplicateRules handles GET /reconciliation/duplicate-rules
+func (h *ReconciliationHandler) GetDuplicateRules(c *gin.Context) {
+	c.JSON(http.StatusOK, h.reconciliationApp.GetDuplicatePolicy())
+}
+
+// GetConflicts handles GET /reconciliation/conflicts
+func (h *ReconciliationHandler) GetConflicts(c *gin.Context) {
+	var filter application.ConflictFilterDTO
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	conflicts, err := h.reconciliationApp.GetConflicts(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, conflicts)
+}
+
+// GetConflict handles GET /reconciliation/conflicts/:id
+func (h *ReconciliationHandler) GetConflict(c *gin.Context) {
+	conflict, err := h.reconciliationApp.GetConflict(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Conflict not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, conflict)
+}
+
+// AcceptConflict handles POST /reconciliation/conflicts/:id/accept
+func (h *ReconciliationHandler) AcceptConflict(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	userDTO := user.(*application.UserDTO)
+	conflict, err := h.reconciliationApp.AcceptConflict(c.Request.Context(), c.Param("id"), userDTO.Username)
+	if err != nil {
+		c.JSON(reconciliationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, conflict)
+}
+
+// OverrideConflict handles POST /reconciliation/conflicts/:id/override
+func (h *ReconciliationHandler) OverrideConflict(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	// The body is optional; without a value the asset keeps its current value
+	var dto application.ConflictOverrideDTO
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&dto); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	userDTO := user.(*application.UserDTO)
+	conflict, err := h.reconciliationApp.OverrideConflict(c.Request.Context(), c.Param("id"), dto, userDTO.Username)
+	if err != nil {
+		c.JSON(reconciliationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, conflict)
+}
+
+// GetMergeProposals handles GET /reconciliation/merge-proposals
+func (h *ReconciliationHandler) GetMergeProposals(c *gin.Context) {
+	var filter application.MergeProposalFilterDTO
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	proposals, err := h.reconciliationApp.GetMergeProposals(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, proposals)
+}
+
+// DetectDuplicates handles POST /reconciliation/merge-proposals/detect
+func (h *ReconciliationHandler) DetectDuplicates(c *gin.Context) {
+	proposals, err := h.reconciliationApp.DetectDuplicates(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, proposals)
+}
+
+// DismissMergeProposal handles POST /reconciliation/merge-proposals/:id/dismiss
+func (h *ReconciliationHandler) DismissMergeProposal(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	userDTO := user.(*application.UserDTO)
+	proposal, err := h.reconciliationApp.DismissMergeProposal(c.Request.Context(), c.Param("id"), userDTO.Username)
+	if err != nil {
+		c.JSON(reconciliationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, proposal)
+}
+
+// MergeProposal handles POST /reconciliation/merge-proposals/:id/merge
+func (h *ReconciliationHandler) MergeProposal(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	// The body is optional; without a survivor the asset created first survives
+	var dto application.MergeProposalMergeDTO
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&dto); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	userDTO := user.(*application.UserDTO)
+	result, err := h.reconciliationApp.MergeProposal(c.Request.Context(), c.Param("id"), dto, userDTO.Username)
+	if err != nil {
+		c.JSON(reconciliationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// MergeAssets handles POST /reconciliation/merge
+func (h *ReconciliationHandler) MergeAssets(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	var dto application.AssetMergeDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userDTO := user.(*application.UserDTO)
+	result, err := h.reconciliationApp.MergeAssets(c.Request.Context(), dto, userDTO.Username)
+	if err != nil {
+		c.JSON(reconciliationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/phuhao00/cmdb/backend/application"
+)
+
+// RelationshipHandler handles HTTP requests for asset relationships
+type RelationshipHandler struct {
+	relationshipApp *application.RelationshipApplication
+}
+
+// NewRelationshipHandler creates a new relationship handler
+func NewRelationshipHandler(relationshipApp *application.RelationshipApplication) *RelationshipHandler {
+	return &RelationshipHandler{
+		relationshipApp: relationshipApp,
+	}
+}
+
+// GetAssetRelationships handles GET /assets/:id/relationships
+func (h *RelationshipHandler) GetAssetRelationships(c *gin.Context) {
+	id := c.Param("id")
+
+	relationships, err := h.relationshipApp.GetAssetRelationships(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"relationships": relationships,
+		"types":         h.relationshipApp.GetRelationshipTypes(),
+	})
+}
+
+// CreateRelationship handles POST /assets/:id/relationships
+func (h *RelationshipHandler) CreateRelationship(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	id := c.Param("id")
+
+	var createDTO application.RelationshipCreateDTO
+	if err := c.ShouldBindJSON(&createDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Add user context to DTO
+	createDTO.CreatedBy = user.(*application.UserDTO).Username
+	createDTO.CreatedByID = user.(*application.UserDTO).ID
+
+	relationship, err := h.relationshipApp.CreateRelationship(c.Request.Context(), id, createDTO)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, relationship)
+}
+
+// DeleteRelationship handles DELETE /assets/:id/relationships/:relationshipId
+func (h *RelationshipHandler) DeleteRelationship(c *gin.Context) {
+	id := c.Param("id")
+	relationshipID := c.Param("relationshipId")
+
+	err := h.relationshipApp.DeleteRelationship(c.Request.Context(), id, relationshipID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Relationship deleted successfully"})
+}
+
+// GetImpactAnalysis handles GET /assets/:id/impact
+func (h *RelationshipHandler) GetImpactAnalysis(c *gin.Context) {
+	id := c.Param("id")
+	direction := c.DefaultQuery("direction", "both")
+	depth, _ := strconv.Atoi(c.DefaultQuery("depth", "3"))
+
+	analysis, err := h.relationshipApp.AnalyzeImpact(c.Request.Context(), id, direction, depth)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, analysis)
+}
@@ -1,10 +1,13 @@
 package api
 
 import (
+	"errors"
+	"io"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/phuhao00/cmdb/backend/application"
+	"github.com/phuhao00/cmdb/backend/domain/service"
 )
 
 // WorkflowHandler handles HTTP requests for workflows
@@ -32,8 +35,10 @@ func (h *WorkflowHandler) RegisterRoutes(router *gin.RouterGroup) {
 		workflows.GET("/history/:assetId", h.GetAssetWorkflowHistory)
 	}
 
-	// Feishu webhook
+	// Approval channel callbacks
 	router.POST("/feishu/webhook", h.HandleFeishuWebhook)
+	router.GET("/approvals/:channel/callback", h.HandleApprovalCallback)
+	router.POST("/approvals/:channel/callback", h.HandleApprovalCallback)
 }
 
 // GetWorkflows handles GET /workflows
@@ -101,9 +106,16 @@ func (h *WorkflowHandler) ApproveWorkflow(c *gin.Context) {
 		return
 	}
 
-	userDTO := user.(*application.UserDTO)
-	err := h.workflowApp.ApproveWorkflow(c.Request.Context(), workflowID, userDTO.ID, userDTO.FullName, dto)
+	err := h.workflowApp.ApproveWorkflow(c.Request.Context(), workflowID, user.(*application.UserDTO), dto)
 	if err != nil {
+		if errors.Is(err, service.ErrNotStageApprover) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrWorkflowDecidedConcurrently) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -126,9 +138,16 @@ func (h *WorkflowHandler) RejectWorkflow(c *gin.Context) {
 		return
 	}
 
-	userDTO := user.(*application.UserDTO)
-	err := h.workflowApp.RejectWorkflow(c.Request.Context(), workflowID, userDTO.ID, userDTO.FullName, dto)
+	err := h.workflowApp.RejectWorkflow(c.Request.Context(), workflowID, user.(*application.UserDTO), dto)
 	if err != nil {
+		if errors.Is(err, service.ErrNotStageApprover) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrWorkflowDecidedConcurrently) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -136,18 +155,50 @@ func (h *WorkflowHandler) RejectWorkflow(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Workflow rejected successfully"})
 }
 
+// HandleApprovalCallback handles GET|POST /approvals/:channel/callback
+func (h *WorkflowHandler) HandleApprovalCallback(c *gin.Context) {
+	h.handleApprovalCallback(c, c.Param("channel"))
+}
+
 // HandleFeishuWebhook handles POST /feishu/webhook
 func (h *WorkflowHandler) HandleFeishuWebhook(c *gin.Context) {
-	var webhookDTO application.FeishuWebhookDTO
+	h.handleApprovalCallback(c, "feishu")
+}
+
+// GetApprovalChannels handles GET /workflows/approval-channels
+func (h *WorkflowHandler) GetApprovalChannels(c *gin.Context) {
+	c.JSON(http.StatusOK, h.workflowApp.GetApprovalChannels())
+}
 
-	if err := c.ShouldBindJSON(&webhookDTO); err != nil {
+// handleApprovalCallback passes the raw request to the channel and writes back its reply
+func (h *WorkflowHandler) handleApprovalCallback(c *gin.Context, channel string) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	err := h.workflowApp.HandleFeishuWebhook(c.Request.Context(), webhookDTO)
+	callback, err := h.workflowApp.HandleApprovalCallback(c.Request.Context(), channel, &service.ApprovalCallbackRequest{
+		Method: c.Request.Method,
+		Query:  c.Request.URL.Query(),
+		Header: c.Request.Header,
+		Body:   body,
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		switch {
+		case errors.Is(err, service.ErrInvalidApprovalCallback):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrApprovalChannelNotConfigured):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	// Some channels expect a specific acknowledgement (challenges, encrypted replies)
+	if callback.Reply != nil {
+		c.Data(http.StatusOK, callback.ReplyContentType, callback.Reply)
 		return
 	}
 
@@ -168,9 +219,16 @@ func (h *WorkflowHandler) GetWorkflowStats(c *gin.Context) {
 		return
 	}
 
+	slaStats, err := h.workflowApp.GetWorkflowSLAStats(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"statusStats": statusStats,
 		"typeStats":   typeStats,
+		"slaStats":    slaStats,
 	})
 }
 
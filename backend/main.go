@@ -2,8 +2,17 @@ package main
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
 	"net/http"
+	"net/url"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/gin-contrib/cors"
@@ -11,8 +20,15 @@ import (
 	"github.com/phuhao00/cmdb/backend/application"
 	"github.com/phuhao00/cmdb/backend/domain/model"
 	"github.com/phuhao00/cmdb/backend/domain/service"
+	"github.com/phuhao00/cmdb/backend/infrastructure/approval"
+	"github.com/phuhao00/cmdb/backend/infrastructure/auditstream"
+	"github.com/phuhao00/cmdb/backend/infrastructure/cloud"
+	"github.com/phuhao00/cmdb/backend/infrastructure/discovery"
+	"github.com/phuhao00/cmdb/backend/infrastructure/feishu"
+	"github.com/phuhao00/cmdb/backend/infrastructure/kubernetes"
 	"github.com/phuhao00/cmdb/backend/infrastructure/logging"
 	"github.com/phuhao00/cmdb/backend/infrastructure/middleware"
+	"github.com/phuhao00/cmdb/backend/infrastructure/oidc"
 	"github.com/phuhao00/cmdb/backend/infrastructure/persistence"
 	"github.com/phuhao00/cmdb/backend/interfaces/api"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -40,18 +56,150 @@ func main() {
 	database := client.Database("cmdb")
 
 	// Initialize repositories
-	assetRepo := persistence.NewMongoDBAssetRepository(database)
+	// Every asset write is recorded in the asset history, whichever service makes it
+	assetHistoryRepo := persistence.NewMongoAssetHistoryRepository(database)
+	assetRepo := service.NewObservedAssetRepository(service.NewHistoryRecordingAssetRepository(persistence.NewMongoDBAssetRepository(database), assetHistoryRepo))
 	workflowRepo := persistence.NewMongoDBWorkflowRepository(database)
 	userRepo := persistence.NewMongoDBUserRepository(database)
-	auditLogRepo := persistence.NewMongoAuditLogRepository(database)
-	assetHistoryRepo := persistence.NewMongoAssetHistoryRepository(database)
+	// Every persisted audit log is also forwarded to the configured audit stream sinks
+	auditForwarders := loadAuditForwarders()
+	auditLogRepo := service.NewStreamingAuditLogRepository(persistence.NewMongoAuditLogRepository(database), auditForwarders...)
+	relationshipRepo := persistence.NewMongoDBRelationshipRepository(database)
+	alertRepo := persistence.NewMongoDBAlertRepository(database)
+	approvalPolicyRepo := persistence.NewMongoDBApprovalPolicyRepository(database)
+	sequenceRepo := persistence.NewMongoDBSequenceRepository(database)
+	idTemplateRepo := persistence.NewMongoDBIDTemplateRepository(database)
+	ciTypeRepo := persistence.NewMongoDBCITypeRepository(database)
+	discoveryRepo := persistence.NewMongoDBDiscoveryRepository(database)
+	agentTokenRepo := persistence.NewMongoDBAgentTokenRepository(database)
+	kubernetesClusterRepo := persistence.NewMongoDBKubernetesClusterRepository(database)
+	cloudAccountRepo := persistence.NewMongoDBCloudAccountRepository(database)
+	reconciliationRepo := persistence.NewMongoDBReconciliationRepository(database)
+	auditCheckpointRepo := persistence.NewMongoAuditCheckpointRepository(database)
+	auditArchiveRepo := persistence.NewMongoAuditArchiveRepository(database)
+	auditArchiveStore := persistence.NewFileAuditArchiveStore(getEnv("AUDIT_ARCHIVE_DIR", "./audit-archive"))
+	tokenRevocationRepo := persistence.NewMongoTokenRevocationRepository(database)
+	oidcLoginRepo := persistence.NewMongoOIDCLoginRepository(database)
 
 	// Initialize services
-	assetService := service.NewAssetService(assetRepo, workflowRepo, assetHistoryRepo)
-	workflowService := service.NewWorkflowService(workflowRepo, assetRepo)
-	authService := service.NewAuthService(userRepo)
+	auditSigner, err := loadAuditSigner()
+	if err != nil {
+		logger.Fatal("Invalid audit signing key", zap.Error(err))
+	}
+	auditLogService := service.NewAuditLogService(auditLogRepo, auditCheckpointRepo, auditArchiveRepo, auditArchiveStore, auditSigner)
+	approvalPolicyService := service.NewApprovalPolicyService(approvalPolicyRepo, assetRepo, userRepo)
+	idService := service.NewIDService(sequenceRepo, idTemplateRepo, assetRepo, workflowRepo)
+	ciTypeService := service.NewCITypeService(ciTypeRepo, assetRepo)
+	assetService := service.NewAssetService(assetRepo, workflowRepo, assetHistoryRepo, approvalPolicyService, idService, ciTypeService, auditLogService)
+	reconciliationService := service.NewReconciliationService(reconciliationRepo, assetRepo, assetHistoryRepo, relationshipRepo, workflowRepo, auditLogService, ciTypeService)
+	assetService.SetReconciliation(reconciliationService)
+	workflowService := service.NewWorkflowService(workflowRepo, assetRepo, approvalPolicyService, idService, ciTypeService, auditLogService)
+	workflowService.SetUserRepository(userRepo)
+	tokenSigner, err := loadTokenSigner()
+	if err != nil {
+		logger.Fatal("Invalid token signing key", zap.Error(err))
+	}
+	tokenRevocations := service.NewTokenRevocationList(tokenRevocationRepo)
+	if err := tokenRevocations.Load(context.Background()); err != nil {
+		logger.Fatal("Failed to load token revocations", zap.Error(err))
+	}
+	authService := service.NewAuthService(userRepo, auditLogService, tokenSigner, tokenRevocations, loadAuthTokenConfig())
 	aiService := service.NewAIService(assetService, workflowService, userRepo)
-	auditLogService := service.NewAuditLogService(auditLogRepo)
+	relationshipService := service.NewRelationshipService(relationshipRepo, assetRepo)
+	alertService := service.NewAlertService(alertRepo, assetRepo)
+	scanner := discovery.NewScanner()
+	discoveryService := service.NewDiscoveryService(discoveryRepo, assetRepo, assetService, scanner)
+	agentService := service.NewAgentService(agentTokenRepo, assetRepo, assetService)
+	kubernetesService := service.NewKubernetesService(kubernetesClusterRepo, assetRepo, relationshipRepo, assetService, ciTypeService, kubernetes.NewReader(getEnvDuration("KUBERNETES_REQUEST_TIMEOUT", 30*time.Second)))
+	cloudService := service.NewCloudService(cloudAccountRepo, assetRepo, relationshipRepo, assetService, ciTypeService)
+
+	// Enforce a custom asset lifecycle if one is configured
+	if path := os.Getenv("ASSET_LIFECYCLE_FILE"); path != "" {
+		if lifecycle, err := loadLifecycle(path); err != nil {
+			logging.Logger.Warn("asset_lifecycle_invalid", zap.String("path", path), zap.Error(err))
+		} else {
+			assetService.SetLifecycle(lifecycle)
+			workflowService.SetLifecycle(lifecycle)
+		}
+	}
+
+	// Apply custom source precedence rules if they are configured
+	if path := os.Getenv("ASSET_PRECEDENCE_FILE"); path != "" {
+		if policy, err := loadReconciliationPolicy(path); err != nil {
+			logging.Logger.Warn("asset_precedence_invalid", zap.String("path", path), zap.Error(err))
+		} else {
+			reconciliationService.SetPolicy(policy)
+		}
+	}
+
+	// Sign users in through an OpenID Connect identity provider if one is configured
+	oidcConfig, oidcMock := loadSingleSignOn(getEnv("PORT", "8080"))
+	if oidcConfig != nil {
+		provider := oidc.NewProvider(*oidcConfig, getEnvDuration("OIDC_REQUEST_TIMEOUT", 10*time.Second))
+		authService.SetSingleSignOn(oidcConfig, provider, oidcLoginRepo)
+		logger.Info("oidc_enabled", zap.String("issuer", oidcConfig.Issuer))
+	}
+
+	// Apply custom duplicate rules if they are configured
+	if path := os.Getenv("DUPLICATE_RULES_FILE"); path != "" {
+		if policy, err := loadDuplicatePolicy(path); err != nil {
+			logging.Logger.Warn("duplicate_rules_invalid", zap.String("path", path), zap.Error(err))
+		} else {
+			reconciliationService.SetDuplicatePolicy(policy)
+		}
+	}
+
+	// Register the approval channels that are configured
+	registerApprovalChannels(workflowService)
+
+	// Remove the relationships of deleted assets
+	assetRepo.SubscribeDeletes(relationshipService)
+
+	// Re-evaluate alert rules whenever an asset is saved, and sweep periodically
+	assetRepo.Subscribe(alertService)
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	alertService.StartEvaluationLoop(backgroundCtx, getEnvDuration("ALERT_EVALUATION_INTERVAL", 5*time.Minute))
+
+	// Remind, escalate and time out pending workflows according to their SLA
+	if err := workflowService.SetSLAConfig(loadWorkflowSLAConfig()); err != nil {
+		logging.Logger.Warn("workflow_sla_config_invalid", zap.Error(err))
+	}
+	workflowService.StartSLALoop(backgroundCtx, getEnvDuration("WORKFLOW_SLA_CHECK_INTERVAL", time.Minute))
+
+	// Stream audit logs to external collectors
+	for _, forwarder := range auditForwarders {
+		forwarder.Start(backgroundCtx)
+	}
+
+	// Pick up access tokens revoked by other instances
+	tokenRevocations.StartRefreshLoop(backgroundCtx, getEnvDuration("JWT_REVOCATION_REFRESH_INTERVAL", 30*time.Second))
+
+	// Seal the audit log chain with signed checkpoints
+	auditLogService.StartCheckpointLoop(backgroundCtx, getEnvDuration("AUDIT_CHECKPOINT_INTERVAL", time.Hour))
+
+	// Accept nmap and masscan reports for import
+	discoveryService.RegisterReportFormat("nmap", discovery.NmapParser{})
+	discoveryService.RegisterReportFormat("masscan", discovery.MasscanParser{})
+
+	// Scan discovery ranges on their schedule
+	if !scanner.ICMPAvailable() {
+		logging.Logger.Info("discovery_icmp_unavailable", zap.String("fallback", "tcp"))
+	}
+	discoveryService.StartScheduler(backgroundCtx, getEnvDuration("DISCOVERY_SCHEDULER_INTERVAL", time.Minute))
+
+	// Resync Kubernetes clusters on their schedule
+	kubernetesService.StartScheduler(backgroundCtx, getEnvDuration("KUBERNETES_SCHEDULER_INTERVAL", time.Minute))
+
+	// Register the cloud providers and resync cloud accounts on their schedule
+	cloudTimeout := getEnvDuration("CLOUD_REQUEST_TIMEOUT", 30*time.Second)
+	cloudService.RegisterProvider("aws", cloud.NewAWSProvider(cloudTimeout))
+	cloudService.RegisterProvider("aliyun", cloud.NewAliyunProvider(cloudTimeout))
+	cloudService.RegisterProvider("openstack", cloud.NewOpenStackProvider(cloudTimeout))
+	cloudService.StartScheduler(backgroundCtx, getEnvDuration("CLOUD_SCHEDULER_INTERVAL", time.Minute))
+
+	// Look for assets reported under different keys
+	reconciliationService.StartScheduler(backgroundCtx, getEnvDuration("DUPLICATE_DETECTION_INTERVAL", time.Hour))
 
 	// Initialize applications
 	assetApp := application.NewAssetApplication(assetService, workflowService)
@@ -59,9 +207,21 @@ func main() {
 	authApp := application.NewAuthApplication(authService)
 	aiApp := application.NewAIApplication(aiService)
 	auditLogApp := application.NewAuditLogApplication(auditLogService)
+	relationshipApp := application.NewRelationshipApplication(relationshipService)
+	alertApp := application.NewAlertApplication(alertService)
+	approvalPolicyApp := application.NewApprovalPolicyApplication(approvalPolicyService)
+	idTemplateApp := application.NewIDTemplateApplication(idService)
+	ciTypeApp := application.NewCITypeApplication(ciTypeService)
+	discoveryApp := application.NewDiscoveryApplication(discoveryService)
+	agentApp := application.NewAgentApplication(agentService)
+	kubernetesApp := application.NewKubernetesApplication(kubernetesService)
+	cloudApp := application.NewCloudApplication(cloudService)
+	reconciliationApp := application.NewReconciliationApplication(reconciliationService)
 
 	// Initialize middleware
-	authMiddleware := middleware.NewAuthMiddleware(authApp)
+	authMiddleware := middleware.NewAuthMiddleware(authApp, auditLogApp)
+	auditMiddleware := middleware.NewAuditMiddleware(auditLogApp)
+	agentAuthMiddleware := middleware.NewAgentAuthMiddleware(agentApp)
 
 	// Create default admin user if not exists
 	createDefaultAdmin(authService)
@@ -73,12 +233,23 @@ func main() {
 	authHandler := api.NewAuthHandler(authApp)
 	aiHandler := api.NewAIHandler(aiApp)
 	auditLogHandler := api.NewAuditLogHandler(auditLogApp)
+	relationshipHandler := api.NewRelationshipHandler(relationshipApp)
+	alertHandler := api.NewAlertHandler(alertApp)
+	approvalPolicyHandler := api.NewApprovalPolicyHandler(approvalPolicyApp)
+	idTemplateHandler := api.NewIDTemplateHandler(idTemplateApp)
+	ciTypeHandler := api.NewCITypeHandler(ciTypeApp)
+	discoveryHandler := api.NewDiscoveryHandler(discoveryApp)
+	agentHandler := api.NewAgentHandler(agentApp)
+	kubernetesHandler := api.NewKubernetesHandler(kubernetesApp)
+	cloudHandler := api.NewCloudHandler(cloudApp)
+	reconciliationHandler := api.NewReconciliationHandler(reconciliationApp)
 
 	// Setup Gin router
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
 	router.Use(gin.Recovery())
 	router.Use(middleware.RequestLogger())
+	router.Use(auditMiddleware.RecordRequests())
 
 	// CORS middleware
 	router.Use(cors.New(cors.Config{
@@ -96,6 +267,14 @@ func main() {
 		// Public auth routes
 		authHandler.RegisterRoutes(api)
 
+		// Approval channel callbacks are authenticated by signature, not by session
+		api.POST("/feishu/webhook", workflowHandler.HandleFeishuWebhook)
+		api.GET("/approvals/:channel/callback", workflowHandler.HandleApprovalCallback)
+		api.POST("/approvals/:channel/callback", workflowHandler.HandleApprovalCallback)
+
+		// Inventory agents authenticate with agent tokens, not user sessions
+		api.POST("/agent/facts", agentAuthMiddleware.RequireAgentToken(), agentHandler.ReportFacts)
+
 		// Temporary AI test route (no auth required)
 		api.POST("/ai/test", func(c *gin.Context) {
 			var req application.ChatRequest
@@ -142,7 +321,15 @@ func main() {
 				assets.GET("/departments", assetHandler.GetDepartments)
 				assets.GET("/owners", assetHandler.GetOwners)
 				assets.GET("/tags", assetHandler.GetAllTags)
+				assets.GET("/lifecycle", assetHandler.GetLifecycle)
+				assets.GET("/export", assetHandler.ExportAssets)
 				assets.GET("/:id", assetHandler.GetAssetByID)
+				assets.GET("/:id/history", assetHandler.GetAssetHistory)
+				assets.GET("/:id/as-of", assetHandler.GetAssetAsOf)
+				assets.GET("/:id/diff", assetHandler.DiffAssetStates)
+				assets.GET("/:id/revisions/:revisionId/revert-preview", assetHandler.PreviewRevert)
+				assets.GET("/:id/relationships", relationshipHandler.GetAssetRelationships)
+				assets.GET("/:id/impact", relationshipHandler.GetImpactAnalysis)
 
 				// Write operations require additional permissions
 				createGroup := assets.Group("/")
@@ -157,7 +344,11 @@ func main() {
 				{
 					updateGroup.PUT("/:id", assetHandler.UpdateAsset)
 					updateGroup.PUT("/:id/costs", assetHandler.UpdateAssetCosts)
+					updateGroup.PUT("/:id/status", assetHandler.ChangeStatus)
+					updateGroup.POST("/:id/revisions/:revisionId/revert", assetHandler.RevertAsset)
 					updateGroup.POST("/:id/tags", assetHandler.AddTags)
+					updateGroup.POST("/:id/relationships", relationshipHandler.CreateRelationship)
+					updateGroup.DELETE("/:id/relationships/:relationshipId", relationshipHandler.DeleteRelationship)
 				}
 
 				deleteGroup := assets.Group("/")
@@ -179,6 +370,7 @@ func main() {
 				workflows.GET("/stats", workflowHandler.GetWorkflowStats)
 				workflows.GET("/pending", workflowHandler.GetPendingWorkflows)
 				workflows.GET("/my", workflowHandler.GetMyWorkflows)
+				workflows.GET("/approval-channels", workflowHandler.GetApprovalChannels)
 				workflows.GET("/:id", workflowHandler.GetWorkflowByID)
 
 				// Create workflow
@@ -188,12 +380,59 @@ func main() {
 					createGroup.POST("", workflowHandler.CreateWorkflow)
 				}
 
-				// Approval operations require special permissions
-				approvalGroup := workflows.Group("/")
-				approvalGroup.Use(authMiddleware.RequireApprovalPermission())
+				// Who may decide is defined by the current approval stage and checked by the workflow service
+				workflows.PUT("/:id/approve", workflowHandler.ApproveWorkflow)
+				workflows.PUT("/:id/reject", workflowHandler.RejectWorkflow)
+			}
+
+			// Approval policy routes
+			approvalPolicies := protected.Group("/approval-policies")
+			approvalPolicies.Use(authMiddleware.RequirePermission("workflows", "read"))
+			{
+				approvalPolicies.GET("", approvalPolicyHandler.GetPolicies)
+				approvalPolicies.GET("/:id", approvalPolicyHandler.GetPolicyByID)
+
+				manageGroup := approvalPolicies.Group("/")
+				manageGroup.Use(authMiddleware.RequirePermission("workflows", "manage"))
 				{
-					approvalGroup.PUT("/:id/approve", workflowHandler.ApproveWorkflow)
-					approvalGroup.PUT("/:id/reject", workflowHandler.RejectWorkflow)
+					manageGroup.POST("", approvalPolicyHandler.CreatePolicy)
+					manageGroup.PUT("/:id", approvalPolicyHandler.UpdatePolicy)
+					manageGroup.DELETE("/:id", approvalPolicyHandler.DeletePolicy)
+				}
+			}
+
+			// Alert routes
+			alerts := protected.Group("/alerts")
+			alerts.Use(authMiddleware.RequirePermission("alerts", "read"))
+			{
+				alerts.GET("", alertHandler.GetAlerts)
+				alerts.GET("/stats", alertHandler.GetAlertStats)
+				alerts.GET("/:id", alertHandler.GetAlertByID)
+
+				// Lifecycle operations require update permission
+				lifecycleGroup := alerts.Group("/")
+				lifecycleGroup.Use(authMiddleware.RequirePermission("alerts", "update"))
+				{
+					lifecycleGroup.PUT("/:id/acknowledge", alertHandler.AcknowledgeAlert)
+					lifecycleGroup.PUT("/:id/resolve", alertHandler.ResolveAlert)
+					lifecycleGroup.PUT("/:id/close", alertHandler.CloseAlert)
+				}
+			}
+
+			// Alert rule routes
+			alertRules := protected.Group("/alert-rules")
+			alertRules.Use(authMiddleware.RequirePermission("alerts", "read"))
+			{
+				alertRules.GET("", alertHandler.GetRules)
+				alertRules.GET("/:id", alertHandler.GetRuleByID)
+
+				manageGroup := alertRules.Group("/")
+				manageGroup.Use(authMiddleware.RequirePermission("alerts", "manage"))
+				{
+					manageGroup.POST("", alertHandler.CreateRule)
+					manageGroup.POST("/evaluate", alertHandler.EvaluateRules)
+					manageGroup.PUT("/:id", alertHandler.UpdateRule)
+					manageGroup.DELETE("/:id", alertHandler.DeleteRule)
 				}
 			}
 
@@ -216,10 +455,146 @@ func main() {
 			users.Use(authMiddleware.RequireRole("admin"))
 			{
 				// User management routes are already registered in authHandler
+				users.PUT("/:id/manager", authHandler.UpdateUserManager)
+				users.PUT("/:id/channel-ids", authHandler.UpdateUserChannelID)
+			}
+
+			// CI type routes; defining types is admin-only
+			ciTypes := protected.Group("/ci-types")
+			ciTypes.Use(authMiddleware.RequirePermission("assets", "read"))
+			{
+				ciTypes.GET("", ciTypeHandler.GetTypes)
+				ciTypes.GET("/:name", ciTypeHandler.GetType)
+
+				manageGroup := ciTypes.Group("/")
+				manageGroup.Use(authMiddleware.RequireRole("admin"))
+				{
+					manageGroup.POST("", ciTypeHandler.CreateType)
+					manageGroup.PUT("/:name", ciTypeHandler.UpdateType)
+					manageGroup.DELETE("/:name", ciTypeHandler.DeleteType)
+				}
+			}
+
+			// Discovery routes; ranges and scans are admin-only, reviewing candidates creates assets
+			discoveryGroup := protected.Group("/discovery")
+			discoveryGroup.Use(authMiddleware.RequirePermission("assets", "read"))
+			{
+				discoveryGroup.GET("/ranges", discoveryHandler.GetRanges)
+				discoveryGroup.GET("/ranges/:id", discoveryHandler.GetRange)
+				discoveryGroup.GET("/runs", discoveryHandler.GetRuns)
+				discoveryGroup.GET("/runs/:id", discoveryHandler.GetRun)
+				discoveryGroup.GET("/candidates", discoveryHandler.GetCandidates)
+
+				manageGroup := discoveryGroup.Group("/")
+				manageGroup.Use(authMiddleware.RequireRole("admin"))
+				{
+					manageGroup.POST("/ranges", discoveryHandler.CreateRange)
+					manageGroup.PUT("/ranges/:id", discoveryHandler.UpdateRange)
+					manageGroup.DELETE("/ranges/:id", discoveryHandler.DeleteRange)
+					manageGroup.POST("/ranges/:id/scan", discoveryHandler.StartScan)
+					manageGroup.POST("/runs/:id/cancel", discoveryHandler.CancelScan)
+				}
+
+				reviewGroup := discoveryGroup.Group("/")
+				reviewGroup.Use(authMiddleware.RequirePermission("assets", "create"))
+				{
+					reviewGroup.POST("/candidates/:id/approve", discoveryHandler.ApproveCandidate)
+					reviewGroup.POST("/candidates/:id/ignore", discoveryHandler.IgnoreCandidate)
+					reviewGroup.POST("/import", discoveryHandler.ImportScan)
+				}
+			}
+
+			// Kubernetes routes; managing and syncing clusters is admin-only
+			kubernetesGroup := protected.Group("/kubernetes/clusters")
+			kubernetesGroup.Use(authMiddleware.RequirePermission("assets", "read"))
+			{
+				kubernetesGroup.GET("", kubernetesHandler.GetClusters)
+				kubernetesGroup.GET("/:id", kubernetesHandler.GetCluster)
+
+				manageGroup := kubernetesGroup.Group("")
+				manageGroup.Use(authMiddleware.RequireRole("admin"))
+				{
+					manageGroup.POST("", kubernetesHandler.CreateCluster)
+					manageGroup.PUT("/:id", kubernetesHandler.UpdateCluster)
+					manageGroup.DELETE("/:id", kubernetesHandler.DeleteCluster)
+					manageGroup.POST("/:id/sync", kubernetesHandler.SyncCluster)
+				}
+			}
+
+			// Cloud inventory routes; managing and syncing accounts is admin-only
+			cloudGroup := protected.Group("/cloud")
+			cloudGroup.Use(authMiddleware.RequirePermission("assets", "read"))
+			{
+				cloudGroup.GET("/providers", cloudHandler.GetProviders)
+				cloudGroup.GET("/accounts", cloudHandler.GetAccounts)
+				cloudGroup.GET("/accounts/:id", cloudHandler.GetAccount)
+
+				manageGroup := cloudGroup.Group("/accounts")
+				manageGroup.Use(authMiddleware.RequireRole("admin"))
+				{
+					manageGroup.POST("", cloudHandler.CreateAccount)
+					manageGroup.PUT("/:id", cloudHandler.UpdateAccount)
+					manageGroup.DELETE("/:id", cloudHandler.DeleteAccount)
+					manageGroup.POST("/:id/sync", cloudHandler.SyncAccount)
+				}
+			}
+
+			// Reconciliation routes; reviewing conflicts changes assets, detection and merging are admin-only
+			reconciliationGroup := protected.Group("/reconciliation")
+			reconciliationGroup.Use(authMiddleware.RequirePermission("assets", "read"))
+			{
+				reconciliationGroup.GET("/rules", reconciliationHandler.GetRules)
+				reconciliationGroup.GET("/duplicate-rules", reconciliationHandler.GetDuplicateRules)
+				reconciliationGroup.GET("/conflicts", reconciliationHandler.GetConflicts)
+				reconciliationGroup.GET("/conflicts/:id", reconciliationHandler.GetConflict)
+				reconciliationGroup.GET("/merge-proposals", reconciliationHandler.GetMergeProposals)
+
+				reviewGroup := reconciliationGroup.Group("/")
+				reviewGroup.Use(authMiddleware.RequirePermission("assets", "update"))
+				{
+					reviewGroup.POST("/conflicts/:id/accept", reconciliationHandler.AcceptConflict)
+					reviewGroup.POST("/conflicts/:id/override", reconciliationHandler.OverrideConflict)
+					reviewGroup.POST("/merge-proposals/:id/dismiss", reconciliationHandler.DismissMergeProposal)
+				}
+
+				manageGroup := reconciliationGroup.Group("/")
+				manageGroup.Use(authMiddleware.RequireRole("admin"))
+				{
+					manageGroup.POST("/merge-proposals/detect", reconciliationHandler.DetectDuplicates)
+					manageGroup.POST("/merge-proposals/:id/merge", reconciliationHandler.MergeProposal)
+					manageGroup.POST("/merge", reconciliationHandler.MergeAssets)
+				}
+			}
+
+			// Admin-only agent token routes
+			agentTokens := protected.Group("/agent-tokens")
+			agentTokens.Use(authMiddleware.RequireRole("admin"))
+			{
+				agentTokens.GET("", agentHandler.GetTokens)
+				agentTokens.POST("", agentHandler.CreateToken)
+				agentTokens.DELETE("/:id", agentHandler.RevokeToken)
+			}
+
+			// Admin-only ID template routes
+			idTemplates := protected.Group("/id-templates")
+			idTemplates.Use(authMiddleware.RequireRole("admin"))
+			{
+				idTemplates.GET("", idTemplateHandler.GetTemplates)
+				idTemplates.PUT("/:scope", idTemplateHandler.SetTemplate)
+				idTemplates.DELETE("/:scope", idTemplateHandler.DeleteTemplate)
 			}
 		}
 	}
 
+	// Keys other services verify access tokens with
+	router.GET("/.well-known/jwks.json", authHandler.GetJWKS)
+
+	// Mock identity provider for development and testing, served under its issuer's path
+	if oidcMock != nil {
+		issuer, _ := url.Parse(oidcMock.Issuer())
+		router.Any(issuer.Path+"/*path", gin.WrapH(http.StripPrefix(issuer.Path, oidcMock)))
+	}
+
 	// Health check
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "ok", "timestamp": time.Now()})
@@ -251,9 +626,347 @@ func createDefaultAdmin(authService *service.AuthService) {
 	}
 }
 
+func registerApprovalChannels(workflowService *service.WorkflowService) {
+	logger := logging.Logger
+
+	if config := feishu.ConfigFromEnv(); config.Enabled() {
+		workflowService.RegisterApprovalChannel(feishu.NewClient(config))
+	} else if config.AppID != "" && !config.CallbacksVerifiable() {
+		logger.Warn("feishu_channel_disabled", zap.String("reason", "FEISHU_ENCRYPT_KEY or FEISHU_VERIFICATION_TOKEN is required to verify callbacks"))
+	}
+	if config := approval.SlackConfigFromEnv(); config.Enabled() {
+		workflowService.RegisterApprovalChannel(approval.NewSlackChannel(config))
+	}
+	if config := approval.DingTalkConfigFromEnv(); config.Enabled() {
+		channel, err := approval.NewDingTalkChannel(config)
+		if err != nil {
+			logger.Error("dingtalk_channel_init_failed", zap.Error(err))
+		} else {
+			workflowService.RegisterApprovalChannel(channel)
+		}
+	}
+	if config := approval.WeComConfigFromEnv(); config.Enabled() {
+		channel, err := approval.NewWeComChannel(config)
+		if err != nil {
+			logger.Error("wecom_channel_init_failed", zap.Error(err))
+		} else {
+			workflowService.RegisterApprovalChannel(channel)
+		}
+	}
+	if config := approval.WebhookConfigFromEnv(); config.Enabled() {
+		workflowService.RegisterApprovalChannel(approval.NewWebhookChannel(config))
+	}
+
+	if name := os.Getenv("APPROVAL_DEFAULT_CHANNEL"); name != "" {
+		if err := workflowService.SetDefaultApprovalChannel(name); err != nil {
+			logger.Warn("approval_default_channel_invalid", zap.String("channel", name), zap.Error(err))
+		}
+	}
+
+	channels, defaultChannel := workflowService.GetApprovalChannels()
+	logger.Info("approval_channels_registered", zap.Strings("channels", channels), zap.String("default", defaultChannel))
+}
+
+func loadWorkflowSLAConfig() service.WorkflowSLAConfig {
+	config := service.DefaultWorkflowSLAConfig()
+
+	for priority, key := range map[model.WorkflowPriority]string{
+		model.UrgentPriority: "WORKFLOW_SLA_URGENT",
+		model.HighPriority:   "WORKFLOW_SLA_HIGH",
+		model.MediumPriority: "WORKFLOW_SLA_MEDIUM",
+		model.LowPriority:    "WORKFLOW_SLA_LOW",
+	} {
+		config.Targets[priority] = getEnvDuration(key, config.Targets[priority])
+	}
+
+	if users := getEnvList("WORKFLOW_ESCALATION_USERS"); len(users) > 0 {
+		config.EscalationUsers = users
+	}
+	if roles := getEnvList("WORKFLOW_ESCALATION_ROLES"); len(roles) > 0 {
+		config.EscalationRoles = nil
+		for _, role := range roles {
+			config.EscalationRoles = append(config.EscalationRoles, model.UserRole(role))
+		}
+	}
+
+	config.TimeoutAction = os.Getenv("WORKFLOW_SLA_TIMEOUT_ACTION")
+	config.Timeout = getEnvDuration("WORKFLOW_SLA_TIMEOUT", 0)
+
+	return config
+}
+
+// loadAuditForwarders creates a forwarder for each audit stream sink in AUDIT_STREAM_FILE, with
+// its spool in AUDIT_STREAM_SPOOL_DIR. Sinks that cannot be set up are logged and skipped.
+func loadAuditForwarders() []*service.AuditForwarder {
+	logger := logging.Logger
+
+	path := os.Getenv("AUDIT_STREAM_FILE")
+	if path == "" {
+		return nil
+	}
+	config, err := loadAuditStreamConfig(path)
+	if err != nil {
+		logger.Warn("audit_stream_config_invalid", zap.String("path", path), zap.Error(err))
+		return nil
+	}
+
+	spoolDir := getEnv("AUDIT_STREAM_SPOOL_DIR", "./audit-spool")
+	var forwarders []*service.AuditForwarder
+	for _, sinkConfig := range config.Sinks {
+		sink, err := auditstream.NewSink(sinkConfig)
+		if err != nil {
+			logger.Error("audit_sink_init_failed", zap.String("sink", sinkConfig.Name), zap.Error(err))
+			continue
+		}
+		spool, err := persistence.NewFileAuditSpool(spoolDir, sinkConfig.Name, sinkConfig.SpoolMaxBytes)
+		if err != nil {
+			logger.Error("audit_sink_init_failed", zap.String("sink", sinkConfig.Name), zap.Error(err))
+			continue
+		}
+
+		forwarders = append(forwarders, service.NewAuditForwarder(sink, sinkConfig, spool))
+		logger.Info("audit_sink_registered", zap.String("sink", sinkConfig.Name), zap.String("type", sinkConfig.Type))
+	}
+	return forwarders
+}
+
+func loadAuditStreamConfig(path string) (*model.AuditStreamConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return model.ParseAuditStreamConfig(data)
+}
+
+// loadAuditSigner reads the key that signs audit checkpoints from AUDIT_SIGNING_KEY, the base64
+// seed of an Ed25519 key, and the public keys of earlier signing keys from AUDIT_TRUSTED_KEYS
+func loadAuditSigner() (*service.AuditSigner, error) {
+	var trusted []ed25519.PublicKey
+	for _, value := range getEnvList("AUDIT_TRUSTED_KEYS") {
+		key, err := base64.StdEncoding.DecodeString(value)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid trusted audit key %q", value)
+		}
+		trusted = append(trusted, ed25519.PublicKey(key))
+	}
+
+	seed, err := base64.StdEncoding.DecodeString(os.Getenv("AUDIT_SIGNING_KEY"))
+	if err != nil {
+		return nil, err
+	}
+	if len(seed) == 0 {
+		// Checkpoints signed with a generated key no longer verify after a restart
+		logging.Logger.Warn("audit_signing_key_missing", zap.String("key", "AUDIT_SIGNING_KEY"))
+		seed = make([]byte, ed25519.SeedSize)
+		if _, err := rand.Read(seed); err != nil {
+			return nil, err
+		}
+	}
+	return service.NewAuditSigner(seed, trusted)
+}
+
+func loadAuthTokenConfig() service.AuthTokenConfig {
+	config := service.DefaultAuthTokenConfig()
+	config.AccessTokenTTL = getEnvDuration("JWT_ACCESS_TTL", config.AccessTokenTTL)
+	config.RefreshTokenTTL = getEnvDuration("JWT_REFRESH_TTL", config.RefreshTokenTTL)
+	return config
+}
+
+// loadTokenSigner reads the RSA key that signs access tokens from the PEM file named by
+// JWT_SIGNING_KEY_FILE, and the keys of JWT_VERIFICATION_KEY_FILES, such as the previous or next
+// signing key, which tokens are also accepted from
+func loadTokenSigner() (*service.TokenSigner, error) {
+	var verificationKeys []*rsa.PublicKey
+	for _, path := range getEnvList("JWT_VERIFICATION_KEY_FILES") {
+		key, err := loadRSAPublicKey(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		verificationKeys = append(verificationKeys, key)
+	}
+
+	var signingKey *rsa.PrivateKey
+	if path := os.Getenv("JWT_SIGNING_KEY_FILE"); path != "" {
+		key, err := loadRSAPrivateKey(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		signingKey = key
+	} else {
+		// Tokens signed with a generated key no longer verify after a restart
+		logging.Logger.Warn("jwt_signing_key_missing", zap.String("key", "JWT_SIGNING_KEY_FILE"))
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, err
+		}
+		signingKey = key
+	}
+	return service.NewTokenSigner(getEnv("JWT_ISSUER", "cmdb"), signingKey, verificationKeys)
+}
+
+// loadSingleSignOn reads the identity provider configuration from the JSON file named by
+// OIDC_CONFIG_FILE. With OIDC_MOCK_PROVIDER=true a mock identity provider for the client "cmdb" is
+// also served, and used unless a configuration file is given.
+func loadSingleSignOn(port string) (*model.OIDCConfig, *oidc.MockProvider) {
+	var mock *oidc.MockProvider
+	if getEnv("OIDC_MOCK_PROVIDER", "false") == "true" {
+		issuer := strings.TrimSuffix(getEnv("OIDC_MOCK_ISSUER", "http://localhost:"+port+"/mock-oidc"), "/")
+		if parsed, err := url.Parse(issuer); err != nil || parsed.Path == "" {
+			logging.Logger.Warn("oidc_mock_issuer_invalid", zap.String("issuer", issuer))
+		} else if provider, err := oidc.NewMockProvider(issuer, "cmdb"); err != nil {
+			logging.Logger.Warn("oidc_mock_provider_failed", zap.Error(err))
+		} else {
+			// Anyone can sign in to the mock provider as anyone, admins included
+			logging.Logger.Warn("oidc_mock_provider_enabled", zap.String("issuer", issuer))
+			mock = provider
+		}
+	}
+
+	path := os.Getenv("OIDC_CONFIG_FILE")
+	if path == "" {
+		if mock == nil {
+			return nil, nil
+		}
+		return &model.OIDCConfig{
+			Issuer:        mock.Issuer(),
+			ClientID:      "cmdb",
+			RedirectURL:   "http://localhost:" + port + "/api/auth/oidc/callback",
+			Scopes:        []string{"openid", "profile", "email", "groups"},
+			UsernameClaim: "preferred_username",
+			GroupsClaim:   "groups",
+			GroupMappings: []model.OIDCGroupMapping{
+				{Group: "cmdb-admins", Role: model.AdminRole},
+				{Group: "cmdb-managers", Role: model.ManagerRole},
+				{Group: "cmdb-operators", Role: model.OperatorRole},
+			},
+			DefaultRole:     model.ViewerRole,
+			AllowLocalUsers: true,
+			PostLoginURL:    getEnv("OIDC_MOCK_POST_LOGIN_URL", "http://localhost:3000/"),
+		}, mock
+	}
+
+	config, err := loadOIDCConfig(path)
+	if err != nil {
+		logging.Logger.Warn("oidc_config_invalid", zap.String("path", path), zap.Error(err))
+		return nil, mock
+	}
+	return config, mock
+}
+
+func loadOIDCConfig(path string) (*model.OIDCConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return model.ParseOIDCConfig(data)
+}
+
+// loadRSAPrivateKey reads a PKCS #1 or PKCS #8 RSA private key from a PEM file
+func loadRSAPrivateKey(path string) (*rsa.PrivateKey, error) {
+	block, err := loadPEMBlock(path)
+	if err != nil {
+		return nil, err
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("not an RSA key")
+	}
+	return rsaKey, nil
+}
+
+// loadRSAPublicKey reads an RSA public key from a PEM file, which may also hold the private key
+func loadRSAPublicKey(path string) (*rsa.PublicKey, error) {
+	block, err := loadPEMBlock(path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.Contains(block.Type, "PRIVATE KEY") {
+		key, err := loadRSAPrivateKey(path)
+		if err != nil {
+			return nil, err
+		}
+		return &key.PublicKey, nil
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("not an RSA key")
+	}
+	return rsaKey, nil
+}
+
+func loadPEMBlock(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found")
+	}
+	return block, nil
+}
+
+func loadLifecycle(path string) (*model.Lifecycle, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return model.ParseLifecycle(data)
+}
+
+func loadReconciliationPolicy(path string) (*model.ReconciliationPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return model.ParseReconciliationPolicy(data)
+}
+
+func loadDuplicatePolicy(path string) (*model.DuplicatePolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return model.ParseDuplicatePolicy(data)
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			return d
+		}
+		logging.Logger.Warn("invalid_duration_env", zap.String("key", key), zap.String("value", value))
+	}
+	return defaultValue
+}
+
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
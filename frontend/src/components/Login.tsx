@@ -1,7 +1,7 @@
 'use client';
 
-import React, { useState } from 'react';
-import { User, Lock, Eye, EyeOff, Loader2 } from 'lucide-react';
+import React, { useEffect, useState } from 'react';
+import { User, Lock, Eye, EyeOff, Loader2, KeyRound } from 'lucide-react';
 import { useAuth } from '@/contexts/AuthContext';
 
 interface LoginFormData {
@@ -30,6 +30,26 @@ const Login: React.FC<LoginProps> = ({ onLogin }) => {
   const [showPassword, setShowPassword] = useState<boolean>(false);
   const [loading, setLoading] = useState<boolean>(false);
   const [error, setError] = useState<string>('');
+  const [ssoEnabled, setSsoEnabled] = useState<boolean>(false);
+
+  useEffect(() => {
+    // Show why a single sign-on failed, as passed back by the server
+    const ssoError = sessionStorage.getItem('sso_error');
+    if (ssoError) {
+      sessionStorage.removeItem('sso_error');
+      setError(`单点登录失败：${ssoError}`);
+    }
+
+    fetch('/api/auth/oidc/config')
+      .then(response => (response.ok ? response.json() : { enabled: false }))
+      .then(data => setSsoEnabled(!!data.enabled))
+      .catch(() => setSsoEnabled(false));
+  }, []);
+
+  const handleSingleSignOn = () => {
+    const returnTo = window.location.pathname + window.location.search;
+    window.location.href = `/api/auth/oidc/login?returnTo=${encodeURIComponent(returnTo)}`;
+  };
 
   const handleChange = (e: React.ChangeEvent<HTMLInputElement>) => {
     const { name, value } = e.target;
@@ -67,6 +87,7 @@ const Login: React.FC<LoginProps> = ({ onLogin }) => {
       if (response.ok) {
         // Store user data and token
         localStorage.setItem('auth_token', data.token);
+        localStorage.setItem('refresh_token', data.refreshToken);
         localStorage.setItem('user', JSON.stringify(data.user));
         
         // Update auth context
@@ -167,6 +188,25 @@ const Login: React.FC<LoginProps> = ({ onLogin }) => {
             {loading ? '登录中...' : '登录'}
           </button>
         </form>
+
+        {ssoEnabled && (
+          <div className="mt-6">
+            <div className="flex items-center gap-4 mb-6 text-gray-400 text-sm">
+              <div className="flex-1 border-t border-gray-200" />
+              或
+              <div className="flex-1 border-t border-gray-200" />
+            </div>
+            <button
+              type="button"
+              onClick={handleSingleSignOn}
+              disabled={loading}
+              className="w-full border-2 border-gray-200 text-gray-800 py-4 px-8 rounded-xl text-lg font-semibold hover:border-blue-500 hover:text-blue-600 transition-all duration-300 disabled:opacity-50 disabled:cursor-not-allowed flex items-center justify-center gap-2"
+            >
+              <KeyRound className="h-5 w-5" />
+              单点登录 (SSO)
+            </button>
+          </div>
+        )}
       </div>
     </div>
   );
@@ -1,6 +1,7 @@
 'use client';
 
 import React, { createContext, useContext, useState, useEffect, ReactNode } from 'react';
+import { refreshSession } from '@/services/api';
 
 interface User {
   id: string;
@@ -8,6 +9,7 @@ interface User {
   email: string;
   fullName: string;
   role: string;
+  authSource?: string;
   permissions?: Array<{
     resource: string;
     actions: string[];
@@ -45,6 +47,19 @@ export const AuthProvider: React.FC<AuthProviderProps> = ({ children }) => {
   const [loading, setLoading] = useState<boolean>(true);
 
   useEffect(() => {
+    // A single sign-on passes its tokens, or its error, in the URL fragment
+    const fragment = new URLSearchParams(window.location.hash.slice(1));
+    if (fragment.has('token') || fragment.has('error')) {
+      window.history.replaceState(null, '', window.location.pathname + window.location.search);
+      const ssoError = fragment.get('error');
+      if (ssoError) {
+        sessionStorage.setItem('sso_error', ssoError);
+      } else {
+        completeSingleSignOn(fragment);
+        return;
+      }
+    }
+
     // Check for existing auth data on mount
     const savedToken = localStorage.getItem('auth_token');
     const savedUser = localStorage.getItem('user');
@@ -77,7 +92,17 @@ export const AuthProvider: React.FC<AuthProviderProps> = ({ children }) => {
       });
 
       if (!response.ok) {
-        logout();
+        // The access token is short-lived; get a new one before giving up
+        const refreshedToken = await refreshSession();
+        if (!refreshedToken) {
+          logout();
+          return;
+        }
+        setToken(refreshedToken);
+        const savedUser = localStorage.getItem('user');
+        if (savedUser) {
+          setUser(JSON.parse(savedUser));
+        }
       }
     } catch (error) {
       console.error('Token verification failed:', error);
@@ -85,6 +110,37 @@ export const AuthProvider: React.FC<AuthProviderProps> = ({ children }) => {
     }
   };
 
+  const completeSingleSignOn = async (fragment: URLSearchParams): Promise<void> => {
+    const authToken = fragment.get('token') as string;
+    localStorage.setItem('auth_token', authToken);
+    localStorage.setItem('refresh_token', fragment.get('refreshToken') || '');
+
+    try {
+      const response = await fetch('/api/auth/me', {
+        headers: {
+          'Authorization': `Bearer ${authToken}`,
+        },
+        credentials: 'include',
+      });
+      if (!response.ok) {
+        throw new Error(`Loading the signed-in user failed with status ${response.status}`);
+      }
+
+      login(await response.json(), authToken);
+
+      const returnTo = fragment.get('returnTo');
+      if (returnTo && returnTo.startsWith('/') && returnTo !== window.location.pathname + window.location.search) {
+        window.location.assign(returnTo);
+      }
+    } catch (error) {
+      console.error('Single sign-on failed:', error);
+      localStorage.removeItem('auth_token');
+      localStorage.removeItem('refresh_token');
+    } finally {
+      setLoading(false);
+    }
+  };
+
   const login = (userData: User, authToken: string): void => {
     setUser(userData);
     setToken(authToken);
@@ -110,6 +166,7 @@ export const AuthProvider: React.FC<AuthProviderProps> = ({ children }) => {
     setUser(null);
     setToken(null);
     localStorage.removeItem('auth_token');
+    localStorage.removeItem('refresh_token');
     localStorage.removeItem('user');
   };
 
@@ -28,13 +28,61 @@ apiClient.interceptors.request.use(
   }
 );
 
+// 进行中的刷新请求，并发的401共用同一次刷新，避免刷新令牌被重复使用而吊销会话
+let refreshing: Promise<string | null> | null = null;
+
+// 用刷新令牌换取新的访问令牌，失败时返回null
+export const refreshSession = (): Promise<string | null> => {
+  if (!refreshing) {
+    refreshing = (async () => {
+      const refreshToken = localStorage.getItem('refresh_token');
+      if (!refreshToken) {
+        return null;
+      }
+      try {
+        const response = await fetch('/api/auth/refresh', {
+          method: 'POST',
+          headers: { 'Content-Type': 'application/json' },
+          credentials: 'include',
+          body: JSON.stringify({ refreshToken }),
+        });
+        if (!response.ok) {
+          return null;
+        }
+        const data = await response.json();
+        localStorage.setItem('auth_token', data.token);
+        localStorage.setItem('refresh_token', data.refreshToken);
+        localStorage.setItem('user', JSON.stringify(data.user));
+        return data.token as string;
+      } catch (error) {
+        console.error('Token refresh failed:', error);
+        return null;
+      } finally {
+        refreshing = null;
+      }
+    })();
+  }
+  return refreshing;
+};
+
 // 响应拦截器 - 处理认证错误
 apiClient.interceptors.response.use(
   (response) => response,
-  (error) => {
+  async (error) => {
+    const request = error.config;
+    if (error.response?.status === 401 && request && !request._retried) {
+      // 访问令牌过期时先尝试刷新，再重试一次原请求
+      request._retried = true;
+      const token = await refreshSession();
+      if (token) {
+        request.headers.Authorization = `Bearer ${token}`;
+        return apiClient(request);
+      }
+    }
     if (error.response?.status === 401) {
       // 清除本地存储的认证信息
       localStorage.removeItem('auth_token');
+      localStorage.removeItem('refresh_token');
       localStorage.removeItem('user');
       // 重定向到登录页面
       window.location.href = '/';